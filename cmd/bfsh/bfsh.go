@@ -1,1659 +0,0 @@
-package main
-
-import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"os"
-	"os/signal"
-	"path"
-	"regexp"
-	"sort"
-	"strconv"
-	"strings"
-	"time"
-
-	"github.com/bluefish-project/bluefish/rvfs"
-
-	"github.com/charmbracelet/lipgloss"
-	"github.com/chzyer/readline"
-	"golang.org/x/term"
-	"gopkg.in/yaml.v3"
-)
-
-// Styles using ANSI colors 0–15 (follow terminal theme)
-var (
-	childStyle      = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(12)).Bold(true)
-	linkStyle       = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(6))
-	objectStyle     = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(5))
-	propStyle       = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(2))
-	dimStyle        = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(8))
-	boldStyle       = lipgloss.NewStyle().Bold(true)
-	warnStyle       = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(3))
-	errorStyle      = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(1)).Bold(true)
-	promptPathStyle = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(12)).Bold(true)
-	promptActStyle  = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(1)).Bold(true)
-
-	// Value styles
-	stringValStyle = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(2))
-	numberValStyle = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(4))
-	trueValStyle   = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(10))
-	falseValStyle  = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(1))
-	nullValStyle   = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(8))
-
-	// Health-semantic styles
-	healthOKStyle       = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(10))
-	healthWarnStyle     = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(11))
-	healthCriticalStyle = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(9)).Bold(true)
-)
-
-// healthKeys are property names that get semantic coloring
-var healthKeys = map[string]bool{
-	"Health":       true,
-	"HealthRollup": true,
-	"State":        true,
-	"Status":       true,
-}
-
-// Config holds connection configuration
-type Config struct {
-	Endpoint string `yaml:"endpoint"`
-	User     string `yaml:"user"`
-	Pass     string `yaml:"pass"`
-	Insecure bool   `yaml:"insecure"`
-}
-
-// loadConfig reads configuration from a YAML file
-func loadConfig(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
-	}
-
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
-	}
-
-	if cfg.Endpoint == "" {
-		return nil, fmt.Errorf("config missing required field: endpoint")
-	}
-	if cfg.User == "" {
-		return nil, fmt.Errorf("config missing required field: user")
-	}
-	if cfg.Pass == "" {
-		return nil, fmt.Errorf("config missing required field: pass")
-	}
-
-	return &cfg, nil
-}
-
-// Navigator manages shell state
-type Navigator struct {
-	vfs        rvfs.VFS
-	cwd        string
-	actionMode bool
-}
-
-// NewNavigator creates a navigator
-func NewNavigator(vfs rvfs.VFS) *Navigator {
-	return &Navigator{
-		vfs: vfs,
-		cwd: "/redfish/v1",
-	}
-}
-
-// cd changes directory
-func (n *Navigator) cd(target string) error {
-	if target == "" {
-		target = "~"
-	}
-
-	// Expand ~ prefix to Redfish root
-	if target == "~" {
-		target = rvfs.RedfishRoot
-	} else if strings.HasPrefix(target, "~/") {
-		target = rvfs.RedfishRoot + "/" + target[2:]
-	}
-
-	resolvedTarget, err := n.vfs.ResolveTarget(n.cwd, target)
-	if err != nil {
-		return err
-	}
-
-	switch resolvedTarget.Type {
-	case rvfs.TargetResource:
-		n.cwd = resolvedTarget.ResourcePath
-
-	case rvfs.TargetLink:
-		n.cwd = resolvedTarget.ResourcePath
-
-	case rvfs.TargetProperty:
-		switch resolvedTarget.Property.Type {
-		case rvfs.PropertyObject, rvfs.PropertyArray:
-			// Navigate into property — compose the full path
-			if strings.HasPrefix(target, "/") {
-				n.cwd = normalizePath(target)
-			} else {
-				n.cwd = normalizePath(path.Join(n.cwd, target))
-			}
-		default:
-			return fmt.Errorf("cannot cd to value: %s", target)
-		}
-	}
-
-	entries := n.listResolved(resolvedTarget)
-	fmt.Printf("%s  (%s)\n", n.cwd, getEntriesSummary(entries))
-	return nil
-}
-
-// open follows links to their canonical destinations (always canonicalizes PropertyLinks)
-func (n *Navigator) open(target string) error {
-	if target == "" {
-		return fmt.Errorf("open requires a target path")
-	}
-
-	// Resolve the target
-	resolvedTarget, err := n.vfs.ResolveTarget(n.cwd, target)
-	if err != nil {
-		// Special case: "open ." from a property path
-		if target == "." {
-			resolvedTarget, err = n.vfs.ResolveTarget(rvfs.RedfishRoot, n.cwd)
-			if err != nil {
-				return err
-			}
-		} else {
-			return err
-		}
-	}
-
-	switch resolvedTarget.Type {
-	case rvfs.TargetResource:
-		n.cwd = resolvedTarget.ResourcePath
-		entries, _ := n.vfs.ListAll(n.cwd)
-		fmt.Printf("%s  (%s)\n", n.cwd, getEntriesSummary(entries))
-
-	case rvfs.TargetLink:
-		n.cwd = resolvedTarget.ResourcePath
-		entries, _ := n.vfs.ListAll(n.cwd)
-		fmt.Printf("%s  (%s)\n", n.cwd, getEntriesSummary(entries))
-
-	case rvfs.TargetProperty:
-		prop := resolvedTarget.Property
-		if prop.Type == rvfs.PropertyLink {
-			// Follow the link
-			n.cwd = prop.LinkTarget
-			entries, _ := n.vfs.ListAll(n.cwd)
-			fmt.Printf("%s  (%s)\n", n.cwd, getEntriesSummary(entries))
-		} else if target == "." {
-			// "open ." from a property path — navigate to containing resource
-			n.cwd = resolvedTarget.Resource.Path
-			entries, _ := n.vfs.ListAll(n.cwd)
-			fmt.Printf("%s  (%s)\n", n.cwd, getEntriesSummary(entries))
-		} else {
-			return fmt.Errorf("cannot open property %s (not a link; use 'cd' to navigate into objects)", target)
-		}
-	}
-
-	return nil
-}
-
-// ls lists all entries (children + properties)
-func (n *Navigator) ls(target string) error {
-	if target == "." {
-		target = ""
-	}
-
-	// Resolve the path
-	var resolved *rvfs.Target
-	var err error
-	if target == "" {
-		resolved, err = n.vfs.ResolveTarget(rvfs.RedfishRoot, n.cwd)
-	} else {
-		resolved, err = n.vfs.ResolveTarget(n.cwd, target)
-	}
-	if err != nil {
-		return err
-	}
-
-	entries := n.listResolved(resolved)
-	n.printShortListingAll(entries)
-	n.printResourceAge(resolved)
-	return nil
-}
-
-// entriesFromProperty creates Entry list from a property's children/elements
-func entriesFromProperty(prop *rvfs.Property) []*rvfs.Entry {
-	var entries []*rvfs.Entry
-
-	switch prop.Type {
-	case rvfs.PropertyObject:
-		for name, child := range prop.Children {
-			entries = append(entries, &rvfs.Entry{
-				Name: name,
-				Path: child.LinkTarget,
-				Type: entryTypeForProperty(child),
-			})
-		}
-	case rvfs.PropertyArray:
-		for _, elem := range prop.Elements {
-			entries = append(entries, &rvfs.Entry{
-				Name: elem.Name,
-				Type: entryTypeForProperty(elem),
-			})
-		}
-	}
-
-	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].Name < entries[j].Name
-	})
-	return entries
-}
-
-// entryTypeForProperty maps property types to entry types
-func entryTypeForProperty(prop *rvfs.Property) rvfs.EntryType {
-	switch prop.Type {
-	case rvfs.PropertyObject:
-		return rvfs.EntryComplex
-	case rvfs.PropertyArray:
-		return rvfs.EntryArray
-	case rvfs.PropertyLink:
-		return rvfs.EntrySymlink
-	default:
-		return rvfs.EntryProperty
-	}
-}
-
-// listResolved returns entries for any resolved target
-func (n *Navigator) listResolved(target *rvfs.Target) []*rvfs.Entry {
-	switch target.Type {
-	case rvfs.TargetResource, rvfs.TargetLink:
-		entries, _ := n.vfs.ListAll(target.ResourcePath)
-		return entries
-	case rvfs.TargetProperty:
-		return entriesFromProperty(target.Property)
-	}
-	return nil
-}
-
-// dump displays raw JSON
-func (n *Navigator) dump(target string) error {
-	// Resolve the path
-	var resolved *rvfs.Target
-	var err error
-	if target == "" {
-		resolved, err = n.vfs.ResolveTarget(rvfs.RedfishRoot, n.cwd)
-	} else {
-		resolved, err = n.vfs.ResolveTarget(n.cwd, target)
-	}
-	if err != nil {
-		return err
-	}
-
-	var buf bytes.Buffer
-	switch resolved.Type {
-	case rvfs.TargetResource, rvfs.TargetLink:
-		json.Indent(&buf, resolved.Resource.RawJSON, "", "  ")
-	case rvfs.TargetProperty:
-		json.Indent(&buf, resolved.Property.RawJSON, "", "  ")
-	}
-	fmt.Println(buf.String())
-	return nil
-}
-
-// ll displays formatted content using parsed structure
-func (n *Navigator) ll(target string) error {
-	if target == "." {
-		target = ""
-	}
-
-	// Resolve the path
-	var resolved *rvfs.Target
-	var err error
-	if target == "" {
-		resolved, err = n.vfs.ResolveTarget(rvfs.RedfishRoot, n.cwd)
-	} else {
-		resolved, err = n.vfs.ResolveTarget(n.cwd, target)
-	}
-	if err != nil {
-		return err
-	}
-
-	switch resolved.Type {
-	case rvfs.TargetResource, rvfs.TargetLink:
-		if err := n.showResource(resolved.ResourcePath); err != nil {
-			return err
-		}
-		n.printResourceAge(resolved)
-	case rvfs.TargetProperty:
-		n.showProperty(resolved.Property, 0, false)
-	}
-	return nil
-}
-
-// showResource displays a resource in formatted style
-func (n *Navigator) showResource(path string) error {
-	resource, err := n.vfs.Get(path)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println()
-	fmt.Println(boldStyle.Render(path))
-	if resource.ODataType != "" {
-		fmt.Printf("Type: %s\n", resource.ODataType)
-	}
-
-	// Show properties (sorted for deterministic output)
-	if len(resource.Properties) > 0 {
-		fmt.Println("\nProperties:")
-
-		// Sort property names
-		propNames := make([]string, 0, len(resource.Properties))
-		for name := range resource.Properties {
-			propNames = append(propNames, name)
-		}
-		sort.Strings(propNames)
-
-		for _, name := range propNames {
-			prop := resource.Properties[name]
-			n.showProperty(prop, 2, false)
-		}
-	}
-
-	// Show children (sorted for deterministic output)
-	if len(resource.Children) > 0 {
-		fmt.Println("\nChildren:")
-
-		// Sort child names
-		childNames := make([]string, 0, len(resource.Children))
-		for name := range resource.Children {
-			childNames = append(childNames, name)
-		}
-		sort.Strings(childNames)
-
-		for _, name := range childNames {
-			child := resource.Children[name]
-			if child.Type == rvfs.ChildLink {
-				fmt.Printf("  %s → %s\n", childStyle.Render(name+"/"), child.Target)
-			} else {
-				fmt.Printf("  %s → %s\n", linkStyle.Render(name+"@"), child.Target)
-			}
-		}
-	}
-
-	return nil
-}
-
-// showProperty displays a property in formatted style with indentation (YAML-style)
-// indent is the indentation level for this property itself
-// isArrayElement indicates this property is the first field of an array element object (suppress indent)
-func (n *Navigator) showProperty(prop *rvfs.Property, indent int, isArrayElement bool) {
-	var propertyIndent string
-	if isArrayElement {
-		propertyIndent = "" // No indent for first field of array element (inline with dash)
-	} else {
-		propertyIndent = strings.Repeat(" ", indent)
-	}
-	childIndent := strings.Repeat(" ", indent+2)
-
-	switch prop.Type {
-	case rvfs.PropertySimple:
-		// Print property name and simple value inline with health-semantic coloring
-		fmt.Printf("%s%s: %s\n", propertyIndent, propStyle.Render(prop.Name), formatHealthValue(prop.Name, prop.Value))
-
-	case rvfs.PropertyLink:
-		// Print property name and link target
-		fmt.Printf("%s%s: %s → %s\n", propertyIndent, propStyle.Render(prop.Name), linkStyle.Render("link"), prop.LinkTarget)
-
-	case rvfs.PropertyObject:
-		// Print property name with field count badge
-		fmt.Printf("%s%s:", propertyIndent, propStyle.Render(prop.Name))
-
-		// Object - show nested fields with indentation (YAML-style)
-		if len(prop.Children) == 0 {
-			// Empty object
-			fmt.Printf(" %s\n", dimStyle.Render("{}"))
-		} else {
-			fmt.Printf(" %s\n", dimStyle.Render(fmt.Sprintf("{%d}", len(prop.Children))))
-
-			// Sort keys for deterministic output
-			keys := make([]string, 0, len(prop.Children))
-			for name := range prop.Children {
-				keys = append(keys, name)
-			}
-			sort.Strings(keys)
-
-			// Print fields
-			for _, name := range keys {
-				child := prop.Children[name]
-				n.showProperty(child, indent+2, false)
-			}
-		}
-
-	case rvfs.PropertyArray:
-		// Print property name with element count badge
-		fmt.Printf("%s%s:", propertyIndent, propStyle.Render(prop.Name))
-
-		// Array - show elements with YAML-style list markers
-		if len(prop.Elements) == 0 {
-			// Empty array
-			fmt.Printf(" %s\n", dimStyle.Render("[]"))
-		} else {
-			fmt.Printf(" %s\n", dimStyle.Render(fmt.Sprintf("[%d]", len(prop.Elements))))
-			// Print each element with dash marker
-			for _, elem := range prop.Elements {
-				// For array elements, we need special handling for objects
-				if elem.Type == rvfs.PropertyObject && len(elem.Children) > 0 {
-					// Print dash at child indent level
-					fmt.Printf("%s- ", childIndent)
-
-					// Print first field inline with dash, rest indented
-					keys := make([]string, 0, len(elem.Children))
-					for name := range elem.Children {
-						keys = append(keys, name)
-					}
-					sort.Strings(keys)
-
-					for i, name := range keys {
-						child := elem.Children[name]
-						if i == 0 {
-							// First field inline with dash (at childIndent level, but suppress indent)
-							n.showProperty(child, indent+4, true)
-						} else {
-							// Subsequent fields indented to align with first field
-							n.showProperty(child, indent+4, false)
-						}
-					}
-				} else {
-					// Simple element or empty object - show inline
-					fmt.Printf("%s- ", childIndent)
-					switch elem.Type {
-					case rvfs.PropertySimple:
-						fmt.Println(formatTypedValue(elem.Value))
-					case rvfs.PropertyObject:
-						fmt.Println(dimStyle.Render("{}"))
-					case rvfs.PropertyLink:
-						fmt.Printf("%s → %s\n", linkStyle.Render("link"), elem.LinkTarget)
-					}
-				}
-			}
-		}
-	}
-}
-
-// formatHealthValue renders health/state values with semantic colors, other values with type colors
-func formatHealthValue(name string, value any) string {
-	if healthKeys[name] {
-		s, ok := value.(string)
-		if ok {
-			upper := strings.ToUpper(s)
-			switch {
-			case upper == "OK" || upper == "ENABLED" || upper == "UP":
-				return healthOKStyle.Render(s)
-			case upper == "WARNING" || upper == "STANDBYOFFLINE" || upper == "STARTING":
-				return healthWarnStyle.Render(s)
-			case upper == "CRITICAL" || upper == "DISABLED" || upper == "ABSENT":
-				return healthCriticalStyle.Render(s)
-			}
-		}
-	}
-	return formatTypedValue(value)
-}
-
-// formatTypedValue formats a value with type-appropriate coloring
-func formatTypedValue(value any) string {
-	switch v := value.(type) {
-	case string:
-		return stringValStyle.Render(v)
-	case bool:
-		if v {
-			return trueValStyle.Render("true")
-		}
-		return falseValStyle.Render("false")
-	case float64:
-		if v == float64(int64(v)) {
-			return numberValStyle.Render(fmt.Sprintf("%d", int64(v)))
-		}
-		return numberValStyle.Render(fmt.Sprintf("%g", v))
-	case nil:
-		return nullValStyle.Render("null")
-	default:
-		return fmt.Sprintf("%v", v)
-	}
-}
-
-// printResourceAge prints the age of a resolved target's resource
-func (n *Navigator) printResourceAge(target *rvfs.Target) {
-	var fetchedAt time.Time
-	switch target.Type {
-	case rvfs.TargetResource, rvfs.TargetLink:
-		if target.Resource != nil {
-			fetchedAt = target.Resource.FetchedAt
-		}
-	case rvfs.TargetProperty:
-		if target.Resource != nil {
-			fetchedAt = target.Resource.FetchedAt
-		}
-	}
-	if fetchedAt.IsZero() {
-		return
-	}
-	fmt.Println(dimStyle.Render(formatAge(fetchedAt)))
-}
-
-func formatAge(t time.Time) string {
-	d := time.Since(t)
-	switch {
-	case d < time.Minute:
-		return fmt.Sprintf("%ds ago", int(d.Seconds()))
-	case d < time.Hour:
-		return fmt.Sprintf("%dm ago", int(d.Minutes()))
-	case d < 24*time.Hour:
-		return fmt.Sprintf("%dh ago", int(d.Hours()))
-	default:
-		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
-	}
-}
-
-// tree displays tree view
-func (n *Navigator) tree(depth int) error {
-	resolved, err := n.vfs.ResolveTarget(rvfs.RedfishRoot, n.cwd)
-	if err != nil {
-		return err
-	}
-
-	var entries []*rvfs.Entry
-	switch resolved.Type {
-	case rvfs.TargetResource, rvfs.TargetLink:
-		entries, _ = n.vfs.ListAll(resolved.ResourcePath)
-	case rvfs.TargetProperty:
-		entries = entriesFromProperty(resolved.Property)
-	}
-
-	output := n.buildTreeFromEntries(n.cwd, entries, "", depth, 0)
-	if output == "" {
-		fmt.Println("(empty)")
-	} else {
-		fmt.Println(output)
-	}
-	return nil
-}
-
-func (n *Navigator) buildTreeFromEntries(basePath string, entries []*rvfs.Entry, prefix string, maxDepth, currentDepth int) string {
-	if currentDepth >= maxDepth {
-		return ""
-	}
-
-	var lines []string
-	for i, entry := range entries {
-		isLast := i == len(entries)-1
-
-		connector := "├── "
-		if isLast {
-			connector = "└── "
-		}
-
-		line := prefix + connector + formatEntry(entry)
-		lines = append(lines, line)
-
-		// Recurse for directories
-		if entry.IsDir() && currentDepth+1 < maxDepth {
-			extension := "│   "
-			if isLast {
-				extension = "    "
-			}
-
-			// Resolve child to get its entries
-			childPath := entry.Path
-			if childPath == "" {
-				childPath = basePath + "/" + entry.Name
-			}
-
-			resolved, err := n.vfs.ResolveTarget(rvfs.RedfishRoot, childPath)
-			if err != nil {
-				continue
-			}
-
-			var childEntries []*rvfs.Entry
-			switch resolved.Type {
-			case rvfs.TargetResource, rvfs.TargetLink:
-				childEntries, _ = n.vfs.ListAll(resolved.ResourcePath)
-			case rvfs.TargetProperty:
-				childEntries = entriesFromProperty(resolved.Property)
-			}
-
-			subtree := n.buildTreeFromEntries(childPath, childEntries, prefix+extension, maxDepth, currentDepth+1)
-			if subtree != "" {
-				lines = append(lines, subtree)
-			}
-		}
-	}
-
-	return strings.Join(lines, "\n")
-}
-
-// find searches for properties recursively
-func (n *Navigator) find(pattern string) error {
-	re, err := regexp.Compile("(?i)" + pattern)
-	if err != nil {
-		return fmt.Errorf("invalid pattern: %v", err)
-	}
-
-	resolved, err := n.vfs.ResolveTarget(rvfs.RedfishRoot, n.cwd)
-	if err != nil {
-		return err
-	}
-
-	var results []string
-
-	switch resolved.Type {
-	case rvfs.TargetResource, rvfs.TargetLink:
-		n.findInResource(resolved.ResourcePath, "", re, &results, 0)
-	case rvfs.TargetProperty:
-		findInProperty(resolved.Property, "", re, &results)
-	}
-
-	if len(results) == 0 {
-		fmt.Printf("No matches found for '%s'\n", pattern)
-	} else {
-		for _, result := range results {
-			fmt.Println(result)
-		}
-	}
-
-	return nil
-}
-
-func (n *Navigator) findInResource(resourcePath, prefix string, re *regexp.Regexp, results *[]string, depth int) {
-	if depth > 5 {
-		return
-	}
-
-	resource, err := n.vfs.Get(resourcePath)
-	if err != nil {
-		return
-	}
-
-	// Search all properties in this resource
-	for _, prop := range resource.Properties {
-		findInProperty(prop, prefix, re, results)
-	}
-
-	// Recurse into child resources
-	for _, child := range resource.Children {
-		childPrefix := child.Name
-		if prefix != "" {
-			childPrefix = prefix + "/" + child.Name
-		}
-		n.findInResource(child.Target, childPrefix, re, results, depth+1)
-	}
-}
-
-func findInProperty(prop *rvfs.Property, prefix string, re *regexp.Regexp, results *[]string) {
-	fullPath := prop.Name
-	if prefix != "" {
-		fullPath = prefix + "/" + prop.Name
-	}
-
-	if re.MatchString(prop.Name) {
-		*results = append(*results,
-			fmt.Sprintf("%s = %s",
-				warnStyle.Render(fullPath),
-				formatPropertyValue(prop)))
-	}
-
-	// Recurse into children
-	switch prop.Type {
-	case rvfs.PropertyObject:
-		for _, child := range prop.Children {
-			findInProperty(child, fullPath, re, results)
-		}
-	case rvfs.PropertyArray:
-		for _, elem := range prop.Elements {
-			findInProperty(elem, fullPath, re, results)
-		}
-	}
-}
-
-// scrape crawls all reachable resources from the current directory
-func (n *Navigator) scrape() error {
-	start := time.Now()
-
-	// Register signal listener for ^C cancellation
-	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, os.Interrupt)
-	defer signal.Stop(sig)
-
-	// Build set of already cached paths
-	cached := make(map[string]bool)
-	for _, p := range n.vfs.GetKnownPaths() {
-		cached[p] = true
-	}
-
-	// BFS from cwd to discover uncached frontiers
-	visited := make(map[string]bool)
-	frontier := []string{n.cwd}
-	var queue []string
-
-	for len(frontier) > 0 {
-		path := frontier[0]
-		frontier = frontier[1:]
-		if visited[path] {
-			continue
-		}
-		visited[path] = true
-
-		if !cached[path] {
-			queue = append(queue, path)
-			continue // Can't inspect children of uncached resources yet
-		}
-
-		res, err := n.vfs.Get(path)
-		if err != nil {
-			continue
-		}
-		for _, child := range res.Children {
-			if !visited[child.Target] {
-				frontier = append(frontier, child.Target)
-			}
-		}
-	}
-
-	if len(queue) == 0 {
-		fmt.Println("Everything is cached")
-		return nil
-	}
-
-	fetched := 0
-	total := len(queue)
-	var errMessages []string
-	cancelled := false
-
-	for len(queue) > 0 {
-		// Check for ^C cancellation
-		select {
-		case <-sig:
-			cancelled = true
-		default:
-		}
-		if cancelled {
-			break
-		}
-
-		path := queue[0]
-		queue = queue[1:]
-		fetched++
-
-		// In-place progress line
-		errPart := ""
-		if len(errMessages) > 0 {
-			errPart = fmt.Sprintf(", %d errors", len(errMessages))
-		}
-		fmt.Printf("\r\033[KFetching %s  (%d/%d%s)", path, fetched, total, errPart)
-
-		res, err := n.vfs.Get(path)
-		if err != nil {
-			errMessages = append(errMessages, fmt.Sprintf("  %s: %s", path, err.Error()))
-			continue
-		}
-
-		// Discover new children from freshly fetched resource
-		for _, child := range res.Children {
-			if !visited[child.Target] {
-				visited[child.Target] = true
-				queue = append(queue, child.Target)
-				total++
-			}
-		}
-	}
-
-	// Clear progress line and print summary
-	elapsed := time.Since(start)
-	fmt.Print("\r\033[K")
-	if cancelled {
-		fmt.Printf("Cancelled: %d fetched, %d errors, %s\n", fetched, len(errMessages), elapsed.Round(time.Millisecond))
-	} else {
-		fmt.Printf("Done: %d fetched, %d errors, %s\n", fetched, len(errMessages), elapsed.Round(time.Millisecond))
-	}
-	for _, msg := range errMessages {
-		fmt.Println(msg)
-	}
-	return nil
-}
-
-// refresh invalidates a resource from cache, re-fetches, and shows it
-func (n *Navigator) refresh(target string) error {
-	// Determine which path to refresh
-	var path string
-	if target == "" {
-		path = n.cwd
-	} else {
-		resolved, err := n.vfs.ResolveTarget(n.cwd, target)
-		if err != nil {
-			return err
-		}
-		switch resolved.Type {
-		case rvfs.TargetResource, rvfs.TargetLink:
-			path = resolved.ResourcePath
-		default:
-			return fmt.Errorf("can only refresh resources, not properties")
-		}
-	}
-
-	n.vfs.Invalidate(path)
-	fmt.Printf("Refreshing %s...\n", path)
-
-	res, err := n.vfs.Get(path)
-	if err != nil {
-		return err
-	}
-
-	// Show refreshed resource like ll
-	if err := n.showResource(path); err != nil {
-		return err
-	}
-	fmt.Println(dimStyle.Render(formatAge(res.FetchedAt)))
-	return nil
-}
-
-// ActionInfo describes a Redfish action on a resource
-type ActionInfo struct {
-	Name      string              // Full name (e.g. #ComputerSystem.Reset)
-	ShortName string              // Stripped name (e.g. Reset)
-	Target    string              // POST URI
-	InfoURI   string              // @Redfish.ActionInfo URI (may be empty)
-	Allowable map[string][]string // Parameter name → AllowableValues
-}
-
-// discoverActions finds all actions on the resource at nav.cwd
-func discoverActions(nav *Navigator) ([]ActionInfo, error) {
-	resolved, err := nav.vfs.ResolveTarget(rvfs.RedfishRoot, nav.cwd)
-	if err != nil {
-		return nil, err
-	}
-
-	var resource *rvfs.Resource
-	switch resolved.Type {
-	case rvfs.TargetResource, rvfs.TargetLink:
-		resource = resolved.Resource
-		if resource == nil {
-			resource, err = nav.vfs.Get(resolved.ResourcePath)
-			if err != nil {
-				return nil, err
-			}
-		}
-	case rvfs.TargetProperty:
-		resource = resolved.Resource
-	}
-	if resource == nil {
-		return nil, nil
-	}
-
-	actionsProp, ok := resource.Properties["Actions"]
-	if !ok || actionsProp.Type != rvfs.PropertyObject {
-		return nil, nil
-	}
-
-	var actions []ActionInfo
-	for key, child := range actionsProp.Children {
-		if key == "Oem" {
-			continue
-		}
-
-		info := ActionInfo{
-			Name:      key,
-			Allowable: make(map[string][]string),
-		}
-
-		// Extract short name: strip #Type. prefix
-		if idx := strings.LastIndex(key, "."); idx != -1 && strings.HasPrefix(key, "#") {
-			info.ShortName = key[idx+1:]
-		} else {
-			info.ShortName = key
-		}
-
-		if child.Type != rvfs.PropertyObject {
-			continue
-		}
-
-		// Extract target, ActionInfo URI, and AllowableValues from children
-		for childKey, childProp := range child.Children {
-			if childKey == "target" && childProp.Type == rvfs.PropertyLink {
-				info.Target = childProp.LinkTarget
-			} else if childKey == "@Redfish.ActionInfo" && childProp.Type == rvfs.PropertyLink {
-				info.InfoURI = childProp.LinkTarget
-			} else if strings.HasSuffix(childKey, "@Redfish.AllowableValues") && childProp.Type == rvfs.PropertyArray {
-				paramName := strings.TrimSuffix(childKey, "@Redfish.AllowableValues")
-				var values []string
-				for _, elem := range childProp.Elements {
-					if elem.Type == rvfs.PropertySimple {
-						if s, ok := elem.Value.(string); ok {
-							values = append(values, s)
-						}
-					}
-				}
-				info.Allowable[paramName] = values
-			}
-		}
-
-		if info.Target != "" {
-			actions = append(actions, info)
-		}
-	}
-
-	sort.Slice(actions, func(i, j int) bool {
-		return actions[i].ShortName < actions[j].ShortName
-	})
-	return actions, nil
-}
-
-// matchAction finds an action by short name or full name (case-insensitive)
-func matchAction(actions []ActionInfo, name string) *ActionInfo {
-	lower := strings.ToLower(name)
-	for i := range actions {
-		if strings.ToLower(actions[i].ShortName) == lower {
-			return &actions[i]
-		}
-	}
-	for i := range actions {
-		if strings.ToLower(actions[i].Name) == lower {
-			return &actions[i]
-		}
-	}
-	return nil
-}
-
-// Display formatting
-
-func (n *Navigator) printShortListingAll(entries []*rvfs.Entry) {
-	if len(entries) == 0 {
-		fmt.Println("(empty)")
-		return
-	}
-
-	items := make([]string, len(entries))
-	for i, entry := range entries {
-		items[i] = formatEntry(entry)
-	}
-
-	fmt.Println(formatColumns(items))
-}
-
-func formatEntry(entry *rvfs.Entry) string {
-	switch entry.Type {
-	case rvfs.EntryLink:
-		return childStyle.Render(entry.Name + "/")
-	case rvfs.EntrySymlink:
-		return linkStyle.Render(entry.Name + "@")
-	case rvfs.EntryComplex:
-		return objectStyle.Render(entry.Name + "/")
-	case rvfs.EntryArray:
-		return objectStyle.Render(entry.Name + "[]")
-	case rvfs.EntryProperty:
-		return propStyle.Render(entry.Name)
-	default:
-		return entry.Name
-	}
-}
-
-func formatPropertyValue(prop *rvfs.Property) string {
-	switch v := prop.Value.(type) {
-	case string:
-		if len(v) > 60 {
-			return v[:57] + "..."
-		}
-		return v
-	case bool:
-		return fmt.Sprintf("%v", v)
-	case float64:
-		if v == float64(int64(v)) {
-			return fmt.Sprintf("%d", int64(v))
-		}
-		return fmt.Sprintf("%g", v)
-	case nil:
-		return "null"
-	case []byte:
-		return "{...}"
-	default:
-		return fmt.Sprintf("%v", v)
-	}
-}
-
-func getEntriesSummary(entries []*rvfs.Entry) string {
-	children := 0
-	links := 0
-	properties := 0
-
-	for _, entry := range entries {
-		switch entry.Type {
-		case rvfs.EntryLink:
-			children++
-		case rvfs.EntrySymlink:
-			links++
-		case rvfs.EntryProperty, rvfs.EntryComplex, rvfs.EntryArray:
-			properties++
-		}
-	}
-
-	var parts []string
-	if children > 0 {
-		parts = append(parts, fmt.Sprintf("%d children", children))
-	}
-	if links > 0 {
-		parts = append(parts, fmt.Sprintf("%d links", links))
-	}
-	if properties > 0 {
-		parts = append(parts, fmt.Sprintf("%d props", properties))
-	}
-
-	if len(parts) == 0 {
-		return "empty"
-	}
-	return strings.Join(parts, ", ")
-}
-
-// normalizePath ensures path has no trailing slash
-func normalizePath(p string) string {
-	return strings.TrimRight(p, "/")
-}
-
-func main() {
-	// Parse arguments: config file only
-	if len(os.Args) != 2 {
-		fmt.Println("Usage: bfsh CONFIG_FILE")
-		fmt.Println("Example: bfsh config.yaml")
-		os.Exit(1)
-	}
-
-	configPath := os.Args[1]
-
-	// Check if it's a YAML file
-	if !strings.HasSuffix(configPath, ".yaml") && !strings.HasSuffix(configPath, ".yml") {
-		fmt.Println("Usage: bfsh CONFIG_FILE")
-		fmt.Println("Example: bfsh config.yaml")
-		os.Exit(1)
-	}
-
-	cfg, err := loadConfig(configPath)
-	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
-		os.Exit(1)
-	}
-
-	endpoint := cfg.Endpoint
-	username := cfg.User
-	password := cfg.Pass
-	insecure := cfg.Insecure
-
-	// Create VFS
-	fmt.Printf("Connecting to %s...\n", endpoint)
-	vfs, err := rvfs.NewVFS(endpoint, username, password, insecure)
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
-	}
-	defer vfs.Sync()
-
-	// Create navigator
-	nav := NewNavigator(vfs)
-
-	// Show initial status
-	entries, _ := vfs.ListAll(nav.cwd)
-	summary := getEntriesSummary(entries)
-	fmt.Printf("%s  (%s)\n", nav.cwd, summary)
-	fmt.Println("Type 'help' for commands")
-
-	// Setup readline with completion preprocessing
-	completer := NewCompleter(nav)
-	listener := NewCompletionListener(nav)
-
-	rl, err := readline.NewEx(&readline.Config{
-		Prompt:            getPrompt(nav),
-		HistoryFile:       os.ExpandEnv("$HOME/.bfsh_history"),
-		AutoComplete:      completer,
-		Listener:          listener,
-		InterruptPrompt:   "^C",
-		EOFPrompt:         "exit",
-		HistorySearchFold: true,
-		HistoryLimit:      1000,
-	})
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
-	}
-	defer rl.Close()
-
-	// REPL loop
-	for {
-		rl.SetPrompt(getPrompt(nav))
-
-		line, err := rl.Readline()
-		if err != nil {
-			if err == readline.ErrInterrupt {
-				if nav.actionMode {
-					nav.actionMode = false
-					fmt.Println("Exited action mode")
-				}
-				continue
-			}
-			break // EOF (^D) exits
-		}
-
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// Enter action mode
-		if line == "!" && !nav.actionMode {
-			actions, err := discoverActions(nav)
-			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-				continue
-			}
-			if len(actions) == 0 {
-				fmt.Println("No actions on current resource")
-				continue
-			}
-			nav.actionMode = true
-			printActionList(actions)
-			continue
-		}
-
-		// Parse command
-		parts := strings.Fields(line)
-		cmd := parts[0]
-		args := parts[1:]
-
-		if nav.actionMode {
-			if cmd == "!" {
-				nav.actionMode = false
-				fmt.Println("Exited action mode")
-				continue
-			}
-			if cmd == "exit" || cmd == "quit" || cmd == "q" {
-				break
-			}
-			if err := executeActionCommand(nav, cmd, args); err != nil {
-				fmt.Printf("Error: %v\n", err)
-			}
-			continue
-		}
-
-		// Execute command
-		if err := executeCommand(nav, cmd, args); err != nil {
-			fmt.Printf("Error: %v\n", err)
-		}
-
-		if cmd == "exit" || cmd == "quit" || cmd == "q" {
-			break
-		}
-	}
-}
-
-func getPrompt(nav *Navigator) string {
-	if nav.actionMode {
-		return promptActStyle.Render("action> ")
-	}
-	return promptPathStyle.Render(nav.cwd) + "> "
-}
-
-func executeCommand(nav *Navigator, cmd string, args []string) error {
-	switch cmd {
-	case "cd":
-		target := ""
-		if len(args) > 0 {
-			target = args[0]
-		}
-		return nav.cd(target)
-
-	case "open":
-		if len(args) == 0 {
-			return fmt.Errorf("usage: open <path>")
-		}
-		return nav.open(args[0])
-
-	case "ls":
-		target := ""
-		if len(args) > 0 {
-			target = strings.Join(args, " ")
-		}
-		return nav.ls(target)
-
-	case "ll":
-		target := ""
-		if len(args) > 0 {
-			target = strings.Join(args, " ")
-		}
-		return nav.ll(target)
-
-	case "pwd":
-		fmt.Println(nav.cwd)
-
-	case "dump":
-		target := ""
-		if len(args) > 0 {
-			target = strings.Join(args, " ")
-		}
-		return nav.dump(target)
-
-	case "tree":
-		depth := 2
-		if len(args) > 0 {
-			if d, err := strconv.Atoi(args[0]); err == nil {
-				depth = d
-			}
-		}
-		return nav.tree(depth)
-
-	case "find":
-		if len(args) == 0 {
-			return fmt.Errorf("usage: find <pattern>")
-		}
-		return nav.find(args[0])
-
-	case "scrape":
-		return nav.scrape()
-
-	case "refresh":
-		target := ""
-		if len(args) > 0 {
-			target = args[0]
-		}
-		return nav.refresh(target)
-
-	case "cache":
-		if len(args) == 0 {
-			paths := nav.vfs.GetKnownPaths()
-			fmt.Printf("Cache: %d resources\n", len(paths))
-		} else if args[0] == "clear" {
-			nav.vfs.Clear()
-			fmt.Println("Cache cleared")
-		} else if args[0] == "list" {
-			paths := nav.vfs.GetKnownPaths()
-			sort.Strings(paths)
-			for _, path := range paths {
-				fmt.Println(path)
-			}
-		}
-
-	case "clear":
-		fmt.Print("\033[H\033[2J")
-
-	case "help", "?":
-		printHelp()
-
-	case "exit", "quit", "q":
-		// Handled in main loop
-		return nil
-
-	default:
-		return fmt.Errorf("unknown command: %s (type 'help' for commands)", cmd)
-	}
-
-	return nil
-}
-
-// printActionList displays available actions
-func printActionList(actions []ActionInfo) {
-	fmt.Println()
-	fmt.Println(errorStyle.Render("Actions"))
-	for _, a := range actions {
-		line := fmt.Sprintf("  %s", warnStyle.Render(a.ShortName))
-		if len(a.Allowable) > 0 {
-			var params []string
-			for param, vals := range a.Allowable {
-				params = append(params, fmt.Sprintf("%s=[%s]", param, strings.Join(vals, "|")))
-			}
-			sort.Strings(params)
-			line += fmt.Sprintf("  %s", strings.Join(params, " "))
-		}
-		fmt.Println(line)
-	}
-	fmt.Println()
-}
-
-// executeActionCommand handles commands in action mode
-func executeActionCommand(nav *Navigator, cmd string, args []string) error {
-	switch cmd {
-	case "ls":
-		actions, err := discoverActions(nav)
-		if err != nil {
-			return err
-		}
-		if len(args) > 0 {
-			action := matchAction(actions, args[0])
-			if action == nil {
-				return fmt.Errorf("unknown action: %s", args[0])
-			}
-			printActionList([]ActionInfo{*action})
-		} else {
-			printActionList(actions)
-		}
-		return nil
-
-	case "ll":
-		actions, err := discoverActions(nav)
-		if err != nil {
-			return err
-		}
-		if len(args) > 0 {
-			action := matchAction(actions, args[0])
-			if action == nil {
-				return fmt.Errorf("unknown action: %s", args[0])
-			}
-			showActionDetail(nav, action)
-		} else {
-			for i := range actions {
-				showActionDetail(nav, &actions[i])
-			}
-		}
-		return nil
-
-	case "help", "?":
-		printActionHelp()
-		return nil
-
-	default:
-		// Try to match as action invocation
-		actions, err := discoverActions(nav)
-		if err != nil {
-			return err
-		}
-		action := matchAction(actions, cmd)
-		if action == nil {
-			return fmt.Errorf("unknown action: %s (type 'help' for commands)", cmd)
-		}
-		return invokeAction(nav, action, args)
-	}
-}
-
-// showActionDetail shows detailed info for one action
-func showActionDetail(nav *Navigator, action *ActionInfo) {
-	fmt.Println()
-	fmt.Println(errorStyle.Render(action.Name))
-	fmt.Printf("  Target: %s\n", action.Target)
-
-	if action.InfoURI != "" {
-		fmt.Printf("  ActionInfo: %s\n", action.InfoURI)
-
-		// Try to fetch and display the ActionInfo resource
-		resource, err := nav.vfs.Get(action.InfoURI)
-		if err == nil {
-			paramsProp, ok := resource.Properties["Parameters"]
-			if ok && paramsProp.Type == rvfs.PropertyArray {
-				fmt.Println("\n  Parameters:")
-				for _, elem := range paramsProp.Elements {
-					if elem.Type != rvfs.PropertyObject {
-						continue
-					}
-					name := ""
-					dataType := ""
-					required := false
-					var allowable []string
-
-					if n, ok := elem.Children["Name"]; ok && n.Type == rvfs.PropertySimple {
-						name = fmt.Sprintf("%v", n.Value)
-					}
-					if dt, ok := elem.Children["DataType"]; ok && dt.Type == rvfs.PropertySimple {
-						dataType = fmt.Sprintf("%v", dt.Value)
-					}
-					if r, ok := elem.Children["Required"]; ok && r.Type == rvfs.PropertySimple {
-						if b, ok := r.Value.(bool); ok {
-							required = b
-						}
-					}
-					if av, ok := elem.Children["AllowableValues"]; ok && av.Type == rvfs.PropertyArray {
-						for _, v := range av.Elements {
-							if v.Type == rvfs.PropertySimple {
-								allowable = append(allowable, fmt.Sprintf("%v", v.Value))
-							}
-						}
-					}
-
-					reqStr := ""
-					if required {
-						reqStr = errorStyle.Render(" (required)")
-					}
-					fmt.Printf("    %s%s  %s", warnStyle.Render(name), reqStr, dataType)
-					if len(allowable) > 0 {
-						fmt.Printf("  [%s]", strings.Join(allowable, "|"))
-					}
-					fmt.Println()
-				}
-			}
-		}
-	}
-
-	if len(action.Allowable) > 0 {
-		if action.InfoURI == "" {
-			fmt.Println("\n  Parameters:")
-		} else {
-			fmt.Println("\n  Allowable values (from annotations):")
-		}
-		for param, vals := range action.Allowable {
-			fmt.Printf("    %s: [%s]\n", warnStyle.Render(param), strings.Join(vals, "|"))
-		}
-	}
-
-	fmt.Println()
-}
-
-// invokeAction executes a Redfish action with confirmation
-func invokeAction(nav *Navigator, action *ActionInfo, args []string) error {
-	// Parse key=value arguments
-	body := make(map[string]any)
-	for _, arg := range args {
-		idx := strings.Index(arg, "=")
-		if idx == -1 {
-			return fmt.Errorf("invalid argument %q (expected key=value)", arg)
-		}
-		key := arg[:idx]
-		val := arg[idx+1:]
-
-		// Validate against AllowableValues if present
-		if allowed, ok := action.Allowable[key]; ok {
-			found := false
-			for _, a := range allowed {
-				if a == val {
-					found = true
-					break
-				}
-			}
-			if !found {
-				return fmt.Errorf("invalid value %q for %s (allowed: %s)", val, key, strings.Join(allowed, ", "))
-			}
-		}
-
-		// Attempt numeric conversion
-		if n, err := strconv.ParseFloat(val, 64); err == nil {
-			if n == float64(int64(n)) {
-				body[key] = int64(n)
-			} else {
-				body[key] = n
-			}
-		} else if val == "true" {
-			body[key] = true
-		} else if val == "false" {
-			body[key] = false
-		} else {
-			body[key] = val
-		}
-	}
-
-	jsonBody, err := json.MarshalIndent(body, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	// Show confirmation
-	fmt.Printf("\n%s %s\n", errorStyle.Render("POST"), action.Target)
-	if len(body) > 0 {
-		fmt.Println(string(jsonBody))
-	}
-	fmt.Print("\nConfirm? [y/N] ")
-
-	var confirm string
-	fmt.Scanln(&confirm)
-	if confirm != "y" && confirm != "Y" {
-		fmt.Println("Cancelled")
-		return nil
-	}
-
-	// Execute
-	data, status, err := nav.vfs.Post(action.Target, jsonBody)
-	if err != nil {
-		return err
-	}
-
-	fmt.Printf("\nHTTP %d\n", status)
-	if len(data) > 0 {
-		var buf bytes.Buffer
-		if json.Indent(&buf, data, "", "  ") == nil {
-			fmt.Println(buf.String())
-		} else {
-			fmt.Println(string(data))
-		}
-	}
-	return nil
-}
-
-// printActionHelp shows action mode help
-func printActionHelp() {
-	cmd := func(s string) string { return linkStyle.Render(s) }
-	arg := func(s string) string { return warnStyle.Render(s) }
-
-	fmt.Println()
-	fmt.Println(boldStyle.Render("Action Mode"))
-	fmt.Printf("  %s %-16s %s\n", cmd("ls"), "", "List available actions")
-	fmt.Printf("  %s %-16s %s\n", cmd("ll"), arg("<action>"), "Show action details and parameters")
-	fmt.Printf("  %s %-16s %s\n", cmd("<action>"), arg("[k=v ...]"), "Invoke action (with confirmation)")
-	fmt.Printf("  %s %-16s %s\n", cmd("!"), "", "Exit action mode")
-	fmt.Printf("  %s %-16s %s\n", cmd("help"), "", "Show this help")
-	fmt.Println()
-	fmt.Println(boldStyle.Render("Example"))
-	fmt.Printf("  %s\n", warnStyle.Render("Reset ResetType=GracefulShutdown"))
-	fmt.Println()
-}
-
-func printHelp() {
-	cmd := func(s string) string { return linkStyle.Render(s) }
-	arg := func(s string) string { return warnStyle.Render(s) }
-	dim := func(s string) string { return dimStyle.Render(s) }
-
-	fmt.Println()
-	fmt.Println(boldStyle.Render("Navigation"))
-	fmt.Printf("  %s %-12s %s    %s %-12s %s\n", cmd("cd"), arg("<path>"), "Navigate to resource/property", cmd("open"), arg("<path>"), "Follow link to target resource")
-	fmt.Printf("  %s %-12s %s    %s %-12s %s\n", cmd("pwd"), "", "Print working directory", cmd("ls"), arg("[path]"), "List entries")
-	fmt.Printf("  %s %-12s %s\n", cmd("ll"), arg("[path]"), "Show formatted content (YAML-style)")
-
-	fmt.Println()
-	fmt.Println(boldStyle.Render("Viewing & Search"))
-	fmt.Printf("  %s %-12s %s    %s %-12s %s\n", cmd("dump"), arg("[path]"), "Show raw JSON", cmd("tree"), arg("[depth]"), "Tree view (default: 2)")
-	fmt.Printf("  %s %-12s %s\n", cmd("find"), arg("<pattern>"), "Search properties recursively")
-
-	fmt.Println()
-	fmt.Println(boldStyle.Render("Fetching"))
-	fmt.Printf("  %s %-12s %s\n", cmd("scrape"), "", "Crawl all reachable resources from cwd")
-	fmt.Printf("  %s %-12s %s\n", cmd("refresh"), arg("[path]"), "Re-fetch a resource (invalidate + fetch)")
-
-	fmt.Println()
-	fmt.Println(boldStyle.Render("Other"))
-	fmt.Printf("  %s %-12s %s    %s %-12s %s\n", cmd("!"), "", "Enter action mode (POST)", cmd("cache"), arg("[cmd]"), "Cache ops (clear, list)")
-	fmt.Printf("  %s %-12s %s    %s %s\n", cmd("clear"), "", "Clear screen", cmd("help"), dim("exit/quit"))
-
-	fmt.Println()
-	fmt.Println(boldStyle.Render("Paths"))
-	fmt.Printf("  %s  %s  %s  %s             %s\n",
-		arg("/"), dim("separator"),
-		arg("[n]"), dim("array index"),
-		dim("Systems/1/Status/Health  BootOrder[0]"))
-	fmt.Printf("  %s %s  %s  %s             %s\n",
-		arg(".."), dim("parent"),
-		arg("~"), dim("root (/redfish/v1)"),
-		dim("open .  returns to containing resource"))
-
-	fmt.Println()
-	fmt.Println(boldStyle.Render("Keys"))
-	fmt.Printf("  %s  %s    %s  %s\n",
-		dim("Tab"), "complete",
-		dim("Ctrl+R"), "history search")
-	fmt.Printf("  %s  %s    %s  %s\n",
-		dim("↑/↓"), "history",
-		dim("Ctrl+L"), "clear screen")
-
-	fmt.Println()
-	fmt.Println(boldStyle.Render("Display"))
-	fmt.Printf("  %s  %s  %s  %s  %s\n",
-		childStyle.Render("dir/"), dim("child"),
-		linkStyle.Render("link@"), dim("symlink"),
-		propStyle.Render("prop"))
-	fmt.Printf("  %s  %s  %s  %s\n",
-		objectStyle.Render("obj/"), dim("object"),
-		objectStyle.Render("arr[]"), dim("array"))
-	fmt.Println()
-}
-
-// formatColumns formats items in columns like ls
-func formatColumns(items []string) string {
-	if len(items) == 0 {
-		return ""
-	}
-
-	// Get terminal width
-	width := 100 // default
-	if fd := int(os.Stdout.Fd()); term.IsTerminal(fd) {
-		if w, _, err := term.GetSize(fd); err == nil {
-			width = w
-		}
-	}
-
-	// Calculate column width (accounting for ANSI codes)
-	maxLen := 0
-	for _, item := range items {
-		stripped := stripAnsi(item)
-		if len(stripped) > maxLen {
-			maxLen = len(stripped)
-		}
-	}
-
-	colWidth := maxLen + 2
-	numCols := width / colWidth
-	if numCols < 1 {
-		numCols = 1
-	}
-
-	// Format in columns
-	var result strings.Builder
-	for i, item := range items {
-		result.WriteString(item)
-		if (i+1)%numCols == 0 {
-			result.WriteString("\n")
-		} else if i < len(items)-1 {
-			stripped := stripAnsi(item)
-			padding := colWidth - len(stripped)
-			result.WriteString(strings.Repeat(" ", padding))
-		}
-	}
-
-	return result.String()
-}
-
-// stripAnsi removes ANSI escape codes from text
-func stripAnsi(text string) string {
-	var result strings.Builder
-	inCode := false
-	for _, ch := range text {
-		if ch == '\033' {
-			inCode = true
-		} else if inCode {
-			if ch == 'm' {
-				inCode = false
-			}
-		} else {
-			result.WriteRune(ch)
-		}
-	}
-	return result.String()
-}
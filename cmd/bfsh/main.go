@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bluefish-project/bluefish/internal/bfsh"
+	"github.com/bluefish-project/bluefish/internal/config"
+)
+
+func main() {
+	if len(os.Args) >= 2 && os.Args[1] == "discover" {
+		if err := bfsh.Discover(os.Args[2:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Parse arguments: config file only
+	if len(os.Args) != 2 ||
+		!strings.HasSuffix(os.Args[1], ".yaml") && !strings.HasSuffix(os.Args[1], ".yml") {
+		fmt.Println("Usage: bfsh CONFIG_FILE")
+		fmt.Println("       bfsh discover [SECONDS]")
+		fmt.Println("Example: bfsh config.yaml")
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load(os.Args[1])
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := bfsh.Run(cfg); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509/pkix"
+	"flag"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bluefish-project/bluefish/internal/config"
+	"github.com/bluefish-project/bluefish/rvfs"
+)
+
+// probeRounds is how many times info requests the service root to measure
+// the round trip
+const probeRounds = 5
+
+// runInfo prints the effective config with the password masked, then what
+// the connection negotiates and what the service reports about itself.
+// Each part is printed as soon as it is known, so a connection that fails
+// still shows everything up to the failure.
+func runInfo(_ *globals, cfg *config.Config, args []string) (err error) {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	fmt.Println("Configuration")
+	masked := cfg.Masked()
+	var data strings.Builder
+	enc := yaml.NewEncoder(&data)
+	enc.SetIndent(2)
+	if err := enc.Encode(&masked); err != nil {
+		return err
+	}
+	for _, line := range strings.Split(strings.TrimRight(data.String(), "\n"), "\n") {
+		fmt.Println("  " + line)
+	}
+	overrides := config.EnvOverrides()
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "endpoint", "user", "insecure":
+			overrides = append(overrides, "-"+f.Name)
+		}
+	})
+	if len(overrides) > 0 {
+		fmt.Printf("  # overridden by %s\n", strings.Join(overrides, ", "))
+	}
+
+	fmt.Println("\nConnection")
+	report, err := rvfs.ProbeConnection(cfg.Endpoint, cfg.Insecure, probeRounds)
+	if err != nil {
+		return err
+	}
+	printConnection(cfg, report)
+
+	fmt.Println("\nService")
+	vfs, closeVFS, err := cfg.Connect()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := closeVFS(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	root, err := vfs.Get(vfs.Root())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("  %-16s %s\n", "Root:", vfs.Root())
+	for _, name := range []string{"RedfishVersion", "Vendor", "Product", "UUID"} {
+		if p, ok := root.Properties[name]; ok && p.Type == rvfs.PropertySimple {
+			fmt.Printf("  %-16s %v\n", name+":", p.Value)
+		}
+	}
+
+	// The session service needs credentials on every conforming service,
+	// so reading it shows how the connection authenticates
+	if child, ok := root.Children["SessionService"]; ok {
+		if _, err := vfs.Get(child.Target); err != nil {
+			fmt.Printf("  %-16s %v\n", "Auth error:", err)
+		}
+	}
+	switch vfs.Auth() {
+	case rvfs.AuthSession:
+		fmt.Printf("  %-16s Redfish session (X-Auth-Token)\n", "Session:")
+	default:
+		fmt.Printf("  %-16s none; the service answered without credentials\n", "Session:")
+	}
+	if quirks := vfs.Quirks(); len(quirks) > 0 {
+		fmt.Printf("  %-16s %s\n", "Quirks:", quirks)
+	}
+
+	features, ok := root.Properties["ProtocolFeaturesSupported"]
+	if !ok {
+		fmt.Println("  ProtocolFeaturesSupported: not reported")
+		return nil
+	}
+	fmt.Println("  ProtocolFeaturesSupported:")
+	for _, line := range featureLines(features, "") {
+		fmt.Println("    " + line)
+	}
+	return nil
+}
+
+// printConnection prints the TLS session and the round trip times
+func printConnection(cfg *config.Config, report *rvfs.ConnectionReport) {
+	fmt.Printf("  %-12s %s\n", "Endpoint:", cfg.Endpoint)
+	fmt.Printf("  %-12s %d on %s without credentials\n", "HTTP:", report.Status, rvfs.VersionsPath)
+
+	if state := report.TLS; state == nil {
+		fmt.Printf("  %-12s none (plain HTTP)\n", "TLS:")
+	} else {
+		line := tls.VersionName(state.Version) + ", " + tls.CipherSuiteName(state.CipherSuite)
+		if state.NegotiatedProtocol != "" {
+			line += ", ALPN " + state.NegotiatedProtocol
+		}
+		fmt.Printf("  %-12s %s\n", "TLS:", line)
+		if len(state.PeerCertificates) > 0 {
+			cert := state.PeerCertificates[0]
+			days := int(time.Until(cert.NotAfter).Hours() / 24)
+			fmt.Printf("  %-12s %s, issued by %s\n", "Certificate:", certName(cert.Subject), certName(cert.Issuer))
+			fmt.Printf("  %-12s %s (%d days)\n", "Expires:", cert.NotAfter.Format(time.DateOnly), days)
+		}
+		switch {
+		case report.VerifyErr == nil:
+			fmt.Printf("  %-12s yes\n", "Verified:")
+		case cfg.Insecure:
+			fmt.Printf("  %-12s no, ignored (insecure): %v\n", "Verified:", report.VerifyErr)
+		default:
+			fmt.Printf("  %-12s no: %v\n", "Verified:", report.VerifyErr)
+		}
+	}
+
+	line := "connect " + report.Connect.Round(time.Millisecond).String()
+	if trips := report.RoundTrips; len(trips) > 0 {
+		var total time.Duration
+		for _, d := range trips {
+			total += d
+		}
+		line += fmt.Sprintf(", round trip %s min / %s avg / %s max",
+			slices.Min(trips).Round(time.Millisecond),
+			(total / time.Duration(len(trips))).Round(time.Millisecond),
+			slices.Max(trips).Round(time.Millisecond))
+	}
+	fmt.Printf("  %-12s %s\n", "Latency:", line)
+}
+
+// certName is the common name of a certificate subject or issuer, or the
+// whole name when it has none
+func certName(name pkix.Name) string {
+	if name.CommonName != "" {
+		return name.CommonName
+	}
+	return name.String()
+}
+
+// featureLines flattens ProtocolFeaturesSupported into one line per value,
+// named by its dotted member path: ExpandQuery.Levels: true
+func featureLines(p *rvfs.Property, prefix string) []string {
+	switch p.Type {
+	case rvfs.PropertyObject:
+		names := make([]string, 0, len(p.Children))
+		for name := range p.Children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var lines []string
+		for _, name := range names {
+			childPrefix := name
+			if prefix != "" {
+				childPrefix = prefix + "." + name
+			}
+			lines = append(lines, featureLines(p.Children[name], childPrefix)...)
+		}
+		return lines
+	case rvfs.PropertyArray:
+		values := make([]string, len(p.Elements))
+		for i, e := range p.Elements {
+			values[i] = fmt.Sprint(e.Value)
+		}
+		return []string{fmt.Sprintf("%s: [%s]", prefix, strings.Join(values, ", "))}
+	}
+	return []string{fmt.Sprintf("%s: %v", prefix, p.Value)}
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/bluefish-project/bluefish/internal/bfsh"
+	"github.com/bluefish-project/bluefish/internal/bfui"
+	"github.com/bluefish-project/bluefish/internal/btsh"
+	"github.com/bluefish-project/bluefish/internal/config"
+	"github.com/bluefish-project/bluefish/internal/session"
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/theme"
+)
+
+// errUsage reports a command line that needs the usage printed
+var errUsage = errors.New("usage")
+
+// command is a bluefish subcommand
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(g *globals, cfg *config.Config, args []string) error
+}
+
+var commands = []command{
+	{"shell", "[-cmd COMMAND]...", "Readline shell", runShell},
+	{"tsh", "[-cmd COMMAND]... [-record-session FILE]", "Bubbletea shell", runTsh},
+	{"ui", "[-record-session FILE]", "Tree browser", runUI},
+	{"get", "PATH", "Print the JSON of a resource or property", runGet},
+	{"cat", "PATH", "Print the bare value of a property; fails when it is missing", runCat},
+	{"export", "[-o FILE] [PATH]", "Save every resource reachable from PATH as one JSON file", runExport},
+	{"info", "", "Print the effective config, TLS session, latency and service version", runInfo},
+	{"diag", "", "Same as info", runInfo},
+	{"serve-api", "[-socket PATH]", "Serve the connection to other tools as JSON-RPC on a unix socket", runServeAPI},
+}
+
+// globals are the flags shared by every subcommand
+type globals struct {
+	config   string
+	endpoint string
+	user     string
+	insecure bool
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage: bluefish [FLAGS] COMMAND [ARGS]")
+	fmt.Fprintln(out, "\nCommands:")
+	width := 0
+	for _, c := range commands {
+		width = max(width, len(c.args))
+	}
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-9s %-*s %s\n", c.name, width, c.args, c.summary)
+	}
+	fmt.Fprintf(out, "  %-9s %-*s %s\n", "discover", width, "[SECONDS]", "List Redfish services answering SSDP")
+	fmt.Fprintf(out, "  %-9s %-*s %s\n", "replay", width, "FILE", "Replay a session recorded with -record-session")
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+	fmt.Fprintln(out, "\nBLUEFISH_ENDPOINT, BLUEFISH_USER, BLUEFISH_PASS and BLUEFISH_INSECURE")
+	fmt.Fprintln(out, "override the config file; the flags override both.")
+}
+
+func main() {
+	var g globals
+	flag.StringVar(&g.config, "c", "", "config `FILE`, or - for stdin")
+	flag.StringVar(&g.endpoint, "endpoint", "", "service `URL`")
+	flag.StringVar(&g.user, "user", "", "user `NAME`")
+	flag.BoolVar(&g.insecure, "insecure", false, "skip TLS certificate verification")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	name, args := flag.Arg(0), flag.Args()[1:]
+
+	err := run(&g, name, args)
+	if errors.Is(err, errUsage) {
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run looks up the subcommand, loads the config it needs and runs it
+func run(g *globals, name string, args []string) error {
+	if name == "discover" {
+		// Discovery finds the endpoints a config names; it needs none
+		return bfsh.Discover(args)
+	}
+	if name == "replay" {
+		// A session bundle carries its own config
+		return runReplay(args)
+	}
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		cfg, err := g.load()
+		if err != nil {
+			return err
+		}
+		return c.run(g, cfg, args)
+	}
+	return fmt.Errorf("unknown command: %s", name)
+}
+
+// load reads the config and applies the connection flags given on the
+// command line
+func (g *globals) load() (*config.Config, error) {
+	cfg, err := config.Read(g.config)
+	if err != nil {
+		return nil, err
+	}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "endpoint":
+			cfg.Endpoint = g.endpoint
+		case "user":
+			cfg.User = g.user
+		case "insecure":
+			cfg.Insecure = g.insecure
+		}
+	})
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func runShell(_ *globals, cfg *config.Config, args []string) error {
+	if err := parseShellFlags(flag.NewFlagSet("shell", flag.ContinueOnError), cfg, args); err != nil {
+		return err
+	}
+	return bfsh.Run(cfg)
+}
+
+func runTsh(g *globals, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("tsh", flag.ContinueOnError)
+	record := recordFlag(fs)
+	if err := parseShellFlags(fs, cfg, args); err != nil {
+		return err
+	}
+	if err := recordSession(cfg, "tsh", *record); err != nil {
+		return err
+	}
+	return btsh.Run(cfg, g.config == "-")
+}
+
+// commandList collects the values of a repeated flag
+type commandList []string
+
+func (c *commandList) String() string { return strings.Join(*c, "; ") }
+
+func (c *commandList) Set(s string) error {
+	*c = append(*c, s)
+	return nil
+}
+
+// parseShellFlags parses the flags of a shell into fs: each -cmd runs a
+// command after connecting, following the config's on_connect ones
+func parseShellFlags(fs *flag.FlagSet, cfg *config.Config, args []string) error {
+	var cmds commandList
+	fs.Var(&cmds, "cmd", "run `COMMAND` after connecting (repeatable)")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+	cfg.OnConnect = append(cfg.OnConnect, cmds...)
+	return nil
+}
+
+func runUI(_ *globals, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("ui", flag.ContinueOnError)
+	record := recordFlag(fs)
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+	if err := recordSession(cfg, "ui", *record); err != nil {
+		return err
+	}
+	return bfui.Run(cfg)
+}
+
+// recordFlag adds -record-session to the flags of a Bubble Tea frontend
+func recordFlag(fs *flag.FlagSet) *string {
+	return fs.String("record-session", "", "record the service's answers and the keys pressed to `FILE`, for replay")
+}
+
+// recordSession records the session of a frontend to file, unless file
+// is empty
+func recordSession(cfg *config.Config, frontend, file string) error {
+	if file == "" {
+		return nil
+	}
+	bundled, err := cfg.Bundled()
+	if err != nil {
+		return err
+	}
+	cfg.Session = session.Record(file, frontend, bundled)
+	return nil
+}
+
+// runReplay replays a recorded session in the frontend it ran in, with
+// the config it ran with
+func runReplay(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	s, err := session.Load(args[0])
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Unbundle(s.Config())
+	if err != nil {
+		return err
+	}
+	cfg.Session = s
+	if s.Frontend() == "ui" {
+		return bfui.Run(cfg)
+	}
+	return btsh.Run(cfg, false)
+}
+
+// runGet prints the JSON at a path, highlighted when stdout is a terminal
+func runGet(_ *globals, cfg *config.Config, args []string) (err error) {
+	if len(args) != 1 {
+		return errUsage
+	}
+	vfs, closeVFS, err := cfg.Connect()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := closeVFS(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	target, err := vfs.ResolveTarget(vfs.Root(), args[0])
+	if err != nil {
+		return err
+	}
+	var data []byte
+	switch target.Type {
+	case rvfs.TargetProperty:
+		data = target.Property.JSON()
+	default:
+		res, err := vfs.Get(target.ResourcePath)
+		if err != nil {
+			return err
+		}
+		data = res.RawJSON
+	}
+
+	var t *theme.Theme
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		if t, err = theme.Load(cfg.Theme); err != nil {
+			return fmt.Errorf("theme config: %w", err)
+		}
+	}
+	out, err := t.RenderJSON(data, theme.JSONOptions{})
+	if err != nil {
+		return err
+	}
+	fmt.Println(out)
+	return nil
+}
+
+// runCat prints the value of a property undecorated, so a script can use
+// it as is; the exit status says whether the property exists
+func runCat(_ *globals, cfg *config.Config, args []string) (err error) {
+	if len(args) != 1 {
+		return errUsage
+	}
+	vfs, closeVFS, err := cfg.Connect()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := closeVFS(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	target, err := vfs.ResolveTarget(vfs.Root(), args[0])
+	if err != nil {
+		return err
+	}
+	if target.Type != rvfs.TargetProperty {
+		return fmt.Errorf("not a property: %s (use get)", args[0])
+	}
+	fmt.Println(target.Property.Text())
+	return nil
+}
+
+// runExport walks the resources reachable from a path, the service root by
+// default, and writes them to a JSON file keyed by path
+func runExport(_ *globals, cfg *config.Config, args []string) (err error) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	filename := fs.String("o", "", "output `FILE` (default export_<time>.json)")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() > 1 {
+		return errUsage
+	}
+	if *filename == "" {
+		*filename = "export_" + time.Now().Format("20060102T150405") + ".json"
+	}
+
+	vfs, closeVFS, err := cfg.Connect()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := closeVFS(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	start := vfs.Root()
+	if fs.NArg() == 1 {
+		target, err := vfs.ResolveTarget(vfs.Root(), fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		if target.Type == rvfs.TargetProperty {
+			return fmt.Errorf("not a resource: %s", fs.Arg(0))
+		}
+		start = target.ResourcePath
+	}
+
+	began := time.Now()
+	collected := make(map[string]json.RawMessage)
+	failed := 0
+	rvfs.Walk(vfs, start, func(p string, res *rvfs.Resource, err error) bool {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  %s: %v\n", p, err)
+			failed++
+		} else if len(res.RawJSON) > 0 {
+			collected[p] = json.RawMessage(res.RawJSON)
+		}
+		return true
+	})
+
+	data, err := json.MarshalIndent(collected, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal failed: %v", err)
+	}
+	if err := os.WriteFile(*filename, data, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d resources to %s (%s)", len(collected), *filename, time.Since(began).Round(time.Millisecond))
+	if failed > 0 {
+		fmt.Printf(", %d errors", failed)
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+
+	"github.com/bluefish-project/bluefish/internal/config"
+)
+
+// runServeAPI is unsupported where there are no unix sockets to keep to
+// the user
+func runServeAPI(_ *globals, _ *config.Config, _ []string) error {
+	return errors.New("serve-api is unsupported on this platform")
+}
@@ -0,0 +1,118 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/bluefish-project/bluefish/internal/api"
+	"github.com/bluefish-project/bluefish/internal/config"
+)
+
+// runServeAPI serves the connection as JSON-RPC on a unix socket only the
+// user may open, until interrupted
+func runServeAPI(_ *globals, cfg *config.Config, args []string) (err error) {
+	fs := flag.NewFlagSet("serve-api", flag.ContinueOnError)
+	socket := fs.String("socket", defaultSocket(), "unix socket `PATH` to listen on")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+
+	vfs, closeVFS, err := cfg.Connect()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := closeVFS(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	l, err := listenSocket(*socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(*socket)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		l.Close()
+	}()
+	fmt.Printf("Serving %s on %s\n", cfg.Endpoint, *socket)
+	if err := api.Serve(l, vfs); !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// defaultSocket is where serve-api listens without -socket: in the user's
+// runtime directory, else in a directory of the user's own in the
+// temporary one
+func defaultSocket() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "bluefish.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("bluefish-%d", os.Getuid()), "bluefish.sock")
+}
+
+// listenSocket listens on a unix socket at path, in a directory only the
+// user may enter, so nobody else can reach the socket before it is made
+// the user's alone. A socket of the user's left by a server that died is
+// taken over; a live one, or a path that is not the user's socket, is not.
+func listenSocket(path string) (net.Listener, error) {
+	if err := privateDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 || !ownedByUser(fi) {
+			return nil, fmt.Errorf("%s exists and is not a socket of yours", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is served by another process", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// privateDir creates dir only the user may enter, and refuses one that
+// exists but another user owns or others may enter
+func privateDir(dir string) error {
+	if err := os.Mkdir(dir, 0o700); err != nil && !errors.Is(err, os.ErrExist) {
+		return err
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() || !ownedByUser(fi) || fi.Mode().Perm()&0o077 != 0 {
+		return fmt.Errorf("%s is not a directory only you may enter", dir)
+	}
+	return nil
+}
+
+// ownedByUser reports whether the user running bluefish owns fi's file
+func ownedByUser(fi os.FileInfo) bool {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	return ok && int(st.Uid) == os.Getuid()
+}
@@ -5,78 +5,39 @@ import (
 	"os"
 	"strings"
 
-	tea "github.com/charmbracelet/bubbletea"
-	"gopkg.in/yaml.v3"
-
-	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/internal/btsh"
+	"github.com/bluefish-project/bluefish/internal/config"
 )
 
-// Config holds connection configuration
-type Config struct {
-	Endpoint string `yaml:"endpoint"`
-	User     string `yaml:"user"`
-	Pass     string `yaml:"pass"`
-	Insecure bool   `yaml:"insecure"`
+// usage prints how to run btsh and exits
+func usage() {
+	fmt.Println("Usage: btsh [CONFIG_FILE | -]")
+	fmt.Println("Example: btsh config.yaml")
+	fmt.Println("  -  reads the config from stdin")
+	fmt.Println("  Without a config file, BLUEFISH_ENDPOINT, BLUEFISH_USER, BLUEFISH_PASS")
+	fmt.Println("  and BLUEFISH_INSECURE configure the connection; they also override the file")
+	os.Exit(1)
 }
 
 func main() {
-	if len(os.Args) != 2 {
-		fmt.Println("Usage: btsh CONFIG_FILE")
-		fmt.Println("Example: btsh config.yaml")
-		os.Exit(1)
-	}
-
-	configPath := os.Args[1]
-
-	if !strings.HasSuffix(configPath, ".yaml") && !strings.HasSuffix(configPath, ".yml") {
-		fmt.Println("Usage: btsh CONFIG_FILE")
-		fmt.Println("Example: btsh config.yaml")
-		os.Exit(1)
-	}
-
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		fmt.Printf("Error reading config: %v\n", err)
-		os.Exit(1)
+	if len(os.Args) > 2 {
+		usage()
 	}
-
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		fmt.Printf("Error parsing config: %v\n", err)
-		os.Exit(1)
+	configPath := ""
+	if len(os.Args) == 2 {
+		configPath = os.Args[1]
 	}
-
-	if cfg.Endpoint == "" || cfg.User == "" || cfg.Pass == "" {
-		fmt.Println("Config must include: endpoint, user, pass")
-		os.Exit(1)
+	if configPath != "" && configPath != "-" &&
+		!strings.HasSuffix(configPath, ".yaml") && !strings.HasSuffix(configPath, ".yml") {
+		usage()
 	}
 
-	fmt.Printf("Connecting to %s...\n", cfg.Endpoint)
-	vfs, err := rvfs.NewVFS(cfg.Endpoint, cfg.User, cfg.Pass, cfg.Insecure)
+	cfg, err := config.Load(configPath)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
-	defer vfs.Sync()
-
-	nav := NewNavigator(vfs)
-	history := NewHistory(os.ExpandEnv("$HOME/.btsh_history"))
-
-	// Show initial status
-	entries, _ := vfs.ListAll(nav.cwd)
-	summary := getEntriesSummary(entries)
-	fmt.Printf("%s  (%s)\n", nav.cwd, summary)
-	fmt.Println("Type 'help' for commands")
-
-	state := &shellState{
-		nav:     nav,
-		history: history,
-	}
-
-	m := newModel(state)
-	p := tea.NewProgram(m, tea.WithoutCatchPanics())
-
-	if _, err := p.Run(); err != nil {
+	if err := btsh.Run(cfg, configPath == "-"); err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
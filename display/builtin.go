@@ -0,0 +1,168 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/theme"
+)
+
+func init() {
+	Register("Thermal", thermal)
+	Register("ComputerSystem", computerSystem)
+}
+
+// thermal renders the temperatures and fans of a Thermal resource as
+// tables of their readings, critical thresholds and status
+func thermal(ctx *Context, res *rvfs.Resource) string {
+	var b strings.Builder
+	if temps := res.Properties["Temperatures"]; temps != nil && len(temps.Elements) > 0 {
+		var rows [][]string
+		for _, t := range temps.Elements {
+			rows = append(rows, []string{
+				ctx.Value("Name", Field(t, "Name")),
+				ctx.reading(Field(t, "ReadingCelsius"), "°C"),
+				ctx.reading(Field(t, "UpperThresholdCritical"), "°C"),
+				ctx.status(t),
+			})
+		}
+		b.WriteString(ctx.Style(theme.Bright, "Temperatures") + "\n")
+		b.WriteString(ctx.Table([]string{"NAME", "READING", "CRITICAL", "STATUS"}, rows))
+	}
+	if fans := res.Properties["Fans"]; fans != nil && len(fans.Elements) > 0 {
+		var rows [][]string
+		for _, f := range fans.Elements {
+			name := Field(f, "Name")
+			if name == nil {
+				name = Field(f, "FanName") // Thermal before v1_1_0
+			}
+			unit := "RPM"
+			if units := Field(f, "ReadingUnits"); units != nil && units.Value == "Percent" {
+				unit = "%"
+			}
+			rows = append(rows, []string{
+				ctx.Value("Name", name),
+				ctx.reading(Field(f, "Reading"), unit),
+				ctx.reading(Field(f, "LowerThresholdCritical"), unit),
+				ctx.status(f),
+			})
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(ctx.Style(theme.Bright, "Fans") + "\n")
+		b.WriteString(ctx.Table([]string{"NAME", "READING", "CRITICAL", "STATUS"}, rows))
+	}
+	if b.Len() == 0 {
+		return ctx.Style(theme.Dim, "No temperature or fan readings") + "\n"
+	}
+	return b.String()
+}
+
+// computerSystem renders a ComputerSystem as a card of what it is, its
+// state and what it has
+func computerSystem(ctx *Context, res *rvfs.Resource) string {
+	type row struct{ label, value string }
+	var rows []row
+	add := func(label string, p *rvfs.Property, name string) {
+		if p != nil && p.Type == rvfs.PropertySimple && p.Value != nil {
+			rows = append(rows, row{label, ctx.Value(name, p)})
+		}
+	}
+
+	if model := ResourceField(res, "Model"); model != nil {
+		value := ctx.Value("Model", model)
+		if maker := ResourceField(res, "Manufacturer"); maker != nil && maker.Value != nil {
+			value = ctx.Value("Manufacturer", maker) + " " + value
+		}
+		rows = append(rows, row{"Model", value})
+	}
+	add("Serial", ResourceField(res, "SerialNumber"), "SerialNumber")
+	add("Host name", ResourceField(res, "HostName"), "HostName")
+	add("Power", ResourceField(res, "PowerState"), "PowerState")
+	if health := ResourceField(res, "Status", "Health"); health != nil && health.Value != nil {
+		value := ctx.Value("Health", health)
+		if rollup := ResourceField(res, "Status", "HealthRollup"); rollup != nil && rollup.Value != nil && rollup.Value != health.Value {
+			value += " (rollup " + ctx.Value("HealthRollup", rollup) + ")"
+		}
+		rows = append(rows, row{"Health", value})
+	}
+	add("BIOS", ResourceField(res, "BiosVersion"), "BiosVersion")
+	if count := ResourceField(res, "ProcessorSummary", "Count"); count != nil && count.Value != nil {
+		value := ctx.Value("Count", count)
+		if model := ResourceField(res, "ProcessorSummary", "Model"); model != nil && model.Value != nil {
+			value += " × " + ctx.Value("Model", model)
+		}
+		rows = append(rows, row{"Processors", value})
+	}
+	if gib := ResourceField(res, "MemorySummary", "TotalSystemMemoryGiB"); gib != nil && gib.Value != nil {
+		rows = append(rows, row{"Memory", ctx.Value("TotalSystemMemoryGiB", gib) + " GiB"})
+	}
+	if boot := ctx.boot(res); boot != "" {
+		rows = append(rows, row{"Boot", boot})
+	}
+
+	width := 0
+	for _, r := range rows {
+		width = max(width, theme.Width(r.label))
+	}
+	title := ctx.Value("Name", ResourceField(res, "Name"))
+	if id := ResourceField(res, "Id"); id != nil && id.Value != nil {
+		title += " " + ctx.Style(theme.Dim, "("+ctx.Value("Id", id)+")")
+	}
+	lines := []string{ctx.Style(theme.Bright, title)}
+	for _, r := range rows {
+		lines = append(lines, ctx.Style(theme.Accent, theme.Pad(r.label, width))+"  "+r.value)
+	}
+
+	card := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	if ctx.Theme != nil {
+		card = card.BorderForeground(ctx.Theme.Color(theme.Dim))
+	}
+	return card.Render(strings.Join(lines, "\n")) + "\n"
+}
+
+// boot describes where a system boots from: the override target while one
+// is set, else the start of its boot order
+func (c *Context) boot(res *rvfs.Resource) string {
+	enabled := ResourceField(res, "Boot", "BootSourceOverrideEnabled")
+	target := ResourceField(res, "Boot", "BootSourceOverrideTarget")
+	if enabled != nil && enabled.Value != nil && enabled.Value != "Disabled" && target != nil && target.Value != nil && target.Value != "None" {
+		return c.Value("BootSourceOverrideTarget", target) + c.Style(theme.Dim, " (override, "+enabled.Text()+")")
+	}
+	order := ResourceField(res, "Boot", "BootOrder")
+	if order == nil || len(order.Elements) == 0 {
+		return ""
+	}
+	var names []string
+	for _, e := range order.Elements[:min(len(order.Elements), 3)] {
+		names = append(names, e.Text())
+	}
+	if len(order.Elements) > 3 {
+		names = append(names, "…")
+	}
+	return strings.Join(names, ", ")
+}
+
+// reading renders a sensor reading in unit
+func (c *Context) reading(p *rvfs.Property, unit string) string {
+	if p == nil || p.Value == nil {
+		return c.Style(theme.Dim, "-")
+	}
+	if _, ok := p.Value.(float64); !ok {
+		return c.Value("Reading", p)
+	}
+	return c.Style(theme.Number, fmt.Sprintf("%g %s", p.Value, unit))
+}
+
+// status renders the health of a sensor, or its state while it is not
+// enabled, such as Absent
+func (c *Context) status(p *rvfs.Property) string {
+	if state := Field(p, "Status", "State"); state != nil && state.Value != nil && state.Value != "Enabled" {
+		return c.Value("State", state)
+	}
+	return c.Value("Health", Field(p, "Status", "Health"))
+}
@@ -0,0 +1,152 @@
+// Package display renders resources by their @odata.type: ll of a Thermal
+// resource is a table of its temperatures and fans, ll of a ComputerSystem
+// a summary card. bfsh, btsh and bfui look renderers up here, and resources
+// of other types keep the generic property listing. Renderers for more
+// types come from Go code built into a binary, which calls Register from
+// an init function, as plugin commands do.
+package display
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/theme"
+)
+
+// Context is what a resource is rendered with
+type Context struct {
+	Theme *theme.Theme // nil renders without color
+	// Redacts reports whether the values of properties named name are
+	// masked; nil masks none
+	Redacts func(name string) bool
+}
+
+// Renderer renders a resource of the type it is registered for
+type Renderer func(ctx *Context, res *rvfs.Resource) string
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Renderer{}
+)
+
+// Register renders resources of a type name, e.g. "Thermal", with r, in
+// place of the renderer registered for it before, built-in ones included
+func Register(typeName string, r Renderer) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[typeName] = r
+}
+
+// Render renders a resource with the renderer of its type, and reports
+// whether there is one
+func Render(ctx *Context, res *rvfs.Resource) (string, bool) {
+	mu.RLock()
+	r, ok := registry[res.TypeName()]
+	mu.RUnlock()
+	if !ok {
+		return "", false
+	}
+	return r(ctx, res), true
+}
+
+// Field returns the property at names below p, nil when there is none
+func Field(p *rvfs.Property, names ...string) *rvfs.Property {
+	for _, name := range names {
+		if p == nil || p.Type != rvfs.PropertyObject {
+			return nil
+		}
+		p = p.Children[name]
+	}
+	return p
+}
+
+// ResourceField returns the property at names in res, nil when there is
+// none
+func ResourceField(res *rvfs.Resource, names ...string) *rvfs.Property {
+	if len(names) == 0 {
+		return nil
+	}
+	return Field(res.Properties[names[0]], names[1:]...)
+}
+
+// Style renders text in a role's color
+func (c *Context) Style(role theme.Role, text string) string {
+	if c.Theme == nil {
+		return text
+	}
+	return c.Theme.Fg(role).Render(text)
+}
+
+// Value renders the value of a simple property named name: health and
+// state words in their severity's color, masked when redacted, "-" when
+// missing or null
+func (c *Context) Value(name string, p *rvfs.Property) string {
+	if p == nil || p.Type != rvfs.PropertySimple || p.Value == nil {
+		return c.Style(theme.Dim, "-")
+	}
+	if c.Redacts != nil && c.Redacts(name) {
+		return c.Style(theme.Dim, rvfs.RedactMask)
+	}
+	switch v := p.Value.(type) {
+	case string:
+		switch strings.ToUpper(v) {
+		case "OK", "ENABLED", "UP":
+			return c.Style(theme.OK, v)
+		case "WARNING", "STANDBYOFFLINE", "STARTING":
+			return c.Style(theme.Warning, v)
+		case "CRITICAL", "DISABLED", "ABSENT":
+			return c.Style(theme.Critical, v)
+		}
+		return v
+	case float64:
+		return c.Style(theme.Number, fmt.Sprintf("%g", v))
+	case bool:
+		if v {
+			return c.Style(theme.True, "true")
+		}
+		return c.Style(theme.False, "false")
+	}
+	return fmt.Sprint(p.Value)
+}
+
+// Table lays rows out in columns under a header, each as wide as its
+// widest cell; cells may carry color
+func (c *Context) Table(header []string, rows [][]string) string {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = theme.Width(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], theme.Width(cell))
+		}
+	}
+
+	var b strings.Builder
+	line := func(cells []string, style func(string) string) {
+		b.WriteString("  ")
+		for i, cell := range cells {
+			if i == len(cells)-1 {
+				b.WriteString(style(cell))
+				break
+			}
+			b.WriteString(style(cell))
+			b.WriteString(strings.Repeat(" ", widths[i]-theme.Width(cell)+2))
+		}
+		b.WriteString("\n")
+	}
+	line(header, func(s string) string { return c.Style(theme.Accent, s) })
+	for _, row := range rows {
+		line(row, func(s string) string { return s })
+	}
+	return b.String()
+}
+
+// unregister removes the renderer of a type, for tests
+func unregister(typeName string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(registry, typeName)
+}
@@ -0,0 +1,88 @@
+package display
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/rvfs/rvfstest"
+)
+
+func TestRender(t *testing.T) {
+	resources := rvfstest.Service()
+	resources["/redfish/v1/Chassis/1/Thermal"] = `{
+		"@odata.id": "/redfish/v1/Chassis/1/Thermal",
+		"@odata.type": "#Thermal.v1_7_0.Thermal",
+		"Temperatures": [
+			{"Name": "CPU1 Temp", "ReadingCelsius": 45, "UpperThresholdCritical": 95, "Status": {"State": "Enabled", "Health": "OK"}},
+			{"Name": "CPU2 Temp", "ReadingCelsius": null, "Status": {"State": "Absent"}}
+		],
+		"Fans": [{"FanName": "Fan1", "Reading": 40, "ReadingUnits": "Percent", "Status": {"State": "Enabled", "Health": "Warning"}}]
+	}`
+	server := rvfstest.NewServer(resources)
+	defer server.Close()
+	v := server.VFS(t)
+	ctx := &Context{}
+
+	thermal, err := v.Get("/redfish/v1/Chassis/1/Thermal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, ok := Render(ctx, thermal)
+	want := `Temperatures
+  NAME       READING  CRITICAL  STATUS
+  CPU1 Temp  45 °C    95 °C     OK
+  CPU2 Temp  -        -         Absent
+
+Fans
+  NAME  READING  CRITICAL  STATUS
+  Fan1  40 %     -         Warning
+`
+	if !ok || out != want {
+		t.Errorf("Thermal = %v\n%s\nwant:\n%s", ok, out, want)
+	}
+
+	system, err := v.Get("/redfish/v1/Systems/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, ok = Render(ctx, system)
+	for _, line := range []string{"System (1)", "Model   Contoso Fake 1000", "Power   On", "BIOS    1.0.0", "Boot    Pxe, Hdd"} {
+		if !ok || !strings.Contains(out, line) {
+			t.Errorf("ComputerSystem card lacks %q:\n%s", line, out)
+		}
+	}
+	ctx.Redacts = func(name string) bool { return name == "Model" }
+	if out, _ = Render(ctx, system); strings.Contains(out, "Fake 1000") || !strings.Contains(out, rvfs.RedactMask) {
+		t.Errorf("redacted card:\n%s", out)
+	}
+
+	chassis, err := v.Get("/redfish/v1/Chassis/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Render(ctx, chassis); ok {
+		t.Error("Chassis has a view")
+	}
+	Register("Chassis", func(ctx *Context, res *rvfs.Resource) string {
+		return ctx.Value("ChassisType", ResourceField(res, "ChassisType"))
+	})
+	defer unregister("Chassis")
+	if out, ok := Render(ctx, chassis); !ok || out != "RackMount" {
+		t.Errorf("registered Chassis view = %q, %v", out, ok)
+	}
+}
+
+func TestTable_Wide(t *testing.T) {
+	out := (&Context{}).Table([]string{"NAME", "STATUS"}, [][]string{
+		{"温度センサー", "OK"},
+		{"Fan1", "警告"},
+	})
+	want := `  NAME          STATUS
+  温度センサー  OK
+  Fan1          警告
+`
+	if out != want {
+		t.Errorf("Table =\n%s\nwant:\n%s", out, want)
+	}
+}
@@ -0,0 +1,210 @@
+// Package api serves a connection to tools written in other languages, as
+// JSON-RPC 1.0 over a local socket: a request is {"method": "VFS.Get",
+// "params": [{"path": "Systems/1"}], "id": 1}. Callers reuse the
+// connection's cache, login session and path resolution instead of
+// reimplementing the Redfish plumbing. Paths are relative to the service
+// root unless absolute.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"sort"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+)
+
+// VFS is the JSON-RPC service, registered as "VFS"
+type VFS struct {
+	vfs   rvfs.VFS
+	posts rvfs.PostGuard // POSTs sent, to hold back one sent twice
+}
+
+// Serve answers JSON-RPC requests on the connections l accepts, each in
+// its own goroutine, until l is closed
+func Serve(l net.Listener, v rvfs.VFS) error {
+	server := rpc.NewServer()
+	if err := server.Register(&VFS{vfs: v}); err != nil {
+		return err
+	}
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+// PathArgs name a path
+type PathArgs struct {
+	Path string `json:"path"`
+}
+
+// ResolveArgs name a path relative to a base, the service root when empty
+type ResolveArgs struct {
+	Base string `json:"base"`
+	Path string `json:"path"`
+}
+
+// PostArgs are a POST to a path, such as an action target; Again sends it
+// even when the same POST was just sent
+type PostArgs struct {
+	Path  string          `json:"path"`
+	Body  json.RawMessage `json:"body"`
+	Again bool            `json:"again"`
+}
+
+// WalkArgs start a walk at a path, reading at most Limit resources; 0
+// reads all that are reachable
+type WalkArgs struct {
+	Path  string `json:"path"`
+	Limit int    `json:"limit"`
+}
+
+// Resource is a resource as the service sent it
+type Resource struct {
+	Path      string          `json:"path"`
+	ODataType string          `json:"odata_type"`
+	JSON      json.RawMessage `json:"json"`
+}
+
+// Target is what a path resolves to: a resource, a link to one, or a
+// property, whose value JSON holds
+type Target struct {
+	Type         string          `json:"type"` // resource, link or property
+	ResourcePath string          `json:"resource_path"`
+	JSON         json.RawMessage `json:"json,omitempty"`
+}
+
+// Entry is what ls lists at a path
+type Entry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"`           // resource, link, property, object, array or symlink
+	Link string `json:"link,omitempty"` // Where a link leads
+}
+
+// Response is the answer to a POST
+type Response struct {
+	StatusCode int             `json:"status_code"`
+	Location   string          `json:"location,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	Messages   []rvfs.Message  `json:"messages,omitempty"`
+}
+
+// Walked are the paths a walk read, in the order read, and why the
+// others failed
+type Walked struct {
+	Paths  []string          `json:"paths"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Get returns the resource at a path, from the cache when it holds it
+func (s *VFS) Get(args *PathArgs, reply *Resource) error {
+	target, err := s.vfs.ResolveTarget(s.vfs.Root(), args.Path)
+	if err != nil {
+		return err
+	}
+	if target.Type == rvfs.TargetProperty {
+		return fmt.Errorf("not a resource: %s", args.Path)
+	}
+	res, err := s.vfs.Get(target.ResourcePath)
+	if err != nil {
+		return err
+	}
+	*reply = Resource{Path: res.Path, ODataType: res.ODataType, JSON: res.RawJSON}
+	return nil
+}
+
+// ResolveTarget resolves a path relative to a base, following links
+func (s *VFS) ResolveTarget(args *ResolveArgs, reply *Target) error {
+	base := args.Base
+	if base == "" {
+		base = s.vfs.Root()
+	}
+	target, err := s.vfs.ResolveTarget(base, args.Path)
+	if err != nil {
+		return err
+	}
+	*reply = Target{ResourcePath: target.ResourcePath}
+	switch target.Type {
+	case rvfs.TargetResource:
+		reply.Type = "resource"
+	case rvfs.TargetLink:
+		reply.Type = "link"
+	case rvfs.TargetProperty:
+		reply.Type = "property"
+		reply.ResourcePath = target.Resource.Path
+		reply.JSON = target.Property.JSON()
+	}
+	return nil
+}
+
+// List lists the children and properties of the resource at a path, by
+// name
+func (s *VFS) List(args *PathArgs, reply *[]Entry) error {
+	target, err := s.vfs.ResolveTarget(s.vfs.Root(), args.Path)
+	if err != nil {
+		return err
+	}
+	if target.Type == rvfs.TargetProperty {
+		return fmt.Errorf("not a resource: %s", args.Path)
+	}
+	entries, err := s.vfs.ListAll(target.ResourcePath)
+	if err != nil {
+		return err
+	}
+	*reply = make([]Entry, len(entries))
+	for i, e := range entries {
+		(*reply)[i] = Entry{Name: e.Name, Path: e.Path, Type: e.Type.String(), Link: e.LinkTarget}
+	}
+	sort.Slice(*reply, func(i, j int) bool { return (*reply)[i].Name < (*reply)[j].Name })
+	return nil
+}
+
+// Post sends a body to a path and returns the service's answer; a status
+// refusing it is an answer, not an error. The same POST sent again within
+// rvfs.RepeatWindow is refused unless Again is set.
+func (s *VFS) Post(args *PostArgs, reply *Response) error {
+	path := s.vfs.Join(s.vfs.Root(), args.Path)
+	resp, err := s.posts.Post(s.vfs, path, args.Body, args.Again)
+	if err != nil {
+		return err
+	}
+	*reply = Response{StatusCode: resp.StatusCode, Location: resp.Location, Messages: resp.Messages}
+	if json.Valid(resp.Body) {
+		reply.Body = resp.Body
+	}
+	return nil
+}
+
+// Walk reads the resources reachable from a path, breadth first, filling
+// the cache for Get
+func (s *VFS) Walk(args *WalkArgs, reply *Walked) error {
+	target, err := s.vfs.ResolveTarget(s.vfs.Root(), args.Path)
+	if err != nil {
+		return err
+	}
+	if target.Type == rvfs.TargetProperty {
+		return fmt.Errorf("not a resource: %s", args.Path)
+	}
+	*reply = Walked{Paths: []string{}}
+	read := 0
+	rvfs.Walk(s.vfs, target.ResourcePath, func(path string, _ *rvfs.Resource, err error) bool {
+		if err != nil {
+			if reply.Errors == nil {
+				reply.Errors = make(map[string]string)
+			}
+			reply.Errors[path] = err.Error()
+		} else {
+			reply.Paths = append(reply.Paths, path)
+		}
+		read++
+		return args.Limit == 0 || read < args.Limit
+	})
+	return nil
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bluefish-project/bluefish/rvfs/rvfstest"
+)
+
+func TestServe(t *testing.T) {
+	server := rvfstest.NewServer(rvfstest.Service())
+	defer server.Close()
+	const reset = "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset"
+	var posted string
+	server.HandlePost(reset, func(body []byte) rvfstest.Reply {
+		posted = string(body)
+		return rvfstest.Reply{Status: 204}
+	})
+
+	l, err := net.Listen("unix", filepath.Join(t.TempDir(), "api.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go Serve(l, server.VFS(t))
+	client, err := jsonrpc.Dial("unix", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var res Resource
+	if err := client.Call("VFS.Get", &PathArgs{Path: "Systems/1"}, &res); err != nil ||
+		res.Path != "/redfish/v1/Systems/1" || res.ODataType != "#ComputerSystem.v1_20_0.ComputerSystem" || !strings.Contains(string(res.JSON), `"Fake 1000"`) {
+		t.Errorf("Get = %+v, %v", res, err)
+	}
+
+	var target Target
+	if err := client.Call("VFS.ResolveTarget", &ResolveArgs{Base: "/redfish/v1/Systems/1", Path: "PowerState"}, &target); err != nil ||
+		target.Type != "property" || target.ResourcePath != "/redfish/v1/Systems/1" || string(target.JSON) != `"On"` {
+		t.Errorf("ResolveTarget PowerState = %+v, %v", target, err)
+	}
+	var link Target
+	if err := client.Call("VFS.ResolveTarget", &ResolveArgs{Path: "Systems/1/Links/Chassis[0]"}, &link); err != nil ||
+		link.Type != "link" || link.ResourcePath != "/redfish/v1/Chassis/1" {
+		t.Errorf("ResolveTarget link = %+v, %v", link, err)
+	}
+	err = client.Call("VFS.ResolveTarget", &ResolveArgs{Path: "Systems/9"}, &Target{})
+	if _, ok := err.(rpc.ServerError); !ok {
+		t.Errorf("ResolveTarget of a missing path = %v", err)
+	}
+
+	var entries []Entry
+	if err := client.Call("VFS.List", &PathArgs{Path: "/redfish/v1"}, &entries); err != nil ||
+		!slices.Contains(entries, Entry{Name: "Systems", Path: "/redfish/v1/Systems", Type: "link"}) {
+		t.Errorf("List = %+v, %v", entries, err)
+	}
+
+	var walked Walked
+	if err := client.Call("VFS.Walk", &WalkArgs{Path: "Systems"}, &walked); err != nil ||
+		!slices.Equal(walked.Paths, []string{"/redfish/v1/Systems", "/redfish/v1/Systems/1"}) {
+		t.Errorf("Walk = %+v, %v", walked, err)
+	}
+	var limited Walked
+	if err := client.Call("VFS.Walk", &WalkArgs{Path: "/redfish/v1", Limit: 2}, &limited); err != nil || len(limited.Paths) != 2 {
+		t.Errorf("Walk with a limit = %+v, %v", limited, err)
+	}
+
+	var resp Response
+	if err := client.Call("VFS.Post", &PostArgs{Path: reset, Body: []byte(`{"ResetType":"On"}`)}, &resp); err != nil ||
+		resp.StatusCode != 204 || posted != `{"ResetType":"On"}` {
+		t.Errorf("Post = %+v, %v; posted %s", resp, err, posted)
+	}
+	posted = ""
+	err = client.Call("VFS.Post", &PostArgs{Path: reset, Body: []byte(`{"ResetType":"On"}`)}, &Response{})
+	if _, ok := err.(rpc.ServerError); !ok || !strings.Contains(err.Error(), "was sent") || posted != "" {
+		t.Errorf("Post sent twice = %v; posted %s", err, posted)
+	}
+	if err := client.Call("VFS.Post", &PostArgs{Path: reset, Body: []byte(`{"ResetType":"On"}`), Again: true}, &resp); err != nil || posted == "" {
+		t.Errorf("Post again = %+v, %v; posted %s", resp, err, posted)
+	}
+}
@@ -0,0 +1,4763 @@
+// Package bfsh is the readline Redfish shell.
+package bfsh
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bluefish-project/bluefish/display"
+	"github.com/bluefish-project/bluefish/internal/config"
+	"github.com/bluefish-project/bluefish/plugin"
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/theme"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/chzyer/readline"
+	"github.com/muesli/termenv"
+	"golang.org/x/term"
+)
+
+// Styles, set from the configured theme by applyTheme
+var (
+	childStyle      lipgloss.Style
+	linkStyle       lipgloss.Style
+	objectStyle     lipgloss.Style
+	propStyle       lipgloss.Style
+	dimStyle        lipgloss.Style
+	boldStyle       lipgloss.Style
+	warnStyle       lipgloss.Style
+	errorStyle      lipgloss.Style
+	promptPathStyle lipgloss.Style
+	promptActStyle  lipgloss.Style
+
+	// Value styles
+	stringValStyle lipgloss.Style
+	numberValStyle lipgloss.Style
+	trueValStyle   lipgloss.Style
+	falseValStyle  lipgloss.Style
+	nullValStyle   lipgloss.Style
+
+	// Health-semantic styles
+	healthOKStyle       lipgloss.Style
+	healthWarnStyle     lipgloss.Style
+	healthCriticalStyle lipgloss.Style
+
+	// activeTheme highlights dump output and colors views
+	activeTheme *theme.Theme
+)
+
+// applyTheme sets the styles from a theme
+func applyTheme(t *theme.Theme) {
+	activeTheme = t
+	childStyle = t.Fg(theme.Child).Bold(true)
+	linkStyle = t.Fg(theme.Link)
+	objectStyle = t.Fg(theme.Object)
+	propStyle = t.Fg(theme.Property)
+	dimStyle = t.Fg(theme.Dim)
+	boldStyle = lipgloss.NewStyle().Bold(true)
+	warnStyle = t.Fg(theme.Accent)
+	errorStyle = t.Fg(theme.Error).Bold(true)
+	promptPathStyle = t.Fg(theme.Child).Bold(true)
+	promptActStyle = t.Fg(theme.Error).Bold(true)
+
+	stringValStyle = t.Fg(theme.String)
+	numberValStyle = t.Fg(theme.Number)
+	trueValStyle = t.Fg(theme.True)
+	falseValStyle = t.Fg(theme.False)
+	nullValStyle = t.Fg(theme.Null)
+
+	healthOKStyle = t.Fg(theme.OK)
+	healthWarnStyle = t.Fg(theme.Warning)
+	healthCriticalStyle = t.Fg(theme.Critical).Bold(true)
+}
+
+// healthKeys are property names that get semantic coloring
+var healthKeys = map[string]bool{
+	"Health":       true,
+	"HealthRollup": true,
+	"State":        true,
+	"Status":       true,
+}
+
+// Navigator manages shell state
+type Navigator struct {
+	vfs        rvfs.VFS
+	cwd        string
+	actionMode bool
+	trace      bool        // Print the requests each command caused
+	redact     bool        // Mask the values redaction names (redact)
+	humanize   bool        // Show values with units in human form (set humanize)
+	ages       bool        // Mark entries in ls and tree by age (set ages)
+	notify     bool        // Announce the end of long operations (set notify)
+	uriLinks   bool        // cd follows links inferred from URI strings (set urilinks)
+	power      bool        // Poll the PowerState of the system cwd is in (set powerwatch)
+	views      bool        // ll renders resources of known types by their view (set views)
+	prefetch   bool        // ll warms the children its resource's type hints at (set prefetch)
+	members    []string    // Member paths of the last collection listing (%N)
+	recent     []string    // Directories left, most recent first (cd -, cd -N)
+	dirStack   []string    // pushd/popd stack, top first
+	transcript *Transcript // Where input and output are teed, nil when off
+
+	scrapePolicy rvfs.ScrapePolicy // How scrape retries failures and which paths it skips
+	redaction    rvfs.Redaction    // Properties whose values ll and dump mask
+	powerWatch   rvfs.PowerWatch   // PowerState changes of the system cwd is in
+	role         *rvfs.SessionRole // What the logged-in account may do, nil when unknown
+	staging      rvfs.Staging      // Property changes stage made, for commit
+	history      rvfs.History      // Writes the session made, for undo
+	posts        rvfs.PostGuard    // Actions sent, to hold back one sent twice
+	prefetcher   rvfs.Prefetcher   // Children ll warms in the background
+	endpoint     string            // Service connected to, for plugin commands
+	user         string            // Account logged in as, for plugin commands
+}
+
+// NewNavigator creates a navigator
+func NewNavigator(vfs rvfs.VFS) *Navigator {
+	return &Navigator{
+		vfs:      vfs,
+		cwd:      vfs.Root(),
+		redact:   true,
+		notify:   true,
+		uriLinks: true,
+		power:    true,
+		views:    true,
+		prefetch: true,
+	}
+}
+
+// cd changes directory
+func (n *Navigator) cd(target string) error {
+	if target == "" {
+		target = "~"
+	}
+
+	// cd - returns to the previous directory, cd -N to the Nth most recent
+	recent, err := n.recentDir(target)
+	if err != nil {
+		return err
+	}
+	if recent != "" {
+		fmt.Println(recent)
+		target = recent
+	}
+
+	// Expand ~ prefix to Redfish root
+	if target == "~" {
+		target = n.vfs.Root()
+	} else if strings.HasPrefix(target, "~/") {
+		target = n.vfs.Root() + "/" + target[2:]
+	}
+
+	resolvedTarget, err := n.vfs.ResolveTarget(n.cwd, target)
+	if err != nil {
+		return err
+	}
+	if resolvedTarget.ViaURIString && !n.uriLinks {
+		return fmt.Errorf("%s follows a URI string; use open (or set urilinks on)", target)
+	}
+
+	switch resolvedTarget.Type {
+	case rvfs.TargetResource:
+		n.chdir(resolvedTarget.ResourcePath)
+
+	case rvfs.TargetLink:
+		n.chdir(resolvedTarget.ResourcePath)
+
+	case rvfs.TargetProperty:
+		switch resolvedTarget.Property.Type {
+		case rvfs.PropertyObject, rvfs.PropertyArray:
+			// Navigate into property — compose the full path
+			n.chdir(n.vfs.Join(n.cwd, target))
+		default:
+			return fmt.Errorf("cannot cd to value: %s", target)
+		}
+	}
+
+	entries := n.listResolved(resolvedTarget)
+	fmt.Println(n.summaryLine(entries))
+	return nil
+}
+
+// maxRecentDirs bounds the directories cd -N can return to
+const maxRecentDirs = 10
+
+// chdir makes path the current directory, remembering the one left
+func (n *Navigator) chdir(path string) {
+	if path == n.cwd {
+		return
+	}
+	recent := []string{n.cwd}
+	for _, dir := range n.recent {
+		if dir != n.cwd && dir != path && len(recent) < maxRecentDirs {
+			recent = append(recent, dir)
+		}
+	}
+	n.recent = recent
+	n.cwd = path
+}
+
+// recentDir returns the directory a "-" (the previous one) or "-N" (the Nth
+// most recent) target names, or "" for any other target
+func (n *Navigator) recentDir(target string) (string, error) {
+	if target == "-" {
+		target = "-1"
+	}
+	num, ok := strings.CutPrefix(target, "-")
+	if !ok {
+		return "", nil
+	}
+	i, err := strconv.Atoi(num)
+	if err != nil || i < 1 {
+		return "", nil
+	}
+	switch {
+	case len(n.recent) == 0:
+		return "", fmt.Errorf("no previous directory")
+	case i > len(n.recent):
+		return "", fmt.Errorf("only %d recent directories", len(n.recent))
+	}
+	return n.recent[i-1], nil
+}
+
+// pushd changes to target, saving the current directory on the stack.
+// Without a target it exchanges the current directory with the top of the
+// stack.
+func (n *Navigator) pushd(target string) error {
+	from := n.cwd
+	if target == "" {
+		if len(n.dirStack) == 0 {
+			return fmt.Errorf("no other directory")
+		}
+		if err := n.cd(n.dirStack[0]); err != nil {
+			return err
+		}
+		n.dirStack[0] = from
+	} else {
+		if err := n.cd(target); err != nil {
+			return err
+		}
+		n.dirStack = append([]string{from}, n.dirStack...)
+	}
+	n.dirs()
+	return nil
+}
+
+// popd returns to the directory on top of the stack, removing it
+func (n *Navigator) popd() error {
+	if len(n.dirStack) == 0 {
+		return fmt.Errorf("directory stack empty")
+	}
+	if err := n.cd(n.dirStack[0]); err != nil {
+		return err
+	}
+	n.dirStack = n.dirStack[1:]
+	n.dirs()
+	return nil
+}
+
+// dirs prints the directory stack, numbered from the current directory
+func (n *Navigator) dirs() {
+	for i, dir := range append([]string{n.cwd}, n.dirStack...) {
+		fmt.Printf("%s  %s\n", dimStyle.Render(fmt.Sprintf("%2d", i)), dir)
+	}
+}
+
+// open follows links to their canonical destinations (always canonicalizes
+// PropertyLinks). Targets carrying an OriginOfCondition open its resource.
+func (n *Navigator) open(target string) error {
+	if target == "" {
+		return fmt.Errorf("open requires a target path")
+	}
+
+	// Resolve the target
+	resolvedTarget, err := n.vfs.ResolveTarget(n.cwd, target)
+	if err != nil {
+		// Special case: "open ." from a property path
+		if target == "." {
+			resolvedTarget, err = n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+			if err != nil {
+				return err
+			}
+		} else {
+			return err
+		}
+	}
+
+	// Log entries, event records and conditions open the resource they
+	// are about
+	if origin := rvfs.OriginOfCondition(resolvedTarget); origin != "" {
+		n.chdir(origin)
+		entries, _ := n.vfs.ListAll(n.cwd)
+		fmt.Println(n.summaryLine(entries))
+		return nil
+	}
+
+	switch resolvedTarget.Type {
+	case rvfs.TargetResource:
+		n.chdir(resolvedTarget.ResourcePath)
+		entries, _ := n.vfs.ListAll(n.cwd)
+		fmt.Println(n.summaryLine(entries))
+
+	case rvfs.TargetLink:
+		n.chdir(resolvedTarget.ResourcePath)
+		entries, _ := n.vfs.ListAll(n.cwd)
+		fmt.Println(n.summaryLine(entries))
+
+	case rvfs.TargetProperty:
+		prop := resolvedTarget.Property
+		if prop.Type == rvfs.PropertyLink {
+			// Follow the link
+			n.chdir(prop.LinkTarget)
+			entries, _ := n.vfs.ListAll(n.cwd)
+			fmt.Println(n.summaryLine(entries))
+		} else if target == "." {
+			// "open ." from a property path — navigate to containing resource
+			n.chdir(resolvedTarget.Resource.Path)
+			entries, _ := n.vfs.ListAll(n.cwd)
+			fmt.Println(n.summaryLine(entries))
+		} else {
+			return fmt.Errorf("cannot open property %s (not a link; use 'cd' to navigate into objects)", target)
+		}
+	}
+
+	return nil
+}
+
+// openPreview shows where open would go without going there: each link
+// it follows on the way and the resource it ends on
+func (n *Navigator) openPreview(target string) error {
+	if target == "" {
+		return fmt.Errorf("usage: open -n <path>")
+	}
+	resolved, chain, err := n.vfs.ResolveChain(n.cwd, target)
+	if err != nil {
+		return err
+	}
+	end, err := openDestination(resolved)
+	if err != nil {
+		return fmt.Errorf("cannot open property %s (%w)", target, err)
+	}
+	fmt.Print(formatChain(chain))
+	if origin := rvfs.OriginOfCondition(resolved); origin != "" {
+		fmt.Println(dimStyle.Render("OriginOfCondition") + linkStyle.Render(" → ") + origin)
+	}
+	fmt.Println(n.formatChainEnd(end))
+	return nil
+}
+
+// openDestination returns the resource open goes to from a target
+func openDestination(target *rvfs.Target) (string, error) {
+	if origin := rvfs.OriginOfCondition(target); origin != "" {
+		return origin, nil
+	}
+	switch {
+	case target.Type == rvfs.TargetResource || target.Type == rvfs.TargetLink:
+		return target.ResourcePath, nil
+	case target.Property.Type == rvfs.PropertyLink:
+		return target.Property.LinkTarget, nil
+	}
+	return "", fmt.Errorf("not a link; use 'cd' to navigate into objects")
+}
+
+// formatChain formats the links a path resolves through, a line per hop
+func formatChain(chain []rvfs.Hop) string {
+	var b strings.Builder
+	for _, hop := range chain {
+		b.WriteString(hop.From + linkStyle.Render(" → ") + hop.To)
+		if hop.URIString {
+			b.WriteString(dimStyle.Render(" (URI string)"))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// formatChainEnd formats the resource a chain of links ends on, with its
+// type, or why it cannot be reached
+func (n *Navigator) formatChainEnd(path string) string {
+	res, err := n.vfs.Get(path)
+	if err != nil {
+		return "= " + path + "  " + errorStyle.Render(err.Error())
+	}
+	return "= " + res.Path + "  " + dimStyle.Render(res.ODataType)
+}
+
+// ls lists entries (children + properties), filtered and ordered by opts
+func (n *Navigator) ls(opts rvfs.ListOptions, target string) error {
+	if target == "." {
+		target = ""
+	}
+
+	// Resolve the path
+	var resolved *rvfs.Target
+	var err error
+	if target == "" {
+		resolved, err = n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+	} else {
+		resolved, err = n.vfs.ResolveTarget(n.cwd, target)
+	}
+	if err != nil {
+		return err
+	}
+
+	entries := n.listResolved(resolved)
+	if opts.All && resolved.Type != rvfs.TargetProperty {
+		entries = append(rvfs.AnnotationEntries(resolved.Resource), entries...)
+	}
+	entries = rvfs.Arrange(entries, opts)
+	n.printShortListingAll(entries, n.numberMembers(resolved, entries))
+	if n.ages && len(entries) > 0 {
+		fmt.Println(ageLegend())
+	}
+	n.printResourceAge(resolved)
+	return nil
+}
+
+// numberMembers records the member entries of a collection listing, in
+// display order, for %N shortcuts and returns each member's number by name.
+// Any other listing clears the shortcuts.
+func (n *Navigator) numberMembers(target *rvfs.Target, entries []*rvfs.Entry) map[string]int {
+	n.members = nil
+	if target.Type == rvfs.TargetProperty {
+		return nil
+	}
+	resource, err := n.vfs.Get(target.ResourcePath)
+	if err != nil || !resource.IsCollection() {
+		return nil
+	}
+	numbers := make(map[string]int)
+	for _, entry := range entries {
+		if _, ok := resource.Children[entry.Name]; ok {
+			n.members = append(n.members, entry.Path)
+			numbers[entry.Name] = len(n.members)
+		}
+	}
+	return numbers
+}
+
+// expandMembers replaces %N arguments with the Nth member of the last
+// collection listing
+func (n *Navigator) expandMembers(args []string) ([]string, error) {
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		num, ok := strings.CutPrefix(arg, "%")
+		if !ok {
+			expanded[i] = arg
+			continue
+		}
+		idx, err := strconv.Atoi(num)
+		if err != nil || idx < 1 || idx > len(n.members) {
+			return nil, fmt.Errorf("no member %s in the last listing", arg)
+		}
+		expanded[i] = n.members[idx-1]
+	}
+	return expanded, nil
+}
+
+// entriesFromProperty creates Entry list from a property's children/elements
+func entriesFromProperty(target *rvfs.Target) []*rvfs.Entry {
+	prop := target.Property
+	var fetched time.Time
+	if target.Resource != nil {
+		fetched = target.Resource.FetchedAt
+	}
+	var entries []*rvfs.Entry
+
+	switch prop.Type {
+	case rvfs.PropertyObject:
+		for name, child := range prop.Children {
+			// A link array is listed link by link, each with where it leads
+			if child.IsLinkArray() {
+				for _, elem := range child.Elements {
+					entries = append(entries, propertyEntry(name+elem.Name, elem, fetched))
+				}
+				continue
+			}
+			entries = append(entries, propertyEntry(name, child, fetched))
+		}
+	case rvfs.PropertyArray:
+		for _, elem := range prop.Elements {
+			entries = append(entries, propertyEntry(elem.Name, elem, fetched))
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// propertyEntry is the entry listing a property under name
+func propertyEntry(name string, prop *rvfs.Property, fetched time.Time) *rvfs.Entry {
+	return &rvfs.Entry{
+		Name:       name,
+		Path:       prop.LinkTarget,
+		Type:       entryTypeForProperty(prop),
+		Size:       int64(len(prop.RawJSON)),
+		Modified:   fetched,
+		URIString:  prop.URIString,
+		LinkTarget: prop.LinkTarget,
+	}
+}
+
+// entryTypeForProperty maps property types to entry types
+func entryTypeForProperty(prop *rvfs.Property) rvfs.EntryType {
+	switch prop.Type {
+	case rvfs.PropertyObject:
+		return rvfs.EntryComplex
+	case rvfs.PropertyArray:
+		return rvfs.EntryArray
+	case rvfs.PropertyLink:
+		return rvfs.EntrySymlink
+	default:
+		return rvfs.EntryProperty
+	}
+}
+
+// listResolved returns entries for any resolved target
+func (n *Navigator) listResolved(target *rvfs.Target) []*rvfs.Entry {
+	switch target.Type {
+	case rvfs.TargetResource, rvfs.TargetLink:
+		entries, _ := n.vfs.ListAll(target.ResourcePath)
+		return entries
+	case rvfs.TargetProperty:
+		return entriesFromProperty(target)
+	}
+	return nil
+}
+
+// dump displays raw JSON, or writes it to a file with -o. JSON past
+// dumpPageBytes is paged, reading keys from in.
+func (n *Navigator) dump(in *bufio.Reader, args []string) error {
+	opts, target, output, err := parseDumpArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// Resolve the path
+	var resolved *rvfs.Target
+	if target == "" {
+		resolved, err = n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+	} else {
+		resolved, err = n.vfs.ResolveTarget(n.cwd, target)
+	}
+	if err != nil {
+		return err
+	}
+
+	var raw []byte
+	switch resolved.Type {
+	case rvfs.TargetResource, rvfs.TargetLink:
+		raw = n.redacted("", resolved.Resource.RawJSON)
+	case rvfs.TargetProperty:
+		raw = n.redacted(resolved.Property.Name, resolved.Property.RawJSON)
+	}
+	if len(raw) == 0 {
+		return nil
+	}
+	if output != "" {
+		size, err := writeJSON(output, raw, opts)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s (%d bytes)\n", output, size)
+		return nil
+	}
+	out, err := activeTheme.RenderJSON(raw, opts)
+	if err != nil {
+		return err
+	}
+	if len(out) > dumpPageBytes {
+		if height := pageHeight(); height > 0 {
+			fmt.Println(warnStyle.Render(fmt.Sprintf("%s of JSON; paging (--fields or -n N trims it, -o FILE writes it out)", formatBytes(int64(len(out))))))
+			page(in, os.Stdout, out, height)
+			return nil
+		}
+	}
+	fmt.Println(out)
+	return nil
+}
+
+// writeJSON writes JSON to a file, rendered uncolored, and returns its size
+func writeJSON(filename string, raw []byte, opts theme.JSONOptions) (int, error) {
+	var plain *theme.Theme
+	out, err := plain.RenderJSON(raw, opts)
+	if err != nil {
+		return 0, err
+	}
+	data := []byte(out + "\n")
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// parseDumpArgs splits dump arguments into render options, the target and
+// the file to write to: -c for compact output, -n N to show at most N
+// elements of each array, --fields A,B to show only those top-level
+// members, -o FILE to write the JSON to FILE
+func parseDumpArgs(args []string) (opts theme.JSONOptions, target, output string, err error) {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-c":
+			opts.Compact = true
+		case "-n":
+			if i+1 >= len(args) {
+				return opts, "", "", fmt.Errorf("dump: -n needs a count")
+			}
+			i++
+			count, err := strconv.Atoi(args[i])
+			if err != nil || count < 1 {
+				return opts, "", "", fmt.Errorf("dump: invalid count %q", args[i])
+			}
+			opts.MaxElements = count
+		case "--fields":
+			if i+1 >= len(args) {
+				return opts, "", "", fmt.Errorf("dump: --fields needs member names")
+			}
+			i++
+			for _, field := range strings.Split(args[i], ",") {
+				if field = strings.TrimSpace(field); field != "" {
+					opts.Fields = append(opts.Fields, field)
+				}
+			}
+			if len(opts.Fields) == 0 {
+				return opts, "", "", fmt.Errorf("dump: --fields needs member names")
+			}
+		case "-o":
+			if i+1 >= len(args) {
+				return opts, "", "", fmt.Errorf("dump: -o needs a file")
+			}
+			i++
+			output = args[i]
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	return opts, rvfs.TargetArg(rest), output, nil
+}
+
+// cat prints the raw value of a property and nothing else, for scripts
+func (n *Navigator) cat(target string) error {
+	resolved, err := n.vfs.ResolveTarget(n.cwd, target)
+	if err != nil {
+		return err
+	}
+	if resolved.Type != rvfs.TargetProperty {
+		return fmt.Errorf("not a property: %s (use dump)", target)
+	}
+	fmt.Println(resolved.Property.Text())
+	return nil
+}
+
+// ll displays formatted content using parsed structure
+func (n *Navigator) ll(target string) error {
+	if target == "." {
+		target = ""
+	}
+
+	// Resolve the path
+	var resolved *rvfs.Target
+	var err error
+	if target == "" {
+		resolved, err = n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+	} else {
+		resolved, err = n.vfs.ResolveTarget(n.cwd, target)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch resolved.Type {
+	case rvfs.TargetResource, rvfs.TargetLink:
+		if err := n.showResource(resolved.ResourcePath); err != nil {
+			return err
+		}
+		n.printResourceAge(resolved)
+		if n.prefetch {
+			n.prefetcher.Warm(n.vfs, n.vfs.Cached(resolved.ResourcePath))
+		}
+	case rvfs.TargetProperty:
+		// An array named directly (or sliced) is shown in full
+		path := n.cwd
+		if target != "" {
+			path = n.vfs.Join(n.cwd, target)
+		}
+		if resolved.Property.Type == rvfs.PropertyArray {
+			path = ""
+		}
+		n.showProperty(resolved.Property, 0, false, path)
+	}
+	return nil
+}
+
+// showResource displays a resource in formatted style
+func (n *Navigator) showResource(path string) error {
+	resource, err := n.vfs.Get(path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	if resource.HasActions() {
+		fmt.Println(boldStyle.Render(path) + " " + actionMark())
+	} else {
+		fmt.Println(boldStyle.Render(path))
+	}
+	if resource.ODataType != "" {
+		fmt.Printf("Type: %s\n", resource.ODataType)
+	}
+	if resource.IsPartial() {
+		fmt.Println(dimStyle.Render("Partial view (" + resource.Query + ")"))
+	}
+
+	// Show conditions first: they are why a resource is unhealthy
+	if len(resource.Conditions) > 0 {
+		fmt.Println("\nConditions:")
+		for _, c := range resource.Conditions {
+			fmt.Println(n.formatCondition(c, 2))
+		}
+	}
+
+	if resource.Capabilities != nil {
+		fmt.Println("\nCreate:")
+		fmt.Print(n.formatCapabilities(resource))
+	}
+
+	// Show service messages (ExtendedInfo)
+	if len(resource.Messages) > 0 {
+		fmt.Println("\nMessages:")
+		for _, m := range resource.Messages {
+			fmt.Println(formatMessage(m, 2))
+		}
+	}
+
+	// Show the view of the resource's type, else its properties (sorted
+	// for deterministic output)
+	if view, ok := n.view(resource); ok {
+		fmt.Println()
+		fmt.Print(view)
+	} else if len(resource.Properties) > 0 {
+		fmt.Println("\nProperties:")
+
+		// Sort property names
+		propNames := make([]string, 0, len(resource.Properties))
+		for name := range resource.Properties {
+			propNames = append(propNames, name)
+		}
+		sort.Strings(propNames)
+
+		for _, name := range propNames {
+			prop := resource.Properties[name]
+			n.showProperty(prop, 2, false, n.vfs.Join(path, name))
+		}
+	}
+
+	// Show children (sorted for deterministic output)
+	if len(resource.Children) > 0 {
+		fmt.Println("\nChildren:")
+
+		// Sort child names
+		childNames := make([]string, 0, len(resource.Children))
+		for name := range resource.Children {
+			childNames = append(childNames, name)
+		}
+		sort.Strings(childNames)
+
+		for _, name := range childNames {
+			child := resource.Children[name]
+			if child.Type == rvfs.ChildLink && n.vfs.Cached(child.Target).HasActions() {
+				fmt.Printf("  %s%s → %s\n", childStyle.Render(name+"/"), actionMark(), child.Target)
+			} else if child.Type == rvfs.ChildLink {
+				fmt.Printf("  %s → %s\n", childStyle.Render(name+"/"), child.Target)
+			} else {
+				fmt.Printf("  %s → %s\n", linkStyle.Render(name+"@"), child.Target)
+			}
+		}
+	}
+
+	return nil
+}
+
+// view renders a resource by the view registered for its type, when views
+// are on and there is one
+func (n *Navigator) view(res *rvfs.Resource) (string, bool) {
+	if !n.views {
+		return "", false
+	}
+	return display.Render(&display.Context{Theme: activeTheme, Redacts: n.redacts}, res)
+}
+
+// arraySummaryLimit is the number of elements ll shows of an array nested in
+// the output; the rest are summarized with a slice to page through them
+const arraySummaryLimit = 20
+
+// showProperty displays a property in formatted style with indentation (YAML-style)
+// indent is the indentation level for this property itself
+// isArrayElement indicates this property is the first field of an array element object (suppress indent)
+// path is the property's full path, used to summarize large arrays; "" shows arrays in full
+func (n *Navigator) showProperty(prop *rvfs.Property, indent int, isArrayElement bool, path string) {
+	var propertyIndent string
+	if isArrayElement {
+		propertyIndent = "" // No indent for first field of array element (inline with dash)
+	} else {
+		propertyIndent = strings.Repeat(" ", indent)
+	}
+	childIndent := strings.Repeat(" ", indent+2)
+
+	switch prop.Type {
+	case rvfs.PropertySimple:
+		// Print property name and simple value inline with health-semantic coloring
+		fmt.Printf("%s%s: %s\n", propertyIndent, propStyle.Render(prop.Name), n.formatValue(prop.Name, prop.Value))
+
+	case rvfs.PropertyLink:
+		// Print property name and link target
+		fmt.Printf("%s%s: %s → %s\n", propertyIndent, propStyle.Render(prop.Name), linkStyle.Render(linkKind(prop)), prop.LinkTarget)
+
+	case rvfs.PropertyObject:
+		// Print property name with field count badge
+		fmt.Printf("%s%s:", propertyIndent, propStyle.Render(prop.Name))
+
+		// Object - show nested fields with indentation (YAML-style)
+		if len(prop.Children) == 0 {
+			// Empty object
+			fmt.Printf(" %s\n", dimStyle.Render("{}"))
+		} else {
+			fmt.Printf(" %s\n", dimStyle.Render(fmt.Sprintf("{%d}", len(prop.Children))))
+
+			// Sort keys for deterministic output
+			keys := make([]string, 0, len(prop.Children))
+			for name := range prop.Children {
+				keys = append(keys, name)
+			}
+			sort.Strings(keys)
+
+			// Print fields
+			for _, name := range keys {
+				child := prop.Children[name]
+				n.showProperty(child, indent+2, false, n.fieldPath(path, name))
+			}
+		}
+
+	case rvfs.PropertyArray:
+		// Print property name with element count badge
+		fmt.Printf("%s%s:", propertyIndent, propStyle.Render(prop.Name))
+
+		// Array - show elements with YAML-style list markers
+		if len(prop.Elements) == 0 {
+			// Empty array
+			fmt.Printf(" %s\n", dimStyle.Render("[]"))
+		} else {
+			fmt.Printf(" %s\n", dimStyle.Render(fmt.Sprintf("[%d]", len(prop.Elements))))
+			shown := prop.Elements
+			if path != "" && len(shown) > arraySummaryLimit {
+				shown = shown[:arraySummaryLimit]
+			}
+			// Print each element with dash marker
+			for _, elem := range shown {
+				elemPath := ""
+				if path != "" {
+					elemPath = path + elem.Name
+				}
+				// For array elements, we need special handling for objects
+				if elem.Type == rvfs.PropertyObject && len(elem.Children) > 0 {
+					// Print dash at child indent level
+					fmt.Printf("%s- ", childIndent)
+
+					// Print first field inline with dash, rest indented
+					keys := make([]string, 0, len(elem.Children))
+					for name := range elem.Children {
+						keys = append(keys, name)
+					}
+					sort.Strings(keys)
+
+					for i, name := range keys {
+						child := elem.Children[name]
+						if i == 0 {
+							// First field inline with dash (at childIndent level, but suppress indent)
+							n.showProperty(child, indent+4, true, n.fieldPath(elemPath, name))
+						} else {
+							// Subsequent fields indented to align with first field
+							n.showProperty(child, indent+4, false, n.fieldPath(elemPath, name))
+						}
+					}
+				} else {
+					// Simple element or empty object - show inline
+					fmt.Printf("%s- ", childIndent)
+					switch elem.Type {
+					case rvfs.PropertySimple:
+						if n.redacts(prop.Name) {
+							fmt.Println(dimStyle.Render(rvfs.RedactMask))
+						} else {
+							fmt.Println(formatTypedValue(elem.Value))
+						}
+					case rvfs.PropertyObject:
+						fmt.Println(dimStyle.Render("{}"))
+					case rvfs.PropertyLink:
+						fmt.Printf("%s → %s\n", linkStyle.Render(linkKind(elem)), elem.LinkTarget)
+					}
+				}
+			}
+			if hidden := len(prop.Elements) - len(shown); hidden > 0 {
+				fmt.Printf("%s%s\n", childIndent, dimStyle.Render(fmt.Sprintf("…%d more (use ll %s[%d:] to page)", hidden, path, len(shown))))
+			}
+		}
+	}
+}
+
+// fieldPath returns the path of a field below path, or "" when path is unknown
+func (n *Navigator) fieldPath(path, name string) string {
+	if path == "" {
+		return ""
+	}
+	return n.vfs.Join(path, name)
+}
+
+// formatValue renders a simple property value, humanized when enabled
+func (n *Navigator) formatValue(name string, value any) string {
+	if value != nil && n.redacts(name) {
+		return dimStyle.Render(rvfs.RedactMask)
+	}
+	if n.humanize {
+		if s, ok := rvfs.Humanize(name, value, time.Now()); ok {
+			if _, isNumber := value.(float64); isNumber {
+				return numberValStyle.Render(s)
+			}
+			return stringValStyle.Render(s)
+		}
+	}
+	return formatHealthValue(name, value)
+}
+
+// formatHealthValue renders health/state values with semantic colors, other values with type colors
+func formatHealthValue(name string, value any) string {
+	if healthKeys[name] {
+		s, ok := value.(string)
+		if ok {
+			upper := strings.ToUpper(s)
+			switch {
+			case upper == "OK" || upper == "ENABLED" || upper == "UP":
+				return healthOKStyle.Render(s)
+			case upper == "WARNING" || upper == "STANDBYOFFLINE" || upper == "STARTING":
+				return healthWarnStyle.Render(s)
+			case upper == "CRITICAL" || upper == "DISABLED" || upper == "ABSENT":
+				return healthCriticalStyle.Render(s)
+			}
+		}
+	}
+	return formatTypedValue(value)
+}
+
+// formatTypedValue formats a value with type-appropriate coloring
+func formatTypedValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return stringValStyle.Render(v)
+	case bool:
+		if v {
+			return trueValStyle.Render("true")
+		}
+		return falseValStyle.Render("false")
+	case float64:
+		if v == float64(int64(v)) {
+			return numberValStyle.Render(fmt.Sprintf("%d", int64(v)))
+		}
+		return numberValStyle.Render(fmt.Sprintf("%g", v))
+	case nil:
+		return nullValStyle.Render("null")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// printResourceAge prints the age of a resolved target's resource
+func (n *Navigator) printResourceAge(target *rvfs.Target) {
+	var fetchedAt time.Time
+	switch target.Type {
+	case rvfs.TargetResource, rvfs.TargetLink:
+		if target.Resource != nil {
+			fetchedAt = target.Resource.FetchedAt
+		}
+	case rvfs.TargetProperty:
+		if target.Resource != nil {
+			fetchedAt = target.Resource.FetchedAt
+		}
+	}
+	if fetchedAt.IsZero() {
+		return
+	}
+	fmt.Println(dimStyle.Render(formatAge(fetchedAt)))
+}
+
+func formatAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+// statsSlowest is the number of slowest requests shown by stats
+const statsSlowest = 10
+
+// formatStats renders cumulative request statistics
+func formatStats(s rvfs.Summary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s %d HTTP, %s, %s total",
+		boldStyle.Render("Requests:"), s.Requests, formatBytes(s.Bytes), s.HTTPTime.Round(time.Millisecond))
+	if s.Errors > 0 {
+		b.WriteString(", " + errorStyle.Render(fmt.Sprintf("%d errors", s.Errors)))
+	}
+	fmt.Fprintf(&b, "\n%s    %d hits, %d misses (%.1f%% hit ratio)\n",
+		boldStyle.Render("Cache:"), s.Hits, s.Misses, s.HitRatio()*100)
+
+	if len(s.Endpoints) > 0 {
+		b.WriteString("\nEndpoints (by average latency):\n")
+		for _, e := range s.Endpoints {
+			fmt.Fprintf(&b, "  %-36s %5d req  avg %s  max %s  %s\n",
+				e.Endpoint, e.Requests,
+				warnStyle.Render(fmt.Sprintf("%7s", e.Average().Round(time.Millisecond))),
+				fmt.Sprintf("%7s", e.Max.Round(time.Millisecond)),
+				dimStyle.Render(formatBytes(e.Bytes)))
+		}
+	}
+
+	if len(s.Slowest) > 0 {
+		b.WriteString("\nSlowest paths:\n")
+		for _, r := range s.Slowest[:min(len(s.Slowest), statsSlowest)] {
+			fmt.Fprintf(&b, "  %s  %-4s %s %s\n",
+				warnStyle.Render(fmt.Sprintf("%7s", r.Duration.Round(time.Millisecond))),
+				r.Method, r.Path, formatStatus(r.Status))
+		}
+	}
+	return b.String()
+}
+
+// formatTiming renders the wall and HTTP time of a single command, from
+// its requests still kept; dropped counts those no longer kept
+func formatTiming(elapsed time.Duration, requests []rvfs.Request, dropped int) string {
+	s := rvfs.Summarize(requests)
+	line := fmt.Sprintf("real %s  http %s  (%d requests, %d cached, %s",
+		elapsed.Round(time.Millisecond), s.HTTPTime.Round(time.Millisecond),
+		s.Requests, s.Hits, formatBytes(s.Bytes))
+	if dropped > 0 {
+		line += fmt.Sprintf("; %d earlier not kept", dropped)
+	}
+	return dimStyle.Render(line + ")")
+}
+
+// setTrace switches request tracing on or off, or reports its state
+func (n *Navigator) setTrace(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "on":
+			n.trace = true
+		case "off":
+			n.trace = false
+		default:
+			return fmt.Errorf("usage: trace [on|off]")
+		}
+	}
+	if n.trace {
+		fmt.Println("Trace on")
+	} else {
+		fmt.Println("Trace off")
+	}
+	return nil
+}
+
+// setRedact switches redaction on or off, or reports its state
+func (n *Navigator) setRedact(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "on":
+			n.redact = true
+		case "off":
+			n.redact = false
+		default:
+			return fmt.Errorf("usage: redact [on|off]")
+		}
+	}
+	switch {
+	case !n.redact:
+		fmt.Println("Redaction off")
+	case !n.redaction.Active():
+		fmt.Println("Redaction on, but the config names no properties (redact.properties)")
+	default:
+		fmt.Printf("Redaction on: %s\n", strings.Join(n.redaction.Properties, ", "))
+	}
+	return nil
+}
+
+// redacts reports whether the values of properties named name are masked
+func (n *Navigator) redacts(name string) bool {
+	return n.redact && n.redaction.Redacts(name)
+}
+
+// redacted returns the JSON value of the property named name ("" for a
+// resource) with the redacted values in it masked, when redaction is on
+func (n *Navigator) redacted(name string, raw []byte) []byte {
+	if !n.redact {
+		return raw
+	}
+	return n.redaction.Redact(name, raw)
+}
+
+// settingNames are the settings set changes, in listing order
+var settingNames = []string{"humanize", "ages", "notify", "urilinks", "powerwatch", "views", "prefetch"}
+
+// toggles returns the settings set changes by name
+func (n *Navigator) toggles() map[string]*bool {
+	return map[string]*bool{"humanize": &n.humanize, "ages": &n.ages, "notify": &n.notify, "urilinks": &n.uriLinks, "powerwatch": &n.power, "views": &n.views, "prefetch": &n.prefetch}
+}
+
+// set changes a setting, or lists the settings without arguments
+func (n *Navigator) set(args []string) error {
+	settings := n.toggles()
+	if len(args) > 0 {
+		setting, ok := settings[args[0]]
+		if len(args) != 2 || !ok {
+			return fmt.Errorf("usage: set [humanize|ages|notify|urilinks|powerwatch|views|prefetch on|off]")
+		}
+		switch args[1] {
+		case "on":
+			*setting = true
+		case "off":
+			*setting = false
+		default:
+			return fmt.Errorf("usage: set [humanize|ages|notify|urilinks|powerwatch|views|prefetch on|off]")
+		}
+	}
+	for _, name := range settingNames {
+		if *settings[name] {
+			fmt.Println(name + " on")
+		} else {
+			fmt.Println(name + " off")
+		}
+	}
+	return nil
+}
+
+// formatTrace renders the requests a command caused, one per line, after
+// a count of the dropped ones no longer kept. Consecutive cache hits on the
+// same path are collapsed into one line.
+func formatTrace(requests []rvfs.Request, dropped int) string {
+	var lines []string
+	if dropped > 0 {
+		lines = append(lines, dimStyle.Render(fmt.Sprintf("  (%d earlier requests not kept)", dropped)))
+	}
+	for i := 0; i < len(requests); {
+		r := requests[i]
+		n := 1
+		for r.Cached && i+n < len(requests) && requests[i+n].Cached && requests[i+n].Path == r.Path {
+			n++
+		}
+		i += n
+
+		if r.Cached {
+			line := fmt.Sprintf("  %-4s %3s %7s  hit   %s", r.Method, "-", "-", r.Path)
+			if n > 1 {
+				line += fmt.Sprintf(" (×%d)", n)
+			}
+			lines = append(lines, dimStyle.Render(line))
+			continue
+		}
+		cache := "miss"
+		if r.Method != "GET" {
+			cache = ""
+		}
+		lines = append(lines, fmt.Sprintf("  %-4s %s %s  %-4s  %s",
+			r.Method, formatStatus(r.Status),
+			warnStyle.Render(fmt.Sprintf("%7s", r.Duration.Round(time.Millisecond))),
+			cache, r.Path))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatWarnings renders the parser warnings of the resources a command
+// fetched, one per line
+func formatWarnings(requests []rvfs.Request) string {
+	var lines []string
+	for _, r := range requests {
+		for _, w := range r.Warnings {
+			lines = append(lines, healthWarnStyle.Render(fmt.Sprintf("warning: %s: %s", r.Path, w)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatStatus renders an HTTP status, 0 meaning the request never completed
+func formatStatus(status int) string {
+	switch {
+	case status == 0:
+		return errorStyle.Render("failed")
+	case status >= 200 && status < 300:
+		return dimStyle.Render(strconv.Itoa(status))
+	default:
+		return errorStyle.Render(strconv.Itoa(status))
+	}
+}
+
+// formatBytes renders a byte count with a binary unit
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// tree displays tree view
+func (n *Navigator) tree(depth int) error {
+	resolved, err := n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+	if err != nil {
+		return err
+	}
+
+	var entries []*rvfs.Entry
+	switch resolved.Type {
+	case rvfs.TargetResource, rvfs.TargetLink:
+		entries, _ = n.vfs.ListAll(resolved.ResourcePath)
+	case rvfs.TargetProperty:
+		entries = entriesFromProperty(resolved)
+	}
+
+	output := n.buildTreeFromEntries(n.cwd, entries, "", depth, 0)
+	if output == "" {
+		fmt.Println("(empty)")
+	} else {
+		fmt.Println(output)
+		if n.ages {
+			fmt.Println(ageLegend())
+		}
+	}
+	return nil
+}
+
+func (n *Navigator) buildTreeFromEntries(basePath string, entries []*rvfs.Entry, prefix string, maxDepth, currentDepth int) string {
+	if currentDepth >= maxDepth {
+		return ""
+	}
+
+	var lines []string
+	for i, entry := range entries {
+		isLast := i == len(entries)-1
+
+		connector := "├── "
+		if isLast {
+			connector = "└── "
+		}
+
+		line := prefix + connector + n.formatAgedEntry(entry)
+		lines = append(lines, line)
+
+		// Recurse for directories
+		if entry.IsDir() && currentDepth+1 < maxDepth {
+			extension := "│   "
+			if isLast {
+				extension = "    "
+			}
+
+			// Resolve child to get its entries
+			childPath := entry.Path
+			if childPath == "" {
+				childPath = n.vfs.Join(basePath, entry.Name)
+			}
+
+			resolved, err := n.vfs.ResolveTarget(n.vfs.Root(), childPath)
+			if err != nil {
+				// Show a refusal found on the way down on the child's line
+				var forbidden *rvfs.ForbiddenError
+				if errors.As(err, &forbidden) {
+					entry.Denied = true
+					lines[len(lines)-1] = prefix + connector + n.formatAgedEntry(entry)
+				}
+				continue
+			}
+
+			var childEntries []*rvfs.Entry
+			switch resolved.Type {
+			case rvfs.TargetResource, rvfs.TargetLink:
+				childEntries, _ = n.vfs.ListAll(resolved.ResourcePath)
+			case rvfs.TargetProperty:
+				childEntries = entriesFromProperty(resolved)
+			}
+
+			subtree := n.buildTreeFromEntries(childPath, childEntries, prefix+extension, maxDepth, currentDepth+1)
+			if subtree != "" {
+				lines = append(lines, subtree)
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// find searches for properties recursively
+func (n *Navigator) find(pattern string) error {
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %v", err)
+	}
+
+	resolved, err := n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+	if err != nil {
+		return err
+	}
+
+	var results []string
+
+	switch resolved.Type {
+	case rvfs.TargetResource, rvfs.TargetLink:
+		n.findInResource(resolved.ResourcePath, "", re, &results, 0)
+	case rvfs.TargetProperty:
+		findInProperty(resolved.Property, "", re, &results)
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("No matches found for '%s'\n", pattern)
+	} else {
+		for _, result := range results {
+			fmt.Println(result)
+		}
+	}
+
+	return nil
+}
+
+func (n *Navigator) findInResource(resourcePath, prefix string, re *regexp.Regexp, results *[]string, depth int) {
+	if depth > 5 {
+		return
+	}
+
+	resource, err := n.vfs.Get(resourcePath)
+	if err != nil {
+		return
+	}
+
+	// Search all properties in this resource
+	for _, prop := range resource.Properties {
+		findInProperty(prop, prefix, re, results)
+	}
+
+	// Recurse into child resources
+	for _, child := range resource.Children {
+		childPrefix := child.Name
+		if prefix != "" {
+			childPrefix = prefix + "/" + child.Name
+		}
+		n.findInResource(child.Target, childPrefix, re, results, depth+1)
+	}
+}
+
+func findInProperty(prop *rvfs.Property, prefix string, re *regexp.Regexp, results *[]string) {
+	fullPath := prop.Name
+	if prefix != "" {
+		fullPath = prefix + "/" + prop.Name
+	}
+
+	if re.MatchString(prop.Name) {
+		*results = append(*results,
+			fmt.Sprintf("%s = %s",
+				warnStyle.Render(fullPath),
+				formatPropertyValue(prop)))
+	}
+
+	// Recurse into children
+	switch prop.Type {
+	case rvfs.PropertyObject:
+		for _, child := range prop.Children {
+			findInProperty(child, fullPath, re, results)
+		}
+	case rvfs.PropertyArray:
+		for _, elem := range prop.Elements {
+			findInProperty(elem, fullPath, re, results)
+		}
+	}
+}
+
+// findCached searches the property names of the cached resources at or
+// below the current resource, without fetching
+func (n *Navigator) findCached(pattern string) error {
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %v", err)
+	}
+	resolved, err := n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+	if err != nil {
+		return err
+	}
+	printMatches(n.vfs.FindCached(resolved.ResourcePath, re), pattern)
+	return nil
+}
+
+// findActions lists the actions of the resources at and below the current
+// one whose names match pattern, with their parameters: a catalog of what
+// can be invoked. cached walks only the cached resources; otherwise the
+// walk fetches, and Ctrl+C ends it.
+func (n *Navigator) findActions(pattern string, cached bool) error {
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %v", err)
+	}
+	resolved, err := n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+	if err != nil {
+		return err
+	}
+	start := resolved.ResourcePath
+	if resolved.Type == rvfs.TargetProperty {
+		start = resolved.Resource.Path
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	var r rvfs.Reader = rvfs.WithContext(ctx, n.vfs)
+	if cached {
+		r = rvfs.CacheReader{Cache: n.vfs}
+	}
+
+	found, resources := 0, 0
+	rvfs.Walk(r, start, func(_ string, res *rvfs.Resource, err error) bool {
+		if err != nil {
+			return ctx.Err() == nil
+		}
+		actions := slices.DeleteFunc(rvfs.ResourceActions(res, r), func(a rvfs.Action) bool {
+			return !re.MatchString(a.Name)
+		})
+		if len(actions) > 0 {
+			fmt.Print(formatActionCatalog(res.Path, actions))
+			found += len(actions)
+			resources++
+		}
+		return ctx.Err() == nil
+	})
+
+	switch {
+	case ctx.Err() != nil:
+		fmt.Printf("Interrupted: %d actions on %d resources so far\n", found, resources)
+	case found == 0 && cached:
+		fmt.Println("No actions found in the cache")
+	case found == 0:
+		fmt.Println("No actions found")
+	default:
+		fmt.Printf("%d actions on %d resources\n", found, resources)
+	}
+	return nil
+}
+
+// formatActionCatalog lists the actions of a resource under its path, each
+// with its target and parameters
+func formatActionCatalog(path string, actions []rvfs.Action) string {
+	var b strings.Builder
+	b.WriteString(childStyle.Render(path) + "\n")
+	for _, a := range actions {
+		fmt.Fprintf(&b, "  %s → %s\n", errorStyle.Render(a.Name), a.Target)
+		for _, p := range a.Parameters {
+			line := "    " + warnStyle.Render(p.Name)
+			if p.Required {
+				line += errorStyle.Render(" (required)")
+			}
+			if p.DataType != "" {
+				line += "  " + p.DataType
+			}
+			if len(p.Allowable) > 0 {
+				line += "  [" + strings.Join(p.Allowable, "|") + "]"
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// grep searches the property values of the cached resources at or below
+// the current resource, without fetching
+func (n *Navigator) grep(text string) error {
+	resolved, err := n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+	if err != nil {
+		return err
+	}
+	printMatches(n.vfs.GrepCached(resolved.ResourcePath, text), text)
+	return nil
+}
+
+// printMatches lists cache search matches under the resource holding them
+func printMatches(matches []rvfs.Match, query string) {
+	if len(matches) == 0 {
+		fmt.Printf("No matches found for '%s' in the cache\n", query)
+		return
+	}
+	resource := ""
+	for _, m := range matches {
+		if m.Resource != resource {
+			resource = m.Resource
+			fmt.Println(childStyle.Render(resource))
+		}
+		fmt.Printf("  %s = %s\n", warnStyle.Render(m.Property), formatPropertyValue(m.Value))
+	}
+}
+
+// scrape crawls all reachable resources from the current directory
+func (n *Navigator) scrape() error {
+	start := time.Now()
+
+	// Register signal listener for ^C cancellation
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	defer signal.Stop(sig)
+
+	// Build set of already cached paths
+	cached := make(map[string]bool)
+	for _, p := range n.vfs.GetKnownPaths() {
+		cached[p] = true
+	}
+
+	// BFS from cwd to discover uncached frontiers
+	visited := make(map[string]bool)
+	frontier := []string{n.cwd}
+	var queue []string
+	skipped := 0
+
+	for len(frontier) > 0 {
+		path := frontier[0]
+		frontier = frontier[1:]
+		if visited[path] {
+			continue
+		}
+		visited[path] = true
+
+		if n.scrapePolicy.Skipped(path) {
+			skipped++
+			continue
+		}
+		if !cached[path] {
+			queue = append(queue, path)
+			continue // Can't inspect children of uncached resources yet
+		}
+
+		res, err := n.vfs.Get(path)
+		if err != nil {
+			continue
+		}
+		for _, child := range res.Children {
+			if !visited[child.Target] {
+				frontier = append(frontier, child.Target)
+			}
+		}
+	}
+
+	if len(queue) == 0 {
+		fmt.Println("Everything is cached")
+		return nil
+	}
+
+	fetched := 0
+	total := len(queue)
+	var errMessages []string
+	errCategories := rvfs.ErrorTally{}
+	recovered := 0
+	cancelled := false
+
+	for len(queue) > 0 {
+		// Check for ^C cancellation
+		select {
+		case <-sig:
+			cancelled = true
+		default:
+		}
+		if cancelled {
+			break
+		}
+
+		path := queue[0]
+		queue = queue[1:]
+		fetched++
+
+		// In-place progress line
+		errPart := ""
+		if len(errMessages) > 0 {
+			errPart = fmt.Sprintf(", %d errors", len(errMessages))
+		}
+		fmt.Printf("\r\033[KFetching %s  (%d/%d%s)", path, fetched, total, errPart)
+
+		res, retries, err := n.scrapePolicy.Fetch(n.vfs, path)
+		if err != nil {
+			errMessages = append(errMessages, fmt.Sprintf("  %s [%s]: %s", path, rvfs.Categorize(err), err.Error()))
+			errCategories.Add(err)
+			continue
+		}
+		if retries > 0 {
+			recovered++
+		}
+
+		// Discover new children from freshly fetched resource
+		for _, child := range res.Children {
+			if visited[child.Target] {
+				continue
+			}
+			visited[child.Target] = true
+			if n.scrapePolicy.Skipped(child.Target) {
+				skipped++
+				continue
+			}
+			queue = append(queue, child.Target)
+			total++
+		}
+	}
+
+	// Clear progress line and print summary
+	fmt.Print("\r\033[K")
+	verb := "Done"
+	if cancelled {
+		verb = "Cancelled"
+	}
+	fmt.Println(scrapeSummary(verb, fetched, len(errMessages), errCategories, skipped, recovered, time.Since(start)))
+	for _, msg := range errMessages {
+		fmt.Println(msg)
+	}
+	return nil
+}
+
+// scrapeSummary is the line that ends a scrape: counts of fetched
+// resources and errors by category, skipped paths and fetches that needed
+// a retry
+func scrapeSummary(verb string, fetched, failed int, categories rvfs.ErrorTally, skipped, recovered int, elapsed time.Duration) string {
+	line := fmt.Sprintf("%s: %d fetched, %d errors", verb, fetched, failed)
+	if failed > 0 {
+		line += " (" + categories.String() + ")"
+	}
+	if skipped > 0 {
+		line += fmt.Sprintf(", %d skipped", skipped)
+	}
+	if recovered > 0 {
+		line += fmt.Sprintf(", %d recovered on retry", recovered)
+	}
+	return line + ", " + elapsed.Round(time.Millisecond).String()
+}
+
+// refresh invalidates a resource from cache, re-fetches, and shows it
+func (n *Navigator) refresh(target string) error {
+	// Determine which path to refresh
+	var path string
+	if target == "" {
+		path = n.cwd
+	} else {
+		resolved, err := n.vfs.ResolveTarget(n.cwd, target)
+		if err != nil {
+			return err
+		}
+		switch resolved.Type {
+		case rvfs.TargetResource, rvfs.TargetLink:
+			path = resolved.ResourcePath
+		default:
+			return fmt.Errorf("can only refresh resources, not properties")
+		}
+	}
+
+	n.vfs.Invalidate(path)
+	fmt.Printf("Refreshing %s...\n", path)
+
+	res, err := n.vfs.Get(path)
+	if err != nil {
+		return err
+	}
+
+	// Show refreshed resource like ll
+	if err := n.showResource(path); err != nil {
+		return err
+	}
+	fmt.Println(dimStyle.Render(formatAge(res.FetchedAt)))
+	return nil
+}
+
+// download streams a binary payload to a file without parsing it: the URI
+// a property such as AdditionalDataURI holds, or the path itself. Progress
+// is redrawn on one line while it arrives.
+func (n *Navigator) download(target, filename string) error {
+	path, err := rvfs.DownloadPath(n.vfs, n.cwd, target)
+	if err != nil {
+		return err
+	}
+	var drawn time.Time
+	written, err := saveDownload(n.vfs, path, filename, func(written, total int64) {
+		if time.Since(drawn) < 100*time.Millisecond && written != total {
+			return
+		}
+		drawn = time.Now()
+		fmt.Print("\r\033[K" + formatTransfer(written, total))
+	})
+	fmt.Print("\r\033[K")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Saved %s from %s to %s\n", formatBytes(written), path, filename)
+	return nil
+}
+
+// saveDownload streams the payload at path into filename. It is written to
+// filename.part and renamed once complete, so a failed download leaves no
+// truncated file behind.
+func saveDownload(v rvfs.Downloader, path, filename string, progress rvfs.ProgressFunc) (int64, error) {
+	part := filename + ".part"
+	f, err := os.Create(part)
+	if err != nil {
+		return 0, err
+	}
+	written, err := v.Download(path, f, progress)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(part)
+		return written, err
+	}
+	return written, os.Rename(part, filename)
+}
+
+// formatTransfer describes how far a download has come
+func formatTransfer(written, total int64) string {
+	if total <= 0 {
+		return "Downloading " + formatBytes(written)
+	}
+	return fmt.Sprintf("Downloading %s of %s (%d%%)", formatBytes(written), formatBytes(total), written*100/total)
+}
+
+// taskPollInterval is how often a task a command waits for is polled
+const taskPollInterval = 2 * time.Second
+
+// diagArgs are the arguments of diag collect:
+// [path] [key=value...] [-o file]
+type diagArgs struct {
+	target string   // Log service, "" for the current resource
+	params []string // key=value parameters of CollectDiagnosticData
+	file   string   // File to save the data to, "" for a name from the entry
+}
+
+// parseDiagArgs parses the arguments of diag collect
+func parseDiagArgs(args []string) (*diagArgs, error) {
+	usage := fmt.Errorf("usage: diag collect [path] [key=value...] [-o file]")
+	if len(args) == 0 || args[0] != "collect" {
+		return nil, usage
+	}
+	d := &diagArgs{}
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "-o":
+			if i+1 >= len(args) {
+				return nil, usage
+			}
+			i++
+			d.file = args[i]
+		case strings.Contains(args[i], "="):
+			d.params = append(d.params, args[i])
+		case d.target == "" && len(d.params) == 0:
+			d.target = args[i]
+		default:
+			return nil, usage
+		}
+	}
+	return d, nil
+}
+
+// diagFile names the file diagnostic data from a log entry is saved to when
+// none is given: diag-ENTRY-20260102-150405
+func diagFile(entry string, at time.Time) string {
+	return "diag-" + rvfs.BaseName(entry) + "-" + at.Format("20060102-150405")
+}
+
+// diagCollect invokes CollectDiagnosticData on a log service, follows the
+// task it spawns and downloads the data it collected. ^C cancels it.
+func (n *Navigator) diagCollect(args []string) error {
+	d, err := parseDiagArgs(args)
+	if err != nil {
+		return err
+	}
+	path := n.cwd
+	if d.target != "" {
+		resolved, err := n.vfs.ResolveTarget(n.cwd, d.target)
+		if err != nil {
+			return err
+		}
+		if resolved.Type == rvfs.TargetProperty {
+			return fmt.Errorf("not a resource: %s", d.target)
+		}
+		path = resolved.ResourcePath
+	}
+	actions, err := discoverActions(n.vfs, path)
+	if err != nil {
+		return err
+	}
+	action := matchAction(actions, rvfs.CollectDiagnosticDataAction)
+	if action == nil {
+		return fmt.Errorf("%s has no CollectDiagnosticData action", path)
+	}
+	body, err := parseActionBody(action, d.params)
+	if err != nil {
+		return err
+	}
+	if err := n.writeRefusal(path); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	vfs := rvfs.WithContext(ctx, n.vfs)
+
+	fmt.Printf("%s %s\n", errorStyle.Render("POST"), action.Target)
+	result, err := rvfs.CollectDiagnosticData(vfs, path, body, taskPollInterval, func(status *rvfs.TaskStatus) {
+		fmt.Print("\r\033[KTask " + status.Path + ": " + status.String())
+	})
+	fmt.Print("\r\033[K")
+	if err != nil {
+		return err
+	}
+	if result.Task != nil {
+		fmt.Printf("Task %s: %s\n", result.Task.Path, result.Task.State)
+	}
+	fmt.Println("Collected into " + result.Entry)
+
+	filename := d.file
+	if filename == "" {
+		filename = diagFile(result.Entry, time.Now())
+	}
+	var drawn time.Time
+	written, err := saveDownload(vfs, result.Download, filename, func(written, total int64) {
+		if time.Since(drawn) < 100*time.Millisecond && written != total {
+			return
+		}
+		drawn = time.Now()
+		fmt.Print("\r\033[K" + formatTransfer(written, total))
+	})
+	fmt.Print("\r\033[K")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Saved %s from %s to %s\n", formatBytes(written), result.Download, filename)
+	return nil
+}
+
+// sparklineWidth is how many of the latest readings a sparkline draws
+const sparklineWidth = 40
+
+// metricsUsage is the error for malformed metrics arguments
+var metricsUsage = fmt.Errorf("usage: metrics list | metrics show <report> [-t] [-o file.csv] | metrics define [id]")
+
+// metrics lists the TelemetryService's report definitions and reports, or
+// shows the readings of one report: metrics list, metrics show <report>
+func (n *Navigator) metrics(args []string) error {
+	if len(args) == 0 {
+		return metricsUsage
+	}
+	switch args[0] {
+	case "list":
+		if len(args) != 1 {
+			return metricsUsage
+		}
+		t, err := rvfs.ReadTelemetry(n.vfs)
+		if err != nil {
+			return err
+		}
+		fmt.Print(formatTelemetry(t))
+		return nil
+	case "show":
+		return n.showMetrics(args[1:])
+	}
+	return metricsUsage
+}
+
+// showMetrics shows a report's readings, a sparkline per metric or with -t
+// a table of every reading, and with -o exports them to a CSV file. The
+// report is named by its Id in MetricReports or by its path.
+func (n *Navigator) showMetrics(args []string) error {
+	var report, file string
+	table := false
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-t":
+			table = true
+		case args[i] == "-o" && i+1 < len(args):
+			i++
+			file = args[i]
+		case report == "" && !strings.HasPrefix(args[i], "-"):
+			report = args[i]
+		default:
+			return metricsUsage
+		}
+	}
+	if report == "" {
+		return metricsUsage
+	}
+
+	path, err := rvfs.MetricReportPath(n.vfs, report)
+	if err != nil {
+		resolved, resolveErr := n.vfs.ResolveTarget(n.cwd, report)
+		if resolveErr != nil || resolved.Type == rvfs.TargetProperty {
+			return err
+		}
+		path = resolved.ResourcePath
+	}
+	r, err := rvfs.ReadMetricReport(n.vfs, path)
+	if err != nil {
+		return err
+	}
+
+	if file != "" {
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		err = rvfs.WriteMetricCSV(f, r)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Exported %d readings of %s to %s\n", len(r.Readings), r.Path, file)
+		return nil
+	}
+	if table {
+		fmt.Print(formatMetricTable(r))
+	} else {
+		fmt.Print(formatMetricReport(r))
+	}
+	return nil
+}
+
+// defineMetrics walks through creating a MetricReportDefinition: the
+// metrics to report, picked from the service's MetricDefinitions by number
+// or by name, where a unique prefix completes, or given as property URIs;
+// then the Id, type, interval and report actions. The definition is POSTed
+// once confirmed.
+func (n *Navigator) defineMetrics(in *bufio.Reader, args []string) error {
+	if len(args) > 1 {
+		return metricsUsage
+	}
+	collection, err := rvfs.MetricReportDefinitionsPath(n.vfs)
+	if err != nil {
+		return err
+	}
+	if err := n.writeRefusal(collection); err != nil {
+		return err
+	}
+	catalog, err := rvfs.MetricCatalog(n.vfs)
+	if err != nil {
+		// Without a catalog, metrics are given as property URIs
+		fmt.Println(dimStyle.Render(err.Error()))
+	}
+
+	spec := &rvfs.ReportDefinitionSpec{}
+	if len(catalog) > 0 {
+		fmt.Println()
+		for i, m := range catalog {
+			fmt.Printf("  %3d  %s %s %s\n", i+1, propStyle.Render(fmt.Sprintf("%-28s", m.ID)),
+				fmt.Sprintf("%-6s", m.Units), dimStyle.Render(strings.Join(m.Properties, ", ")))
+		}
+	}
+	fmt.Println(dimStyle.Render("\nMetrics by number, name or property URI, empty line to finish"))
+	for {
+		line := promptLine(in, "+ ")
+		if line == "" {
+			if len(spec.Metrics) > 0 || len(spec.Properties) > 0 {
+				break
+			}
+			if _, err := in.Peek(1); err != nil {
+				return fmt.Errorf("a report definition needs at least one metric")
+			}
+			continue
+		}
+		if i, err := strconv.Atoi(line); err == nil && len(catalog) > 0 {
+			if i < 1 || i > len(catalog) {
+				fmt.Printf("  pick 1-%d\n", len(catalog))
+				continue
+			}
+			spec.Metrics = append(spec.Metrics, catalog[i-1])
+			fmt.Println("  " + catalog[i-1].ID)
+			continue
+		}
+		switch matches := rvfs.MatchMetrics(catalog, line); len(matches) {
+		case 0:
+			spec.Properties = append(spec.Properties, line)
+			fmt.Println(dimStyle.Render("  property " + line))
+		case 1:
+			spec.Metrics = append(spec.Metrics, matches[0])
+			fmt.Println("  " + matches[0].ID)
+		default:
+			names := make([]string, len(matches))
+			for i, m := range matches {
+				names[i] = m.ID
+			}
+			fmt.Printf("  ambiguous: %s\n", strings.Join(names, ", "))
+		}
+	}
+
+	if len(args) == 1 {
+		spec.ID = args[0]
+	}
+	for spec.ID == "" {
+		if spec.ID = promptLine(in, "Id: "); spec.ID == "" {
+			if _, err := in.Peek(1); err != nil {
+				return fmt.Errorf("a report definition needs an Id")
+			}
+		}
+	}
+	for {
+		spec.Type = promptLine(in, "Type "+dimStyle.Render("["+strings.Join(rvfs.MetricReportTypes, "|")+"]")+" (Periodic): ")
+		if spec.Type == "" {
+			spec.Type = "Periodic"
+		}
+		if slices.Contains(rvfs.MetricReportTypes, spec.Type) {
+			break
+		}
+		if _, err := in.Peek(1); err != nil {
+			return fmt.Errorf("invalid type %q", spec.Type)
+		}
+	}
+	for spec.Type == "Periodic" && spec.Interval == "" {
+		interval, err := rvfs.ISODuration(promptLine(in, "Interval (e.g. 30s, PT5M): "))
+		if err == nil {
+			spec.Interval = interval
+			break
+		}
+		if _, peekErr := in.Peek(1); peekErr != nil {
+			return err
+		}
+		fmt.Println("  " + err.Error())
+	}
+	for spec.Actions == nil {
+		line := promptLine(in, "Report actions "+dimStyle.Render("["+strings.Join(rvfs.MetricReportActions, "|")+"]")+" (LogToMetricReportsCollection): ")
+		actions := []string{"LogToMetricReportsCollection"}
+		if line != "" {
+			actions = strings.Split(strings.ReplaceAll(line, " ", ""), ",")
+		}
+		if !slices.ContainsFunc(actions, func(a string) bool { return !slices.Contains(rvfs.MetricReportActions, a) }) {
+			spec.Actions = actions
+			break
+		}
+		if _, err := in.Peek(1); err != nil {
+			return fmt.Errorf("invalid report actions %q", line)
+		}
+		fmt.Printf("  allowed: %s\n", strings.Join(rvfs.MetricReportActions, ", "))
+	}
+	body, err := spec.Body()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%s %s\n%s\n", errorStyle.Render("POST"), collection, body)
+	if confirm := promptLine(in, "\nConfirm? [y/N] "); confirm != "y" && confirm != "Y" {
+		fmt.Println("Cancelled")
+		return nil
+	}
+	resp, err := n.vfs.Post(collection, body)
+	n.history.RecordPost(collection, resp, err)
+	if err != nil {
+		return err
+	}
+	n.vfs.Invalidate(collection)
+	fmt.Print(n.formatCreateResult(resp))
+	return nil
+}
+
+// formatMemory draws the memory of each system: its total and health,
+// then a row per module, only the failed ones when failedOnly is set
+func formatMemory(memory []*rvfs.SystemMemory, failedOnly bool) string {
+	if len(memory) == 0 {
+		return dimStyle.Render("No systems") + "\n"
+	}
+	var b strings.Builder
+	for _, m := range memory {
+		populated, failed := 0, 0
+		for _, module := range m.Modules {
+			if module.Populated() {
+				populated++
+			}
+			if module.Failed() {
+				failed++
+			}
+		}
+		summary := fmt.Sprintf("%d of %d slots populated", populated, len(m.Modules))
+		if m.TotalGiB > 0 {
+			summary = strconv.FormatFloat(m.TotalGiB, 'f', -1, 64) + " GiB, " + summary
+		}
+		if m.PersistentGiB > 0 {
+			summary += ", " + strconv.FormatFloat(m.PersistentGiB, 'f', -1, 64) + " GiB persistent"
+		}
+		if m.Health != "" {
+			summary += ", health " + severityStyle(m.Health).Render(m.Health)
+		}
+		if failed > 0 {
+			summary += ", " + healthCriticalStyle.Render(fmt.Sprintf("%d failed", failed))
+		}
+		fmt.Fprintf(&b, "%s %s\n", childStyle.Render(m.System), summary)
+
+		var rows []*rvfs.MemoryModule
+		for _, module := range m.Modules {
+			if !failedOnly || module.Failed() {
+				rows = append(rows, module)
+			}
+		}
+		if len(rows) == 0 {
+			if failedOnly {
+				b.WriteString(dimStyle.Render("  No failed modules") + "\n")
+			}
+			continue
+		}
+		b.WriteString(dimStyle.Render(fmt.Sprintf("  %-14s %-9s %-9s %-6s %-14s %-20s %s", "Slot", "Capacity", "Speed", "Type", "Manufacturer", "Part number", "Health")) + "\n")
+		for _, module := range rows {
+			if !module.Populated() {
+				fmt.Fprintf(&b, "  %-14s %s\n", module.Slot, dimStyle.Render("absent"))
+				continue
+			}
+			capacity := ""
+			switch {
+			case module.CapacityMiB > 0 && module.CapacityMiB%1024 == 0:
+				capacity = fmt.Sprintf("%d GiB", module.CapacityMiB/1024)
+			case module.CapacityMiB > 0:
+				capacity = fmt.Sprintf("%d MiB", module.CapacityMiB)
+			}
+			speed := ""
+			if module.SpeedMHz > 0 {
+				speed = fmt.Sprintf("%d MHz", module.SpeedMHz)
+			}
+			health := module.Health
+			if module.State != "" && module.State != "Enabled" {
+				health = strings.TrimSpace(health + " " + module.State)
+			}
+			style := severityStyle(module.Health)
+			if module.Failed() {
+				style = healthCriticalStyle
+			}
+			fmt.Fprintf(&b, "  %-14s %-9s %-9s %-6s %-14s %-20s %s\n", module.Slot, capacity, speed, module.Type,
+				module.Manufacturer, module.PartNumber, style.Render(health))
+		}
+	}
+	return b.String()
+}
+
+// formatProcessors draws a table of the CPUs of each system, then one of
+// its accelerators
+func formatProcessors(all []*rvfs.SystemProcessors) string {
+	if len(all) == 0 {
+		return dimStyle.Render("No systems") + "\n"
+	}
+	var b strings.Builder
+	for _, s := range all {
+		var cpus, accelerators []*rvfs.Processor
+		for _, p := range s.Processors {
+			if p.Accelerator() {
+				accelerators = append(accelerators, p)
+			} else {
+				cpus = append(cpus, p)
+			}
+		}
+		fmt.Fprintf(&b, "%s %s\n", childStyle.Render(s.System), dimStyle.Render(fmt.Sprintf("%d CPUs, %d accelerators", len(cpus), len(accelerators))))
+		for _, group := range []struct {
+			title      string
+			processors []*rvfs.Processor
+		}{{"CPUs", cpus}, {"Accelerators", accelerators}} {
+			if len(group.processors) == 0 {
+				continue
+			}
+			b.WriteString("  " + boldStyle.Render(group.title) + "\n")
+			b.WriteString(dimStyle.Render(fmt.Sprintf("  %-10s %-36s %-9s %-15s %-8s %-8s %s", "Socket", "Model", "Cores", "Speed MHz", "Temp", "Power", "Health")) + "\n")
+			for _, p := range group.processors {
+				if p.State == "Absent" {
+					fmt.Fprintf(&b, "  %-10s %s\n", p.Socket, dimStyle.Render("absent"))
+					continue
+				}
+				model := p.Model
+				if model == "" {
+					model = strings.TrimSpace(p.Manufacturer + " " + p.Type)
+				}
+				cores := ""
+				if p.Cores > 0 {
+					cores = fmt.Sprintf("%d/%d", p.Cores, p.Threads)
+				}
+				speed := ""
+				if p.SpeedMHz > 0 || p.MaxSpeedMHz > 0 {
+					speed = fmt.Sprintf("%d/%d", p.SpeedMHz, p.MaxSpeedMHz)
+				}
+				readings := make([]string, 2)
+				for i, name := range []string{"TemperatureCelsius", "PowerWatts"} {
+					if r, ok := p.Reading(name); ok {
+						readings[i], _ = rvfs.Humanize(r.Name, r.Value, time.Now())
+					}
+				}
+				fmt.Fprintf(&b, "  %-10s %-36s %-9s %-15s %-8s %-8s %s\n", p.Socket, model, cores, speed,
+					readings[0], readings[1], severityStyle(p.Health).Render(p.Health))
+			}
+		}
+	}
+	return b.String()
+}
+
+// formatFirmwareInventory draws a row per firmware component, the ones
+// that can be updated highlighted, then how many of them there are
+func formatFirmwareInventory(inventory []*rvfs.SoftwareComponent) string {
+	if len(inventory) == 0 {
+		return dimStyle.Render("No firmware inventory") + "\n"
+	}
+	var b strings.Builder
+	b.WriteString(dimStyle.Render(fmt.Sprintf("%-28s %-20s %-10s %-16s %s", "Name", "Version", "Updateable", "State", "Related")) + "\n")
+	updateable := 0
+	for _, c := range inventory {
+		name := c.Name
+		if name == "" {
+			name = c.ID
+		}
+		flag := dimStyle.Render(fmt.Sprintf("%-10s", "no"))
+		if c.Updateable {
+			updateable++
+			name = childStyle.Render(fmt.Sprintf("%-28s", name))
+			flag = healthOKStyle.Render(fmt.Sprintf("%-10s", "yes"))
+		} else {
+			name = fmt.Sprintf("%-28s", name)
+		}
+		state := strings.TrimSpace(c.State + " " + c.Health)
+		state = severityStyle(c.Health).Render(fmt.Sprintf("%-16s", state))
+		fmt.Fprintf(&b, "%s %-20s %s %s %s\n", name, c.Version, flag, state, dimStyle.Render(strings.Join(c.Related, ", ")))
+	}
+	fmt.Fprintf(&b, "%d of %d components updateable\n", updateable, len(inventory))
+	return b.String()
+}
+
+// formatPCIe draws each PCIe device with its slot, link and firmware, then
+// its functions and what they serve as a tree. A link trained below what
+// the device supports is highlighted.
+func formatPCIe(devices []*rvfs.PCIeDevice) string {
+	if len(devices) == 0 {
+		return dimStyle.Render("No PCIe devices") + "\n"
+	}
+	var b strings.Builder
+	for _, d := range devices {
+		name := d.Name
+		if d.Model != "" && d.Model != d.Name {
+			name += " " + d.Model
+		}
+		fmt.Fprintf(&b, "%s %s\n", childStyle.Render(name), dimStyle.Render(d.Path))
+
+		var details []string
+		if slot := strings.TrimSpace(d.Slot + " " + d.SlotType); slot != "" {
+			details = append(details, slot)
+		}
+		if link := d.Link.String(); link != "" {
+			if supported := d.MaxLink.String(); supported != "" && supported != link {
+				link = warnStyle.Render(link) + " of " + supported
+			}
+			details = append(details, link)
+		} else if supported := d.MaxLink.String(); supported != "" {
+			details = append(details, "up to "+supported)
+		}
+		if d.Manufacturer != "" {
+			details = append(details, d.Manufacturer)
+		}
+		if d.Firmware != "" {
+			details = append(details, "firmware "+d.Firmware)
+		}
+		if len(details) > 0 {
+			b.WriteString("  " + strings.Join(details, ", ") + "\n")
+		}
+
+		branches := len(d.Functions) + len(d.Associations)
+		for i, f := range d.Functions {
+			connector, extension := "├── ", "│   "
+			if i == branches-1 {
+				connector, extension = "└── ", "    "
+			}
+			line := "Function " + f.ID
+			for _, s := range []string{f.Class, f.Type} {
+				if s != "" {
+					line += " " + s
+				}
+			}
+			if f.VendorID != "" || f.DeviceID != "" {
+				line += " " + f.VendorID + ":" + f.DeviceID
+			}
+			fmt.Fprintf(&b, "  %s%s %s\n", connector, line, dimStyle.Render(f.Path))
+			for j, a := range f.Associations {
+				connector := "├── "
+				if j == len(f.Associations)-1 {
+					connector = "└── "
+				}
+				fmt.Fprintf(&b, "  %s%s%s %s\n", extension, connector, propStyle.Render(a.Kind), a.Path)
+			}
+		}
+		for i, a := range d.Associations {
+			connector := "├── "
+			if len(d.Functions)+i == branches-1 {
+				connector = "└── "
+			}
+			fmt.Fprintf(&b, "  %s%s %s\n", connector, propStyle.Render(a.Kind), a.Path)
+		}
+	}
+	return b.String()
+}
+
+// formatTopology draws the containment hierarchy as a tree: each chassis
+// with the chassis, systems and managers inside it, their type, health and
+// the managers managing them
+func formatTopology(nodes []*rvfs.TopologyNode) string {
+	if len(nodes) == 0 {
+		return dimStyle.Render("No chassis, systems or managers") + "\n"
+	}
+	var b strings.Builder
+	for _, node := range nodes {
+		writeTopologyNode(&b, node, "", "")
+	}
+	return b.String()
+}
+
+// writeTopologyNode writes a node after connector and what it contains
+// below it, indented by prefix
+func writeTopologyNode(b *strings.Builder, node *rvfs.TopologyNode, prefix, connector string) {
+	line := propStyle.Render(node.Kind) + " " + childStyle.Render(node.ID)
+	if node.Name != "" && node.Name != node.ID {
+		line += " " + strconv.Quote(node.Name)
+	}
+	if node.Type != "" {
+		line += " (" + node.Type + ")"
+	}
+	if node.Health != "" {
+		line += " " + severityStyle(node.Health).Render(node.Health)
+	}
+	if len(node.ManagedBy) > 0 {
+		line += " managed by " + strings.Join(node.ManagedBy, ", ")
+	}
+	fmt.Fprintf(b, "%s%s%s %s\n", prefix, connector, line, dimStyle.Render(node.Path))
+
+	switch connector {
+	case "├── ":
+		prefix += "│   "
+	case "└── ":
+		prefix += "    "
+	}
+	for i, inner := range node.Contains {
+		if i == len(node.Contains)-1 {
+			writeTopologyNode(b, inner, prefix, "└── ")
+		} else {
+			writeTopologyNode(b, inner, prefix, "├── ")
+		}
+	}
+}
+
+// formatTelemetry lists report definitions, with their schedule and
+// metrics, and the reports generated with their reading counts
+func formatTelemetry(t *rvfs.Telemetry) string {
+	var b strings.Builder
+	b.WriteString(boldStyle.Render("Definitions") + "\n")
+	if len(t.Definitions) == 0 {
+		b.WriteString(dimStyle.Render("  none") + "\n")
+	}
+	for _, d := range t.Definitions {
+		schedule := d.Type
+		if d.Interval != "" {
+			schedule += " every " + d.Interval
+		}
+		fmt.Fprintf(&b, "  %s %-24s %s\n", childStyle.Render(fmt.Sprintf("%-24s", d.ID)), schedule, dimStyle.Render(strings.Join(d.Metrics, ", ")))
+	}
+	b.WriteString(boldStyle.Render("Reports") + "\n")
+	if len(t.Reports) == 0 {
+		b.WriteString(dimStyle.Render("  none") + "\n")
+	}
+	for _, r := range t.Reports {
+		fmt.Fprintf(&b, "  %s %5d readings  %s\n", childStyle.Render(fmt.Sprintf("%-24s", r.ID)), len(r.Readings), dimStyle.Render(r.Timestamp))
+	}
+	return b.String()
+}
+
+// formatMetricReport shows a report as a line per metric: how many
+// readings it has, their range and latest value, and a sparkline of them
+func formatMetricReport(r *rvfs.MetricReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %s\n", boldStyle.Render(r.Path), dimStyle.Render(r.Timestamp))
+	series := r.Series()
+	if len(series) == 0 {
+		b.WriteString(dimStyle.Render("  no readings") + "\n")
+	}
+	for _, s := range series {
+		values := s.Values()
+		last := s.Readings[len(s.Readings)-1].Value
+		if len(values) == 0 {
+			fmt.Fprintf(&b, "  %s %4d  %s\n", propStyle.Render(fmt.Sprintf("%-28s", s.Name())), len(s.Readings), last)
+		} else {
+			fmt.Fprintf(&b, "  %s %4d  %s..%s  last %s  %s\n", propStyle.Render(fmt.Sprintf("%-28s", s.Name())), len(s.Readings),
+				strconv.FormatFloat(slices.Min(values), 'f', -1, 64), strconv.FormatFloat(slices.Max(values), 'f', -1, 64), last,
+				linkStyle.Render(rvfs.Sparkline(values, sparklineWidth)))
+		}
+		if s.MetricID != "" && s.Property != "" {
+			b.WriteString("    " + dimStyle.Render(s.Property) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// formatMetricTable shows every reading of a report, one row each
+func formatMetricTable(r *rvfs.MetricReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-26s %-28s %s\n", "Timestamp", "Metric", "Value")
+	for _, s := range r.Series() {
+		for _, reading := range s.Readings {
+			fmt.Fprintf(&b, "%s %s %s\n", dimStyle.Render(fmt.Sprintf("%-26s", reading.Timestamp)), propStyle.Render(fmt.Sprintf("%-28s", s.Name())), reading.Value)
+		}
+	}
+	return b.String()
+}
+
+// memory shows the MemorySummary and modules of every system, or of the
+// system at target; --failed keeps the modules that are unhealthy
+func (n *Navigator) memory(args []string) error {
+	base, failed := n.vfs.Root(), false
+	for _, a := range args {
+		switch {
+		case a == "--failed":
+			failed = true
+		case base == n.vfs.Root() && !strings.HasPrefix(a, "-"):
+			resolved, err := n.vfs.ResolveTarget(n.cwd, a)
+			if err != nil {
+				return err
+			}
+			if resolved.Type == rvfs.TargetProperty {
+				return fmt.Errorf("not a resource: %s", a)
+			}
+			base = resolved.ResourcePath
+		default:
+			return fmt.Errorf("usage: memory [path] [--failed]")
+		}
+	}
+	memory, err := rvfs.ReadMemory(n.vfs, base)
+	if err != nil {
+		return err
+	}
+	fmt.Print(formatMemory(memory, failed))
+	return nil
+}
+
+// cpu lists the processors of every system, or of the system at target,
+// CPUs first and then GPUs and other accelerators
+func (n *Navigator) cpu(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: cpu [path]")
+	}
+	base := n.vfs.Root()
+	if len(args) == 1 {
+		resolved, err := n.vfs.ResolveTarget(n.cwd, args[0])
+		if err != nil {
+			return err
+		}
+		if resolved.Type == rvfs.TargetProperty {
+			return fmt.Errorf("not a resource: %s", args[0])
+		}
+		base = resolved.ResourcePath
+	}
+	processors, err := rvfs.ReadProcessors(n.vfs, base)
+	if err != nil {
+		return err
+	}
+	fmt.Print(formatProcessors(processors))
+	return nil
+}
+
+// firmware lists the UpdateService's FirmwareInventory
+func (n *Navigator) firmware(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: firmware")
+	}
+	inventory, err := rvfs.ReadFirmwareInventory(n.vfs)
+	if err != nil {
+		return err
+	}
+	fmt.Print(formatFirmwareInventory(inventory))
+	return nil
+}
+
+// pcie shows the PCIe devices of the service, or of the system or chassis
+// at target, as a tree of their functions and the resources they serve
+func (n *Navigator) pcie(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: pcie [path]")
+	}
+	base := n.vfs.Root()
+	if len(args) == 1 {
+		resolved, err := n.vfs.ResolveTarget(n.cwd, args[0])
+		if err != nil {
+			return err
+		}
+		if resolved.Type == rvfs.TargetProperty {
+			return fmt.Errorf("not a resource: %s", args[0])
+		}
+		base = resolved.ResourcePath
+	}
+	devices, err := rvfs.PCIeTopology(n.vfs, base)
+	if err != nil {
+		return err
+	}
+	fmt.Print(formatPCIe(devices))
+	return nil
+}
+
+// topology shows the physical containment hierarchy of the service, or of
+// the chassis at target
+func (n *Navigator) topology(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: topology [chassis]")
+	}
+	base := n.vfs.Root()
+	if len(args) == 1 {
+		resolved, err := n.vfs.ResolveTarget(n.cwd, args[0])
+		if err != nil {
+			return err
+		}
+		if resolved.Type == rvfs.TargetProperty {
+			return fmt.Errorf("not a resource: %s", args[0])
+		}
+		base = resolved.ResourcePath
+	}
+	nodes, err := rvfs.Topology(n.vfs, base)
+	if err != nil {
+		return err
+	}
+	fmt.Print(formatTopology(nodes))
+	return nil
+}
+
+// locate turns the locator LED of a system, chassis or drive on or off:
+// locate on|off [target], the current resource by default
+func (n *Navigator) locate(args []string) error {
+	if len(args) == 0 || len(args) > 2 || (args[0] != "on" && args[0] != "off") {
+		return fmt.Errorf("usage: locate on|off [path]")
+	}
+	path := n.cwd
+	if len(args) == 2 {
+		resolved, err := n.vfs.ResolveTarget(n.cwd, args[1])
+		if err != nil {
+			return err
+		}
+		if resolved.Type == rvfs.TargetProperty {
+			return fmt.Errorf("not a resource: %s", args[1])
+		}
+		path = resolved.ResourcePath
+	}
+	if err := n.writeRefusal(path); err != nil {
+		return err
+	}
+	change, err := rvfs.SetLocationIndicator(n.vfs, path, args[0] == "on")
+	if err != nil {
+		return err
+	}
+	n.history.RecordPatch(change.Resource, []rvfs.Change{change})
+	fmt.Printf("Locator %s: %s %s\n", args[0], path, dimStyle.Render("("+change.Property[0]+")"))
+	return nil
+}
+
+// stat shows the methods the service allows on a resource, and whether a
+// PATCH or DELETE of it would go through the service and the role
+func (n *Navigator) stat(target string) error {
+	path := n.cwd
+	var chain []rvfs.Hop
+	if target != "" {
+		resolved, hops, err := n.vfs.ResolveChain(n.cwd, target)
+		if err != nil {
+			return err
+		}
+		if resolved.Type == rvfs.TargetProperty {
+			return fmt.Errorf("not a resource: %s", target)
+		}
+		path, chain = resolved.ResourcePath, hops
+	}
+	info, err := n.vfs.Stat(path)
+	if err != nil {
+		return err
+	}
+	// A path through links shows where they lead first
+	fmt.Print(formatChain(chain))
+	fmt.Print(formatStat(info, n.writeRefusal(path)))
+	return nil
+}
+
+// privileges maps a resource against the service's PrivilegeRegistry:
+// which roles may GET, PATCH, POST or DELETE it, and what the shell's own
+// role may not do. A property stands for the resource holding it.
+func (n *Navigator) privileges(target string) error {
+	resolved, err := n.vfs.ResolveTarget(n.cwd, target)
+	if err != nil {
+		return err
+	}
+	path := resolved.ResourcePath
+	if resolved.Type == rvfs.TargetProperty {
+		path = resolved.Resource.Path
+	}
+	m, err := rvfs.ResourcePrivileges(n.vfs, path)
+	if err != nil {
+		return err
+	}
+	fmt.Print(formatPrivileges(m, n.role))
+	return nil
+}
+
+// formatPrivileges lists, per operation on a resource, the privilege sets
+// the service's registry asks for and the roles holding one, the role the
+// shell acts as in bold, then what that role may not do
+func formatPrivileges(m *rvfs.PrivilegeMap, role *rvfs.SessionRole) string {
+	var b strings.Builder
+	b.WriteString(m.Resource + "  " + dimStyle.Render(m.Entity+", per "+m.Registry) + "\n")
+	if m.Override != "" {
+		b.WriteString("  " + warnStyle.Render("Override: "+m.Override) + "\n")
+	}
+	var refused []string
+	for _, op := range m.Operations {
+		fmt.Fprintf(&b, "  %-8s %s\n", op.Operation+":", formatAccess(op, role))
+		if role != nil && op.Privileges != nil && !slices.Contains(op.Roles, role.RoleID) {
+			refused = append(refused, op.Operation)
+		}
+	}
+	for _, p := range m.Properties {
+		fmt.Fprintf(&b, "  %s %s\n", "PATCH "+propStyle.Render(p.Property)+":", formatAccess(p.Access, role))
+	}
+	switch {
+	case role == nil:
+	case len(refused) == 0:
+		b.WriteString(healthOKStyle.Render("Role "+role.RoleID+" may do all of them") + "\n")
+	default:
+		b.WriteString(warnStyle.Render("Role "+role.RoleID+" may not "+strings.Join(refused, ", ")) + "\n")
+	}
+	return b.String()
+}
+
+// formatAccess shows the privilege sets allowing an operation, any one of
+// them whole, and the roles holding one
+func formatAccess(access rvfs.OperationAccess, role *rvfs.SessionRole) string {
+	if access.Privileges == nil {
+		return dimStyle.Render("not mapped")
+	}
+	sets := make([]string, len(access.Privileges))
+	for i, set := range access.Privileges {
+		sets[i] = strings.Join(set, "+")
+		if len(set) == 0 {
+			sets[i] = "no privilege"
+		}
+	}
+	s := strings.Join(sets, " | ") + dimStyle.Render(" → ") + roleNames(access.Roles, role)
+	if len(access.SelfRoles) > 0 {
+		s += dimStyle.Render("; own account only: ") + roleNames(access.SelfRoles, role)
+	}
+	return s
+}
+
+// roleNames lists roles, the one the shell acts as in bold
+func roleNames(roles []string, role *rvfs.SessionRole) string {
+	if len(roles) == 0 {
+		return errorStyle.Render("no role")
+	}
+	names := make([]string, len(roles))
+	for i, name := range roles {
+		names[i] = name
+		if role != nil && name == role.RoleID {
+			names[i] = boldStyle.Render(name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// writeMethods are the methods stat says a write would use
+var writeMethods = []string{"PATCH", "DELETE"}
+
+// formatStat formats the Allow header of a resource and a line per write
+// method, refused by the service or, given a refusal, by the role
+func formatStat(info *rvfs.ResourceInfo, refusal error) string {
+	var b strings.Builder
+	b.WriteString(info.Path + "\n")
+	allow := dimStyle.Render("not reported")
+	if info.Allow != nil {
+		allow = strings.Join(info.Allow, ", ")
+	}
+	fmt.Fprintf(&b, "  %-8s %s\n", "Allow:", allow)
+	for _, method := range writeMethods {
+		var state string
+		switch {
+		case !info.Allows(method):
+			state = errorStyle.Render("not allowed by the service")
+		case refusal != nil:
+			state = warnStyle.Render("refused: " + refusal.Error())
+		case info.Allow == nil:
+			state = dimStyle.Render("unknown; the service did not say")
+		default:
+			state = healthOKStyle.Render("allowed")
+		}
+		fmt.Fprintf(&b, "  %-8s %s\n", method+":", state)
+	}
+	return b.String()
+}
+
+// ActionInfo describes a Redfish action on a resource
+type ActionInfo struct {
+	Name      string              // Full name (e.g. #ComputerSystem.Reset)
+	ShortName string              // Stripped name (e.g. Reset)
+	Target    string              // POST URI
+	InfoURI   string              // @Redfish.ActionInfo URI (may be empty)
+	Allowable map[string][]string // Parameter name → AllowableValues
+}
+
+// discoverActions finds all actions on the resource at path
+func discoverActions(vfs rvfs.VFS, path string) ([]ActionInfo, error) {
+	resolved, err := vfs.ResolveTarget(vfs.Root(), path)
+	if err != nil {
+		return nil, err
+	}
+
+	var resource *rvfs.Resource
+	switch resolved.Type {
+	case rvfs.TargetResource, rvfs.TargetLink:
+		resource = resolved.Resource
+		if resource == nil {
+			resource, err = vfs.Get(resolved.ResourcePath)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case rvfs.TargetProperty:
+		resource = resolved.Resource
+	}
+	if resource == nil {
+		return nil, nil
+	}
+
+	actionsProp, ok := resource.Properties["Actions"]
+	if !ok || actionsProp.Type != rvfs.PropertyObject {
+		return nil, nil
+	}
+
+	var actions []ActionInfo
+	for key, child := range actionsProp.Children {
+		if key == "Oem" {
+			continue
+		}
+
+		info := ActionInfo{
+			Name:      key,
+			Allowable: make(map[string][]string),
+		}
+
+		// Extract short name: strip #Type. prefix
+		if idx := strings.LastIndex(key, "."); idx != -1 && strings.HasPrefix(key, "#") {
+			info.ShortName = key[idx+1:]
+		} else {
+			info.ShortName = key
+		}
+
+		if child.Type != rvfs.PropertyObject {
+			continue
+		}
+
+		// Extract target, ActionInfo URI, and AllowableValues from children
+		for childKey, childProp := range child.Children {
+			if childKey == "target" && childProp.Type == rvfs.PropertyLink {
+				info.Target = childProp.LinkTarget
+			} else if childKey == "@Redfish.ActionInfo" && childProp.Type == rvfs.PropertyLink {
+				info.InfoURI = childProp.LinkTarget
+			} else if strings.HasSuffix(childKey, "@Redfish.AllowableValues") && childProp.Type == rvfs.PropertyArray {
+				paramName := strings.TrimSuffix(childKey, "@Redfish.AllowableValues")
+				var values []string
+				for _, elem := range childProp.Elements {
+					if elem.Type == rvfs.PropertySimple {
+						if s, ok := elem.Value.(string); ok {
+							values = append(values, s)
+						}
+					}
+				}
+				info.Allowable[paramName] = values
+			}
+		}
+
+		if info.Target != "" {
+			actions = append(actions, info)
+		}
+	}
+
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].ShortName < actions[j].ShortName
+	})
+	return actions, nil
+}
+
+// matchAction finds an action by short name or full name (case-insensitive)
+func matchAction(actions []ActionInfo, name string) *ActionInfo {
+	lower := strings.ToLower(name)
+	for i := range actions {
+		if strings.ToLower(actions[i].ShortName) == lower {
+			return &actions[i]
+		}
+	}
+	for i := range actions {
+		if strings.ToLower(actions[i].Name) == lower {
+			return &actions[i]
+		}
+	}
+	return nil
+}
+
+// Display formatting
+
+func (n *Navigator) printShortListingAll(entries []*rvfs.Entry, numbers map[string]int) {
+	if len(entries) == 0 {
+		fmt.Println("(empty)")
+		return
+	}
+
+	items := make([]string, len(entries))
+	for i, entry := range entries {
+		items[i] = n.formatAgedEntry(entry)
+		if num, ok := numbers[entry.Name]; ok {
+			items[i] = dimStyle.Render("%"+strconv.Itoa(num)) + " " + items[i]
+		}
+	}
+
+	fmt.Println(formatColumns(items))
+}
+
+// formatAgedEntry formats an entry, after the mark of its age bucket when
+// ages are shown
+func (n *Navigator) formatAgedEntry(entry *rvfs.Entry) string {
+	if !n.ages {
+		return formatEntry(entry)
+	}
+	return ageMark(entry.Age(time.Now())) + " " + formatEntry(entry)
+}
+
+// ageMark is the mark of an age bucket
+func ageMark(age rvfs.EntryAge) string {
+	switch age {
+	case rvfs.AgeFresh:
+		return healthOKStyle.Render("●")
+	case rvfs.AgeRecent:
+		return healthWarnStyle.Render("◐")
+	case rvfs.AgeStale:
+		return healthCriticalStyle.Render("○")
+	}
+	return dimStyle.Render("·")
+}
+
+// ageLegend explains the age marks below a listing
+func ageLegend() string {
+	return ageMark(rvfs.AgeFresh) + dimStyle.Render(" <1m  ") +
+		ageMark(rvfs.AgeRecent) + dimStyle.Render(" <1h  ") +
+		ageMark(rvfs.AgeStale) + dimStyle.Render(" older  ") +
+		ageMark(rvfs.AgeUnfetched) + dimStyle.Render(" not fetched")
+}
+
+// actionMark follows resources that expose actions in listings
+func actionMark() string {
+	return warnStyle.Render("⚡")
+}
+
+// linkKind names a link property in long listings: "link" for an
+// @odata.id reference, "uri" for a string named like a URI
+func linkKind(prop *rvfs.Property) string {
+	if prop.URIString {
+		return "uri"
+	}
+	return "link"
+}
+
+func formatEntry(entry *rvfs.Entry) string {
+	if entry.Denied {
+		// Refused to this role: the name alone, marked, as it cannot be entered
+		return errorStyle.Render("⊘ " + entry.Name)
+	}
+	switch entry.Type {
+	case rvfs.EntryLink:
+		if entry.Actions {
+			return childStyle.Render(entry.Name+"/") + actionMark()
+		}
+		return childStyle.Render(entry.Name + "/")
+	case rvfs.EntrySymlink:
+		mark := "@"
+		if entry.URIString {
+			mark = "~"
+		}
+		if entry.LinkTarget != "" {
+			return linkStyle.Render(entry.Name+mark) + dimStyle.Render(" → "+entry.LinkTarget)
+		}
+		return linkStyle.Render(entry.Name + mark)
+	case rvfs.EntryComplex:
+		return objectStyle.Render(entry.Name + "/")
+	case rvfs.EntryArray:
+		return objectStyle.Render(entry.Name + "[]")
+	case rvfs.EntryProperty:
+		return propStyle.Render(entry.Name)
+	default:
+		return entry.Name
+	}
+}
+
+func formatPropertyValue(prop *rvfs.Property) string {
+	switch v := prop.Value.(type) {
+	case string:
+		if len(v) > 60 {
+			return v[:57] + "..."
+		}
+		return v
+	case bool:
+		return fmt.Sprintf("%v", v)
+	case float64:
+		if v == float64(int64(v)) {
+			return fmt.Sprintf("%d", int64(v))
+		}
+		return fmt.Sprintf("%g", v)
+	case nil:
+		return "null"
+	case []byte:
+		return "{...}"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// summaryLine describes the current location: what it contains and the
+// conditions reported for its resource
+func (n *Navigator) summaryLine(entries []*rvfs.Entry) string {
+	line := fmt.Sprintf("%s  (%s)", n.cwd, getEntriesSummary(entries))
+	if target, err := n.vfs.ResolveTarget(n.vfs.Root(), n.cwd); err == nil && target != nil && target.Resource != nil {
+		if conditions := formatConditionsSummary(target.Resource); conditions != "" {
+			line += "  " + conditions
+		}
+	}
+	return line
+}
+
+// maxBannerFirmware bounds the firmware versions the banner lists
+const maxBannerFirmware = 6
+
+// formatBanner renders the summary of the service shown on connect
+func formatBanner(s *rvfs.ServiceSummary) string {
+	var b strings.Builder
+	name := strings.TrimSpace(s.Vendor + " " + s.Model)
+	if name == "" {
+		name = "Redfish service"
+	}
+	b.WriteString(boldStyle.Render(name))
+	if s.RedfishVersion != "" {
+		b.WriteString("  " + dimStyle.Render("Redfish "+s.RedfishVersion))
+	}
+	auth := "no authentication"
+	if s.Auth == rvfs.AuthSession {
+		auth = "session auth"
+	}
+	b.WriteString("  " + dimStyle.Render(auth) + "\n")
+
+	var counts []string
+	for _, c := range []struct {
+		name  string
+		count int
+	}{{"Systems", s.Systems}, {"Chassis", s.Chassis}, {"Managers", s.Managers}} {
+		if c.count >= 0 {
+			counts = append(counts, fmt.Sprintf("%s %d", c.name, c.count))
+		}
+	}
+	if s.Health != "" {
+		counts = append(counts, "Health "+formatHealthValue("Health", s.Health))
+	}
+	if len(counts) > 0 {
+		b.WriteString(strings.Join(counts, "  ") + "\n")
+	}
+
+	for i, fw := range s.Firmware {
+		if i == maxBannerFirmware {
+			b.WriteString(dimStyle.Render(fmt.Sprintf("  ... %d more", len(s.Firmware)-i)) + "\n")
+			break
+		}
+		fmt.Fprintf(&b, "  %-5s %s  %s\n", fw.Kind, fw.Version, dimStyle.Render(fw.Resource))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// formatConditionsSummary counts a resource's conditions, colored by the
+// most severe one
+func formatConditionsSummary(r *rvfs.Resource) string {
+	switch len(r.Conditions) {
+	case 0:
+		return ""
+	case 1:
+		return severityStyle(r.ConditionSeverity()).Render("1 condition")
+	default:
+		return severityStyle(r.ConditionSeverity()).Render(fmt.Sprintf("%d conditions", len(r.Conditions)))
+	}
+}
+
+func getEntriesSummary(entries []*rvfs.Entry) string {
+	children := 0
+	links := 0
+	properties := 0
+	denied := 0
+
+	for _, entry := range entries {
+		if entry.Denied {
+			denied++
+		}
+		switch entry.Type {
+		case rvfs.EntryLink:
+			children++
+		case rvfs.EntrySymlink:
+			links++
+		case rvfs.EntryProperty, rvfs.EntryComplex, rvfs.EntryArray:
+			properties++
+		}
+	}
+
+	var parts []string
+	if children > 0 {
+		parts = append(parts, fmt.Sprintf("%d children", children))
+	}
+	if links > 0 {
+		parts = append(parts, fmt.Sprintf("%d links", links))
+	}
+	if properties > 0 {
+		parts = append(parts, fmt.Sprintf("%d props", properties))
+	}
+	if denied > 0 {
+		parts = append(parts, fmt.Sprintf("%d denied", denied))
+	}
+
+	if len(parts) == 0 {
+		return "empty"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// discoverTimeout is how long discover waits for answers by default
+const discoverTimeout = 3 * time.Second
+
+// Discover lists the Redfish services answering SSDP on the local network,
+// with the config line to reach each. The optional argument is how many
+// seconds to wait for answers.
+func Discover(args []string) error {
+	timeout := discoverTimeout
+	if len(args) > 1 {
+		return fmt.Errorf("usage: discover [SECONDS]")
+	}
+	if len(args) == 1 {
+		seconds, err := strconv.Atoi(args[0])
+		if err != nil || seconds <= 0 {
+			return fmt.Errorf("invalid timeout: %s", args[0])
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	t, _ := theme.Load(theme.Config{})
+	applyTheme(t)
+
+	fmt.Printf("Searching for Redfish services (%s)...\n", timeout)
+	services, err := rvfs.Discover(timeout)
+	if err != nil {
+		return err
+	}
+	if len(services) == 0 {
+		fmt.Println("No services answered; SSDP may be disabled on the BMCs or blocked on this network")
+		return nil
+	}
+	for _, svc := range services {
+		fmt.Printf("\n%s  %s\n", childStyle.Render(svc.Endpoint), dimStyle.Render(svc.UUID))
+		fmt.Printf("  endpoint: %s\n", svc.Endpoint)
+	}
+	return nil
+}
+
+// Run connects with cfg and runs the shell until the user exits
+func Run(cfg *config.Config) (err error) {
+	t, err := theme.Load(cfg.Theme)
+	if err != nil {
+		return fmt.Errorf("theme config: %w", err)
+	}
+	applyTheme(t)
+	if err := plugin.RegisterExternal(cfg.Commands); err != nil {
+		return fmt.Errorf("commands config: %w", err)
+	}
+
+	fmt.Printf("Connecting to %s...\n", cfg.Endpoint)
+	vfs, closeVFS, err := cfg.Connect()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := closeVFS(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	// Create navigator
+	nav := NewNavigator(vfs)
+	nav.scrapePolicy = cfg.Scrape
+	nav.redaction = cfg.Redact
+	nav.endpoint, nav.user = cfg.Endpoint, cfg.User
+	toggles := nav.toggles()
+	for name, on := range cfg.Settings.Set {
+		if setting, ok := toggles[name]; ok {
+			*setting = on
+		}
+	}
+
+	// Show what we connected to; these are the first requests that may
+	// need a session
+	summary, err := rvfs.SummarizeService(vfs)
+	if err != nil {
+		return err
+	}
+	fmt.Println(formatBanner(summary))
+	entries, err := vfs.ListAll(nav.cwd)
+	if err != nil {
+		return err
+	}
+	fmt.Println(nav.summaryLine(entries))
+	if quirks := vfs.Quirks(); len(quirks) > 0 {
+		fmt.Printf("Vendor quirks: %s\n", quirks)
+	}
+	if cfg.Simulate.Enabled() {
+		fmt.Println(warnStyle.Render("Simulating: " + cfg.Simulate.String()))
+	}
+	if nav.role, err = rvfs.ResolveRole(vfs, cfg.User); err != nil {
+		fmt.Println(dimStyle.Render(fmt.Sprintf("Role: unknown (%v); writes are not checked", err)))
+	} else {
+		fmt.Printf("Role: %s\n", nav.role)
+	}
+	fmt.Println("Type 'help' for commands")
+
+	// Setup readline with completion preprocessing
+	completer := NewCompleter(nav)
+	listener := NewCompletionListener(nav)
+
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:            getPrompt(nav),
+		HistoryFile:       os.ExpandEnv("$HOME/.bfsh_history"),
+		AutoComplete:      completer,
+		Listener:          listener,
+		InterruptPrompt:   "^C",
+		EOFPrompt:         "exit",
+		HistorySearchFold: true,
+		HistoryLimit:      1000,
+	})
+	if err != nil {
+		return err
+	}
+	defer rl.Close()
+
+	var waitingIn atomic.Pointer[string]
+	stopWatch := make(chan struct{})
+	defer close(stopWatch)
+	go watchPower(nav, rl.Stdout(), &waitingIn, stopWatch)
+
+	// REPL loop, starting with the commands to run on connecting
+	startup := cfg.OnConnect
+	for {
+		rl.SetPrompt(getPrompt(nav))
+
+		var line string
+		if len(startup) > 0 {
+			line, startup = strings.TrimSpace(startup[0]), startup[1:]
+			if line == "" {
+				continue
+			}
+			fmt.Println(getPrompt(nav) + line)
+		} else {
+			if nav.power {
+				cwd := nav.cwd
+				waitingIn.Store(&cwd)
+			}
+			line, err = rl.Readline()
+			waitingIn.Store(nil)
+			if err != nil {
+				if err == readline.ErrInterrupt {
+					if nav.actionMode {
+						nav.actionMode = false
+						fmt.Println("Exited action mode")
+					}
+					continue
+				}
+				break // EOF (^D) exits
+			}
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		transcript := nav.transcript
+		if transcript != nil {
+			transcript.Begin(getPrompt(nav), line)
+		}
+		mark := vfs.Stats().Len()
+		start := time.Now()
+		quit, err := runLine(nav, line)
+		var authErr *rvfs.AuthError
+		if errors.As(err, &authErr) && promptPassword(rl, vfs, cfg.User, cfg.Endpoint) {
+			// Resume the command the expired session cut short
+			start = time.Now()
+			quit, err = runLine(nav, line)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			if len(startup) > 0 {
+				fmt.Println(warnStyle.Render(fmt.Sprintf("on_connect stopped after an error; %d commands not run", len(startup))))
+				startup = nil
+			}
+		}
+		if nav.notify && !nav.actionMode && notifies(line, time.Since(start)) {
+			fmt.Print(notification(line, time.Since(start), err))
+		}
+		requests, dropped := vfs.Stats().Since(mark)
+		if nav.trace {
+			if trace := formatTrace(requests, dropped); trace != "" {
+				fmt.Println(trace)
+			}
+		}
+		if warnings := formatWarnings(requests); warnings != "" {
+			fmt.Println(warnings)
+		}
+		if transcript != nil {
+			transcript.End()
+		}
+		if quit {
+			break
+		}
+	}
+	if nav.transcript != nil {
+		nav.transcript.Stop()
+	}
+	return nil
+}
+
+// runLine executes one input line, returning true when the shell should
+// exit, and the error the command failed with
+func runLine(nav *Navigator, line string) (bool, error) {
+	// Enter action mode
+	if line == "!" && !nav.actionMode {
+		actions, err := discoverActions(nav.vfs, nav.cwd)
+		if err != nil {
+			return false, err
+		}
+		if len(actions) == 0 {
+			fmt.Println("No actions on current resource")
+			return false, nil
+		}
+		nav.actionMode = true
+		printActionList(actions, nav.writeRefusal(nav.cwd))
+		return false, nil
+	}
+
+	// Parse command
+	parts := strings.Fields(line)
+	cmd := parts[0]
+	args := parts[1:]
+
+	if nav.actionMode {
+		if cmd == "!" {
+			nav.actionMode = false
+			fmt.Println("Exited action mode")
+			return false, nil
+		}
+		if cmd == "exit" || cmd == "quit" || cmd == "q" {
+			return true, nil
+		}
+		return false, executeActionCommand(nav, cmd, args)
+	}
+
+	err := executeCommand(nav, cmd, args)
+	return cmd == "exit" || cmd == "quit" || cmd == "q", err
+}
+
+// notifyCommands are the commands that can run long enough for the user
+// to turn to something else: crawls, downloads and the tasks diag follows
+var notifyCommands = []string{"scrape", "download", "diag"}
+
+// notifyAfter is how long one of notifyCommands runs before its end is
+// announced
+const notifyAfter = 10 * time.Second
+
+// notifies reports whether the end of a command line that ran for elapsed
+// is announced
+func notifies(line string, elapsed time.Duration) bool {
+	cmd, _, _ := strings.Cut(line, " ")
+	return elapsed >= notifyAfter && slices.Contains(notifyCommands, cmd)
+}
+
+// notification announces the end of a long command: the terminal bell, a
+// desktop notification (OSC 777) for terminals that show one, and a
+// summary line for the scrollback
+func notification(line string, elapsed time.Duration, err error) string {
+	summary := fmt.Sprintf("%s finished in %s", line, elapsed.Round(time.Second))
+	if err != nil {
+		summary = fmt.Sprintf("%s failed after %s", line, elapsed.Round(time.Second))
+	}
+	return "\a" + termenv.OSC + "777;notify;bfsh;" + summary + termenv.ST + dimStyle.Render(summary) + "\n"
+}
+
+// watchPower polls the PowerState of the system the shell waits for input
+// in, and writes a change above the prompt, so a node power-cycled from
+// elsewhere is noticed before decisions are made on the cached state.
+// waitingIn holds the cwd the shell waits in, nil while a command runs or
+// with powerwatch off. It returns once stop is closed.
+func watchPower(nav *Navigator, out io.Writer, waitingIn *atomic.Pointer[string], stop <-chan struct{}) {
+	ticker := time.NewTicker(rvfs.PowerWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		cwd := waitingIn.Load()
+		if cwd == nil {
+			continue
+		}
+		system := rvfs.HostSystem(nav.vfs, *cwd)
+		if system == "" {
+			continue
+		}
+		change, err := nav.powerWatch.Check(nav.vfs, system)
+		if err != nil || change == nil || waitingIn.Load() == nil {
+			continue
+		}
+		fmt.Fprintln(out, warnStyle.Render("⚡ "+change.String())+dimStyle.Render(" (refresh "+change.System+" updates the cached view)"))
+	}
+}
+
+// promptPassword asks for the password again when the service refuses to
+// renew an expired session, as after the password was rotated, and logs in
+// with it. It returns true once a login succeeds; an empty answer or
+// Ctrl+C gives up, leaving the command's error to be reported.
+func promptPassword(rl *readline.Instance, vfs rvfs.VFS, username, endpoint string) bool {
+	host := endpoint
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	for {
+		password, err := rl.ReadPassword(fmt.Sprintf("Session expired — enter password for %s@%s: ", username, host))
+		if err != nil || len(password) == 0 {
+			return false
+		}
+		err = vfs.Reauthenticate(string(password))
+		if err == nil {
+			return true
+		}
+		fmt.Printf("Error: %v\n", err)
+		var authErr *rvfs.AuthError
+		if !errors.As(err, &authErr) {
+			return false
+		}
+	}
+}
+
+func getPrompt(nav *Navigator) string {
+	if nav.actionMode {
+		return promptActStyle.Render("action> ")
+	}
+	return promptPathStyle.Render(nav.cwd) + "> "
+}
+
+func executeCommand(nav *Navigator, cmd string, args []string) error {
+	switch cmd {
+	case "cd", "pushd", "open", "ls", "ll", "dump", "cat", "refresh", "stat", "privileges", "download", "diag", "locate", "pcie", "topology", "memory", "cpu":
+		var err error
+		if args, err = nav.expandMembers(args); err != nil {
+			return err
+		}
+	}
+
+	switch cmd {
+	case "cd":
+		return nav.cd(rvfs.TargetArg(args))
+
+	case "pushd":
+		return nav.pushd(rvfs.TargetArg(args))
+
+	case "popd":
+		return nav.popd()
+
+	case "dirs":
+		nav.dirs()
+
+	case "open":
+		if len(args) > 0 && args[0] == "-n" {
+			return nav.openPreview(rvfs.TargetArg(args[1:]))
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("usage: open [-n] <path>")
+		}
+		return nav.open(rvfs.TargetArg(args))
+
+	case "ls":
+		opts, target, err := rvfs.ParseListArgs(args)
+		if err != nil {
+			return err
+		}
+		return nav.ls(opts, target)
+
+	case "ll":
+		return nav.ll(rvfs.TargetArg(args))
+
+	case "pwd":
+		fmt.Println(nav.cwd)
+
+	case "dump":
+		return nav.dump(bufio.NewReader(os.Stdin), args)
+
+	case "cat":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: cat <property>")
+		}
+		return nav.cat(args[0])
+
+	case "tree":
+		depth := 2
+		if len(args) > 0 {
+			if d, err := strconv.Atoi(args[0]); err == nil {
+				depth = d
+			}
+		}
+		return nav.tree(depth)
+
+	case "find":
+		cached, actions := false, false
+		for len(args) > 0 && (args[0] == "-c" || args[0] == "--actions") {
+			cached = cached || args[0] == "-c"
+			actions = actions || args[0] == "--actions"
+			args = args[1:]
+		}
+		if actions && len(args) > 1 || !actions && len(args) == 0 {
+			return fmt.Errorf("usage: find [-c] <pattern> | find [-c] --actions [pattern]")
+		}
+		if actions {
+			return nav.findActions(strings.Join(args, ""), cached)
+		}
+		if cached {
+			return nav.findCached(args[0])
+		}
+		return nav.find(args[0])
+
+	case "grep":
+		if len(args) == 0 {
+			return fmt.Errorf("usage: grep <text>")
+		}
+		return nav.grep(strings.Join(args, " "))
+
+	case "scrape":
+		return nav.scrape()
+
+	case "refresh":
+		return nav.refresh(rvfs.TargetArg(args))
+
+	case "stat":
+		return nav.stat(rvfs.TargetArg(args))
+
+	case "privileges":
+		return nav.privileges(rvfs.TargetArg(args))
+
+	case "download":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: download <path> <file>")
+		}
+		return nav.download(args[0], args[1])
+
+	case "diag":
+		return nav.diagCollect(args)
+
+	case "locate":
+		return nav.locate(args)
+
+	case "pcie":
+		return nav.pcie(args)
+	case "topology":
+		return nav.topology(args)
+
+	case "memory":
+		return nav.memory(args)
+
+	case "cpu":
+		return nav.cpu(args)
+
+	case "firmware":
+		return nav.firmware(args)
+
+	case "apply":
+		return nav.apply(bufio.NewReader(os.Stdin), args)
+
+	case "stage":
+		return nav.stage(args)
+
+	case "staged":
+		nav.staged()
+
+	case "unstage":
+		return nav.unstage(args)
+
+	case "commit":
+		return nav.commit(bufio.NewReader(os.Stdin))
+
+	case "undo":
+		return nav.undo(bufio.NewReader(os.Stdin), args)
+
+	case "metrics":
+		if len(args) > 0 && args[0] == "define" {
+			return nav.defineMetrics(bufio.NewReader(os.Stdin), args[1:])
+		}
+		return nav.metrics(args)
+
+	case "cache":
+		if len(args) == 0 {
+			paths := nav.vfs.GetKnownPaths()
+			fmt.Printf("Cache: %d resources\n", len(paths))
+		} else if args[0] == "clear" {
+			nav.vfs.Clear()
+			fmt.Println("Cache cleared")
+		} else if args[0] == "list" {
+			paths := nav.vfs.GetKnownPaths()
+			sort.Strings(paths)
+			for _, path := range paths {
+				fmt.Println(path)
+			}
+		}
+
+	case "stats":
+		if len(args) > 0 && args[0] == "reset" {
+			nav.vfs.Stats().Reset()
+			fmt.Println("Stats reset")
+			return nil
+		}
+		fmt.Print(formatStats(nav.vfs.Stats().Summary()))
+
+	case "trace":
+		return nav.setTrace(args)
+
+	case "redact":
+		return nav.setRedact(args)
+
+	case "transcript":
+		return nav.setTranscript(args)
+
+	case "set":
+		return nav.set(args)
+
+	case "foreach":
+		return nav.foreach(args)
+
+	case "create":
+		return nav.create(bufio.NewReader(os.Stdin), args)
+
+	case "time":
+		if len(args) == 0 {
+			return fmt.Errorf("usage: time <command>")
+		}
+		stats := nav.vfs.Stats()
+		mark := stats.Len()
+		start := time.Now()
+		err := executeCommand(nav, args[0], args[1:])
+		requests, dropped := stats.Since(mark)
+		fmt.Println(formatTiming(time.Since(start), requests, dropped))
+		return err
+
+	case "clear":
+		fmt.Print("\033[H\033[2J")
+
+	case "help", "?":
+		printHelp()
+
+	case "exit", "quit", "q":
+		// Handled in main loop
+		return nil
+
+	default:
+		if p, ok := plugin.Lookup(cmd); ok {
+			return nav.runPlugin(p, args)
+		}
+		return fmt.Errorf("unknown command: %s (type 'help' for commands)", cmd)
+	}
+
+	return nil
+}
+
+// runPlugin runs a plugin command; Ctrl+C interrupts it
+func (n *Navigator) runPlugin(p *plugin.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return p.Run(&plugin.Env{
+		Context:  ctx,
+		VFS:      rvfs.WithContext(ctx, n.vfs),
+		Cwd:      n.cwd,
+		Endpoint: n.endpoint,
+		User:     n.user,
+		Out:      os.Stdout,
+	}, args)
+}
+
+// printActionList displays available actions. When the role may not write
+// to the resource, refusal says why and the actions are shown disabled.
+func printActionList(actions []ActionInfo, refusal error) {
+	fmt.Println()
+	fmt.Println(errorStyle.Render("Actions"))
+	if refusal != nil {
+		fmt.Println(dimStyle.Render("  Disabled: " + refusal.Error()))
+	}
+	for _, a := range actions {
+		line := fmt.Sprintf("  %s", warnStyle.Render(a.ShortName))
+		if refusal != nil {
+			line = "  " + dimStyle.Render(a.ShortName)
+		}
+		if len(a.Allowable) > 0 {
+			var params []string
+			for param, vals := range a.Allowable {
+				params = append(params, fmt.Sprintf("%s=[%s]", param, strings.Join(vals, "|")))
+			}
+			sort.Strings(params)
+			line += fmt.Sprintf("  %s", strings.Join(params, " "))
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
+}
+
+// executeActionCommand handles commands in action mode
+func executeActionCommand(nav *Navigator, cmd string, args []string) error {
+	switch cmd {
+	case "ls":
+		actions, err := discoverActions(nav.vfs, nav.cwd)
+		if err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			action := matchAction(actions, args[0])
+			if action == nil {
+				return fmt.Errorf("unknown action: %s", args[0])
+			}
+			printActionList([]ActionInfo{*action}, nav.writeRefusal(nav.cwd))
+		} else {
+			printActionList(actions, nav.writeRefusal(nav.cwd))
+		}
+		return nil
+
+	case "ll":
+		actions, err := discoverActions(nav.vfs, nav.cwd)
+		if err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			action := matchAction(actions, args[0])
+			if action == nil {
+				return fmt.Errorf("unknown action: %s", args[0])
+			}
+			showActionDetail(nav, action)
+		} else {
+			for i := range actions {
+				showActionDetail(nav, &actions[i])
+			}
+		}
+		return nil
+
+	case "help", "?":
+		printActionHelp()
+		return nil
+
+	default:
+		// Try to match as action invocation
+		actions, err := discoverActions(nav.vfs, nav.cwd)
+		if err != nil {
+			return err
+		}
+		action := matchAction(actions, cmd)
+		if action == nil {
+			return fmt.Errorf("unknown action: %s (type 'help' for commands)", cmd)
+		}
+		if err := nav.writeRefusal(nav.cwd); err != nil {
+			return err
+		}
+		return invokeAction(nav, action, args)
+	}
+}
+
+// writeRefusal returns why the role may not write to the resource at path,
+// or holding the property at path; nil when it may or the role is unknown
+func (n *Navigator) writeRefusal(path string) error {
+	if n.role == nil {
+		return nil
+	}
+	resolved, err := n.vfs.ResolveTarget(n.vfs.Root(), path)
+	if err != nil {
+		return nil
+	}
+	resource := resolved.Resource
+	if resource == nil {
+		if resource, err = n.vfs.Get(resolved.ResourcePath); err != nil {
+			return nil
+		}
+	}
+	return n.role.Refusal(resource)
+}
+
+// showActionDetail shows detailed info for one action
+func showActionDetail(nav *Navigator, action *ActionInfo) {
+	fmt.Println()
+	fmt.Println(errorStyle.Render(action.Name))
+	fmt.Printf("  Target: %s\n", action.Target)
+
+	if action.InfoURI != "" {
+		fmt.Printf("  ActionInfo: %s\n", action.InfoURI)
+
+		// Try to fetch and display the ActionInfo resource
+		resource, err := nav.vfs.Get(action.InfoURI)
+		if err == nil {
+			paramsProp, ok := resource.Properties["Parameters"]
+			if ok && paramsProp.Type == rvfs.PropertyArray {
+				fmt.Println("\n  Parameters:")
+				for _, elem := range paramsProp.Elements {
+					if elem.Type != rvfs.PropertyObject {
+						continue
+					}
+					name := ""
+					dataType := ""
+					required := false
+					var allowable []string
+
+					if n, ok := elem.Children["Name"]; ok && n.Type == rvfs.PropertySimple {
+						name = fmt.Sprintf("%v", n.Value)
+					}
+					if dt, ok := elem.Children["DataType"]; ok && dt.Type == rvfs.PropertySimple {
+						dataType = fmt.Sprintf("%v", dt.Value)
+					}
+					if r, ok := elem.Children["Required"]; ok && r.Type == rvfs.PropertySimple {
+						if b, ok := r.Value.(bool); ok {
+							required = b
+						}
+					}
+					if av, ok := elem.Children["AllowableValues"]; ok && av.Type == rvfs.PropertyArray {
+						for _, v := range av.Elements {
+							if v.Type == rvfs.PropertySimple {
+								allowable = append(allowable, fmt.Sprintf("%v", v.Value))
+							}
+						}
+					}
+
+					reqStr := ""
+					if required {
+						reqStr = errorStyle.Render(" (required)")
+					}
+					fmt.Printf("    %s%s  %s", warnStyle.Render(name), reqStr, dataType)
+					if len(allowable) > 0 {
+						fmt.Printf("  [%s]", strings.Join(allowable, "|"))
+					}
+					fmt.Println()
+				}
+			}
+		}
+	}
+
+	if len(action.Allowable) > 0 {
+		if action.InfoURI == "" {
+			fmt.Println("\n  Parameters:")
+		} else {
+			fmt.Println("\n  Allowable values (from annotations):")
+		}
+		for param, vals := range action.Allowable {
+			fmt.Printf("    %s: [%s]\n", warnStyle.Render(param), strings.Join(vals, "|"))
+		}
+	}
+
+	fmt.Println()
+}
+
+// parseActionBody parses key=value and key:=json arguments into a JSON
+// body, checking values against the parameters' AllowableValues
+func parseActionBody(action *ActionInfo, args []string) ([]byte, error) {
+	body := make(map[string]any)
+	for _, arg := range args {
+		key, val, err := rvfs.ParseParameter(arg)
+		if err != nil {
+			return nil, err
+		}
+		if allowed, ok := action.Allowable[key]; ok {
+			if s, isString := val.(string); !isString || !slices.Contains(allowed, s) {
+				return nil, fmt.Errorf("invalid value %v for %s (allowed: %s)", val, key, strings.Join(allowed, ", "))
+			}
+		}
+		body[key] = val
+	}
+
+	return json.MarshalIndent(body, "", "  ")
+}
+
+// invokeAction executes a Redfish action with confirmation. The same
+// action sent again within rvfs.RepeatWindow is refused unless --again is
+// among the arguments.
+func invokeAction(nav *Navigator, action *ActionInfo, args []string) error {
+	again := slices.Contains(args, "--again")
+	args = slices.DeleteFunc(slices.Clone(args), func(arg string) bool { return arg == "--again" })
+	jsonBody, err := parseActionBody(action, args)
+	if err != nil {
+		return err
+	}
+	if !again {
+		if err := nav.posts.Check(action.Target, jsonBody); err != nil {
+			return fmt.Errorf("%w; repeat it with --again", err)
+		}
+	}
+
+	// Show confirmation
+	fmt.Printf("\n%s %s\n", errorStyle.Render("POST"), action.Target)
+	if len(jsonBody) > 2 { // Not just "{}"
+		fmt.Println(string(jsonBody))
+	}
+	fmt.Print("\nConfirm? [y/N] ")
+
+	var confirm string
+	fmt.Scanln(&confirm)
+	if confirm != "y" && confirm != "Y" {
+		fmt.Println("Cancelled")
+		return nil
+	}
+
+	// Execute
+	resp, err := nav.posts.Post(nav.vfs, action.Target, jsonBody, again)
+	nav.history.RecordPost(action.Target, resp, err)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nHTTP %d\n", resp.StatusCode)
+	for _, m := range resp.Messages {
+		fmt.Println(formatMessage(m, 2))
+	}
+	if len(resp.Body) > 0 {
+		var buf bytes.Buffer
+		if json.Indent(&buf, resp.Body, "", "  ") == nil {
+			fmt.Println(buf.String())
+		} else {
+			fmt.Println(string(resp.Body))
+		}
+	}
+	return nil
+}
+
+// bulkConcurrency caps the POSTs foreach runs at once
+const bulkConcurrency = 8
+
+// bulkTarget is one resource matched by foreach
+type bulkTarget struct {
+	Resource string // Matched resource path
+	Target   string // Action target URI; empty when skipped
+	Err      error  // Why the resource is skipped
+}
+
+// planBulk resolves a foreach: the resources matching pattern, and for each
+// the target of the named action. Resources without the action, whose
+// allowable values reject the arguments, or that role may not write to are
+// skipped. The body is the same for every target.
+func planBulk(vfs rvfs.VFS, role *rvfs.SessionRole, cwd, pattern, actionName string, args []string) ([]bulkTarget, []byte, error) {
+	paths, err := rvfs.Glob(vfs, cwd, pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(paths) == 0 {
+		return nil, nil, fmt.Errorf("no match: %s", pattern)
+	}
+
+	var body []byte
+	plan := make([]bulkTarget, len(paths))
+	for i, path := range paths {
+		plan[i].Resource = path
+		actions, err := discoverActions(vfs, path)
+		if err != nil {
+			plan[i].Err = err
+			continue
+		}
+		action := matchAction(actions, actionName)
+		if action == nil {
+			plan[i].Err = fmt.Errorf("no action %s", actionName)
+			continue
+		}
+		if body, err = parseActionBody(action, args); err != nil {
+			plan[i].Err = err
+			continue
+		}
+		if role != nil {
+			if resource, err := vfs.Get(path); err == nil {
+				if plan[i].Err = role.Refusal(resource); plan[i].Err != nil {
+					continue
+				}
+			}
+		}
+		plan[i].Target = action.Target
+	}
+	return plan, body, nil
+}
+
+// foreach invokes an action on every resource matching a pattern:
+// foreach Systems/* ! Reset ResetType=GracefulRestart. All POSTs are
+// previewed and confirmed once, then run bulkConcurrency at a time. POSTs
+// sent again within rvfs.RepeatWindow are refused unless --again is among
+// the arguments.
+func (n *Navigator) foreach(args []string) error {
+	if len(args) < 3 || args[1] != "!" {
+		return fmt.Errorf("usage: foreach <pattern> ! <action> [key=value ...] [--again]")
+	}
+	again := slices.Contains(args[3:], "--again")
+	params := slices.DeleteFunc(slices.Clone(args[3:]), func(arg string) bool { return arg == "--again" })
+	plan, body, err := planBulk(n.vfs, n.role, n.cwd, args[0], args[2], params)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	var targets []string
+	for _, t := range plan {
+		if t.Err != nil {
+			fmt.Printf("%s %s: %v\n", dimStyle.Render("skip"), t.Resource, t.Err)
+			continue
+		}
+		fmt.Printf("%s %s\n", errorStyle.Render("POST"), t.Target)
+		targets = append(targets, t.Target)
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no resource matching %s has action %s", args[0], args[2])
+	}
+	if !again {
+		if err := n.posts.CheckAll(targets, body); err != nil {
+			return fmt.Errorf("%w; repeat it with --again", err)
+		}
+	}
+	if len(body) > 2 { // Not just "{}"
+		fmt.Println(string(body))
+	}
+	fmt.Printf("\nRun %d POSTs? [y/N] ", len(targets))
+
+	var confirm string
+	fmt.Scanln(&confirm)
+	if confirm != "y" && confirm != "Y" {
+		fmt.Println("Cancelled")
+		return nil
+	}
+
+	results := n.posts.PostAll(n.vfs, targets, body, bulkConcurrency, again)
+	for _, r := range results {
+		n.history.RecordPost(r.Path, r.Response, r.Err)
+	}
+	fmt.Print(formatBulkResults(results))
+	return nil
+}
+
+// formatBulkResults renders one line per POST, with any service messages,
+// and a count of successes and failures
+func formatBulkResults(results []rvfs.BulkResult) string {
+	var b strings.Builder
+	b.WriteString("\n")
+	failed := 0
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed++
+			fmt.Fprintf(&b, "%s  %s: %v\n", errorStyle.Render("failed  "), r.Path, r.Err)
+		case r.Response.StatusCode < 200 || r.Response.StatusCode > 299:
+			failed++
+			fmt.Fprintf(&b, "%s  %s\n", errorStyle.Render(fmt.Sprintf("HTTP %d", r.Response.StatusCode)), r.Path)
+		default:
+			fmt.Fprintf(&b, "%s  %s\n", healthOKStyle.Render(fmt.Sprintf("HTTP %d", r.Response.StatusCode)), r.Path)
+		}
+		if r.Response != nil {
+			for _, m := range r.Response.Messages {
+				b.WriteString(formatMessage(m, 2) + "\n")
+			}
+		}
+	}
+	fmt.Fprintf(&b, "%d succeeded, %d failed\n", len(results)-failed, failed)
+	return b.String()
+}
+
+// applyArgs are the arguments of apply
+type applyArgs struct {
+	desired  string // Desired state YAML file to plan from
+	plan     string // Saved plan to apply instead, from --plan
+	output   string // File to save the plan to, from -o
+	planOnly bool   // Show or save the plan without applying it
+	again    bool   // Send actions just sent regardless, from --again
+}
+
+// parseApplyArgs parses the arguments of apply: a desired state file with
+// --plan-only and -o, or --plan and a saved plan, either with --again
+func parseApplyArgs(args []string) (*applyArgs, error) {
+	usage := fmt.Errorf("usage: apply <desired.yaml> [--plan-only] [-o plan.json] [--again] | apply --plan <plan.json> [--again]")
+	a := &applyArgs{}
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--plan-only":
+			a.planOnly = true
+		case args[i] == "--again":
+			a.again = true
+		case args[i] == "-o" || args[i] == "--plan":
+			if i+1 >= len(args) {
+				return nil, usage
+			}
+			if args[i] == "-o" {
+				a.output = args[i+1]
+			} else {
+				a.plan = args[i+1]
+			}
+			i++
+		case a.desired == "":
+			a.desired = args[i]
+		default:
+			return nil, usage
+		}
+	}
+	if (a.desired == "") == (a.plan == "") || (a.plan != "" && (a.planOnly || a.output != "")) {
+		return nil, usage
+	}
+	return a, nil
+}
+
+// apply brings the service to the desired state of a YAML file: the plan
+// of what differs is shown, then sent once confirmed. The plan can instead
+// be saved with -o for review, and applied later with --plan as long as
+// the properties it changes still have the values it was made against.
+// Actions just sent are refused, like any action, unless --again is given.
+func (n *Navigator) apply(in *bufio.Reader, args []string) error {
+	a, err := parseApplyArgs(args)
+	if err != nil {
+		return err
+	}
+	var plan *rvfs.Plan
+	if a.plan != "" {
+		if plan, err = rvfs.LoadPlan(a.plan); err != nil {
+			return err
+		}
+		stale, err := rvfs.StaleChanges(n.vfs, plan)
+		if err != nil {
+			return err
+		}
+		if len(stale) > 0 {
+			return staleError(a.plan+" is stale; plan again", stale)
+		}
+	} else {
+		desired, err := rvfs.LoadDesiredState(a.desired)
+		if err != nil {
+			return err
+		}
+		if plan, err = rvfs.PlanApply(n.vfs, desired); err != nil {
+			return err
+		}
+		if a.output != "" {
+			if err := rvfs.SavePlan(a.output, plan); err != nil {
+				return err
+			}
+			fmt.Printf("Saved plan of %d changes to %s\n", len(plan.Changes), a.output)
+		}
+	}
+	if len(plan.Changes) == 0 {
+		if a.plan != "" {
+			fmt.Printf("No changes in %s\n", a.plan)
+		} else {
+			fmt.Printf("No changes; the service matches %s\n", a.desired)
+		}
+		return nil
+	}
+	if a.planOnly {
+		fmt.Print(formatPlan(plan))
+		return nil
+	}
+	for _, c := range plan.Changes {
+		if err := n.writeRefusal(c.Resource); err != nil {
+			return err
+		}
+	}
+	if !a.again {
+		if err := n.posts.CheckPlan(plan); err != nil {
+			return fmt.Errorf("%w; repeat it with --again", err)
+		}
+	}
+
+	fmt.Print(formatPlan(plan))
+	if confirm := promptLine(in, "\nApply? [y/N] "); confirm != "y" && confirm != "Y" {
+		fmt.Println("Cancelled")
+		return nil
+	}
+	results := rvfs.ApplyPlan(n.vfs, plan, &n.posts, a.again)
+	n.history.Record(results)
+	fmt.Print(formatApplyResults(results))
+	return nil
+}
+
+// staleError refuses a plan whose properties changed since it was made,
+// naming each with the value the plan expected and the live one
+func staleError(reason string, stale []rvfs.Change) error {
+	lines := make([]string, len(stale))
+	for i, c := range stale {
+		lines[i] = fmt.Sprintf("  %s %s: planned from %s, now %s", c.Resource, c.PropertyName(), planValue(c.Old), planValue(c.New))
+	}
+	return fmt.Errorf("%s:\n%s", reason, strings.Join(lines, "\n"))
+}
+
+// formatPlan lists the changes of an apply plan by resource, old value to
+// new, then the actions it invokes
+func formatPlan(plan *rvfs.Plan) string {
+	var b strings.Builder
+	resources, actions := 0, 0
+	last := ""
+	for _, c := range plan.Changes {
+		switch c.Method {
+		case "PATCH":
+			if c.Resource != last {
+				fmt.Fprintf(&b, "%s %s\n", warnStyle.Render("PATCH"), childStyle.Render(c.Resource))
+				last = c.Resource
+				resources++
+			}
+			old := dimStyle.Render("(unset)")
+			if c.Old != nil {
+				old = planValue(c.Old)
+			}
+			fmt.Fprintf(&b, "  %s: %s → %s\n", propStyle.Render(c.PropertyName()), old, planValue(c.New))
+		case "POST":
+			actions++
+			fmt.Fprintf(&b, "%s %s %s\n  %s\n", errorStyle.Render("POST"), c.Target, dimStyle.Render(c.PropertyName()), planValue(c.New))
+		}
+	}
+	fmt.Fprintf(&b, "Plan: %d changes to %d resources, %d actions\n", plan.Patches(), resources, actions)
+	return b.String()
+}
+
+// planValue renders a plan value as compact JSON
+func planValue(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// formatApplyResults reports each request of an applied plan with the
+// messages the service returned, then how many succeeded
+func formatApplyResults(results []rvfs.ApplyResult) string {
+	var b strings.Builder
+	b.WriteString("\n")
+	failed := 0
+	for _, r := range results {
+		request := r.Method + " " + r.Path
+		if r.Method == "PATCH" {
+			request += fmt.Sprintf(" (%d properties)", len(r.Changes))
+		}
+		switch {
+		case r.Err != nil:
+			failed++
+			fmt.Fprintf(&b, "%s  %s: %v\n", errorStyle.Render("failed  "), request, r.Err)
+		default:
+			fmt.Fprintf(&b, "%s  %s\n", healthOKStyle.Render(fmt.Sprintf("HTTP %d", r.Response.StatusCode)), request)
+		}
+		if r.Response != nil {
+			for _, m := range r.Response.Messages {
+				b.WriteString(formatMessage(m, 2) + "\n")
+			}
+		}
+	}
+	fmt.Fprintf(&b, "%d succeeded, %d failed\n", len(results)-failed, failed)
+	return b.String()
+}
+
+// stage records a property change for commit
+func (n *Navigator) stage(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: stage <property> <value>")
+	}
+	change, err := n.staging.Stage(n.vfs, n.cwd, args[0], rvfs.TargetArg(args[1:]))
+	if err != nil {
+		return err
+	}
+	if change == nil {
+		fmt.Printf("%s already has that value; nothing staged (%d staged)\n", args[0], n.staging.Len())
+		return nil
+	}
+	fmt.Printf("Staged %s %s: %s → %s (%d staged)\n", childStyle.Render(change.Resource), propStyle.Render(change.PropertyName()),
+		planValue(change.Old), planValue(change.New), n.staging.Len())
+	return nil
+}
+
+// staged lists the staged changes as the PATCHes commit sends
+func (n *Navigator) staged() {
+	if n.staging.Len() == 0 {
+		fmt.Println("Nothing staged")
+		return
+	}
+	fmt.Print(formatPlan(n.staging.Plan()))
+}
+
+// unstage drops the staged changes of a property or resource, or all of
+// them without an argument
+func (n *Navigator) unstage(args []string) error {
+	dropped := 0
+	if len(args) == 0 {
+		dropped = n.staging.Clear()
+	} else {
+		var err error
+		if dropped, err = n.staging.Unstage(n.vfs, n.cwd, rvfs.TargetArg(args)); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Dropped %d staged changes (%d staged)\n", dropped, n.staging.Len())
+	return nil
+}
+
+// commit sends the staged changes, one PATCH per resource, after
+// confirmation, unless what they change has changed since they were
+// staged. Changes the service refuses stay staged.
+func (n *Navigator) commit(in *bufio.Reader) error {
+	if n.staging.Len() == 0 {
+		fmt.Println("Nothing staged")
+		return nil
+	}
+	plan := n.staging.Plan()
+	for _, c := range plan.Changes {
+		if err := n.writeRefusal(c.Resource); err != nil {
+			return err
+		}
+	}
+	stale, err := rvfs.StaleChanges(n.vfs, plan)
+	if err != nil {
+		return err
+	}
+	if len(stale) > 0 {
+		return staleError("properties changed since they were staged; unstage or stage them again", stale)
+	}
+
+	fmt.Print(formatPlan(plan))
+	if confirm := promptLine(in, "\nCommit? [y/N] "); confirm != "y" && confirm != "Y" {
+		fmt.Println("Cancelled")
+		return nil
+	}
+	results := rvfs.ApplyPlan(n.vfs, plan, &n.posts, false)
+	n.staging.Committed(results)
+	n.history.Record(results)
+	fmt.Print(formatApplyResults(results))
+	if n.staging.Len() > 0 {
+		fmt.Printf("%d changes stay staged\n", n.staging.Len())
+	}
+	return nil
+}
+
+// undo reverses the last count writes of the session, after confirmation,
+// by PATCHing the properties they changed back to their earlier values.
+// Actions and creates are named and forgotten, as they cannot be reversed,
+// and nothing is sent when a property changed since it was written.
+func (n *Navigator) undo(in *bufio.Reader, args []string) error {
+	count := 1
+	if len(args) > 1 {
+		return fmt.Errorf("usage: undo [count]")
+	}
+	if len(args) == 1 {
+		var err error
+		if count, err = strconv.Atoi(args[0]); err != nil || count < 1 {
+			return fmt.Errorf("usage: undo [count]")
+		}
+	}
+	if n.history.Len() == 0 {
+		fmt.Println("Nothing to undo")
+		return nil
+	}
+	if count > n.history.Len() {
+		return fmt.Errorf("only %d writes to undo", n.history.Len())
+	}
+
+	plan, irreversible := n.history.Undo(count)
+	for _, w := range irreversible {
+		fmt.Println(warnStyle.Render(fmt.Sprintf("%s %s is an action or a create, which cannot be undone; skipped", w.Method, w.Path)))
+	}
+	if len(plan.Changes) == 0 {
+		n.history.Undone(count, nil)
+		return nil
+	}
+	for _, c := range plan.Changes {
+		if err := n.writeRefusal(c.Resource); err != nil {
+			return err
+		}
+	}
+	stale, err := rvfs.StaleChanges(n.vfs, plan)
+	if err != nil {
+		return err
+	}
+	if len(stale) > 0 {
+		return staleError("properties changed since they were written; undo would overwrite them", stale)
+	}
+
+	fmt.Print(formatPlan(plan))
+	if confirm := promptLine(in, "\nUndo? [y/N] "); confirm != "y" && confirm != "Y" {
+		fmt.Println("Cancelled")
+		return nil
+	}
+	results := rvfs.ApplyPlan(n.vfs, plan, &n.posts, false)
+	n.history.Undone(count, results)
+	fmt.Print(formatApplyResults(results))
+	return nil
+}
+
+// create adds a member to a collection: the fields the collection's
+// capabilities mark required or optional on create are prompted for, then
+// any others as key=value, and the body is POSTed after confirmation
+func (n *Navigator) create(in *bufio.Reader, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: create <collection>")
+	}
+	resolved, err := n.vfs.ResolveTarget(n.cwd, args[0])
+	if err != nil {
+		return err
+	}
+	if resolved.Type != rvfs.TargetResource && resolved.Type != rvfs.TargetLink {
+		return fmt.Errorf("not a collection: %s", args[0])
+	}
+	if err := n.writeRefusal(resolved.ResourcePath); err != nil {
+		return err
+	}
+	capabilities, err := rvfs.CreateCapabilities(n.vfs, resolved.ResourcePath)
+	if err != nil {
+		return err
+	}
+
+	target := resolved.ResourcePath
+	var fields []rvfs.CreateField
+	if len(capabilities) > 0 {
+		c := capabilities[0]
+		if len(capabilities) > 1 {
+			fmt.Println()
+			for i, c := range capabilities {
+				fmt.Printf("  %d  %s\n", i+1, c.UseCase)
+			}
+			choice, err := strconv.Atoi(promptLine(in, fmt.Sprintf("Use case [1-%d]: ", len(capabilities))))
+			if err != nil || choice < 1 || choice > len(capabilities) {
+				fmt.Println("Cancelled")
+				return nil
+			}
+			c = capabilities[choice-1]
+		}
+		target, fields = c.TargetCollection, c.Fields
+	}
+
+	values, err := promptFields(in, fields)
+	if err != nil {
+		return err
+	}
+	if err := rvfs.CheckFields(fields, values); err != nil {
+		return err
+	}
+	body, err := rvfs.CreateBody(values)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%s %s\n%s\n", errorStyle.Render("POST"), target, body)
+	if confirm := promptLine(in, "\nConfirm? [y/N] "); confirm != "y" && confirm != "Y" {
+		fmt.Println("Cancelled")
+		return nil
+	}
+
+	resp, err := n.vfs.Post(target, body)
+	n.history.RecordPost(target, resp, err)
+	if err != nil {
+		return err
+	}
+	n.vfs.Invalidate(target)
+	fmt.Print(n.formatCreateResult(resp))
+	return nil
+}
+
+// formatCapabilities lists the use cases a collection offers for creating
+// members, the fields of each, and how full the collection is
+func (n *Navigator) formatCapabilities(collection *rvfs.Resource) string {
+	var b strings.Builder
+	cc := collection.Capabilities
+	for _, c := range cc.Capabilities {
+		fmt.Fprintf(&b, "  %s %s\n", boldStyle.Render(c.UseCase), dimStyle.Render("→ "+c.TargetCollection))
+		fields, err := rvfs.CapabilityFields(n.vfs, c)
+		if err != nil {
+			fmt.Fprintf(&b, "    %s\n", dimStyle.Render(err.Error()))
+			continue
+		}
+		if len(fields) > 0 {
+			fmt.Fprintf(&b, "    %s\n", formatFieldList(fields))
+		}
+	}
+	if len(cc.Capabilities) == 0 {
+		fmt.Fprintf(&b, "  %s\n", dimStyle.Render("no use cases; new members are not accepted"))
+	}
+	if cc.MaxMembers > 0 {
+		fmt.Fprintf(&b, "  %d of %d members\n", rvfs.MemberCount(collection), cc.MaxMembers)
+	}
+	return b.String()
+}
+
+// formatFieldList renders create fields on one line: required ones bold,
+// allowable values in brackets
+func formatFieldList(fields []rvfs.CreateField) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = fieldLabel(f)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// fieldLabel renders a create field: bold when required, with its
+// allowable values
+func fieldLabel(f rvfs.CreateField) string {
+	label := f.Name
+	if f.Required {
+		label = boldStyle.Render(f.Name)
+	} else {
+		label += dimStyle.Render(" (optional)")
+	}
+	if len(f.Allowable) > 0 {
+		label += " " + dimStyle.Render("["+strings.Join(f.Allowable, "|")+"]")
+	}
+	return label
+}
+
+// promptFields asks for each field in turn, then for further key=value
+// fields until an empty line. Required fields are asked again until given;
+// values are checked against the allowable ones.
+func promptFields(in *bufio.Reader, fields []rvfs.CreateField) (map[string]string, error) {
+	values := make(map[string]string)
+	if len(fields) > 0 {
+		fmt.Println()
+	}
+	for _, f := range fields {
+		label := fieldLabel(f)
+		for {
+			val := promptLine(in, label+": ")
+			if val == "" && !f.Required {
+				break
+			}
+			if val == "" {
+				if _, err := in.Peek(1); err != nil {
+					return nil, fmt.Errorf("%s is required", f.Name)
+				}
+				continue
+			}
+			if len(f.Allowable) > 0 && !slices.Contains(f.Allowable, val) {
+				fmt.Printf("  allowed: %s\n", strings.Join(f.Allowable, ", "))
+				continue
+			}
+			values[f.Name] = val
+			break
+		}
+	}
+
+	fmt.Println(dimStyle.Render("\nMore fields as key=value, empty line to finish"))
+	for {
+		line := promptLine(in, "+ ")
+		if line == "" {
+			return values, nil
+		}
+		key, val, ok := strings.Cut(line, "=")
+		if !ok || key == "" {
+			fmt.Printf("  invalid field %q (expected key=value)\n", line)
+			continue
+		}
+		values[key] = val
+	}
+}
+
+// promptLine prints a prompt and reads a line, trimmed
+func promptLine(in *bufio.Reader, prompt string) string {
+	fmt.Print(prompt)
+	line, _ := in.ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// formatCreateResult summarizes a create request: the status and messages,
+// then the identity and status of the new resource
+func (n *Navigator) formatCreateResult(resp *rvfs.Response) string {
+	var b strings.Builder
+	style := healthOKStyle
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		style = errorStyle
+	}
+	fmt.Fprintf(&b, "\n%s\n", style.Render(fmt.Sprintf("HTTP %d", resp.StatusCode)))
+	for _, m := range resp.Messages {
+		b.WriteString(formatMessage(m, 2) + "\n")
+	}
+
+	created := rvfs.CreatedPath(resp)
+	if created == "" {
+		return b.String()
+	}
+	fmt.Fprintf(&b, "Created %s\n", childStyle.Render(created))
+	res, err := n.vfs.Get(created)
+	if err != nil {
+		return b.String()
+	}
+	for _, name := range []string{"Id", "Name"} {
+		if p, ok := res.Properties[name]; ok && p.Type == rvfs.PropertySimple {
+			fmt.Fprintf(&b, "  %s: %s\n", propStyle.Render(name), formatTypedValue(p.Value))
+		}
+	}
+	if status, ok := res.Properties["Status"]; ok && status.Type == rvfs.PropertyObject {
+		for _, name := range []string{"State", "Health"} {
+			if p, ok := status.Children[name]; ok && p.Type == rvfs.PropertySimple {
+				fmt.Fprintf(&b, "  %s: %s\n", propStyle.Render(name), formatHealthValue(name, p.Value))
+			}
+		}
+	}
+	return b.String()
+}
+
+// formatMessage renders a Redfish message colored by severity
+func formatMessage(m rvfs.Message, indent int) string {
+	pad := strings.Repeat(" ", indent)
+	line := pad + severityStyle(m.Severity).Render(m.String())
+	if m.Resolution != "" {
+		line += "\n" + pad + "  " + dimStyle.Render("Resolution: "+m.Resolution)
+	}
+	return line
+}
+
+// severityStyle returns the style of a message or condition severity
+func severityStyle(severity string) lipgloss.Style {
+	switch strings.ToUpper(severity) {
+	case "OK":
+		return healthOKStyle
+	case "WARNING":
+		return healthWarnStyle
+	case "CRITICAL":
+		return healthCriticalStyle
+	}
+	return boldStyle
+}
+
+// formatCondition renders a Status condition like a message, followed by
+// when it was raised, the resource it originates from and its log entry
+func (n *Navigator) formatCondition(c rvfs.Condition, indent int) string {
+	pad := strings.Repeat(" ", indent) + "  "
+	line := formatMessage(c.Message, indent)
+	if c.Timestamp != "" {
+		line += "\n" + pad + dimStyle.Render("Raised: ") + n.formatValue("Timestamp", c.Timestamp)
+	}
+	if c.OriginOfCondition != "" {
+		line += "\n" + pad + dimStyle.Render("Origin: ") + n.originName(c.OriginOfCondition)
+	}
+	if c.LogEntry != "" {
+		line += "\n" + pad + dimStyle.Render("Log entry: ") + linkStyle.Render(c.LogEntry)
+	}
+	return line
+}
+
+// originName names the resource a condition originates from, fetching it
+// for its Name; the path alone is shown when it cannot be fetched
+func (n *Navigator) originName(path string) string {
+	res, err := n.vfs.Get(path)
+	if err != nil {
+		return linkStyle.Render(path)
+	}
+	if prop, ok := res.Properties["Name"]; ok {
+		if name, ok := prop.Value.(string); ok && name != "" {
+			return name + " " + dimStyle.Render("→") + " " + linkStyle.Render(path)
+		}
+	}
+	return linkStyle.Render(path)
+}
+
+// printActionHelp shows action mode help
+func printActionHelp() {
+	cmd := func(s string) string { return linkStyle.Render(s) }
+	arg := func(s string) string { return warnStyle.Render(s) }
+
+	fmt.Println()
+	fmt.Println(boldStyle.Render("Action Mode"))
+	fmt.Printf("  %s %-16s %s\n", cmd("ls"), "", "List available actions")
+	fmt.Printf("  %s %-16s %s\n", cmd("ll"), arg("<action>"), "Show action details and parameters")
+	fmt.Printf("  %s %-16s %s\n", cmd("<action>"), arg("[k=v k:=json]"), "Invoke action (with confirmation); k=v is a string")
+	fmt.Printf("  %s %-16s %s\n", cmd("<action>"), arg("--again ..."), "Send an action just sent again, within seconds")
+	fmt.Printf("  %s %-16s %s\n", cmd("!"), "", "Exit action mode")
+	fmt.Printf("  %s %-16s %s\n", cmd("help"), "", "Show this help")
+	fmt.Println()
+	fmt.Println(boldStyle.Render("Example"))
+	fmt.Printf("  %s\n", warnStyle.Render("Reset ResetType=GracefulShutdown"))
+	fmt.Println()
+}
+
+func printHelp() {
+	cmd := func(s string) string { return linkStyle.Render(s) }
+	arg := func(s string) string { return warnStyle.Render(s) }
+	dim := func(s string) string { return dimStyle.Render(s) }
+
+	fmt.Println()
+	fmt.Println(boldStyle.Render("Navigation"))
+	fmt.Printf("  %s %-12s %s    %s %-12s %s\n", cmd("cd"), arg("<path>"), "Navigate to resource/property", cmd("open"), arg("[-n] <path>"), "Follow link to target resource (-n: show the hops only)")
+	fmt.Printf("  %s %-12s %s    %s %-12s %s\n", cmd("pwd"), "", "Print working directory", cmd("ls"), arg("[path]"), "List entries (-t -S -a, children|props|links)")
+	fmt.Printf("  %s %-12s %s\n", cmd("ll"), arg("[path]"), "Show formatted content (YAML-style)")
+	fmt.Printf("  %s %-12s %s    %s %-12s %s\n", cmd("cd"), arg("-"), "Previous directory (-N: Nth back)", cmd("pushd"), arg("[path]"), "Change directory, saving this one")
+	fmt.Printf("  %s %-12s %s    %s %-12s %s\n", cmd("popd"), "", "Return to the last saved directory", cmd("dirs"), "", "Show the directory stack")
+
+	fmt.Println()
+	fmt.Println(boldStyle.Render("Viewing & Search"))
+	fmt.Printf("  %s %-12s %s    %s %-12s %s\n", cmd("dump"), arg("[path]"), "Show raw JSON (-c compact, -n N elements, --fields A,B, -o file)", cmd("tree"), arg("[depth]"), "Tree view (default: 2)")
+	fmt.Printf("  %s %-12s %s\n", cmd("cat"), arg("<property>"), "Print a property's bare value, for scripts")
+	fmt.Printf("  %s %-12s %s\n", cmd("find"), arg("<pattern>"), "Search properties recursively (-c: cached resources only, instant)")
+	fmt.Printf("  %s %-12s %s\n", cmd("find"), arg("--actions"), "List every action below cwd with its parameters; a pattern filters names (-c: cached only)")
+	fmt.Printf("  %s %-12s %s\n", cmd("grep"), arg("<text>"), "Search property values of cached resources")
+
+	fmt.Println()
+	fmt.Println(boldStyle.Render("Fetching"))
+	fmt.Printf("  %s %-12s %s\n", cmd("scrape"), "", "Crawl all reachable resources from cwd")
+	fmt.Printf("  %s %-12s %s\n", cmd("refresh"), arg("[path]"), "Re-fetch a resource (invalidate + fetch)")
+	fmt.Printf("  %s %-12s %s\n", cmd("download"), arg("<path> <file>"), "Stream a binary payload (e.g. AdditionalDataURI) to a file")
+	fmt.Printf("  %s %-12s %s\n", cmd("diag"), arg("collect [path]"), "CollectDiagnosticData, follow its task, download the data (-o file)")
+	fmt.Printf("  %s %-12s %s\n", cmd("metrics"), arg("list|show"), "Telemetry reports; show <report> draws sparklines (-t table, -o file.csv)")
+	fmt.Printf("  %s %-12s %s\n", cmd("metrics"), arg("define [id]"), "Create a MetricReportDefinition step by step")
+	fmt.Printf("  %s %-12s %s\n", cmd("memory"), arg("[path] [--failed]"), "Memory summary and modules of the systems")
+	fmt.Printf("  %s %-12s %s\n", cmd("cpu"), arg("[path]"), "Processors and accelerators of the systems")
+	fmt.Printf("  %s %-12s %s\n", cmd("firmware"), "", "Firmware inventory and what can be updated")
+	fmt.Printf("  %s %-12s %s\n", cmd("pcie"), arg("[path]"), "PCIe devices, functions and what they serve")
+	fmt.Printf("  %s %-12s %s\n", cmd("topology"), arg("[chassis]"), "Chassis inside one another, the systems and managers in them")
+	fmt.Printf("  %s %-12s %s\n", cmd("locate"), arg("on|off [path]"), "Blink the locator LED of a system, chassis or drive")
+	fmt.Printf("  %s %-12s %s\n", cmd("stat"), arg("[path]"), "Methods the service allows (OPTIONS), and whether PATCH/DELETE would pass")
+	fmt.Printf("  %s %-12s %s\n", cmd("privileges"), arg("[path]"), "Roles the PrivilegeRegistry lets GET/PATCH/POST/DELETE a resource")
+
+	fmt.Println()
+	fmt.Println(boldStyle.Render("Other"))
+	fmt.Printf("  %s %-12s %s    %s %-12s %s\n", cmd("!"), "", "Enter action mode (POST)", cmd("cache"), arg("[cmd]"), "Cache ops (clear, list)")
+	fmt.Printf("  %s %-12s %s    %s %-12s %s\n", cmd("stats"), arg("[reset]"), "Request and cache statistics", cmd("time"), arg("<cmd>"), "Time a command (wall/HTTP)")
+	fmt.Printf("  %s %-12s %s\n", cmd("trace"), arg("[on|off]"), "Print the HTTP requests each command causes")
+	fmt.Printf("  %s %-12s %s\n", cmd("transcript"), arg("[on [file]|off]"), "Tee input and output, uncolored, to a file")
+	fmt.Printf("  %s %-12s %s\n", cmd("redact"), arg("[on|off]"), "Mask the values of the properties the config's redact list names")
+	fmt.Printf("  %s %-12s %s\n", cmd("set"), arg("humanize"), "Show sizes, durations and times in human units (on|off)")
+	fmt.Printf("  %s %-12s %s\n", cmd("set"), arg("ages"), "Mark ls and tree entries by how long ago they were fetched (on|off)")
+	fmt.Printf("  %s %-12s %s\n", cmd("set"), arg("notify"), "Ring and notify when scrape, download or diag ends after 10s (on|off)")
+	fmt.Printf("  %s %-12s %s\n", cmd("set"), arg("urilinks"), "Let cd follow links inferred from ...Uri strings; off requires open (on|off)")
+	fmt.Printf("  %s %-12s %s\n", cmd("set"), arg("powerwatch"), "Announce PowerState changes of the system cwd is in (on|off)")
+	fmt.Printf("  %s %-12s %s\n", cmd("set"), arg("views"), "Render known resource types in ll by their view (on|off)")
+	fmt.Printf("  %s %-12s %s\n", cmd("set"), arg("prefetch"), "Fetch the children ll's resource type says come next in the background (on|off)")
+	fmt.Printf("  %s %-12s %s\n", cmd("foreach"), arg("<pat> ! <act>"), "Invoke an action on every match, e.g. foreach Systems/* ! Reset")
+	fmt.Printf("  %s %-12s %s\n", cmd("apply"), arg("<file.yaml>"), "Show and apply the changes to a desired state (--plan-only, -o plan.json, --again)")
+	fmt.Printf("  %s %-12s %s\n", cmd("apply"), arg("--plan <f>"), "Apply a saved plan, unless what it changes has changed since")
+	fmt.Printf("  %s %-12s %s\n", cmd("stage"), arg("<prop> <val>"), "Stage a property change for commit (staged lists, unstage [path] drops)")
+	fmt.Printf("  %s %-12s %s\n", cmd("commit"), "", "Send the staged changes, one PATCH per resource")
+	fmt.Printf("  %s %-12s %s\n", cmd("undo"), arg("[count]"), "PATCH back the properties the last writes changed")
+	fmt.Printf("  %s %-12s %s\n", cmd("create"), arg("<collection>"), "Create a collection member, prompting for its fields")
+	fmt.Printf("  %s %-12s %s    %s %s\n", cmd("clear"), "", "Clear screen", cmd("help"), dim("exit/quit"))
+
+	if plugins := plugin.Commands(); len(plugins) > 0 {
+		fmt.Println()
+		fmt.Println(boldStyle.Render("Plugins"))
+		for _, p := range plugins {
+			fmt.Printf("  %s %-12s %s\n", cmd(p.Name), arg(p.Usage), p.Summary)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(boldStyle.Render("Paths"))
+	fmt.Printf("  %s  %s  %s  %s             %s\n",
+		arg("/"), dim("separator"),
+		arg("[n]"), dim("array index"),
+		dim("Systems/1/Status/Health  BootOrder[0]"))
+	fmt.Printf("  %s %s  %s  %s             %s\n",
+		arg(".."), dim("parent"),
+		arg("~"), dim("the service root, e.g. /redfish/v1"),
+		dim("open .  returns to containing resource"))
+	fmt.Printf("  %s  %s  %s\n",
+		arg("?$select=A,B"), dim("OData query ($filter, $top, $skip)"),
+		dim("ls 'Systems/1?$select=Status,PowerState'"))
+	fmt.Printf("  %s  %s  %s\n",
+		arg("%N"), dim("Nth member of the last collection ls"),
+		dim("ls Systems  cd %2"))
+
+	fmt.Println()
+	fmt.Println(boldStyle.Render("Keys"))
+	fmt.Printf("  %s  %s    %s  %s\n",
+		dim("Tab"), "complete",
+		dim("Ctrl+R"), "history search")
+	fmt.Printf("  %s  %s    %s  %s\n",
+		dim("↑/↓"), "history",
+		dim("Ctrl+L"), "clear screen")
+
+	fmt.Println()
+	fmt.Println(boldStyle.Render("Display"))
+	fmt.Printf("  %s  %s  %s  %s  %s\n",
+		childStyle.Render("dir/"), dim("child"),
+		linkStyle.Render("link@"), dim("symlink"),
+		propStyle.Render("prop"))
+	fmt.Printf("  %s  %s  %s  %s\n",
+		objectStyle.Render("obj/"), dim("object"),
+		objectStyle.Render("arr[]"), dim("array"))
+	fmt.Println()
+}
+
+// formatColumns formats items in columns like ls
+func formatColumns(items []string) string {
+	if len(items) == 0 {
+		return ""
+	}
+
+	// Get terminal width
+	width := 100 // default
+	if fd := int(os.Stdout.Fd()); term.IsTerminal(fd) {
+		if w, _, err := term.GetSize(fd); err == nil {
+			width = w
+		}
+	}
+
+	// Calculate column width in cells, ANSI codes and wide runes counted
+	maxLen := 0
+	for _, item := range items {
+		maxLen = max(maxLen, theme.Width(item))
+	}
+
+	colWidth := maxLen + 2
+	numCols := width / colWidth
+	if numCols < 1 {
+		numCols = 1
+	}
+
+	// Format in columns
+	var result strings.Builder
+	for i, item := range items {
+		result.WriteString(item)
+		if (i+1)%numCols == 0 {
+			result.WriteString("\n")
+		} else if i < len(items)-1 {
+			result.WriteString(strings.Repeat(" ", colWidth-theme.Width(item)))
+		}
+	}
+
+	return result.String()
+}
+
+// stripAnsi removes ANSI escape codes from text
+func stripAnsi(text string) string {
+	var result strings.Builder
+	inCode := false
+	for _, ch := range text {
+		if ch == '\033' {
+			inCode = true
+		} else if inCode {
+			if ch == 'm' {
+				inCode = false
+			}
+		} else {
+			result.WriteRune(ch)
+		}
+	}
+	return result.String()
+}
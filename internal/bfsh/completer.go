@@ -1,9 +1,12 @@
-package main
+package bfsh
 
 import (
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/bluefish-project/bluefish/plugin"
 	"github.com/bluefish-project/bluefish/rvfs"
 )
 
@@ -26,6 +29,12 @@ func (c *Completer) Do(line []rune, pos int) ([][]rune, int) {
 		return c.doActionMode(text, words)
 	}
 
+	// time prefixes another command; complete the command that follows
+	if rest, ok := strings.CutPrefix(strings.TrimLeft(text, " "), "time "); ok {
+		rest = strings.TrimLeft(rest, " ")
+		return c.Do([]rune(rest), len([]rune(rest)))
+	}
+
 	// Command completion
 	if len(words) == 0 || (len(words) == 1 && !strings.HasSuffix(text, " ")) {
 		return c.completeCommand(words)
@@ -39,12 +48,82 @@ func (c *Completer) Do(line []rune, pos int) ([][]rune, int) {
 	}
 
 	switch cmd {
-	case "cd", "ls", "ll", "dump", "open", "refresh":
+	case "cd", "pushd":
+		if strings.HasPrefix(partial, "-") {
+			return c.completeRecent(partial)
+		}
+		return c.completePath(partial)
+	case "ls", "ll", "dump", "cat", "open", "refresh", "stat", "privileges", "download", "create", "stage", "unstage", "pcie", "topology", "memory", "cpu":
 		return c.completePath(partial)
 	case "tree":
 		return c.completeTreeDepth()
 	case "cache":
 		return c.completeCacheCommand()
+	case "stats":
+		return c.completeStatsCommand()
+	case "trace", "redact":
+		return c.completeTraceCommand()
+	case "transcript":
+		if len(words) == 1 {
+			return c.completeTraceCommand()
+		}
+	case "set":
+		return c.completeSetCommand(words, partial)
+	case "diag":
+		// The subcommand, then the log service
+		if len(words) == 1 || len(words) == 2 && partial != "" {
+			if strings.HasPrefix("collect", partial) {
+				return toRuneSlices([]string{"collect"}, len(partial)), len(partial)
+			}
+			return nil, 0
+		}
+		if len(words) == 2 || len(words) == 3 && partial != "" {
+			return c.completePath(partial)
+		}
+	case "locate":
+		if len(words) == 1 || len(words) == 2 && partial != "" {
+			var matches []string
+			for _, state := range []string{"on", "off"} {
+				if strings.HasPrefix(state, partial) {
+					matches = append(matches, state)
+				}
+			}
+			return toRuneSlices(matches, len(partial)), len(partial)
+		}
+		if len(words) == 2 || len(words) == 3 && partial != "" {
+			return c.completePath(partial)
+		}
+	case "metrics":
+		if len(words) == 1 || len(words) == 2 && partial != "" {
+			var matches []string
+			for _, sub := range []string{"define", "list", "show"} {
+				if strings.HasPrefix(sub, partial) {
+					matches = append(matches, sub)
+				}
+			}
+			return toRuneSlices(matches, len(partial)), len(partial)
+		}
+		if words[1] == "show" && (len(words) == 2 || len(words) == 3 && partial != "") {
+			return c.completePath(partial)
+		}
+	case "foreach":
+		// The pattern is a path; the action follows "!"
+		if len(words) == 1 || (len(words) == 2 && partial != "") {
+			return c.completePath(partial)
+		}
+	default:
+		if p, ok := plugin.Lookup(cmd); ok {
+			// The position of the argument being typed
+			i := len(words) - 1
+			if partial != "" {
+				i--
+			}
+			matches, path := p.Complete(i, partial)
+			if path {
+				return c.completePath(partial)
+			}
+			return toRuneSlices(matches, len(partial)), len(partial)
+		}
 	}
 
 	return nil, 0
@@ -52,7 +131,7 @@ func (c *Completer) Do(line []rune, pos int) ([][]rune, int) {
 
 // doActionMode handles tab completion in action mode
 func (c *Completer) doActionMode(text string, words []string) ([][]rune, int) {
-	actions, _ := discoverActions(c.nav)
+	actions, _ := discoverActions(c.nav.vfs, c.nav.cwd)
 
 	// Command position: complete action names + built-in commands
 	if len(words) == 0 || (len(words) == 1 && !strings.HasSuffix(text, " ")) {
@@ -135,12 +214,29 @@ func (c *Completer) doActionMode(text string, words []string) ([][]rune, int) {
 	return toRuneSlices(matches, len(partial)), len(partial)
 }
 
+// completeRecent completes -N references to the recent directories
+func (c *Completer) completeRecent(partial string) ([][]rune, int) {
+	var matches []string
+	for i := range c.nav.recent {
+		ref := "-" + strconv.Itoa(i+1)
+		if strings.HasPrefix(ref, partial) {
+			matches = append(matches, ref)
+		}
+	}
+	return toRuneSlices(matches, len(partial)), len(partial)
+}
+
 // completeCommand completes command names
 func (c *Completer) completeCommand(words []string) ([][]rune, int) {
 	commands := []string{
-		"cd", "ls", "ll", "pwd", "dump", "tree", "find", "open",
-		"scrape", "refresh",
-		"cache", "clear", "help", "exit", "quit",
+		"cd", "pushd", "popd", "dirs", "ls", "ll", "pwd", "dump", "cat", "tree", "find", "grep", "open",
+		"scrape", "refresh", "stat", "privileges", "download", "diag", "metrics", "locate", "pcie", "topology", "memory", "cpu", "firmware",
+		"cache", "stats", "time", "trace", "transcript", "redact", "set", "foreach", "create", "apply", "stage", "staged", "unstage", "commit", "undo", "clear", "help", "exit", "quit",
+	}
+	for _, p := range plugin.Commands() {
+		if !slices.Contains(commands, p.Name) {
+			commands = append(commands, p.Name)
+		}
 	}
 
 	prefix := ""
@@ -182,7 +278,7 @@ func (c *Completer) completePath(partial string) ([][]rune, int) {
 	if base == "" {
 		// Complete at current location — resolve cwd through ResolveTarget
 		// so it works for both resource and property paths
-		resolved, err := c.nav.vfs.ResolveTarget(rvfs.RedfishRoot, c.nav.cwd)
+		resolved, err := c.nav.vfs.ResolveTarget(c.nav.vfs.Root(), c.nav.cwd)
 		if err != nil {
 			return nil, 0
 		}
@@ -313,7 +409,14 @@ func (c *Completer) createEntriesFromProperty(prop *rvfs.Property, separator run
 
 	case rvfs.PropertyArray:
 		if separator == '/' {
-			return nil // Can't use / on an array — must use [
+			// Only the elements of a link array have names, their targets'
+			if !prop.IsLinkArray() {
+				return nil
+			}
+			for name := range rvfs.LinkElements(prop) {
+				entries = append(entries, &rvfs.Entry{Name: name, Type: rvfs.EntrySymlink})
+			}
+			return entries
 		}
 		for _, elem := range prop.Elements {
 			// Strip brackets from element name to get bare index
@@ -343,6 +446,38 @@ func (c *Completer) completeCacheCommand() ([][]rune, int) {
 	return toRuneSlices(cmds, 0), 0
 }
 
+// completeStatsCommand completes stats subcommands
+func (c *Completer) completeStatsCommand() ([][]rune, int) {
+	return toRuneSlices([]string{"reset"}, 0), 0
+}
+
+// completeTraceCommand completes trace arguments
+func (c *Completer) completeTraceCommand() ([][]rune, int) {
+	return toRuneSlices([]string{"on", "off"}, 0), 0
+}
+
+// completeSetCommand completes set's setting name and value
+func (c *Completer) completeSetCommand(words []string, partial string) ([][]rune, int) {
+	pos := len(words) - 1 // Argument position being completed
+	if partial == "" {
+		pos = len(words)
+	}
+	var options []string
+	switch pos {
+	case 1:
+		options = settingNames
+	case 2:
+		options = []string{"on", "off"}
+	}
+	var matches []string
+	for _, opt := range options {
+		if strings.HasPrefix(opt, partial) {
+			matches = append(matches, opt)
+		}
+	}
+	return toRuneSlices(matches, len(partial)), len(partial)
+}
+
 // toRuneSlices converts string completions to rune slices
 func toRuneSlices(strs []string, prefixLen int) [][]rune {
 	result := make([][]rune, len(strs))
@@ -1,4 +1,4 @@
-package main
+package bfsh
 
 import (
 	"strings"
@@ -9,6 +9,7 @@ import (
 
 // mockVFSForCompletion provides a minimal VFS for completion testing
 type mockVFSForCompletion struct {
+	rvfs.BaseVFS
 	resource *rvfs.Resource
 }
 
@@ -60,22 +61,11 @@ func (m *mockVFSForCompletion) ResolveTarget(basePath, targetPath string) (*rvfs
 	return nil, &rvfs.NotFoundError{Path: targetPath}
 }
 
-func (m *mockVFSForCompletion) ListProperties(path string) ([]*rvfs.Property, error) {
-	return nil, nil
-}
-
 func (m *mockVFSForCompletion) GetKnownPaths() []string {
 	return []string{"/redfish/v1/Systems/1"}
 }
 
-func (m *mockVFSForCompletion) Post(path string, body []byte) ([]byte, int, error) {
-	return nil, 0, nil
-}
-func (m *mockVFSForCompletion) Invalidate(path string)  {}
-func (m *mockVFSForCompletion) Clear()                  {}
-func (m *mockVFSForCompletion) Sync() error             { return nil }
-func (m *mockVFSForCompletion) Parent(p string) string  { return "/redfish/v1" }
-func (m *mockVFSForCompletion) Join(b, t string) string { return "" }
+func (m *mockVFSForCompletion) Parent(p string) string { return "/redfish/v1" }
 
 func createTestResource() *rvfs.Resource {
 	return &rvfs.Resource{
@@ -374,6 +364,7 @@ func TestCompleter_ComplexSeparatorCompositions(t *testing.T) {
 
 // mockVFSForComplexCompletion is a specialized mock for testing complex separator compositions
 type mockVFSForComplexCompletion struct {
+	rvfs.BaseVFS
 	resource *rvfs.Resource
 }
 
@@ -398,17 +389,3 @@ func (m *mockVFSForComplexCompletion) ResolveTarget(basePath, targetPath string)
 	}
 	return nil, &rvfs.NotFoundError{Path: targetPath}
 }
-
-func (m *mockVFSForComplexCompletion) ListProperties(path string) ([]*rvfs.Property, error) {
-	return nil, nil
-}
-
-func (m *mockVFSForComplexCompletion) Post(path string, body []byte) ([]byte, int, error) {
-	return nil, 0, nil
-}
-func (m *mockVFSForComplexCompletion) GetKnownPaths() []string   { return nil }
-func (m *mockVFSForComplexCompletion) Invalidate(path string)    {}
-func (m *mockVFSForComplexCompletion) Clear()                    {}
-func (m *mockVFSForComplexCompletion) Sync() error               { return nil }
-func (m *mockVFSForComplexCompletion) Parent(path string) string { return "" }
-func (m *mockVFSForComplexCompletion) Join(b, t string) string   { return "" }
@@ -1,6 +1,7 @@
-package main
+package bfsh
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/bluefish-project/bluefish/rvfs"
@@ -33,7 +34,7 @@ func (c *CompletionListener) OnChange(line []rune, pos int, key rune) ([]rune, i
 	}
 
 	cmd := words[0]
-	if cmd != "cd" && cmd != "ls" && cmd != "ll" && cmd != "dump" {
+	if cmd != "cd" && cmd != "pushd" && cmd != "ls" && cmd != "ll" && cmd != "dump" && cmd != "cat" {
 		return line, pos, false
 	}
 
@@ -43,6 +44,14 @@ func (c *CompletionListener) OnChange(line []rune, pos int, key rune) ([]rune, i
 		partial = words[len(words)-1]
 	}
 
+	// A complete -N reference expands to the directory it names
+	if cmd == "cd" || cmd == "pushd" {
+		if dir := c.expandRecent(partial); dir != "" {
+			expanded := append([]rune(strings.TrimSuffix(text, partial)+dir), line[pos:]...)
+			return expanded, pos - len([]rune(partial)) + len([]rune(dir)), true
+		}
+	}
+
 	// Pre-fetch using shared resolution logic
 	c.prefetch(partial)
 
@@ -74,3 +83,22 @@ func (c *CompletionListener) prefetch(partial string) {
 		}
 	}
 }
+
+// expandRecent returns the recent directory a -N reference names, once no
+// longer reference could still be meant
+func (c *CompletionListener) expandRecent(partial string) string {
+	if partial == "-" || !strings.HasPrefix(partial, "-") {
+		return ""
+	}
+	dir, err := c.nav.recentDir(partial)
+	if err != nil || dir == "" {
+		return ""
+	}
+	for i := range c.nav.recent {
+		ref := "-" + strconv.Itoa(i+1)
+		if ref != partial && strings.HasPrefix(ref, partial) {
+			return ""
+		}
+	}
+	return dir
+}
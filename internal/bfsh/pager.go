@@ -0,0 +1,57 @@
+package bfsh
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// dumpPageBytes is the size of rendered JSON past which dump warns and
+// pages it rather than flooding the terminal
+const dumpPageBytes = 64 << 10
+
+// pageHeight returns the lines a page of the terminal holds, 0 when
+// stdout is not a terminal and so is not paged
+func pageHeight() int {
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return 0
+	}
+	_, h, err := term.GetSize(fd)
+	if err != nil {
+		return 0
+	}
+	return h
+}
+
+// page writes text to out a page of height lines at a time, the last line
+// of each a prompt read from in: Enter shows the next page, q stops. A
+// height of 0 writes the text whole.
+func page(in *bufio.Reader, out io.Writer, text string, height int) {
+	lines := strings.SplitAfter(strings.TrimSuffix(text, "\n")+"\n", "\n")
+	lines = lines[:len(lines)-1]
+	if height < 2 || len(lines) < height {
+		io.WriteString(out, strings.Join(lines, ""))
+		return
+	}
+	for start := 0; start < len(lines); {
+		end := min(start+height-1, len(lines))
+		io.WriteString(out, strings.Join(lines[start:end], ""))
+		if start = end; start == len(lines) {
+			return
+		}
+		fmt.Fprint(out, dimStyle.Render(fmt.Sprintf("-- %d/%d lines, Enter for more, q to stop --", start, len(lines))), " ")
+		answer, err := in.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(out)
+			return
+		}
+		if strings.TrimSpace(answer) == "q" {
+			return
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package bfsh
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Transcript tees the lines typed at the shell and the output of the
+// commands they run to a file, without color, for a record of what was done
+// through the shell. Each command's output is captured by pointing
+// os.Stdout at a pipe while it runs; the pipe's reader passes the output on
+// to the terminal as it comes and writes it to the file line by line.
+type Transcript struct {
+	path    string
+	file    *os.File
+	stopped bool // Stop was called while a command was running
+
+	stdout  *os.File      // The terminal while a command is captured
+	pipe    *os.File      // Write end of the pipe installed as os.Stdout
+	drained chan struct{} // Closed when the reader has copied everything
+}
+
+// StartTranscript creates the transcript file; without a path it is named
+// after the time it starts, in the working directory
+func StartTranscript(path string) (*Transcript, error) {
+	now := time.Now()
+	if path == "" {
+		path = "bfsh_transcript_" + now.Format("20060102T150405") + ".txt"
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(file, "# bfsh transcript started %s\n", now.Format(time.RFC3339))
+	return &Transcript{path: path, file: file}, nil
+}
+
+// Begin records a typed line with the time and prompt it was typed at and
+// starts capturing the output of the command it runs
+func (t *Transcript) Begin(prompt, line string) {
+	fmt.Fprintf(t.file, "[%s] %s%s\n", time.Now().Format(time.RFC3339), ansi.Strip(prompt), line)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		fmt.Fprintf(t.file, "# output not captured: %v\n", err)
+		return
+	}
+	t.stdout, t.pipe = os.Stdout, w
+	t.drained = make(chan struct{})
+	os.Stdout = w
+	go t.copy(r, t.stdout)
+}
+
+// End stops capturing once the reader has copied the command's output, and
+// closes the file when the command stopped the transcript
+func (t *Transcript) End() {
+	if t.pipe != nil {
+		os.Stdout = t.stdout
+		t.pipe.Close()
+		<-t.drained
+		t.stdout, t.pipe = nil, nil
+	}
+	if t.stopped {
+		t.close()
+	}
+}
+
+// Stop ends the transcript; the output of a command running it is still
+// recorded, up to End
+func (t *Transcript) Stop() {
+	if t.pipe != nil {
+		t.stopped = true
+		return
+	}
+	t.close()
+}
+
+func (t *Transcript) close() {
+	fmt.Fprintf(t.file, "# bfsh transcript stopped %s\n", time.Now().Format(time.RFC3339))
+	t.file.Close()
+}
+
+// copy passes what is written to the pipe to the terminal and writes it to
+// the file without escape sequences. Whole lines are stripped, so sequences
+// split across reads come out clean.
+func (t *Transcript) copy(r *os.File, terminal io.Writer) {
+	defer close(t.drained)
+	defer r.Close()
+	var pending []byte
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			terminal.Write(buf[:n])
+			pending = append(pending, buf[:n]...)
+			if i := bytes.LastIndexByte(pending, '\n'); i >= 0 {
+				io.WriteString(t.file, ansi.Strip(string(pending[:i+1])))
+				pending = append(pending[:0], pending[i+1:]...)
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	if len(pending) > 0 {
+		io.WriteString(t.file, ansi.Strip(string(pending))+"\n")
+	}
+}
+
+// setTranscript runs transcript on [file], transcript off, and a bare
+// transcript, which reports where the transcript is going
+func (n *Navigator) setTranscript(args []string) error {
+	switch {
+	case len(args) >= 1 && len(args) <= 2 && args[0] == "on":
+		if n.transcript != nil {
+			return fmt.Errorf("transcript already on: %s", n.transcript.path)
+		}
+		path := ""
+		if len(args) == 2 {
+			path = args[1]
+		}
+		t, err := StartTranscript(path)
+		if err != nil {
+			return err
+		}
+		n.transcript = t
+		fmt.Printf("Transcript on: %s\n", t.path)
+	case len(args) == 1 && args[0] == "off":
+		if n.transcript == nil {
+			return fmt.Errorf("transcript is not on")
+		}
+		fmt.Printf("Transcript off: %s\n", n.transcript.path)
+		n.transcript.Stop()
+		n.transcript = nil
+	case len(args) == 0:
+		if n.transcript == nil {
+			fmt.Println("Transcript off")
+		} else {
+			fmt.Printf("Transcript on: %s\n", n.transcript.path)
+		}
+	default:
+		return fmt.Errorf("usage: transcript [on [file]|off]")
+	}
+	return nil
+}
@@ -1,10 +1,9 @@
-package main
+package bfui
 
 import (
 	"encoding/json"
 	"fmt"
 	"sort"
-	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -46,16 +45,28 @@ type ActionModel struct {
 	actions []ActionInfo
 	cursor  int
 
+	// bulk maps each action to its target on every selected resource when
+	// acting on a selection; nil when acting on one resource
+	bulk map[string][]string
+
+	// refusal is why the role may not run the actions, nil when it may
+	refusal error
+
 	// Params phase
 	selected *ActionInfo
 	params   []ActionParam
 	paramIdx int // Which param is being edited
 	input    textinput.Model
 
+	// repeat is why the POST confirmed was held back as one just sent;
+	// confirming again sends it
+	repeat error
+
 	// Result phase
-	resultStatus int
-	resultBody   string
-	resultErr    error
+	resultStatus   int
+	resultBody     string
+	resultMessages []rvfs.Message
+	resultErr      error
 
 	width  int
 	height int
@@ -69,8 +80,17 @@ func NewActionModel() ActionModel {
 	}
 }
 
-// Open activates action mode after discovery
-func (a *ActionModel) Open(actions []ActionInfo) {
+// Open activates action mode after discovery. A refusal shows the actions
+// disabled, with why.
+func (a *ActionModel) Open(actions []ActionInfo, refusal error) {
+	a.OpenBulk(actions, nil, refusal)
+}
+
+// OpenBulk activates action mode for actions run on several resources,
+// given each action's target on every one of them
+func (a *ActionModel) OpenBulk(actions []ActionInfo, targets map[string][]string, refusal error) {
+	a.bulk = targets
+	a.refusal = refusal
 	a.actions = actions
 	a.cursor = 0
 	a.phase = PhaseSelect
@@ -79,6 +99,14 @@ func (a *ActionModel) Open(actions []ActionInfo) {
 	a.resultErr = nil
 }
 
+// Targets returns where the selected action is POSTed
+func (a *ActionModel) Targets() []string {
+	if a.bulk != nil {
+		return a.bulk[a.selected.Name]
+	}
+	return []string{a.selected.Target}
+}
+
 // Close resets the action model
 func (a *ActionModel) Close() {
 	a.input.Blur()
@@ -98,6 +126,10 @@ func (a *ActionModel) SelectAction() {
 	if a.cursor < 0 || a.cursor >= len(a.actions) {
 		return
 	}
+	if a.refusal != nil {
+		a.SetResult(0, "", nil, a.refusal)
+		return
+	}
 	action := a.actions[a.cursor]
 	a.selected = &action
 	a.phase = PhaseParams
@@ -154,6 +186,7 @@ func (a *ActionModel) ConfirmParams() {
 	}
 	a.input.Blur()
 	a.phase = PhaseConfirm
+	a.repeat = nil
 }
 
 // NextParam moves to next parameter
@@ -170,25 +203,13 @@ func (a *ActionModel) NextParam() {
 	}
 }
 
-// BuildBody builds the JSON body for the POST
+// BuildBody builds the JSON body for the POST. Parameters come from their
+// AllowableValues and are sent as the strings they are, like name=value in
+// the shells; a numeric-looking value is not turned into a number.
 func (a *ActionModel) BuildBody() ([]byte, error) {
 	body := make(map[string]any)
 	for _, p := range a.params {
-		if p.Value == "" {
-			continue
-		}
-		// Type conversion
-		if n, err := strconv.ParseFloat(p.Value, 64); err == nil {
-			if n == float64(int64(n)) {
-				body[p.Name] = int64(n)
-			} else {
-				body[p.Name] = n
-			}
-		} else if p.Value == "true" {
-			body[p.Name] = true
-		} else if p.Value == "false" {
-			body[p.Name] = false
-		} else {
+		if p.Value != "" {
 			body[p.Name] = p.Value
 		}
 	}
@@ -196,10 +217,11 @@ func (a *ActionModel) BuildBody() ([]byte, error) {
 }
 
 // SetResult sets the result of a POST action
-func (a *ActionModel) SetResult(status int, body string, err error) {
+func (a *ActionModel) SetResult(status int, body string, messages []rvfs.Message, err error) {
 	a.phase = PhaseResult
 	a.resultStatus = status
 	a.resultBody = body
+	a.resultMessages = messages
 	a.resultErr = err
 }
 
@@ -258,9 +280,16 @@ func (a *ActionModel) View() string {
 func (a *ActionModel) viewSelect(b *strings.Builder) {
 	b.WriteString(actionTitleStyle.Render("Actions"))
 	b.WriteString("\n\n")
+	if a.refusal != nil {
+		b.WriteString(helpDescStyle.Render("  Disabled: " + a.refusal.Error()))
+		b.WriteString("\n\n")
+	}
 
 	for i, action := range a.actions {
 		line := "  " + actionNameStyle.Render(action.ShortName)
+		if a.refusal != nil {
+			line = "  " + helpDescStyle.Render(action.ShortName)
+		}
 		if len(action.Allowable) > 0 {
 			var params []string
 			for param, vals := range action.Allowable {
@@ -285,7 +314,11 @@ func (a *ActionModel) viewSelect(b *strings.Builder) {
 func (a *ActionModel) viewParams(b *strings.Builder) {
 	b.WriteString(actionTitleStyle.Render(a.selected.ShortName))
 	b.WriteString("  ")
-	b.WriteString(actionTargetStyle.Render(a.selected.Target))
+	if a.bulk != nil {
+		b.WriteString(actionTargetStyle.Render(fmt.Sprintf("%d selected resources", len(a.Targets()))))
+	} else {
+		b.WriteString(actionTargetStyle.Render(a.selected.Target))
+	}
 	b.WriteString("\n\n")
 
 	if len(a.params) == 0 {
@@ -320,9 +353,12 @@ func (a *ActionModel) viewParams(b *strings.Builder) {
 }
 
 func (a *ActionModel) viewConfirm(b *strings.Builder) {
-	b.WriteString(actionConfirmStyle.Render("POST "))
-	b.WriteString(actionTargetStyle.Render(a.selected.Target))
-	b.WriteString("\n\n")
+	for _, target := range a.Targets() {
+		b.WriteString(actionConfirmStyle.Render("POST "))
+		b.WriteString(actionTargetStyle.Render(target))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
 
 	body, err := a.BuildBody()
 	if err != nil {
@@ -331,7 +367,13 @@ func (a *ActionModel) viewConfirm(b *strings.Builder) {
 		b.WriteString(detailValueStyle.Render(string(body)))
 	}
 	b.WriteString("\n\n")
-	b.WriteString(actionConfirmStyle.Render("Execute? "))
+	if a.repeat != nil {
+		b.WriteString(actionErrorStyle.Render(fmt.Sprintf("%v", a.repeat)))
+		b.WriteString("\n")
+		b.WriteString(actionConfirmStyle.Render("Send it again? "))
+	} else {
+		b.WriteString(actionConfirmStyle.Render("Execute? "))
+	}
 	b.WriteString(helpDescStyle.Render("y:yes  n/esc:cancel"))
 }
 
@@ -339,6 +381,11 @@ func (a *ActionModel) viewResult(b *strings.Builder) {
 	if a.resultErr != nil {
 		b.WriteString(actionErrorStyle.Render(fmt.Sprintf("Error: %v", a.resultErr)))
 		b.WriteString("\n")
+		if a.resultBody != "" {
+			b.WriteString("\n")
+			b.WriteString(detailValueStyle.Render(a.resultBody))
+			b.WriteString("\n")
+		}
 	} else {
 		statusStr := fmt.Sprintf("HTTP %d", a.resultStatus)
 		if a.resultStatus >= 200 && a.resultStatus < 300 {
@@ -347,6 +394,12 @@ func (a *ActionModel) viewResult(b *strings.Builder) {
 			b.WriteString(actionErrorStyle.Render(statusStr))
 		}
 		b.WriteString("\n\n")
+		for _, m := range a.resultMessages {
+			b.WriteString(formatMessage(m, 0))
+		}
+		if len(a.resultMessages) > 0 {
+			b.WriteString("\n")
+		}
 		if a.resultBody != "" {
 			b.WriteString(detailValueStyle.Render(a.resultBody))
 			b.WriteString("\n")
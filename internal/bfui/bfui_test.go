@@ -0,0 +1,772 @@
+package bfui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/rvfs/rvfstest"
+)
+
+// TestApplyKeymap tests remapping the bindings from a keymap config, and
+// the configs it refuses
+func TestApplyKeymap(t *testing.T) {
+	tests := []struct {
+		name   string
+		keymap map[string]map[string][]string
+		err    string // Part of the error, "" when the keymap applies
+		check  func(t *testing.T)
+	}{
+		{
+			name:   "unknown mode",
+			keymap: map[string]map[string][]string{"insert": {"up": {"k"}}},
+			err:    `unknown mode "insert"`,
+		},
+		{
+			name:   "unknown binding",
+			keymap: map[string]map[string][]string{"normal": {"jump": {"J"}}},
+			err:    `normal: unknown binding "jump"`,
+		},
+		{
+			name:   "key bound to two bindings",
+			keymap: map[string]map[string][]string{"normal": {"up": {"ctrl+y"}, "down": {"ctrl+y"}}},
+			err:    `normal: key "ctrl+y" is bound to both down and up`,
+		},
+		{
+			name:   "key taken from a default",
+			keymap: map[string]map[string][]string{"normal": {"up": {"j"}}},
+			err:    `normal: key "j" is bound to both down and up`,
+		},
+		{
+			name:   "empty key",
+			keymap: map[string]map[string][]string{"normal": {"up": {""}}},
+			err:    "normal.up: empty key name",
+		},
+		{
+			name:   "override replaces the default",
+			keymap: map[string]map[string][]string{"normal": {"up": {"ctrl+p", "up"}}, "search": {"next_item": {}}},
+			check: func(t *testing.T) {
+				if keys := normalKeys.Up.Keys(); !slices.Equal(keys, []string{"ctrl+p", "up"}) {
+					t.Errorf("up keys = %q", keys)
+				}
+				if help := normalKeys.Up.Help().Key; help != "ctrl+p/↑" {
+					t.Errorf("up help = %q", help)
+				}
+				if keys := normalKeys.Down.Keys(); !slices.Equal(keys, []string{"j", "down"}) {
+					t.Errorf("down keys = %q, want the default", keys)
+				}
+				if keys := searchKeys.NextItem.Keys(); len(keys) != 0 || keyLabel(searchKeys.NextItem) != "-" {
+					t.Errorf("unbound next_item keys = %q", keys)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restoreKeymap(t)
+			err := applyKeymap(tt.keymap)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("applyKeymap = %v", err)
+				}
+				tt.check(t)
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("applyKeymap = %v, want an error with %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// restoreKeymap puts the bindings back as they are once the test ends
+func restoreKeymap(t *testing.T) {
+	saved := make(map[*key.Binding]key.Binding)
+	for _, bindings := range keymapBindings() {
+		for _, b := range bindings {
+			saved[b] = *b
+		}
+	}
+	t.Cleanup(func() {
+		for b, v := range saved {
+			*b = v
+		}
+	})
+}
+
+// TestFetchQueue tests that a path asked for again while its fetch is in
+// flight or waiting is fetched once, and that no more than
+// maxConcurrentFetches run at a time
+func TestFetchQueue(t *testing.T) {
+	const count = 10
+	server := rvfstest.NewServer(rvfstest.Service())
+	defer server.Close()
+	var paths []string
+	for i := range count {
+		path := fmt.Sprintf("/redfish/v1/Systems/%d", i)
+		server.Set(path, fmt.Sprintf(`{"@odata.id": %q, "Id": "%d"}`, path, i))
+		paths = append(paths, path)
+	}
+	vfs := server.VFS(t)
+	server.SetLatency(20 * time.Millisecond)
+
+	q := newFetchQueue(vfs)
+	msgs := make(chan tea.Msg)
+	start := func(cmd tea.Cmd) {
+		if cmd != nil {
+			go func() { msgs <- cmd() }()
+		}
+	}
+	for _, path := range paths {
+		start(q.Request(path))
+	}
+	// Asked for again while running, and while waiting
+	if q.Request(paths[0]) != nil || q.Request(paths[count-1]) != nil {
+		t.Error("a path in flight or waiting was fetched again")
+	}
+	if q.Pending() != count {
+		t.Errorf("Pending = %d, want %d", q.Pending(), count)
+	}
+
+	for q.Pending() > 0 {
+		msg := (<-msgs).(ResourceLoadedMsg)
+		if msg.Err != nil {
+			t.Errorf("fetch of %s: %v", msg.Path, msg.Err)
+		}
+		start(q.Done(msg.Path))
+	}
+	for _, path := range paths {
+		if n := server.Requests(http.MethodGet, path); n != 1 {
+			t.Errorf("%s fetched %d times", path, n)
+		}
+	}
+	if peak := server.PeakRequests(); peak != maxConcurrentFetches {
+		t.Errorf("%d fetches ran at once, want %d", peak, maxConcurrentFetches)
+	}
+}
+
+// TestStateFile tests that the state saved for each endpoint reads back
+// as it was put, and that a file readable by others is written
+func TestStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bfui_state.json")
+	f, err := loadStateFile(path)
+	if err != nil || f.Get("https://bmc1") != nil {
+		t.Fatalf("loadStateFile of a missing file = %+v, %v", f, err)
+	}
+	saved := map[string]*uiState{
+		"https://bmc1": {
+			BasePath:    "/redfish/v1/Systems/1",
+			RootStack:   []string{"/redfish/v1"},
+			Expanded:    []string{"/redfish/v1/Systems/1/Processors"},
+			Cursor:      "/redfish/v1/Systems/1/Processors/CPU1",
+			Bookmarks:   []string{"/redfish/v1/Chassis/1"},
+			Marks:       map[string]mark{"a": {BasePath: "/redfish/v1", Cursor: "/redfish/v1/Chassis"}},
+			TreePercent: 55,
+			Raw:         true,
+		},
+		"https://bmc2": {BasePath: "/redfish/v1", SideBySide: true},
+	}
+	for endpoint, state := range saved {
+		if err := f.Put(endpoint, state); err != nil {
+			t.Fatalf("Put(%s) = %v", endpoint, err)
+		}
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o644 {
+		t.Errorf("state file = %v, %v, want mode 0644", fi, err)
+	}
+
+	f, err = loadStateFile(path)
+	if err != nil {
+		t.Fatalf("loadStateFile = %v", err)
+	}
+	for endpoint, state := range saved {
+		if got := f.Get(endpoint); !reflect.DeepEqual(got, state) {
+			t.Errorf("Get(%s) = %+v, want %+v", endpoint, got, state)
+		}
+	}
+	if f.Get("https://bmc3") != nil {
+		t.Error("Get of an endpoint never put is not nil")
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadStateFile(path); err == nil {
+		t.Error("loadStateFile of a damaged file succeeded")
+	}
+}
+
+// TestRestoreState tests that restoring a saved state returns the model to
+// it, keeping the tree width only within bounds
+func TestRestoreState(t *testing.T) {
+	server := rvfstest.NewServer(rvfstest.Service())
+	defer server.Close()
+	m := NewModel(server.VFS(t))
+
+	state := &uiState{
+		BasePath:    "/redfish/v1/Systems/1",
+		RootStack:   []string{"/redfish/v1"},
+		Bookmarks:   []string{"/redfish/v1/Chassis/1"},
+		Marks:       map[string]mark{"a": {BasePath: "/redfish/v1", Cursor: "/redfish/v1/Chassis"}},
+		TreePercent: 60,
+		Raw:         true,
+		SideBySide:  true,
+	}
+	restored := m.restore(state)
+	if got := restored.state(); !reflect.DeepEqual(got, state) {
+		t.Errorf("state after restore = %+v, want %+v", got, state)
+	}
+	if restored.restoring != state {
+		t.Error("the tree is not rebuilt from the restored state")
+	}
+
+	for _, percent := range []int{0, minTreePercent - 1, maxTreePercent + 1} {
+		if got := m.restore(&uiState{TreePercent: percent}).treePercent; got != defaultTreePercent {
+			t.Errorf("tree percent %d restored as %d, want the default %d", percent, got, defaultTreePercent)
+		}
+	}
+	for _, percent := range []int{minTreePercent, maxTreePercent} {
+		if got := m.restore(&uiState{TreePercent: percent}).treePercent; got != percent {
+			t.Errorf("tree percent %d restored as %d", percent, got)
+		}
+	}
+	if got := m.restore(&uiState{}).basePath; got != "/redfish/v1" {
+		t.Errorf("a state without a base path moved the tree to %q", got)
+	}
+}
+
+// loadedModel returns a model of the server's service sized as a terminal,
+// its tree rooted at base and loaded
+func loadedModel(t *testing.T, server *rvfstest.Server, base string) Model {
+	t.Helper()
+	vfs := server.VFS(t)
+	m := NewModel(vfs)
+	m.basePath = base
+	m.breadcrumb.SetPath(base)
+	resource, err := vfs.Get(base)
+	if err != nil {
+		t.Fatalf("Get(%s) = %v", base, err)
+	}
+	model, _ := m.Update(ResourceLoadedMsg{Path: base, Resource: resource})
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 160, Height: 50})
+	return model.(Model)
+}
+
+// press sends m each key in turn, returning the model and the command of
+// the last
+func press(m Model, keys ...string) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	for _, k := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		switch k {
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(k)}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEscape}
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		}
+		var model tea.Model
+		model, cmd = m.Update(msg)
+		m = model.(Model)
+	}
+	return m, cmd
+}
+
+// moveTo moves the tree cursor down to the item at path
+func moveTo(t *testing.T, m Model, path string) Model {
+	t.Helper()
+	for range len(m.tree.visible) {
+		if item := m.tree.Current(); item != nil && item.Path == path {
+			return m
+		}
+		m, _ = press(m, "j")
+	}
+	t.Fatalf("no item at %s below the cursor", path)
+	return m
+}
+
+// TestSelection tests marking and unmarking tree items in select mode,
+// the count the status bar shows, and the paths each operation on the
+// marked items is given
+func TestSelection(t *testing.T) {
+	resources := rvfstest.Service()
+	resources["/redfish/v1"] = `{
+		"@odata.id": "/redfish/v1",
+		"Name": "Root Service",
+		"Systems": {"@odata.id": "/redfish/v1/Systems"},
+		"Chassis": {"@odata.id": "/redfish/v1/Chassis"},
+		"ContainerUri": "/redfish/v1/Chassis",
+		"PrimaryChassisUri": "/redfish/v1/Chassis/1"
+	}`
+	server := rvfstest.NewServer(resources)
+	defer server.Close()
+	m := loadedModel(t, server, "/redfish/v1")
+
+	m, _ = press(m, "V")
+	if m.mode != ModeSelect {
+		t.Fatalf("mode after V = %v, want select", m.mode)
+	}
+	for _, path := range []string{"/redfish/v1/Chassis", "/redfish/v1/Systems", "/redfish/v1/ContainerUri", "/redfish/v1/Name", "/redfish/v1/PrimaryChassisUri"} {
+		m, _ = press(moveTo(t, m, path), " ")
+	}
+	if bar := ansi.Strip(m.viewStatusBar()); !strings.Contains(bar, "5 selected") {
+		t.Errorf("status bar with 5 marked = %q", bar)
+	}
+	m.tree.cursor = 0
+	m, _ = press(moveTo(t, m, "/redfish/v1/Systems"), " ")
+	if bar := ansi.Strip(m.viewStatusBar()); !strings.Contains(bar, "4 selected") {
+		t.Errorf("status bar once Systems is unmarked = %q", bar)
+	}
+
+	// Properties have no resource, and a link counts as its target
+	want := []string{"/redfish/v1/Chassis", "/redfish/v1/Chassis/1"}
+	if paths := m.selectedResources(); !slices.Equal(paths, want) {
+		t.Errorf("selectedResources = %q, want %q", paths, want)
+	}
+
+	refreshed, cmd := press(m, "r")
+	if cmd == nil {
+		t.Fatal("refresh of the marked resources sent nothing")
+	}
+	var paths []string
+	for _, c := range cmd().(tea.BatchMsg) {
+		paths = append(paths, c().(ResourceLoadedMsg).Path)
+	}
+	if slices.Sort(paths); !slices.Equal(paths, want) || refreshed.statusMsg != "Refreshing 2 resources..." {
+		t.Errorf("refreshed %q (%q), want %q", paths, refreshed.statusMsg, want)
+	}
+
+	// Copying takes every marked path, properties too
+	var clipboard bytes.Buffer
+	output := termenv.DefaultOutput()
+	termenv.SetDefaultOutput(termenv.NewOutput(&clipboard))
+	copied, _ := press(m, "y")
+	termenv.SetDefaultOutput(output)
+	sequence := termenv.OSC + "52;c;" + base64.StdEncoding.EncodeToString([]byte("/redfish/v1/Chassis\n/redfish/v1/ContainerUri\n/redfish/v1/Name\n/redfish/v1/PrimaryChassisUri"))
+	if !strings.HasPrefix(clipboard.String(), sequence) {
+		t.Errorf("copied %q, want %q", clipboard.String(), sequence)
+	}
+	if copied.statusMsg != "Copied 4 paths" {
+		t.Errorf("copy status = %q", copied.statusMsg)
+	}
+
+	exported, _ := press(m, "x")
+	if exported.mode != ModeExport || !slices.Equal(exported.export.roots, want) {
+		t.Errorf("export in mode %v of %q, want %q", exported.mode, exported.export.roots, want)
+	}
+
+	cleared, _ := press(m, "c")
+	if bar := ansi.Strip(cleared.viewStatusBar()); strings.Contains(bar, "selected") || len(cleared.tree.Marked()) != 0 {
+		t.Errorf("status bar after clearing = %q", bar)
+	}
+	if cleared, _ = press(cleared, "r"); cleared.statusMsg != "No resources marked (space marks)" {
+		t.Errorf("refresh with nothing marked = %q", cleared.statusMsg)
+	}
+	if cleared, _ = press(cleared, "esc"); cleared.mode != ModeNormal {
+		t.Errorf("mode after esc = %v, want normal", cleared.mode)
+	}
+}
+
+// TestSelectionAction tests that an action common to the marked resources
+// is found with the target of each, and posted to all of them
+func TestSelectionAction(t *testing.T) {
+	resources := rvfstest.Service()
+	resources["/redfish/v1/Systems"] = `{
+		"@odata.id": "/redfish/v1/Systems",
+		"Members": [{"@odata.id": "/redfish/v1/Systems/1"}, {"@odata.id": "/redfish/v1/Systems/2"}]
+	}`
+	resources["/redfish/v1/Systems/2"] = strings.ReplaceAll(resources["/redfish/v1/Systems/1"], "Systems/1", "Systems/2")
+	server := rvfstest.NewServer(resources)
+	defer server.Close()
+	var mu sync.Mutex
+	var posted []string
+	targets := []string{"/redfish/v1/Systems/1/Actions/ComputerSystem.Reset", "/redfish/v1/Systems/2/Actions/ComputerSystem.Reset"}
+	for _, target := range targets {
+		server.HandlePost(target, func(body []byte) rvfstest.Reply {
+			mu.Lock()
+			defer mu.Unlock()
+			posted = append(posted, target+" "+string(body))
+			return rvfstest.Reply{Status: http.StatusNoContent}
+		})
+	}
+	m := loadedModel(t, server, "/redfish/v1/Systems")
+
+	m, _ = press(m, "V")
+	m, _ = press(moveTo(t, m, "/redfish/v1/Systems/1"), " ", " ")
+	m, cmd := press(m, "!")
+	if cmd == nil {
+		t.Fatal("no actions discovered on the marked resources")
+	}
+	msg := cmd().(ActionsDiscoveredMsg)
+	if msg.Err != nil || len(msg.Actions) != 1 || !slices.Equal(msg.Targets["#ComputerSystem.Reset"], targets) {
+		t.Fatalf("discovered %+v, targets %q, %v", msg.Actions, msg.Targets, msg.Err)
+	}
+
+	result := m.postSelected(msg.Targets["#ComputerSystem.Reset"], []byte(`{"ResetType":"On"}`), false)().(ActionResultMsg)
+	slices.Sort(posted)
+	want := []string{targets[0] + ` {"ResetType":"On"}`, targets[1] + ` {"ResetType":"On"}`}
+	if result.Err != nil || result.StatusCode != http.StatusNoContent || !slices.Equal(posted, want) {
+		t.Errorf("post to the marked = %+v; posted %q, want %q", result, posted, want)
+	}
+}
+
+func TestPropertySection(t *testing.T) {
+	tests := []struct {
+		name string
+		prop *rvfs.Property
+		want string
+	}{
+		{"Id", &rvfs.Property{Type: rvfs.PropertySimple}, "Identity"},
+		{"SerialNumber", &rvfs.Property{Type: rvfs.PropertySimple}, "Identity"},
+		{"Status", &rvfs.Property{Type: rvfs.PropertyObject}, "Status"},
+		{"PowerState", &rvfs.Property{Type: rvfs.PropertySimple}, "Status"},
+		{"Links", &rvfs.Property{Type: rvfs.PropertyObject}, "Links"},
+		{"FirmwareInventoryUri", &rvfs.Property{Type: rvfs.PropertyLink}, "Links"},
+		{"Actions", &rvfs.Property{Type: rvfs.PropertyObject}, "Actions"},
+		{"Oem", &rvfs.Property{Type: rvfs.PropertyObject}, "Oem"},
+		{"Boot", &rvfs.Property{Type: rvfs.PropertyObject}, "Other"},
+	}
+	for _, tt := range tests {
+		if got := propertySection(tt.name, tt.prop); got != tt.want {
+			t.Errorf("propertySection(%s) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// sectionBody returns the lines the details panel shows under a section's
+// header, "" when the section is folded
+func sectionBody(t *testing.T, d *DetailsModel, name string) string {
+	t.Helper()
+	lines := strings.Split(ansi.Strip(d.content), "\n")
+	for i, s := range d.sections {
+		if s.name != name {
+			continue
+		}
+		end := len(lines)
+		if i+1 < len(d.sections) {
+			end = d.sections[i+1].line
+		}
+		return strings.Join(lines[s.line+1:end], "\n")
+	}
+	t.Fatalf("no %s section", name)
+	return ""
+}
+
+// TestDetailsFold tests folding the section at the top of the details and
+// every section, and that the folds carry over to resources of the same
+// @odata.type only
+func TestDetailsFold(t *testing.T) {
+	service := rvfstest.Service()
+	parse := func(path, payload string) *TreeItem {
+		resource, err := rvfs.NewParser(rvfs.ParserOptions{}).Parse(path, []byte(payload))
+		if err != nil {
+			t.Fatalf("Parse(%s) = %v", path, err)
+		}
+		return &TreeItem{Path: path, Kind: KindResource, Resource: resource}
+	}
+	system1 := parse("/redfish/v1/Systems/1", service["/redfish/v1/Systems/1"])
+	system2 := parse("/redfish/v1/Systems/2", strings.ReplaceAll(service["/redfish/v1/Systems/1"], "Systems/1", "Systems/2"))
+	chassis := parse("/redfish/v1/Chassis/1", service["/redfish/v1/Chassis/1"])
+
+	d := NewDetailsModel()
+	d.SetSize(80, 5)
+	d.SetItem(system1)
+	var names []string
+	for _, s := range d.sections {
+		names = append(names, s.name)
+	}
+	if want := []string{"View", "Identity", "Status", "Links", "Actions", "Other"}; !slices.Equal(names, want) {
+		t.Fatalf("sections = %q, want %q", names, want)
+	}
+	if body := sectionBody(t, &d, "Identity"); !strings.Contains(body, `BiosVersion: "1.0.0"`) || !strings.Contains(body, `Manufacturer: "Contoso"`) {
+		t.Errorf("Identity = %q", body)
+	}
+	if body := sectionBody(t, &d, "Status"); !strings.Contains(body, `PowerState: "On"`) || !strings.Contains(body, "Health: OK") {
+		t.Errorf("Status = %q", body)
+	}
+	if body := sectionBody(t, &d, "Other"); !strings.Contains(body, "Boot:") {
+		t.Errorf("Other = %q", body)
+	}
+
+	// Folding acts on the section scrolled to the top
+	d.NextSection()
+	d.NextSection()
+	d.ToggleFold()
+	if body := sectionBody(t, &d, "Identity"); body != "" {
+		t.Errorf("folded Identity shows %q", body)
+	}
+	if top := d.sections[1]; top.name != "Identity" || d.viewport.YOffset != top.line {
+		t.Errorf("panel at line %d after folding, want Identity's header at %d", d.viewport.YOffset, top.line)
+	}
+	if !strings.Contains(ansi.Strip(d.content), "▸ Identity (5)") {
+		t.Errorf("folded header missing from %q", ansi.Strip(d.content))
+	}
+
+	// Another system opens with Identity folded, a chassis does not
+	d.SetItem(system2)
+	if body := sectionBody(t, &d, "Identity"); body != "" {
+		t.Errorf("Identity of another system shows %q", body)
+	}
+	if body := sectionBody(t, &d, "Status"); body == "" {
+		t.Error("Status of another system is folded")
+	}
+	d.SetItem(chassis)
+	if body := sectionBody(t, &d, "Identity"); !strings.Contains(body, `Name: "Chassis"`) {
+		t.Errorf("Identity of a chassis = %q", body)
+	}
+
+	d.ToggleFoldAll()
+	for _, s := range d.sections {
+		if body := sectionBody(t, &d, s.name); body != "" {
+			t.Errorf("%s shows %q with every section folded", s.name, body)
+		}
+	}
+	d.ToggleFoldAll()
+	for _, s := range d.sections {
+		if body := sectionBody(t, &d, s.name); body == "" {
+			t.Errorf("%s is folded once every section is unfolded", s.name)
+		}
+	}
+
+	// Folding every section of a system, with one folded, folds the rest
+	d.SetItem(system1)
+	d.ToggleFoldAll()
+	if folded := d.foldedSections(); len(folded) != len(d.sections) || !folded["Status"] || !folded["Identity"] {
+		t.Errorf("system folds after folding all = %v", folded)
+	}
+}
+
+// crawl runs a scrape's fetches until the queue is empty
+func crawl(s *ScrapeModel, cmd tea.Cmd) {
+	for cmd != nil {
+		switch msg := cmd().(type) {
+		case scrapeTickMsg:
+			cmd = s.HandleTick(msg.Path)
+		case scrapeDoneMsg:
+			cmd = s.HandleDone(msg)
+		}
+	}
+}
+
+// failedPaths returns the paths of a scrape's failures with their status,
+// sorted
+func failedPaths(s *ScrapeModel) []string {
+	var paths []string
+	for _, f := range s.failures {
+		paths = append(paths, fmt.Sprintf("%s %d", f.Path, f.Status))
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// TestScrapeTriage tests the failure list a scrape ends with, and which
+// failures a retry fetches again
+func TestScrapeTriage(t *testing.T) {
+	server := rvfstest.NewServer(rvfstest.Service())
+	defer server.Close()
+	server.Fail("/redfish/v1/Systems", http.StatusServiceUnavailable)
+	server.Fail("/redfish/v1/Chassis", http.StatusInternalServerError)
+	server.Fail("/redfish/v1/Managers", http.StatusNotFound)
+	server.Fail("/redfish/v1/SessionService", http.StatusForbidden)
+	vfs := server.VFS(t)
+	if _, err := vfs.Get("/redfish/v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewScrapeModel(vfs)
+	crawl(&s, s.Start("/redfish/v1"))
+	want := []string{"/redfish/v1/Chassis 500", "/redfish/v1/Managers 404", "/redfish/v1/Systems 503"}
+	if got := failedPaths(&s); !slices.Equal(got, want) {
+		t.Fatalf("failures = %q, want %q", got, want)
+	}
+	if !s.Triaging() || s.denied != 1 || !strings.Contains(s.Summary(), "3 failed") {
+		t.Errorf("triaging %v, %d denied, summary %q", s.Triaging(), s.denied, s.Summary())
+	}
+	if tally := s.categories().String(); tally != "1 notfound, 2 server" {
+		t.Errorf("categories = %q", tally)
+	}
+
+	requests := func() map[string]int {
+		counts := make(map[string]int)
+		for _, f := range s.failures {
+			counts[f.Path] = server.Requests(http.MethodGet, f.Path)
+		}
+		return counts
+	}
+
+	retryOnly := func(selected string) {
+		t.Helper()
+		before := requests()
+		crawl(&s, s.Retry())
+		for path, n := range requests() {
+			want := before[path]
+			if path == selected {
+				want++
+			}
+			if n != want {
+				t.Errorf("%s fetched %d more times retrying %s", path, n-before[path], selected)
+			}
+		}
+	}
+
+	// Without marks only the selected failure is fetched again
+	s.MoveCursor(1)
+	retryOnly(s.Selected())
+
+	// Marking and unmarking leaves nothing marked, so the selected one is
+	// fetched again
+	s.cursor = 0
+	s.ToggleMark()
+	s.MoveCursor(-1)
+	s.ToggleMark()
+	s.cursor = 0
+	retryOnly(s.Selected())
+
+	// Marked failures are fetched again, and leave the list once fetched
+	s.cursor = 0
+	s.ToggleMark()
+	s.ToggleMark()
+	var marked []string
+	for path := range s.marked {
+		marked = append(marked, path)
+	}
+	slices.Sort(marked)
+	for _, path := range marked {
+		server.Fail(path, 0)
+	}
+	before := requests()
+	crawl(&s, s.Retry())
+	var rest []string
+	for _, f := range s.failures {
+		rest = append(rest, f.Path)
+	}
+	if len(rest) != 1 || slices.Contains(marked, rest[0]) {
+		t.Errorf("failures after retrying %q = %q", marked, rest)
+	}
+	for _, path := range marked {
+		if n := server.Requests(http.MethodGet, path); n != before[path]+1 {
+			t.Errorf("%s fetched %d times, want %d", path, n, before[path]+1)
+		}
+	}
+	if n := server.Requests(http.MethodGet, rest[0]); n != before[rest[0]] {
+		t.Errorf("unmarked %s fetched again", rest[0])
+	}
+}
+
+// settle runs the fetches cmd starts, and those their results start,
+// until none is left
+func settle(m Model, cmd tea.Cmd) Model {
+	if cmd == nil {
+		return m
+	}
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		for _, c := range msg {
+			m = settle(m, c)
+		}
+	case fetchResourceMsg, ResourceLoadedMsg:
+		model, next := m.Update(msg)
+		m = settle(model.(Model), next)
+	}
+	return m
+}
+
+// TestMarks tests setting a mark and jumping back to it, in the tree shown
+// and in the tree of another root, and that marks are saved with the rest
+// of the state for the endpoint
+func TestMarks(t *testing.T) {
+	server := rvfstest.NewServer(rvfstest.Service())
+	defer server.Close()
+	m := loadedModel(t, server, "/redfish/v1")
+	const marked = "/redfish/v1/Systems/1"
+
+	m = moveTo(t, m, "/redfish/v1/Systems")
+	m, cmd := press(m, "l")
+	m = settle(m, cmd)
+	m = moveTo(t, m, marked)
+	m, _ = press(m, "m", "a")
+	want := mark{BasePath: "/redfish/v1", Expanded: m.tree.AncestorPaths(), Cursor: marked}
+	if !reflect.DeepEqual(m.marks, map[string]mark{"a": want}) {
+		t.Errorf("marks = %+v, want a at %+v", m.marks, want)
+	}
+	if m.statusMsg != "Mark a set at "+marked {
+		t.Errorf("status = %q", m.statusMsg)
+	}
+
+	// Collapsed away from the mark, in the same tree
+	m.tree.cursor = 0
+	m = moveTo(t, m, "/redfish/v1/Systems")
+	m, _ = press(m, "h")
+	for _, item := range m.tree.visible {
+		if item.Path == marked {
+			t.Fatalf("%s shown with Systems collapsed", marked)
+		}
+	}
+	m, cmd = press(m, "'", "a")
+	m = settle(m, cmd)
+	if item := m.tree.Current(); item == nil || item.Path != marked {
+		t.Errorf("cursor after jumping to a = %+v, want %s", item, marked)
+	}
+	if len(m.rootStack) != 0 {
+		t.Errorf("jumping within the tree pushed %v", m.rootStack)
+	}
+
+	m, _ = press(m, "'", "b")
+	if m.statusMsg != "No mark b" {
+		t.Errorf("status after jumping to an unset mark = %q", m.statusMsg)
+	}
+	m, _ = press(m, "m", "esc")
+	if m.markOp != markNone || len(m.marks) != 1 {
+		t.Errorf("a key naming no mark left op %v, marks %v", m.markOp, m.marks)
+	}
+
+	// From a tree of another root, which back returns to
+	model, cmd := m.navigateTo("/redfish/v1/Chassis")
+	m = settle(model.(Model), cmd)
+	m, cmd = press(m, "'", "a")
+	m = settle(m, cmd)
+	if m.basePath != "/redfish/v1" || !reflect.DeepEqual(m.rootStack, []string{"/redfish/v1/Chassis"}) {
+		t.Errorf("jump from another root left base %s, stack %v", m.basePath, m.rootStack)
+	}
+	if item := m.tree.Current(); item == nil || item.Path != marked {
+		t.Errorf("cursor after jumping from another root = %+v, want %s", item, marked)
+	}
+
+	// Saved for the endpoint and restored on the next run
+	f, err := loadStateFile(filepath.Join(t.TempDir(), "bfui_state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Put("https://bmc", m.state()); err != nil {
+		t.Fatal(err)
+	}
+	if f, err = loadStateFile(f.path); err != nil {
+		t.Fatal(err)
+	}
+	next := loadedModel(t, server, "/redfish/v1")
+	next = next.restore(&uiState{BasePath: "/redfish/v1", Marks: f.Get("https://bmc").Marks})
+	if !reflect.DeepEqual(next.marks, m.marks) {
+		t.Errorf("marks restored = %+v, want %+v", next.marks, m.marks)
+	}
+	next, cmd = press(next, "'", "a")
+	next = settle(next, cmd)
+	if item := next.tree.Current(); item == nil || item.Path != marked {
+		t.Errorf("cursor after jumping to a restored mark = %+v, want %s", item, marked)
+	}
+}
@@ -1,7 +1,9 @@
-package main
+package bfui
 
 import (
 	"strings"
+
+	"github.com/bluefish-project/bluefish/theme"
 )
 
 // BreadcrumbModel renders a path as styled segments
@@ -10,8 +12,8 @@ type BreadcrumbModel struct {
 	maxWidth int
 }
 
-func NewBreadcrumbModel() BreadcrumbModel {
-	return BreadcrumbModel{path: "/redfish/v1"}
+func NewBreadcrumbModel(path string) BreadcrumbModel {
+	return BreadcrumbModel{path: path}
 }
 
 func (b *BreadcrumbModel) SetPath(path string) {
@@ -45,7 +47,7 @@ func (b *BreadcrumbModel) View() string {
 	// If too wide, truncate from the left
 	if b.maxWidth > 0 {
 		plain := strings.Join(segments, " > ")
-		for len(plain) > b.maxWidth && len(parts) > 2 {
+		for theme.Width(plain) > b.maxWidth && len(parts) > 2 {
 			parts = parts[1:]
 			parts[0] = breadcrumbSepStyle.Render("..") + sep + parts[0]
 			segments = segments[1:]
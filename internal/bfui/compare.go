@@ -0,0 +1,153 @@
+package bfui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/theme"
+)
+
+// handlePin pins the selected resource so the resources selected after it
+// are compared against it; on the pinned resource it unpins
+func (m Model) handlePin() (tea.Model, tea.Cmd) {
+	item := m.tree.Current()
+	if item == nil {
+		return m, nil
+	}
+	resource := itemResource(item)
+	pinned := m.details.Pinned()
+	switch {
+	case resource == nil:
+		m.statusMsg = "Only resources can be pinned"
+	case pinned != nil && pinned.Path == resource.Path:
+		m.details.Pin(nil)
+		m.statusMsg = "Unpinned " + resource.Path
+	default:
+		m.details.Pin(resource)
+		m.statusMsg = "Pinned " + resource.Path + "; select another resource to compare"
+	}
+	return m, nil
+}
+
+// Pin sets the resource the selected one is compared against; nil unpins.
+// The pinned resource is compared as it was when pinned.
+func (d *DetailsModel) Pin(resource *rvfs.Resource) {
+	d.pinned = resource
+	d.render()
+}
+
+// Pinned returns the pinned resource, or nil
+func (d *DetailsModel) Pinned() *rvfs.Resource {
+	return d.pinned
+}
+
+// ToggleSideBySide switches the comparison between a unified list and
+// side-by-side columns
+func (d *DetailsModel) ToggleSideBySide() {
+	d.sideBySide = !d.sideBySide
+	d.render()
+}
+
+// comparing returns the resource of the item when it is compared against
+// the pinned one, nil when the item's details are shown instead
+func (d *DetailsModel) comparing(item *TreeItem) *rvfs.Resource {
+	resource := itemResource(item)
+	if d.pinned == nil || resource == nil || resource.Path == d.pinned.Path {
+		return nil
+	}
+	return resource
+}
+
+// itemResource returns the resource a tree item stands for, nil for
+// properties, links and children not loaded yet
+func itemResource(item *TreeItem) *rvfs.Resource {
+	if item.Kind == KindResource || item.Kind == KindChild {
+		return item.Resource
+	}
+	return nil
+}
+
+// renderCompare renders the properties that differ between the pinned
+// resource and resource
+func (d *DetailsModel) renderCompare(b *strings.Builder, resource *rvfs.Resource) {
+	diffs := rvfs.DiffResources(d.pinned, resource)
+
+	layout := "P: side by side"
+	if d.sideBySide {
+		layout = "P: unified"
+	}
+	b.WriteString(detailLabelStyle.Render(fmt.Sprintf("Compare: %d differences", len(diffs))))
+	b.WriteString(indicatorStyle.Render(fmt.Sprintf("  (%s)", layout)))
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("  %s %s %s\n", diffOldStyle.Render("-"), detailValueStyle.Render(d.pinned.Path), indicatorStyle.Render("(pinned)")))
+	b.WriteString(fmt.Sprintf("  %s %s\n\n", diffNewStyle.Render("+"), detailValueStyle.Render(resource.Path)))
+
+	if len(diffs) == 0 {
+		b.WriteString(loadingStyle.Render("No differences"))
+		b.WriteString("\n")
+		return
+	}
+	if d.sideBySide {
+		d.renderSideBySide(b, diffs)
+		return
+	}
+	for _, diff := range diffs {
+		b.WriteString(propNameStyle.Render(diff.Property))
+		b.WriteString("\n")
+		if diff.Old != nil {
+			b.WriteString(fmt.Sprintf("  %s %s\n", diffOldStyle.Render("-"), diffOldStyle.Render(diffValue(diff.Old))))
+		}
+		if diff.New != nil {
+			b.WriteString(fmt.Sprintf("  %s %s\n", diffNewStyle.Render("+"), diffNewStyle.Render(diffValue(diff.New))))
+		}
+	}
+}
+
+// renderSideBySide renders differences as a property column and a column
+// for each resource, truncating what does not fit the panel
+func (d *DetailsModel) renderSideBySide(b *strings.Builder, diffs []rvfs.Difference) {
+	width := max(d.viewport.Width, 40)
+	nameWidth := 0
+	for _, diff := range diffs {
+		nameWidth = max(nameWidth, theme.Width(diff.Property))
+	}
+	nameWidth = min(nameWidth, width/3)
+	valueWidth := (width - nameWidth - 4) / 2
+
+	column := func(p *rvfs.Property) string {
+		if p == nil {
+			return "(absent)"
+		}
+		return diffValue(p)
+	}
+	for _, diff := range diffs {
+		name := theme.Truncate(diff.Property, nameWidth, "…")
+		old := theme.Truncate(column(diff.Old), valueWidth, "…")
+		new := theme.Truncate(column(diff.New), valueWidth, "…")
+		b.WriteString(fmt.Sprintf("%s  %s  %s\n",
+			propNameStyle.Render(theme.Pad(name, nameWidth)),
+			diffOldStyle.Render(theme.Pad(old, valueWidth)),
+			diffNewStyle.Render(new)))
+	}
+}
+
+// diffValue renders a compared property on one line: simple values as in
+// the details, links by target and objects and arrays as compact JSON
+func diffValue(p *rvfs.Property) string {
+	switch p.Type {
+	case rvfs.PropertySimple:
+		return formatPlainValue(p.Value)
+	case rvfs.PropertyLink:
+		return "→ " + p.LinkTarget
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, p.RawJSON); err != nil {
+		return string(p.RawJSON)
+	}
+	return compact.String()
+}
@@ -0,0 +1,484 @@
+package bfui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bluefish-project/bluefish/display"
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/theme"
+)
+
+// rawMaxElements is how many elements of each array the raw view shows
+const rawMaxElements = 20
+
+// detailSections are the sections resource details are grouped into, in
+// display order; View holds the rendering of a resource type with a view
+var detailSections = []string{"View", "Identity", "Status", "Links", "Actions", "Oem", "Other"}
+
+// identityProperties and statusProperties are the top-level properties
+// shown under Identity and Status
+var (
+	identityProperties = map[string]bool{
+		"Id": true, "Name": true, "Description": true, "UUID": true,
+		"Manufacturer": true, "Model": true, "SKU": true, "SerialNumber": true,
+		"PartNumber": true, "AssetTag": true, "Version": true,
+		"FirmwareVersion": true, "BiosVersion": true,
+	}
+	statusProperties = map[string]bool{
+		"Status": true, "PowerState": true, "IndicatorLED": true,
+		"LocationIndicatorActive": true,
+	}
+)
+
+// detailSection is a section header in the rendered details
+type detailSection struct {
+	name string
+	line int // Line of its header in the content
+}
+
+// DetailsModel manages the details panel with a scrollable viewport
+type DetailsModel struct {
+	viewport viewport.Model
+	content  string
+	ready    bool
+	item     *TreeItem
+	raw      bool // Show the item's JSON instead of its details
+
+	// Sections of the rendered resource, and the folded ones of each
+	// @odata.type, remembered across items
+	sections  []detailSection
+	odataType string
+	folded    map[string]map[string]bool
+
+	pinned     *rvfs.Resource // Resource other resources are compared against
+	sideBySide bool           // Compare in columns rather than a unified list
+}
+
+func NewDetailsModel() DetailsModel {
+	return DetailsModel{folded: make(map[string]map[string]bool)}
+}
+
+func (d *DetailsModel) SetSize(width, height int) {
+	if !d.ready {
+		d.viewport = viewport.New(width, height)
+		d.viewport.SetContent(d.content)
+		d.ready = true
+	} else {
+		d.viewport.Width = width
+		d.viewport.Height = height
+	}
+}
+
+func (d *DetailsModel) Update(msg tea.Msg) {
+	d.viewport, _ = d.viewport.Update(msg)
+}
+
+func (d *DetailsModel) ScrollDown() {
+	d.viewport.ScrollDown(1)
+}
+
+func (d *DetailsModel) ScrollUp() {
+	d.viewport.ScrollUp(1)
+}
+
+// ToggleRaw switches between the details and the raw JSON of the item
+func (d *DetailsModel) ToggleRaw() {
+	d.raw = !d.raw
+	d.SetItem(d.item)
+}
+
+// NextSection scrolls the next section header to the top
+func (d *DetailsModel) NextSection() {
+	for _, s := range d.sections {
+		if s.line > d.viewport.YOffset {
+			d.viewport.SetYOffset(s.line)
+			return
+		}
+	}
+}
+
+// PrevSection scrolls the previous section header to the top
+func (d *DetailsModel) PrevSection() {
+	for i := len(d.sections) - 1; i >= 0; i-- {
+		if d.sections[i].line < d.viewport.YOffset {
+			d.viewport.SetYOffset(d.sections[i].line)
+			return
+		}
+	}
+}
+
+// ToggleFold folds or unfolds the section at the top of the panel, or the
+// first section while the panel shows what precedes them. The fold is
+// remembered for every resource of the same @odata.type.
+func (d *DetailsModel) ToggleFold() {
+	if len(d.sections) == 0 {
+		return
+	}
+	current := d.sections[0].name
+	for _, s := range d.sections {
+		if s.line <= d.viewport.YOffset {
+			current = s.name
+		}
+	}
+	folded := d.foldedSections()
+	folded[current] = !folded[current]
+	d.render()
+	for _, s := range d.sections {
+		if s.name == current {
+			d.viewport.SetYOffset(s.line)
+		}
+	}
+}
+
+// ToggleFoldAll folds every section, or unfolds them all when all are
+// folded
+func (d *DetailsModel) ToggleFoldAll() {
+	if len(d.sections) == 0 {
+		return
+	}
+	folded := d.foldedSections()
+	all := true
+	for _, s := range d.sections {
+		all = all && folded[s.name]
+	}
+	for _, s := range d.sections {
+		folded[s.name] = !all
+	}
+	d.render()
+	d.viewport.GotoTop()
+}
+
+// foldedSections returns the fold state of the rendered resource's type
+func (d *DetailsModel) foldedSections() map[string]bool {
+	folded, ok := d.folded[d.odataType]
+	if !ok {
+		folded = make(map[string]bool)
+		d.folded[d.odataType] = folded
+	}
+	return folded
+}
+
+// SetItem updates the details panel to show info about a tree item
+func (d *DetailsModel) SetItem(item *TreeItem) {
+	d.item = item
+	d.render()
+	if d.ready {
+		d.viewport.GotoTop()
+	}
+}
+
+// render renders the item into the viewport, keeping the scroll position
+func (d *DetailsModel) render() {
+	item := d.item
+	d.sections = nil
+	d.odataType = ""
+	if item == nil {
+		d.content = ""
+		if d.ready {
+			d.viewport.SetContent("")
+		}
+		return
+	}
+
+	var b strings.Builder
+
+	// Path
+	b.WriteString(detailLabelStyle.Render("Path: "))
+	b.WriteString(detailValueStyle.Render(item.Path))
+	b.WriteString("\n\n")
+
+	switch resource := d.comparing(item); {
+	case d.raw:
+		d.renderRaw(&b, item)
+	case resource != nil:
+		d.renderCompare(&b, resource)
+	default:
+		d.renderKind(&b, item)
+	}
+
+	d.content = b.String()
+	if d.ready {
+		d.viewport.SetContent(d.content)
+	}
+}
+
+// renderKind renders the details of an item by its kind
+func (d *DetailsModel) renderKind(b *strings.Builder, item *TreeItem) {
+	switch item.Kind {
+	case KindResource:
+		d.renderResource(b, item)
+	case KindChild:
+		d.renderChild(b, item)
+	case KindSimple:
+		d.renderSimple(b, item)
+	case KindObject:
+		d.renderObject(b, item)
+	case KindArray:
+		d.renderArray(b, item)
+	case KindLink:
+		d.renderLink(b, item)
+	}
+}
+
+// renderRaw renders the JSON of the item's resource or property
+func (d *DetailsModel) renderRaw(b *strings.Builder, item *TreeItem) {
+	var raw []byte
+	switch {
+	case item.Resource != nil && item.Kind == KindResource:
+		raw = item.Resource.RawJSON
+	case item.Property != nil:
+		raw = item.Property.RawJSON
+	}
+	if len(raw) == 0 {
+		b.WriteString(detailLabelStyle.Render("No JSON loaded for this item"))
+		return
+	}
+	out, err := activeTheme.RenderJSON(raw, theme.JSONOptions{MaxElements: rawMaxElements})
+	if err != nil {
+		b.WriteString(actionErrorStyle.Render(err.Error()))
+		return
+	}
+	b.WriteString(out)
+}
+
+func (d *DetailsModel) renderResource(b *strings.Builder, item *TreeItem) {
+	b.WriteString(detailLabelStyle.Render("Type: "))
+	b.WriteString("Resource\n")
+
+	if item.Resource == nil {
+		return
+	}
+
+	if item.Resource.ODataType != "" {
+		b.WriteString(detailLabelStyle.Render("@odata.type: "))
+		b.WriteString(detailValueStyle.Render(item.Resource.ODataType))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	d.renderSections(b, item.Resource)
+}
+
+func (d *DetailsModel) renderChild(b *strings.Builder, item *TreeItem) {
+	b.WriteString(detailLabelStyle.Render("Type: "))
+	b.WriteString("Child Resource\n")
+
+	if item.Child != nil {
+		b.WriteString(detailLabelStyle.Render("Target: "))
+		b.WriteString(childStyle.Render(item.Child.Target))
+		b.WriteString("\n")
+		if item.Child.IsExternal() {
+			b.WriteString(detailLabelStyle.Render("External: "))
+			b.WriteString("yes (symlink)\n")
+		}
+	}
+	if item.Denied {
+		b.WriteString("\n")
+		b.WriteString(deniedStyle.Render("Permission denied: the service refuses this resource to the logged-in role"))
+		b.WriteString("\n")
+	}
+
+	if item.Resource != nil {
+		b.WriteString("\n")
+		d.renderSections(b, item.Resource)
+	}
+}
+
+func (d *DetailsModel) renderSimple(b *strings.Builder, item *TreeItem) {
+	b.WriteString(detailLabelStyle.Render("Type: "))
+	b.WriteString("Value\n\n")
+	b.WriteString(detailLabelStyle.Render("Value: "))
+	if item.Property != nil {
+		b.WriteString(formatHealthValue(item.Name, item.Property.Value))
+	}
+	b.WriteString("\n")
+}
+
+func (d *DetailsModel) renderObject(b *strings.Builder, item *TreeItem) {
+	b.WriteString(detailLabelStyle.Render("Type: "))
+	b.WriteString("Object\n")
+	b.WriteString(detailLabelStyle.Render(fmt.Sprintf("Fields: %d", item.ChildCount)))
+	b.WriteString("\n\n")
+
+	if item.Property != nil {
+		childNames := make([]string, 0, len(item.Property.Children))
+		for n := range item.Property.Children {
+			childNames = append(childNames, n)
+		}
+		sort.Strings(childNames)
+		for _, name := range childNames {
+			child := item.Property.Children[name]
+			d.renderPropertyRecursive(b, name, child, 0)
+		}
+	}
+}
+
+func (d *DetailsModel) renderArray(b *strings.Builder, item *TreeItem) {
+	b.WriteString(detailLabelStyle.Render("Type: "))
+	b.WriteString("Array\n")
+	b.WriteString(detailLabelStyle.Render(fmt.Sprintf("Elements: %d", item.ChildCount)))
+	b.WriteString("\n\n")
+
+	if item.Property != nil {
+		for i, elem := range item.Property.Elements {
+			name := fmt.Sprintf("[%d]", i)
+			d.renderPropertyRecursive(b, name, elem, 0)
+		}
+	}
+}
+
+func (d *DetailsModel) renderLink(b *strings.Builder, item *TreeItem) {
+	b.WriteString(detailLabelStyle.Render("Type: "))
+	b.WriteString("Link\n\n")
+	b.WriteString(detailLabelStyle.Render("Target: "))
+	b.WriteString(linkStyle.Render(item.LinkTarget))
+	b.WriteString("\n\n")
+	b.WriteString(helpDescStyle.Render("Press Enter to follow"))
+	b.WriteString("\n")
+}
+
+// renderSections renders a resource's conditions, messages, children and
+// properties grouped into sections, leaving out the folded ones' content
+func (d *DetailsModel) renderSections(b *strings.Builder, resource *rvfs.Resource) {
+	d.odataType = resource.ODataType
+	folded := d.foldedSections()
+
+	content := make(map[string]*strings.Builder)
+	counts := make(map[string]int)
+	for _, name := range detailSections {
+		content[name] = &strings.Builder{}
+	}
+
+	status := content["Status"]
+	if len(resource.Conditions) > 0 {
+		status.WriteString(detailLabelStyle.Render(fmt.Sprintf("  Conditions: %d", len(resource.Conditions))))
+		status.WriteString("\n")
+		for _, c := range resource.Conditions {
+			status.WriteString(formatCondition(c, 2))
+		}
+		counts["Status"] += len(resource.Conditions)
+	}
+	if len(resource.Messages) > 0 {
+		status.WriteString(detailLabelStyle.Render(fmt.Sprintf("  Messages: %d", len(resource.Messages))))
+		status.WriteString("\n")
+		for _, m := range resource.Messages {
+			status.WriteString(formatMessage(m, 2))
+		}
+		counts["Status"] += len(resource.Messages)
+	}
+
+	if view, ok := display.Render(&display.Context{Theme: activeTheme}, resource); ok {
+		for _, line := range strings.SplitAfter(strings.TrimSuffix(view, "\n"), "\n") {
+			content["View"].WriteString("  " + line)
+		}
+		content["View"].WriteString("\n")
+		counts["View"] = 1
+	}
+
+	links := content["Links"]
+	for _, name := range sortedKeys(resource.Children) {
+		child := resource.Children[name]
+		links.WriteString(fmt.Sprintf("  %s %s %s\n",
+			childStyle.Render(name),
+			linkStyle.Render("→"),
+			detailValueStyle.Render(child.Target)))
+		counts["Links"]++
+	}
+
+	for _, name := range sortedKeys(resource.Properties) {
+		prop := resource.Properties[name]
+		section := propertySection(name, prop)
+		d.renderPropertyRecursive(content[section], name, prop, 1)
+		counts[section]++
+	}
+
+	for _, name := range detailSections {
+		if counts[name] == 0 {
+			continue
+		}
+		d.sections = append(d.sections, detailSection{name: name, line: strings.Count(b.String(), "\n")})
+		indicator := "▾ "
+		if folded[name] {
+			indicator = "▸ "
+		}
+		b.WriteString(indicatorStyle.Render(indicator))
+		label := fmt.Sprintf("%s (%d)", name, counts[name])
+		if name == "View" {
+			label = name
+		}
+		b.WriteString(detailLabelStyle.Render(label))
+		b.WriteString("\n")
+		if !folded[name] {
+			b.WriteString(content[name].String())
+		}
+	}
+}
+
+// propertySection returns the section a top-level property is shown in
+func propertySection(name string, prop *rvfs.Property) string {
+	switch {
+	case identityProperties[name]:
+		return "Identity"
+	case statusProperties[name]:
+		return "Status"
+	case name == "Links" || prop.Type == rvfs.PropertyLink:
+		return "Links"
+	case name == "Actions":
+		return "Actions"
+	case name == "Oem":
+		return "Oem"
+	}
+	return "Other"
+}
+
+func (d *DetailsModel) renderPropertyRecursive(b *strings.Builder, name string, prop *rvfs.Property, indent int) {
+	prefix := strings.Repeat("  ", indent)
+
+	switch prop.Type {
+	case rvfs.PropertySimple:
+		b.WriteString(fmt.Sprintf("%s%s: %s\n", prefix, propNameStyle.Render(name), formatHealthValue(name, prop.Value)))
+
+	case rvfs.PropertyLink:
+		b.WriteString(fmt.Sprintf("%s%s: %s %s\n", prefix, propNameStyle.Render(name), linkStyle.Render("→"), linkStyle.Render(prop.LinkTarget)))
+
+	case rvfs.PropertyObject:
+		b.WriteString(fmt.Sprintf("%s%s:\n", prefix, propNameStyle.Render(name)))
+		childNames := make([]string, 0, len(prop.Children))
+		for n := range prop.Children {
+			childNames = append(childNames, n)
+		}
+		sort.Strings(childNames)
+		for _, cn := range childNames {
+			d.renderPropertyRecursive(b, cn, prop.Children[cn], indent+1)
+		}
+
+	case rvfs.PropertyArray:
+		b.WriteString(fmt.Sprintf("%s%s: [%d]\n", prefix, propNameStyle.Render(name), len(prop.Elements)))
+		for i, elem := range prop.Elements {
+			elemName := fmt.Sprintf("[%d]", i)
+			d.renderPropertyRecursive(b, elemName, elem, indent+1)
+		}
+	}
+}
+
+func (d *DetailsModel) View() string {
+	if !d.ready {
+		return ""
+	}
+	return d.viewport.View()
+}
+
+// sortedKeys returns sorted keys from a map[string]*rvfs.Child
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
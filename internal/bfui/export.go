@@ -1,13 +1,13 @@
-package main
+package bfui
 
 import (
 	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 
 	"github.com/bluefish-project/bluefish/rvfs"
 )
@@ -35,7 +35,8 @@ type exportWrittenMsg struct {
 // ExportModel manages the export overlay
 type ExportModel struct {
 	vfs       rvfs.VFS
-	root      string
+	redaction rvfs.Redaction // Properties whose values are masked in the file
+	roots     []string
 	filename  string
 	queue     []string
 	visited   map[string]bool
@@ -47,6 +48,7 @@ type ExportModel struct {
 	active    bool
 	written   bool
 	result    string
+	started   time.Time
 	width     int
 	height    int
 }
@@ -55,10 +57,10 @@ func NewExportModel(vfs rvfs.VFS) ExportModel {
 	return ExportModel{vfs: vfs}
 }
 
-// Start begins an export from a root path
-func (e *ExportModel) Start(rootPath, filename string) tea.Cmd {
+// Start begins an export of the resources reachable from the root paths
+func (e *ExportModel) Start(roots []string, filename string) tea.Cmd {
 	e.active = true
-	e.root = rootPath
+	e.roots = roots
 	e.filename = filename
 	e.queue = nil
 	e.visited = make(map[string]bool)
@@ -69,14 +71,15 @@ func (e *ExportModel) Start(rootPath, filename string) tea.Cmd {
 	e.errors = nil
 	e.written = false
 	e.result = ""
+	e.started = time.Now()
 
-	// BFS from rootPath to discover all reachable paths
+	// BFS from the roots to discover all reachable paths
 	cached := make(map[string]bool)
 	for _, p := range e.vfs.GetKnownPaths() {
 		cached[p] = true
 	}
 
-	frontier := []string{rootPath}
+	frontier := append([]string(nil), roots...)
 	var uncached []string
 
 	for len(frontier) > 0 {
@@ -191,7 +194,11 @@ func (e *ExportModel) HandleDone(msg exportDoneMsg) tea.Cmd {
 func (e *ExportModel) writeFile() tea.Cmd {
 	collected := e.collected
 	filename := e.filename
+	redaction := e.redaction
 	return func() tea.Msg {
+		for path, raw := range collected {
+			collected[path] = redaction.Redact("", raw)
+		}
 		data, err := json.MarshalIndent(collected, "", "  ")
 		if err != nil {
 			return exportWrittenMsg{Filename: filename, Err: fmt.Errorf("marshal: %v", err)}
@@ -269,8 +276,8 @@ func (e *ExportModel) View() string {
 	if filled > barWidth {
 		filled = barWidth
 	}
-	bar := lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(2)).Render(strings.Repeat("█", filled))
-	empty := lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(8)).Render(strings.Repeat("░", barWidth-filled))
+	bar := progressStyle.Render(strings.Repeat("█", filled))
+	empty := progressEmptyStyle.Render(strings.Repeat("░", barWidth-filled))
 	b.WriteString("  " + bar + empty)
 	b.WriteString("\n\n")
 
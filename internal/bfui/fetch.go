@@ -0,0 +1,75 @@
+package bfui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+)
+
+// maxConcurrentFetches is how many tree expansions fetch at once; the rest
+// wait their turn so a slow BMC is not flooded
+const maxConcurrentFetches = 4
+
+// fetchQueue schedules the fetches of expanded tree nodes: a path already
+// in flight or waiting is fetched once, and at most maxConcurrentFetches
+// run at a time
+type fetchQueue struct {
+	vfs      rvfs.VFS
+	inFlight map[string]bool
+	waiting  []string
+}
+
+func newFetchQueue(vfs rvfs.VFS) fetchQueue {
+	return fetchQueue{vfs: vfs, inFlight: make(map[string]bool)}
+}
+
+// Request queues a fetch of path and returns the fetch to start, if one
+// may start now
+func (q *fetchQueue) Request(path string) tea.Cmd {
+	if q.inFlight[path] {
+		return nil
+	}
+	for _, p := range q.waiting {
+		if p == path {
+			return nil
+		}
+	}
+	q.waiting = append(q.waiting, path)
+	return q.next()
+}
+
+// Done records that the fetch of path finished and returns the next fetch
+// to start. Paths the queue did not fetch are ignored.
+func (q *fetchQueue) Done(path string) tea.Cmd {
+	if !q.inFlight[path] {
+		return nil
+	}
+	delete(q.inFlight, path)
+	return q.next()
+}
+
+// DropWaiting forgets the fetches not started yet, as when the tree they
+// were for is replaced
+func (q *fetchQueue) DropWaiting() {
+	q.waiting = nil
+}
+
+// Pending is how many fetches are running or waiting
+func (q *fetchQueue) Pending() int {
+	return len(q.inFlight) + len(q.waiting)
+}
+
+// next starts the first waiting fetch when there is room
+func (q *fetchQueue) next() tea.Cmd {
+	if len(q.waiting) == 0 || len(q.inFlight) >= maxConcurrentFetches {
+		return nil
+	}
+	path := q.waiting[0]
+	q.waiting = q.waiting[1:]
+	q.inFlight[path] = true
+	vfs := q.vfs
+	return func() tea.Msg {
+		resource, err := vfs.Get(path)
+		return ResourceLoadedMsg{Path: path, Resource: resource, Err: err}
+	}
+}
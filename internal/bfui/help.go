@@ -0,0 +1,115 @@
+package bfui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// helpContent builds the help modal text from the active key bindings
+func helpContent() string {
+	var b strings.Builder
+
+	title := actionTitleStyle.Render("Keybindings")
+	b.WriteString(title)
+	b.WriteString("\n\n")
+
+	section := func(name string) {
+		b.WriteString(detailLabelStyle.Render(name))
+		b.WriteString("\n")
+	}
+	row := func(kb string, desc string) {
+		b.WriteString(fmt.Sprintf("  %s  %s\n",
+			helpKeyStyle.Render(fmt.Sprintf("%-14s", kb)),
+			helpDescStyle.Render(desc)))
+	}
+
+	pair := func(a, b key.Binding) string {
+		return keyLabel(a) + " / " + keyLabel(b)
+	}
+
+	section("Navigation")
+	row(keyLabel(normalKeys.Down), "Move cursor down")
+	row(keyLabel(normalKeys.Up), "Move cursor up")
+	row(keyLabel(normalKeys.Collapse), "Collapse node or move to parent")
+	row(keyLabel(normalKeys.Expand), "Expand node")
+	row(keyLabel(normalKeys.Toggle), "Toggle expand / collapse")
+	row(keyLabel(normalKeys.Enter), "Open: rebase tree on child/link, or jump to OriginOfCondition")
+	row(keyLabel(normalKeys.Back), "Back to previous root")
+	row(keyLabel(normalKeys.GoUp), "Go up to parent resource")
+	row(keyLabel(normalKeys.Home), "Go to the service root")
+	b.WriteString("\n")
+
+	section("Details")
+	row(keyLabel(normalKeys.ScrollDown), "Scroll details panel down")
+	row(keyLabel(normalKeys.ScrollUp), "Scroll details panel up")
+	row(keyLabel(normalKeys.Raw), "Toggle raw JSON (highlighted, long arrays collapsed)")
+	row(pair(normalKeys.NextSection, normalKeys.PrevSection), "Jump to the next / previous section")
+	row(keyLabel(normalKeys.Fold), "Fold / unfold the section at the top of the panel")
+	row(keyLabel(normalKeys.FoldAll), "Fold / unfold all sections")
+	row(keyLabel(normalKeys.Pin), "Pin the resource to compare others against; again to unpin")
+	row(keyLabel(normalKeys.SideBySide), "Compare unified / side by side")
+	row(pair(normalKeys.Narrow, normalKeys.Widen), "Narrow / widen the tree panel")
+	b.WriteString("\n")
+
+	section("Bookmarks, Marks & Workspaces")
+	row(keyLabel(normalKeys.Bookmark), "Bookmark the selected resource; again to remove")
+	row(keyLabel(normalKeys.NextBookmark), "Rebase the tree on the next bookmark")
+	row(keyLabel(normalKeys.SetMark)+" a-z0-9", "Mark the cursor position under a letter or digit")
+	row(keyLabel(normalKeys.JumpMark)+" a-z0-9", "Jump back to a mark, rebasing the tree if it is elsewhere")
+	row(keyLabel(normalKeys.Workspace), "Save or open a named workspace")
+	row(keyLabel(workspaceKeys.Open), "Open the named or selected workspace")
+	row(keyLabel(workspaceKeys.Save), "Save the tree, bookmarks and pin under the name")
+	b.WriteString("\n")
+
+	section("Overlays")
+	row(keyLabel(normalKeys.Search), "Search cached paths (fuzzy); =pattern finds properties")
+	row(keyLabel(normalKeys.Action), "Action mode (POST operations)")
+	row(keyLabel(normalKeys.Help), "This help screen")
+	row(keyLabel(overlayKeys.Cancel), "Close this screen or the scrape progress")
+	row(keyLabel(overlayKeys.Background), "Send a running scrape or export to the background; its key shows it again")
+	b.WriteString("\n")
+
+	section("Other")
+	row(keyLabel(normalKeys.Refresh), "Refresh current resource")
+	row(keyLabel(normalKeys.Scrape), "Scrape (crawl uncached resources)")
+	row(keyLabel(normalKeys.Export), "Export resources to JSON file")
+	row(keyLabel(normalKeys.Quit), "Quit")
+	b.WriteString("\n")
+
+	section(fmt.Sprintf("Select Mode (%s)", keyLabel(normalKeys.Select)))
+	row(keyLabel(selectKeys.Mark), "Mark / unmark item and move down")
+	row(keyLabel(selectKeys.Export), "Export the marked resources and everything below them")
+	row(keyLabel(selectKeys.Refresh), "Refresh the marked resources")
+	row(keyLabel(selectKeys.Copy), "Copy the marked paths to the clipboard")
+	row(keyLabel(selectKeys.Action), "Run an action common to the marked resources on all of them")
+	row(keyLabel(selectKeys.Clear), "Clear marks")
+	row(keyLabel(selectKeys.Cancel), "Back to normal mode, keeping the marks")
+	b.WriteString("\n")
+
+	section("Search Mode")
+	row("type", "Filter paths")
+	row(keyLabel(searchKeys.NextItem), "Next result")
+	row(keyLabel(searchKeys.PrevItem), "Previous result")
+	row(keyLabel(searchKeys.Confirm), "Navigate to selection")
+	row(keyLabel(searchKeys.Cancel), "Cancel search")
+	b.WriteString("\n")
+
+	section("Scrape Failures")
+	row(pair(scrapeKeys.Down, scrapeKeys.Up), "Select failed path")
+	row(keyLabel(scrapeKeys.Mark), "Mark for retry")
+	row(keyLabel(scrapeKeys.Retry), "Retry marked (or selected) paths")
+	row(keyLabel(scrapeKeys.Parent), "Open the failed path's parent")
+	row(keyLabel(scrapeKeys.Export), "Export the failure list to JSON")
+	b.WriteString("\n")
+
+	section("Action Mode")
+	row(pair(actionKeys.Down, actionKeys.Up), "Select action")
+	row(keyLabel(actionKeys.Confirm), "Choose action / confirm params")
+	row(keyLabel(actionKeys.Tab), "Cycle allowable values")
+	row(keyLabel(actionKeys.Yes), "Confirm POST")
+	row(pair(actionKeys.No, actionKeys.Cancel), "Cancel / go back")
+
+	return b.String()
+}
@@ -0,0 +1,174 @@
+package bfui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keymapBindings names the bindings a keymap config can remap, by mode
+// and binding
+func keymapBindings() map[string]map[string]*key.Binding {
+	return map[string]map[string]*key.Binding{
+		"normal": {
+			"up":            &normalKeys.Up,
+			"down":          &normalKeys.Down,
+			"collapse":      &normalKeys.Collapse,
+			"expand":        &normalKeys.Expand,
+			"toggle":        &normalKeys.Toggle,
+			"enter":         &normalKeys.Enter,
+			"back":          &normalKeys.Back,
+			"go_up":         &normalKeys.GoUp,
+			"home":          &normalKeys.Home,
+			"refresh":       &normalKeys.Refresh,
+			"scrape":        &normalKeys.Scrape,
+			"export":        &normalKeys.Export,
+			"scroll_down":   &normalKeys.ScrollDown,
+			"scroll_up":     &normalKeys.ScrollUp,
+			"raw":           &normalKeys.Raw,
+			"next_section":  &normalKeys.NextSection,
+			"prev_section":  &normalKeys.PrevSection,
+			"fold":          &normalKeys.Fold,
+			"fold_all":      &normalKeys.FoldAll,
+			"pin":           &normalKeys.Pin,
+			"side_by_side":  &normalKeys.SideBySide,
+			"narrow":        &normalKeys.Narrow,
+			"widen":         &normalKeys.Widen,
+			"bookmark":      &normalKeys.Bookmark,
+			"next_bookmark": &normalKeys.NextBookmark,
+			"set_mark":      &normalKeys.SetMark,
+			"jump_mark":     &normalKeys.JumpMark,
+			"workspace":     &normalKeys.Workspace,
+			"select":        &normalKeys.Select,
+			"search":        &normalKeys.Search,
+			"action":        &normalKeys.Action,
+			"help":          &normalKeys.Help,
+			"quit":          &normalKeys.Quit,
+		},
+		"search": {
+			"confirm":   &searchKeys.Confirm,
+			"cancel":    &searchKeys.Cancel,
+			"next_item": &searchKeys.NextItem,
+			"prev_item": &searchKeys.PrevItem,
+		},
+		"workspace": {
+			"open":      &workspaceKeys.Open,
+			"save":      &workspaceKeys.Save,
+			"cancel":    &workspaceKeys.Cancel,
+			"next_item": &workspaceKeys.NextItem,
+			"prev_item": &workspaceKeys.PrevItem,
+		},
+		"action": {
+			"up":      &actionKeys.Up,
+			"down":    &actionKeys.Down,
+			"confirm": &actionKeys.Confirm,
+			"cancel":  &actionKeys.Cancel,
+			"tab":     &actionKeys.Tab,
+			"yes":     &actionKeys.Yes,
+			"no":      &actionKeys.No,
+		},
+		"select": {
+			"mark":    &selectKeys.Mark,
+			"export":  &selectKeys.Export,
+			"refresh": &selectKeys.Refresh,
+			"copy":    &selectKeys.Copy,
+			"action":  &selectKeys.Action,
+			"clear":   &selectKeys.Clear,
+			"cancel":  &selectKeys.Cancel,
+		},
+		"scrape": {
+			"up":     &scrapeKeys.Up,
+			"down":   &scrapeKeys.Down,
+			"mark":   &scrapeKeys.Mark,
+			"retry":  &scrapeKeys.Retry,
+			"parent": &scrapeKeys.Parent,
+			"export": &scrapeKeys.Export,
+		},
+		"overlay": {
+			"cancel":     &overlayKeys.Cancel,
+			"background": &overlayKeys.Background,
+		},
+	}
+}
+
+// applyKeymap rebinds keys from the keymap config, which maps a mode and a
+// binding name to the keys that trigger it. Keys are named as bubbletea
+// reports them (j, up, ctrl+n, pgdown, " " for space); an empty list
+// unbinds. A key may trigger only one binding per mode.
+func applyKeymap(keymap map[string]map[string][]string) error {
+	modes := keymapBindings()
+	for _, mode := range sortedKeys(keymap) {
+		bindings, ok := modes[mode]
+		if !ok {
+			return fmt.Errorf("unknown mode %q (want %s)", mode, strings.Join(sortedKeys(modes), ", "))
+		}
+		for _, name := range sortedKeys(keymap[mode]) {
+			b, ok := bindings[name]
+			if !ok {
+				return fmt.Errorf("%s: unknown binding %q (want %s)", mode, name, strings.Join(sortedKeys(bindings), ", "))
+			}
+			keys := keymap[mode][name]
+			for _, k := range keys {
+				if k == "" {
+					return fmt.Errorf("%s.%s: empty key name", mode, name)
+				}
+			}
+			b.SetKeys(keys...)
+			b.SetHelp(keyLabel(*b), b.Help().Desc)
+		}
+	}
+
+	for _, mode := range sortedKeys(modes) {
+		owner := map[string]string{}
+		for _, name := range sortedKeys(modes[mode]) {
+			for _, k := range modes[mode][name].Keys() {
+				if other, ok := owner[k]; ok {
+					return fmt.Errorf("%s: key %q is bound to both %s and %s", mode, k, other, name)
+				}
+				owner[k] = name
+			}
+		}
+	}
+	return nil
+}
+
+// keySymbols shows keys the way the help screen names them
+var keySymbols = map[string]string{
+	"up":    "↑",
+	"down":  "↓",
+	"left":  "←",
+	"right": "→",
+	" ":     "space",
+}
+
+// keyName is how the help shows one key
+func keyName(k string) string {
+	if s, ok := keySymbols[k]; ok {
+		return s
+	}
+	return k
+}
+
+// keyLabel lists the keys of a binding for the help screen; an unbound
+// binding shows as a dash
+func keyLabel(b key.Binding) string {
+	keys := b.Keys()
+	if len(keys) == 0 {
+		return "-"
+	}
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = keyName(k)
+	}
+	return strings.Join(names, "/")
+}
+
+// shortKey is the first key of a binding, for the help bar
+func shortKey(b key.Binding) string {
+	keys := b.Keys()
+	if len(keys) == 0 {
+		return "-"
+	}
+	return keyName(keys[0])
+}
@@ -0,0 +1,374 @@
+package bfui
+
+import "github.com/charmbracelet/bubbles/key"
+
+// NormalKeyMap defines key bindings for normal browsing mode
+type NormalKeyMap struct {
+	Up           key.Binding
+	Down         key.Binding
+	Collapse     key.Binding
+	Expand       key.Binding
+	Toggle       key.Binding
+	Enter        key.Binding
+	Back         key.Binding
+	GoUp         key.Binding
+	Home         key.Binding
+	Refresh      key.Binding
+	Scrape       key.Binding
+	Export       key.Binding
+	ScrollDown   key.Binding
+	ScrollUp     key.Binding
+	Raw          key.Binding
+	NextSection  key.Binding
+	PrevSection  key.Binding
+	Fold         key.Binding
+	FoldAll      key.Binding
+	Pin          key.Binding
+	SideBySide   key.Binding
+	Narrow       key.Binding
+	Widen        key.Binding
+	Bookmark     key.Binding
+	NextBookmark key.Binding
+	SetMark      key.Binding
+	JumpMark     key.Binding
+	Workspace    key.Binding
+	Select       key.Binding
+	Search       key.Binding
+	Action       key.Binding
+	Help         key.Binding
+	Quit         key.Binding
+}
+
+var normalKeys = NormalKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("k", "up"),
+		key.WithHelp("k/↑", "up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("j", "down"),
+		key.WithHelp("j/↓", "down"),
+	),
+	Collapse: key.NewBinding(
+		key.WithKeys("h", "left"),
+		key.WithHelp("h/←", "collapse"),
+	),
+	Expand: key.NewBinding(
+		key.WithKeys("l", "right"),
+		key.WithHelp("l/→", "expand"),
+	),
+	Toggle: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "toggle"),
+	),
+	Enter: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "open / rebase"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("backspace"),
+		key.WithHelp("backspace", "back"),
+	),
+	GoUp: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "parent"),
+	),
+	Home: key.NewBinding(
+		key.WithKeys("~"),
+		key.WithHelp("~", "root"),
+	),
+	Refresh: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "refresh"),
+	),
+	Scrape: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "scrape"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "export"),
+	),
+	ScrollDown: key.NewBinding(
+		key.WithKeys("J"),
+		key.WithHelp("J", "scroll details ↓"),
+	),
+	ScrollUp: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "scroll details ↑"),
+	),
+	Raw: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "raw JSON"),
+	),
+	NextSection: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "next section"),
+	),
+	PrevSection: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "previous section"),
+	),
+	Fold: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "fold section"),
+	),
+	FoldAll: key.NewBinding(
+		key.WithKeys("Z"),
+		key.WithHelp("Z", "fold all"),
+	),
+	Pin: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "pin / compare"),
+	),
+	SideBySide: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "side by side"),
+	),
+	Narrow: key.NewBinding(
+		key.WithKeys("<"),
+		key.WithHelp("<", "narrow tree"),
+	),
+	Widen: key.NewBinding(
+		key.WithKeys(">"),
+		key.WithHelp(">", "widen tree"),
+	),
+	Bookmark: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "bookmark"),
+	),
+	NextBookmark: key.NewBinding(
+		key.WithKeys("B"),
+		key.WithHelp("B", "next bookmark"),
+	),
+	SetMark: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "set mark"),
+	),
+	JumpMark: key.NewBinding(
+		key.WithKeys("'"),
+		key.WithHelp("'", "jump to mark"),
+	),
+	Workspace: key.NewBinding(
+		key.WithKeys("W"),
+		key.WithHelp("W", "workspaces"),
+	),
+	Select: key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "select"),
+	),
+	Search: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search"),
+	),
+	Action: key.NewBinding(
+		key.WithKeys("!"),
+		key.WithHelp("!", "actions"),
+	),
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "help"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+// SearchKeyMap defines key bindings for search overlay mode
+type SearchKeyMap struct {
+	Confirm  key.Binding
+	Cancel   key.Binding
+	NextItem key.Binding
+	PrevItem key.Binding
+}
+
+var searchKeys = SearchKeyMap{
+	Confirm: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "go"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel"),
+	),
+	NextItem: key.NewBinding(
+		key.WithKeys("ctrl+j", "down"),
+		key.WithHelp("ctrl+j/↓", "next"),
+	),
+	PrevItem: key.NewBinding(
+		key.WithKeys("ctrl+k", "up"),
+		key.WithHelp("ctrl+k/↑", "prev"),
+	),
+}
+
+// WorkspaceKeyMap defines key bindings for the workspace overlay
+type WorkspaceKeyMap struct {
+	Open     key.Binding
+	Save     key.Binding
+	Cancel   key.Binding
+	NextItem key.Binding
+	PrevItem key.Binding
+}
+
+var workspaceKeys = WorkspaceKeyMap{
+	Open: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "open"),
+	),
+	Save: key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "save"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel"),
+	),
+	NextItem: key.NewBinding(
+		key.WithKeys("ctrl+j", "down"),
+		key.WithHelp("ctrl+j/↓", "next"),
+	),
+	PrevItem: key.NewBinding(
+		key.WithKeys("ctrl+k", "up"),
+		key.WithHelp("ctrl+k/↑", "prev"),
+	),
+}
+
+// ActionKeyMap defines key bindings for action overlay mode
+type ActionKeyMap struct {
+	Up      key.Binding
+	Down    key.Binding
+	Confirm key.Binding
+	Cancel  key.Binding
+	Tab     key.Binding
+	Yes     key.Binding
+	No      key.Binding
+}
+
+var actionKeys = ActionKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("k", "up"),
+		key.WithHelp("k/↑", "up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("j", "down"),
+		key.WithHelp("j/↓", "down"),
+	),
+	Confirm: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "select"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Tab: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "cycle values"),
+	),
+	Yes: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "confirm POST"),
+	),
+	No: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "cancel"),
+	),
+}
+
+// SelectKeyMap defines key bindings for visual-select mode; the tree
+// navigation keys of normal mode work too
+type SelectKeyMap struct {
+	Mark    key.Binding
+	Export  key.Binding
+	Refresh key.Binding
+	Copy    key.Binding
+	Action  key.Binding
+	Clear   key.Binding
+	Cancel  key.Binding
+}
+
+var selectKeys = SelectKeyMap{
+	Mark: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "mark"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "export"),
+	),
+	Refresh: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "refresh"),
+	),
+	Copy: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy paths"),
+	),
+	Action: key.NewBinding(
+		key.WithKeys("!"),
+		key.WithHelp("!", "action"),
+	),
+	Clear: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "clear marks"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc", "V"),
+		key.WithHelp("esc", "done"),
+	),
+}
+
+// ScrapeKeyMap defines key bindings for triaging scrape failures
+type ScrapeKeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Mark   key.Binding
+	Retry  key.Binding
+	Parent key.Binding
+	Export key.Binding
+}
+
+var scrapeKeys = ScrapeKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("k", "up"),
+		key.WithHelp("k/↑", "up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("j", "down"),
+		key.WithHelp("j/↓", "down"),
+	),
+	Mark: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "mark"),
+	),
+	Retry: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "retry"),
+	),
+	Parent: key.NewBinding(
+		key.WithKeys("o", "enter"),
+		key.WithHelp("o", "open parent"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "export list"),
+	),
+}
+
+// OverlayKeyMap defines the shared bindings of the help, scrape and export
+// modals
+type OverlayKeyMap struct {
+	Cancel     key.Binding
+	Background key.Binding // Hide a running scrape or export, letting it finish
+}
+
+var overlayKeys = OverlayKeyMap{
+	Cancel: key.NewBinding(
+		key.WithKeys("esc", "?"),
+		key.WithHelp("esc/?", "close"),
+	),
+	Background: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "background"),
+	),
+}
@@ -0,0 +1,90 @@
+package bfui
+
+import (
+	"fmt"
+	"maps"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// mark is a tree position saved under a letter or digit, to jump back to
+type mark struct {
+	BasePath string   `json:"base_path"`
+	Expanded []string `json:"expanded,omitempty"` // Nodes above the cursor, parents first
+	Cursor   string   `json:"cursor"`
+}
+
+// markOp is the mark key waiting for the key that names the mark
+type markOp int
+
+const (
+	markNone markOp = iota
+	markSet
+	markJump
+)
+
+// markName returns the mark a key names: a letter or a digit
+func markName(msg tea.KeyMsg) (string, bool) {
+	if msg.Type != tea.KeyRunes || msg.Alt || len(msg.Runes) != 1 {
+		return "", false
+	}
+	switch r := msg.Runes[0]; {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return string(r), true
+	}
+	return "", false
+}
+
+// handleMarkKey sets or jumps to the mark the key names; any other key
+// cancels
+func (m Model) handleMarkKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	op := m.markOp
+	m.markOp = markNone
+	m.statusMsg = ""
+	name, ok := markName(msg)
+	if !ok {
+		return m, nil
+	}
+	if op == markSet {
+		return m.setMark(name)
+	}
+	return m.jumpToMark(name)
+}
+
+// setMark saves the tree position under name, replacing the mark it held
+func (m Model) setMark(name string) (tea.Model, tea.Cmd) {
+	item := m.tree.Current()
+	if item == nil {
+		return m, nil
+	}
+	m.marks = maps.Clone(m.marks)
+	if m.marks == nil {
+		m.marks = make(map[string]mark)
+	}
+	m.marks[name] = mark{BasePath: m.basePath, Expanded: m.tree.AncestorPaths(), Cursor: item.Path}
+	m.statusMsg = fmt.Sprintf("Mark %s set at %s", name, item.Path)
+	return m, nil
+}
+
+// jumpToMark returns to the position saved under name: in the tree shown
+// when the mark is in it, expanding the nodes above it, or rebasing the
+// tree on the mark's root, which back returns from
+func (m Model) jumpToMark(name string) (tea.Model, tea.Cmd) {
+	mk, ok := m.marks[name]
+	if !ok {
+		m.statusMsg = fmt.Sprintf("No mark %s", name)
+		return m, nil
+	}
+	if mk.BasePath == m.basePath && m.tree.root != nil {
+		cmd := m.tree.Restore(mk.Expanded, mk.Cursor)
+		if item := m.tree.Current(); item != nil {
+			m.details.SetItem(item)
+		}
+		return m, cmd
+	}
+	m.rootStack = append(m.rootStack, m.basePath)
+	model, cmd := m.navigateTo(mk.BasePath)
+	m = model.(Model)
+	m.restoring = &uiState{BasePath: mk.BasePath, Expanded: mk.Expanded, Cursor: mk.Cursor}
+	return m, cmd
+}
@@ -1,4 +1,4 @@
-package main
+package bfui
 
 import "github.com/bluefish-project/bluefish/rvfs"
 
@@ -9,17 +9,35 @@ type ResourceLoadedMsg struct {
 	Err      error
 }
 
+// ServiceSummarizedMsg is sent when the startup summary of the service is
+// gathered
+type ServiceSummarizedMsg struct {
+	Summary *rvfs.ServiceSummary
+	Err     error
+}
+
 // ActionsDiscoveredMsg is sent when action discovery completes
 type ActionsDiscoveredMsg struct {
 	Path    string
 	Actions []ActionInfo
+	// Targets maps each action to its target on every selected resource
+	// when discovering for a selection; nil for one resource
+	Targets map[string][]string
+	Refusal error // Why the role may not write to a resource acted on
 	Err     error
 }
 
+// RoleResolvedMsg is sent when the logged-in account's role is known
+type RoleResolvedMsg struct {
+	Role *rvfs.SessionRole
+	Err  error
+}
+
 // ActionResultMsg is sent when a POST action completes
 type ActionResultMsg struct {
 	StatusCode int
 	Body       string
+	Messages   []rvfs.Message
 	Err        error
 }
 
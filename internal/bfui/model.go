@@ -1,8 +1,9 @@
-package main
+package bfui
 
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/charmbracelet/x/ansi"
 
 	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/theme"
 )
 
 // Mode represents the current UI mode
@@ -25,6 +27,8 @@ const (
 	ModeHelp
 	ModeScrape
 	ModeExport
+	ModeSelect
+	ModeWorkspace
 )
 
 // Model is the root Bubble Tea model
@@ -40,35 +44,71 @@ type Model struct {
 	action     ActionModel
 	scrape     ScrapeModel
 	export     ExportModel
+	workspaces WorkspaceModel
 
 	width, height    int
 	mode             Mode
 	statusMsg        string
+	toast            string // Announcement of an operation that ended in the background
+	toastSeq         int    // Counts toasts, so only the latest is taken down
+	service          string // One-line summary of the service, shown at the full tree
 	loading          bool
 	currentFetchedAt time.Time
+	treePercent      int      // Share of the width the tree panel gets
+	restoring        *uiState // Saved state applied as the tree loads
+	fetches          fetchQueue
+	bookmarks        []string          // Resources bookmarked, in the order added
+	marks            map[string]mark   // Tree positions by letter or digit
+	markOp           markOp            // Mark key pressed, waiting for the mark's name
+	endpoint         string            // Service a workspace is saved for
+	user             string            // Who the connection logs in as
+	role             *rvfs.SessionRole // What the account may do, nil when unknown
+	powerWatch       *rvfs.PowerWatch  // PowerState changes of the system shown
+	posts            *rvfs.PostGuard   // Actions sent, to hold back one sent twice
+	prefetcher       *rvfs.Prefetcher  // Children of loaded resources warmed in the background
+	staleSystem      string            // System whose PowerState changed since it was cached
+	simulating       string            // Fault simulation in effect, "" for none
 }
 
 // NewModel creates a new root model
 func NewModel(vfs rvfs.VFS) Model {
 	return Model{
 		vfs:        vfs,
-		basePath:   rvfs.RedfishRoot,
+		basePath:   vfs.Root(),
 		tree:       NewTreeModel(),
 		details:    NewDetailsModel(),
-		breadcrumb: NewBreadcrumbModel(),
-		search:     NewSearchModel(),
+		breadcrumb: NewBreadcrumbModel(vfs.Root()),
+		search:     NewSearchModel(vfs),
 		action:     NewActionModel(),
 		scrape:     NewScrapeModel(vfs),
 		export:     NewExportModel(vfs),
+		workspaces: NewWorkspaceModel(),
+
+		treePercent: defaultTreePercent,
+		fetches:     newFetchQueue(vfs),
+		powerWatch:  &rvfs.PowerWatch{},
+		posts:       &rvfs.PostGuard{},
+		prefetcher:  &rvfs.Prefetcher{},
 	}
 }
 
 // Init implements tea.Model
 func (m Model) Init() tea.Cmd {
-	return func() tea.Msg {
-		resource, err := m.vfs.Get(m.basePath)
-		return ResourceLoadedMsg{Path: m.basePath, Resource: resource, Err: err}
-	}
+	return tea.Batch(
+		func() tea.Msg {
+			resource, err := m.vfs.Get(m.basePath)
+			return ResourceLoadedMsg{Path: m.basePath, Resource: resource, Err: err}
+		},
+		func() tea.Msg {
+			summary, err := rvfs.SummarizeService(m.vfs)
+			return ServiceSummarizedMsg{Summary: summary, Err: err}
+		},
+		func() tea.Msg {
+			role, err := rvfs.ResolveRole(m.vfs, m.user)
+			return RoleResolvedMsg{Role: role, Err: err}
+		},
+		powerTick(),
+	)
 }
 
 // Update implements tea.Model
@@ -83,18 +123,26 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ResourceLoadedMsg:
 		return m.handleResourceLoaded(msg)
 
-	case fetchResourceMsg:
-		path := msg.Path
-		return m, func() tea.Msg {
-			resource, err := m.vfs.Get(path)
-			return ResourceLoadedMsg{Path: path, Resource: resource, Err: err}
+	case ServiceSummarizedMsg:
+		// A failure shows when the tree fails to load the root
+		if msg.Err == nil {
+			m.service = formatServiceSummary(msg.Summary)
 		}
+		return m, nil
+
+	case RoleResolvedMsg:
+		// Writes are left unchecked when the role cannot be read
+		m.role = msg.Role
+		return m, nil
+
+	case fetchResourceMsg:
+		return m, m.fetches.Request(msg.Path)
 
 	case ActionsDiscoveredMsg:
 		return m.handleActionsDiscovered(msg)
 
 	case ActionResultMsg:
-		m.action.SetResult(msg.StatusCode, msg.Body, msg.Err)
+		m.action.SetResult(msg.StatusCode, msg.Body, msg.Messages, msg.Err)
 		return m, nil
 
 	case scrapeTickMsg:
@@ -103,7 +151,30 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case scrapeDoneMsg:
 		cmd := m.scrape.HandleDone(msg)
-		return m, cmd
+		if !m.scrape.IsDone() {
+			return m, cmd
+		}
+		background := m.mode != ModeScrape
+		summary := m.scrape.Summary()
+		if background && !m.scrape.Triaging() {
+			// Nothing left to look at; the toast says how it went
+			m.scrape.Close()
+		}
+		var announced tea.Cmd
+		m, announced = m.announce(summary, m.scrape.started, background)
+		return m, tea.Batch(cmd, announced)
+
+	case scrapeErrorsWrittenMsg:
+		m.scrape.HandleErrorsWritten(msg)
+		return m, nil
+
+	case pinnedLoadedMsg:
+		if msg.Err != nil {
+			m.statusMsg = fmt.Sprintf("Could not pin: %v", msg.Err)
+		} else {
+			m.details.Pin(msg.Resource)
+		}
+		return m, nil
 
 	case exportTickMsg:
 		cmd := m.export.HandleTick(msg.Path)
@@ -114,7 +185,30 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 
 	case exportWrittenMsg:
+		if !m.export.IsActive() {
+			// Closed while the file was written
+			return m, nil
+		}
 		m.export.HandleWritten(msg)
+		background := m.mode != ModeExport
+		if background {
+			m.export.Close()
+		}
+		var cmd tea.Cmd
+		m, cmd = m.announce(m.export.result, m.export.started, background)
+		return m, cmd
+
+	case powerTickMsg:
+		return m.handlePowerTick()
+
+	case powerChangedMsg:
+		m.staleSystem = msg.Change.System
+		return m.showToast(fmt.Sprintf("⚡ %s\n%s refreshes it", msg.Change, keyLabel(normalKeys.Refresh)))
+
+	case toastExpiredMsg:
+		if msg.Seq == m.toastSeq {
+			m.toast = ""
+		}
 		return m, nil
 
 	case tea.KeyMsg:
@@ -125,34 +219,71 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handleResourceLoaded(msg ResourceLoadedMsg) (tea.Model, tea.Cmd) {
+	next := m.fetches.Done(msg.Path)
+	model, cmd := m.integrateResource(msg)
+	return model, tea.Batch(cmd, next)
+}
+
+// integrateResource shows a loaded resource in the tree and details
+func (m Model) integrateResource(msg ResourceLoadedMsg) (tea.Model, tea.Cmd) {
 	if msg.Err != nil {
-		m.statusMsg = fmt.Sprintf("Error: %v", msg.Err)
+		if m.restoring != nil && msg.Path == m.basePath && m.tree.root == nil {
+			// Where the last run ended is gone; start over at the root
+			m.restoring = nil
+			m.rootStack = nil
+			model, cmd := m.navigateTo(m.vfs.Root())
+			m = model.(Model)
+			m.statusMsg = fmt.Sprintf("Could not restore %s: %v", msg.Path, msg.Err)
+			return m, cmd
+		}
+		m.tree.LoadFailed()
 		m.loading = false
+		var forbidden *rvfs.ForbiddenError
+		if errors.As(msg.Err, &forbidden) {
+			m.tree.SetDenied(msg.Path)
+			m.statusMsg = fmt.Sprintf("Permission denied: %s", msg.Path)
+			if item := m.tree.Current(); item != nil {
+				m.details.SetItem(item)
+			}
+			return m, nil
+		}
+		m.statusMsg = fmt.Sprintf("Error: %v", msg.Err)
 		return m, nil
 	}
 
 	if msg.Path == m.basePath && m.tree.root == nil {
 		// Initial load
 		m.tree.Init(msg.Resource, msg.Path)
+		m.tree.MarkDenied(m.vfs.Denied)
+		m.tree.MarkActions(m.vfs.Cached)
 		m.recalcLayout()
 		m.statusMsg = ""
 		m.loading = false
 		m.currentFetchedAt = msg.Resource.FetchedAt
+		m.prefetcher.Warm(m.vfs, msg.Resource)
 
+		var cmd tea.Cmd
+		if m.restoring != nil {
+			cmd = m.tree.Restore(m.restoring.Expanded, m.restoring.Cursor)
+			m.restoring = nil
+		}
 		item := m.tree.Current()
 		if item != nil {
 			m.details.SetItem(item)
 		}
-		return m, nil
+		return m, cmd
 	}
 
 	// Async child load
-	m.tree.HandleResourceLoaded(msg.Path, msg.Resource)
+	cmd := m.tree.HandleResourceLoaded(msg.Path, msg.Resource)
+	m.tree.MarkDenied(m.vfs.Denied)
+	m.tree.MarkActions(m.vfs.Cached)
 	m.loading = false
 
 	// Track age of the resource at cursor
 	if msg.Resource != nil {
 		m.currentFetchedAt = msg.Resource.FetchedAt
+		m.prefetcher.Warm(m.vfs, msg.Resource)
 	}
 
 	// Update details if cursor is on this item
@@ -160,7 +291,7 @@ func (m Model) handleResourceLoaded(msg ResourceLoadedMsg) (tea.Model, tea.Cmd)
 	if item != nil {
 		m.details.SetItem(item)
 	}
-	return m, nil
+	return m, cmd
 }
 
 func (m Model) handleActionsDiscovered(msg ActionsDiscoveredMsg) (tea.Model, tea.Cmd) {
@@ -172,8 +303,14 @@ func (m Model) handleActionsDiscovered(msg ActionsDiscoveredMsg) (tea.Model, tea
 		m.statusMsg = "No actions on current resource"
 		return m, nil
 	}
+	m.statusMsg = ""
 	m.mode = ModeAction
-	m.action.Open(msg.Actions)
+	m.recalcLayout()
+	if msg.Targets != nil {
+		m.action.OpenBulk(msg.Actions, msg.Targets, msg.Refusal)
+	} else {
+		m.action.Open(msg.Actions, msg.Refusal)
+	}
 	return m, nil
 }
 
@@ -191,11 +328,19 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleScrapeKey(msg)
 	case ModeExport:
 		return m.handleExportKey(msg)
+	case ModeSelect:
+		return m.handleSelectKey(msg)
+	case ModeWorkspace:
+		return m.handleWorkspaceKey(msg)
 	}
 	return m, nil
 }
 
 func (m Model) handleNormalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.markOp != markNone {
+		return m.handleMarkKey(msg)
+	}
+
 	switch {
 	case key.Matches(msg, normalKeys.Quit):
 		return m, tea.Quit
@@ -258,6 +403,36 @@ func (m Model) handleNormalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, normalKeys.ScrollUp):
 		m.details.ScrollUp()
 
+	case key.Matches(msg, normalKeys.Raw):
+		m.details.ToggleRaw()
+
+	case key.Matches(msg, normalKeys.NextSection):
+		m.details.NextSection()
+
+	case key.Matches(msg, normalKeys.PrevSection):
+		m.details.PrevSection()
+
+	case key.Matches(msg, normalKeys.Fold):
+		m.details.ToggleFold()
+
+	case key.Matches(msg, normalKeys.FoldAll):
+		m.details.ToggleFoldAll()
+
+	case key.Matches(msg, normalKeys.Pin):
+		return m.handlePin()
+
+	case key.Matches(msg, normalKeys.SideBySide):
+		m.details.ToggleSideBySide()
+
+	case key.Matches(msg, normalKeys.Narrow):
+		m.resizeTree(-treePercentStep)
+
+	case key.Matches(msg, normalKeys.Widen):
+		m.resizeTree(treePercentStep)
+
+	case key.Matches(msg, normalKeys.Select):
+		m.mode = ModeSelect
+
 	case key.Matches(msg, normalKeys.Search):
 		m.mode = ModeSearch
 		m.recalcLayout()
@@ -267,6 +442,25 @@ func (m Model) handleNormalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, normalKeys.Action):
 		return m.handleActionMode()
 
+	case key.Matches(msg, normalKeys.Bookmark):
+		return m.handleBookmark()
+
+	case key.Matches(msg, normalKeys.NextBookmark):
+		return m.handleNextBookmark()
+
+	case key.Matches(msg, normalKeys.SetMark):
+		m.markOp = markSet
+		m.statusMsg = "Set mark: press a letter or digit"
+
+	case key.Matches(msg, normalKeys.JumpMark):
+		m.markOp = markJump
+		m.statusMsg = "Jump to mark: press a letter or digit"
+
+	case key.Matches(msg, normalKeys.Workspace):
+		m.mode = ModeWorkspace
+		m.recalcLayout()
+		m.workspaces.Open()
+
 	case key.Matches(msg, normalKeys.Help):
 		m.mode = ModeHelp
 		m.recalcLayout()
@@ -376,6 +570,37 @@ func (m Model) handleScrapeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.mode = ModeNormal
 		m.scrape.Close()
 		m.recalcLayout()
+		return m, nil
+	}
+	if key.Matches(msg, overlayKeys.Background) && !m.scrape.IsDone() {
+		m.mode = ModeNormal
+		m.recalcLayout()
+		m.statusMsg = "Scrape continues in the background; " + shortKey(normalKeys.Scrape) + " shows it"
+		return m, nil
+	}
+	if !m.scrape.Triaging() {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(msg, scrapeKeys.Up):
+		m.scrape.MoveCursor(-1)
+	case key.Matches(msg, scrapeKeys.Down):
+		m.scrape.MoveCursor(1)
+	case key.Matches(msg, scrapeKeys.Mark):
+		m.scrape.ToggleMark()
+	case key.Matches(msg, scrapeKeys.Retry):
+		return m, m.scrape.Retry()
+	case key.Matches(msg, scrapeKeys.Parent):
+		parent := m.vfs.Parent(m.scrape.Selected())
+		m.mode = ModeNormal
+		m.scrape.Close()
+		m.recalcLayout()
+		m.rootStack = append(m.rootStack, m.basePath)
+		return m.navigateTo(parent)
+	case key.Matches(msg, scrapeKeys.Export):
+		filename := "scrape_errors_" + time.Now().Format("20060102T150405") + ".json"
+		return m, m.scrape.ExportFailures(filename)
 	}
 	return m, nil
 }
@@ -386,14 +611,23 @@ func (m Model) handleExportKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.export.Close()
 		m.recalcLayout()
 	}
+	if key.Matches(msg, overlayKeys.Background) && !m.export.IsDone() {
+		m.mode = ModeNormal
+		m.recalcLayout()
+		m.statusMsg = "Export continues in the background; " + shortKey(normalKeys.Export) + " shows it"
+	}
 	return m, nil
 }
 
 func (m Model) handleExport() (tea.Model, tea.Cmd) {
 	m.mode = ModeExport
 	m.recalcLayout()
+	if m.export.IsActive() {
+		// Back to the export running in the background
+		return m, nil
+	}
 	filename := "export_" + time.Now().Format("20060102T150405") + ".json"
-	cmd := m.export.Start(m.basePath, filename)
+	cmd := m.export.Start([]string{m.basePath}, filename)
 	return m, cmd
 }
 
@@ -404,6 +638,22 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// Log entries, event records and conditions jump to the resource they
+	// are about. Children rebase first; Enter on the rebased entry jumps.
+	var target *rvfs.Target
+	switch item.Kind {
+	case KindResource:
+		target = &rvfs.Target{Type: rvfs.TargetResource, Resource: item.Resource}
+	case KindObject:
+		target = &rvfs.Target{Type: rvfs.TargetProperty, Property: item.Property}
+	}
+	if target != nil {
+		if origin := rvfs.OriginOfCondition(target); origin != "" {
+			m.rootStack = append(m.rootStack, m.basePath)
+			return m.navigateTo(origin)
+		}
+	}
+
 	switch item.Kind {
 	case KindLink:
 		m.rootStack = append(m.rootStack, m.basePath)
@@ -440,12 +690,12 @@ func (m Model) handleGoUp() (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handleHome() (tea.Model, tea.Cmd) {
-	if m.basePath == rvfs.RedfishRoot {
+	if m.basePath == m.vfs.Root() {
 		m.statusMsg = "Already at root"
 		return m, nil
 	}
 	m.rootStack = nil
-	return m.navigateTo(rvfs.RedfishRoot)
+	return m.navigateTo(m.vfs.Root())
 }
 
 func (m Model) handleRefresh() (tea.Model, tea.Cmd) {
@@ -454,12 +704,16 @@ func (m Model) handleRefresh() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	// Only resource-backed items (Child, Resource, Link) can be refreshed
+	// A system whose PowerState changed is refreshed first; otherwise only
+	// resource-backed items (Child, Resource, Link) can be refreshed
 	path := item.Path
-	switch item.Kind {
-	case KindChild, KindResource:
+	switch {
+	case m.staleSystem != "":
+		path = m.staleSystem
+		m.staleSystem = ""
+	case item.Kind == KindChild, item.Kind == KindResource:
 		// Refresh this resource
-	case KindLink:
+	case item.Kind == KindLink:
 		path = item.LinkTarget
 	default:
 		m.statusMsg = "Nothing to refresh (select a resource)"
@@ -477,6 +731,10 @@ func (m Model) handleRefresh() (tea.Model, tea.Cmd) {
 func (m Model) handleScrape() (tea.Model, tea.Cmd) {
 	m.mode = ModeScrape
 	m.recalcLayout()
+	if m.scrape.IsActive() {
+		// Back to the scrape running in the background, or its failures
+		return m, nil
+	}
 	cmd := m.scrape.Start(m.basePath)
 	return m, cmd
 }
@@ -506,7 +764,7 @@ func (m Model) handleActionMode() (tea.Model, tea.Cmd) {
 	}
 	m.mode = ModeAction
 	m.recalcLayout()
-	m.action.Open(actions)
+	m.action.Open(actions, m.role.Refusal(resource))
 	return m, nil
 }
 
@@ -514,27 +772,44 @@ func (m Model) executeAction() (tea.Model, tea.Cmd) {
 	action := m.action.selected
 	body, err := m.action.BuildBody()
 	if err != nil {
-		m.action.SetResult(0, "", err)
+		m.action.SetResult(0, "", nil, err)
 		return m, nil
 	}
 
+	// The same action sent within rvfs.RepeatWindow takes a second y
+	targets := m.action.Targets()
+	again := m.action.repeat != nil
+	if !again {
+		if m.action.repeat = m.posts.CheckAll(targets, body); m.action.repeat != nil {
+			return m, nil
+		}
+	}
+	m.action.repeat = nil
+	if m.action.bulk != nil {
+		return m, m.postSelected(targets, body, again)
+	}
+
 	target := action.Target
 	return m, func() tea.Msg {
-		data, status, err := m.vfs.Post(target, body)
+		resp, err := m.posts.Post(m.vfs, target, body, again)
+		if err != nil {
+			return ActionResultMsg{Err: err}
+		}
 		var bodyStr string
-		if len(data) > 0 {
+		if len(resp.Body) > 0 {
 			var buf bytes.Buffer
-			if json.Indent(&buf, data, "", "  ") == nil {
+			if json.Indent(&buf, resp.Body, "", "  ") == nil {
 				bodyStr = buf.String()
 			} else {
-				bodyStr = string(data)
+				bodyStr = string(resp.Body)
 			}
 		}
-		return ActionResultMsg{StatusCode: status, Body: bodyStr, Err: err}
+		return ActionResultMsg{StatusCode: resp.StatusCode, Body: bodyStr, Messages: resp.Messages}
 	}
 }
 
 func (m Model) navigateTo(path string) (tea.Model, tea.Cmd) {
+	m.fetches.DropWaiting()
 	m.basePath = path
 	m.breadcrumb.SetPath(path)
 	m.tree = NewTreeModel()
@@ -548,6 +823,19 @@ func (m Model) navigateTo(path string) (tea.Model, tea.Cmd) {
 	}
 }
 
+// Bounds and step of the tree panel's share of the width
+const (
+	minTreePercent  = 20
+	maxTreePercent  = 80
+	treePercentStep = 5
+)
+
+// resizeTree moves the split between the tree and details panels
+func (m *Model) resizeTree(delta int) {
+	m.treePercent = min(max(m.treePercent+delta, minTreePercent), maxTreePercent)
+	m.recalcLayout()
+}
+
 func (m *Model) recalcLayout() {
 	// Measure chrome heights from actual renders
 	statusHeight := lipgloss.Height(m.viewStatusBar())
@@ -563,10 +851,10 @@ func (m *Model) recalcLayout() {
 
 	// Measure separator
 	sep := separatorStyle.Render(" │ ")
-	sepWidth := lipgloss.Width(sep)
+	sepWidth := theme.Width(sep)
 
-	// Tree gets 40%, details gets the rest minus separator
-	treeWidth := m.width * 2 / 5
+	// Tree gets its share, details gets the rest minus separator
+	treeWidth := m.width * m.treePercent / 100
 	detailsWidth := m.width - treeWidth - sepWidth
 
 	m.tree.width = treeWidth
@@ -595,6 +883,8 @@ func (m *Model) recalcLayout() {
 		m.scrape.height = innerH
 		m.export.width = innerW
 		m.export.height = innerH
+		m.workspaces.width = innerW
+		m.workspaces.height = innerH
 	}
 }
 
@@ -614,7 +904,7 @@ func (m Model) View() string {
 
 	// Main content: tree | separator | details — always rendered at full height
 	sep := separatorStyle.Render(" │ ")
-	sepWidth := lipgloss.Width(sep)
+	sepWidth := theme.Width(sep)
 	treeWidth := m.width * 2 / 5
 	detailsWidth := m.width - treeWidth - sepWidth
 
@@ -636,6 +926,9 @@ func (m Model) View() string {
 	if overlay, ok := m.renderOverlay(); ok {
 		content = placeOverlay(m.width, m.tree.height, overlay, content)
 	}
+	if m.toast != "" {
+		content = placeToast(m.width, m.toast, content)
+	}
 
 	sections = append(sections, content)
 
@@ -668,6 +961,9 @@ func (m Model) renderOverlay() (string, bool) {
 	case ModeExport:
 		inner = m.export.View()
 		w, h = m.export.width, m.export.height
+	case ModeWorkspace:
+		inner = m.workspaces.View()
+		w, h = m.workspaces.width, m.workspaces.height
 	default:
 		return "", false
 	}
@@ -687,7 +983,7 @@ func placeOverlay(bgWidth, bgHeight int, overlay, background string) string {
 	bgLines := strings.Split(background, "\n")
 	fgLines := strings.Split(overlay, "\n")
 
-	fgWidth := lipgloss.Width(overlay)
+	fgWidth := theme.Width(overlay)
 	fgHeight := len(fgLines)
 
 	// Center
@@ -707,7 +1003,7 @@ func placeOverlay(bgWidth, bgHeight int, overlay, background string) string {
 		}
 		// Split background line at visual column boundaries,
 		// keeping styled content on both sides of the overlay
-		left := ansi.Truncate(bgLines[row], startX, "")
+		left := theme.Truncate(bgLines[row], startX, "")
 		right := ansi.TruncateLeft(bgLines[row], startX+fgWidth, "")
 		bgLines[row] = left + fgLine + right
 	}
@@ -721,18 +1017,51 @@ func (m Model) viewStatusBar() string {
 	var info string
 	if m.statusMsg != "" {
 		info = "  " + m.statusMsg
-	} else if m.basePath == rvfs.RedfishRoot {
+	} else if m.basePath == m.vfs.Root() && m.service != "" {
+		info = "  " + m.service
+		if m.role != nil {
+			info += " · role " + m.role.RoleID
+		}
+	} else if m.basePath == m.vfs.Root() {
 		info = "  Tree: Full"
 	} else {
 		info = fmt.Sprintf("  Subtree: %s", m.basePath)
 	}
 
+	// Conditions reported for the resource the tree is rooted at
+	var conditions string
+	if m.tree.root != nil && m.tree.root.Item.Resource != nil {
+		if s := formatConditionsSummary(m.tree.root.Item.Resource); s != "" {
+			conditions = "  " + s
+		}
+	}
+
+	var selected string
+	if n := len(m.tree.marked); n > 0 {
+		selected = "  " + actionNameStyle.Render(fmt.Sprintf("%d selected", n))
+	}
+
+	var pinned string
+	if r := m.details.Pinned(); r != nil {
+		pinned = "  " + diffOldStyle.Render("pinned "+rvfs.BaseName(r.Path))
+	}
+
 	var age string
 	if !m.currentFetchedAt.IsZero() {
 		age = "  " + helpDescStyle.Render(formatAge(m.currentFetchedAt))
 	}
 
-	return title + info + age
+	var pending string
+	if n := m.fetches.Pending(); n > 0 {
+		pending = "  " + helpDescStyle.Render(fmt.Sprintf("⟳ %d pending", n))
+	}
+
+	var simulating string
+	if m.simulating != "" {
+		simulating = "  " + diffOldStyle.Render("simulating "+m.simulating)
+	}
+
+	return title + info + conditions + selected + pinned + age + pending + simulating + m.viewBackground()
 }
 
 func formatAge(t time.Time) string {
@@ -754,28 +1083,66 @@ func (m Model) viewHelpBar() string {
 	switch m.mode {
 	case ModeNormal:
 		pairs = []string{
-			"enter", "open",
-			"h/j/k/l", "nav",
-			"bs", "back",
-			"/", "search",
-			"!", "action",
-			"s", "scrape",
-			"x", "export",
-			"?", "help",
+			shortKey(normalKeys.Enter), "open",
+			shortKey(normalKeys.Collapse) + "/" + shortKey(normalKeys.Down) + "/" +
+				shortKey(normalKeys.Up) + "/" + shortKey(normalKeys.Expand), "nav",
+			shortKey(normalKeys.Back), "back",
+			shortKey(normalKeys.Search), "search",
+			shortKey(normalKeys.Action), "action",
+			shortKey(normalKeys.Scrape), "scrape",
+			shortKey(normalKeys.Export), "export",
+			shortKey(normalKeys.Select), "select",
+			shortKey(normalKeys.Help), "help",
+		}
+	case ModeSelect:
+		pairs = []string{
+			shortKey(selectKeys.Mark), "mark",
+			shortKey(selectKeys.Export), "export",
+			shortKey(selectKeys.Refresh), "refresh",
+			shortKey(selectKeys.Copy), "copy paths",
+			shortKey(selectKeys.Action), "action",
+			shortKey(selectKeys.Clear), "clear",
+			shortKey(selectKeys.Cancel), "done",
 		}
 	case ModeSearch:
 		pairs = []string{
-			"enter", "go",
-			"esc", "cancel",
-			"ctrl+j/k", "nav",
+			shortKey(searchKeys.Confirm), "go",
+			shortKey(searchKeys.Cancel), "cancel",
+			shortKey(searchKeys.NextItem) + "/" + shortKey(searchKeys.PrevItem), "nav",
+		}
+	case ModeWorkspace:
+		pairs = []string{
+			shortKey(workspaceKeys.Open), "open",
+			shortKey(workspaceKeys.Save), "save",
+			shortKey(workspaceKeys.Cancel), "cancel",
+			shortKey(workspaceKeys.NextItem) + "/" + shortKey(workspaceKeys.PrevItem), "nav",
 		}
 	case ModeAction:
 		pairs = []string{
-			"esc", "back",
+			shortKey(actionKeys.Cancel), "back",
+		}
+	case ModeScrape:
+		if m.scrape.Triaging() {
+			pairs = []string{
+				shortKey(scrapeKeys.Down) + "/" + shortKey(scrapeKeys.Up), "select",
+				shortKey(scrapeKeys.Mark), "mark",
+				shortKey(scrapeKeys.Retry), "retry",
+				shortKey(scrapeKeys.Parent), "open parent",
+				shortKey(scrapeKeys.Export), "export list",
+			}
 		}
-	case ModeHelp, ModeScrape, ModeExport:
+		if !m.scrape.IsDone() {
+			pairs = append(pairs, shortKey(overlayKeys.Background), "background")
+		}
+		pairs = append(pairs, shortKey(overlayKeys.Cancel), "close")
+	case ModeExport:
+		if !m.export.IsDone() {
+			pairs = append(pairs, shortKey(overlayKeys.Background), "background")
+		}
+		pairs = append(pairs, shortKey(overlayKeys.Cancel), "close")
+	case ModeHelp:
 		pairs = []string{
-			"esc", "close",
+			shortKey(overlayKeys.Cancel), "close",
 		}
 	}
 
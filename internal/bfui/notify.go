@@ -0,0 +1,134 @@
+package bfui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/termenv"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/theme"
+)
+
+// notifyAfter is how long a scrape or export runs in its overlay before
+// its end is announced with the terminal bell and a desktop notification.
+// One sent to the background is announced however long it took.
+const notifyAfter = 10 * time.Second
+
+// toastDuration is how long a toast stays up
+const toastDuration = 6 * time.Second
+
+// toastExpiredMsg takes down the toast with the given sequence number,
+// unless a newer one replaced it
+type toastExpiredMsg struct {
+	Seq int
+}
+
+// announce reports the end of a long operation. Finished in the background,
+// it gets a toast; the terminal rings and shows a desktop notification
+// (OSC 777, for terminals that support it) when it ran in the background
+// or took longer than notifyAfter.
+func (m Model) announce(summary string, started time.Time, background bool) (Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	if background {
+		var cmd tea.Cmd
+		m, cmd = m.showToast(summary)
+		cmds = append(cmds, cmd)
+	}
+	if background || time.Since(started) >= notifyAfter {
+		cmds = append(cmds, func() tea.Msg {
+			fmt.Fprint(os.Stdout, "\a")
+			termenv.Notify("bfui", summary)
+			return nil
+		})
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// showToast puts up a toast, taken down after toastDuration
+func (m Model) showToast(text string) (Model, tea.Cmd) {
+	m.toast = text
+	m.toastSeq++
+	seq := m.toastSeq
+	return m, tea.Tick(toastDuration, func(time.Time) tea.Msg {
+		return toastExpiredMsg{Seq: seq}
+	})
+}
+
+// powerTickMsg polls the PowerState of the system shown
+type powerTickMsg struct{}
+
+// powerChangedMsg is sent when the PowerState of the system shown changed
+// from the cached one
+type powerChangedMsg struct {
+	Change *rvfs.PowerChange
+}
+
+// powerTick schedules the next poll of the PowerState
+func powerTick() tea.Cmd {
+	return tea.Tick(rvfs.PowerWatchInterval, func(time.Time) tea.Msg {
+		return powerTickMsg{}
+	})
+}
+
+// handlePowerTick polls the PowerState of the system the cursor is in, or
+// the subtree shown, so a node power-cycled from elsewhere is noticed
+// before decisions are made on the cached state
+func (m Model) handlePowerTick() (tea.Model, tea.Cmd) {
+	if m.mode != ModeNormal {
+		return m, powerTick()
+	}
+	path := m.basePath
+	if item := m.tree.Current(); item != nil && item.Path != "" {
+		path = item.Path
+	}
+	vfs, watch := m.vfs, m.powerWatch
+	return m, tea.Batch(powerTick(), func() tea.Msg {
+		system := rvfs.HostSystem(vfs, path)
+		if system == "" {
+			return nil
+		}
+		change, err := watch.Check(vfs, system)
+		if err != nil || change == nil {
+			return nil
+		}
+		return powerChangedMsg{Change: change}
+	})
+}
+
+// viewBackground names the operations running in the background, with
+// their progress, for the status bar
+func (m Model) viewBackground() string {
+	var running []string
+	if m.scrape.IsActive() && m.mode != ModeScrape {
+		if m.scrape.IsDone() {
+			running = append(running, "scrape done")
+		} else {
+			running = append(running, fmt.Sprintf("scrape %d/%d", m.scrape.done, m.scrape.total))
+		}
+	}
+	if m.export.IsActive() && m.mode != ModeExport && !m.export.IsDone() {
+		running = append(running, fmt.Sprintf("export %d/%d", m.export.done, m.export.total))
+	}
+	if len(running) == 0 {
+		return ""
+	}
+	return "  " + actionNameStyle.Render(strings.Join(running, " · "))
+}
+
+// placeToast draws the toast in the top right corner of the content
+func placeToast(width int, toast, content string) string {
+	box := toastStyle.Render(toast)
+	lines := strings.Split(content, "\n")
+	startX := max(0, width-theme.Width(box))
+	for i, line := range strings.Split(box, "\n") {
+		if i >= len(lines) {
+			break
+		}
+		lines[i] = theme.Truncate(lines[i], startX, "") + line
+	}
+	return strings.Join(lines, "\n")
+}
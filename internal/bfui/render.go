@@ -0,0 +1,160 @@
+package bfui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+)
+
+// healthKeys are property names that get semantic coloring
+var healthKeys = map[string]bool{
+	"Health":       true,
+	"HealthRollup": true,
+	"State":        true,
+	"Status":       true,
+}
+
+// formatValue renders a Go value with color coding
+func formatValue(v any) string {
+	if v == nil {
+		return nullStyle.Render("null")
+	}
+	switch val := v.(type) {
+	case string:
+		return stringStyle.Render(fmt.Sprintf("%q", val))
+	case bool:
+		if val {
+			return trueStyle.Render("true")
+		}
+		return falseStyle.Render("false")
+	case float64:
+		if val == float64(int64(val)) {
+			return numberStyle.Render(fmt.Sprintf("%d", int64(val)))
+		}
+		return numberStyle.Render(fmt.Sprintf("%g", val))
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// formatHealthValue renders health/state values with semantic colors
+func formatHealthValue(name string, v any) string {
+	if !healthKeys[name] {
+		return formatValue(v)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return formatValue(v)
+	}
+	upper := strings.ToUpper(s)
+	switch {
+	case upper == "OK" || upper == "ENABLED" || upper == "UP":
+		return healthOKStyle.Render(s)
+	case upper == "WARNING" || upper == "STANDBYOFFLINE" || upper == "STARTING":
+		return healthWarningStyle.Render(s)
+	case upper == "CRITICAL" || upper == "DISABLED" || upper == "ABSENT":
+		return healthCriticalStyle.Render(s)
+	default:
+		return formatValue(v)
+	}
+}
+
+// formatMessage renders a Redfish message colored by severity
+func formatMessage(m rvfs.Message, indent int) string {
+	pad := strings.Repeat(" ", indent)
+	s := pad + severityStyle(m.Severity).Render(m.String()) + "\n"
+	if m.Resolution != "" {
+		s += pad + "  " + helpDescStyle.Render("Resolution: "+m.Resolution) + "\n"
+	}
+	return s
+}
+
+// severityStyle returns the style of a message or condition severity
+func severityStyle(severity string) lipgloss.Style {
+	switch strings.ToUpper(severity) {
+	case "OK":
+		return healthOKStyle
+	case "WARNING":
+		return healthWarningStyle
+	case "CRITICAL":
+		return healthCriticalStyle
+	}
+	return detailValueStyle
+}
+
+// formatCondition renders a Status condition like a message, followed by
+// when it was raised, the resource it originates from and its log entry
+func formatCondition(c rvfs.Condition, indent int) string {
+	pad := strings.Repeat(" ", indent) + "  "
+	s := formatMessage(c.Message, indent)
+	if c.Timestamp != "" {
+		s += pad + helpDescStyle.Render("Raised: ") + detailValueStyle.Render(c.Timestamp) + "\n"
+	}
+	if c.OriginOfCondition != "" {
+		s += pad + helpDescStyle.Render("Origin: ") + linkStyle.Render(c.OriginOfCondition) + "\n"
+	}
+	if c.LogEntry != "" {
+		s += pad + helpDescStyle.Render("Log entry: ") + linkStyle.Render(c.LogEntry) + "\n"
+	}
+	return s
+}
+
+// formatConditionsSummary counts a resource's conditions, colored by the
+// most severe one
+func formatConditionsSummary(r *rvfs.Resource) string {
+	switch len(r.Conditions) {
+	case 0:
+		return ""
+	case 1:
+		return severityStyle(r.ConditionSeverity()).Render("1 condition")
+	default:
+		return severityStyle(r.ConditionSeverity()).Render(fmt.Sprintf("%d conditions", len(r.Conditions)))
+	}
+}
+
+// formatPlainValue renders a value without ANSI codes (for measuring widths)
+func formatPlainValue(v any) string {
+	if v == nil {
+		return "null"
+	}
+	switch val := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", val)
+	case bool:
+		return fmt.Sprintf("%v", val)
+	case float64:
+		if val == float64(int64(val)) {
+			return fmt.Sprintf("%d", int64(val))
+		}
+		return fmt.Sprintf("%g", val)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// formatServiceSummary renders what the service is, how much it manages and
+// its overall health on one line
+func formatServiceSummary(s *rvfs.ServiceSummary) string {
+	var parts []string
+	if name := strings.TrimSpace(s.Vendor + " " + s.Model); name != "" {
+		parts = append(parts, name)
+	}
+	if s.RedfishVersion != "" {
+		parts = append(parts, "Redfish "+s.RedfishVersion)
+	}
+	for _, c := range []struct {
+		name  string
+		count int
+	}{{"Systems", s.Systems}, {"Chassis", s.Chassis}, {"Managers", s.Managers}} {
+		if c.count >= 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", c.name, c.count))
+		}
+	}
+	if s.Health != "" {
+		parts = append(parts, "Health "+formatHealthValue("Health", s.Health))
+	}
+	return strings.Join(parts, "  ")
+}
@@ -0,0 +1,74 @@
+// Package bfui is the Redfish tree browser.
+package bfui
+
+import (
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bluefish-project/bluefish/internal/config"
+	"github.com/bluefish-project/bluefish/theme"
+)
+
+// Run connects with cfg and runs the browser until the user quits
+func Run(cfg *config.Config) (err error) {
+	t, err := theme.Load(cfg.Theme)
+	if err != nil {
+		return fmt.Errorf("theme config: %w", err)
+	}
+	applyTheme(t)
+	if err := applyKeymap(cfg.Keymap); err != nil {
+		return fmt.Errorf("keymap config: %w", err)
+	}
+
+	vfs, closeVFS, err := cfg.Connect()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := closeVFS(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	// Losing the saved state only costs the user their place. A recorded
+	// or replayed session starts afresh, so both start alike.
+	var states *stateFile
+	if cfg.Session == nil {
+		var stateErr error
+		if states, stateErr = loadStateFile(os.ExpandEnv("$HOME/.bfui_state.json")); stateErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", stateErr)
+		}
+	}
+
+	m := NewModel(vfs)
+	m.endpoint = cfg.Endpoint
+	m.user = cfg.User
+	m.scrape.policy = cfg.Scrape
+	m.export.redaction = cfg.Redact
+	m.simulating = cfg.Simulate.String()
+	if states != nil {
+		if state := states.Get(cfg.Endpoint); state != nil {
+			m = m.restore(state)
+		}
+	}
+	opts := []tea.ProgramOption{tea.WithAltScreen()}
+	if cfg.Session != nil {
+		opts = append(opts, tea.WithFilter(cfg.Session.Filter))
+	}
+	p := tea.NewProgram(m, opts...)
+	if cfg.Session != nil {
+		cfg.Session.Start(p)
+	}
+	final, err := p.Run()
+	if err != nil {
+		return err
+	}
+	if states != nil {
+		if err := states.Put(cfg.Endpoint, final.(Model).state()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: saving UI state: %v\n", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,472 @@
+package bfui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+)
+
+// ScrapeModel manages the resource crawl overlay. Once the crawl ends, its
+// failures can be triaged: retried, opened or exported.
+type ScrapeModel struct {
+	vfs       rvfs.VFS
+	queue     []string          // Paths still to fetch
+	done      int               // Count of fetched paths
+	total     int               // Total discovered paths
+	current   string            // Path currently being fetched
+	policy    rvfs.ScrapePolicy // How fetches are retried and which paths are skipped
+	failures  []scrapeFailure
+	denied    int             // Paths refused to this role, not retried
+	skipped   map[string]bool // Paths the policy keeps the crawl away from
+	recovered int             // Fetches that succeeded on a retry
+	cursor    int             // Selected failure
+	marked    map[string]bool // Failures marked for retry
+	result    string          // Outcome of the last export
+	started   time.Time
+	active    bool
+	width     int
+	height    int
+}
+
+// scrapeFailure is a path the scrape could not fetch
+type scrapeFailure struct {
+	Path   string
+	Status int // HTTP status; 0 when no response was received
+	Err    error
+}
+
+func NewScrapeModel(vfs rvfs.VFS) ScrapeModel {
+	return ScrapeModel{vfs: vfs}
+}
+
+// Start begins a scrape from a root path, queueing all uncached children
+func (s *ScrapeModel) Start(rootPath string) tea.Cmd {
+	s.active = true
+	s.queue = nil
+	s.done = 0
+	s.total = 0
+	s.current = ""
+	s.failures = nil
+	s.denied = 0
+	s.skipped = make(map[string]bool)
+	s.recovered = 0
+	s.cursor = 0
+	s.marked = make(map[string]bool)
+	s.result = ""
+	s.started = time.Now()
+
+	// Seed: collect all known children recursively from cached resources,
+	// find the ones that aren't cached yet
+	cached := make(map[string]bool)
+	for _, p := range s.vfs.GetKnownPaths() {
+		cached[p] = true
+	}
+
+	// BFS from rootPath to discover all reachable children
+	visited := make(map[string]bool)
+	frontier := []string{rootPath}
+	var uncached []string
+
+	for len(frontier) > 0 {
+		path := frontier[0]
+		frontier = frontier[1:]
+		if visited[path] {
+			continue
+		}
+		visited[path] = true
+
+		if s.policy.Skipped(path) {
+			s.skipped[path] = true
+			continue
+		}
+		if s.vfs.Denied(path) {
+			// Refused before; asking again would be refused again
+			s.denied++
+			continue
+		}
+		if !cached[path] {
+			uncached = append(uncached, path)
+			continue // Can't inspect children of uncached resources yet
+		}
+
+		// Inspect cached resource for children
+		res, err := s.vfs.Get(path)
+		if err != nil {
+			continue
+		}
+		for _, child := range res.Children {
+			if !visited[child.Target] {
+				frontier = append(frontier, child.Target)
+			}
+		}
+	}
+
+	s.queue = uncached
+	s.total = len(uncached)
+
+	if s.total == 0 {
+		s.current = "Everything is cached"
+		return nil
+	}
+
+	return s.fetchNext()
+}
+
+// fetchNext returns a Cmd that fetches the next item in the queue
+func (s *ScrapeModel) fetchNext() tea.Cmd {
+	if len(s.queue) == 0 {
+		return nil
+	}
+	path := s.queue[0]
+	s.current = path
+	return func() tea.Msg {
+		return scrapeTickMsg{Path: path}
+	}
+}
+
+// scrapeTickMsg triggers a single resource fetch during scraping
+type scrapeTickMsg struct {
+	Path string
+}
+
+// scrapeDoneMsg is sent after one resource is fetched (or fails)
+type scrapeDoneMsg struct {
+	Path        string
+	Resource    *rvfs.Resource
+	Err         error
+	Retries     int      // Fetches repeated before this result
+	NewChildren []string // Newly discovered child paths
+}
+
+// HandleTick fetches one resource and returns the result
+func (s *ScrapeModel) HandleTick(path string) tea.Cmd {
+	vfs, policy := s.vfs, s.policy
+	return func() tea.Msg {
+		res, retries, err := policy.Fetch(vfs, path)
+		var newChildren []string
+		if err == nil {
+			// Discover children we haven't seen
+			for _, child := range res.Children {
+				newChildren = append(newChildren, child.Target)
+			}
+		}
+		return scrapeDoneMsg{Path: path, Resource: res, Err: err, Retries: retries, NewChildren: newChildren}
+	}
+}
+
+// viewDenied counts the resources refused to the logged-in role, skipped
+// by the policy and fetched only on a retry
+func (s *ScrapeModel) viewDenied() string {
+	var b strings.Builder
+	if s.denied > 0 {
+		b.WriteString(fmt.Sprintf("  %s %d\n", deniedStyle.Render("Permission denied:"), s.denied))
+	}
+	if len(s.skipped) > 0 {
+		b.WriteString(fmt.Sprintf("  %s %d\n", helpDescStyle.Render("Skipped:"), len(s.skipped)))
+	}
+	if s.recovered > 0 {
+		b.WriteString(fmt.Sprintf("  %s %d\n", helpDescStyle.Render("Recovered on retry:"), s.recovered))
+	}
+	return b.String()
+}
+
+// categories tallies the failures by category
+func (s *ScrapeModel) categories() rvfs.ErrorTally {
+	tally := rvfs.ErrorTally{}
+	for _, f := range s.failures {
+		tally.Add(f.Err)
+	}
+	return tally
+}
+
+// HandleDone processes the result of a single fetch and queues more work
+func (s *ScrapeModel) HandleDone(msg scrapeDoneMsg) tea.Cmd {
+	// Remove from front of queue
+	if len(s.queue) > 0 && s.queue[0] == msg.Path {
+		s.queue = s.queue[1:]
+	}
+	s.done++
+
+	var forbidden *rvfs.ForbiddenError
+	if errors.As(msg.Err, &forbidden) {
+		// Not a failure to retry: the role may not read it
+		s.denied++
+	} else if msg.Err != nil {
+		f := scrapeFailure{Path: msg.Path, Err: msg.Err}
+		var httpErr *rvfs.HTTPError
+		if errors.As(msg.Err, &httpErr) {
+			f.Status = httpErr.StatusCode
+		}
+		s.failures = append(s.failures, f)
+	} else {
+		if msg.Retries > 0 {
+			s.recovered++
+		}
+		// Add newly discovered uncached children to queue
+		queued := make(map[string]bool)
+		for _, p := range s.queue {
+			queued[p] = true
+		}
+		cached := make(map[string]bool)
+		for _, p := range s.vfs.GetKnownPaths() {
+			cached[p] = true
+		}
+		for _, child := range msg.NewChildren {
+			if s.policy.Skipped(child) {
+				s.skipped[child] = true
+				continue
+			}
+			if !cached[child] && !queued[child] {
+				s.queue = append(s.queue, child)
+				s.total++
+			}
+		}
+	}
+
+	return s.fetchNext()
+}
+
+// Summary tells how a finished scrape went, for its announcement
+func (s *ScrapeModel) Summary() string {
+	summary := fmt.Sprintf("Scrape of %d resources finished in %s", s.done, time.Since(s.started).Round(time.Second))
+	if len(s.failures) > 0 {
+		summary += fmt.Sprintf(", %d failed (%s to triage)", len(s.failures), shortKey(normalKeys.Scrape))
+	}
+	return summary
+}
+
+func (s *ScrapeModel) IsActive() bool {
+	return s.active
+}
+
+func (s *ScrapeModel) IsDone() bool {
+	return s.active && len(s.queue) == 0
+}
+
+func (s *ScrapeModel) Close() {
+	s.active = false
+	s.queue = nil
+}
+
+// Triaging returns true once the crawl has ended with failures to act on
+func (s *ScrapeModel) Triaging() bool {
+	return s.IsDone() && len(s.failures) > 0
+}
+
+// MoveCursor moves the failure selection by delta, clamped to the list
+func (s *ScrapeModel) MoveCursor(delta int) {
+	s.cursor = max(0, min(s.cursor+delta, len(s.failures)-1))
+}
+
+// ToggleMark marks or unmarks the selected failure for retry
+func (s *ScrapeModel) ToggleMark() {
+	path := s.failures[s.cursor].Path
+	if s.marked[path] {
+		delete(s.marked, path)
+	} else {
+		s.marked[path] = true
+	}
+	s.MoveCursor(1)
+}
+
+// Selected returns the path of the selected failure
+func (s *ScrapeModel) Selected() string {
+	if s.cursor >= len(s.failures) {
+		return ""
+	}
+	return s.failures[s.cursor].Path
+}
+
+// Retry fetches the marked failures again, or the selected one when none
+// are marked. Resources fetched on retry have their children crawled too.
+func (s *ScrapeModel) Retry() tea.Cmd {
+	var retry []string
+	var remaining []scrapeFailure
+	for i, f := range s.failures {
+		if s.marked[f.Path] || (len(s.marked) == 0 && i == s.cursor) {
+			retry = append(retry, f.Path)
+		} else {
+			remaining = append(remaining, f)
+		}
+	}
+	s.failures = remaining
+	s.marked = make(map[string]bool)
+	s.cursor = 0
+	s.result = ""
+	s.queue = append(s.queue, retry...)
+	s.total += len(retry)
+	return s.fetchNext()
+}
+
+// scrapeErrorsWrittenMsg is sent after the failure list is written
+type scrapeErrorsWrittenMsg struct {
+	Filename string
+	Count    int
+	Err      error
+}
+
+// ExportFailures writes the failure list to a JSON file
+func (s *ScrapeModel) ExportFailures(filename string) tea.Cmd {
+	type record struct {
+		Path     string             `json:"path"`
+		Status   int                `json:"status,omitempty"`
+		Category rvfs.ErrorCategory `json:"category"`
+		Error    string             `json:"error"`
+	}
+	records := make([]record, len(s.failures))
+	for i, f := range s.failures {
+		records[i] = record{Path: f.Path, Status: f.Status, Category: rvfs.Categorize(f.Err), Error: f.Err.Error()}
+	}
+	return func() tea.Msg {
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return scrapeErrorsWrittenMsg{Filename: filename, Err: err}
+		}
+		err = os.WriteFile(filename, data, 0644)
+		return scrapeErrorsWrittenMsg{Filename: filename, Count: len(records), Err: err}
+	}
+}
+
+// HandleErrorsWritten records the outcome of ExportFailures
+func (s *ScrapeModel) HandleErrorsWritten(msg scrapeErrorsWrittenMsg) {
+	if msg.Err != nil {
+		s.result = fmt.Sprintf("Error: %v", msg.Err)
+	} else {
+		s.result = fmt.Sprintf("Wrote %d failures to %s", msg.Count, msg.Filename)
+	}
+}
+
+func (s *ScrapeModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(detailLabelStyle.Render("Scrape"))
+	b.WriteString("\n\n")
+
+	if s.total == 0 {
+		b.WriteString(actionSuccessStyle.Render("  All reachable resources are cached."))
+		b.WriteString("\n")
+		b.WriteString(s.viewDenied())
+		b.WriteString("\n")
+		b.WriteString(helpDescStyle.Render("  esc: close"))
+		return b.String()
+	}
+
+	// Progress fraction
+	b.WriteString(fmt.Sprintf("  %s %d / %d",
+		detailLabelStyle.Render("Progress:"),
+		s.done, s.total))
+	b.WriteString("\n")
+
+	// Progress bar
+	barWidth := s.width - 4
+	if barWidth < 10 {
+		barWidth = 10
+	}
+	filled := 0
+	if s.total > 0 {
+		filled = barWidth * s.done / s.total
+	}
+	if filled > barWidth {
+		filled = barWidth
+	}
+	bar := progressStyle.Render(strings.Repeat("█", filled))
+	empty := progressEmptyStyle.Render(strings.Repeat("░", barWidth-filled))
+	b.WriteString("  " + bar + empty)
+	b.WriteString("\n\n")
+
+	// Current path
+	if s.current != "" {
+		b.WriteString(fmt.Sprintf("  %s %s\n",
+			helpDescStyle.Render("Fetching:"),
+			childStyle.Render(s.current)))
+	}
+
+	// Remaining
+	remaining := len(s.queue)
+	if remaining > 0 {
+		b.WriteString(fmt.Sprintf("  %s %d\n",
+			helpDescStyle.Render("Remaining:"),
+			remaining))
+	}
+
+	b.WriteString(s.viewDenied())
+
+	if s.Triaging() {
+		b.WriteString("\n")
+		b.WriteString(s.viewFailures())
+		return b.String()
+	}
+
+	// Errors
+	if len(s.failures) > 0 {
+		b.WriteString(fmt.Sprintf("\n  %s %d (%s)\n",
+			actionErrorStyle.Render("Errors:"),
+			len(s.failures), s.categories()))
+		show := len(s.failures)
+		if show > 3 {
+			show = 3
+		}
+		for _, f := range s.failures[len(s.failures)-show:] {
+			b.WriteString("    " + actionErrorStyle.Render(fmt.Sprintf("%s: %v", f.Path, f.Err)) + "\n")
+		}
+	}
+
+	if s.IsDone() {
+		b.WriteString("\n")
+		b.WriteString(actionSuccessStyle.Render("  Done!"))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(helpDescStyle.Render("  esc: close"))
+
+	return b.String()
+}
+
+// viewFailures renders the post-run failure list, scrolled to keep the
+// selection visible
+func (s *ScrapeModel) viewFailures() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("  %s %d (%s)\n",
+		actionErrorStyle.Render("Failed:"),
+		len(s.failures), s.categories()))
+
+	// Rows left after the progress lines, headers and footer
+	rows := max(s.height-13, 3)
+	start := max(0, min(s.cursor-rows/2, len(s.failures)-rows))
+	end := min(start+rows, len(s.failures))
+	for i := start; i < end; i++ {
+		f := s.failures[i]
+		mark := "  "
+		if s.marked[f.Path] {
+			mark = actionNameStyle.Render("● ")
+		}
+		status := "---"
+		if f.Status != 0 {
+			status = fmt.Sprintf("%d", f.Status)
+		}
+		category := fmt.Sprintf("%-8s", rvfs.Categorize(f.Err))
+		line := fmt.Sprintf("%s %s %s", actionErrorStyle.Render(status), helpDescStyle.Render(category), f.Path)
+		if i == s.cursor {
+			line = cursorStyle.Render(fmt.Sprintf("%s %s %s", status, category, f.Path))
+		}
+		b.WriteString("  " + mark + line + "\n")
+	}
+	b.WriteString("\n  " + helpKeyStyle.Render(s.failures[s.cursor].Err.Error()) + "\n")
+
+	if strings.HasPrefix(s.result, "Error") {
+		b.WriteString("\n  " + actionErrorStyle.Render(s.result) + "\n")
+	} else if s.result != "" {
+		b.WriteString("\n  " + actionSuccessStyle.Render(s.result) + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(helpDescStyle.Render("  j/k: select  space: mark  r: retry  o: open parent  x: export list  esc: close"))
+	return b.String()
+}
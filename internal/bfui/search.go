@@ -1,28 +1,45 @@
-package main
+package bfui
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bluefish-project/bluefish/rvfs"
 )
 
-// SearchModel manages the search overlay
+// maxSearchResults caps the results of a search or find
+const maxSearchResults = 50
+
+// searchResult is a selectable line of the search overlay
+type searchResult struct {
+	path  string // Resource to navigate to
+	label string // Line shown for the result
+}
+
+// SearchModel manages the search overlay. A query starting with "=" finds
+// properties by name across the cached resources instead of matching paths.
 type SearchModel struct {
+	vfs     rvfs.VFS
 	input   textinput.Model
 	paths   []string // All known paths
-	results []string // Filtered results
+	results []searchResult
+	err     error // Invalid find pattern
 	cursor  int
 	maxShow int // Max results to display
 	height  int
 	width   int
 }
 
-func NewSearchModel() SearchModel {
+func NewSearchModel(vfs rvfs.VFS) SearchModel {
 	ti := textinput.New()
-	ti.Placeholder = "Search paths..."
+	ti.Placeholder = "Search paths, or =pattern to find properties..."
 	ti.CharLimit = 256
 	return SearchModel{
+		vfs:     vfs,
 		input:   ti,
 		maxShow: 10,
 	}
@@ -35,6 +52,7 @@ func (s *SearchModel) Open(paths []string) {
 	s.input.Focus()
 	s.cursor = 0
 	s.results = nil
+	s.err = nil
 }
 
 // Close deactivates search mode
@@ -45,7 +63,7 @@ func (s *SearchModel) Close() {
 // Selected returns the currently highlighted result path, or empty
 func (s *SearchModel) Selected() string {
 	if s.cursor >= 0 && s.cursor < len(s.results) {
-		return s.results[s.cursor]
+		return s.results[s.cursor].path
 	}
 	return ""
 }
@@ -62,20 +80,18 @@ func (s *SearchModel) Update(msg tea.Msg) tea.Cmd {
 }
 
 func (s *SearchModel) filter(query string) {
-	if query == "" {
-		s.results = nil
-		s.cursor = 0
-		return
-	}
-
-	lower := strings.ToLower(query)
 	s.results = nil
-
-	for _, p := range s.paths {
-		if fuzzyMatch(strings.ToLower(p), lower) {
-			s.results = append(s.results, p)
-			if len(s.results) >= 50 {
-				break
+	s.err = nil
+	if pattern, ok := strings.CutPrefix(query, "="); ok {
+		s.find(pattern)
+	} else if query != "" {
+		lower := strings.ToLower(query)
+		for _, p := range s.paths {
+			if fuzzyMatch(strings.ToLower(p), lower) {
+				s.results = append(s.results, searchResult{path: p, label: p})
+				if len(s.results) >= maxSearchResults {
+					break
+				}
 			}
 		}
 	}
@@ -88,6 +104,42 @@ func (s *SearchModel) filter(query string) {
 	}
 }
 
+// find lists the properties of the cached resources whose names match a
+// case-insensitive pattern
+func (s *SearchModel) find(pattern string) {
+	if pattern == "" {
+		return
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		s.err = err
+		return
+	}
+	for _, m := range s.vfs.FindCached(s.vfs.Root(), re) {
+		s.results = append(s.results, searchResult{
+			path:  m.Resource,
+			label: fmt.Sprintf("%s  %s = %s", m.Resource, m.Property, findValue(m.Value)),
+		})
+		if len(s.results) >= maxSearchResults {
+			return
+		}
+	}
+}
+
+// findValue renders the value of a found property on one line
+func findValue(p *rvfs.Property) string {
+	switch p.Type {
+	case rvfs.PropertySimple:
+		return formatPlainValue(p.Value)
+	case rvfs.PropertyLink:
+		return "→ " + p.LinkTarget
+	case rvfs.PropertyArray:
+		return fmt.Sprintf("[%d]", len(p.Elements))
+	default:
+		return "{...}"
+	}
+}
+
 // fuzzyMatch checks if all characters of pattern appear in order in text
 func fuzzyMatch(text, pattern string) bool {
 	pi := 0
@@ -118,7 +170,10 @@ func (s *SearchModel) View() string {
 	b.WriteString(s.input.View())
 	b.WriteString("\n")
 
-	if len(s.results) == 0 && s.input.Value() != "" {
+	if s.err != nil {
+		b.WriteString(helpDescStyle.Render("  Invalid pattern: " + s.err.Error()))
+		b.WriteString("\n")
+	} else if len(s.results) == 0 && strings.TrimPrefix(s.input.Value(), "=") != "" {
 		b.WriteString(helpDescStyle.Render("  No matches"))
 		b.WriteString("\n")
 	}
@@ -144,9 +199,9 @@ func (s *SearchModel) View() string {
 
 	for i := start; i < end; i++ {
 		if i == s.cursor {
-			b.WriteString(cursorStyle.Render("  " + s.results[i]))
+			b.WriteString(cursorStyle.Render("  " + s.results[i].label))
 		} else {
-			b.WriteString(searchMatchStyle.Render("  " + s.results[i]))
+			b.WriteString(searchMatchStyle.Render("  " + s.results[i].label))
 		}
 		b.WriteString("\n")
 	}
@@ -0,0 +1,206 @@
+package bfui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/termenv"
+)
+
+// bulkConcurrency caps the POSTs an action on a selection runs at once
+const bulkConcurrency = 8
+
+// handleSelectKey handles visual-select mode: Space marks items, the tree
+// navigates as in normal mode, and the other keys act on the marked items
+func (m Model) handleSelectKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, selectKeys.Cancel):
+		m.mode = ModeNormal
+	case key.Matches(msg, selectKeys.Mark):
+		if item := m.tree.ToggleMark(); item != nil {
+			m.details.SetItem(item)
+		}
+	case key.Matches(msg, selectKeys.Clear):
+		m.tree.ClearMarks()
+	case key.Matches(msg, selectKeys.Export):
+		return m.exportSelected()
+	case key.Matches(msg, selectKeys.Refresh):
+		return m.refreshSelected()
+	case key.Matches(msg, selectKeys.Copy):
+		return m.copySelected()
+	case key.Matches(msg, selectKeys.Action):
+		return m.discoverSelected()
+	case key.Matches(msg, normalKeys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, normalKeys.Up), key.Matches(msg, normalKeys.Down),
+		key.Matches(msg, normalKeys.Expand), key.Matches(msg, normalKeys.Collapse),
+		key.Matches(msg, normalKeys.ScrollUp), key.Matches(msg, normalKeys.ScrollDown),
+		key.Matches(msg, normalKeys.NextSection), key.Matches(msg, normalKeys.PrevSection),
+		key.Matches(msg, normalKeys.Fold), key.Matches(msg, normalKeys.FoldAll):
+		return m.handleNormalKey(msg)
+	}
+	return m, nil
+}
+
+// selectedResources returns the paths of the resources among the marked
+// items: children and resources themselves, and what links point to.
+// Marked properties have no resource of their own and are left out.
+func (m Model) selectedResources() []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, item := range m.tree.Marked() {
+		var path string
+		switch item.Kind {
+		case KindChild, KindResource:
+			path = item.Path
+		case KindLink:
+			path = item.LinkTarget
+		default:
+			continue
+		}
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// exportSelected exports the marked resources and everything reachable
+// from them to one file
+func (m Model) exportSelected() (tea.Model, tea.Cmd) {
+	paths := m.selectedResources()
+	if len(paths) == 0 {
+		m.statusMsg = "No resources marked (space marks)"
+		return m, nil
+	}
+	if m.export.IsActive() {
+		m.statusMsg = "An export is running; " + shortKey(normalKeys.Export) + " shows it"
+		return m, nil
+	}
+	m.mode = ModeExport
+	m.recalcLayout()
+	filename := "export_" + time.Now().Format("20060102T150405") + ".json"
+	return m, m.export.Start(paths, filename)
+}
+
+// refreshSelected re-fetches every marked resource
+func (m Model) refreshSelected() (tea.Model, tea.Cmd) {
+	paths := m.selectedResources()
+	if len(paths) == 0 {
+		m.statusMsg = "No resources marked (space marks)"
+		return m, nil
+	}
+	cmds := make([]tea.Cmd, len(paths))
+	for i, path := range paths {
+		m.vfs.Invalidate(path)
+		cmds[i] = func() tea.Msg {
+			resource, err := m.vfs.Get(path)
+			return ResourceLoadedMsg{Path: path, Resource: resource, Err: err}
+		}
+	}
+	m.statusMsg = fmt.Sprintf("Refreshing %d resources...", len(paths))
+	return m, tea.Batch(cmds...)
+}
+
+// copySelected puts the paths of the marked items on the clipboard, one
+// per line, with the OSC 52 terminal sequence so it works over SSH
+func (m Model) copySelected() (tea.Model, tea.Cmd) {
+	items := m.tree.Marked()
+	if len(items) == 0 {
+		m.statusMsg = "Nothing marked (space marks)"
+		return m, nil
+	}
+	paths := make([]string, len(items))
+	for i, item := range items {
+		paths[i] = item.Path
+	}
+	termenv.Copy(strings.Join(paths, "\n"))
+	m.statusMsg = fmt.Sprintf("Copied %d paths", len(paths))
+	return m, nil
+}
+
+// discoverSelected finds the actions every marked resource has, for the
+// action overlay to run on all of them
+func (m Model) discoverSelected() (tea.Model, tea.Cmd) {
+	paths := m.selectedResources()
+	if len(paths) == 0 {
+		m.statusMsg = "No resources marked (space marks)"
+		return m, nil
+	}
+	vfs, role := m.vfs, m.role
+	m.statusMsg = fmt.Sprintf("Discovering actions on %d resources...", len(paths))
+	return m, func() tea.Msg {
+		var common []ActionInfo
+		var refusal error
+		targets := make(map[string][]string)
+		for i, path := range paths {
+			resource, err := vfs.Get(path)
+			if err != nil {
+				return ActionsDiscoveredMsg{Err: err}
+			}
+			if refusal == nil {
+				refusal = role.Refusal(resource)
+			}
+			actions := discoverActions(vfs, resource)
+			if i == 0 {
+				common = actions
+			}
+			have := make(map[string]bool)
+			for _, a := range actions {
+				have[a.Name] = true
+				targets[a.Name] = append(targets[a.Name], a.Target)
+			}
+			kept := common[:0]
+			for _, a := range common {
+				if have[a.Name] {
+					kept = append(kept, a)
+				}
+			}
+			common = kept
+		}
+		if len(common) == 0 {
+			return ActionsDiscoveredMsg{Err: fmt.Errorf("no action is common to the %d marked resources", len(paths))}
+		}
+		for name := range targets {
+			if len(targets[name]) != len(paths) {
+				delete(targets, name)
+			}
+		}
+		return ActionsDiscoveredMsg{Actions: common, Targets: targets, Refusal: refusal}
+	}
+}
+
+// postSelected sends an action's body to its target on every marked
+// resource and reports each outcome; again sends those just sent as well
+func (m Model) postSelected(targets []string, body []byte, again bool) tea.Cmd {
+	vfs, posts := m.vfs, m.posts
+	return func() tea.Msg {
+		results := posts.PostAll(vfs, targets, body, bulkConcurrency, again)
+		var b strings.Builder
+		status, failed := 0, 0
+		for _, r := range results {
+			switch {
+			case r.Err != nil:
+				failed++
+				fmt.Fprintf(&b, "%s  %s: %v\n", actionErrorStyle.Render("error"), r.Path, r.Err)
+			case r.Response.StatusCode >= 300:
+				failed++
+				fmt.Fprintf(&b, "%s  %s\n", actionErrorStyle.Render(fmt.Sprintf("HTTP %d", r.Response.StatusCode)), r.Path)
+			default:
+				fmt.Fprintf(&b, "%s  %s\n", actionSuccessStyle.Render(fmt.Sprintf("HTTP %d", r.Response.StatusCode)), r.Path)
+			}
+			if r.Response != nil {
+				status = max(status, r.Response.StatusCode)
+			}
+		}
+		var err error
+		if failed > 0 {
+			err = fmt.Errorf("%d of %d POSTs failed", failed, len(results))
+		}
+		return ActionResultMsg{StatusCode: status, Body: strings.TrimRight(b.String(), "\n"), Err: err}
+	}
+}
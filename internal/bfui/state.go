@@ -0,0 +1,101 @@
+package bfui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+)
+
+// defaultTreePercent is the share of the width the tree panel gets
+const defaultTreePercent = 40
+
+// uiState is where the user left bfui on one endpoint
+type uiState struct {
+	BasePath    string          `json:"base_path"`
+	RootStack   []string        `json:"root_stack,omitempty"`
+	Expanded    []string        `json:"expanded,omitempty"` // Expanded nodes, parents first
+	Cursor      string          `json:"cursor,omitempty"`
+	Bookmarks   []string        `json:"bookmarks,omitempty"`
+	Marks       map[string]mark `json:"marks,omitempty"`
+	TreePercent int             `json:"tree_percent,omitempty"`
+	Raw         bool            `json:"raw,omitempty"` // Details panel shows raw JSON
+	SideBySide  bool            `json:"side_by_side,omitempty"`
+}
+
+// stateFile keeps the UI state of each endpoint between runs
+type stateFile struct {
+	path      string
+	endpoints map[string]*uiState
+}
+
+// loadStateFile reads the state file at path; a missing file is empty
+func loadStateFile(path string) (*stateFile, error) {
+	f := &stateFile{path: path, endpoints: map[string]*uiState{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &f.endpoints); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Get returns the state saved for endpoint, or nil
+func (f *stateFile) Get(endpoint string) *uiState {
+	return f.endpoints[endpoint]
+}
+
+// Put records the state of endpoint and writes the file
+func (f *stateFile) Put(endpoint string, state *uiState) error {
+	f.endpoints[endpoint] = state
+	data, err := json.MarshalIndent(f.endpoints, "", "  ")
+	if err != nil {
+		return err
+	}
+	return rvfs.WriteFile(f.path, data, 0644)
+}
+
+// state captures where the user is, for the next run
+func (m Model) state() *uiState {
+	state := &uiState{
+		BasePath:    m.basePath,
+		RootStack:   m.rootStack,
+		Expanded:    m.tree.ExpandedPaths(),
+		Bookmarks:   m.bookmarks,
+		Marks:       m.marks,
+		TreePercent: m.treePercent,
+		Raw:         m.details.raw,
+		SideBySide:  m.details.sideBySide,
+	}
+	if item := m.tree.Current(); item != nil {
+		state.Cursor = item.Path
+	}
+	return state
+}
+
+// restore returns the model to a saved state; the tree is rebuilt as its
+// resources load
+func (m Model) restore(state *uiState) Model {
+	if state.BasePath != "" {
+		m.basePath = state.BasePath
+		m.breadcrumb.SetPath(state.BasePath)
+	}
+	m.rootStack = state.RootStack
+	m.bookmarks = state.Bookmarks
+	m.marks = state.Marks
+	if state.TreePercent >= minTreePercent && state.TreePercent <= maxTreePercent {
+		m.treePercent = state.TreePercent
+	}
+	m.details.raw = state.Raw
+	m.details.sideBySide = state.SideBySide
+	m.restoring = state
+	return m
+}
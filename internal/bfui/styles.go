@@ -0,0 +1,164 @@
+package bfui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/bluefish-project/bluefish/theme"
+)
+
+// Styles are set from the configured theme by applyTheme. Themes use ANSI
+// colors 0–15 so they follow the terminal's palette (Solarized, Dracula,
+// Gruvbox, etc. all remap these).
+
+var (
+	// Panel borders
+	borderStyle lipgloss.Style
+
+	// Status bar
+	statusStyle lipgloss.Style
+
+	// Breadcrumb
+	breadcrumbStyle     lipgloss.Style
+	breadcrumbSepStyle  lipgloss.Style
+	breadcrumbLastStyle lipgloss.Style
+
+	// Help bar
+	helpKeyStyle  lipgloss.Style
+	helpDescStyle lipgloss.Style
+
+	// Tree items
+	cursorStyle    lipgloss.Style
+	childStyle     lipgloss.Style
+	objectStyle    lipgloss.Style
+	arrayStyle     lipgloss.Style
+	linkStyle      lipgloss.Style
+	propNameStyle  lipgloss.Style
+	indicatorStyle lipgloss.Style
+
+	// Values
+	stringStyle lipgloss.Style
+	numberStyle lipgloss.Style
+	nullStyle   lipgloss.Style
+	trueStyle   lipgloss.Style
+	falseStyle  lipgloss.Style
+
+	// Health/status semantic colors
+	healthOKStyle       lipgloss.Style
+	healthWarningStyle  lipgloss.Style
+	healthCriticalStyle lipgloss.Style
+
+	// Details panel
+	detailLabelStyle lipgloss.Style
+	detailValueStyle lipgloss.Style
+
+	// Compare view: values of the pinned and the selected resource
+	diffOldStyle lipgloss.Style
+	diffNewStyle lipgloss.Style
+
+	// Search overlay
+	searchPromptStyle lipgloss.Style
+	searchMatchStyle  lipgloss.Style
+
+	// Action overlay
+	actionTitleStyle   lipgloss.Style
+	actionNameStyle    lipgloss.Style
+	actionTargetStyle  lipgloss.Style
+	actionConfirmStyle lipgloss.Style
+	actionSuccessStyle lipgloss.Style
+	actionErrorStyle   lipgloss.Style
+
+	// Progress bars (scrape/export)
+	progressStyle      lipgloss.Style
+	progressEmptyStyle lipgloss.Style
+
+	// Loading
+	loadingStyle lipgloss.Style
+
+	// Resources refused to the logged-in role
+	deniedStyle lipgloss.Style
+
+	// Overlay panel (search/action modals)
+	overlayStyle lipgloss.Style
+
+	// Toast announcing an operation that ended in the background
+	toastStyle lipgloss.Style
+
+	// Separator between tree and details
+	separatorStyle lipgloss.Style
+
+	// activeTheme highlights the raw JSON view and colors resource views
+	activeTheme *theme.Theme
+)
+
+// applyTheme sets the styles from a theme
+func applyTheme(t *theme.Theme) {
+	activeTheme = t
+	borderStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Color(theme.Dim))
+
+	statusStyle = t.Fg(theme.Highlight).
+		Bold(true).
+		Reverse(true).
+		Padding(0, 1)
+
+	breadcrumbStyle = t.Fg(theme.Text)
+	breadcrumbSepStyle = t.Fg(theme.Dim)
+	breadcrumbLastStyle = t.Fg(theme.Bright).Bold(true)
+
+	helpKeyStyle = t.Fg(theme.Dim)
+	helpDescStyle = t.Fg(theme.Text)
+
+	cursorStyle = lipgloss.NewStyle().Reverse(true).Bold(true)
+	childStyle = t.Fg(theme.Child)
+	objectStyle = t.Fg(theme.Object)
+	arrayStyle = t.Fg(theme.Object)
+	linkStyle = t.Fg(theme.Link)
+	propNameStyle = t.Fg(theme.Property)
+	indicatorStyle = t.Fg(theme.Dim)
+
+	stringStyle = t.Fg(theme.String)
+	numberStyle = t.Fg(theme.Number)
+	nullStyle = t.Fg(theme.Null)
+	trueStyle = t.Fg(theme.True)
+	falseStyle = t.Fg(theme.False)
+
+	healthOKStyle = t.Fg(theme.OK)
+	healthWarningStyle = t.Fg(theme.Warning)
+	healthCriticalStyle = t.Fg(theme.Critical)
+
+	detailLabelStyle = t.Fg(theme.Accent).Bold(true)
+	detailValueStyle = t.Fg(theme.Text)
+
+	diffOldStyle = t.Fg(theme.Error)
+	diffNewStyle = t.Fg(theme.OK)
+
+	searchPromptStyle = t.Fg(theme.Accent).Bold(true)
+	searchMatchStyle = t.Fg(theme.Match)
+
+	actionTitleStyle = t.Fg(theme.Error).Bold(true)
+	actionNameStyle = t.Fg(theme.Accent)
+	actionTargetStyle = t.Fg(theme.Child)
+	actionConfirmStyle = t.Fg(theme.Error).Bold(true)
+	actionSuccessStyle = t.Fg(theme.OK)
+	actionErrorStyle = t.Fg(theme.Error)
+
+	progressStyle = t.Fg(theme.OK)
+	progressEmptyStyle = t.Fg(theme.Dim)
+
+	loadingStyle = t.Fg(theme.Dim).Italic(true)
+
+	deniedStyle = t.Fg(theme.Error).Faint(true)
+
+	overlayStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Color(theme.Accent)).
+		Padding(0, 1)
+
+	toastStyle = t.Fg(theme.Bright).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Color(theme.OK)).
+		Padding(0, 1)
+
+	separatorStyle = t.Fg(theme.Dim)
+}
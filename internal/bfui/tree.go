@@ -1,13 +1,15 @@
-package main
+package bfui
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/theme"
 )
 
 // TreeItemKind classifies what a tree item represents
@@ -36,6 +38,8 @@ type TreeItem struct {
 	ChildCount  int
 	HasChildren bool
 	IsExpanded  bool
+	Denied      bool // The service refused the resource to this role
+	Actions     bool // The resource, loaded or cached, exposes actions
 }
 
 // treeNode is the backing data for the full tree (not just visible items)
@@ -56,11 +60,21 @@ type TreeModel struct {
 
 	// Node lookup for async load results
 	nodeMap map[string]*treeNode
+
+	// Items marked for batch operations, by path
+	marked map[string]TreeItem
+
+	// Restoring a saved state: nodes still to expand, the path to put the
+	// cursor on, and how many of their fetches are outstanding
+	pending       map[string]bool
+	pendingCursor string
+	restoring     int
 }
 
 func NewTreeModel() TreeModel {
 	return TreeModel{
 		nodeMap: make(map[string]*treeNode),
+		marked:  make(map[string]TreeItem),
 	}
 }
 
@@ -77,7 +91,7 @@ func (t *TreeModel) Init(resource *rvfs.Resource, basePath string) {
 
 func (t *TreeModel) buildResourceNode(resource *rvfs.Resource, path string, depth int) *treeNode {
 	name := rvfs.BaseName(path)
-	if path == rvfs.RedfishRoot {
+	if rvfs.IsServiceRoot(path) {
 		name = "Root"
 	}
 
@@ -88,6 +102,7 @@ func (t *TreeModel) buildResourceNode(resource *rvfs.Resource, path string, dept
 			Depth:    depth,
 			Kind:     KindResource,
 			Resource: resource,
+			Actions:  resource.HasActions(),
 		},
 		Loaded: true,
 	}
@@ -225,16 +240,19 @@ func (t *TreeModel) findNode(path string) *treeNode {
 	return t.nodeMap[path]
 }
 
-// HandleResourceLoaded integrates an async-fetched resource into the tree
-func (t *TreeModel) HandleResourceLoaded(path string, resource *rvfs.Resource) {
+// HandleResourceLoaded integrates an async-fetched resource into the tree.
+// While a saved state is restored, it returns the fetches of the nodes to
+// expand below the resource.
+func (t *TreeModel) HandleResourceLoaded(path string, resource *rvfs.Resource) tea.Cmd {
 	node := t.findNode(path)
 	if node == nil {
-		return
+		return nil
 	}
 
 	node.Loaded = true
 	node.Item.Resource = resource
 	node.Item.Kind = KindResource
+	node.Item.Actions = resource.HasActions()
 	node.Children = nil
 
 	// Build child nodes
@@ -277,6 +295,157 @@ func (t *TreeModel) HandleResourceLoaded(path string, resource *rvfs.Resource) {
 
 	node.Item.HasChildren = len(node.Children) > 0
 	t.rebuildVisible()
+
+	if t.restoring == 0 {
+		return nil
+	}
+	t.restoring--
+	return t.expandPending(node)
+}
+
+// LoadFailed accounts for a fetch that failed, so a restore waiting on it
+// can finish
+func (t *TreeModel) LoadFailed() {
+	if t.restoring == 0 {
+		return
+	}
+	t.restoring--
+	if t.restoring == 0 {
+		t.pending = nil
+		t.pendingCursor = ""
+	}
+}
+
+// SetDenied marks the resource at path as refused to the logged-in role;
+// it has nothing to expand
+func (t *TreeModel) SetDenied(path string) {
+	node := t.findNode(path)
+	if node == nil {
+		return
+	}
+	node.Item.Denied = true
+	node.Item.IsExpanded = false
+	node.Item.HasChildren = false
+	node.Loaded = true
+	t.rebuildVisible()
+}
+
+// MarkDenied marks the unloaded children that denied reports as refused,
+// so they show as such before anyone tries them
+func (t *TreeModel) MarkDenied(denied func(path string) bool) {
+	changed := false
+	for path, node := range t.nodeMap {
+		if node.Item.Kind == KindChild && !node.Loaded && !node.Item.Denied && denied(path) {
+			node.Item.Denied = true
+			node.Item.HasChildren = false
+			node.Loaded = true
+			changed = true
+		}
+	}
+	if changed {
+		t.rebuildVisible()
+	}
+}
+
+// MarkActions marks the unloaded children whose cached resource exposes
+// actions, so the tree shows where operations are before they are opened
+func (t *TreeModel) MarkActions(cached func(path string) *rvfs.Resource) {
+	changed := false
+	for path, node := range t.nodeMap {
+		if node.Item.Kind == KindChild && !node.Loaded && !node.Item.Actions && cached(path).HasActions() {
+			node.Item.Actions = true
+			changed = true
+		}
+	}
+	if changed {
+		t.rebuildVisible()
+	}
+}
+
+// ExpandedPaths lists the expanded nodes below the root that are visible
+// or would be, parents first
+func (t *TreeModel) ExpandedPaths() []string {
+	var paths []string
+	for _, item := range t.visible[min(1, len(t.visible)):] {
+		if item.IsExpanded {
+			paths = append(paths, item.Path)
+		}
+	}
+	return paths
+}
+
+// AncestorPaths lists the nodes between the root and the cursor, parents
+// first: those Restore expands to bring the cursor back
+func (t *TreeModel) AncestorPaths() []string {
+	if t.cursor >= len(t.visible) {
+		return nil
+	}
+	var paths []string
+	depth := t.visible[t.cursor].Depth
+	for i := t.cursor - 1; i >= 0 && depth > 1; i-- {
+		if t.visible[i].Depth < depth {
+			depth = t.visible[i].Depth
+			paths = append(paths, t.visible[i].Path)
+		}
+	}
+	slices.Reverse(paths)
+	return paths
+}
+
+// Restore expands the nodes at expanded, fetching their resources, and
+// puts the cursor on the item at cursor once it appears. Nodes that no
+// longer exist are skipped.
+func (t *TreeModel) Restore(expanded []string, cursor string) tea.Cmd {
+	t.pending = make(map[string]bool, len(expanded))
+	for _, p := range expanded {
+		t.pending[p] = true
+	}
+	t.pendingCursor = cursor
+	return t.expandPending(t.root)
+}
+
+// expandPending expands the pending nodes below node and returns the
+// fetches of those not loaded yet
+func (t *TreeModel) expandPending(node *treeNode) tea.Cmd {
+	cmd := t.expandPendingBelow(node)
+	t.rebuildVisible()
+	if t.pendingCursor != "" {
+		for i, item := range t.visible {
+			if item.Path == t.pendingCursor {
+				t.cursor = i
+				t.ensureVisible()
+				t.pendingCursor = ""
+				break
+			}
+		}
+	}
+	if t.restoring == 0 {
+		// Everything reachable is restored; what is left no longer exists
+		t.pending = nil
+		t.pendingCursor = ""
+	}
+	return cmd
+}
+
+func (t *TreeModel) expandPendingBelow(node *treeNode) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, child := range node.Children {
+		if !t.pending[child.Item.Path] {
+			continue
+		}
+		delete(t.pending, child.Item.Path)
+		child.Item.IsExpanded = true
+		if !child.Loaded {
+			t.restoring++
+			path := child.Item.Path
+			cmds = append(cmds, func() tea.Msg {
+				return fetchResourceMsg{Path: path}
+			})
+			continue
+		}
+		cmds = append(cmds, t.expandPendingBelow(child))
+	}
+	return tea.Batch(cmds...)
 }
 
 // MoveUp moves cursor up
@@ -373,6 +542,54 @@ func (t *TreeModel) Toggle() tea.Cmd {
 	return t.Expand()
 }
 
+// ToggleMark marks or unmarks the current item and moves to the next
+func (t *TreeModel) ToggleMark() *TreeItem {
+	item := t.Current()
+	if item == nil {
+		return nil
+	}
+	if _, ok := t.marked[item.Path]; ok {
+		delete(t.marked, item.Path)
+	} else {
+		t.marked[item.Path] = *item
+	}
+	return t.MoveDown()
+}
+
+// Marked returns the marked items ordered by path
+func (t *TreeModel) Marked() []TreeItem {
+	paths := make([]string, 0, len(t.marked))
+	for p := range t.marked {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	items := make([]TreeItem, len(paths))
+	for i, p := range paths {
+		items[i] = t.marked[p]
+	}
+	return items
+}
+
+// ClearMarks unmarks every item
+func (t *TreeModel) ClearMarks() {
+	t.marked = make(map[string]TreeItem)
+}
+
+// gutter returns the mark column of an item, shown while any item is
+// marked
+func (t *TreeModel) gutter(item TreeItem, styled bool) string {
+	if len(t.marked) == 0 {
+		return ""
+	}
+	if _, ok := t.marked[item.Path]; !ok {
+		return "  "
+	}
+	if styled {
+		return actionNameStyle.Render("● ")
+	}
+	return "● "
+}
+
 // ensureVisible adjusts scroll offset to keep cursor in view
 func (t *TreeModel) ensureVisible() {
 	if t.height <= 0 {
@@ -410,12 +627,7 @@ func (t *TreeModel) View() string {
 		var line string
 		if i == t.cursor {
 			// Render plain text with reverse for clean highlight bar
-			plain := t.renderItemPlain(item)
-			padding := t.width - len(plain)
-			if padding < 0 {
-				padding = 0
-			}
-			line = cursorStyle.Render(plain + strings.Repeat(" ", padding))
+			line = cursorStyle.Render(theme.Pad(t.renderItemPlain(item), t.width))
 		} else {
 			line = t.renderItem(item)
 		}
@@ -446,13 +658,15 @@ func (t *TreeModel) renderItem(item TreeItem) string {
 	var text string
 	switch item.Kind {
 	case KindResource:
-		text = childStyle.Render(item.Name)
+		text = childStyle.Render(item.Name) + actionMark(item)
 	case KindChild:
 		node := t.findNode(item.Path)
-		if node != nil && !node.Loaded && item.IsExpanded {
+		if item.Denied {
+			text = deniedStyle.Render("⊘ " + item.Name)
+		} else if node != nil && !node.Loaded && item.IsExpanded {
 			text = childStyle.Render(item.Name) + " " + loadingStyle.Render("loading...")
 		} else {
-			text = childStyle.Render(item.Name)
+			text = childStyle.Render(item.Name) + actionMark(item)
 		}
 	case KindSimple:
 		text = propNameStyle.Render(item.Name) + ": " + formatHealthValue(item.Name, item.Property.Value)
@@ -464,10 +678,18 @@ func (t *TreeModel) renderItem(item TreeItem) string {
 		text = linkStyle.Render(item.Name) + " " + linkStyle.Render("→") + " " + linkStyle.Render(item.LinkTarget)
 	}
 
-	return indent + indicator + text
+	return t.gutter(item, true) + indent + indicator + text
+}
+
+// actionMark follows a resource that exposes actions
+func actionMark(item TreeItem) string {
+	if !item.Actions {
+		return ""
+	}
+	return " " + healthWarningStyle.Render("⚡")
 }
 
-// renderItemPlain returns the item text without ANSI codes (for width measurement)
+// renderItemPlain returns the item text without ANSI codes, for the cursor bar
 func (t *TreeModel) renderItemPlain(item TreeItem) string {
 	indent := strings.Repeat("  ", item.Depth)
 
@@ -483,10 +705,13 @@ func (t *TreeModel) renderItemPlain(item TreeItem) string {
 
 	var text string
 	switch item.Kind {
-	case KindResource:
-		text = item.Name
-	case KindChild:
+	case KindResource, KindChild:
 		text = item.Name
+		if item.Denied {
+			text = "⊘ " + item.Name
+		} else if item.Actions {
+			text += " ⚡"
+		}
 	case KindSimple:
 		text = item.Name + ": " + item.Value
 	case KindObject:
@@ -497,5 +722,5 @@ func (t *TreeModel) renderItemPlain(item TreeItem) string {
 		text = item.Name + " → " + item.LinkTarget
 	}
 
-	return indent + indicator + text
+	return t.gutter(item, false) + indent + indicator + text
 }
@@ -0,0 +1,249 @@
+package bfui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bluefish-project/bluefish/internal/workspace"
+	"github.com/bluefish-project/bluefish/rvfs"
+)
+
+// WorkspaceModel is the overlay that names a workspace to save or open
+type WorkspaceModel struct {
+	input  textinput.Model
+	names  []string // Saved workspaces
+	cursor int
+	err    error // Listing the saved workspaces failed
+	width  int
+	height int
+}
+
+func NewWorkspaceModel() WorkspaceModel {
+	ti := textinput.New()
+	ti.Placeholder = "Workspace name or file.json..."
+	ti.CharLimit = 256
+	return WorkspaceModel{input: ti}
+}
+
+// Open lists the saved workspaces and focuses the name input
+func (w *WorkspaceModel) Open() {
+	w.names, w.err = workspace.List()
+	w.cursor = 0
+	w.input.SetValue("")
+	w.input.Focus()
+}
+
+// Close deactivates the overlay
+func (w *WorkspaceModel) Close() {
+	w.input.Blur()
+}
+
+// Name returns the typed name, or the selected saved workspace when none
+// is typed
+func (w *WorkspaceModel) Name() string {
+	if name := strings.TrimSpace(w.input.Value()); name != "" {
+		return name
+	}
+	if w.cursor < len(w.names) {
+		return w.names[w.cursor]
+	}
+	return ""
+}
+
+// MoveCursor selects a saved workspace and puts its name in the input
+func (w *WorkspaceModel) MoveCursor(delta int) {
+	if len(w.names) == 0 {
+		return
+	}
+	w.cursor = max(0, min(w.cursor+delta, len(w.names)-1))
+	w.input.SetValue(w.names[w.cursor])
+	w.input.CursorEnd()
+}
+
+func (w *WorkspaceModel) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	w.input, cmd = w.input.Update(msg)
+	return cmd
+}
+
+func (w *WorkspaceModel) View() string {
+	var b strings.Builder
+	b.WriteString(searchPromptStyle.Render("Workspace: "))
+	b.WriteString(w.input.View())
+	b.WriteString("\n\n")
+
+	switch {
+	case w.err != nil:
+		b.WriteString(actionErrorStyle.Render("  " + w.err.Error()))
+		b.WriteString("\n")
+	case len(w.names) == 0:
+		b.WriteString(helpDescStyle.Render("  No saved workspaces"))
+		b.WriteString("\n")
+	}
+	rows := max(w.height-5, 1)
+	start := max(0, min(w.cursor-rows/2, len(w.names)-rows))
+	end := min(start+rows, len(w.names))
+	for i := start; i < end; i++ {
+		if i == w.cursor {
+			b.WriteString(cursorStyle.Render("  " + w.names[i]))
+		} else {
+			b.WriteString(searchMatchStyle.Render("  " + w.names[i]))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(helpDescStyle.Render(fmt.Sprintf("  %s:open  %s:save  %s:cancel",
+		shortKey(workspaceKeys.Open), shortKey(workspaceKeys.Save), shortKey(workspaceKeys.Cancel))))
+	return b.String()
+}
+
+// pinnedLoadedMsg is sent when the pinned resource of an opened workspace
+// is fetched
+type pinnedLoadedMsg struct {
+	Resource *rvfs.Resource
+	Err      error
+}
+
+// workspace captures the navigation context to save
+func (m Model) workspace() *workspace.Workspace {
+	state := m.state()
+	w := &workspace.Workspace{
+		Endpoint:  m.endpoint,
+		BasePath:  state.BasePath,
+		Expanded:  state.Expanded,
+		Cursor:    state.Cursor,
+		Bookmarks: m.bookmarks,
+	}
+	if pinned := m.details.Pinned(); pinned != nil {
+		w.Pinned = pinned.Path
+	}
+	return w
+}
+
+// openWorkspace rebuilds the tree of a saved workspace, its bookmarks and
+// its pinned resource
+func (m Model) openWorkspace(name string, w *workspace.Workspace) (tea.Model, tea.Cmd) {
+	m = m.restore(&uiState{
+		BasePath:    w.BasePath,
+		Expanded:    w.Expanded,
+		Cursor:      w.Cursor,
+		Bookmarks:   w.Bookmarks,
+		Marks:       m.marks,
+		TreePercent: m.treePercent,
+		Raw:         m.details.raw,
+		SideBySide:  m.details.sideBySide,
+	})
+	m.details.Pin(nil)
+	model, cmd := m.navigateTo(w.BasePath)
+	m = model.(Model)
+	m.statusMsg = fmt.Sprintf("Opened workspace %s", name)
+	if w.Endpoint != "" && w.Endpoint != m.endpoint {
+		m.statusMsg += " (saved on " + w.Endpoint + "; paths may not exist here)"
+	}
+	if w.Pinned != "" {
+		vfs, path := m.vfs, w.Pinned
+		cmd = tea.Batch(cmd, func() tea.Msg {
+			resource, err := vfs.Get(path)
+			return pinnedLoadedMsg{Resource: resource, Err: err}
+		})
+	}
+	return m, cmd
+}
+
+func (m Model) handleWorkspaceKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, workspaceKeys.Cancel):
+		m.mode = ModeNormal
+		m.workspaces.Close()
+		m.recalcLayout()
+		return m, nil
+
+	case key.Matches(msg, workspaceKeys.NextItem):
+		m.workspaces.MoveCursor(1)
+		return m, nil
+
+	case key.Matches(msg, workspaceKeys.PrevItem):
+		m.workspaces.MoveCursor(-1)
+		return m, nil
+
+	case key.Matches(msg, workspaceKeys.Save), key.Matches(msg, workspaceKeys.Open):
+		name := m.workspaces.Name()
+		if name == "" {
+			return m, nil
+		}
+		m.mode = ModeNormal
+		m.workspaces.Close()
+		m.recalcLayout()
+		if key.Matches(msg, workspaceKeys.Save) {
+			if err := workspace.Save(name, m.workspace()); err != nil {
+				m.statusMsg = fmt.Sprintf("Error: %v", err)
+			} else {
+				m.statusMsg = "Saved workspace to " + workspace.File(name)
+			}
+			return m, nil
+		}
+		w, err := workspace.Load(name)
+		if err != nil {
+			m.statusMsg = fmt.Sprintf("Error: %v", err)
+			return m, nil
+		}
+		return m.openWorkspace(name, w)
+	}
+
+	cmd := m.workspaces.Update(msg)
+	return m, cmd
+}
+
+// bookmarkPath is the resource a tree item bookmarks, or "" for a property
+func bookmarkPath(item *TreeItem) string {
+	switch item.Kind {
+	case KindChild, KindResource:
+		return item.Path
+	case KindLink:
+		return item.LinkTarget
+	}
+	return ""
+}
+
+// handleBookmark bookmarks the selected resource, or removes its bookmark
+func (m Model) handleBookmark() (tea.Model, tea.Cmd) {
+	item := m.tree.Current()
+	if item == nil {
+		return m, nil
+	}
+	path := bookmarkPath(item)
+	if path == "" {
+		m.statusMsg = "Only resources can be bookmarked"
+		return m, nil
+	}
+	if i := slices.Index(m.bookmarks, path); i >= 0 {
+		m.bookmarks = slices.Delete(slices.Clone(m.bookmarks), i, i+1)
+		m.statusMsg = "Removed bookmark " + path
+	} else {
+		m.bookmarks = append(slices.Clone(m.bookmarks), path)
+		m.statusMsg = fmt.Sprintf("Bookmarked %s (%d)", path, len(m.bookmarks))
+	}
+	return m, nil
+}
+
+// handleNextBookmark rebases the tree on the bookmark after the current
+// root, wrapping around
+func (m Model) handleNextBookmark() (tea.Model, tea.Cmd) {
+	if len(m.bookmarks) == 0 {
+		m.statusMsg = "No bookmarks"
+		return m, nil
+	}
+	next := m.bookmarks[(slices.Index(m.bookmarks, m.basePath)+1)%len(m.bookmarks)]
+	if next == m.basePath {
+		m.statusMsg = "At the only bookmark"
+		return m, nil
+	}
+	m.rootStack = append(m.rootStack, m.basePath)
+	return m.navigateTo(next)
+}
@@ -1,11 +1,11 @@
-package main
+package btsh
 
 import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"sort"
-	"strconv"
 	"strings"
 
 	"github.com/bluefish-project/bluefish/rvfs"
@@ -20,9 +20,9 @@ type ActionInfo struct {
 	Allowable map[string][]string
 }
 
-// discoverActions finds all actions on the resource at nav.cwd
-func discoverActions(nav *Navigator) ([]ActionInfo, error) {
-	resolved, err := nav.vfs.ResolveTarget(rvfs.RedfishRoot, nav.cwd)
+// discoverActions finds all actions on the resource at path
+func discoverActions(vfs rvfs.VFS, path string) ([]ActionInfo, error) {
+	resolved, err := vfs.ResolveTarget(vfs.Root(), path)
 	if err != nil {
 		return nil, err
 	}
@@ -32,7 +32,7 @@ func discoverActions(nav *Navigator) ([]ActionInfo, error) {
 	case rvfs.TargetResource, rvfs.TargetLink:
 		resource = resolved.Resource
 		if resource == nil {
-			resource, err = nav.vfs.Get(resolved.ResourcePath)
+			resource, err = vfs.Get(resolved.ResourcePath)
 			if err != nil {
 				return nil, err
 			}
@@ -116,14 +116,41 @@ func matchAction(actions []ActionInfo, name string) *ActionInfo {
 	return nil
 }
 
-// formatActionList formats available actions
-func formatActionList(actions []ActionInfo) string {
+// writeRefusal returns why the role may not write to the resource at path,
+// or holding the property at path; nil when it may or the role is unknown
+func (n *Navigator) writeRefusal(path string) error {
+	if n.role == nil {
+		return nil
+	}
+	resolved, err := n.vfs.ResolveTarget(n.vfs.Root(), path)
+	if err != nil {
+		return nil
+	}
+	resource := resolved.Resource
+	if resource == nil {
+		if resource, err = n.vfs.Get(resolved.ResourcePath); err != nil {
+			return nil
+		}
+	}
+	return n.role.Refusal(resource)
+}
+
+// formatActionList formats available actions. When the role may not write
+// to the resource, refusal says why and the actions are shown disabled.
+func formatActionList(actions []ActionInfo, refusal error) string {
 	var b strings.Builder
 	b.WriteString("\n")
 	b.WriteString(errorStyle.Render("Actions"))
 	b.WriteString("\n")
+	if refusal != nil {
+		b.WriteString(dimStyle.Render("  Disabled: " + refusal.Error()))
+		b.WriteString("\n")
+	}
 	for _, a := range actions {
 		line := fmt.Sprintf("  %s", warnStyle.Render(a.ShortName))
+		if refusal != nil {
+			line = "  " + dimStyle.Render(a.ShortName)
+		}
 		if len(a.Allowable) > 0 {
 			var params []string
 			for param, vals := range a.Allowable {
@@ -212,43 +239,21 @@ func formatActionDetail(nav *Navigator, action *ActionInfo) string {
 	return b.String()
 }
 
-// parseActionBody parses key=value arguments into a JSON body
+// parseActionBody parses key=value and key:=json arguments into a JSON
+// body, checking values against the parameters' AllowableValues
 func parseActionBody(action *ActionInfo, args []string) ([]byte, error) {
 	body := make(map[string]any)
 	for _, arg := range args {
-		idx := strings.Index(arg, "=")
-		if idx == -1 {
-			return nil, fmt.Errorf("invalid argument %q (expected key=value)", arg)
+		key, val, err := rvfs.ParseParameter(arg)
+		if err != nil {
+			return nil, err
 		}
-		key := arg[:idx]
-		val := arg[idx+1:]
-
 		if allowed, ok := action.Allowable[key]; ok {
-			found := false
-			for _, a := range allowed {
-				if a == val {
-					found = true
-					break
-				}
-			}
-			if !found {
-				return nil, fmt.Errorf("invalid value %q for %s (allowed: %s)", val, key, strings.Join(allowed, ", "))
+			if s, isString := val.(string); !isString || !slices.Contains(allowed, s) {
+				return nil, fmt.Errorf("invalid value %v for %s (allowed: %s)", val, key, strings.Join(allowed, ", "))
 			}
 		}
-
-		if n, err := strconv.ParseFloat(val, 64); err == nil {
-			if n == float64(int64(n)) {
-				body[key] = int64(n)
-			} else {
-				body[key] = n
-			}
-		} else if val == "true" {
-			body[key] = true
-		} else if val == "false" {
-			body[key] = false
-		} else {
-			body[key] = val
-		}
+		body[key] = val
 	}
 
 	return json.MarshalIndent(body, "", "  ")
@@ -266,17 +271,119 @@ func formatActionConfirm(action *ActionInfo, jsonBody []byte) string {
 }
 
 // formatActionResult formats the result of a POST
-func formatActionResult(status int, data []byte) string {
+func formatActionResult(resp *rvfs.Response) string {
 	var b strings.Builder
-	fmt.Fprintf(&b, "\nHTTP %d\n", status)
-	if len(data) > 0 {
+	fmt.Fprintf(&b, "\nHTTP %d\n", resp.StatusCode)
+	for _, m := range resp.Messages {
+		writeMessage(&b, m, 2)
+	}
+	if len(resp.Body) > 0 {
 		var buf bytes.Buffer
-		if json.Indent(&buf, data, "", "  ") == nil {
+		if json.Indent(&buf, resp.Body, "", "  ") == nil {
 			b.WriteString(buf.String())
 		} else {
-			b.WriteString(string(data))
+			b.WriteString(string(resp.Body))
 		}
 		b.WriteString("\n")
 	}
 	return b.String()
 }
+
+// bulkConcurrency caps the POSTs foreach runs at once
+const bulkConcurrency = 8
+
+// bulkTarget is one resource matched by foreach
+type bulkTarget struct {
+	Resource string // Matched resource path
+	Target   string // Action target URI; empty when skipped
+	Err      error  // Why the resource is skipped
+}
+
+// planBulk resolves a foreach: the resources matching pattern, and for each
+// the target of the named action. Resources without the action, whose
+// allowable values reject the arguments, or that role may not write to are
+// skipped. The body is the same for every target.
+func planBulk(vfs rvfs.VFS, role *rvfs.SessionRole, cwd, pattern, actionName string, args []string) ([]bulkTarget, []byte, error) {
+	paths, err := rvfs.Glob(vfs, cwd, pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(paths) == 0 {
+		return nil, nil, fmt.Errorf("no match: %s", pattern)
+	}
+
+	var body []byte
+	plan := make([]bulkTarget, len(paths))
+	for i, path := range paths {
+		plan[i].Resource = path
+		actions, err := discoverActions(vfs, path)
+		if err != nil {
+			plan[i].Err = err
+			continue
+		}
+		action := matchAction(actions, actionName)
+		if action == nil {
+			plan[i].Err = fmt.Errorf("no action %s", actionName)
+			continue
+		}
+		if body, err = parseActionBody(action, args); err != nil {
+			plan[i].Err = err
+			continue
+		}
+		if role != nil {
+			if resource, err := vfs.Get(path); err == nil {
+				if plan[i].Err = role.Refusal(resource); plan[i].Err != nil {
+					continue
+				}
+			}
+		}
+		plan[i].Target = action.Target
+	}
+	return plan, body, nil
+}
+
+// formatBulkPlan formats the preview of a foreach: one line per matched
+// resource, then the shared body
+func formatBulkPlan(plan []bulkTarget, body []byte) string {
+	var b strings.Builder
+	b.WriteString("\n")
+	for _, t := range plan {
+		if t.Err != nil {
+			fmt.Fprintf(&b, "%s %s: %v\n", dimStyle.Render("skip"), t.Resource, t.Err)
+			continue
+		}
+		fmt.Fprintf(&b, "%s %s\n", errorStyle.Render("POST"), t.Target)
+	}
+	if len(body) > 2 { // Not just "{}"
+		b.WriteString(string(body))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// formatBulkResults formats one line per POST, with any service messages,
+// and a count of successes and failures
+func formatBulkResults(results []rvfs.BulkResult) string {
+	var b strings.Builder
+	b.WriteString("\n")
+	failed := 0
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed++
+			fmt.Fprintf(&b, "%s  %s: %v\n", errorStyle.Render("failed  "), r.Path, r.Err)
+		case r.Response.StatusCode < 200 || r.Response.StatusCode > 299:
+			failed++
+			fmt.Fprintf(&b, "%s  %s\n", errorStyle.Render(fmt.Sprintf("HTTP %d", r.Response.StatusCode)), r.Path)
+		default:
+			fmt.Fprintf(&b, "%s  %s\n", healthOKStyle.Render(fmt.Sprintf("HTTP %d", r.Response.StatusCode)), r.Path)
+		}
+		if r.Response != nil {
+			for _, m := range r.Response.Messages {
+				writeMessage(&b, m, 2)
+			}
+		}
+	}
+	fmt.Fprintf(&b, "%d succeeded, %d failed", len(results)-failed, failed)
+	return b.String()
+}
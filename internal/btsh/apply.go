@@ -0,0 +1,320 @@
+package btsh
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+)
+
+// applyArgs are the arguments of apply
+type applyArgs struct {
+	desired  string // Desired state YAML file to plan from
+	plan     string // Saved plan to apply instead, from --plan
+	output   string // File to save the plan to, from -o
+	planOnly bool   // Show or save the plan without applying it
+	again    bool   // Send actions just sent regardless, from --again
+}
+
+// parseApplyArgs parses the arguments of apply: a desired state file with
+// --plan-only and -o, or --plan and a saved plan, either with --again
+func parseApplyArgs(args []string) (*applyArgs, error) {
+	usage := fmt.Errorf("usage: apply <desired.yaml> [--plan-only] [-o plan.json] [--again] | apply --plan <plan.json> [--again]")
+	a := &applyArgs{}
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--plan-only":
+			a.planOnly = true
+		case args[i] == "--again":
+			a.again = true
+		case args[i] == "-o" || args[i] == "--plan":
+			if i+1 >= len(args) {
+				return nil, usage
+			}
+			if args[i] == "-o" {
+				a.output = args[i+1]
+			} else {
+				a.plan = args[i+1]
+			}
+			i++
+		case a.desired == "":
+			a.desired = args[i]
+		default:
+			return nil, usage
+		}
+	}
+	if (a.desired == "") == (a.plan == "") || (a.plan != "" && (a.planOnly || a.output != "")) {
+		return nil, usage
+	}
+	return a, nil
+}
+
+// planApply plans the changes that bring the service to the desired state
+// of a YAML file, or loads a saved plan that still matches the service,
+// to be sent once confirmed. With -o the plan is saved for review, and
+// with --plan-only it is only shown. Actions just sent are refused, like
+// any action, unless --again is given.
+func planApply(nav *Navigator, a *applyArgs) tea.Msg {
+	var plan *rvfs.Plan
+	var saved string
+	if a.plan != "" {
+		var err error
+		if plan, err = rvfs.LoadPlan(a.plan); err != nil {
+			return commandResultMsg{err: err}
+		}
+		stale, err := rvfs.StaleChanges(nav.vfs, plan)
+		if err != nil {
+			return commandResultMsg{err: err}
+		}
+		if len(stale) > 0 {
+			return commandResultMsg{err: staleError(a.plan+" is stale; plan again", stale)}
+		}
+	} else {
+		desired, err := rvfs.LoadDesiredState(a.desired)
+		if err != nil {
+			return commandResultMsg{err: err}
+		}
+		if plan, err = rvfs.PlanApply(nav.vfs, desired); err != nil {
+			return commandResultMsg{err: err}
+		}
+		if a.output != "" {
+			if err := rvfs.SavePlan(a.output, plan); err != nil {
+				return commandResultMsg{err: err}
+			}
+			saved = fmt.Sprintf("Saved plan of %d changes to %s", len(plan.Changes), a.output)
+		}
+	}
+	if len(plan.Changes) == 0 {
+		output := "No changes; the service matches " + a.desired
+		if a.plan != "" {
+			output = "No changes in " + a.plan
+		}
+		return commandResultMsg{output: strings.TrimPrefix(saved+"\n"+output, "\n")}
+	}
+	if a.planOnly {
+		return commandResultMsg{output: strings.TrimPrefix(saved+"\n"+formatPlan(plan), "\n")}
+	}
+	for _, c := range plan.Changes {
+		if err := nav.writeRefusal(c.Resource); err != nil {
+			return commandResultMsg{err: err}
+		}
+	}
+	if !a.again {
+		if err := nav.posts.CheckPlan(plan); err != nil {
+			return commandResultMsg{err: fmt.Errorf("%w; repeat it with --again", err)}
+		}
+	}
+	return postPlannedMsg{
+		output: strings.TrimPrefix(saved+"\n"+formatPlan(plan), "\n"),
+		prompt: "Apply? [y/N]",
+		label:  "Applying...",
+		run: func() string {
+			results := rvfs.ApplyPlan(nav.vfs, plan, &nav.posts, a.again)
+			nav.history.Record(results)
+			return formatApplyResults(results)
+		},
+	}
+}
+
+// staleError refuses a plan whose properties changed since it was made,
+// naming each with the value the plan expected and the live one
+func staleError(reason string, stale []rvfs.Change) error {
+	lines := make([]string, len(stale))
+	for i, c := range stale {
+		lines[i] = fmt.Sprintf("  %s %s: planned from %s, now %s", c.Resource, c.PropertyName(), planValue(c.Old), planValue(c.New))
+	}
+	return fmt.Errorf("%s:\n%s", reason, strings.Join(lines, "\n"))
+}
+
+// stage records a property change for commit
+func (n *Navigator) stage(args []string) (string, error) {
+	if len(args) < 2 {
+		return "", fmt.Errorf("usage: stage <property> <value>")
+	}
+	change, err := n.staging.Stage(n.vfs, n.cwd, args[0], rvfs.TargetArg(args[1:]))
+	if err != nil {
+		return "", err
+	}
+	if change == nil {
+		return fmt.Sprintf("%s already has that value; nothing staged (%d staged)", args[0], n.staging.Len()), nil
+	}
+	return fmt.Sprintf("Staged %s %s: %s → %s (%d staged)", childStyle.Render(change.Resource), propStyle.Render(change.PropertyName()),
+		planValue(change.Old), planValue(change.New), n.staging.Len()), nil
+}
+
+// unstage drops the staged changes of a property or resource, or all of
+// them without an argument
+func (n *Navigator) unstage(args []string) (string, error) {
+	dropped := 0
+	if len(args) == 0 {
+		dropped = n.staging.Clear()
+	} else {
+		var err error
+		if dropped, err = n.staging.Unstage(n.vfs, n.cwd, rvfs.TargetArg(args)); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("Dropped %d staged changes (%d staged)", dropped, n.staging.Len()), nil
+}
+
+// planCommit shows the staged changes, one PATCH per resource, to be sent
+// once confirmed, unless what they change has changed since they were
+// staged. Changes the service refuses stay staged.
+func planCommit(nav *Navigator) tea.Msg {
+	if nav.staging.Len() == 0 {
+		return commandResultMsg{output: "Nothing staged"}
+	}
+	plan := nav.staging.Plan()
+	for _, c := range plan.Changes {
+		if err := nav.writeRefusal(c.Resource); err != nil {
+			return commandResultMsg{err: err}
+		}
+	}
+	stale, err := rvfs.StaleChanges(nav.vfs, plan)
+	if err != nil {
+		return commandResultMsg{err: err}
+	}
+	if len(stale) > 0 {
+		return commandResultMsg{err: staleError("properties changed since they were staged; unstage or stage them again", stale)}
+	}
+	return postPlannedMsg{
+		output: formatPlan(plan),
+		prompt: "Commit? [y/N]",
+		label:  "Committing...",
+		run: func() string {
+			results := rvfs.ApplyPlan(nav.vfs, plan, &nav.posts, false)
+			nav.staging.Committed(results)
+			nav.history.Record(results)
+			output := formatApplyResults(results)
+			if nav.staging.Len() > 0 {
+				output += fmt.Sprintf("\n%d changes stay staged", nav.staging.Len())
+			}
+			return output
+		},
+	}
+}
+
+// formatPlan lists the changes of an apply plan by resource, old value to
+// new, then the actions it invokes
+func formatPlan(plan *rvfs.Plan) string {
+	var b strings.Builder
+	resources, actions := 0, 0
+	last := ""
+	for _, c := range plan.Changes {
+		switch c.Method {
+		case "PATCH":
+			if c.Resource != last {
+				fmt.Fprintf(&b, "%s %s\n", warnStyle.Render("PATCH"), childStyle.Render(c.Resource))
+				last = c.Resource
+				resources++
+			}
+			old := dimStyle.Render("(unset)")
+			if c.Old != nil {
+				old = planValue(c.Old)
+			}
+			fmt.Fprintf(&b, "  %s: %s → %s\n", propStyle.Render(c.PropertyName()), old, planValue(c.New))
+		case "POST":
+			actions++
+			fmt.Fprintf(&b, "%s %s %s\n  %s\n", errorStyle.Render("POST"), c.Target, dimStyle.Render(c.PropertyName()), planValue(c.New))
+		}
+	}
+	fmt.Fprintf(&b, "Plan: %d changes to %d resources, %d actions\n", plan.Patches(), resources, actions)
+	return b.String()
+}
+
+// planValue renders a plan value as compact JSON
+func planValue(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// formatApplyResults reports each request of an applied plan with the
+// messages the service returned, then how many succeeded
+func formatApplyResults(results []rvfs.ApplyResult) string {
+	var b strings.Builder
+	b.WriteString("\n")
+	failed := 0
+	for _, r := range results {
+		request := r.Method + " " + r.Path
+		if r.Method == "PATCH" {
+			request += fmt.Sprintf(" (%d properties)", len(r.Changes))
+		}
+		switch {
+		case r.Err != nil:
+			failed++
+			fmt.Fprintf(&b, "%s  %s: %v\n", errorStyle.Render("failed  "), request, r.Err)
+		default:
+			fmt.Fprintf(&b, "%s  %s\n", healthOKStyle.Render(fmt.Sprintf("HTTP %d", r.Response.StatusCode)), request)
+		}
+		if r.Response != nil {
+			for _, m := range r.Response.Messages {
+				writeMessage(&b, m, 2)
+			}
+		}
+	}
+	fmt.Fprintf(&b, "%d succeeded, %d failed", len(results)-failed, failed)
+	return b.String()
+}
+
+// planUndo shows the PATCHes that reverse the last count writes of the
+// session, setting the properties they changed back to their earlier
+// values, to be sent once confirmed. Actions and creates are named and
+// forgotten, as they cannot be reversed, and nothing is sent when a
+// property changed since it was written.
+func planUndo(nav *Navigator, args []string) tea.Msg {
+	count := 1
+	if len(args) > 1 {
+		return commandResultMsg{err: fmt.Errorf("usage: undo [count]")}
+	}
+	if len(args) == 1 {
+		var err error
+		if count, err = strconv.Atoi(args[0]); err != nil || count < 1 {
+			return commandResultMsg{err: fmt.Errorf("usage: undo [count]")}
+		}
+	}
+	if nav.history.Len() == 0 {
+		return commandResultMsg{output: "Nothing to undo"}
+	}
+	if count > nav.history.Len() {
+		return commandResultMsg{err: fmt.Errorf("only %d writes to undo", nav.history.Len())}
+	}
+
+	plan, irreversible := nav.history.Undo(count)
+	var skipped []string
+	for _, w := range irreversible {
+		skipped = append(skipped, warnStyle.Render(fmt.Sprintf("%s %s is an action or a create, which cannot be undone; skipped", w.Method, w.Path)))
+	}
+	if len(plan.Changes) == 0 {
+		nav.history.Undone(count, nil)
+		return commandResultMsg{output: strings.Join(skipped, "\n")}
+	}
+	for _, c := range plan.Changes {
+		if err := nav.writeRefusal(c.Resource); err != nil {
+			return commandResultMsg{err: err}
+		}
+	}
+	stale, err := rvfs.StaleChanges(nav.vfs, plan)
+	if err != nil {
+		return commandResultMsg{err: err}
+	}
+	if len(stale) > 0 {
+		return commandResultMsg{err: staleError("properties changed since they were written; undo would overwrite them", stale)}
+	}
+	return postPlannedMsg{
+		output: strings.Join(append(skipped, formatPlan(plan)), "\n"),
+		prompt: "Undo? [y/N]",
+		label:  "Undoing...",
+		run: func() string {
+			results := rvfs.ApplyPlan(nav.vfs, plan, &nav.posts, false)
+			nav.history.Undone(count, results)
+			return formatApplyResults(results)
+		},
+	}
+}
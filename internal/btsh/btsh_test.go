@@ -0,0 +1,363 @@
+package btsh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/rvfs/rvfstest"
+)
+
+// ageText matches the ages printed under listings, which depend on how
+// long the test has run
+var ageText = regexp.MustCompile(`\b\d+[smhd] ago\b`)
+
+// TestGolden renders every resource of the fixture corpus with ls, ll and
+// tree and compares the output with bfsh's golden files, so the shells
+// list the same service the same way. After a deliberate change, rewrite
+// the files from bfsh and make btsh render them too.
+func TestGolden(t *testing.T) {
+	// Listings are as wide as the terminal; the golden files are as wide
+	// as the fallback
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	stdout := os.Stdout
+	os.Stdout = devNull
+	defer func() { os.Stdout = stdout }()
+
+	for _, name := range rvfstest.Mockups() {
+		t.Run(name, func(t *testing.T) {
+			resources := rvfstest.Mockup(name)
+			server := rvfstest.NewServer(resources)
+			defer server.Close()
+			nav := NewNavigator(server.VFS(t))
+			nav.prefetch = false // Children cached in the background would change the marks
+
+			var b strings.Builder
+			for _, path := range slices.Sorted(maps.Keys(resources)) {
+				if path == "/redfish" {
+					continue
+				}
+				if _, err := nav.cd(path); err != nil {
+					t.Fatalf("cd %s failed: %v", path, err)
+				}
+				for _, command := range []struct {
+					name string
+					run  func() (string, error)
+				}{
+					{"ls", func() (string, error) {
+						l, err := nav.ls(rvfs.ListOptions{}, "")
+						return text(nav, l, err)
+					}},
+					{"ll", func() (string, error) {
+						s, err := nav.ll("")
+						return text(nav, s, err)
+					}},
+					{"tree", func() (string, error) { return nav.tree(2) }},
+				} {
+					out, err := command.run()
+					if err != nil {
+						t.Fatalf("%s %s failed: %v", command.name, path, err)
+					}
+					fmt.Fprintf(&b, "==> %s %s\n%s\n\n", command.name, path, ageText.ReplaceAllString(stripAnsi(out), "N ago"))
+				}
+			}
+			file := filepath.Join("..", "bfsh", "testdata", name+".golden")
+			want, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != string(want) {
+				t.Errorf("output differs from bfsh's %s:\n%s", file, lineDiff(string(want), b.String()))
+			}
+		})
+	}
+}
+
+// text renders a command's result as the terminal shows it
+func text[R result](nav *Navigator, r R, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	return r.text(nav)
+}
+
+// lineDiff shows the first line where got differs from want, with the
+// lines before it
+func lineDiff(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := range max(len(wantLines), len(gotLines)) {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			start := max(0, i-3)
+			return fmt.Sprintf("line %d:\n  context: %q\n  want:    %q\n  got:     %q", i+1, gotLines[start:i], w, g)
+		}
+	}
+	return ""
+}
+
+func TestFormatCompletionColumns(t *testing.T) {
+	labels := make([]string, 30)
+	for i := range labels {
+		labels[i] = fmt.Sprintf("m%02d", i+1)
+	}
+
+	// 3 columns of 10 rows; 3 rows at a time, the page holding m26
+	menu, paged := formatCompletionColumns(labels, 25, 20, 3)
+	lines := strings.Split(stripAnsi(menu), "\n")
+	if !paged || len(lines) != 3 || strings.Fields(lines[0])[0] != "m19" || !strings.Contains(menu, "m26") || strings.Contains(menu, "m01") {
+		t.Errorf("paged menu:\n%s", stripAnsi(menu))
+	}
+	if status := stripAnsi(formatCompletionStatus(25, 30, paged, "")); status != "  26/30  PgUp/PgDn" {
+		t.Errorf("paged status = %q", status)
+	}
+
+	// Fits: all of it, without a status
+	if menu, paged := formatCompletionColumns(labels[:6], -1, 20, 3); paged || strings.Count(menu, "\n") != 1 {
+		t.Errorf("short menu:\n%s", stripAnsi(menu))
+	}
+	if status := formatCompletionStatus(2, 6, false, ""); status != "" {
+		t.Errorf("status of a short menu = %q", status)
+	}
+	if status := stripAnsi(formatCompletionStatus(-1, 0, false, "zz")); status != `  nothing holds "zz"` {
+		t.Errorf("status of an empty filter = %q", status)
+	}
+
+	// Wider than the terminal: cut
+	long := strings.Repeat("x", 50)
+	if menu, _ := formatCompletionColumns([]string{long, "y"}, -1, 20, 3); !strings.Contains(menu, strings.Repeat("x", 15)+"…") || strings.Contains(menu, long) {
+		t.Errorf("wide labels:\n%s", stripAnsi(menu))
+	}
+}
+
+func TestFilterCompletions(t *testing.T) {
+	completions := []string{"cd Systems/System.Embedded.1", "cd Systems/BladeA", "cd Systems/bladeB"}
+	if got := filterCompletions(completions, "blade"); !slices.Equal(got, completions[1:]) {
+		t.Errorf("filter blade = %q", got)
+	}
+	// Only the label counts, not the command before it
+	if got := filterCompletions(completions, "cd"); got != nil {
+		t.Errorf("filter cd = %q", got)
+	}
+}
+
+// TestResults checks what ls, ll and grep find through their JSON, the
+// form scripts read with --json
+func TestResults(t *testing.T) {
+	server := rvfstest.NewServer(rvfstest.Mockup("dell"))
+	defer server.Close()
+	nav := NewNavigator(server.VFS(t))
+	if _, err := nav.cd("/redfish/v1/Systems"); err != nil {
+		t.Fatal(err)
+	}
+
+	var l listing
+	decodeResult(t, nav, func() (result, error) { return nav.ls(rvfs.ListOptions{}, "") }, &l)
+	if l.Path != "/redfish/v1/Systems" || len(l.Entries) != 2 {
+		t.Fatalf("ls = %+v", l)
+	}
+	member := l.Entries[1]
+	if member.Name != "System.Embedded.1" || member.Member != 1 || member.Type != "link" || l.FetchedAt.IsZero() {
+		t.Errorf("ls member = %+v", member)
+	}
+
+	// Values are redacted as ll shows them
+	nav.redact = true
+	nav.redaction = rvfs.Redaction{Properties: []string{"SerialNumber"}}
+	if err := nav.redaction.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	var s shown
+	decodeResult(t, nav, func() (result, error) { return nav.ll("System.Embedded.1") }, &s)
+	if s.Path != "/redfish/v1/Systems/System.Embedded.1" || !strings.HasPrefix(s.ODataType, "#ComputerSystem.") || strings.Contains(string(s.Value), "CNIVC0012300AB") {
+		t.Errorf("ll = %+v", s)
+	}
+	decodeResult(t, nav, func() (result, error) { return nav.ll("System.Embedded.1/PowerState") }, &s)
+	if s.Path != "/redfish/v1/Systems/System.Embedded.1/PowerState" || string(s.Value) != `"On"` {
+		t.Errorf("ll PowerState = %s %s", s.Path, s.Value)
+	}
+
+	var m matches
+	decodeResult(t, nav, func() (result, error) { return nav.grep("CNIVC0012300AB") }, &m)
+	if len(m.Matches) != 1 || m.Matches[0].Property != "SerialNumber" || m.Matches[0].Member != 1 || string(m.Matches[0].Value) == `"CNIVC0012300AB"` {
+		t.Errorf("grep = %+v", m)
+	}
+	if got, _ := nav.expandMembers([]string{"%1"}); got[0] != m.Matches[0].Resource {
+		t.Errorf("%%1 after grep = %q", got)
+	}
+}
+
+// decodeResult runs a command and decodes the JSON of its result into v
+func decodeResult(t *testing.T, nav *Navigator, run func() (result, error), v any) {
+	t.Helper()
+	r, err := run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := nav.render(r, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(out), v); err != nil {
+		t.Fatalf("%v in %s", err, out)
+	}
+}
+
+// TestBounded runs commands against a slow service: the command timeout and
+// Ctrl+C abort the command's requests only, and the navigator sends the
+// requests after it through the connection again
+func TestBounded(t *testing.T) {
+	server := rvfstest.NewServer(rvfstest.Mockup("dell"))
+	defer server.Close()
+	nav := NewNavigator(server.VFS(t))
+	conn := nav.vfs
+	m := newModel(&shellState{nav: nav, commandTimeout: 50 * time.Millisecond})
+	s := m.state
+
+	// run runs a bounded command and delivers its message as the program
+	// would, returning the command's own message
+	run := func(cmd tea.Cmd) commandResultMsg {
+		t.Helper()
+		bounded, ok := cmd().(boundedMsg)
+		if !ok {
+			t.Fatal("bounded command did not answer with a boundedMsg")
+		}
+		_, next := m.update(bounded)
+		if nav.vfs != conn {
+			t.Error("navigator still bound to the command's context")
+		}
+		return next().(commandResultMsg)
+	}
+	read := func(path string) func(context.Context) tea.Msg {
+		return func(context.Context) tea.Msg {
+			_, err := nav.vfs.Get(path)
+			return commandResultMsg{err: err}
+		}
+	}
+
+	server.SetLatency(time.Second)
+	msg := run(s.boundedContext(s.commandTimeout, read("/redfish/v1/Systems")))
+	if msg.err == nil || !strings.Contains(msg.err.Error(), "timed out after 50ms") {
+		t.Errorf("timed out command err = %v", msg.err)
+	}
+
+	cmd := s.boundedContext(0, read("/redfish/v1/Chassis"))
+	time.AfterFunc(50*time.Millisecond, s.cancelCommand)
+	if msg := run(cmd); msg.err == nil || msg.err.Error() != "interrupted" {
+		t.Errorf("interrupted command err = %v", msg.err)
+	}
+
+	// What the aborted commands read was not cached as failed
+	server.SetLatency(0)
+	for _, path := range []string{"/redfish/v1/Systems", "/redfish/v1/Chassis"} {
+		if msg := run(s.bounded(func() tea.Msg { _, err := nav.cd(path); return commandResultMsg{err: err} })); msg.err != nil {
+			t.Errorf("cd %s after the abort: %v", path, msg.err)
+		}
+	}
+}
+
+// TestMacros records commands typed at the prompt and plays them back, and
+// checks that a macro cannot play a macro, itself included
+func TestMacros(t *testing.T) {
+	server := rvfstest.NewServer(rvfstest.Mockup("dell"))
+	defer server.Close()
+	nav := NewNavigator(server.VFS(t))
+	macros := NewMacros(t.TempDir())
+	m := newModel(&shellState{nav: nav, history: NewHistory(filepath.Join(t.TempDir(), "history")), macros: macros})
+
+	// enter types line at the prompt and returns what the shell printed
+	// until it was done with it, macros played included
+	enter := func(line string) string {
+		t.Helper()
+		m.input.SetValue(line)
+		var printed []string
+		next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = next.(model)
+		cmds := []tea.Cmd{cmd}
+		for steps := 0; len(cmds) > 0; steps++ {
+			if steps > 1000 {
+				t.Fatalf("%s does not end", line)
+			}
+			cmd, cmds = cmds[0], cmds[1:]
+			if cmd == nil {
+				continue
+			}
+			switch msg := cmd().(type) {
+			case nil:
+			case tea.BatchMsg:
+				cmds = append(cmds, msg...)
+			default:
+				// tea.Sequence and tea.Println send messages of types of
+				// their own: a list of commands, and the line to print
+				v := reflect.ValueOf(msg)
+				switch {
+				case v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeFor[tea.Cmd]():
+					for i := range v.Len() {
+						cmds = append(cmds, v.Index(i).Interface().(tea.Cmd))
+					}
+				case v.Kind() == reflect.Struct && v.FieldByName("messageBody").IsValid():
+					printed = append(printed, v.FieldByName("messageBody").String())
+				default:
+					next, cmd := m.Update(msg)
+					m = next.(model)
+					cmds = append(cmds, cmd)
+				}
+			}
+		}
+		return strings.Join(printed, "\n")
+	}
+
+	enter("record start tour")
+	enter("cd Systems")
+	enter("play tour") // Neither recorded nor played
+	enter("cd System.Embedded.1")
+	if out := enter("record stop"); !strings.Contains(out, "Saved tour (2 commands)") {
+		t.Errorf("record stop printed %q", out)
+	}
+	if lines, err := macros.Load("tour"); err != nil || !slices.Equal(lines, []string{"cd Systems", "cd System.Embedded.1"}) {
+		t.Errorf("tour holds %q, %v", lines, err)
+	}
+
+	enter("cd /redfish/v1")
+	out := enter("play tour")
+	if nav.cwd != "/redfish/v1/Systems/System.Embedded.1" {
+		t.Errorf("cwd after play tour = %s", nav.cwd)
+	}
+	if !strings.Contains(out, "> cd Systems") || !strings.Contains(out, "> cd System.Embedded.1") {
+		t.Errorf("play tour did not echo its commands:\n%s", out)
+	}
+
+	// A macro playing a macro is refused, even as its last command
+	if err := macros.Save("loop", []string{"cd /redfish/v1", "play tour", "play loop"}); err != nil {
+		t.Fatal(err)
+	}
+	out = enter("play loop")
+	if strings.Count(out, "macros cannot play macros") != 2 {
+		t.Errorf("play loop printed:\n%s", out)
+	}
+	if nav.cwd != "/redfish/v1" || m.state.playing != "" {
+		t.Errorf("after play loop cwd = %s, playing %q", nav.cwd, m.state.playing)
+	}
+}
@@ -1,10 +1,12 @@
-package main
+package btsh
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -16,47 +18,80 @@ import (
 
 // executeCommandAsync returns a tea.Cmd that runs the given shell command asynchronously
 func executeCommandAsync(nav *Navigator, cmd string, args []string) tea.Cmd {
+	if pathCommands[cmd] {
+		expanded, err := nav.expandMembers(args)
+		if err != nil {
+			return func() tea.Msg {
+				return commandResultMsg{err: err}
+			}
+		}
+		args = expanded
+	}
+
 	switch cmd {
 	case "cd":
-		target := ""
-		if len(args) > 0 {
-			target = args[0]
-		}
+		target := rvfs.TargetArg(args)
 		return func() tea.Msg {
 			output, err := nav.cd(target)
 			return commandResultMsg{output: output, err: err, newCwd: nav.cwd}
 		}
 
+	case "pushd":
+		target := rvfs.TargetArg(args)
+		return func() tea.Msg {
+			output, err := nav.pushd(target)
+			return commandResultMsg{output: output, err: err, newCwd: nav.cwd}
+		}
+
+	case "popd":
+		return func() tea.Msg {
+			output, err := nav.popd()
+			return commandResultMsg{output: output, err: err, newCwd: nav.cwd}
+		}
+
+	case "dirs":
+		return func() tea.Msg {
+			return commandResultMsg{output: nav.dirs()}
+		}
+
 	case "open":
+		if len(args) > 0 && args[0] == "-n" {
+			target := rvfs.TargetArg(args[1:])
+			return func() tea.Msg {
+				output, err := nav.openPreview(target)
+				return commandResultMsg{output: output, err: err}
+			}
+		}
 		if len(args) == 0 {
 			return func() tea.Msg {
-				return commandResultMsg{err: fmt.Errorf("usage: open <path>")}
+				return commandResultMsg{err: fmt.Errorf("usage: open [-n] <path>")}
 			}
 		}
-		target := args[0]
+		target := rvfs.TargetArg(args)
 		return func() tea.Msg {
 			output, err := nav.open(target)
 			return commandResultMsg{output: output, err: err, newCwd: nav.cwd}
 		}
 
 	case "ls":
-		target := ""
-		if len(args) > 0 {
-			target = strings.Join(args, " ")
+		args, asJSON := jsonFlag(args)
+		opts, target, err := rvfs.ParseListArgs(args)
+		if err != nil {
+			return func() tea.Msg {
+				return commandResultMsg{err: err}
+			}
 		}
 		return func() tea.Msg {
-			output, err := nav.ls(target)
-			return commandResultMsg{output: output, err: err}
+			l, err := nav.ls(opts, target)
+			return nav.rendered(l, asJSON, err)
 		}
 
 	case "ll":
-		target := ""
-		if len(args) > 0 {
-			target = strings.Join(args, " ")
-		}
+		args, asJSON := jsonFlag(args)
+		target := rvfs.TargetArg(args)
 		return func() tea.Msg {
-			output, err := nav.ll(target)
-			return commandResultMsg{output: output, err: err}
+			s, err := nav.ll(target)
+			return nav.rendered(s, asJSON, err)
 		}
 
 	case "pwd":
@@ -64,13 +99,47 @@ func executeCommandAsync(nav *Navigator, cmd string, args []string) tea.Cmd {
 			return commandResultMsg{output: nav.cwd}
 		}
 
+	case "bookmark":
+		return func() tea.Msg {
+			if len(args) > 0 && args[0] == "-d" {
+				if len(args) == 1 {
+					return commandResultMsg{err: fmt.Errorf("usage: bookmark -d <path>")}
+				}
+				output, err := nav.unbookmark(rvfs.TargetArg(args[1:]))
+				return commandResultMsg{output: output, err: err}
+			}
+			output, err := nav.bookmark(rvfs.TargetArg(args))
+			return commandResultMsg{output: output, err: err}
+		}
+
+	case "bookmarks":
+		return func() tea.Msg {
+			return commandResultMsg{output: nav.listBookmarks()}
+		}
+
+	case "workspace":
+		return func() tea.Msg {
+			output, err := nav.workspace(args)
+			return commandResultMsg{output: output, err: err, newCwd: nav.cwd}
+		}
+
 	case "dump":
-		target := ""
-		if len(args) > 0 {
-			target = strings.Join(args, " ")
+		return func() tea.Msg {
+			output, err := nav.dump(args)
+			if err == nil && len(output) > dumpPageBytes {
+				return pageMsg{output: output, notice: warnStyle.Render(fmt.Sprintf("%s of JSON paged (--fields or -n N trims it, -o FILE writes it out)", formatBytes(int64(len(output)))))}
+			}
+			return commandResultMsg{output: output, err: err}
+		}
+
+	case "cat":
+		if len(args) != 1 {
+			return func() tea.Msg {
+				return commandResultMsg{err: fmt.Errorf("usage: cat <property>")}
+			}
 		}
 		return func() tea.Msg {
-			output, err := nav.dump(target)
+			output, err := nav.cat(args[0])
 			return commandResultMsg{output: output, err: err}
 		}
 
@@ -91,31 +160,272 @@ func executeCommandAsync(nav *Navigator, cmd string, args []string) tea.Cmd {
 		return nil
 
 	case "find":
-		if len(args) == 0 {
+		args, asJSON := jsonFlag(args)
+		cached, actions, rest := findFlags(args)
+		if asJSON && (actions || !cached) {
+			return func() tea.Msg {
+				return commandResultMsg{err: errFindUsage}
+			}
+		}
+		if cached && actions && len(rest) <= 1 {
+			pattern := strings.Join(rest, "")
+			return func() tea.Msg {
+				output, err := nav.findActionsCached(pattern)
+				return commandResultMsg{output: output, err: err}
+			}
+		}
+		if cached && !actions && len(rest) == 1 {
+			pattern := rest[0]
+			return func() tea.Msg {
+				m, err := nav.findCached(pattern)
+				return nav.rendered(m, asJSON, err)
+			}
+		}
+		if len(args) == 0 || cached || actions && len(rest) > 1 {
 			return func() tea.Msg {
-				return commandResultMsg{err: fmt.Errorf("usage: find <pattern>")}
+				return commandResultMsg{err: errFindUsage}
 			}
 		}
 		// Find is handled as a stepped operation (like scrape)
 		// so it needs access to state — handled in handleReadyKey
 		return nil
 
-	case "refresh":
-		target := ""
-		if len(args) > 0 {
-			target = args[0]
+	case "grep":
+		args, asJSON := jsonFlag(args)
+		if len(args) == 0 {
+			return func() tea.Msg {
+				return commandResultMsg{err: fmt.Errorf("usage: grep <text>")}
+			}
+		}
+		text := strings.Join(args, " ")
+		return func() tea.Msg {
+			m, err := nav.grep(text)
+			return nav.rendered(m, asJSON, err)
 		}
+
+	case "refresh":
+		target := rvfs.TargetArg(args)
 		return func() tea.Msg {
 			output, err := nav.refresh(target)
 			return commandResultMsg{output: output, err: err}
 		}
 
+	case "download":
+		if len(args) != 2 {
+			return func() tea.Msg {
+				return commandResultMsg{err: fmt.Errorf("usage: download <path> <file>")}
+			}
+		}
+		return func() tea.Msg {
+			output, err := nav.download(args[0], args[1])
+			return commandResultMsg{output: output, err: err}
+		}
+
+	case "diag":
+		return func() tea.Msg {
+			output, err := nav.diagCollect(args)
+			return commandResultMsg{output: output, err: err}
+		}
+
+	case "memory":
+		return func() tea.Msg {
+			output, err := nav.memory(args)
+			return commandResultMsg{output: output, err: err}
+		}
+
+	case "cpu":
+		return func() tea.Msg {
+			output, err := nav.cpu(args)
+			return commandResultMsg{output: output, err: err}
+		}
+
+	case "firmware":
+		return func() tea.Msg {
+			output, err := nav.firmware(args)
+			return commandResultMsg{output: output, err: err}
+		}
+
+	case "pcie":
+		return func() tea.Msg {
+			output, err := nav.pcie(args)
+			return commandResultMsg{output: output, err: err}
+		}
+
+	case "topology":
+		return func() tea.Msg {
+			output, err := nav.topology(args)
+			return commandResultMsg{output: output, err: err}
+		}
+
+	case "locate":
+		return func() tea.Msg {
+			output, err := nav.locate(args)
+			return commandResultMsg{output: output, err: err}
+		}
+
+	case "metrics":
+		if len(args) > 0 && args[0] == "define" {
+			return func() tea.Msg {
+				return planDefineMetrics(nav, args[1:])
+			}
+		}
+		return func() tea.Msg {
+			output, err := nav.metrics(args)
+			return commandResultMsg{output: output, err: err}
+		}
+
+	case "stat":
+		target := rvfs.TargetArg(args)
+		return func() tea.Msg {
+			output, err := nav.stat(target)
+			return commandResultMsg{output: output, err: err}
+		}
+
+	case "privileges":
+		target := rvfs.TargetArg(args)
+		return func() tea.Msg {
+			output, err := nav.privileges(target)
+			return commandResultMsg{output: output, err: err}
+		}
+
+	case "watchlist":
+		return func() tea.Msg {
+			output, err := nav.watchlist(args)
+			return commandResultMsg{output: output, err: err}
+		}
+
 	case "cache":
 		return func() tea.Msg {
 			output, err := nav.cache(args)
 			return commandResultMsg{output: output, err: err}
 		}
 
+	case "stats":
+		return func() tea.Msg {
+			if len(args) > 0 && args[0] == "reset" {
+				nav.vfs.Stats().Reset()
+				return commandResultMsg{output: "Stats reset"}
+			}
+			return commandResultMsg{output: formatStats(nav.vfs.Stats().Summary())}
+		}
+
+	case "trace":
+		return func() tea.Msg {
+			output, err := nav.setTrace(args)
+			return commandResultMsg{output: output, err: err}
+		}
+
+	case "redact":
+		return func() tea.Msg {
+			output, err := nav.setRedact(args)
+			return commandResultMsg{output: output, err: err}
+		}
+
+	case "foreach":
+		if len(args) < 3 || args[1] != "!" {
+			return func() tea.Msg {
+				return commandResultMsg{err: fmt.Errorf("usage: foreach <pattern> ! <action> [key=value ...] [--again]")}
+			}
+		}
+		// --again sends POSTs just sent once more
+		again := slices.Contains(args[3:], "--again")
+		params := slices.DeleteFunc(slices.Clone(args[3:]), func(arg string) bool { return arg == "--again" })
+		return func() tea.Msg {
+			plan, body, err := planBulk(nav.vfs, nav.role, nav.cwd, args[0], args[2], params)
+			if err != nil {
+				return postPlannedMsg{err: err}
+			}
+			var targets []string
+			for _, t := range plan {
+				if t.Err == nil {
+					targets = append(targets, t.Target)
+				}
+			}
+			if len(targets) == 0 {
+				return postPlannedMsg{err: fmt.Errorf("no resource matching %s has action %s", args[0], args[2])}
+			}
+			if !again {
+				if err := nav.posts.CheckAll(targets, body); err != nil {
+					return postPlannedMsg{err: fmt.Errorf("%w; repeat it with --again", err)}
+				}
+			}
+			return postPlannedMsg{
+				output: formatBulkPlan(plan, body),
+				prompt: fmt.Sprintf("Run %d POSTs? [y/N]", len(targets)),
+				label:  fmt.Sprintf("Running %d POSTs...", len(targets)),
+				run: func() string {
+					results := nav.posts.PostAll(nav.vfs, targets, body, bulkConcurrency, again)
+					for _, r := range results {
+						nav.history.RecordPost(r.Path, r.Response, r.Err)
+					}
+					return formatBulkResults(results)
+				},
+			}
+		}
+
+	case "apply":
+		a, err := parseApplyArgs(args)
+		if err != nil {
+			return func() tea.Msg {
+				return commandResultMsg{err: err}
+			}
+		}
+		return func() tea.Msg {
+			return planApply(nav, a)
+		}
+
+	case "stage", "unstage":
+		return func() tea.Msg {
+			var output string
+			var err error
+			if cmd == "stage" {
+				output, err = nav.stage(args)
+			} else {
+				output, err = nav.unstage(args)
+			}
+			return commandResultMsg{output: output, err: err}
+		}
+
+	case "staged":
+		return func() tea.Msg {
+			if nav.staging.Len() == 0 {
+				return commandResultMsg{output: "Nothing staged"}
+			}
+			return commandResultMsg{output: formatPlan(nav.staging.Plan())}
+		}
+
+	case "commit":
+		return func() tea.Msg {
+			return planCommit(nav)
+		}
+
+	case "undo":
+		return func() tea.Msg {
+			return planUndo(nav, args)
+		}
+
+	case "create":
+		if len(args) == 0 {
+			return func() tea.Msg {
+				return commandResultMsg{err: fmt.Errorf("usage: create <collection> [key=value ...]")}
+			}
+		}
+		return func() tea.Msg {
+			return planCreate(nav, args[0], args[1:])
+		}
+
+	case "set":
+		return func() tea.Msg {
+			output, err := nav.set(args)
+			return commandResultMsg{output: output, err: err}
+		}
+
+	case "time":
+		// The prefix is stripped in handleReadyKey; a bare time has no command
+		return func() tea.Msg {
+			return commandResultMsg{err: fmt.Errorf("usage: time <command>")}
+		}
+
 	case "clear":
 		// Handled directly in handleReadyKey
 		return nil
@@ -145,7 +455,7 @@ func executeActionCommandAsync(nav *Navigator, cmd string, args []string) tea.Cm
 
 	case "ls":
 		return func() tea.Msg {
-			actions, err := discoverActions(nav)
+			actions, err := discoverActions(nav.vfs, nav.cwd)
 			if err != nil {
 				return commandResultMsg{err: err}
 			}
@@ -154,14 +464,14 @@ func executeActionCommandAsync(nav *Navigator, cmd string, args []string) tea.Cm
 				if action == nil {
 					return commandResultMsg{err: fmt.Errorf("unknown action: %s", args[0])}
 				}
-				return commandResultMsg{output: formatActionList([]ActionInfo{*action})}
+				return commandResultMsg{output: formatActionList([]ActionInfo{*action}, nav.writeRefusal(nav.cwd))}
 			}
-			return commandResultMsg{output: formatActionList(actions)}
+			return commandResultMsg{output: formatActionList(actions, nav.writeRefusal(nav.cwd))}
 		}
 
 	case "ll":
 		return func() tea.Msg {
-			actions, err := discoverActions(nav)
+			actions, err := discoverActions(nav.vfs, nav.cwd)
 			if err != nil {
 				return commandResultMsg{err: err}
 			}
@@ -190,7 +500,7 @@ func executeActionCommandAsync(nav *Navigator, cmd string, args []string) tea.Cm
 	default:
 		// Try to match as action invocation
 		return func() tea.Msg {
-			actions, err := discoverActions(nav)
+			actions, err := discoverActions(nav.vfs, nav.cwd)
 			if err != nil {
 				return commandResultMsg{err: err}
 			}
@@ -198,12 +508,22 @@ func executeActionCommandAsync(nav *Navigator, cmd string, args []string) tea.Cm
 			if action == nil {
 				return commandResultMsg{err: fmt.Errorf("unknown action: %s (type 'help' for commands)", cmd)}
 			}
+			if err := nav.writeRefusal(nav.cwd); err != nil {
+				return commandResultMsg{err: err}
+			}
 
-			// Parse body
+			// Parse body; --again sends an action just sent once more
+			again := slices.Contains(args, "--again")
+			args := slices.DeleteFunc(slices.Clone(args), func(arg string) bool { return arg == "--again" })
 			jsonBody, err := parseActionBody(action, args)
 			if err != nil {
 				return commandResultMsg{err: err}
 			}
+			if !again {
+				if err := nav.posts.Check(action.Target, jsonBody); err != nil {
+					return commandResultMsg{err: fmt.Errorf("%w; repeat it with --again", err)}
+				}
+			}
 
 			// Return confirmation prompt — model will handle ModeConfirm
 			return actionDiscoveredMsg{
@@ -211,6 +531,7 @@ func executeActionCommandAsync(nav *Navigator, cmd string, args []string) tea.Cm
 				output:  formatActionConfirm(action, jsonBody),
 				confirm: true,
 				body:    jsonBody,
+				again:   again,
 			}
 		}
 	}
@@ -230,6 +551,7 @@ func startScrape(state *shellState) tea.Cmd {
 	visited := make(map[string]bool)
 	frontier := []string{nav.cwd}
 	var queue []string
+	skipped := 0
 
 	for len(frontier) > 0 {
 		p := frontier[0]
@@ -239,6 +561,10 @@ func startScrape(state *shellState) tea.Cmd {
 		}
 		visited[p] = true
 
+		if state.scrapePolicy.Skipped(p) {
+			skipped++
+			continue
+		}
 		if !cached[p] {
 			queue = append(queue, p)
 			continue
@@ -266,6 +592,9 @@ func startScrape(state *shellState) tea.Cmd {
 	state.scrapeDone = 0
 	state.scrapeTotal = len(queue)
 	state.scrapeErrors = nil
+	state.scrapeCategories = rvfs.ErrorTally{}
+	state.scrapeSkipped = skipped
+	state.scrapeRecovered = 0
 	state.scrapeCancelled = false
 	state.scrapeStart = time.Now()
 
@@ -284,7 +613,7 @@ func handleScrapeDone(state *shellState, msg scrapeDoneMsg) tea.Cmd {
 
 	// Actually fetch the resource
 	nav := state.nav
-	res, err := nav.vfs.Get(msg.path)
+	res, retries, err := state.scrapePolicy.Fetch(nav.vfs, msg.path)
 
 	// Remove from front of queue
 	if len(state.scrapeQueue) > 0 && state.scrapeQueue[0] == msg.path {
@@ -293,15 +622,24 @@ func handleScrapeDone(state *shellState, msg scrapeDoneMsg) tea.Cmd {
 	state.scrapeDone++
 
 	if err != nil {
-		state.scrapeErrors = append(state.scrapeErrors, fmt.Sprintf("  %s: %s", msg.path, err.Error()))
+		state.scrapeErrors = append(state.scrapeErrors, fmt.Sprintf("  %s [%s]: %s", msg.path, rvfs.Categorize(err), err.Error()))
+		state.scrapeCategories.Add(err)
 	} else {
+		if retries > 0 {
+			state.scrapeRecovered++
+		}
 		// Discover new children
 		for _, child := range res.Children {
-			if !state.scrapeVisited[child.Target] {
-				state.scrapeVisited[child.Target] = true
-				state.scrapeQueue = append(state.scrapeQueue, child.Target)
-				state.scrapeTotal++
+			if state.scrapeVisited[child.Target] {
+				continue
+			}
+			state.scrapeVisited[child.Target] = true
+			if state.scrapePolicy.Skipped(child.Target) {
+				state.scrapeSkipped++
+				continue
 			}
+			state.scrapeQueue = append(state.scrapeQueue, child.Target)
+			state.scrapeTotal++
 		}
 	}
 
@@ -323,20 +661,37 @@ func handleScrapeDone(state *shellState, msg scrapeDoneMsg) tea.Cmd {
 	}
 }
 
-// startFind initiates a stepped find operation
-func startFind(state *shellState, pattern string) (tea.Cmd, error) {
+var errFindUsage = errors.New("usage: find [-c] <pattern> | find -c --json <pattern> | find [-c] --actions [pattern]")
+
+// findFlags splits the leading -c and --actions flags of find from its
+// pattern
+func findFlags(args []string) (cached, actions bool, rest []string) {
+	for len(args) > 0 && (args[0] == "-c" || args[0] == "--actions") {
+		cached = cached || args[0] == "-c"
+		actions = actions || args[0] == "--actions"
+		args = args[1:]
+	}
+	return cached, actions, args
+}
+
+// startFind initiates a stepped find operation: of the properties whose
+// names match pattern or, cataloging actions, of the actions
+func startFind(state *shellState, pattern string, actions bool) (tea.Cmd, error) {
 	re, err := regexp.Compile("(?i)" + pattern)
 	if err != nil {
 		return nil, fmt.Errorf("invalid pattern: %v", err)
 	}
 
-	resolved, err := state.nav.vfs.ResolveTarget(rvfs.RedfishRoot, state.nav.cwd)
+	resolved, err := state.nav.vfs.ResolveTarget(state.nav.vfs.Root(), state.nav.cwd)
 	if err != nil {
 		return nil, err
 	}
 
+	// Matches are numbered for %N from the start of each find
+	state.nav.members = nil
+
 	// For property targets, search synchronously (in-memory, fast)
-	if resolved.Type == rvfs.TargetProperty {
+	if resolved.Type == rvfs.TargetProperty && !actions {
 		var results []string
 		findInProperty(resolved.Property, "", re, &results)
 		if len(results) == 0 {
@@ -344,18 +699,24 @@ func startFind(state *shellState, pattern string) (tea.Cmd, error) {
 				return commandResultMsg{output: fmt.Sprintf("No matches for '%s'", pattern)}
 			}, nil
 		}
-		output := strings.Join(results, "\n")
+		output := strings.Join(state.nav.numberMatches(resolved.ResourcePath, results), "\n")
 		return func() tea.Msg {
 			return commandResultMsg{output: output}
 		}, nil
 	}
 
-	// For resource targets, use stepped BFS
+	// For resource targets, use stepped BFS; actions are cataloged from
+	// the resource holding a property
 	startPath := resolved.ResourcePath
+	if resolved.Type == rvfs.TargetProperty {
+		startPath = resolved.Resource.Path
+	}
 	state.findQueue = []findQueueEntry{{path: startPath, prefix: ""}}
 	state.findVisited = map[string]bool{startPath: true}
 	state.findPattern = re
+	state.findActions = actions
 	state.findResults = 0
+	state.findResources = 0
 	state.findSearched = 0
 	state.findTotal = 1
 	state.findCancelled = false
@@ -403,19 +764,32 @@ func handleFindStep(state *shellState, msg findStepMsg) (string, tea.Cmd) {
 		}
 	}
 
-	// Search all properties in this resource
+	// Search all properties in this resource, or catalog its actions
 	var results []string
-	for _, prop := range resource.Properties {
-		findInProperty(prop, prefix, state.findPattern, &results)
+	var output string
+	if state.findActions {
+		actions := slices.DeleteFunc(rvfs.ResourceActions(resource, nav.vfs), func(a rvfs.Action) bool {
+			return !state.findPattern.MatchString(a.Name)
+		})
+		if len(actions) > 0 {
+			output = strings.TrimSuffix(formatActionCatalog(resource.Path, actions), "\n")
+			state.findResults += len(actions)
+			state.findResources++
+		}
+	} else {
+		for _, prop := range resource.Properties {
+			findInProperty(prop, prefix, state.findPattern, &results)
+		}
+		state.findResults += len(results)
 	}
-	state.findResults += len(results)
 
-	// Enqueue children (respecting depth limit via prefix depth)
+	// Enqueue children (respecting depth limit via prefix depth); a
+	// catalog of actions covers the whole subtree
 	prefixDepth := 0
 	if prefix != "" {
 		prefixDepth = strings.Count(prefix, "/") + 1
 	}
-	if prefixDepth < 5 {
+	if prefixDepth < 5 || state.findActions {
 		for _, child := range resource.Children {
 			if !state.findVisited[child.Target] {
 				state.findVisited[child.Target] = true
@@ -437,9 +811,8 @@ func handleFindStep(state *shellState, msg findStepMsg) (string, tea.Cmd) {
 		state.findResults, state.findSearched, state.findTotal)
 
 	// Format results from this step
-	var output string
 	if len(results) > 0 {
-		output = strings.Join(results, "\n")
+		output = strings.Join(nav.numberMatches(msg.path, results), "\n")
 	}
 
 	// Chain next or finish
@@ -461,6 +834,17 @@ func handleFindStep(state *shellState, msg findStepMsg) (string, tea.Cmd) {
 
 func finishFind(state *shellState) string {
 	elapsed := time.Since(state.findStart)
+	if state.findActions {
+		if state.findCancelled {
+			return fmt.Sprintf("Cancelled: %d actions on %d resources, %d/%d resources searched, %s",
+				state.findResults, state.findResources, state.findSearched, state.findTotal, elapsed.Round(time.Millisecond))
+		}
+		if state.findResults == 0 {
+			return fmt.Sprintf("No actions (%d resources searched, %s)", state.findSearched, elapsed.Round(time.Millisecond))
+		}
+		return fmt.Sprintf("%d actions on %d resources (%d resources searched, %s)",
+			state.findResults, state.findResources, state.findSearched, elapsed.Round(time.Millisecond))
+	}
 	if state.findCancelled {
 		return fmt.Sprintf("Cancelled: %d matches, %d/%d resources searched, %s",
 			state.findResults, state.findSearched, state.findTotal, elapsed.Round(time.Millisecond))
@@ -512,7 +896,7 @@ func startExport(state *shellState, filename string) tea.Cmd {
 			continue
 		}
 		if len(res.RawJSON) > 0 {
-			collected[p] = json.RawMessage(res.RawJSON)
+			collected[p] = json.RawMessage(nav.redacted("", res.RawJSON))
 		}
 		for _, child := range res.Children {
 			if !visited[child.Target] {
@@ -563,7 +947,7 @@ func handleExportStep(state *shellState, msg exportStepMsg) tea.Cmd {
 	} else {
 		// Collect the raw JSON
 		if len(res.RawJSON) > 0 {
-			state.exportCollected[msg.path] = json.RawMessage(res.RawJSON)
+			state.exportCollected[msg.path] = json.RawMessage(nav.redacted("", res.RawJSON))
 		}
 		// Discover new children
 		for _, child := range res.Children {
@@ -651,11 +1035,21 @@ func finishExport(state *shellState) tea.Cmd {
 func finishScrape(state *shellState) tea.Cmd {
 	elapsed := time.Since(state.scrapeStart)
 	var b strings.Builder
+	verb := "Done"
 	if state.scrapeCancelled {
-		fmt.Fprintf(&b, "Cancelled: %d fetched, %d errors, %s", state.scrapeDone, len(state.scrapeErrors), elapsed.Round(time.Millisecond))
-	} else {
-		fmt.Fprintf(&b, "Done: %d fetched, %d errors, %s", state.scrapeDone, len(state.scrapeErrors), elapsed.Round(time.Millisecond))
+		verb = "Cancelled"
+	}
+	fmt.Fprintf(&b, "%s: %d fetched, %d errors", verb, state.scrapeDone, len(state.scrapeErrors))
+	if len(state.scrapeErrors) > 0 {
+		fmt.Fprintf(&b, " (%s)", state.scrapeCategories)
+	}
+	if state.scrapeSkipped > 0 {
+		fmt.Fprintf(&b, ", %d skipped", state.scrapeSkipped)
+	}
+	if state.scrapeRecovered > 0 {
+		fmt.Fprintf(&b, ", %d recovered on retry", state.scrapeRecovered)
 	}
+	fmt.Fprintf(&b, ", %s", elapsed.Round(time.Millisecond))
 	for _, msg := range state.scrapeErrors {
 		b.WriteString("\n")
 		b.WriteString(msg)
@@ -1,22 +1,26 @@
-package main
+package btsh
 
 import (
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/bluefish-project/bluefish/plugin"
 	"github.com/bluefish-project/bluefish/rvfs"
 )
 
 // commands that take a path argument
 var pathCommands = map[string]bool{
-	"cd": true, "ls": true, "ll": true, "dump": true, "open": true, "refresh": true,
+	"cd": true, "pushd": true, "ls": true, "ll": true, "dump": true, "cat": true, "open": true, "refresh": true,
+	"stat": true, "privileges": true, "download": true, "bookmark": true, "pcie": true, "topology": true, "memory": true, "cpu": true, "unstage": true,
 }
 
 // all commands for command-position completion
 var allCommands = []string{
-	"cd", "ls", "ll", "pwd", "dump", "tree", "find", "open",
-	"scrape", "export", "refresh",
-	"cache", "clear", "help", "exit", "quit",
+	"cd", "pushd", "popd", "dirs", "ls", "ll", "pwd", "dump", "cat", "tree", "find", "grep", "open",
+	"scrape", "export", "refresh", "stat", "privileges", "download", "diag", "metrics", "locate", "pcie", "topology", "memory", "cpu", "firmware", "apply", "stage", "staged", "unstage", "commit", "undo", "bookmark", "bookmarks", "workspace", "watchlist", "settings",
+	"cache", "stats", "time", "trace", "redact", "set", "foreach", "create", "record", "play", "clear", "help", "exit", "quit",
 }
 
 // computeSuggestions returns full-line suggestions for the textinput.
@@ -26,6 +30,15 @@ func computeSuggestions(nav *Navigator, line string, actionMode bool) []string {
 		return computeActionSuggestions(nav, line)
 	}
 
+	// time prefixes another command; complete the command that follows
+	if rest, ok := strings.CutPrefix(line, "time "); ok {
+		var suggestions []string
+		for _, s := range computeSuggestions(nav, strings.TrimLeft(rest, " "), false) {
+			suggestions = append(suggestions, "time "+s)
+		}
+		return suggestions
+	}
+
 	words := strings.Fields(line)
 
 	// Command completion
@@ -40,6 +53,11 @@ func computeSuggestions(nav *Navigator, line string, actionMode bool) []string {
 				suggestions = append(suggestions, cmd)
 			}
 		}
+		for _, p := range plugin.Commands() {
+			if strings.HasPrefix(p.Name, prefix) && p.Name != prefix && !slices.Contains(allCommands, p.Name) {
+				suggestions = append(suggestions, p.Name)
+			}
+		}
 		return suggestions
 	}
 
@@ -49,11 +67,73 @@ func computeSuggestions(nav *Navigator, line string, actionMode bool) []string {
 		partial = words[len(words)-1]
 	}
 
-	// Path argument completion
-	if pathCommands[cmd] {
+	// cd - and pushd - offer the recent directories themselves
+	if (cmd == "cd" || cmd == "pushd") && strings.HasPrefix(partial, "-") {
+		linePrefix := line[:len(line)-len(partial)]
+		var suggestions []string
+		for i, dir := range nav.recent {
+			if ref := "-" + strconv.Itoa(i+1); strings.HasPrefix(ref, partial) {
+				suggestions = append(suggestions, linePrefix+dir)
+			}
+		}
+		return suggestions
+	}
+
+	// metrics completes its subcommand, then show the report's path
+	if cmd == "metrics" && (len(words) == 1 || (len(words) == 2 && partial != "")) {
+		var suggestions []string
+		for _, sub := range []string{"define", "list", "show"} {
+			if strings.HasPrefix(sub, partial) && sub != partial {
+				suggestions = append(suggestions, cmd+" "+sub)
+			}
+		}
+		return suggestions
+	}
+
+	// metrics define completes its fields after the Id, and the metric
+	// names of the service's MetricDefinitions
+	if cmd == "metrics" && words[1] == "define" && len(words)-len(strings.Fields(partial)) >= 3 {
+		return completeDefineField(nav, line[:len(line)-len(partial)], partial)
+	}
+
+	// watchlist completes its subcommand, then the path to add or remove
+	if cmd == "watchlist" && (len(words) == 1 || (len(words) == 2 && partial != "")) {
+		var suggestions []string
+		for _, sub := range []string{"add", "clear", "rm", "show"} {
+			if strings.HasPrefix(sub, partial) && sub != partial {
+				suggestions = append(suggestions, cmd+" "+sub)
+			}
+		}
+		return suggestions
+	}
+
+	// locate completes on or off, then the path
+	if cmd == "locate" && (len(words) == 1 || (len(words) == 2 && partial != "")) {
+		var suggestions []string
+		for _, state := range []string{"on", "off"} {
+			if strings.HasPrefix(state, partial) && state != partial {
+				suggestions = append(suggestions, cmd+" "+state)
+			}
+		}
+		return suggestions
+	}
+
+	// diag completes its subcommand
+	if cmd == "diag" && (len(words) == 1 || (len(words) == 2 && partial != "")) {
+		if strings.HasPrefix("collect", partial) && partial != "collect" {
+			return []string{cmd + " collect"}
+		}
+		return nil
+	}
+
+	// Path argument completion; foreach, create and stage take a path first, diag
+	// collect the log service, locate the resource, metrics show the
+	// report and watchlist add and rm the resource
+	if pathCommands[cmd] || ((cmd == "foreach" || cmd == "create" || cmd == "stage") && (len(words) == 1 || (len(words) == 2 && partial != ""))) ||
+		((cmd == "diag" || cmd == "locate" || cmd == "metrics" && words[1] == "show" || cmd == "watchlist" && (words[1] == "add" || words[1] == "rm")) && (len(words) == 2 || (len(words) == 3 && partial != ""))) {
 		completions := completePath(nav, partial)
-		// Build full-line suggestions
-		linePrefix := cmd + " "
+		// Build full-line suggestions, keeping any flags before the path
+		linePrefix := line[:len(line)-len(partial)]
 		var suggestions []string
 		for _, c := range completions {
 			suggestions = append(suggestions, linePrefix+c)
@@ -83,6 +163,52 @@ func computeSuggestions(nav *Navigator, line string, actionMode bool) []string {
 		return suggestions
 	}
 
+	// set completes the setting name, then its value
+	if cmd == "set" {
+		var subs []string
+		switch len(words) - len(strings.Fields(partial)) {
+		case 1:
+			subs = settingNames
+		case 2:
+			subs = []string{"on", "off"}
+		}
+		linePrefix := line[:len(line)-len(partial)]
+		var suggestions []string
+		for _, sub := range subs {
+			if strings.HasPrefix(sub, partial) && sub != partial {
+				suggestions = append(suggestions, linePrefix+sub)
+			}
+		}
+		return suggestions
+	}
+
+	// Plugin commands complete their arguments as they declare
+	if p, ok := plugin.Lookup(cmd); ok && !slices.Contains(allCommands, cmd) {
+		linePrefix := line[:len(line)-len(partial)]
+		choices, path := p.Complete(len(words)-1-len(strings.Fields(partial)), partial)
+		if path {
+			choices = completePath(nav, partial)
+		}
+		var suggestions []string
+		for _, w := range choices {
+			if w != partial {
+				suggestions = append(suggestions, linePrefix+w)
+			}
+		}
+		return suggestions
+	}
+
+	// stats, trace, redact and record argument completion
+	if subs, ok := map[string][]string{"stats": {"reset"}, "trace": {"on", "off"}, "redact": {"on", "off"}, "record": {"start", "stop"}, "settings": {"export", "import"}}[cmd]; ok {
+		var suggestions []string
+		for _, sub := range subs {
+			if strings.HasPrefix(sub, partial) && sub != partial {
+				suggestions = append(suggestions, cmd+" "+sub)
+			}
+		}
+		return suggestions
+	}
+
 	return nil
 }
 
@@ -107,7 +233,7 @@ func completePath(nav *Navigator, partial string) []string {
 	var completions []string
 
 	if base == "" {
-		resolved, err := nav.vfs.ResolveTarget(rvfs.RedfishRoot, nav.cwd)
+		resolved, err := nav.vfs.ResolveTarget(nav.vfs.Root(), nav.cwd)
 		if err != nil {
 			return nil
 		}
@@ -212,7 +338,14 @@ func createEntriesFromProperty(prop *rvfs.Property, separator rune) []*rvfs.Entr
 
 	case rvfs.PropertyArray:
 		if separator == '/' {
-			return nil
+			// Only the elements of a link array have names, their targets'
+			if !prop.IsLinkArray() {
+				return nil
+			}
+			for name := range rvfs.LinkElements(prop) {
+				entries = append(entries, &rvfs.Entry{Name: name, Type: rvfs.EntrySymlink})
+			}
+			return entries
 		}
 		for _, elem := range prop.Elements {
 			name := elem.Name
@@ -231,7 +364,7 @@ func createEntriesFromProperty(prop *rvfs.Property, separator rune) []*rvfs.Entr
 
 // computeActionSuggestions generates suggestions in action mode
 func computeActionSuggestions(nav *Navigator, line string) []string {
-	actions, _ := discoverActions(nav)
+	actions, _ := discoverActions(nav.vfs, nav.cwd)
 
 	words := strings.Fields(line)
 
@@ -316,3 +449,47 @@ func computeActionSuggestions(nav *Navigator, line string) []string {
 	sort.Strings(suggestions)
 	return suggestions
 }
+
+// completeDefineField completes a field of metrics define: its name, or
+// the value of metric, type and actions
+func completeDefineField(nav *Navigator, linePrefix, partial string) []string {
+	key, val, hasValue := strings.Cut(partial, "=")
+	var options []string
+	switch {
+	case !hasValue:
+		options = []string{"metric=", "property=", "type=", "interval=", "actions="}
+		val = partial
+	case key == "metric":
+		catalog, _ := rvfs.MetricCatalog(nav.vfs)
+		for _, m := range catalog {
+			options = append(options, m.ID)
+		}
+	case key == "type":
+		options = rvfs.MetricReportTypes
+	case key == "actions":
+		options = rvfs.MetricReportActions
+	}
+	var suggestions []string
+	for _, opt := range options {
+		if strings.HasPrefix(strings.ToLower(opt), strings.ToLower(val)) && opt != val {
+			if hasValue {
+				opt = key + "=" + opt
+			}
+			suggestions = append(suggestions, linePrefix+opt)
+		}
+	}
+	return suggestions
+}
+
+// filterCompletions returns the completions whose menu labels hold filter,
+// ignoring case, in order
+func filterCompletions(completions []string, filter string) []string {
+	filter = strings.ToLower(filter)
+	var matches []string
+	for _, c := range completions {
+		if strings.Contains(strings.ToLower(completionMenuDisplay(c)), filter) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
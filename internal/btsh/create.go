@@ -0,0 +1,264 @@
+package btsh
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+)
+
+// planCreate prepares the POST adding a member to a collection from
+// key=value fields. Without fields it shows the fields the collection's
+// capabilities ask for.
+func planCreate(nav *Navigator, collection string, args []string) tea.Msg {
+	resolved, err := nav.vfs.ResolveTarget(nav.cwd, collection)
+	if err != nil {
+		return commandResultMsg{err: err}
+	}
+	if resolved.Type != rvfs.TargetResource && resolved.Type != rvfs.TargetLink {
+		return commandResultMsg{err: fmt.Errorf("not a collection: %s", collection)}
+	}
+	capabilities, err := rvfs.CreateCapabilities(nav.vfs, resolved.ResourcePath)
+	if err != nil {
+		return commandResultMsg{err: err}
+	}
+	if len(args) == 0 {
+		return commandResultMsg{output: formatCreateForms(resolved.ResourcePath, capabilities)}
+	}
+	if err := nav.writeRefusal(resolved.ResourcePath); err != nil {
+		return commandResultMsg{err: err}
+	}
+
+	values := make(map[string]string)
+	for _, arg := range args {
+		key, val, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return commandResultMsg{err: fmt.Errorf("invalid argument %q (expected key=value)", arg)}
+		}
+		values[key] = val
+	}
+
+	target := resolved.ResourcePath
+	if len(capabilities) > 0 {
+		c, err := chooseCapability(capabilities, values)
+		if err != nil {
+			return commandResultMsg{err: err}
+		}
+		target = c.TargetCollection
+	}
+	body, err := rvfs.CreateBody(values)
+	if err != nil {
+		return commandResultMsg{err: err}
+	}
+
+	return postPlannedMsg{
+		output: fmt.Sprintf("\n%s %s\n%s\n", errorStyle.Render("POST"), target, body),
+		prompt: "Confirm? [y/N]",
+		label:  "Creating...",
+		run: func() string {
+			resp, err := nav.vfs.Post(target, body)
+			nav.history.RecordPost(target, resp, err)
+			if err != nil {
+				return fmt.Sprintf("Error: %v", err)
+			}
+			nav.vfs.Invalidate(target)
+			return formatCreateResult(nav, resp)
+		},
+	}
+}
+
+// planDefineMetrics prepares the POST creating a MetricReportDefinition:
+// metrics define <id> metric=NAME... [property=URI...] [type=T]
+// [interval=30s] [actions=A,B]. Metric names come from the service's
+// MetricDefinitions, where a unique prefix is enough. Without fields it
+// lists the metrics there are to pick.
+func planDefineMetrics(nav *Navigator, args []string) tea.Msg {
+	collection, err := rvfs.MetricReportDefinitionsPath(nav.vfs)
+	if err != nil {
+		return commandResultMsg{err: err}
+	}
+	catalog, catalogErr := rvfs.MetricCatalog(nav.vfs)
+	if len(args) < 2 {
+		return commandResultMsg{output: formatMetricCatalog(catalog, catalogErr)}
+	}
+	if err := nav.writeRefusal(collection); err != nil {
+		return commandResultMsg{err: err}
+	}
+
+	spec := &rvfs.ReportDefinitionSpec{ID: args[0], Type: "Periodic", Actions: []string{"LogToMetricReportsCollection"}}
+	for _, arg := range args[1:] {
+		key, val, ok := strings.Cut(arg, "=")
+		if !ok || val == "" {
+			return commandResultMsg{err: fmt.Errorf("invalid argument %q (expected key=value)", arg)}
+		}
+		switch key {
+		case "metric":
+			matches := rvfs.MatchMetrics(catalog, val)
+			if len(matches) != 1 {
+				return commandResultMsg{err: fmt.Errorf("%d metrics match %q", len(matches), val)}
+			}
+			spec.Metrics = append(spec.Metrics, matches[0])
+		case "property":
+			spec.Properties = append(spec.Properties, val)
+		case "type":
+			spec.Type = val
+		case "interval":
+			if spec.Interval, err = rvfs.ISODuration(val); err != nil {
+				return commandResultMsg{err: err}
+			}
+		case "actions":
+			spec.Actions = strings.Split(val, ",")
+		default:
+			return commandResultMsg{err: fmt.Errorf("unknown field %q (metric, property, type, interval, actions)", key)}
+		}
+	}
+	body, err := spec.Body()
+	if err != nil {
+		return commandResultMsg{err: err}
+	}
+
+	return postPlannedMsg{
+		output: fmt.Sprintf("\n%s %s\n%s\n", errorStyle.Render("POST"), collection, body),
+		prompt: "Confirm? [y/N]",
+		label:  "Creating...",
+		run: func() string {
+			resp, err := nav.vfs.Post(collection, body)
+			nav.history.RecordPost(collection, resp, err)
+			if err != nil {
+				return fmt.Sprintf("Error: %v", err)
+			}
+			nav.vfs.Invalidate(collection)
+			return formatCreateResult(nav, resp)
+		},
+	}
+}
+
+// formatMetricCatalog lists the metrics a report definition can pick, and
+// how to define one
+func formatMetricCatalog(catalog []*rvfs.Metric, err error) string {
+	var b strings.Builder
+	if err != nil {
+		fmt.Fprintf(&b, "%s\n", dimStyle.Render(err.Error()+"; give metrics as property=URI"))
+	}
+	for _, m := range catalog {
+		fmt.Fprintf(&b, "  %s %-6s %s\n", propStyle.Render(fmt.Sprintf("%-28s", m.ID)), m.Units, dimStyle.Render(strings.Join(m.Properties, ", ")))
+	}
+	b.WriteString(dimStyle.Render("usage: metrics define <id> metric=NAME... [property=URI...] [type=" +
+		strings.Join(rvfs.MetricReportTypes, "|") + "] [interval=30s] [actions=" + strings.Join(rvfs.MetricReportActions, ",") + "]"))
+	return b.String()
+}
+
+// chooseCapability picks the first capability whose required fields are all
+// given and whose allowable values accept them
+func chooseCapability(capabilities []rvfs.CreateCapability, values map[string]string) (*rvfs.CreateCapability, error) {
+	var reasons []string
+	for i := range capabilities {
+		c := &capabilities[i]
+		err := rvfs.CheckFields(c.Fields, values)
+		if err == nil {
+			return c, nil
+		}
+		if len(capabilities) == 1 {
+			return nil, err
+		}
+		reasons = append(reasons, fmt.Sprintf("%s: %v", c.UseCase, err))
+	}
+	return nil, fmt.Errorf("no use case fits:\n  %s", strings.Join(reasons, "\n  "))
+}
+
+// formatCreateForms lists the fields each use case of a collection takes
+func formatCreateForms(collection string, capabilities []rvfs.CreateCapability) string {
+	var b strings.Builder
+	if len(capabilities) == 0 {
+		fmt.Fprintf(&b, "\n%s advertises no create capabilities; give the fields as key=value\n", collection)
+		return b.String()
+	}
+	for _, c := range capabilities {
+		fmt.Fprintf(&b, "\n%s %s\n", boldStyle.Render(c.UseCase), dimStyle.Render("→ "+c.TargetCollection))
+		for _, f := range c.Fields {
+			b.WriteString("  " + fieldLabel(f) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// writeCapabilities lists the use cases a collection offers for creating
+// members, the fields of each, and how full the collection is
+func (n *Navigator) writeCapabilities(b *strings.Builder, collection *rvfs.Resource) {
+	cc := collection.Capabilities
+	for _, c := range cc.Capabilities {
+		fmt.Fprintf(b, "  %s %s\n", boldStyle.Render(c.UseCase), dimStyle.Render("→ "+c.TargetCollection))
+		fields, err := rvfs.CapabilityFields(n.vfs, c)
+		if err != nil {
+			fmt.Fprintf(b, "    %s\n", dimStyle.Render(err.Error()))
+			continue
+		}
+		if len(fields) > 0 {
+			labels := make([]string, len(fields))
+			for i, f := range fields {
+				labels[i] = fieldLabel(f)
+			}
+			fmt.Fprintf(b, "    %s\n", strings.Join(labels, "  "))
+		}
+	}
+	if len(cc.Capabilities) == 0 {
+		fmt.Fprintf(b, "  %s\n", dimStyle.Render("no use cases; new members are not accepted"))
+	}
+	if cc.MaxMembers > 0 {
+		fmt.Fprintf(b, "  %d of %d members\n", rvfs.MemberCount(collection), cc.MaxMembers)
+	}
+}
+
+// fieldLabel renders a create field: bold when required, with its
+// allowable values
+func fieldLabel(f rvfs.CreateField) string {
+	label := f.Name
+	if f.Required {
+		label = boldStyle.Render(f.Name)
+	} else {
+		label += dimStyle.Render(" (optional)")
+	}
+	if len(f.Allowable) > 0 {
+		label += " " + dimStyle.Render("["+strings.Join(f.Allowable, "|")+"]")
+	}
+	return label
+}
+
+// formatCreateResult summarizes a create request: the status and messages,
+// then the identity and status of the new resource
+func formatCreateResult(nav *Navigator, resp *rvfs.Response) string {
+	var b strings.Builder
+	style := healthOKStyle
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		style = errorStyle
+	}
+	fmt.Fprintf(&b, "\n%s\n", style.Render(fmt.Sprintf("HTTP %d", resp.StatusCode)))
+	for _, m := range resp.Messages {
+		writeMessage(&b, m, 2)
+	}
+
+	created := rvfs.CreatedPath(resp)
+	if created == "" {
+		return strings.TrimSuffix(b.String(), "\n")
+	}
+	fmt.Fprintf(&b, "Created %s", childStyle.Render(created))
+	res, err := nav.vfs.Get(created)
+	if err != nil {
+		return b.String()
+	}
+	for _, name := range []string{"Id", "Name"} {
+		if p, ok := res.Properties[name]; ok && p.Type == rvfs.PropertySimple {
+			fmt.Fprintf(&b, "\n  %s: %s", propStyle.Render(name), formatTypedValue(p.Value))
+		}
+	}
+	if status, ok := res.Properties["Status"]; ok && status.Type == rvfs.PropertyObject {
+		for _, name := range []string{"State", "Health"} {
+			if p, ok := status.Children[name]; ok && p.Type == rvfs.PropertySimple {
+				fmt.Fprintf(&b, "\n  %s: %s", propStyle.Render(name), formatHealthValue(name, p.Value))
+			}
+		}
+	}
+	return b.String()
+}
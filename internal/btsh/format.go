@@ -0,0 +1,1435 @@
+package btsh
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bluefish-project/bluefish/display"
+	"github.com/bluefish-project/bluefish/plugin"
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/theme"
+
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/term"
+)
+
+// Styles, set from the configured theme by applyTheme
+var (
+	childStyle      lipgloss.Style
+	linkStyle       lipgloss.Style
+	objectStyle     lipgloss.Style
+	propStyle       lipgloss.Style
+	dimStyle        lipgloss.Style
+	boldStyle       lipgloss.Style
+	warnStyle       lipgloss.Style
+	errorStyle      lipgloss.Style
+	promptPathStyle lipgloss.Style
+	promptActStyle  lipgloss.Style
+
+	// Value styles
+	stringValStyle lipgloss.Style
+	numberValStyle lipgloss.Style
+	trueValStyle   lipgloss.Style
+	falseValStyle  lipgloss.Style
+	nullValStyle   lipgloss.Style
+
+	// Health-semantic styles
+	healthOKStyle       lipgloss.Style
+	healthWarnStyle     lipgloss.Style
+	healthCriticalStyle lipgloss.Style
+
+	// activeTheme highlights dump output and colors views
+	activeTheme *theme.Theme
+)
+
+// applyTheme sets the styles from a theme, including the completion menu
+func applyTheme(t *theme.Theme) {
+	activeTheme = t
+	childStyle = t.Fg(theme.Child).Bold(true)
+	linkStyle = t.Fg(theme.Link)
+	objectStyle = t.Fg(theme.Object)
+	propStyle = t.Fg(theme.Property)
+	dimStyle = t.Fg(theme.Dim)
+	boldStyle = lipgloss.NewStyle().Bold(true)
+	warnStyle = t.Fg(theme.Accent)
+	errorStyle = t.Fg(theme.Error).Bold(true)
+	promptPathStyle = t.Fg(theme.Child).Bold(true)
+	promptActStyle = t.Fg(theme.Error).Bold(true)
+
+	stringValStyle = t.Fg(theme.String)
+	numberValStyle = t.Fg(theme.Number)
+	trueValStyle = t.Fg(theme.True)
+	falseValStyle = t.Fg(theme.False)
+	nullValStyle = t.Fg(theme.Null)
+
+	healthOKStyle = t.Fg(theme.OK)
+	healthWarnStyle = t.Fg(theme.Warning)
+	healthCriticalStyle = t.Fg(theme.Critical).Bold(true)
+
+	compSelectedStyle = t.Fg(theme.Match).Bold(true)
+	compNormalStyle = t.Fg(theme.Dim)
+}
+
+// healthKeys are property names that get semantic coloring
+var healthKeys = map[string]bool{
+	"Health":       true,
+	"HealthRollup": true,
+	"State":        true,
+	"Status":       true,
+}
+
+// formatAgedEntry formats an entry, after the mark of its age bucket when
+// ages are shown
+func (n *Navigator) formatAgedEntry(entry *rvfs.Entry) string {
+	if !n.ages {
+		return formatEntry(entry)
+	}
+	return ageMark(entry.Age(time.Now())) + " " + formatEntry(entry)
+}
+
+// ageMark is the mark of an age bucket
+func ageMark(age rvfs.EntryAge) string {
+	switch age {
+	case rvfs.AgeFresh:
+		return healthOKStyle.Render("●")
+	case rvfs.AgeRecent:
+		return healthWarnStyle.Render("◐")
+	case rvfs.AgeStale:
+		return healthCriticalStyle.Render("○")
+	}
+	return dimStyle.Render("·")
+}
+
+// ageLegend explains the age marks below a listing
+func ageLegend() string {
+	return ageMark(rvfs.AgeFresh) + dimStyle.Render(" <1m  ") +
+		ageMark(rvfs.AgeRecent) + dimStyle.Render(" <1h  ") +
+		ageMark(rvfs.AgeStale) + dimStyle.Render(" older  ") +
+		ageMark(rvfs.AgeUnfetched) + dimStyle.Render(" not fetched")
+}
+
+// actionMark follows resources that expose actions in listings
+func actionMark() string {
+	return warnStyle.Render("⚡")
+}
+
+// linkKind names a link property in long listings: "link" for an
+// @odata.id reference, "uri" for a string named like a URI
+func linkKind(prop *rvfs.Property) string {
+	if prop.URIString {
+		return "uri"
+	}
+	return "link"
+}
+
+func formatEntry(entry *rvfs.Entry) string {
+	if entry.Denied {
+		// Refused to this role: the name alone, marked, as it cannot be entered
+		return errorStyle.Render("⊘ " + entry.Name)
+	}
+	switch entry.Type {
+	case rvfs.EntryLink:
+		if entry.Actions {
+			return childStyle.Render(entry.Name+"/") + actionMark()
+		}
+		return childStyle.Render(entry.Name + "/")
+	case rvfs.EntrySymlink:
+		mark := "@"
+		if entry.URIString {
+			mark = "~"
+		}
+		if entry.LinkTarget != "" {
+			return linkStyle.Render(entry.Name+mark) + dimStyle.Render(" → "+entry.LinkTarget)
+		}
+		return linkStyle.Render(entry.Name + mark)
+	case rvfs.EntryComplex:
+		return objectStyle.Render(entry.Name + "/")
+	case rvfs.EntryArray:
+		return objectStyle.Render(entry.Name + "[]")
+	case rvfs.EntryProperty:
+		return propStyle.Render(entry.Name)
+	default:
+		return entry.Name
+	}
+}
+
+func formatColumns(items []string) string {
+	if len(items) == 0 {
+		return ""
+	}
+
+	width := terminalWidth(100)
+
+	maxLen := 0
+	for _, item := range items {
+		maxLen = max(maxLen, theme.Width(item))
+	}
+
+	colWidth := maxLen + 2
+	numCols := width / colWidth
+	if numCols < 1 {
+		numCols = 1
+	}
+
+	var result strings.Builder
+	for i, item := range items {
+		result.WriteString(item)
+		if (i+1)%numCols == 0 {
+			result.WriteString("\n")
+		} else if i < len(items)-1 {
+			result.WriteString(strings.Repeat(" ", colWidth-theme.Width(item)))
+		}
+	}
+
+	return result.String()
+}
+
+// completionColumns returns how wide completion labels are laid out in
+// width, the widest cut to fit, and how many columns of them fit
+func completionColumns(labels []string, width int) (labelWidth, numCols int) {
+	for _, label := range labels {
+		labelWidth = max(labelWidth, theme.Width(label))
+	}
+	labelWidth = max(1, min(labelWidth, width-4))
+	numCols = max(1, (width-2)/(labelWidth+2)) // -2 for leading indent
+	return labelWidth, numCols
+}
+
+// formatCompletionColumns lays out completion labels in columns that fit
+// width, highlighting the item at selectedIdx (or none if -1). Labels wider
+// than the terminal are cut. Past maxRows rows, only the page of rows
+// holding the highlighted item is laid out; paged reports whether it was.
+func formatCompletionColumns(labels []string, selectedIdx, width, maxRows int) (menu string, paged bool) {
+	if len(labels) == 0 {
+		return "", false
+	}
+	labelWidth, numCols := completionColumns(labels, width)
+	colWidth := labelWidth + 2
+
+	first, last := 0, len(labels)
+	if pageSize := maxRows * numCols; len(labels) > pageSize {
+		first = max(0, selectedIdx) / pageSize * pageSize
+		last = min(len(labels), first+pageSize)
+		paged = true
+	}
+
+	var result strings.Builder
+	for i := first; i < last; i++ {
+		if (i-first)%numCols == 0 {
+			if i > first {
+				result.WriteString("\n")
+			}
+			result.WriteString("  ") // indent
+		}
+
+		// Style the label
+		label := theme.Truncate(labels[i], labelWidth, "…")
+		if i == selectedIdx {
+			result.WriteString(compSelectedStyle.Render(label))
+		} else {
+			result.WriteString(compNormalStyle.Render(label))
+		}
+
+		// Pad to column width (unless last in row or last item)
+		if (i-first+1)%numCols != 0 && i < last-1 {
+			result.WriteString(strings.Repeat(" ", max(0, colWidth-theme.Width(label))))
+		}
+	}
+	return result.String(), paged
+}
+
+// formatCompletionStatus says where the highlighted entry of a paged or
+// filtered menu is, "34/200", and what the filter typed into it is. It is
+// "" for a menu that shows all its entries.
+func formatCompletionStatus(selectedIdx, total int, paged bool, filter string) string {
+	var parts []string
+	switch {
+	case selectedIdx >= 0 && (paged || filter != ""):
+		parts = append(parts, fmt.Sprintf("%d/%d", selectedIdx+1, total))
+	case paged:
+		parts = append(parts, fmt.Sprintf("%d entries", total))
+	}
+	if paged {
+		parts = append(parts, "PgUp/PgDn")
+	}
+	if filter != "" {
+		if total == 0 {
+			parts = append(parts, "nothing holds "+strconv.Quote(filter))
+		} else {
+			parts = append(parts, "filter "+strconv.Quote(filter))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "  " + dimStyle.Render(strings.Join(parts, "  "))
+}
+
+// formatCompletionDescription renders the schema description of the
+// highlighted completion as one dim line under the menu, cut to width
+func formatCompletionDescription(text string, width int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if limit := width - 3; limit > 0 {
+		text = theme.Truncate(text, limit, "…")
+	}
+	return "  " + dimStyle.Render(text)
+}
+
+// terminalWidth returns the width of the terminal, or fallback when stdout
+// is not one
+func terminalWidth(fallback int) int {
+	if fd := int(os.Stdout.Fd()); term.IsTerminal(fd) {
+		if w, _, err := term.GetSize(fd); err == nil {
+			return w
+		}
+	}
+	return fallback
+}
+
+func stripAnsi(text string) string {
+	var result strings.Builder
+	inCode := false
+	for _, ch := range text {
+		if ch == '\033' {
+			inCode = true
+		} else if inCode {
+			if ch == 'm' {
+				inCode = false
+			}
+		} else {
+			result.WriteRune(ch)
+		}
+	}
+	return result.String()
+}
+
+// formatValue renders a simple property value, humanized when enabled
+func formatValue(name string, value any, humanize bool) string {
+	if humanize {
+		if s, ok := rvfs.Humanize(name, value, time.Now()); ok {
+			if _, isNumber := value.(float64); isNumber {
+				return numberValStyle.Render(s)
+			}
+			return stringValStyle.Render(s)
+		}
+	}
+	return formatHealthValue(name, value)
+}
+
+func formatHealthValue(name string, value any) string {
+	if healthKeys[name] {
+		s, ok := value.(string)
+		if ok {
+			upper := strings.ToUpper(s)
+			switch {
+			case upper == "OK" || upper == "ENABLED" || upper == "UP":
+				return healthOKStyle.Render(s)
+			case upper == "WARNING" || upper == "STANDBYOFFLINE" || upper == "STARTING":
+				return healthWarnStyle.Render(s)
+			case upper == "CRITICAL" || upper == "DISABLED" || upper == "ABSENT":
+				return healthCriticalStyle.Render(s)
+			}
+		}
+	}
+	return formatTypedValue(value)
+}
+
+func formatTypedValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return stringValStyle.Render(v)
+	case bool:
+		if v {
+			return trueValStyle.Render("true")
+		}
+		return falseValStyle.Render("false")
+	case float64:
+		if v == float64(int64(v)) {
+			return numberValStyle.Render(fmt.Sprintf("%d", int64(v)))
+		}
+		return numberValStyle.Render(fmt.Sprintf("%g", v))
+	case nil:
+		return nullValStyle.Render("null")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func formatPropertyValue(prop *rvfs.Property) string {
+	switch v := prop.Value.(type) {
+	case string:
+		if len(v) > 60 {
+			return v[:57] + "..."
+		}
+		return v
+	case bool:
+		return fmt.Sprintf("%v", v)
+	case float64:
+		if v == float64(int64(v)) {
+			return fmt.Sprintf("%d", int64(v))
+		}
+		return fmt.Sprintf("%g", v)
+	case nil:
+		return "null"
+	case []byte:
+		return "{...}"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// summaryLine describes the current location: what it contains and the
+// conditions reported for its resource
+func (n *Navigator) summaryLine(entries []*rvfs.Entry) string {
+	line := fmt.Sprintf("%s  (%s)", n.cwd, getEntriesSummary(entries))
+	if target, err := n.vfs.ResolveTarget(n.vfs.Root(), n.cwd); err == nil && target != nil && target.Resource != nil {
+		if conditions := formatConditionsSummary(target.Resource); conditions != "" {
+			line += "  " + conditions
+		}
+	}
+	return line
+}
+
+// maxBannerFirmware bounds the firmware versions the banner lists
+const maxBannerFirmware = 6
+
+// formatBanner renders the summary of the service shown on connect
+func formatBanner(s *rvfs.ServiceSummary) string {
+	var b strings.Builder
+	name := strings.TrimSpace(s.Vendor + " " + s.Model)
+	if name == "" {
+		name = "Redfish service"
+	}
+	b.WriteString(boldStyle.Render(name))
+	if s.RedfishVersion != "" {
+		b.WriteString("  " + dimStyle.Render("Redfish "+s.RedfishVersion))
+	}
+	auth := "no authentication"
+	if s.Auth == rvfs.AuthSession {
+		auth = "session auth"
+	}
+	b.WriteString("  " + dimStyle.Render(auth) + "\n")
+
+	var counts []string
+	for _, c := range []struct {
+		name  string
+		count int
+	}{{"Systems", s.Systems}, {"Chassis", s.Chassis}, {"Managers", s.Managers}} {
+		if c.count >= 0 {
+			counts = append(counts, fmt.Sprintf("%s %d", c.name, c.count))
+		}
+	}
+	if s.Health != "" {
+		counts = append(counts, "Health "+formatHealthValue("Health", s.Health))
+	}
+	if len(counts) > 0 {
+		b.WriteString(strings.Join(counts, "  ") + "\n")
+	}
+
+	for i, fw := range s.Firmware {
+		if i == maxBannerFirmware {
+			b.WriteString(dimStyle.Render(fmt.Sprintf("  ... %d more", len(s.Firmware)-i)) + "\n")
+			break
+		}
+		fmt.Fprintf(&b, "  %-5s %s  %s\n", fw.Kind, fw.Version, dimStyle.Render(fw.Resource))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// formatConditionsSummary counts a resource's conditions, colored by the
+// most severe one
+func formatConditionsSummary(r *rvfs.Resource) string {
+	switch len(r.Conditions) {
+	case 0:
+		return ""
+	case 1:
+		return severityStyle(r.ConditionSeverity()).Render("1 condition")
+	default:
+		return severityStyle(r.ConditionSeverity()).Render(fmt.Sprintf("%d conditions", len(r.Conditions)))
+	}
+}
+
+func getEntriesSummary(entries []*rvfs.Entry) string {
+	children := 0
+	links := 0
+	properties := 0
+	denied := 0
+
+	for _, entry := range entries {
+		if entry.Denied {
+			denied++
+		}
+		switch entry.Type {
+		case rvfs.EntryLink:
+			children++
+		case rvfs.EntrySymlink:
+			links++
+		case rvfs.EntryProperty, rvfs.EntryComplex, rvfs.EntryArray:
+			properties++
+		}
+	}
+
+	var parts []string
+	if children > 0 {
+		parts = append(parts, fmt.Sprintf("%d children", children))
+	}
+	if links > 0 {
+		parts = append(parts, fmt.Sprintf("%d links", links))
+	}
+	if properties > 0 {
+		parts = append(parts, fmt.Sprintf("%d props", properties))
+	}
+	if denied > 0 {
+		parts = append(parts, fmt.Sprintf("%d denied", denied))
+	}
+
+	if len(parts) == 0 {
+		return "empty"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+// arraySummaryLimit is the number of elements ll shows of an array nested in
+// the output; the rest are summarized with a slice to page through them
+const arraySummaryLimit = 20
+
+// showProperty writes a property in YAML-style to a builder. path is the
+// property's full path, used to summarize large arrays; "" shows arrays in full.
+func (n *Navigator) showProperty(b *strings.Builder, prop *rvfs.Property, indent int, isArrayElement bool, path string) {
+	var propertyIndent string
+	if isArrayElement {
+		propertyIndent = ""
+	} else {
+		propertyIndent = strings.Repeat(" ", indent)
+	}
+	childIndent := strings.Repeat(" ", indent+2)
+
+	switch prop.Type {
+	case rvfs.PropertySimple:
+		value := formatValue(prop.Name, prop.Value, n.humanize)
+		if prop.Value != nil && n.redacts(prop.Name) {
+			value = dimStyle.Render(rvfs.RedactMask)
+		}
+		fmt.Fprintf(b, "%s%s: %s\n", propertyIndent, propStyle.Render(prop.Name), value)
+
+	case rvfs.PropertyLink:
+		fmt.Fprintf(b, "%s%s: %s → %s\n", propertyIndent, propStyle.Render(prop.Name), linkStyle.Render(linkKind(prop)), prop.LinkTarget)
+
+	case rvfs.PropertyObject:
+		fmt.Fprintf(b, "%s%s:", propertyIndent, propStyle.Render(prop.Name))
+		if len(prop.Children) == 0 {
+			fmt.Fprintf(b, " %s\n", dimStyle.Render("{}"))
+		} else {
+			fmt.Fprintf(b, " %s\n", dimStyle.Render(fmt.Sprintf("{%d}", len(prop.Children))))
+			keys := make([]string, 0, len(prop.Children))
+			for name := range prop.Children {
+				keys = append(keys, name)
+			}
+			sort.Strings(keys)
+			for _, name := range keys {
+				child := prop.Children[name]
+				n.showProperty(b, child, indent+2, false, n.fieldPath(path, name))
+			}
+		}
+
+	case rvfs.PropertyArray:
+		fmt.Fprintf(b, "%s%s:", propertyIndent, propStyle.Render(prop.Name))
+		if len(prop.Elements) == 0 {
+			fmt.Fprintf(b, " %s\n", dimStyle.Render("[]"))
+		} else {
+			fmt.Fprintf(b, " %s\n", dimStyle.Render(fmt.Sprintf("[%d]", len(prop.Elements))))
+			shown := prop.Elements
+			if path != "" && len(shown) > arraySummaryLimit {
+				shown = shown[:arraySummaryLimit]
+			}
+			for _, elem := range shown {
+				elemPath := ""
+				if path != "" {
+					elemPath = path + elem.Name
+				}
+				if elem.Type == rvfs.PropertyObject && len(elem.Children) > 0 {
+					fmt.Fprintf(b, "%s- ", childIndent)
+					keys := make([]string, 0, len(elem.Children))
+					for name := range elem.Children {
+						keys = append(keys, name)
+					}
+					sort.Strings(keys)
+					for i, name := range keys {
+						child := elem.Children[name]
+						if i == 0 {
+							n.showProperty(b, child, indent+4, true, n.fieldPath(elemPath, name))
+						} else {
+							n.showProperty(b, child, indent+4, false, n.fieldPath(elemPath, name))
+						}
+					}
+				} else {
+					fmt.Fprintf(b, "%s- ", childIndent)
+					switch elem.Type {
+					case rvfs.PropertySimple:
+						if n.redacts(prop.Name) {
+							b.WriteString(dimStyle.Render(rvfs.RedactMask))
+						} else {
+							b.WriteString(formatTypedValue(elem.Value))
+						}
+						b.WriteString("\n")
+					case rvfs.PropertyObject:
+						b.WriteString(dimStyle.Render("{}"))
+						b.WriteString("\n")
+					case rvfs.PropertyLink:
+						fmt.Fprintf(b, "%s → %s\n", linkStyle.Render(linkKind(elem)), elem.LinkTarget)
+					}
+				}
+			}
+			if hidden := len(prop.Elements) - len(shown); hidden > 0 {
+				fmt.Fprintf(b, "%s%s\n", childIndent, dimStyle.Render(fmt.Sprintf("…%d more (use ll %s[%d:] to page)", hidden, path, len(shown))))
+			}
+		}
+	}
+}
+
+// fieldPath returns the path of a field below path, or "" when path is unknown
+func (n *Navigator) fieldPath(path, name string) string {
+	if path == "" {
+		return ""
+	}
+	return n.vfs.Join(path, name)
+}
+
+// view renders a resource by the view registered for its type, when views
+// are on and there is one
+func (n *Navigator) view(res *rvfs.Resource) (string, bool) {
+	if !n.views {
+		return "", false
+	}
+	return display.Render(&display.Context{Theme: activeTheme, Redacts: n.redacts}, res)
+}
+
+// showResource writes a resource in formatted style to a builder
+func (n *Navigator) showResource(b *strings.Builder, path string) error {
+	resource, err := n.vfs.Get(path)
+	if err != nil {
+		return err
+	}
+
+	b.WriteString("\n")
+	b.WriteString(boldStyle.Render(path))
+	if resource.HasActions() {
+		b.WriteString(" " + actionMark())
+	}
+	b.WriteString("\n")
+	if resource.ODataType != "" {
+		fmt.Fprintf(b, "Type: %s\n", resource.ODataType)
+	}
+	if resource.IsPartial() {
+		b.WriteString(dimStyle.Render("Partial view (" + resource.Query + ")"))
+		b.WriteString("\n")
+	}
+
+	// Conditions first: they are why a resource is unhealthy
+	if len(resource.Conditions) > 0 {
+		b.WriteString("\nConditions:\n")
+		for _, c := range resource.Conditions {
+			n.writeCondition(b, c, 2)
+		}
+	}
+
+	if resource.Capabilities != nil {
+		b.WriteString("\nCreate:\n")
+		n.writeCapabilities(b, resource)
+	}
+
+	if len(resource.Messages) > 0 {
+		b.WriteString("\nMessages:\n")
+		for _, m := range resource.Messages {
+			writeMessage(b, m, 2)
+		}
+	}
+
+	// The view of the resource's type, else its properties
+	if view, ok := n.view(resource); ok {
+		b.WriteString("\n")
+		b.WriteString(view)
+	} else if len(resource.Properties) > 0 {
+		b.WriteString("\nProperties:\n")
+		propNames := make([]string, 0, len(resource.Properties))
+		for name := range resource.Properties {
+			propNames = append(propNames, name)
+		}
+		sort.Strings(propNames)
+		for _, name := range propNames {
+			prop := resource.Properties[name]
+			n.showProperty(b, prop, 2, false, n.vfs.Join(path, name))
+		}
+	}
+
+	if len(resource.Children) > 0 {
+		b.WriteString("\nChildren:\n")
+		childNames := make([]string, 0, len(resource.Children))
+		for name := range resource.Children {
+			childNames = append(childNames, name)
+		}
+		sort.Strings(childNames)
+		for _, name := range childNames {
+			child := resource.Children[name]
+			if child.Type == rvfs.ChildLink && n.vfs.Cached(child.Target).HasActions() {
+				fmt.Fprintf(b, "  %s%s → %s\n", childStyle.Render(name+"/"), actionMark(), child.Target)
+			} else if child.Type == rvfs.ChildLink {
+				fmt.Fprintf(b, "  %s → %s\n", childStyle.Render(name+"/"), child.Target)
+			} else {
+				fmt.Fprintf(b, "  %s → %s\n", linkStyle.Render(name+"@"), child.Target)
+			}
+		}
+	}
+
+	return nil
+}
+
+// fetchedAt is when the resource holding a target was fetched, zero when
+// it is not known
+func fetchedAt(target *rvfs.Target) time.Time {
+	if target.Resource == nil {
+		return time.Time{}
+	}
+	return target.Resource.FetchedAt
+}
+
+// formatHelp returns the help text
+func formatHelp() string {
+	cmd := func(s string) string { return linkStyle.Render(s) }
+	arg := func(s string) string { return warnStyle.Render(s) }
+	dim := func(s string) string { return dimStyle.Render(s) }
+
+	var b strings.Builder
+
+	b.WriteString("\n")
+	b.WriteString(boldStyle.Render("Navigation"))
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s %-12s %s    %s %-12s %s\n", cmd("cd"), arg("<path>"), "Navigate to resource/property", cmd("open"), arg("[-n] <path>"), "Follow link to target resource (-n: show the hops only)")
+	fmt.Fprintf(&b, "  %s %-12s %s    %s %-12s %s\n", cmd("pwd"), "", "Print working directory", cmd("ls"), arg("[path]"), "List entries (-t -S -a, children|props|links)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("ll"), arg("[path]"), "Show formatted content (YAML-style)")
+	fmt.Fprintf(&b, "  %s %-12s %s    %s %-12s %s\n", cmd("cd"), arg("-"), "Previous directory (-N: Nth back)", cmd("pushd"), arg("[path]"), "Change directory, saving this one")
+	fmt.Fprintf(&b, "  %s %-12s %s    %s %-12s %s\n", cmd("popd"), "", "Return to the last saved directory", cmd("dirs"), "", "Show the directory stack")
+	fmt.Fprintf(&b, "  %s %-12s %s    %s %-12s %s\n", cmd("bookmark"), arg("[path]"), "Bookmark a resource (-d removes)", cmd("bookmarks"), "", "List bookmarks as %N")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("workspace"), arg("[save|open N]"), "Save or reopen cwd and bookmarks; without args, list them")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("settings"), arg("export|import <f>"), "Share theme, keymap, redaction, settings, bookmarks, watch list and macros as YAML")
+
+	b.WriteString("\n")
+	b.WriteString(boldStyle.Render("Viewing & Search"))
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s %-12s %s    %s %-12s %s\n", cmd("dump"), arg("[path]"), "Show raw JSON (-c compact, -n N elements, --fields A,B, -o file)", cmd("tree"), arg("[depth]"), "Tree view (default: 2)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("cat"), arg("<property>"), "Print a property's bare value, for scripts")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("find"), arg("<pattern>"), "Search properties recursively (-c: cached resources only, instant)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("find"), arg("--actions"), "List every action below cwd with its parameters; a pattern filters names (-c: cached only)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("grep"), arg("<text>"), "Search property values of cached resources")
+
+	b.WriteString("\n")
+	b.WriteString(boldStyle.Render("Fetching"))
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("scrape"), "", "Crawl all reachable resources from cwd")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("export"), arg("[file]"), "Export resources to JSON file")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("refresh"), arg("[path]"), "Re-fetch a resource (invalidate + fetch)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("watchlist"), arg("add|rm <path>"), "Refresh resources every 30s, printing the properties that changed (show, clear)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("download"), arg("<path> <file>"), "Stream a binary payload (e.g. AdditionalDataURI) to a file")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("metrics"), arg("list|show"), "Telemetry reports; show <report> draws sparklines (-t table, -o file.csv)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("metrics"), arg("define <id>"), "Create a MetricReportDefinition: metric=NAME interval=30s ...")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("diag"), arg("collect [path]"), "CollectDiagnosticData, follow its task, download the data (-o file)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("memory"), arg("[path] [--failed]"), "Memory summary and modules of the systems")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("cpu"), arg("[path]"), "Processors and accelerators of the systems")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("firmware"), "", "Firmware inventory and what can be updated")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("pcie"), arg("[path]"), "PCIe devices, functions and what they serve")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("topology"), arg("[chassis]"), "Chassis inside one another, the systems and managers in them")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("locate"), arg("on|off [path]"), "Blink the locator LED of a system, chassis or drive")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("stat"), arg("[path]"), "Methods the service allows (OPTIONS), and whether PATCH/DELETE would pass")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("privileges"), arg("[path]"), "Roles the PrivilegeRegistry lets GET/PATCH/POST/DELETE a resource")
+
+	b.WriteString("\n")
+	b.WriteString(boldStyle.Render("Other"))
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s %-12s %s    %s %-12s %s\n", cmd("!"), "", "Enter action mode (POST)", cmd("cache"), arg("[cmd]"), "Cache ops (clear, list)")
+	fmt.Fprintf(&b, "  %s %-12s %s    %s %-12s %s\n", cmd("stats"), arg("[reset]"), "Request and cache statistics", cmd("time"), arg("<cmd>"), "Time a command (wall/HTTP)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("trace"), arg("[on|off]"), "Print the HTTP requests each command causes")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("redact"), arg("[on|off]"), "Mask the values of the properties the config's redact list names")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("set"), arg("humanize"), "Show sizes, durations and times in human units (on|off)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("set"), arg("ages"), "Mark ls and tree entries by how long ago they were fetched (on|off)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("set"), arg("notify"), "Ring and notify when scrape, export, download or diag ends after 10s (on|off)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("set"), arg("urilinks"), "Let cd follow links inferred from ...Uri strings; off requires open (on|off)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("set"), arg("powerwatch"), "Announce PowerState changes of the system cwd is in (on|off)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("set"), arg("views"), "Render known resource types in ll by their view (on|off)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("set"), arg("prefetch"), "Fetch the children ll's resource type says come next in the background (on|off)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("set"), arg("json"), "Print ls, ll, grep and find -c results as JSON, as --json does (on|off)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("foreach"), arg("<pat> ! <act>"), "Invoke an action on every match, e.g. foreach Systems/* ! Reset")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("apply"), arg("<file.yaml>"), "Show and apply the changes to a desired state (--plan-only, -o plan.json, --again)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("apply"), arg("--plan <f>"), "Apply a saved plan, unless what it changes has changed since")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("stage"), arg("<prop> <val>"), "Stage a property change for commit (staged lists, unstage [path] drops)")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("commit"), "", "Send the staged changes, one PATCH per resource")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("undo"), arg("[count]"), "PATCH back the properties the last writes changed")
+	fmt.Fprintf(&b, "  %s %-12s %s\n", cmd("create"), arg("<coll> [k=v]"), "Create a collection member; without fields, list them")
+	fmt.Fprintf(&b, "  %s %-12s %s    %s %-12s %s\n", cmd("record"), arg("start|stop"), "Record typed commands as a macro", cmd("play"), arg("[name]"), "Run a macro; without a name, list them")
+	fmt.Fprintf(&b, "  %s %-12s %s    %s %s\n", cmd("clear"), "", "Clear screen", cmd("help"), dim("exit/quit"))
+
+	if plugins := plugin.Commands(); len(plugins) > 0 {
+		b.WriteString("\n")
+		b.WriteString(boldStyle.Render("Plugins"))
+		b.WriteString("\n")
+		for _, p := range plugins {
+			fmt.Fprintf(&b, "  %s %-12s %s\n", cmd(p.Name), arg(p.Usage), p.Summary)
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(boldStyle.Render("Paths"))
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s  %s  %s  %s             %s\n",
+		arg("/"), dim("separator"),
+		arg("[n]"), dim("array index"),
+		dim("Systems/1/Status/Health  BootOrder[0]"))
+	fmt.Fprintf(&b, "  %s %s  %s  %s             %s\n",
+		arg(".."), dim("parent"),
+		arg("~"), dim("the service root, e.g. /redfish/v1"),
+		dim("open .  returns to containing resource"))
+	fmt.Fprintf(&b, "  %s  %s  %s\n",
+		arg("?$select=A,B"), dim("OData query ($filter, $top, $skip)"),
+		dim("ls 'Systems/1?$select=Status,PowerState'"))
+	fmt.Fprintf(&b, "  %s  %s  %s\n",
+		arg("%N"), dim("Nth member of the last collection ls"),
+		dim("ls Systems  cd %2"))
+
+	b.WriteString("\n")
+	b.WriteString(boldStyle.Render("Keys"))
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s  %s    %s  %s\n",
+		dim("Tab"), "complete (ghost text)",
+		dim("Up/Down"), "history")
+	fmt.Fprintf(&b, "  %s  %s    %s  %s\n",
+		dim("Ctrl+C"), "clear / exit action mode",
+		dim("Ctrl+D"), "quit")
+
+	b.WriteString("\n")
+	b.WriteString(boldStyle.Render("Display"))
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s  %s  %s  %s  %s\n",
+		childStyle.Render("dir/"), dim("child"),
+		linkStyle.Render("link@"), dim("symlink"),
+		propStyle.Render("prop"))
+	fmt.Fprintf(&b, "  %s  %s  %s  %s\n",
+		objectStyle.Render("obj/"), dim("object"),
+		objectStyle.Render("arr[]"), dim("array"))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// formatActionHelp returns action mode help text
+func formatActionHelp() string {
+	cmd := func(s string) string { return linkStyle.Render(s) }
+	arg := func(s string) string { return warnStyle.Render(s) }
+
+	var b strings.Builder
+
+	b.WriteString("\n")
+	b.WriteString(boldStyle.Render("Action Mode"))
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s %-16s %s\n", cmd("ls"), "", "List available actions")
+	fmt.Fprintf(&b, "  %s %-16s %s\n", cmd("ll"), arg("<action>"), "Show action details and parameters")
+	fmt.Fprintf(&b, "  %s %-16s %s\n", cmd("<action>"), arg("[k=v k:=json]"), "Invoke action (with confirmation); k=v is a string")
+	fmt.Fprintf(&b, "  %s %-16s %s\n", cmd("<action>"), arg("--again ..."), "Send an action just sent again, within seconds")
+	fmt.Fprintf(&b, "  %s %-16s %s\n", cmd("!"), "", "Exit action mode")
+	fmt.Fprintf(&b, "  %s %-16s %s\n", cmd("help"), "", "Show this help")
+	b.WriteString("\n")
+	b.WriteString(boldStyle.Render("Example"))
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  %s\n", warnStyle.Render("Reset ResetType=GracefulShutdown"))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// writeMessage writes a Redfish message colored by severity
+func writeMessage(b *strings.Builder, m rvfs.Message, indent int) {
+	pad := strings.Repeat(" ", indent)
+	b.WriteString(pad + severityStyle(m.Severity).Render(m.String()) + "\n")
+	if m.Resolution != "" {
+		b.WriteString(pad + "  " + dimStyle.Render("Resolution: "+m.Resolution) + "\n")
+	}
+}
+
+// severityStyle returns the style of a message or condition severity
+func severityStyle(severity string) lipgloss.Style {
+	switch strings.ToUpper(severity) {
+	case "OK":
+		return healthOKStyle
+	case "WARNING":
+		return healthWarnStyle
+	case "CRITICAL":
+		return healthCriticalStyle
+	}
+	return boldStyle
+}
+
+// writeCondition renders a Status condition like a message, followed by
+// when it was raised, the resource it originates from and its log entry
+func (n *Navigator) writeCondition(b *strings.Builder, c rvfs.Condition, indent int) {
+	pad := strings.Repeat(" ", indent) + "  "
+	writeMessage(b, c.Message, indent)
+	if c.Timestamp != "" {
+		b.WriteString(pad + dimStyle.Render("Raised: ") + formatValue("Timestamp", c.Timestamp, n.humanize) + "\n")
+	}
+	if c.OriginOfCondition != "" {
+		b.WriteString(pad + dimStyle.Render("Origin: ") + n.originName(c.OriginOfCondition) + "\n")
+	}
+	if c.LogEntry != "" {
+		b.WriteString(pad + dimStyle.Render("Log entry: ") + linkStyle.Render(c.LogEntry) + "\n")
+	}
+}
+
+// originName names the resource a condition originates from, fetching it
+// for its Name; the path alone is shown when it cannot be fetched
+func (n *Navigator) originName(path string) string {
+	res, err := n.vfs.Get(path)
+	if err != nil {
+		return linkStyle.Render(path)
+	}
+	if prop, ok := res.Properties["Name"]; ok {
+		if name, ok := prop.Value.(string); ok && name != "" {
+			return name + " " + dimStyle.Render("→") + " " + linkStyle.Render(path)
+		}
+	}
+	return linkStyle.Render(path)
+}
+
+// statsSlowest is the number of slowest requests shown by stats
+const statsSlowest = 10
+
+// formatStats renders cumulative request statistics
+func formatStats(s rvfs.Summary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s %d HTTP, %s, %s total",
+		boldStyle.Render("Requests:"), s.Requests, formatBytes(s.Bytes), s.HTTPTime.Round(time.Millisecond))
+	if s.Errors > 0 {
+		b.WriteString(", " + errorStyle.Render(fmt.Sprintf("%d errors", s.Errors)))
+	}
+	fmt.Fprintf(&b, "\n%s    %d hits, %d misses (%.1f%% hit ratio)",
+		boldStyle.Render("Cache:"), s.Hits, s.Misses, s.HitRatio()*100)
+
+	if len(s.Endpoints) > 0 {
+		b.WriteString("\n\nEndpoints (by average latency):")
+		for _, e := range s.Endpoints {
+			fmt.Fprintf(&b, "\n  %-36s %5d req  avg %s  max %s  %s",
+				e.Endpoint, e.Requests,
+				warnStyle.Render(fmt.Sprintf("%7s", e.Average().Round(time.Millisecond))),
+				fmt.Sprintf("%7s", e.Max.Round(time.Millisecond)),
+				dimStyle.Render(formatBytes(e.Bytes)))
+		}
+	}
+
+	if len(s.Slowest) > 0 {
+		b.WriteString("\n\nSlowest paths:")
+		for _, r := range s.Slowest[:min(len(s.Slowest), statsSlowest)] {
+			fmt.Fprintf(&b, "\n  %s  %-4s %s %s",
+				warnStyle.Render(fmt.Sprintf("%7s", r.Duration.Round(time.Millisecond))),
+				r.Method, r.Path, formatStatus(r.Status))
+		}
+	}
+	return b.String()
+}
+
+// formatTiming renders the wall and HTTP time of a single command, from
+// its requests still kept; dropped counts those no longer kept
+func formatTiming(elapsed time.Duration, requests []rvfs.Request, dropped int) string {
+	s := rvfs.Summarize(requests)
+	line := fmt.Sprintf("real %s  http %s  (%d requests, %d cached, %s",
+		elapsed.Round(time.Millisecond), s.HTTPTime.Round(time.Millisecond),
+		s.Requests, s.Hits, formatBytes(s.Bytes))
+	if dropped > 0 {
+		line += fmt.Sprintf("; %d earlier not kept", dropped)
+	}
+	return dimStyle.Render(line + ")")
+}
+
+// formatTrace renders the requests a command caused, one per line, after
+// a count of the dropped ones no longer kept. Consecutive cache hits on the
+// same path are collapsed into one line.
+func formatTrace(requests []rvfs.Request, dropped int) string {
+	var lines []string
+	if dropped > 0 {
+		lines = append(lines, dimStyle.Render(fmt.Sprintf("  (%d earlier requests not kept)", dropped)))
+	}
+	for i := 0; i < len(requests); {
+		r := requests[i]
+		n := 1
+		for r.Cached && i+n < len(requests) && requests[i+n].Cached && requests[i+n].Path == r.Path {
+			n++
+		}
+		i += n
+
+		if r.Cached {
+			line := fmt.Sprintf("  %-4s %3s %7s  hit   %s", r.Method, "-", "-", r.Path)
+			if n > 1 {
+				line += fmt.Sprintf(" (×%d)", n)
+			}
+			lines = append(lines, dimStyle.Render(line))
+			continue
+		}
+		cache := "miss"
+		if r.Method != "GET" {
+			cache = ""
+		}
+		lines = append(lines, fmt.Sprintf("  %-4s %s %s  %-4s  %s",
+			r.Method, formatStatus(r.Status),
+			warnStyle.Render(fmt.Sprintf("%7s", r.Duration.Round(time.Millisecond))),
+			cache, r.Path))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatWarnings renders the parser warnings of the resources a command
+// fetched, one per line
+func formatWarnings(requests []rvfs.Request) string {
+	var lines []string
+	for _, r := range requests {
+		for _, w := range r.Warnings {
+			lines = append(lines, healthWarnStyle.Render(fmt.Sprintf("warning: %s: %s", r.Path, w)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatStatus renders an HTTP status, 0 meaning the request never completed
+func formatStatus(status int) string {
+	switch {
+	case status == 0:
+		return errorStyle.Render("failed")
+	case status >= 200 && status < 300:
+		return dimStyle.Render(strconv.Itoa(status))
+	default:
+		return errorStyle.Render(strconv.Itoa(status))
+	}
+}
+
+// formatBytes renders a byte count with a binary unit
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// formatMemory draws the memory of each system: its total and health,
+// then a row per module, only the failed ones when failedOnly is set
+func formatMemory(memory []*rvfs.SystemMemory, failedOnly bool) string {
+	if len(memory) == 0 {
+		return dimStyle.Render("No systems") + "\n"
+	}
+	var b strings.Builder
+	for _, m := range memory {
+		populated, failed := 0, 0
+		for _, module := range m.Modules {
+			if module.Populated() {
+				populated++
+			}
+			if module.Failed() {
+				failed++
+			}
+		}
+		summary := fmt.Sprintf("%d of %d slots populated", populated, len(m.Modules))
+		if m.TotalGiB > 0 {
+			summary = strconv.FormatFloat(m.TotalGiB, 'f', -1, 64) + " GiB, " + summary
+		}
+		if m.PersistentGiB > 0 {
+			summary += ", " + strconv.FormatFloat(m.PersistentGiB, 'f', -1, 64) + " GiB persistent"
+		}
+		if m.Health != "" {
+			summary += ", health " + severityStyle(m.Health).Render(m.Health)
+		}
+		if failed > 0 {
+			summary += ", " + healthCriticalStyle.Render(fmt.Sprintf("%d failed", failed))
+		}
+		fmt.Fprintf(&b, "%s %s\n", childStyle.Render(m.System), summary)
+
+		var rows []*rvfs.MemoryModule
+		for _, module := range m.Modules {
+			if !failedOnly || module.Failed() {
+				rows = append(rows, module)
+			}
+		}
+		if len(rows) == 0 {
+			if failedOnly {
+				b.WriteString(dimStyle.Render("  No failed modules") + "\n")
+			}
+			continue
+		}
+		b.WriteString(dimStyle.Render(fmt.Sprintf("  %-14s %-9s %-9s %-6s %-14s %-20s %s", "Slot", "Capacity", "Speed", "Type", "Manufacturer", "Part number", "Health")) + "\n")
+		for _, module := range rows {
+			if !module.Populated() {
+				fmt.Fprintf(&b, "  %-14s %s\n", module.Slot, dimStyle.Render("absent"))
+				continue
+			}
+			capacity := ""
+			switch {
+			case module.CapacityMiB > 0 && module.CapacityMiB%1024 == 0:
+				capacity = fmt.Sprintf("%d GiB", module.CapacityMiB/1024)
+			case module.CapacityMiB > 0:
+				capacity = fmt.Sprintf("%d MiB", module.CapacityMiB)
+			}
+			speed := ""
+			if module.SpeedMHz > 0 {
+				speed = fmt.Sprintf("%d MHz", module.SpeedMHz)
+			}
+			health := module.Health
+			if module.State != "" && module.State != "Enabled" {
+				health = strings.TrimSpace(health + " " + module.State)
+			}
+			style := severityStyle(module.Health)
+			if module.Failed() {
+				style = healthCriticalStyle
+			}
+			fmt.Fprintf(&b, "  %-14s %-9s %-9s %-6s %-14s %-20s %s\n", module.Slot, capacity, speed, module.Type,
+				module.Manufacturer, module.PartNumber, style.Render(health))
+		}
+	}
+	return b.String()
+}
+
+// formatProcessors draws a table of the CPUs of each system, then one of
+// its accelerators
+func formatProcessors(all []*rvfs.SystemProcessors) string {
+	if len(all) == 0 {
+		return dimStyle.Render("No systems") + "\n"
+	}
+	var b strings.Builder
+	for _, s := range all {
+		var cpus, accelerators []*rvfs.Processor
+		for _, p := range s.Processors {
+			if p.Accelerator() {
+				accelerators = append(accelerators, p)
+			} else {
+				cpus = append(cpus, p)
+			}
+		}
+		fmt.Fprintf(&b, "%s %s\n", childStyle.Render(s.System), dimStyle.Render(fmt.Sprintf("%d CPUs, %d accelerators", len(cpus), len(accelerators))))
+		for _, group := range []struct {
+			title      string
+			processors []*rvfs.Processor
+		}{{"CPUs", cpus}, {"Accelerators", accelerators}} {
+			if len(group.processors) == 0 {
+				continue
+			}
+			b.WriteString("  " + boldStyle.Render(group.title) + "\n")
+			b.WriteString(dimStyle.Render(fmt.Sprintf("  %-10s %-36s %-9s %-15s %-8s %-8s %s", "Socket", "Model", "Cores", "Speed MHz", "Temp", "Power", "Health")) + "\n")
+			for _, p := range group.processors {
+				if p.State == "Absent" {
+					fmt.Fprintf(&b, "  %-10s %s\n", p.Socket, dimStyle.Render("absent"))
+					continue
+				}
+				model := p.Model
+				if model == "" {
+					model = strings.TrimSpace(p.Manufacturer + " " + p.Type)
+				}
+				cores := ""
+				if p.Cores > 0 {
+					cores = fmt.Sprintf("%d/%d", p.Cores, p.Threads)
+				}
+				speed := ""
+				if p.SpeedMHz > 0 || p.MaxSpeedMHz > 0 {
+					speed = fmt.Sprintf("%d/%d", p.SpeedMHz, p.MaxSpeedMHz)
+				}
+				readings := make([]string, 2)
+				for i, name := range []string{"TemperatureCelsius", "PowerWatts"} {
+					if r, ok := p.Reading(name); ok {
+						readings[i], _ = rvfs.Humanize(r.Name, r.Value, time.Now())
+					}
+				}
+				fmt.Fprintf(&b, "  %-10s %-36s %-9s %-15s %-8s %-8s %s\n", p.Socket, model, cores, speed,
+					readings[0], readings[1], severityStyle(p.Health).Render(p.Health))
+			}
+		}
+	}
+	return b.String()
+}
+
+// formatFirmwareInventory draws a row per firmware component, the ones
+// that can be updated highlighted, then how many of them there are
+func formatFirmwareInventory(inventory []*rvfs.SoftwareComponent) string {
+	if len(inventory) == 0 {
+		return dimStyle.Render("No firmware inventory") + "\n"
+	}
+	var b strings.Builder
+	b.WriteString(dimStyle.Render(fmt.Sprintf("%-28s %-20s %-10s %-16s %s", "Name", "Version", "Updateable", "State", "Related")) + "\n")
+	updateable := 0
+	for _, c := range inventory {
+		name := c.Name
+		if name == "" {
+			name = c.ID
+		}
+		flag := dimStyle.Render(fmt.Sprintf("%-10s", "no"))
+		if c.Updateable {
+			updateable++
+			name = childStyle.Render(fmt.Sprintf("%-28s", name))
+			flag = healthOKStyle.Render(fmt.Sprintf("%-10s", "yes"))
+		} else {
+			name = fmt.Sprintf("%-28s", name)
+		}
+		state := strings.TrimSpace(c.State + " " + c.Health)
+		state = severityStyle(c.Health).Render(fmt.Sprintf("%-16s", state))
+		fmt.Fprintf(&b, "%s %-20s %s %s %s\n", name, c.Version, flag, state, dimStyle.Render(strings.Join(c.Related, ", ")))
+	}
+	fmt.Fprintf(&b, "%d of %d components updateable\n", updateable, len(inventory))
+	return b.String()
+}
+
+// formatPCIe draws each PCIe device with its slot, link and firmware, then
+// its functions and what they serve as a tree. A link trained below what
+// the device supports is highlighted.
+func formatPCIe(devices []*rvfs.PCIeDevice) string {
+	if len(devices) == 0 {
+		return dimStyle.Render("No PCIe devices") + "\n"
+	}
+	var b strings.Builder
+	for _, d := range devices {
+		name := d.Name
+		if d.Model != "" && d.Model != d.Name {
+			name += " " + d.Model
+		}
+		fmt.Fprintf(&b, "%s %s\n", childStyle.Render(name), dimStyle.Render(d.Path))
+
+		var details []string
+		if slot := strings.TrimSpace(d.Slot + " " + d.SlotType); slot != "" {
+			details = append(details, slot)
+		}
+		if link := d.Link.String(); link != "" {
+			if supported := d.MaxLink.String(); supported != "" && supported != link {
+				link = warnStyle.Render(link) + " of " + supported
+			}
+			details = append(details, link)
+		} else if supported := d.MaxLink.String(); supported != "" {
+			details = append(details, "up to "+supported)
+		}
+		if d.Manufacturer != "" {
+			details = append(details, d.Manufacturer)
+		}
+		if d.Firmware != "" {
+			details = append(details, "firmware "+d.Firmware)
+		}
+		if len(details) > 0 {
+			b.WriteString("  " + strings.Join(details, ", ") + "\n")
+		}
+
+		branches := len(d.Functions) + len(d.Associations)
+		for i, f := range d.Functions {
+			connector, extension := "├── ", "│   "
+			if i == branches-1 {
+				connector, extension = "└── ", "    "
+			}
+			line := "Function " + f.ID
+			for _, s := range []string{f.Class, f.Type} {
+				if s != "" {
+					line += " " + s
+				}
+			}
+			if f.VendorID != "" || f.DeviceID != "" {
+				line += " " + f.VendorID + ":" + f.DeviceID
+			}
+			fmt.Fprintf(&b, "  %s%s %s\n", connector, line, dimStyle.Render(f.Path))
+			for j, a := range f.Associations {
+				connector := "├── "
+				if j == len(f.Associations)-1 {
+					connector = "└── "
+				}
+				fmt.Fprintf(&b, "  %s%s%s %s\n", extension, connector, propStyle.Render(a.Kind), a.Path)
+			}
+		}
+		for i, a := range d.Associations {
+			connector := "├── "
+			if len(d.Functions)+i == branches-1 {
+				connector = "└── "
+			}
+			fmt.Fprintf(&b, "  %s%s %s\n", connector, propStyle.Render(a.Kind), a.Path)
+		}
+	}
+	return b.String()
+}
+
+// formatTopology draws the containment hierarchy as a tree: each chassis
+// with the chassis, systems and managers inside it, their type, health and
+// the managers managing them
+func formatTopology(nodes []*rvfs.TopologyNode) string {
+	if len(nodes) == 0 {
+		return dimStyle.Render("No chassis, systems or managers") + "\n"
+	}
+	var b strings.Builder
+	for _, node := range nodes {
+		writeTopologyNode(&b, node, "", "")
+	}
+	return b.String()
+}
+
+// writeTopologyNode writes a node after connector and what it contains
+// below it, indented by prefix
+func writeTopologyNode(b *strings.Builder, node *rvfs.TopologyNode, prefix, connector string) {
+	line := propStyle.Render(node.Kind) + " " + childStyle.Render(node.ID)
+	if node.Name != "" && node.Name != node.ID {
+		line += " " + strconv.Quote(node.Name)
+	}
+	if node.Type != "" {
+		line += " (" + node.Type + ")"
+	}
+	if node.Health != "" {
+		line += " " + severityStyle(node.Health).Render(node.Health)
+	}
+	if len(node.ManagedBy) > 0 {
+		line += " managed by " + strings.Join(node.ManagedBy, ", ")
+	}
+	fmt.Fprintf(b, "%s%s%s %s\n", prefix, connector, line, dimStyle.Render(node.Path))
+
+	switch connector {
+	case "├── ":
+		prefix += "│   "
+	case "└── ":
+		prefix += "    "
+	}
+	for i, inner := range node.Contains {
+		if i == len(node.Contains)-1 {
+			writeTopologyNode(b, inner, prefix, "└── ")
+		} else {
+			writeTopologyNode(b, inner, prefix, "├── ")
+		}
+	}
+}
+
+// formatTelemetry lists report definitions, with their schedule and
+// metrics, and the reports generated with their reading counts
+func formatTelemetry(t *rvfs.Telemetry) string {
+	var b strings.Builder
+	b.WriteString(boldStyle.Render("Definitions") + "\n")
+	if len(t.Definitions) == 0 {
+		b.WriteString(dimStyle.Render("  none") + "\n")
+	}
+	for _, d := range t.Definitions {
+		schedule := d.Type
+		if d.Interval != "" {
+			schedule += " every " + d.Interval
+		}
+		fmt.Fprintf(&b, "  %s %-24s %s\n", childStyle.Render(fmt.Sprintf("%-24s", d.ID)), schedule, dimStyle.Render(strings.Join(d.Metrics, ", ")))
+	}
+	b.WriteString(boldStyle.Render("Reports") + "\n")
+	if len(t.Reports) == 0 {
+		b.WriteString(dimStyle.Render("  none") + "\n")
+	}
+	for _, r := range t.Reports {
+		fmt.Fprintf(&b, "  %s %5d readings  %s\n", childStyle.Render(fmt.Sprintf("%-24s", r.ID)), len(r.Readings), dimStyle.Render(r.Timestamp))
+	}
+	return b.String()
+}
+
+// formatMetricReport shows a report as a line per metric: how many
+// readings it has, their range and latest value, and a sparkline of them
+func formatMetricReport(r *rvfs.MetricReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %s\n", boldStyle.Render(r.Path), dimStyle.Render(r.Timestamp))
+	series := r.Series()
+	if len(series) == 0 {
+		b.WriteString(dimStyle.Render("  no readings") + "\n")
+	}
+	for _, s := range series {
+		values := s.Values()
+		last := s.Readings[len(s.Readings)-1].Value
+		if len(values) == 0 {
+			fmt.Fprintf(&b, "  %s %4d  %s\n", propStyle.Render(fmt.Sprintf("%-28s", s.Name())), len(s.Readings), last)
+		} else {
+			fmt.Fprintf(&b, "  %s %4d  %s..%s  last %s  %s\n", propStyle.Render(fmt.Sprintf("%-28s", s.Name())), len(s.Readings),
+				strconv.FormatFloat(slices.Min(values), 'f', -1, 64), strconv.FormatFloat(slices.Max(values), 'f', -1, 64), last,
+				linkStyle.Render(rvfs.Sparkline(values, sparklineWidth)))
+		}
+		if s.MetricID != "" && s.Property != "" {
+			b.WriteString("    " + dimStyle.Render(s.Property) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// formatMetricTable shows every reading of a report, one row each
+func formatMetricTable(r *rvfs.MetricReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-26s %-28s %s\n", "Timestamp", "Metric", "Value")
+	for _, s := range r.Series() {
+		for _, reading := range s.Readings {
+			fmt.Fprintf(&b, "%s %s %s\n", dimStyle.Render(fmt.Sprintf("%-26s", reading.Timestamp)), propStyle.Render(fmt.Sprintf("%-28s", s.Name())), reading.Value)
+		}
+	}
+	return b.String()
+}
+
+// formatActionCatalog lists the actions of a resource under its path, each
+// with its target and parameters
+func formatActionCatalog(path string, actions []rvfs.Action) string {
+	var b strings.Builder
+	b.WriteString(childStyle.Render(path) + "\n")
+	for _, a := range actions {
+		fmt.Fprintf(&b, "  %s → %s\n", errorStyle.Render(a.Name), a.Target)
+		for _, p := range a.Parameters {
+			line := "    " + warnStyle.Render(p.Name)
+			if p.Required {
+				line += errorStyle.Render(" (required)")
+			}
+			if p.DataType != "" {
+				line += "  " + p.DataType
+			}
+			if len(p.Allowable) > 0 {
+				line += "  [" + strings.Join(p.Allowable, "|") + "]"
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// formatWatchChanges reports the properties of watched resources that
+// changed, one line each under the resource
+func formatWatchChanges(changes []rvfs.WatchChange) string {
+	var lines []string
+	for _, c := range changes {
+		lines = append(lines, warnStyle.Render("⟳ ")+childStyle.Render(c.Path))
+		for _, d := range c.Differences {
+			lines = append(lines, fmt.Sprintf("  %s: %s → %s", propStyle.Render(d.Property), watchedValue(d.Old), watchedValue(d.New)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// watchedValue renders a changed property on one line: simple values as
+// ls does, links by target and objects and arrays as JSON
+func watchedValue(p *rvfs.Property) string {
+	if p == nil {
+		return dimStyle.Render("(absent)")
+	}
+	switch p.Type {
+	case rvfs.PropertySimple:
+		return formatPropertyValue(p)
+	case rvfs.PropertyLink:
+		return p.LinkTarget
+	}
+	return string(p.JSON())
+}
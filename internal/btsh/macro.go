@@ -0,0 +1,218 @@
+package btsh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// macroName is what a macro may be called; names become file names
+var macroName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Macros records the commands typed at the prompt as named scripts and
+// loads them for playback. A macro is a text file in dir with one command
+// per line; blank lines and lines starting with # are skipped, so macros
+// can be written or annotated by hand.
+type Macros struct {
+	dir       string
+	recording string   // Name being recorded, "" when not recording
+	lines     []string // Commands recorded so far
+}
+
+// NewMacros creates a macro store keeping its scripts in dir
+func NewMacros(dir string) *Macros {
+	return &Macros{dir: dir}
+}
+
+// Start begins recording the commands typed as name
+func (ms *Macros) Start(name string) error {
+	if ms.recording != "" {
+		return fmt.Errorf("already recording %s", ms.recording)
+	}
+	if !macroName.MatchString(name) {
+		return fmt.Errorf("invalid macro name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	ms.recording = name
+	ms.lines = nil
+	return nil
+}
+
+// Record adds a typed command to the macro being recorded. The commands
+// that record and play macros are not recorded.
+func (ms *Macros) Record(line string) {
+	if ms.recording == "" {
+		return
+	}
+	switch strings.Fields(line)[0] {
+	case "record", "play":
+		return
+	}
+	ms.lines = append(ms.lines, line)
+}
+
+// Stop ends recording and saves the macro, returning its name and how
+// many commands it holds. A macro without commands is not saved.
+func (ms *Macros) Stop() (string, int, error) {
+	if ms.recording == "" {
+		return "", 0, fmt.Errorf("not recording")
+	}
+	name, lines := ms.recording, ms.lines
+	ms.recording, ms.lines = "", nil
+	if len(lines) == 0 {
+		return name, 0, nil
+	}
+	if err := ms.Save(name, lines); err != nil {
+		return name, 0, err
+	}
+	return name, len(lines), nil
+}
+
+// Save writes the commands of a macro, replacing any saved as name
+func (ms *Macros) Save(name string, lines []string) error {
+	if !macroName.MatchString(name) {
+		return fmt.Errorf("invalid macro name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	if err := os.MkdirAll(ms.dir, 0700); err != nil {
+		return err
+	}
+	data := strings.Join(lines, "\n") + "\n"
+	return os.WriteFile(filepath.Join(ms.dir, name), []byte(data), 0600)
+}
+
+// Load returns the commands of a saved macro
+func (ms *Macros) Load(name string) ([]string, error) {
+	if !macroName.MatchString(name) {
+		return nil, fmt.Errorf("invalid macro name %q", name)
+	}
+	data, err := os.ReadFile(filepath.Join(ms.dir, name))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no macro named %s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// Names returns the saved macros in order
+func (ms *Macros) Names() []string {
+	entries, err := os.ReadDir(ms.dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && macroName.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// record runs record start <name>, record stop, and a bare record, which
+// reports what is being recorded
+func (ms *Macros) record(args []string) (string, error) {
+	switch {
+	case len(args) == 2 && args[0] == "start":
+		if err := ms.Start(args[1]); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Recording %s; 'record stop' saves it", args[1]), nil
+	case len(args) == 1 && args[0] == "stop":
+		name, n, err := ms.Stop()
+		if err != nil {
+			return "", err
+		}
+		if n == 0 {
+			return fmt.Sprintf("Nothing recorded; %s not saved", name), nil
+		}
+		return fmt.Sprintf("Saved %s (%d commands); 'play %s' runs it", name, n, name), nil
+	case len(args) == 0:
+		if ms.recording == "" {
+			return "Not recording", nil
+		}
+		return fmt.Sprintf("Recording %s (%d commands so far)", ms.recording, len(ms.lines)), nil
+	}
+	return "", fmt.Errorf("usage: record start <name> | record stop")
+}
+
+// startPlayback queues the commands of a macro. They run one at a time as
+// the shell becomes ready; confirmations and action mode wait for the
+// user, and a failing command stops the playback.
+func (m model) startPlayback(args []string) (string, error) {
+	if len(args) == 0 {
+		names := m.state.macros.Names()
+		if len(names) == 0 {
+			return "No macros; 'record start <name>' records one", nil
+		}
+		return strings.Join(names, "\n"), nil
+	}
+	if len(args) > 1 {
+		return "", fmt.Errorf("usage: play [name]")
+	}
+	if m.state.playing != "" {
+		return "", fmt.Errorf("macros cannot play macros")
+	}
+	lines, err := m.state.macros.Load(args[0])
+	if err != nil {
+		return "", err
+	}
+	if len(lines) == 0 {
+		return fmt.Sprintf("%s has no commands", args[0]), nil
+	}
+	m.state.playing = args[0]
+	m.state.playQueue = lines
+	return fmt.Sprintf("Playing %s (%d commands)", args[0], len(lines)), nil
+}
+
+// schedulePlayback runs the next command of a playing macro once the shell
+// is ready for it, after cmd has printed the output of the last one
+func (m model) schedulePlayback(cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	if m.mode != ModeReady || len(m.state.playQueue) == 0 || m.state.playScheduled {
+		return m, cmd
+	}
+	m.state.playScheduled = true
+	return m, tea.Sequence(cmd, func() tea.Msg { return playNextMsg{} })
+}
+
+// handlePlayNext runs the next command of the playing macro, echoed as if
+// typed. The macro plays until its last command has started, so that one
+// cannot play a macro either.
+func (m model) handlePlayNext() (tea.Model, tea.Cmd) {
+	m.state.playScheduled = false
+	if m.mode != ModeReady || len(m.state.playQueue) == 0 {
+		return m, nil
+	}
+	line := m.state.playQueue[0]
+	m.state.playQueue = m.state.playQueue[1:]
+	echo := promptPathStyle.Render(m.state.nav.cwd) + "> " + line
+	next, cmd := m.runLine(line, echo)
+	if len(m.state.playQueue) == 0 {
+		m.state.playing = ""
+	}
+	return next, cmd
+}
+
+// stopPlayback abandons the rest of a playing macro, returning a note for
+// the output, or "" when none is playing
+func (s *shellState) stopPlayback(why string) string {
+	if s.playing == "" {
+		return ""
+	}
+	note := fmt.Sprintf("%s stopped %s; %d commands not run", s.playing, why, len(s.playQueue))
+	s.playing, s.playQueue = "", nil
+	return warnStyle.Render(note)
+}
@@ -0,0 +1,109 @@
+package btsh
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+)
+
+// boundedMsg carries the message of a command run under its own context,
+// with the connection the navigator goes back to once it is done
+type boundedMsg struct {
+	msg tea.Msg
+	vfs rvfs.VFS
+}
+
+// commandResultMsg is sent when an async command finishes
+type commandResultMsg struct {
+	output string
+	err    error
+	// cd sets this so the model can update cwd
+	newCwd string
+}
+
+// playNextMsg runs the next command of a playing macro
+type playNextMsg struct{}
+
+// describedMsg carries the schema description of a completion
+type describedMsg struct {
+	completion string
+	text       string
+}
+
+// scrapeProgressMsg updates spinner label during scrape
+type scrapeProgressMsg struct {
+	path   string
+	done   int
+	total  int
+	errors int
+}
+
+// scrapeDoneMsg is sent when a single scrape fetch completes
+type scrapeDoneMsg struct {
+	path        string
+	newChildren []string
+	err         error
+}
+
+// findStepMsg triggers the next find search step
+type findStepMsg struct {
+	path string
+}
+
+// actionDiscoveredMsg is sent when action discovery completes.
+// confirm=true means an action invocation needing y/N confirmation;
+// confirm=false means entering action mode (showing available actions).
+type actionDiscoveredMsg struct {
+	actions []ActionInfo
+	output  string
+	err     error
+	confirm bool
+	body    []byte // JSON body for confirm
+	again   bool   // Sent with --again
+}
+
+// postPlannedMsg is sent when a foreach or create has prepared its POSTs
+// and needs y/N confirmation
+type postPlannedMsg struct {
+	output string        // Preview of the POSTs
+	prompt string        // Confirmation question
+	label  string        // Spinner label while the POSTs run
+	run    func() string // Sends the POSTs and returns their report
+	err    error
+}
+
+// exportStepMsg triggers the next export fetch step
+type exportStepMsg struct {
+	path string
+}
+
+// powerTickMsg polls the PowerState of the system cwd is in
+type powerTickMsg struct{}
+
+// powerChangedMsg is sent when the PowerState of the system cwd is in
+// changed from the cached one
+type powerChangedMsg struct {
+	change *rvfs.PowerChange
+}
+
+// watchTickMsg refreshes the resources on the watch list
+type watchTickMsg struct{}
+
+// watchChangedMsg is sent when resources on the watch list changed
+type watchChangedMsg struct {
+	changes []rvfs.WatchChange
+}
+
+// actionResultMsg is sent when a POST action completes
+type actionResultMsg struct {
+	status int
+	body   string
+	err    error
+}
+
+// pageMsg carries output too long for the terminal, shown in the pager
+// before it is reported like a commandResultMsg
+type pageMsg struct {
+	output string
+	notice string // Printed in its place once the pager is left
+}
@@ -0,0 +1,1312 @@
+package btsh
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"github.com/bluefish-project/bluefish/plugin"
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/theme"
+)
+
+// Mode represents the shell state
+type Mode int
+
+const (
+	ModeReady   Mode = iota // Accepting input
+	ModeRunning             // Command executing, spinner visible
+	ModeAction              // Action mode prompt
+	ModeConfirm             // Awaiting y/N for action POST
+	ModePager               // Paging through long output
+)
+
+// notifyCommands are the commands that can run long enough for the user
+// to turn to something else: crawls, exports, downloads and the tasks diag
+// follows
+var notifyCommands = []string{"scrape", "export", "download", "diag"}
+
+// notifyAfter is how long one of notifyCommands runs before its end is
+// announced
+const notifyAfter = 10 * time.Second
+
+// findQueueEntry tracks a resource to search and its display prefix
+type findQueueEntry struct {
+	path   string
+	prefix string
+}
+
+// Completion menu styles, set by applyTheme
+var (
+	compSelectedStyle lipgloss.Style
+	compNormalStyle   lipgloss.Style
+)
+
+// shellState holds mutable state shared between model and program.
+type shellState struct {
+	nav     *Navigator
+	history *History
+	macros  *Macros
+
+	// Macro playback state: the commands of the playing macro not yet run
+	playing       string
+	playQueue     []string
+	playScheduled bool // A playNextMsg is on its way
+
+	// Scrape state
+	scrapeQueue      []string
+	scrapeVisited    map[string]bool
+	scrapeDone       int
+	scrapeTotal      int
+	scrapePolicy     rvfs.ScrapePolicy // How scrape retries failures and which paths it skips
+	scrapeErrors     []string
+	scrapeCategories rvfs.ErrorTally
+	scrapeSkipped    int
+	scrapeRecovered  int // Fetches that succeeded on a retry
+	scrapeCancelled  bool
+	scrapeStart      time.Time
+	spinnerLabel     string
+
+	// Find state
+	findQueue     []findQueueEntry
+	findVisited   map[string]bool
+	findPattern   *regexp.Regexp
+	findActions   bool // Cataloging actions, not matching properties
+	findResults   int
+	findResources int // Resources with actions, when cataloging them
+	findSearched  int
+	findTotal     int
+	findCancelled bool
+	findStart     time.Time
+
+	// Export state
+	exportQueue     []string
+	exportVisited   map[string]bool
+	exportCollected map[string]json.RawMessage
+	exportDone      int
+	exportTotal     int
+	exportErrors    []string
+	exportCancelled bool
+	exportStart     time.Time
+	exportFilename  string
+
+	// Command report state: set when a command starts, reported (time
+	// prefix, trace) when it finishes
+	timed    bool
+	cmdLine  string // Line the command was typed as, "" for a confirmation
+	cmdStart time.Time
+	cmdMark  int
+
+	// How long a command may wait for the service, and the cancel function
+	// Ctrl+C aborts the running command's requests with
+	commandTimeout time.Duration
+	cancelCommand  context.CancelFunc
+
+	// Theme and keymap the shell runs with, for settings export; bfui is
+	// the one to use the keymap
+	theme  theme.Config
+	keymap map[string]map[string][]string
+
+	// Track if we were in action mode before a command
+	inActionMode bool
+
+	// Action confirm state
+	pendingAction *ActionInfo
+	pendingBody   []byte
+	pendingAgain  bool          // Sent with --again, so not held back as a repeat
+	pendingPost   func() string // Confirmed foreach or create to run
+}
+
+// model is the bubbletea model for the inline shell
+type model struct {
+	state   *shellState
+	input   textinput.Model
+	spinner spinner.Model
+	mode    Mode
+
+	// For tracking suggestion updates
+	lastInput string
+
+	// Completion menu state
+	completions   []string // full-line completions matching current input
+	completionIdx int      // -1 = not cycling, 0+ = highlighted index
+	menuFilter    string   // Typed into the open menu: only entries whose labels hold it are shown
+	menuAll       []string // Completions before the filter
+
+	// Schema description of the completion it was looked up for
+	described   string
+	description string
+
+	// Terminal size, for the pager
+	width  int
+	height int
+
+	// Pager state: the output paged and what is printed when it is left
+	pager       viewport.Model
+	pagerNotice string
+}
+
+func newModel(state *shellState) model {
+	ti := textinput.New()
+	ti.Prompt = promptPathStyle.Render(state.nav.cwd) + "> "
+	ti.Focus()
+	ti.CharLimit = 512
+	ti.ShowSuggestions = true
+
+	// We handle Tab/Shift+Tab ourselves for cycling.
+	// Right arrow accepts ghost text via textinput.
+	ti.KeyMap.AcceptSuggestion = key.NewBinding(key.WithKeys("right"))
+	ti.KeyMap.NextSuggestion.SetEnabled(false)
+	ti.KeyMap.PrevSuggestion.SetEnabled(false)
+
+	sp := spinner.New()
+	sp.Spinner = spinner.Dot
+
+	m := model{
+		state:         state,
+		input:         ti,
+		spinner:       sp,
+		mode:          ModeReady,
+		completionIdx: -1,
+	}
+
+	// Initial suggestions
+	m.updateSuggestions()
+
+	return m
+}
+
+func (m model) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, m.spinner.Tick, powerTick(), watchTick())
+}
+
+// powerTick schedules the next poll of the PowerState
+func powerTick() tea.Cmd {
+	return tea.Tick(rvfs.PowerWatchInterval, func(time.Time) tea.Msg {
+		return powerTickMsg{}
+	})
+}
+
+// handlePowerTick polls the PowerState of the system cwd is in while the
+// shell waits for input, so a node power-cycled from elsewhere is noticed
+// before decisions are made on the cached state
+func (m model) handlePowerTick() (tea.Model, tea.Cmd) {
+	nav := m.state.nav
+	if m.mode != ModeReady || !nav.power {
+		return m, powerTick()
+	}
+	cwd, vfs := nav.cwd, nav.vfs
+	return m, tea.Batch(powerTick(), func() tea.Msg {
+		system := rvfs.HostSystem(vfs, cwd)
+		if system == "" {
+			return nil
+		}
+		change, err := nav.powerWatch.Check(vfs, system)
+		if err != nil || change == nil {
+			return nil
+		}
+		return powerChangedMsg{change: change}
+	})
+}
+
+// handlePowerChanged announces a PowerState change and offers the refresh
+// of the system as the next command
+func (m model) handlePowerChanged(msg powerChangedMsg) (tea.Model, tea.Cmd) {
+	notice := warnStyle.Render("⚡ " + msg.change.String())
+	refresh := "refresh " + msg.change.System
+	if m.mode == ModeReady && m.input.Value() == "" {
+		m.input.SetValue(refresh)
+		m.input.CursorEnd()
+		m.updateSuggestions()
+		notice += dimStyle.Render(" (Enter refreshes the cached view)")
+	} else {
+		notice += dimStyle.Render(" (" + refresh + " updates the cached view)")
+	}
+	return m, tea.Println(notice)
+}
+
+// watchTick schedules the next refresh of the watch list
+func watchTick() tea.Cmd {
+	return tea.Tick(rvfs.WatchListInterval, func(time.Time) tea.Msg {
+		return watchTickMsg{}
+	})
+}
+
+// handleWatchTick refreshes the resources on the watch list while the
+// shell waits for input. Refreshes that fail are left for the next tick.
+func (m model) handleWatchTick() (tea.Model, tea.Cmd) {
+	nav := m.state.nav
+	if m.mode != ModeReady || len(nav.watchList.Paths()) == 0 {
+		return m, watchTick()
+	}
+	vfs := nav.vfs
+	return m, tea.Batch(watchTick(), func() tea.Msg {
+		changes, _ := nav.watchList.Check(vfs)
+		if len(changes) == 0 {
+			return nil
+		}
+		return watchChangedMsg{changes: changes}
+	})
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	updated := next.(model)
+	// The prompt changes with cwd and mode, and the width on resize
+	updated.fitInput()
+	return updated.schedulePlayback(cmd)
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+
+	case boundedMsg:
+		// Requests sent from now on are no longer the command's to abort
+		m.state.nav.vfs = msg.vfs
+		return m, func() tea.Msg { return msg.msg }
+
+	case commandResultMsg:
+		return m.handleCommandResult(msg)
+
+	case pageMsg:
+		return m.handlePage(msg)
+
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		if m.mode == ModePager {
+			m.pager.Width, m.pager.Height = msg.Width, msg.Height-1
+		}
+		return m, nil
+
+	case scrapeDoneMsg:
+		return m.handleScrapeDone(msg)
+
+	case findStepMsg:
+		return m.handleFindStep(msg)
+
+	case exportStepMsg:
+		return m.handleExportStep(msg)
+
+	case actionDiscoveredMsg:
+		return m.handleActionDiscovered(msg)
+
+	case postPlannedMsg:
+		return m.handlePostPlanned(msg)
+
+	case playNextMsg:
+		return m.handlePlayNext()
+
+	case describedMsg:
+		m.described, m.description = msg.completion, msg.text
+		return m, nil
+
+	case actionResultMsg:
+		return m.handleActionResult(msg)
+
+	case powerTickMsg:
+		return m.handlePowerTick()
+
+	case powerChangedMsg:
+		return m.handlePowerChanged(msg)
+
+	case watchTickMsg:
+		return m.handleWatchTick()
+
+	case watchChangedMsg:
+		return m, tea.Println(formatWatchChanges(msg.changes))
+
+	case spinner.TickMsg:
+		// Always process spinner ticks so it doesn't stop.
+		// View() only shows the spinner in ModeRunning.
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.mode {
+	case ModeReady:
+		return m.handleReadyKey(msg)
+	case ModeRunning:
+		return m.handleRunningKey(msg)
+	case ModeAction:
+		return m.handleActionKey(msg)
+	case ModeConfirm:
+		return m.handleConfirmKey(msg)
+	case ModePager:
+		return m.handlePagerKey(msg)
+	}
+	return m, nil
+}
+
+func (m model) handleReadyKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m, handled := m.handleMenuKey(msg)
+	if handled {
+		return m, m.describeCompletion()
+	}
+	switch msg.Type {
+	case tea.KeyTab:
+		m = m.handleTab()
+		return m, m.describeCompletion()
+
+	case tea.KeyShiftTab:
+		m = m.handleShiftTab()
+		return m, m.describeCompletion()
+
+	case tea.KeyEscape:
+		if m.completionIdx >= 0 {
+			m.closeMenu()
+		}
+		return m, nil
+
+	case tea.KeyEnter:
+		// If cycling through completions, accept the selection
+		if m.completionIdx >= 0 && m.completionIdx < len(m.completions) {
+			return m.acceptCompletion(), nil
+		}
+
+		line := strings.TrimSpace(m.input.Value())
+		if line == "" {
+			// Empty enter: print blank prompt, scroll down
+			return m, tea.Println(promptPathStyle.Render(m.state.nav.cwd) + "> ")
+		}
+
+		// Echo the command
+		echo := promptPathStyle.Render(m.state.nav.cwd) + "> " + line
+
+		m.state.history.Add(line)
+		m.state.history.Reset()
+		m.state.macros.Record(line)
+		m.input.SetValue("")
+		m.lastInput = ""
+		m.completionIdx = -1
+
+		return m.runLine(line, echo)
+
+	case tea.KeyCtrlL:
+		return m, tea.ClearScreen
+
+	case tea.KeyCtrlC:
+		if m.completionIdx >= 0 {
+			m.closeMenu()
+			return m, nil
+		}
+		if m.input.Value() != "" {
+			m.input.SetValue("")
+			m.lastInput = ""
+			m.updateSuggestions()
+			return m, nil
+		}
+		return m, tea.Quit
+
+	case tea.KeyCtrlD:
+		if m.input.Value() == "" {
+			return m, tea.Quit
+		}
+		// Pass through to textinput
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+
+	case tea.KeyUp:
+		m.completionIdx = -1
+		entry, ok := m.state.history.Up(m.input.Value())
+		if ok {
+			m.input.SetValue(entry)
+			m.input.CursorEnd()
+			m.lastInput = entry
+			m.updateSuggestions()
+		}
+		return m, nil
+
+	case tea.KeyDown:
+		m.completionIdx = -1
+		entry, ok := m.state.history.Down(m.input.Value())
+		if ok {
+			m.input.SetValue(entry)
+			m.input.CursorEnd()
+			m.lastInput = entry
+			m.updateSuggestions()
+		}
+		return m, nil
+
+	default:
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+
+		// Update suggestions if input changed
+		current := m.input.Value()
+		if current != m.lastInput {
+			m.lastInput = current
+			m.updateSuggestions()
+		}
+
+		return m, cmd
+	}
+}
+
+// runLine runs a command line typed at the prompt or played from a macro,
+// printing echo first
+func (m model) runLine(line, echo string) (tea.Model, tea.Cmd) {
+	// time prefix: measure the command and report when it finishes
+	rest, timed := strings.CutPrefix(line, "time ")
+	if timed {
+		line = strings.TrimSpace(rest)
+	}
+	m.state.beginCommand(line, timed)
+
+	// Handle ! to enter action mode
+	if line == "!" {
+		m2, cmd := m.enterActionMode()
+		return m2, tea.Batch(tea.Println(echo), cmd)
+	}
+
+	// Handle scrape specially (needs state)
+	if line == "scrape" {
+		m.mode = ModeRunning
+		m.state.spinnerLabel = "Starting scrape..."
+		cmd := startScrape(m.state)
+		return m, tea.Batch(tea.Println(echo), cmd)
+	}
+
+	// Handle export specially (needs state)
+	if line == "export" || strings.HasPrefix(line, "export ") {
+		filename := ""
+		if strings.HasPrefix(line, "export ") {
+			filename = strings.TrimSpace(line[7:])
+		}
+		m.mode = ModeRunning
+		m.state.spinnerLabel = "Starting export..."
+		cmd := startExport(m.state, filename)
+		return m, tea.Batch(tea.Println(echo), cmd)
+	}
+
+	// Handle clear directly
+	if line == "clear" {
+		m.completionIdx = -1
+		return m, tea.ClearScreen
+	}
+
+	// Handle find specially (stepped operation like scrape); find -c
+	// searches the cache and runs like any other command
+	if strings.HasPrefix(line, "find ") {
+		fields, asJSON := jsonFlag(strings.Fields(line[5:]))
+		cached, actions, rest := findFlags(fields)
+		pattern := strings.TrimSpace(line[5:])
+		if actions {
+			pattern = strings.Join(rest, "")
+		}
+		switch {
+		case cached:
+			// Falls through to executeCommandAsync
+		case asJSON || pattern == "" && !actions || len(rest) > 1 && actions:
+			return m, tea.Batch(tea.Println(echo), tea.Println("Error: "+errFindUsage.Error()))
+		default:
+			cmd, err := startFind(m.state, pattern, actions)
+			if err != nil {
+				return m, tea.Batch(tea.Println(echo), tea.Println(fmt.Sprintf("Error: %v", err)))
+			}
+			m.mode = ModeRunning
+			m.state.spinnerLabel = "Starting search..."
+			return m, tea.Batch(tea.Println(echo), cmd)
+		}
+	}
+
+	// Parse and execute
+	parts := strings.Fields(line)
+	cmd := parts[0]
+	args := parts[1:]
+
+	// Handle record, play and settings directly (need state)
+	if cmd == "record" || cmd == "play" || cmd == "settings" {
+		var output string
+		var err error
+		switch cmd {
+		case "record":
+			output, err = m.state.macros.record(args)
+		case "play":
+			output, err = m.startPlayback(args)
+		case "settings":
+			output, err = m.state.settings(args)
+		}
+		if err != nil {
+			output = fmt.Sprintf("Error: %v", err)
+		}
+		return m, tea.Sequence(tea.Println(echo), tea.Println(output))
+	}
+
+	m.mode = ModeRunning
+	m.state.spinnerLabel = "Running..."
+	if p, ok := plugin.Lookup(cmd); ok && !slices.Contains(allCommands, cmd) {
+		// Its program runs as long as it takes; only Ctrl+C stops it
+		m.state.spinnerLabel = "Running " + cmd + "..."
+		return m, tea.Batch(tea.Println(echo), m.state.boundedContext(0, func(ctx context.Context) tea.Msg {
+			output, err := m.state.nav.runPlugin(ctx, p, args)
+			return commandResultMsg{output: output, err: err}
+		}))
+	}
+	timeout := m.state.commandTimeout
+	if cmd == "diag" {
+		// It waits out a task that takes as long as it takes; only Ctrl+C
+		// stops it
+		timeout = 0
+	}
+	return m, tea.Batch(tea.Println(echo), m.state.boundedBy(timeout, executeCommandAsync(m.state.nav, cmd, args)))
+}
+
+func (m model) handleRunningKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyCtrlC {
+		if m.state.cancelCommand != nil {
+			m.state.cancelCommand()
+		}
+		if len(m.state.scrapeQueue) > 0 {
+			m.state.scrapeCancelled = true
+		}
+		if len(m.state.findQueue) > 0 {
+			m.state.findCancelled = true
+		}
+		if len(m.state.exportQueue) > 0 {
+			m.state.exportCancelled = true
+		}
+		if note := m.state.stopPlayback("by Ctrl+C"); note != "" {
+			return m, tea.Println(note)
+		}
+	}
+	return m, nil
+}
+
+func (m model) handleActionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m, handled := m.handleMenuKey(msg)
+	if handled {
+		return m, nil
+	}
+	switch msg.Type {
+	case tea.KeyTab:
+		return m.handleTab(), nil
+
+	case tea.KeyShiftTab:
+		return m.handleShiftTab(), nil
+
+	case tea.KeyEscape:
+		if m.completionIdx >= 0 {
+			m.closeMenu()
+		}
+		return m, nil
+
+	case tea.KeyEnter:
+		// If cycling through completions, accept the selection
+		if m.completionIdx >= 0 && m.completionIdx < len(m.completions) {
+			return m.acceptCompletion(), nil
+		}
+
+		line := strings.TrimSpace(m.input.Value())
+		if line == "" {
+			return m, tea.Println(promptActStyle.Render("action> "))
+		}
+
+		// Echo
+		echo := promptActStyle.Render("action> ") + line
+
+		m.state.history.Add(line)
+		m.state.history.Reset()
+		m.input.SetValue("")
+		m.lastInput = ""
+		m.completionIdx = -1
+
+		parts := strings.Fields(line)
+		cmd := parts[0]
+		args := parts[1:]
+		m.state.beginCommand(line, false)
+
+		// Exit action mode
+		if cmd == "!" {
+			m.mode = ModeReady
+			m.input.Prompt = promptPathStyle.Render(m.state.nav.cwd) + "> "
+			m.updateSuggestions()
+			return m, tea.Println(echo + "\n" + "Exited action mode")
+		}
+
+		m.mode = ModeRunning
+		m.state.inActionMode = true
+		m.state.spinnerLabel = "Running..."
+		return m, tea.Batch(tea.Println(echo), m.state.bounded(executeActionCommandAsync(m.state.nav, cmd, args)))
+
+	case tea.KeyCtrlL:
+		return m, tea.ClearScreen
+
+	case tea.KeyCtrlC:
+		m.mode = ModeReady
+		m.input.Prompt = promptPathStyle.Render(m.state.nav.cwd) + "> "
+		m.input.SetValue("")
+		m.lastInput = ""
+		m.completionIdx = -1
+		m.updateSuggestions()
+		return m, tea.Println("Exited action mode")
+
+	case tea.KeyCtrlD:
+		m.mode = ModeReady
+		m.input.Prompt = promptPathStyle.Render(m.state.nav.cwd) + "> "
+		m.input.SetValue("")
+		m.lastInput = ""
+		m.completionIdx = -1
+		m.updateSuggestions()
+		return m, tea.Println("Exited action mode")
+
+	case tea.KeyUp:
+		m.completionIdx = -1
+		entry, ok := m.state.history.Up(m.input.Value())
+		if ok {
+			m.input.SetValue(entry)
+			m.input.CursorEnd()
+			m.lastInput = entry
+			m.updateSuggestions()
+		}
+		return m, nil
+
+	case tea.KeyDown:
+		m.completionIdx = -1
+		entry, ok := m.state.history.Down(m.input.Value())
+		if ok {
+			m.input.SetValue(entry)
+			m.input.CursorEnd()
+			m.lastInput = entry
+			m.updateSuggestions()
+		}
+		return m, nil
+
+	default:
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+
+		current := m.input.Value()
+		if current != m.lastInput {
+			m.lastInput = current
+			m.updateSuggestions()
+		}
+
+		return m, cmd
+	}
+}
+
+func (m model) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		if run := m.state.pendingPost; run != nil {
+			m.state.pendingPost = nil
+			m.mode = ModeRunning
+			m.state.beginCommand("", false)
+			return m, m.state.bounded(func() tea.Msg {
+				return commandResultMsg{output: run()}
+			})
+		}
+		action := m.state.pendingAction
+		body := m.state.pendingBody
+		again := m.state.pendingAgain
+		m.mode = ModeRunning
+		m.state.spinnerLabel = "Executing..."
+		target := action.Target
+		nav := m.state.nav
+		m.state.beginCommand("", false)
+		return m, m.state.bounded(func() tea.Msg {
+			resp, err := nav.posts.Post(nav.vfs, target, body, again)
+			nav.history.RecordPost(target, resp, err)
+			var bodyStr string
+			var status int
+			if err == nil {
+				bodyStr = formatActionResult(resp)
+				status = resp.StatusCode
+			}
+			return actionResultMsg{status: status, body: bodyStr, err: err}
+		})
+
+	case "n", "N", "ctrl+c", "escape":
+		if m.state.pendingPost != nil {
+			m.state.pendingPost = nil
+			m.state.spinnerLabel = ""
+			m.mode = ModeReady
+			m.input.Prompt = promptPathStyle.Render(m.state.nav.cwd) + "> "
+			m.input.Focus()
+			return m, tea.Println(joinOutput("Cancelled", m.state.stopPlayback("at a cancelled confirmation")))
+		}
+		m.state.pendingAction = nil
+		m.state.pendingBody = nil
+		m.state.pendingAgain = false
+		m.mode = ModeAction
+		m.input.Prompt = promptActStyle.Render("action> ")
+		m.input.Focus()
+		return m, tea.Println("Cancelled")
+	}
+	return m, nil
+}
+
+func (m model) handleCommandResult(msg commandResultMsg) (tea.Model, tea.Cmd) {
+	var output string
+	if msg.err != nil {
+		// What a command printed before it failed, as a plugin's program
+		output = joinOutput(msg.output, fmt.Sprintf("Error: %v", msg.err), m.state.stopPlayback("after an error"))
+	} else if msg.output != "" {
+		output = msg.output
+	}
+	output = joinOutput(output, m.state.commandReport(), m.state.notification(msg.err))
+
+	// Update cwd if changed (cd, open)
+	if msg.newCwd != "" {
+		m.input.Prompt = promptPathStyle.Render(msg.newCwd) + "> "
+		if m.mode == ModeAction {
+			m.input.Prompt = promptActStyle.Render("action> ")
+		}
+	}
+
+	if m.state.inActionMode {
+		m.mode = ModeAction
+		m.input.Prompt = promptActStyle.Render("action> ")
+		m.state.inActionMode = false
+	} else {
+		m.mode = ModeReady
+		m.input.Prompt = promptPathStyle.Render(m.state.nav.cwd) + "> "
+	}
+	m.input.Focus()
+	m.state.spinnerLabel = ""
+	m.updateSuggestions()
+
+	if output != "" {
+		return m, tea.Println(output)
+	}
+	return m, nil
+}
+
+func (m model) handleScrapeDone(msg scrapeDoneMsg) (tea.Model, tea.Cmd) {
+	cmd := handleScrapeDone(m.state, msg)
+	return m, cmd
+}
+
+func (m model) handleFindStep(msg findStepMsg) (tea.Model, tea.Cmd) {
+	output, cmd := handleFindStep(m.state, msg)
+	if cmd == nil {
+		output = joinOutput(output, m.state.commandReport())
+		// Find finished — clean up and transition back to ready
+		m.state.findQueue = nil
+		m.state.findVisited = nil
+		m.state.findPattern = nil
+		m.mode = ModeReady
+		m.input.Prompt = promptPathStyle.Render(m.state.nav.cwd) + "> "
+		m.input.Focus()
+		m.state.spinnerLabel = ""
+		m.updateSuggestions()
+	}
+	if output != "" {
+		return m, tea.Batch(tea.Println(output), cmd)
+	}
+	return m, cmd
+}
+
+func (m model) handleExportStep(msg exportStepMsg) (tea.Model, tea.Cmd) {
+	cmd := handleExportStep(m.state, msg)
+	if cmd == nil {
+		// Export finished — clean up and transition back to ready
+		m.mode = ModeReady
+		m.input.Prompt = promptPathStyle.Render(m.state.nav.cwd) + "> "
+		m.input.Focus()
+		m.state.spinnerLabel = ""
+		m.updateSuggestions()
+	}
+	return m, cmd
+}
+
+func (m model) handleActionDiscovered(msg actionDiscoveredMsg) (tea.Model, tea.Cmd) {
+	report := m.state.commandReport()
+	if msg.err != nil {
+		m.mode = ModeReady
+		m.input.Prompt = promptPathStyle.Render(m.state.nav.cwd) + "> "
+		m.input.Focus()
+		return m, tea.Println(joinOutput(fmt.Sprintf("Error: %v", msg.err), report))
+	}
+
+	if msg.confirm {
+		// Action invocation needing confirmation
+		output := msg.output
+		if output != "" {
+			output += "\nConfirm? [y/N]"
+		} else {
+			output = "Confirm? [y/N]"
+		}
+		if len(msg.actions) > 0 {
+			action := msg.actions[0]
+			m.state.pendingAction = &action
+			m.state.pendingBody = msg.body
+			m.state.pendingAgain = msg.again
+		}
+		m.mode = ModeConfirm
+		m.input.Blur()
+		return m, tea.Println(output)
+	}
+
+	// Entering action mode
+	m.mode = ModeAction
+	m.input.Prompt = promptActStyle.Render("action> ")
+	m.input.Focus()
+	m.updateSuggestions()
+	if output := joinOutput(msg.output, report); output != "" {
+		return m, tea.Println(output)
+	}
+	return m, nil
+}
+
+func (m model) handlePostPlanned(msg postPlannedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.mode = ModeReady
+		m.input.Prompt = promptPathStyle.Render(m.state.nav.cwd) + "> "
+		m.input.Focus()
+		m.state.spinnerLabel = ""
+		return m, tea.Println(joinOutput(fmt.Sprintf("Error: %v", msg.err), m.state.commandReport()))
+	}
+
+	m.state.pendingPost = msg.run
+	m.state.spinnerLabel = msg.label
+	m.mode = ModeConfirm
+	m.input.Blur()
+	return m, tea.Println(msg.output + "\n" + msg.prompt)
+}
+
+func (m model) handleActionResult(msg actionResultMsg) (tea.Model, tea.Cmd) {
+	var output string
+	if msg.err != nil {
+		output = fmt.Sprintf("Error: %v", msg.err)
+	} else if msg.body != "" {
+		output = msg.body
+	}
+	output = joinOutput(output, m.state.commandReport())
+
+	m.state.pendingAction = nil
+	m.state.pendingBody = nil
+	m.state.pendingAgain = false
+	m.mode = ModeAction
+	m.input.Prompt = promptActStyle.Render("action> ")
+	m.input.Focus()
+	m.state.spinnerLabel = ""
+
+	if output != "" {
+		return m, tea.Println(output)
+	}
+	return m, nil
+}
+
+// beginCommand marks the start of a command for its report
+func (s *shellState) beginCommand(line string, timed bool) {
+	s.timed = timed
+	s.cmdLine = line
+	s.cmdStart = time.Now()
+	s.cmdMark = s.nav.vfs.Stats().Len()
+}
+
+// bounded runs a command's requests under a context that expires after the
+// command timeout and that Ctrl+C cancels. A command cut short reports
+// that, rather than the network error of the request it aborted.
+func (s *shellState) bounded(cmd tea.Cmd) tea.Cmd {
+	return s.boundedBy(s.commandTimeout, cmd)
+}
+
+// boundedBy is bounded with a timeout of its own; 0 leaves only Ctrl+C
+func (s *shellState) boundedBy(timeout time.Duration, cmd tea.Cmd) tea.Cmd {
+	return s.boundedContext(timeout, func(context.Context) tea.Msg { return cmd() })
+}
+
+// boundedContext is boundedBy for a command that needs the context itself,
+// such as to stop a program it runs. The navigator sends the command's
+// requests through a view of the connection bound to the context until
+// the command's message arrives; requests sent in the background, such as
+// the PowerState poll, go through the connection and are not aborted.
+func (s *shellState) boundedContext(timeout time.Duration, cmd func(ctx context.Context) tea.Msg) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	}
+	s.cancelCommand = cancel
+	vfs := s.nav.vfs
+	s.nav.vfs = rvfs.WithContext(ctx, vfs)
+	return func() tea.Msg {
+		msg := cmd(ctx)
+		cut := ctx.Err()
+		cancel()
+		if cut != nil {
+			switch m := msg.(type) {
+			case commandResultMsg:
+				if m.err != nil {
+					m.err = interruptedError(cut, timeout, "")
+				}
+				msg = m
+			case actionResultMsg:
+				if m.err != nil {
+					m.err = interruptedError(cut, timeout, "; the service may still carry out the action")
+				}
+				msg = m
+			}
+		}
+		return boundedMsg{msg: msg, vfs: vfs}
+	}
+}
+
+// interruptedError explains why a command's requests were aborted
+func interruptedError(cut error, timeout time.Duration, note string) error {
+	if errors.Is(cut, context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s waiting for the service (command_timeout)%s", timeout, note)
+	}
+	return fmt.Errorf("interrupted%s", note)
+}
+
+// commandReport returns the trace, timing and parser warnings of the
+// finished command, or "" when there is nothing to report
+func (s *shellState) commandReport() string {
+	stats := s.nav.vfs.Stats()
+	requests, dropped := stats.Since(s.cmdMark)
+	s.cmdMark = stats.Len()
+
+	var trace, timing string
+	if s.nav.trace {
+		trace = formatTrace(requests, dropped)
+	}
+	if s.timed {
+		timing = formatTiming(time.Since(s.cmdStart), requests, dropped)
+		s.timed = false
+	}
+	return joinOutput(trace, timing, formatWarnings(requests))
+}
+
+// notification announces the end of a long command, when the notify
+// setting is on: the terminal bell, a desktop notification (OSC 777) for
+// terminals that show one, and a summary line. It is "" for commands that
+// ended quickly or are not among notifyCommands.
+func (s *shellState) notification(err error) string {
+	cmd, _, _ := strings.Cut(s.cmdLine, " ")
+	elapsed := time.Since(s.cmdStart)
+	if !s.nav.notify || elapsed < notifyAfter || !slices.Contains(notifyCommands, cmd) {
+		return ""
+	}
+	summary := fmt.Sprintf("%s finished in %s", s.cmdLine, elapsed.Round(time.Second))
+	if err != nil {
+		summary = fmt.Sprintf("%s failed after %s", s.cmdLine, elapsed.Round(time.Second))
+	}
+	return "\a" + termenv.OSC + "777;notify;btsh;" + summary + termenv.ST + dimStyle.Render(summary)
+}
+
+// joinOutput joins non-empty output blocks with newlines
+func joinOutput(parts ...string) string {
+	var nonEmpty []string
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, "\n")
+}
+
+func (m model) enterActionMode() (model, tea.Cmd) {
+	m.mode = ModeRunning
+	m.state.spinnerLabel = "Discovering actions..."
+	nav := m.state.nav
+	return m, func() tea.Msg {
+		actions, err := discoverActions(nav.vfs, nav.cwd)
+		if err != nil {
+			return commandResultMsg{err: err}
+		}
+		if len(actions) == 0 {
+			return commandResultMsg{output: "No actions on current resource"}
+		}
+		return actionDiscoveredMsg{
+			actions: actions,
+			output:  formatActionList(actions, nav.writeRefusal(nav.cwd)),
+		}
+	}
+}
+
+// --- Completion menu logic ---
+
+// handleTab cycles forward through completions
+func (m model) handleTab() model {
+	if len(m.completions) == 0 {
+		return m
+	}
+	// Single match: fill immediately
+	if len(m.completions) == 1 {
+		m.input.SetValue(m.completions[0])
+		m.input.CursorEnd()
+		m.lastInput = m.completions[0]
+		m.updateSuggestions()
+		return m
+	}
+	// Multiple matches: cycle
+	m.completionIdx = (m.completionIdx + 1) % len(m.completions)
+	m.syncGhostText()
+	return m
+}
+
+// handleShiftTab cycles backward through completions
+func (m model) handleShiftTab() model {
+	if len(m.completions) <= 1 || m.completionIdx < 0 {
+		return m
+	}
+	m.completionIdx--
+	if m.completionIdx < 0 {
+		m.completionIdx = len(m.completions) - 1
+	}
+	m.syncGhostText()
+	return m
+}
+
+// handleMenuKey handles the keys that page and filter the open completion
+// menu: PgUp and PgDn move a page, and printable keys narrow it to the
+// entries whose labels hold what was typed, Backspace widening it again.
+// Other keys but Tab, Shift+Tab and Enter on an entry drop the filter.
+// handled reports whether msg was taken.
+func (m model) handleMenuKey(msg tea.KeyMsg) (model, bool) {
+	if m.completionIdx < 0 && m.menuFilter == "" {
+		return m, false
+	}
+	switch msg.Type {
+	case tea.KeyPgDown, tea.KeyPgUp:
+		if len(m.completions) == 0 {
+			return m, true
+		}
+		page := m.completionPage()
+		first := max(0, m.completionIdx) / page * page
+		if msg.Type == tea.KeyPgDown {
+			m.completionIdx = min(len(m.completions)-1, first+page)
+		} else {
+			m.completionIdx = max(0, first-page)
+		}
+	case tea.KeyRunes:
+		if msg.Alt || msg.Paste {
+			m.closeMenu()
+			return m, false
+		}
+		m.filterMenu(m.menuFilter + string(msg.Runes))
+	case tea.KeyBackspace:
+		if m.menuFilter == "" {
+			m.closeMenu()
+			return m, false
+		}
+		runes := []rune(m.menuFilter)
+		m.filterMenu(string(runes[:len(runes)-1]))
+	case tea.KeyTab, tea.KeyShiftTab:
+		return m, false
+	case tea.KeyEnter:
+		if m.completionIdx < 0 {
+			m.closeMenu()
+		}
+		return m, false
+	default:
+		if m.menuFilter != "" {
+			m.closeMenu()
+		}
+		return m, false
+	}
+	m.syncGhostText()
+	return m, true
+}
+
+// filterMenu narrows the open completion menu to the entries whose labels
+// hold filter, highlighting the first of them
+func (m *model) filterMenu(filter string) {
+	if m.menuFilter == "" {
+		m.menuAll = m.completions
+	}
+	m.menuFilter = filter
+	m.completions = filterCompletions(m.menuAll, filter)
+	m.completionIdx = -1
+	if len(m.completions) > 0 {
+		m.completionIdx = 0
+	}
+}
+
+// closeMenu stops cycling through the completions, dropping the filter
+func (m *model) closeMenu() {
+	if m.menuFilter != "" {
+		m.completions = m.menuAll
+	}
+	m.menuFilter, m.menuAll = "", nil
+	m.completionIdx = -1
+	m.syncGhostText()
+}
+
+// completionPage is how many entries a page of the completion menu holds
+func (m model) completionPage() int {
+	_, numCols := completionColumns(m.completionLabels(), m.terminalWidth())
+	return numCols * m.completionMenuRows()
+}
+
+// describeCompletion looks up the schema description of the highlighted
+// completion's path in the background, as it may fetch schemas. Commands
+// and action names have none.
+func (m model) describeCompletion() tea.Cmd {
+	if m.completionIdx < 0 || m.completionIdx >= len(m.completions) {
+		return nil
+	}
+	c := m.completions[m.completionIdx]
+	if c == m.described {
+		return nil
+	}
+	words := strings.Fields(c)
+	if len(words) < 2 {
+		return nil
+	}
+	vfs, cwd := m.state.nav.vfs, m.state.nav.cwd
+	target := words[len(words)-1]
+	return func() tea.Msg {
+		text, _ := vfs.Describe(cwd, target)
+		return describedMsg{completion: c, text: text}
+	}
+}
+
+// acceptCompletion fills the selected completion into the input
+func (m model) acceptCompletion() model {
+	if m.completionIdx < 0 || m.completionIdx >= len(m.completions) {
+		return m
+	}
+	m.input.SetValue(m.completions[m.completionIdx])
+	m.input.CursorEnd()
+	m.lastInput = m.completions[m.completionIdx]
+	m.completionIdx = -1
+	m.updateSuggestions()
+	return m
+}
+
+// syncGhostText sets textinput suggestions to show only the currently
+// highlighted completion as ghost text.
+func (m *model) syncGhostText() {
+	if m.completionIdx >= 0 && m.completionIdx < len(m.completions) {
+		m.input.SetSuggestions([]string{m.completions[m.completionIdx]})
+	} else if m.input.Value() == "" {
+		m.input.SetSuggestions(nil)
+	} else {
+		m.input.SetSuggestions(m.completions)
+	}
+}
+
+// updateSuggestions recomputes suggestions for the current input
+func (m *model) updateSuggestions() {
+	m.completions = computeSuggestions(m.state.nav, m.input.Value(), m.mode == ModeAction)
+	m.completionIdx = -1
+	m.menuFilter, m.menuAll = "", nil
+	// Only show ghost text when there's actual input
+	if m.input.Value() == "" {
+		m.input.SetSuggestions(nil)
+	} else {
+		m.input.SetSuggestions(m.completions)
+	}
+}
+
+// completionMenuDisplay returns the display label for a completion entry.
+// Extracts just the varying part (last argument) from a full-line completion.
+func completionMenuDisplay(c string) string {
+	words := strings.Fields(c)
+	if len(words) > 1 {
+		return words[len(words)-1]
+	}
+	return c
+}
+
+// completionLabels returns the menu labels of the completions
+func (m model) completionLabels() []string {
+	labels := make([]string, len(m.completions))
+	for i, c := range m.completions {
+		labels[i] = completionMenuDisplay(c)
+	}
+	return labels
+}
+
+// renderCompletionMenu renders completions in columns that fit the terminal,
+// with the currently selected item highlighted, and under a paged or
+// filtered menu where that item is
+func (m model) renderCompletionMenu() string {
+	labels := m.completionLabels()
+	menu, paged := formatCompletionColumns(labels, m.completionIdx, m.terminalWidth(), m.completionMenuRows())
+	if status := formatCompletionStatus(m.completionIdx, len(labels), paged, m.menuFilter); status != "" {
+		if menu != "" {
+			menu += "\n"
+		}
+		menu += status
+	}
+	return menu
+}
+
+// terminalWidth is the width of the terminal as last reported, for what
+// the prompt line lays out; before the first report, the width now
+func (m model) terminalWidth() int {
+	if m.width > 0 {
+		return m.width
+	}
+	return terminalWidth(80)
+}
+
+// completionMenuRows is how many rows of completions the menu shows at
+// once: half the terminal, at least 3 and at most 10
+func (m model) completionMenuRows() int {
+	if m.height == 0 {
+		return 10
+	}
+	return max(3, min(10, m.height/2))
+}
+
+// fitInput keeps the input line to one terminal row, scrolling a long
+// line sideways so the cursor stays in view, where the renderer would cut
+// it off once the terminal narrows. Two cells are left for the cursor and
+// the menu marker.
+func (m *model) fitInput() {
+	if m.width > 0 {
+		m.input.Width = max(1, m.width-theme.Width(m.input.Prompt)-2)
+	}
+}
+
+// View renders only the prompt line (inline mode)
+func (m model) View() string {
+	switch m.mode {
+	case ModeRunning:
+		label := m.state.spinnerLabel
+		if t := m.state.nav.transfer.Load(); t != nil {
+			label = formatTransfer(t.written.Load(), t.total.Load())
+		} else if t := m.state.nav.task.Load(); t != nil {
+			label = "Task " + t.Path + ": " + t.String()
+		}
+		if label == "" {
+			label = "Running..."
+		}
+		return m.spinner.View() + " " + label
+	case ModeConfirm:
+		return ""
+	case ModePager:
+		return m.pagerView()
+	default:
+		// The input pads itself to its width; the padding is dropped so the
+		// menu marker below stays within the line
+		v := strings.TrimRight(m.input.View(), " ")
+		showMenu := m.menuFilter != "" || len(m.completions) > 1 && (m.input.Value() != "" || m.completionIdx >= 0)
+		if showMenu {
+			// Trailing space differentiates this line from the no-menu render,
+			// preventing bubbletea's inline renderer from skipping it (canSkip)
+			// and then erasing it with EraseScreenBelow when the view shrinks.
+			v += " \n" + m.renderCompletionMenu()
+			if m.completionIdx >= 0 && m.completions[m.completionIdx] == m.described && m.description != "" {
+				v += "\n" + formatCompletionDescription(m.description, m.terminalWidth())
+			}
+		}
+		return v
+	}
+}
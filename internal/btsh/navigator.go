@@ -0,0 +1,1611 @@
+package btsh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bluefish-project/bluefish/plugin"
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/theme"
+)
+
+// Navigator manages shell state
+type Navigator struct {
+	vfs       rvfs.VFS
+	cwd       string
+	trace     bool     // Print the requests each command caused
+	redact    bool     // Mask the values redaction names (redact)
+	humanize  bool     // Show values with units in human form (set humanize)
+	ages      bool     // Mark entries in ls and tree by age (set ages)
+	notify    bool     // Announce the end of long operations (set notify)
+	uriLinks  bool     // cd follows links inferred from URI strings (set urilinks)
+	power     bool     // Poll the PowerState of the system cwd is in (set powerwatch)
+	views     bool     // ll renders resources of known types by their view (set views)
+	prefetch  bool     // ll warms the children its resource's type hints at (set prefetch)
+	json      bool     // ls, ll, grep and find -c print their results as JSON (set json)
+	members   []string // Paths behind %N: the last collection listing's members or find's matches
+	recent    []string // Directories left, most recent first (cd -, cd -N)
+	dirStack  []string // pushd/popd stack, top first
+	bookmarks []string // Resources bookmarked, saved with a workspace
+	endpoint  string   // Service a workspace is saved for
+	user      string   // Account logged in as, for plugin commands
+
+	redaction  rvfs.Redaction  // Properties whose values ll, dump and export mask
+	powerWatch rvfs.PowerWatch // PowerState changes of the system cwd is in
+	watchList  rvfs.WatchList  // Resources refreshed on an interval (watchlist)
+	staging    rvfs.Staging    // Property changes stage made, for commit
+	history    rvfs.History    // Writes the session made, for undo
+	posts      rvfs.PostGuard  // Actions sent, to hold back one sent twice
+	prefetcher rvfs.Prefetcher // Children ll warms in the background
+
+	role     *rvfs.SessionRole               // What the logged-in account may do, nil when unknown
+	transfer atomic.Pointer[transfer]        // The running download, nil when none
+	task     atomic.Pointer[rvfs.TaskStatus] // The task being waited for, nil when none
+}
+
+// NewNavigator creates a navigator
+func NewNavigator(vfs rvfs.VFS) *Navigator {
+	return &Navigator{
+		vfs:      vfs,
+		cwd:      vfs.Root(),
+		redact:   true,
+		notify:   true,
+		uriLinks: true,
+		power:    true,
+		views:    true,
+		prefetch: true,
+	}
+}
+
+// entriesFromProperty creates Entry list from a property's children/elements
+func entriesFromProperty(target *rvfs.Target) []*rvfs.Entry {
+	prop := target.Property
+	var fetched time.Time
+	if target.Resource != nil {
+		fetched = target.Resource.FetchedAt
+	}
+	var entries []*rvfs.Entry
+
+	switch prop.Type {
+	case rvfs.PropertyObject:
+		for name, child := range prop.Children {
+			// A link array is listed link by link, each with where it leads
+			if child.IsLinkArray() {
+				for _, elem := range child.Elements {
+					entries = append(entries, propertyEntry(name+elem.Name, elem, fetched))
+				}
+				continue
+			}
+			entries = append(entries, propertyEntry(name, child, fetched))
+		}
+	case rvfs.PropertyArray:
+		for _, elem := range prop.Elements {
+			entries = append(entries, propertyEntry(elem.Name, elem, fetched))
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// propertyEntry is the entry listing a property under name
+func propertyEntry(name string, prop *rvfs.Property, fetched time.Time) *rvfs.Entry {
+	return &rvfs.Entry{
+		Name:       name,
+		Path:       prop.LinkTarget,
+		Type:       entryTypeForProperty(prop),
+		Size:       int64(len(prop.RawJSON)),
+		Modified:   fetched,
+		URIString:  prop.URIString,
+		LinkTarget: prop.LinkTarget,
+	}
+}
+
+// entryTypeForProperty maps property types to entry types
+func entryTypeForProperty(prop *rvfs.Property) rvfs.EntryType {
+	switch prop.Type {
+	case rvfs.PropertyObject:
+		return rvfs.EntryComplex
+	case rvfs.PropertyArray:
+		return rvfs.EntryArray
+	case rvfs.PropertyLink:
+		return rvfs.EntrySymlink
+	default:
+		return rvfs.EntryProperty
+	}
+}
+
+// listResolved returns entries for any resolved target
+func listResolved(vfs rvfs.VFS, target *rvfs.Target) []*rvfs.Entry {
+	switch target.Type {
+	case rvfs.TargetResource, rvfs.TargetLink:
+		entries, _ := vfs.ListAll(target.ResourcePath)
+		return entries
+	case rvfs.TargetProperty:
+		return entriesFromProperty(target)
+	}
+	return nil
+}
+
+// cd changes directory and returns a status message
+func (n *Navigator) cd(target string) (string, error) {
+	if target == "" {
+		target = "~"
+	}
+
+	// cd - returns to the previous directory, cd -N to the Nth most recent
+	recent, err := n.recentDir(target)
+	if err != nil {
+		return "", err
+	}
+	if recent != "" {
+		target = recent
+	}
+
+	if target == "~" {
+		target = n.vfs.Root()
+	} else if strings.HasPrefix(target, "~/") {
+		target = n.vfs.Root() + "/" + target[2:]
+	}
+
+	resolvedTarget, err := n.vfs.ResolveTarget(n.cwd, target)
+	if err != nil {
+		return "", err
+	}
+	if resolvedTarget.ViaURIString && !n.uriLinks {
+		return "", fmt.Errorf("%s follows a URI string; use open (or set urilinks on)", target)
+	}
+
+	switch resolvedTarget.Type {
+	case rvfs.TargetResource:
+		n.chdir(resolvedTarget.ResourcePath)
+	case rvfs.TargetLink:
+		n.chdir(resolvedTarget.ResourcePath)
+	case rvfs.TargetProperty:
+		switch resolvedTarget.Property.Type {
+		case rvfs.PropertyObject, rvfs.PropertyArray:
+			n.chdir(n.vfs.Join(n.cwd, target))
+		default:
+			return "", fmt.Errorf("cannot cd to value: %s", target)
+		}
+	}
+
+	entries := listResolved(n.vfs, resolvedTarget)
+	if recent != "" {
+		return recent + "\n" + n.summaryLine(entries), nil
+	}
+	return n.summaryLine(entries), nil
+}
+
+// maxRecentDirs bounds the directories cd -N can return to
+const maxRecentDirs = 10
+
+// chdir makes path the current directory, remembering the one left
+func (n *Navigator) chdir(path string) {
+	if path == n.cwd {
+		return
+	}
+	recent := []string{n.cwd}
+	for _, dir := range n.recent {
+		if dir != n.cwd && dir != path && len(recent) < maxRecentDirs {
+			recent = append(recent, dir)
+		}
+	}
+	n.recent = recent
+	n.cwd = path
+}
+
+// recentDir returns the directory a "-" (the previous one) or "-N" (the Nth
+// most recent) target names, or "" for any other target
+func (n *Navigator) recentDir(target string) (string, error) {
+	if target == "-" {
+		target = "-1"
+	}
+	num, ok := strings.CutPrefix(target, "-")
+	if !ok {
+		return "", nil
+	}
+	i, err := strconv.Atoi(num)
+	if err != nil || i < 1 {
+		return "", nil
+	}
+	switch {
+	case len(n.recent) == 0:
+		return "", fmt.Errorf("no previous directory")
+	case i > len(n.recent):
+		return "", fmt.Errorf("only %d recent directories", len(n.recent))
+	}
+	return n.recent[i-1], nil
+}
+
+// pushd changes to target, saving the current directory on the stack.
+// Without a target it exchanges the current directory with the top of the
+// stack.
+func (n *Navigator) pushd(target string) (string, error) {
+	from := n.cwd
+	var summary string
+	var err error
+	if target == "" {
+		if len(n.dirStack) == 0 {
+			return "", fmt.Errorf("no other directory")
+		}
+		if summary, err = n.cd(n.dirStack[0]); err != nil {
+			return "", err
+		}
+		n.dirStack[0] = from
+	} else {
+		if summary, err = n.cd(target); err != nil {
+			return "", err
+		}
+		n.dirStack = append([]string{from}, n.dirStack...)
+	}
+	return summary + "\n" + n.dirs(), nil
+}
+
+// popd returns to the directory on top of the stack, removing it
+func (n *Navigator) popd() (string, error) {
+	if len(n.dirStack) == 0 {
+		return "", fmt.Errorf("directory stack empty")
+	}
+	summary, err := n.cd(n.dirStack[0])
+	if err != nil {
+		return "", err
+	}
+	n.dirStack = n.dirStack[1:]
+	return summary + "\n" + n.dirs(), nil
+}
+
+// dirs lists the directory stack, numbered from the current directory
+func (n *Navigator) dirs() string {
+	var lines []string
+	for i, dir := range append([]string{n.cwd}, n.dirStack...) {
+		lines = append(lines, fmt.Sprintf("%s  %s", dimStyle.Render(fmt.Sprintf("%2d", i)), dir))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// open follows links to their canonical destinations. Targets carrying an
+// OriginOfCondition open its resource.
+func (n *Navigator) open(target string) (string, error) {
+	if target == "" {
+		return "", fmt.Errorf("open requires a target path")
+	}
+
+	resolvedTarget, err := n.vfs.ResolveTarget(n.cwd, target)
+	if err != nil {
+		if target == "." {
+			resolvedTarget, err = n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+			if err != nil {
+				return "", err
+			}
+		} else {
+			return "", err
+		}
+	}
+
+	// Log entries, event records and conditions open the resource they
+	// are about
+	if origin := rvfs.OriginOfCondition(resolvedTarget); origin != "" {
+		n.chdir(origin)
+		entries, _ := n.vfs.ListAll(n.cwd)
+		return n.summaryLine(entries), nil
+	}
+
+	switch resolvedTarget.Type {
+	case rvfs.TargetResource:
+		n.chdir(resolvedTarget.ResourcePath)
+		entries, _ := n.vfs.ListAll(n.cwd)
+		return n.summaryLine(entries), nil
+
+	case rvfs.TargetLink:
+		n.chdir(resolvedTarget.ResourcePath)
+		entries, _ := n.vfs.ListAll(n.cwd)
+		return n.summaryLine(entries), nil
+
+	case rvfs.TargetProperty:
+		prop := resolvedTarget.Property
+		if prop.Type == rvfs.PropertyLink {
+			n.chdir(prop.LinkTarget)
+			entries, _ := n.vfs.ListAll(n.cwd)
+			return n.summaryLine(entries), nil
+		} else if target == "." {
+			n.chdir(resolvedTarget.Resource.Path)
+			entries, _ := n.vfs.ListAll(n.cwd)
+			return n.summaryLine(entries), nil
+		}
+		return "", fmt.Errorf("cannot open property %s (not a link; use 'cd' to navigate into objects)", target)
+	}
+
+	return "", nil
+}
+
+// openPreview shows where open would go without going there: each link
+// it follows on the way and the resource it ends on
+func (n *Navigator) openPreview(target string) (string, error) {
+	if target == "" {
+		return "", fmt.Errorf("usage: open -n <path>")
+	}
+	resolved, chain, err := n.vfs.ResolveChain(n.cwd, target)
+	if err != nil {
+		return "", err
+	}
+	end, err := openDestination(resolved)
+	if err != nil {
+		return "", fmt.Errorf("cannot open property %s (%w)", target, err)
+	}
+	output := formatChain(chain)
+	if origin := rvfs.OriginOfCondition(resolved); origin != "" {
+		output += dimStyle.Render("OriginOfCondition") + linkStyle.Render(" → ") + origin + "\n"
+	}
+	return output + n.formatChainEnd(end), nil
+}
+
+// openDestination returns the resource open goes to from a target
+func openDestination(target *rvfs.Target) (string, error) {
+	if origin := rvfs.OriginOfCondition(target); origin != "" {
+		return origin, nil
+	}
+	switch {
+	case target.Type == rvfs.TargetResource || target.Type == rvfs.TargetLink:
+		return target.ResourcePath, nil
+	case target.Property.Type == rvfs.PropertyLink:
+		return target.Property.LinkTarget, nil
+	}
+	return "", fmt.Errorf("not a link; use 'cd' to navigate into objects")
+}
+
+// formatChain formats the links a path resolves through, a line per hop
+func formatChain(chain []rvfs.Hop) string {
+	var b strings.Builder
+	for _, hop := range chain {
+		b.WriteString(hop.From + linkStyle.Render(" → ") + hop.To)
+		if hop.URIString {
+			b.WriteString(dimStyle.Render(" (URI string)"))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// formatChainEnd formats the resource a chain of links ends on, with its
+// type, or why it cannot be reached
+func (n *Navigator) formatChainEnd(path string) string {
+	res, err := n.vfs.Get(path)
+	if err != nil {
+		return "= " + path + "  " + errorStyle.Render(err.Error())
+	}
+	return "= " + res.Path + "  " + dimStyle.Render(res.ODataType)
+}
+
+// ls lists entries, filtered and ordered by opts
+func (n *Navigator) ls(opts rvfs.ListOptions, target string) (*listing, error) {
+	if target == "." {
+		target = ""
+	}
+
+	var resolved *rvfs.Target
+	var err error
+	if target == "" {
+		resolved, err = n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+	} else {
+		resolved, err = n.vfs.ResolveTarget(n.cwd, target)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := listResolved(n.vfs, resolved)
+	if opts.All && resolved.Type != rvfs.TargetProperty {
+		entries = append(rvfs.AnnotationEntries(resolved.Resource), entries...)
+	}
+	entries = rvfs.Arrange(entries, opts)
+	numbers := n.numberMembers(resolved, entries)
+	l := &listing{Path: n.targetPath(resolved, target), Entries: []listedEntry{}, FetchedAt: fetchedAt(resolved)}
+	for _, entry := range entries {
+		l.Entries = append(l.Entries, listedEntry{
+			Name:     entry.Name,
+			Path:     entry.Path,
+			Type:     entry.Type.String(),
+			Link:     entry.LinkTarget,
+			Member:   numbers[entry.Name],
+			Actions:  entry.Actions,
+			Denied:   entry.Denied,
+			Modified: entry.Modified,
+			entry:    entry,
+		})
+	}
+	return l, nil
+}
+
+// targetPath is the path a command named: the resource a resource or link
+// resolved to, or the property's path from cwd
+func (n *Navigator) targetPath(resolved *rvfs.Target, target string) string {
+	if resolved.Type != rvfs.TargetProperty {
+		return resolved.ResourcePath
+	}
+	if target == "" {
+		return n.cwd
+	}
+	return n.vfs.Join(n.cwd, target)
+}
+
+// numberMembers records the member entries of a collection listing, in
+// display order, for %N shortcuts and returns each member's number by name.
+// Any other listing clears the shortcuts.
+func (n *Navigator) numberMembers(target *rvfs.Target, entries []*rvfs.Entry) map[string]int {
+	n.members = nil
+	if target.Type == rvfs.TargetProperty {
+		return nil
+	}
+	resource, err := n.vfs.Get(target.ResourcePath)
+	if err != nil || !resource.IsCollection() {
+		return nil
+	}
+	numbers := make(map[string]int)
+	for _, entry := range entries {
+		if _, ok := resource.Children[entry.Name]; ok {
+			n.members = append(n.members, entry.Path)
+			numbers[entry.Name] = len(n.members)
+		}
+	}
+	return numbers
+}
+
+// numberMatches numbers find results for %N shortcuts; each refers to the
+// resource at path, where the result was found
+func (n *Navigator) numberMatches(path string, results []string) []string {
+	numbered := make([]string, len(results))
+	for i, r := range results {
+		n.members = append(n.members, path)
+		numbered[i] = dimStyle.Render("%"+strconv.Itoa(len(n.members))) + " " + r
+	}
+	return numbered
+}
+
+// expandMembers replaces %N arguments with the Nth member of the last
+// collection listing, or the resource holding the Nth find match
+func (n *Navigator) expandMembers(args []string) ([]string, error) {
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		num, ok := strings.CutPrefix(arg, "%")
+		if !ok {
+			expanded[i] = arg
+			continue
+		}
+		idx, err := strconv.Atoi(num)
+		if err != nil || idx < 1 || idx > len(n.members) {
+			return nil, fmt.Errorf("no member %s in the last listing", arg)
+		}
+		expanded[i] = n.members[idx-1]
+	}
+	return expanded, nil
+}
+
+// ll displays formatted content
+func (n *Navigator) ll(target string) (*shown, error) {
+	if target == "." {
+		target = ""
+	}
+
+	var resolved *rvfs.Target
+	var err error
+	if target == "" {
+		resolved, err = n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+	} else {
+		resolved, err = n.vfs.ResolveTarget(n.cwd, target)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := &shown{Path: n.targetPath(resolved, target), FetchedAt: fetchedAt(resolved)}
+	if resolved.Type == rvfs.TargetProperty {
+		// An array named directly (or sliced) is shown in full
+		s.property = resolved.Property
+		s.whole = resolved.Property.Type == rvfs.PropertyArray
+		s.Value = n.redacted(resolved.Property.Name, resolved.Property.JSON())
+		return s, nil
+	}
+	resource, err := n.vfs.Get(resolved.ResourcePath)
+	if err != nil {
+		return nil, err
+	}
+	s.ODataType = resource.ODataType
+	s.Value = n.redacted("", resource.RawJSON)
+	s.Conditions = resource.Conditions
+	s.Warnings = resource.Warnings
+	if n.prefetch {
+		n.prefetcher.Warm(n.vfs, resource)
+	}
+	return s, nil
+}
+
+// dump displays raw JSON, highlighted
+func (n *Navigator) dump(args []string) (string, error) {
+	opts, target, output, err := parseDumpArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	var resolved *rvfs.Target
+	if target == "" {
+		resolved, err = n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+	} else {
+		resolved, err = n.vfs.ResolveTarget(n.cwd, target)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var raw []byte
+	switch resolved.Type {
+	case rvfs.TargetResource, rvfs.TargetLink:
+		raw = n.redacted("", resolved.Resource.RawJSON)
+	case rvfs.TargetProperty:
+		raw = n.redacted(resolved.Property.Name, resolved.Property.RawJSON)
+	}
+	if len(raw) == 0 {
+		return "", nil
+	}
+	if output != "" {
+		size, err := writeJSON(output, raw, opts)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Wrote %s (%d bytes)", output, size), nil
+	}
+	return activeTheme.RenderJSON(raw, opts)
+}
+
+// writeJSON writes JSON to a file, rendered uncolored, and returns its size
+func writeJSON(filename string, raw []byte, opts theme.JSONOptions) (int, error) {
+	var plain *theme.Theme
+	out, err := plain.RenderJSON(raw, opts)
+	if err != nil {
+		return 0, err
+	}
+	data := []byte(out + "\n")
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// parseDumpArgs splits dump arguments into render options, the target and
+// the file to write to: -c for compact output, -n N to show at most N
+// elements of each array, --fields A,B to show only those top-level
+// members, -o FILE to write the JSON to FILE
+func parseDumpArgs(args []string) (opts theme.JSONOptions, target, output string, err error) {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-c":
+			opts.Compact = true
+		case "-n":
+			if i+1 >= len(args) {
+				return opts, "", "", fmt.Errorf("dump: -n needs a count")
+			}
+			i++
+			count, err := strconv.Atoi(args[i])
+			if err != nil || count < 1 {
+				return opts, "", "", fmt.Errorf("dump: invalid count %q", args[i])
+			}
+			opts.MaxElements = count
+		case "--fields":
+			if i+1 >= len(args) {
+				return opts, "", "", fmt.Errorf("dump: --fields needs member names")
+			}
+			i++
+			for _, field := range strings.Split(args[i], ",") {
+				if field = strings.TrimSpace(field); field != "" {
+					opts.Fields = append(opts.Fields, field)
+				}
+			}
+			if len(opts.Fields) == 0 {
+				return opts, "", "", fmt.Errorf("dump: --fields needs member names")
+			}
+		case "-o":
+			if i+1 >= len(args) {
+				return opts, "", "", fmt.Errorf("dump: -o needs a file")
+			}
+			i++
+			output = args[i]
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	return opts, rvfs.TargetArg(rest), output, nil
+}
+
+// cat returns the raw value of a property and nothing else, for scripts
+func (n *Navigator) cat(target string) (string, error) {
+	resolved, err := n.vfs.ResolveTarget(n.cwd, target)
+	if err != nil {
+		return "", err
+	}
+	if resolved.Type != rvfs.TargetProperty {
+		return "", fmt.Errorf("not a property: %s (use dump)", target)
+	}
+	return resolved.Property.Text(), nil
+}
+
+// tree displays tree view
+func (n *Navigator) tree(depth int) (string, error) {
+	resolved, err := n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+	if err != nil {
+		return "", err
+	}
+
+	var entries []*rvfs.Entry
+	switch resolved.Type {
+	case rvfs.TargetResource, rvfs.TargetLink:
+		entries, _ = n.vfs.ListAll(resolved.ResourcePath)
+	case rvfs.TargetProperty:
+		entries = entriesFromProperty(resolved)
+	}
+
+	output := n.buildTreeFromEntries(n.cwd, entries, "", depth, 0)
+	if output == "" {
+		return "(empty)", nil
+	}
+	if n.ages {
+		output += "\n" + ageLegend()
+	}
+	return output, nil
+}
+
+func (n *Navigator) buildTreeFromEntries(basePath string, entries []*rvfs.Entry, prefix string, maxDepth, currentDepth int) string {
+	if currentDepth >= maxDepth {
+		return ""
+	}
+
+	var lines []string
+	for i, entry := range entries {
+		isLast := i == len(entries)-1
+
+		connector := "├── "
+		if isLast {
+			connector = "└── "
+		}
+
+		line := prefix + connector + n.formatAgedEntry(entry)
+		lines = append(lines, line)
+
+		if entry.IsDir() && currentDepth+1 < maxDepth {
+			extension := "│   "
+			if isLast {
+				extension = "    "
+			}
+
+			childPath := entry.Path
+			if childPath == "" {
+				childPath = n.vfs.Join(basePath, entry.Name)
+			}
+
+			resolved, err := n.vfs.ResolveTarget(n.vfs.Root(), childPath)
+			if err != nil {
+				// Show a refusal found on the way down on the child's line
+				var forbidden *rvfs.ForbiddenError
+				if errors.As(err, &forbidden) {
+					entry.Denied = true
+					lines[len(lines)-1] = prefix + connector + n.formatAgedEntry(entry)
+				}
+				continue
+			}
+
+			var childEntries []*rvfs.Entry
+			switch resolved.Type {
+			case rvfs.TargetResource, rvfs.TargetLink:
+				childEntries, _ = n.vfs.ListAll(resolved.ResourcePath)
+			case rvfs.TargetProperty:
+				childEntries = entriesFromProperty(resolved)
+			}
+
+			subtree := n.buildTreeFromEntries(childPath, childEntries, prefix+extension, maxDepth, currentDepth+1)
+			if subtree != "" {
+				lines = append(lines, subtree)
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// find searches for properties recursively
+func (n *Navigator) find(pattern string) (string, error) {
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %v", err)
+	}
+
+	resolved, err := n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+	if err != nil {
+		return "", err
+	}
+
+	var results []string
+
+	switch resolved.Type {
+	case rvfs.TargetResource, rvfs.TargetLink:
+		n.findInResource(resolved.ResourcePath, "", re, &results, 0)
+	case rvfs.TargetProperty:
+		findInProperty(resolved.Property, "", re, &results)
+	}
+
+	if len(results) == 0 {
+		return fmt.Sprintf("No matches found for '%s'", pattern), nil
+	}
+	return strings.Join(results, "\n"), nil
+}
+
+// findCached searches the property names of the cached resources at or
+// below the current resource, without fetching
+func (n *Navigator) findCached(pattern string) (*matches, error) {
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %v", err)
+	}
+	resolved, err := n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+	if err != nil {
+		return nil, err
+	}
+	return n.matched(n.vfs.FindCached(resolved.ResourcePath, re), pattern), nil
+}
+
+// findActionsCached lists the actions of the cached resources at or below
+// the current resource whose names match pattern, without fetching
+func (n *Navigator) findActionsCached(pattern string) (string, error) {
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %v", err)
+	}
+	resolved, err := n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+	if err != nil {
+		return "", err
+	}
+	start := resolved.ResourcePath
+	if resolved.Type == rvfs.TargetProperty {
+		start = resolved.Resource.Path
+	}
+
+	r := rvfs.CacheReader{Cache: n.vfs}
+	var b strings.Builder
+	found, resources := 0, 0
+	rvfs.Walk(r, start, func(_ string, res *rvfs.Resource, err error) bool {
+		if err != nil {
+			return true
+		}
+		actions := slices.DeleteFunc(rvfs.ResourceActions(res, r), func(a rvfs.Action) bool {
+			return !re.MatchString(a.Name)
+		})
+		if len(actions) > 0 {
+			b.WriteString(formatActionCatalog(res.Path, actions))
+			found += len(actions)
+			resources++
+		}
+		return true
+	})
+	if found == 0 {
+		return "No actions found in the cache", nil
+	}
+	fmt.Fprintf(&b, "%d actions on %d resources", found, resources)
+	return b.String(), nil
+}
+
+// grep searches the property values of the cached resources at or below
+// the current resource, without fetching
+func (n *Navigator) grep(text string) (*matches, error) {
+	resolved, err := n.vfs.ResolveTarget(n.vfs.Root(), n.cwd)
+	if err != nil {
+		return nil, err
+	}
+	return n.matched(n.vfs.GrepCached(resolved.ResourcePath, text), text), nil
+}
+
+// matched numbers cache search matches for %N, each referring to the
+// resource holding it
+func (n *Navigator) matched(found []rvfs.Match, query string) *matches {
+	n.members = nil
+	m := &matches{Query: query, Matches: []matchedValue{}}
+	for _, match := range found {
+		n.members = append(n.members, match.Resource)
+		m.Matches = append(m.Matches, matchedValue{
+			Resource: match.Resource,
+			Property: match.Property,
+			Value:    n.redacted(match.Value.Name, match.Value.JSON()),
+			Member:   len(n.members),
+			value:    match.Value,
+		})
+	}
+	return m
+}
+
+func (n *Navigator) findInResource(resourcePath, prefix string, re *regexp.Regexp, results *[]string, depth int) {
+	if depth > 5 {
+		return
+	}
+
+	resource, err := n.vfs.Get(resourcePath)
+	if err != nil {
+		return
+	}
+
+	for _, prop := range resource.Properties {
+		findInProperty(prop, prefix, re, results)
+	}
+
+	for _, child := range resource.Children {
+		childPrefix := child.Name
+		if prefix != "" {
+			childPrefix = prefix + "/" + child.Name
+		}
+		n.findInResource(child.Target, childPrefix, re, results, depth+1)
+	}
+}
+
+func findInProperty(prop *rvfs.Property, prefix string, re *regexp.Regexp, results *[]string) {
+	fullPath := prop.Name
+	if prefix != "" {
+		fullPath = prefix + "/" + prop.Name
+	}
+
+	if re.MatchString(prop.Name) {
+		*results = append(*results,
+			fmt.Sprintf("%s = %s",
+				warnStyle.Render(fullPath),
+				formatPropertyValue(prop)))
+	}
+
+	switch prop.Type {
+	case rvfs.PropertyObject:
+		for _, child := range prop.Children {
+			findInProperty(child, fullPath, re, results)
+		}
+	case rvfs.PropertyArray:
+		for _, elem := range prop.Elements {
+			findInProperty(elem, fullPath, re, results)
+		}
+	}
+}
+
+// refresh invalidates and re-fetches a resource
+func (n *Navigator) refresh(target string) (string, error) {
+	var p string
+	if target == "" {
+		p = n.cwd
+	} else {
+		resolved, err := n.vfs.ResolveTarget(n.cwd, target)
+		if err != nil {
+			return "", err
+		}
+		switch resolved.Type {
+		case rvfs.TargetResource, rvfs.TargetLink:
+			p = resolved.ResourcePath
+		default:
+			return "", fmt.Errorf("can only refresh resources, not properties")
+		}
+	}
+
+	n.vfs.Invalidate(p)
+
+	res, err := n.vfs.Get(p)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := n.showResource(&b, p); err != nil {
+		return "", err
+	}
+	b.WriteString(dimStyle.Render(formatAge(res.FetchedAt)))
+	return b.String(), nil
+}
+
+// watchlistUsage is the error for malformed watchlist arguments
+var watchlistUsage = fmt.Errorf("usage: watchlist [show] | watchlist add|rm <path> | watchlist clear")
+
+// watchlist edits or shows the resources the shell refreshes every
+// rvfs.WatchListInterval while it waits for input, reporting the
+// properties that changed
+func (n *Navigator) watchlist(args []string) (string, error) {
+	if len(args) == 0 || args[0] == "show" && len(args) == 1 {
+		paths := n.watchList.Paths()
+		if len(paths) == 0 {
+			return "Watch list empty (watchlist add <path>)", nil
+		}
+		header := fmt.Sprintf("Watching %d resources every %s", len(paths), rvfs.WatchListInterval)
+		return dimStyle.Render(header) + "\n" + strings.Join(paths, "\n"), nil
+	}
+
+	switch {
+	case args[0] == "clear" && len(args) == 1:
+		n.watchList.Clear()
+		return "Watch list cleared", nil
+	case (args[0] == "add" || args[0] == "rm") && len(args) == 2:
+		resolved, err := n.vfs.ResolveTarget(n.cwd, args[1])
+		if err != nil {
+			return "", err
+		}
+		if resolved.Type == rvfs.TargetProperty {
+			return "", fmt.Errorf("can only watch resources, not properties")
+		}
+		p := resolved.ResourcePath
+		if args[0] == "rm" {
+			if !n.watchList.Remove(p) {
+				return "", fmt.Errorf("not watching %s", p)
+			}
+			return "Stopped watching " + p, nil
+		}
+		// Cached now, so the first refresh has a copy to differ from
+		if _, err := n.vfs.Get(p); err != nil {
+			return "", err
+		}
+		if !n.watchList.Add(p) {
+			return "Already watching " + p, nil
+		}
+		return "Watching " + p, nil
+	}
+	return "", watchlistUsage
+}
+
+// transfer is how far the running download has come, for the spinner
+type transfer struct {
+	written atomic.Int64
+	total   atomic.Int64
+}
+
+// download streams a binary payload to a file without parsing it: the URI
+// a property such as AdditionalDataURI holds, or the path itself. The
+// spinner shows its progress while it arrives.
+func (n *Navigator) download(target, filename string) (string, error) {
+	path, err := rvfs.DownloadPath(n.vfs, n.cwd, target)
+	if err != nil {
+		return "", err
+	}
+	t := &transfer{}
+	t.total.Store(-1)
+	n.transfer.Store(t)
+	defer n.transfer.Store(nil)
+	written, err := saveDownload(n.vfs, path, filename, func(written, total int64) {
+		t.written.Store(written)
+		t.total.Store(total)
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Saved %s from %s to %s", formatBytes(written), path, filename), nil
+}
+
+// saveDownload streams the payload at path into filename. It is written to
+// filename.part and renamed once complete, so a failed download leaves no
+// truncated file behind.
+func saveDownload(v rvfs.Downloader, path, filename string, progress rvfs.ProgressFunc) (int64, error) {
+	part := filename + ".part"
+	f, err := os.Create(part)
+	if err != nil {
+		return 0, err
+	}
+	written, err := v.Download(path, f, progress)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(part)
+		return written, err
+	}
+	return written, os.Rename(part, filename)
+}
+
+// formatTransfer describes how far a download has come
+func formatTransfer(written, total int64) string {
+	if total <= 0 {
+		return "Downloading " + formatBytes(written)
+	}
+	return fmt.Sprintf("Downloading %s of %s (%d%%)", formatBytes(written), formatBytes(total), written*100/total)
+}
+
+// taskPollInterval is how often a task a command waits for is polled
+const taskPollInterval = 2 * time.Second
+
+// diagArgs are the arguments of diag collect:
+// [path] [key=value...] [-o file]
+type diagArgs struct {
+	target string   // Log service, "" for the current resource
+	params []string // key=value parameters of CollectDiagnosticData
+	file   string   // File to save the data to, "" for a name from the entry
+}
+
+// parseDiagArgs parses the arguments of diag collect
+func parseDiagArgs(args []string) (*diagArgs, error) {
+	usage := fmt.Errorf("usage: diag collect [path] [key=value...] [-o file]")
+	if len(args) == 0 || args[0] != "collect" {
+		return nil, usage
+	}
+	d := &diagArgs{}
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "-o":
+			if i+1 >= len(args) {
+				return nil, usage
+			}
+			i++
+			d.file = args[i]
+		case strings.Contains(args[i], "="):
+			d.params = append(d.params, args[i])
+		case d.target == "" && len(d.params) == 0:
+			d.target = args[i]
+		default:
+			return nil, usage
+		}
+	}
+	return d, nil
+}
+
+// diagFile names the file diagnostic data from a log entry is saved to when
+// none is given: diag-ENTRY-20260102-150405
+func diagFile(entry string, at time.Time) string {
+	return "diag-" + rvfs.BaseName(entry) + "-" + at.Format("20060102-150405")
+}
+
+// diagCollect invokes CollectDiagnosticData on a log service, follows the
+// task it spawns and downloads the data it collected. The spinner shows the
+// task's state, then the download's progress.
+func (n *Navigator) diagCollect(args []string) (string, error) {
+	d, err := parseDiagArgs(args)
+	if err != nil {
+		return "", err
+	}
+	p := n.cwd
+	if d.target != "" {
+		resolved, err := n.vfs.ResolveTarget(n.cwd, d.target)
+		if err != nil {
+			return "", err
+		}
+		if resolved.Type == rvfs.TargetProperty {
+			return "", fmt.Errorf("not a resource: %s", d.target)
+		}
+		p = resolved.ResourcePath
+	}
+	actions, err := discoverActions(n.vfs, p)
+	if err != nil {
+		return "", err
+	}
+	action := matchAction(actions, rvfs.CollectDiagnosticDataAction)
+	if action == nil {
+		return "", fmt.Errorf("%s has no CollectDiagnosticData action", p)
+	}
+	body, err := parseActionBody(action, d.params)
+	if err != nil {
+		return "", err
+	}
+	if err := n.writeRefusal(p); err != nil {
+		return "", err
+	}
+
+	defer n.task.Store(nil)
+	result, err := rvfs.CollectDiagnosticData(n.vfs, p, body, taskPollInterval, func(status *rvfs.TaskStatus) {
+		n.task.Store(status)
+	})
+	if err != nil {
+		return "", err
+	}
+	n.task.Store(nil)
+
+	var b strings.Builder
+	if result.Task != nil {
+		fmt.Fprintf(&b, "Task %s: %s\n", result.Task.Path, result.Task.State)
+	}
+	fmt.Fprintf(&b, "Collected into %s\n", result.Entry)
+
+	filename := d.file
+	if filename == "" {
+		filename = diagFile(result.Entry, time.Now())
+	}
+	t := &transfer{}
+	t.total.Store(-1)
+	n.transfer.Store(t)
+	defer n.transfer.Store(nil)
+	written, err := saveDownload(n.vfs, result.Download, filename, func(written, total int64) {
+		t.written.Store(written)
+		t.total.Store(total)
+	})
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(&b, "Saved %s from %s to %s", formatBytes(written), result.Download, filename)
+	return b.String(), nil
+}
+
+// sparklineWidth is how many of the latest readings a sparkline draws
+const sparklineWidth = 40
+
+// metricsUsage is the error for malformed metrics arguments
+var metricsUsage = fmt.Errorf("usage: metrics list | metrics show <report> [-t] [-o file.csv] | metrics define <id> ...")
+
+// metrics lists the TelemetryService's report definitions and reports, or
+// shows the readings of one report: metrics list, metrics show <report>
+func (n *Navigator) metrics(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", metricsUsage
+	}
+	switch args[0] {
+	case "list":
+		if len(args) != 1 {
+			return "", metricsUsage
+		}
+		t, err := rvfs.ReadTelemetry(n.vfs)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(formatTelemetry(t), "\n"), nil
+	case "show":
+		return n.showMetrics(args[1:])
+	}
+	return "", metricsUsage
+}
+
+// showMetrics shows a report's readings, a sparkline per metric or with -t
+// a table of every reading, and with -o exports them to a CSV file. The
+// report is named by its Id in MetricReports or by its path.
+func (n *Navigator) showMetrics(args []string) (string, error) {
+	var report, file string
+	table := false
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-t":
+			table = true
+		case args[i] == "-o" && i+1 < len(args):
+			i++
+			file = args[i]
+		case report == "" && !strings.HasPrefix(args[i], "-"):
+			report = args[i]
+		default:
+			return "", metricsUsage
+		}
+	}
+	if report == "" {
+		return "", metricsUsage
+	}
+
+	path, err := rvfs.MetricReportPath(n.vfs, report)
+	if err != nil {
+		resolved, resolveErr := n.vfs.ResolveTarget(n.cwd, report)
+		if resolveErr != nil || resolved.Type == rvfs.TargetProperty {
+			return "", err
+		}
+		path = resolved.ResourcePath
+	}
+	r, err := rvfs.ReadMetricReport(n.vfs, path)
+	if err != nil {
+		return "", err
+	}
+
+	if file != "" {
+		f, err := os.Create(file)
+		if err != nil {
+			return "", err
+		}
+		err = rvfs.WriteMetricCSV(f, r)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Exported %d readings of %s to %s", len(r.Readings), r.Path, file), nil
+	}
+	if table {
+		return strings.TrimRight(formatMetricTable(r), "\n"), nil
+	}
+	return strings.TrimRight(formatMetricReport(r), "\n"), nil
+}
+
+// memory shows the MemorySummary and modules of every system, or of the
+// system at target; --failed keeps the modules that are unhealthy
+func (n *Navigator) memory(args []string) (string, error) {
+	base, failed := n.vfs.Root(), false
+	for _, a := range args {
+		switch {
+		case a == "--failed":
+			failed = true
+		case base == n.vfs.Root() && !strings.HasPrefix(a, "-"):
+			resolved, err := n.vfs.ResolveTarget(n.cwd, a)
+			if err != nil {
+				return "", err
+			}
+			if resolved.Type == rvfs.TargetProperty {
+				return "", fmt.Errorf("not a resource: %s", a)
+			}
+			base = resolved.ResourcePath
+		default:
+			return "", fmt.Errorf("usage: memory [path] [--failed]")
+		}
+	}
+	memory, err := rvfs.ReadMemory(n.vfs, base)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(formatMemory(memory, failed), "\n"), nil
+}
+
+// cpu lists the processors of every system, or of the system at target,
+// CPUs first and then GPUs and other accelerators
+func (n *Navigator) cpu(args []string) (string, error) {
+	if len(args) > 1 {
+		return "", fmt.Errorf("usage: cpu [path]")
+	}
+	base := n.vfs.Root()
+	if len(args) == 1 {
+		resolved, err := n.vfs.ResolveTarget(n.cwd, args[0])
+		if err != nil {
+			return "", err
+		}
+		if resolved.Type == rvfs.TargetProperty {
+			return "", fmt.Errorf("not a resource: %s", args[0])
+		}
+		base = resolved.ResourcePath
+	}
+	processors, err := rvfs.ReadProcessors(n.vfs, base)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(formatProcessors(processors), "\n"), nil
+}
+
+// firmware lists the UpdateService's FirmwareInventory
+func (n *Navigator) firmware(args []string) (string, error) {
+	if len(args) != 0 {
+		return "", fmt.Errorf("usage: firmware")
+	}
+	inventory, err := rvfs.ReadFirmwareInventory(n.vfs)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(formatFirmwareInventory(inventory), "\n"), nil
+}
+
+// pcie shows the PCIe devices of the service, or of the system or chassis
+// at target, as a tree of their functions and the resources they serve
+func (n *Navigator) pcie(args []string) (string, error) {
+	if len(args) > 1 {
+		return "", fmt.Errorf("usage: pcie [path]")
+	}
+	base := n.vfs.Root()
+	if len(args) == 1 {
+		resolved, err := n.vfs.ResolveTarget(n.cwd, args[0])
+		if err != nil {
+			return "", err
+		}
+		if resolved.Type == rvfs.TargetProperty {
+			return "", fmt.Errorf("not a resource: %s", args[0])
+		}
+		base = resolved.ResourcePath
+	}
+	devices, err := rvfs.PCIeTopology(n.vfs, base)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(formatPCIe(devices), "\n"), nil
+}
+
+// topology shows the physical containment hierarchy of the service, or of
+// the chassis at target
+func (n *Navigator) topology(args []string) (string, error) {
+	if len(args) > 1 {
+		return "", fmt.Errorf("usage: topology [chassis]")
+	}
+	base := n.vfs.Root()
+	if len(args) == 1 {
+		resolved, err := n.vfs.ResolveTarget(n.cwd, args[0])
+		if err != nil {
+			return "", err
+		}
+		if resolved.Type == rvfs.TargetProperty {
+			return "", fmt.Errorf("not a resource: %s", args[0])
+		}
+		base = resolved.ResourcePath
+	}
+	nodes, err := rvfs.Topology(n.vfs, base)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(formatTopology(nodes), "\n"), nil
+}
+
+// locate turns the locator LED of a system, chassis or drive on or off:
+// locate on|off [target], the current resource by default
+func (n *Navigator) locate(args []string) (string, error) {
+	if len(args) == 0 || len(args) > 2 || (args[0] != "on" && args[0] != "off") {
+		return "", fmt.Errorf("usage: locate on|off [path]")
+	}
+	path := n.cwd
+	if len(args) == 2 {
+		resolved, err := n.vfs.ResolveTarget(n.cwd, args[1])
+		if err != nil {
+			return "", err
+		}
+		if resolved.Type == rvfs.TargetProperty {
+			return "", fmt.Errorf("not a resource: %s", args[1])
+		}
+		path = resolved.ResourcePath
+	}
+	if err := n.writeRefusal(path); err != nil {
+		return "", err
+	}
+	change, err := rvfs.SetLocationIndicator(n.vfs, path, args[0] == "on")
+	if err != nil {
+		return "", err
+	}
+	n.history.RecordPatch(change.Resource, []rvfs.Change{change})
+	return fmt.Sprintf("Locator %s: %s %s", args[0], path, dimStyle.Render("("+change.Property[0]+")")), nil
+}
+
+// stat shows the methods the service allows on a resource, and whether a
+// PATCH or DELETE of it would go through the service and the role
+func (n *Navigator) stat(target string) (string, error) {
+	p := n.cwd
+	var chain []rvfs.Hop
+	if target != "" {
+		resolved, hops, err := n.vfs.ResolveChain(n.cwd, target)
+		if err != nil {
+			return "", err
+		}
+		if resolved.Type == rvfs.TargetProperty {
+			return "", fmt.Errorf("not a resource: %s", target)
+		}
+		p, chain = resolved.ResourcePath, hops
+	}
+	info, err := n.vfs.Stat(p)
+	if err != nil {
+		return "", err
+	}
+	// A path through links shows where they lead first
+	return strings.TrimRight(formatChain(chain)+formatStat(info, n.writeRefusal(p)), "\n"), nil
+}
+
+// writeMethods are the methods stat says a write would use
+var writeMethods = []string{"PATCH", "DELETE"}
+
+// formatStat formats the Allow header of a resource and a line per write
+// method, refused by the service or, given a refusal, by the role
+func formatStat(info *rvfs.ResourceInfo, refusal error) string {
+	var b strings.Builder
+	b.WriteString(info.Path + "\n")
+	allow := dimStyle.Render("not reported")
+	if info.Allow != nil {
+		allow = strings.Join(info.Allow, ", ")
+	}
+	fmt.Fprintf(&b, "  %-8s %s\n", "Allow:", allow)
+	for _, method := range writeMethods {
+		var state string
+		switch {
+		case !info.Allows(method):
+			state = errorStyle.Render("not allowed by the service")
+		case refusal != nil:
+			state = warnStyle.Render("refused: " + refusal.Error())
+		case info.Allow == nil:
+			state = dimStyle.Render("unknown; the service did not say")
+		default:
+			state = healthOKStyle.Render("allowed")
+		}
+		fmt.Fprintf(&b, "  %-8s %s\n", method+":", state)
+	}
+	return b.String()
+}
+
+// privileges maps a resource against the service's PrivilegeRegistry:
+// which roles may GET, PATCH, POST or DELETE it, and what the shell's own
+// role may not do. A property stands for the resource holding it.
+func (n *Navigator) privileges(target string) (string, error) {
+	resolved, err := n.vfs.ResolveTarget(n.cwd, target)
+	if err != nil {
+		return "", err
+	}
+	path := resolved.ResourcePath
+	if resolved.Type == rvfs.TargetProperty {
+		path = resolved.Resource.Path
+	}
+	m, err := rvfs.ResourcePrivileges(n.vfs, path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(formatPrivileges(m, n.role), "\n"), nil
+}
+
+// formatPrivileges lists, per operation on a resource, the privilege sets
+// the service's registry asks for and the roles holding one, the role the
+// shell acts as in bold, then what that role may not do
+func formatPrivileges(m *rvfs.PrivilegeMap, role *rvfs.SessionRole) string {
+	var b strings.Builder
+	b.WriteString(m.Resource + "  " + dimStyle.Render(m.Entity+", per "+m.Registry) + "\n")
+	if m.Override != "" {
+		b.WriteString("  " + warnStyle.Render("Override: "+m.Override) + "\n")
+	}
+	var refused []string
+	for _, op := range m.Operations {
+		fmt.Fprintf(&b, "  %-8s %s\n", op.Operation+":", formatAccess(op, role))
+		if role != nil && op.Privileges != nil && !slices.Contains(op.Roles, role.RoleID) {
+			refused = append(refused, op.Operation)
+		}
+	}
+	for _, p := range m.Properties {
+		fmt.Fprintf(&b, "  %s %s\n", "PATCH "+propStyle.Render(p.Property)+":", formatAccess(p.Access, role))
+	}
+	switch {
+	case role == nil:
+	case len(refused) == 0:
+		b.WriteString(healthOKStyle.Render("Role "+role.RoleID+" may do all of them") + "\n")
+	default:
+		b.WriteString(warnStyle.Render("Role "+role.RoleID+" may not "+strings.Join(refused, ", ")) + "\n")
+	}
+	return b.String()
+}
+
+// formatAccess shows the privilege sets allowing an operation, any one of
+// them whole, and the roles holding one
+func formatAccess(access rvfs.OperationAccess, role *rvfs.SessionRole) string {
+	if access.Privileges == nil {
+		return dimStyle.Render("not mapped")
+	}
+	sets := make([]string, len(access.Privileges))
+	for i, set := range access.Privileges {
+		sets[i] = strings.Join(set, "+")
+		if len(set) == 0 {
+			sets[i] = "no privilege"
+		}
+	}
+	s := strings.Join(sets, " | ") + dimStyle.Render(" → ") + roleNames(access.Roles, role)
+	if len(access.SelfRoles) > 0 {
+		s += dimStyle.Render("; own account only: ") + roleNames(access.SelfRoles, role)
+	}
+	return s
+}
+
+// roleNames lists roles, the one the shell acts as in bold
+func roleNames(roles []string, role *rvfs.SessionRole) string {
+	if len(roles) == 0 {
+		return errorStyle.Render("no role")
+	}
+	names := make([]string, len(roles))
+	for i, name := range roles {
+		names[i] = name
+		if role != nil && name == role.RoleID {
+			names[i] = boldStyle.Render(name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// cache handles cache commands
+func (n *Navigator) cache(args []string) (string, error) {
+	if len(args) == 0 {
+		paths := n.vfs.GetKnownPaths()
+		return fmt.Sprintf("Cache: %d resources", len(paths)), nil
+	}
+
+	switch args[0] {
+	case "clear":
+		n.vfs.Clear()
+		return "Cache cleared", nil
+	case "list":
+		paths := n.vfs.GetKnownPaths()
+		sort.Strings(paths)
+		return strings.Join(paths, "\n"), nil
+	default:
+		return "", fmt.Errorf("unknown cache command: %s (try: clear, list)", args[0])
+	}
+}
+
+// settingNames are the settings set changes, in listing order
+var settingNames = []string{"humanize", "ages", "notify", "urilinks", "powerwatch", "views", "prefetch", "json"}
+
+// toggles returns the settings set changes by name
+func (n *Navigator) toggles() map[string]*bool {
+	return map[string]*bool{"humanize": &n.humanize, "ages": &n.ages, "notify": &n.notify, "urilinks": &n.uriLinks, "powerwatch": &n.power, "views": &n.views, "prefetch": &n.prefetch, "json": &n.json}
+}
+
+// set changes a setting, or lists the settings without arguments
+func (n *Navigator) set(args []string) (string, error) {
+	settings := n.toggles()
+	if len(args) > 0 {
+		setting, ok := settings[args[0]]
+		if len(args) != 2 || !ok {
+			return "", fmt.Errorf("usage: set [humanize|ages|notify|urilinks|powerwatch|views|prefetch|json on|off]")
+		}
+		switch args[1] {
+		case "on":
+			*setting = true
+		case "off":
+			*setting = false
+		default:
+			return "", fmt.Errorf("usage: set [humanize|ages|notify|urilinks|powerwatch|views|prefetch|json on|off]")
+		}
+	}
+	lines := make([]string, len(settingNames))
+	for i, name := range settingNames {
+		if *settings[name] {
+			lines[i] = name + " on"
+		} else {
+			lines[i] = name + " off"
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// runPlugin runs a plugin command under ctx and returns what it printed
+func (n *Navigator) runPlugin(ctx context.Context, p *plugin.Command, args []string) (string, error) {
+	var out strings.Builder
+	err := p.Run(&plugin.Env{
+		Context:  ctx,
+		VFS:      n.vfs,
+		Cwd:      n.cwd,
+		Endpoint: n.endpoint,
+		User:     n.user,
+		Out:      &out,
+	}, args)
+	return strings.TrimRight(out.String(), "\n"), err
+}
+
+// setRedact switches redaction on or off, or reports its state
+func (n *Navigator) setRedact(args []string) (string, error) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "on":
+			n.redact = true
+		case "off":
+			n.redact = false
+		default:
+			return "", fmt.Errorf("usage: redact [on|off]")
+		}
+	}
+	switch {
+	case !n.redact:
+		return "Redaction off", nil
+	case !n.redaction.Active():
+		return "Redaction on, but the config names no properties (redact.properties)", nil
+	}
+	return "Redaction on: " + strings.Join(n.redaction.Properties, ", "), nil
+}
+
+// redacts reports whether the values of properties named name are masked
+func (n *Navigator) redacts(name string) bool {
+	return n.redact && n.redaction.Redacts(name)
+}
+
+// redacted returns the JSON value of the property named name ("" for a
+// resource) with the redacted values in it masked, when redaction is on
+func (n *Navigator) redacted(name string, raw []byte) []byte {
+	if !n.redact {
+		return raw
+	}
+	return n.redaction.Redact(name, raw)
+}
+
+// setTrace switches request tracing on or off, or reports its state
+func (n *Navigator) setTrace(args []string) (string, error) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "on":
+			n.trace = true
+		case "off":
+			n.trace = false
+		default:
+			return "", fmt.Errorf("usage: trace [on|off]")
+		}
+	}
+	if n.trace {
+		return "Trace on", nil
+	}
+	return "Trace off", nil
+}
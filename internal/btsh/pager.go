@@ -0,0 +1,52 @@
+package btsh
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dumpPageBytes is the size of rendered JSON past which dump shows it in
+// the pager rather than flooding the scrollback
+const dumpPageBytes = 64 << 10
+
+// handlePage shows long output in the pager; a playing macro gets it
+// printed, as it cannot page
+func (m model) handlePage(msg pageMsg) (tea.Model, tea.Cmd) {
+	if m.state.playing != "" || m.height < 3 {
+		return m.handleCommandResult(commandResultMsg{output: msg.output})
+	}
+	m.mode = ModePager
+	m.pager = viewport.New(m.width, m.height-1)
+	m.pager.SetContent(msg.output)
+	m.pagerNotice = msg.notice
+	return m, nil
+}
+
+// handlePagerKey scrolls the pager: the arrows, PgUp/PgDn, space, b, u, d,
+// g and G move, q, Esc and Ctrl+C leave
+func (m model) handlePagerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc", "ctrl+c":
+		m.pager = viewport.Model{}
+		return m.handleCommandResult(commandResultMsg{output: m.pagerNotice})
+	case "g", "home":
+		m.pager.GotoTop()
+		return m, nil
+	case "G", "end":
+		m.pager.GotoBottom()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.pager, cmd = m.pager.Update(msg)
+	return m, cmd
+}
+
+// pagerView renders the pager's page and a status line under it
+func (m model) pagerView() string {
+	status := fmt.Sprintf("-- %d-%d of %d lines (%3.f%%), q to stop --",
+		m.pager.YOffset+1, min(m.pager.YOffset+m.pager.Height, m.pager.TotalLineCount()),
+		m.pager.TotalLineCount(), m.pager.ScrollPercent()*100)
+	return m.pager.View() + "\n" + dimStyle.Render(status)
+}
@@ -0,0 +1,169 @@
+package btsh
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+)
+
+// result is what a command found, apart from how it is shown: the
+// navigator returns one and the shell renders it at the edge, as text for
+// the terminal or as JSON for scripts (--json, set json). Tests check
+// results without going through the text.
+type result interface {
+	text(n *Navigator) (string, error)
+}
+
+// render shows a result as JSON when asked for with --json or set json,
+// and as text otherwise
+func (n *Navigator) render(r result, asJSON bool) (string, error) {
+	if !asJSON && !n.json {
+		return r.text(n)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// rendered is the message of a command returning a result
+func (n *Navigator) rendered(r result, asJSON bool, err error) commandResultMsg {
+	if err != nil {
+		return commandResultMsg{err: err}
+	}
+	output, err := n.render(r, asJSON)
+	return commandResultMsg{output: output, err: err}
+}
+
+// jsonFlag takes --json out of a command's arguments and reports whether
+// it was among them
+func jsonFlag(args []string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg != "--json" {
+			rest = append(rest, arg)
+		}
+	}
+	return rest, len(rest) < len(args)
+}
+
+// listing is what ls found at a path, the entries in display order
+type listing struct {
+	Path      string        `json:"path"`
+	Entries   []listedEntry `json:"entries"`
+	FetchedAt time.Time     `json:"fetched_at,omitzero"`
+}
+
+// listedEntry is an entry of a listing
+type listedEntry struct {
+	Name     string    `json:"name"`
+	Path     string    `json:"path"`
+	Type     string    `json:"type"`           // resource, link, property, object, array or symlink
+	Link     string    `json:"link,omitempty"` // Where a link inside a property leads
+	Member   int       `json:"member,omitempty"`
+	Actions  bool      `json:"actions,omitempty"`
+	Denied   bool      `json:"denied,omitempty"`
+	Modified time.Time `json:"modified,omitzero"` // Zero for an uncached child
+
+	entry *rvfs.Entry
+}
+
+// text lays the entries out in columns, members numbered for %N, with the
+// age of the data below
+func (l *listing) text(n *Navigator) (string, error) {
+	var b strings.Builder
+	if len(l.Entries) == 0 {
+		b.WriteString("(empty)")
+	} else {
+		items := make([]string, len(l.Entries))
+		for i, e := range l.Entries {
+			items[i] = n.formatAgedEntry(e.entry)
+			if e.Member > 0 {
+				items[i] = dimStyle.Render("%"+strconv.Itoa(e.Member)) + " " + items[i]
+			}
+		}
+		b.WriteString(formatColumns(items))
+		if n.ages {
+			b.WriteString("\n" + ageLegend())
+		}
+	}
+	if !l.FetchedAt.IsZero() {
+		b.WriteString("\n")
+		b.WriteString(dimStyle.Render(formatAge(l.FetchedAt)))
+	}
+	return b.String(), nil
+}
+
+// shown is what ll found: a resource, with the conditions it reports and
+// the spec violations its parser worked around, or a property. Values are
+// redacted as the shell redacts them.
+type shown struct {
+	Path       string           `json:"path"`
+	ODataType  string           `json:"odata_type,omitempty"`
+	Value      json.RawMessage  `json:"value"`
+	Conditions []rvfs.Condition `json:"conditions,omitempty"`
+	Warnings   []string         `json:"warnings,omitempty"`
+	FetchedAt  time.Time        `json:"fetched_at,omitzero"`
+
+	property *rvfs.Property // nil for a resource
+	whole    bool           // An array named directly, shown in full
+}
+
+// text shows a resource the way its type is viewed, or a property with
+// its children
+func (s *shown) text(n *Navigator) (string, error) {
+	var b strings.Builder
+	if s.property == nil {
+		if err := n.showResource(&b, s.Path); err != nil {
+			return "", err
+		}
+		if !s.FetchedAt.IsZero() {
+			b.WriteString(dimStyle.Render(formatAge(s.FetchedAt)))
+		}
+		return b.String(), nil
+	}
+	path := s.Path
+	if s.whole {
+		path = ""
+	}
+	n.showProperty(&b, s.property, 0, false, path)
+	return b.String(), nil
+}
+
+// matches is what find -c or grep found in the cache, grouped by the
+// resource holding them
+type matches struct {
+	Query   string         `json:"query"`
+	Matches []matchedValue `json:"matches"`
+}
+
+// matchedValue is a property found, numbered for %N
+type matchedValue struct {
+	Resource string          `json:"resource"`
+	Property string          `json:"property"`
+	Value    json.RawMessage `json:"value"`
+	Member   int             `json:"member"`
+
+	value *rvfs.Property
+}
+
+// text lists the properties found under the resource holding them
+func (m *matches) text(n *Navigator) (string, error) {
+	if len(m.Matches) == 0 {
+		return fmt.Sprintf("No matches found for '%s' in the cache", m.Query), nil
+	}
+	var lines []string
+	for i, match := range m.Matches {
+		if i == 0 || m.Matches[i-1].Resource != match.Resource {
+			lines = append(lines, childStyle.Render(match.Resource))
+		}
+		lines = append(lines, fmt.Sprintf("  %s %s = %s", dimStyle.Render("%"+strconv.Itoa(match.Member)),
+			warnStyle.Render(match.Property), formatPropertyValue(match.value)))
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,114 @@
+// Package btsh is the Redfish shell built on bubbletea.
+package btsh
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bluefish-project/bluefish/internal/config"
+	"github.com/bluefish-project/bluefish/plugin"
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/theme"
+)
+
+// Run connects with cfg and runs the shell until the user exits. ttyInput
+// reads keys from the terminal rather than stdin, for when stdin held the
+// config.
+func Run(cfg *config.Config, ttyInput bool) (err error) {
+	t, err := theme.Load(cfg.Theme)
+	if err != nil {
+		return fmt.Errorf("theme config: %w", err)
+	}
+	applyTheme(t)
+	if err := plugin.RegisterExternal(cfg.Commands); err != nil {
+		return fmt.Errorf("commands config: %w", err)
+	}
+
+	fmt.Printf("Connecting to %s...\n", cfg.Endpoint)
+	vfs, closeVFS, err := cfg.Connect()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := closeVFS(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	nav := NewNavigator(vfs)
+	nav.endpoint, nav.user = cfg.Endpoint, cfg.User
+	nav.redaction = cfg.Redact
+	nav.applySettings(cfg.Settings)
+	history := NewHistory(os.ExpandEnv("$HOME/.btsh_history"))
+	if cfg.Session != nil {
+		// A recorded or replayed session starts without history, so the
+		// arrow keys recall the same lines in both
+		history = NewHistory("")
+	}
+
+	// Show what we connected to; these are the first requests that may
+	// need a session
+	summary, err := rvfs.SummarizeService(vfs)
+	if err != nil {
+		return err
+	}
+	fmt.Println(formatBanner(summary))
+	entries, err := vfs.ListAll(nav.cwd)
+	if err != nil {
+		return err
+	}
+	fmt.Println(nav.summaryLine(entries))
+	if quirks := vfs.Quirks(); len(quirks) > 0 {
+		fmt.Printf("Vendor quirks: %s\n", quirks)
+	}
+	if cfg.Simulate.Enabled() {
+		fmt.Println(warnStyle.Render("Simulating: " + cfg.Simulate.String()))
+	}
+	if nav.role, err = rvfs.ResolveRole(vfs, cfg.User); err != nil {
+		fmt.Println(dimStyle.Render(fmt.Sprintf("Role: unknown (%v); writes are not checked", err)))
+	} else {
+		fmt.Printf("Role: %s\n", nav.role)
+	}
+	fmt.Println("Type 'help' for commands")
+
+	state := &shellState{
+		nav:     nav,
+		history: history,
+		macros:  NewMacros(os.ExpandEnv("$HOME/.btsh_macros")),
+
+		commandTimeout: cfg.CommandTimeout,
+		scrapePolicy:   cfg.Scrape,
+		theme:          cfg.Theme,
+		keymap:         cfg.Keymap,
+	}
+
+	// The commands to run on connecting play like a macro
+	for _, line := range cfg.OnConnect {
+		if line = strings.TrimSpace(line); line != "" {
+			state.playQueue = append(state.playQueue, line)
+		}
+	}
+	if len(state.playQueue) > 0 {
+		state.playing = "on_connect"
+	}
+
+	m := newModel(state)
+	opts := []tea.ProgramOption{tea.WithoutCatchPanics()}
+	if ttyInput {
+		// Stdin held the config; keys come from the terminal
+		opts = append(opts, tea.WithInputTTY())
+	}
+	if cfg.Session != nil {
+		opts = append(opts, tea.WithFilter(cfg.Session.Filter))
+	}
+	p := tea.NewProgram(m, opts...)
+	if cfg.Session != nil {
+		cfg.Session.Start(p)
+	}
+
+	_, err = p.Run()
+	return err
+}
@@ -0,0 +1,144 @@
+package btsh
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/bluefish-project/bluefish/internal/config"
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/theme"
+)
+
+// settingsUsage is the error for malformed settings arguments
+var settingsUsage = fmt.Errorf("usage: settings export|import <file.yaml>")
+
+// settings exports the shell's settings, bookmarks, watch list and macros
+// to a file, or imports them from one: settings export|import FILE
+func (s *shellState) settings(args []string) (string, error) {
+	if len(args) != 2 {
+		return "", settingsUsage
+	}
+	switch args[0] {
+	case "export":
+		return s.exportSettings(args[1])
+	case "import":
+		return s.importSettings(args[1])
+	}
+	return "", settingsUsage
+}
+
+// exportSettings writes the settings the shell runs with to file
+func (s *shellState) exportSettings(file string) (string, error) {
+	nav := s.nav
+	out := config.Settings{
+		Theme:     s.theme,
+		Keymap:    s.keymap,
+		Redact:    rvfs.Redaction{Properties: nav.redaction.Properties},
+		Set:       make(map[string]bool),
+		Bookmarks: nav.bookmarks,
+		Watchlist: nav.watchList.Paths(),
+	}
+	for name, on := range nav.toggles() {
+		out.Set[name] = *on
+	}
+	for _, name := range s.macros.Names() {
+		lines, err := s.macros.Load(name)
+		if err != nil {
+			return "", err
+		}
+		if out.Macros == nil {
+			out.Macros = make(map[string][]string)
+		}
+		out.Macros[name] = lines
+	}
+	if err := config.WriteSettings(file, &out); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Exported settings to %s: %s", file, settingsSummary(&out)), nil
+}
+
+// importSettings applies the settings in file to the shell and keeps them
+// in config.SettingsFile for every frontend started after. Theme, redaction
+// and shell settings replace the shell's; bookmarks and watched resources
+// are added to its own. Macros are saved as macro files.
+func (s *shellState) importSettings(file string) (string, error) {
+	in, err := config.ReadSettings(file)
+	if err != nil {
+		return "", err
+	}
+	nav := s.nav
+	toggles := nav.toggles()
+	for name := range in.Set {
+		if _, ok := toggles[name]; !ok {
+			return "", fmt.Errorf("%s: unknown setting %q (%s)", file, name, strings.Join(settingNames, ", "))
+		}
+	}
+	t, err := theme.Load(in.Theme)
+	if err != nil {
+		return "", err
+	}
+
+	applyTheme(t)
+	s.theme, s.keymap = in.Theme, in.Keymap
+	nav.redaction = in.Redact
+	for name, on := range in.Set {
+		*toggles[name] = on
+	}
+	for _, path := range in.Bookmarks {
+		if !slices.Contains(nav.bookmarks, path) {
+			nav.bookmarks = append(nav.bookmarks, path)
+		}
+	}
+	for _, path := range in.Watchlist {
+		nav.watchList.Add(path)
+	}
+	for _, name := range slices.Sorted(maps.Keys(in.Macros)) {
+		if err := s.macros.Save(name, in.Macros[name]); err != nil {
+			return "", err
+		}
+	}
+
+	kept := *in
+	kept.Macros = nil
+	if err := config.WriteSettings(config.SettingsFile(), &kept); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Imported settings from %s: %s\nKept in %s", file, settingsSummary(in), config.SettingsFile()), nil
+}
+
+// applySettings starts the shell with the imported settings beyond theme
+// and redaction, which the config carries: shell settings, bookmarks and
+// watched resources. Settings the shell does not know are skipped.
+func (n *Navigator) applySettings(s config.Settings) {
+	toggles := n.toggles()
+	for name, on := range s.Set {
+		if setting, ok := toggles[name]; ok {
+			*setting = on
+		}
+	}
+	n.bookmarks = slices.Clone(s.Bookmarks)
+	for _, path := range s.Watchlist {
+		n.watchList.Add(path)
+	}
+}
+
+// settingsSummary counts what a settings file holds
+func settingsSummary(s *config.Settings) string {
+	name := s.Theme.Name
+	if name == "" {
+		name = "dark"
+	}
+	return fmt.Sprintf("theme %s, %d key bindings, %d redacted patterns, %d settings, %d bookmarks, %d watched, %d macros",
+		name, countBindings(s.Keymap), len(s.Redact.Properties), len(s.Set), len(s.Bookmarks), len(s.Watchlist), len(s.Macros))
+}
+
+// countBindings counts the remapped bindings of a keymap, over its modes
+func countBindings(keymap map[string]map[string][]string) int {
+	n := 0
+	for _, bindings := range keymap {
+		n += len(bindings)
+	}
+	return n
+}
@@ -0,0 +1,104 @@
+package btsh
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bluefish-project/bluefish/internal/workspace"
+	"github.com/bluefish-project/bluefish/rvfs"
+)
+
+// bookmark adds a resource to the bookmarks, the current one when target
+// is empty
+func (n *Navigator) bookmark(target string) (string, error) {
+	path := n.cwd
+	if target != "" {
+		resolved, err := n.vfs.ResolveTarget(n.cwd, target)
+		if err != nil {
+			return "", err
+		}
+		if resolved.Type == rvfs.TargetProperty {
+			return "", fmt.Errorf("not a resource: %s", target)
+		}
+		path = resolved.ResourcePath
+	}
+	if slices.Contains(n.bookmarks, path) {
+		return "", fmt.Errorf("already bookmarked: %s", path)
+	}
+	n.bookmarks = append(n.bookmarks, path)
+	return "Bookmarked " + path, nil
+}
+
+// unbookmark removes a bookmark, named by its path or by %N from the last
+// bookmark listing
+func (n *Navigator) unbookmark(target string) (string, error) {
+	path := n.vfs.Join(n.cwd, target)
+	i := slices.Index(n.bookmarks, path)
+	if i < 0 {
+		return "", fmt.Errorf("not bookmarked: %s", path)
+	}
+	n.bookmarks = slices.Delete(n.bookmarks, i, i+1)
+	return "Removed bookmark " + path, nil
+}
+
+// listBookmarks numbers the bookmarks so %N goes to one: cd %2
+func (n *Navigator) listBookmarks() string {
+	if len(n.bookmarks) == 0 {
+		return "No bookmarks"
+	}
+	n.members = slices.Clone(n.bookmarks)
+	lines := make([]string, len(n.bookmarks))
+	for i, path := range n.bookmarks {
+		lines[i] = dimStyle.Render("%"+strconv.Itoa(i+1)) + " " + path
+	}
+	return strings.Join(lines, "\n")
+}
+
+// workspace lists the saved workspaces, or saves or opens one:
+// workspace [save|open NAME]
+func (n *Navigator) workspace(args []string) (string, error) {
+	if len(args) == 0 {
+		names, err := workspace.List()
+		if err != nil {
+			return "", err
+		}
+		if len(names) == 0 {
+			return "No saved workspaces", nil
+		}
+		return strings.Join(names, "\n"), nil
+	}
+	if len(args) != 2 {
+		return "", fmt.Errorf("usage: workspace [save|open NAME]")
+	}
+	name := args[1]
+	switch args[0] {
+	case "save":
+		w := &workspace.Workspace{
+			Endpoint:  n.endpoint,
+			BasePath:  n.cwd,
+			Bookmarks: n.bookmarks,
+		}
+		if err := workspace.Save(name, w); err != nil {
+			return "", err
+		}
+		return "Saved workspace to " + workspace.File(name), nil
+	case "open":
+		w, err := workspace.Load(name)
+		if err != nil {
+			return "", err
+		}
+		summary, err := n.cd(w.BasePath)
+		if err != nil {
+			return "", err
+		}
+		n.bookmarks = w.Bookmarks
+		out := fmt.Sprintf("Opened workspace %s: %d bookmarks\n%s", name, len(w.Bookmarks), summary)
+		if w.Endpoint != "" && w.Endpoint != n.endpoint {
+			out += "\n" + warnStyle.Render("Saved on "+w.Endpoint+"; paths may not exist here")
+		}
+		return out, nil
+	}
+	return "", fmt.Errorf("usage: workspace [save|open NAME]")
+}
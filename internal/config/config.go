@@ -0,0 +1,297 @@
+// Package config loads the configuration the bluefish frontends share and
+// opens the connection it describes.
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bluefish-project/bluefish/internal/session"
+	"github.com/bluefish-project/bluefish/plugin"
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/theme"
+)
+
+// DefaultCommandTimeout is how long a command waits for the service when
+// the config sets no command_timeout
+const DefaultCommandTimeout = time.Minute
+
+// Config holds connection configuration
+type Config struct {
+	Endpoint string `yaml:"endpoint"`
+	User     string `yaml:"user"`
+	Pass     string `yaml:"pass"`
+	Insecure bool   `yaml:"insecure"`
+
+	// Version pins the Redfish protocol version, e.g. v1; by default the
+	// newest the service lists at /redfish
+	Version string `yaml:"version"`
+
+	// Parser overrides URI reference detection for vendor payloads
+	Parser rvfs.ParserOptions `yaml:"parser"`
+
+	// Quirks works around firmware that departs from the Redfish spec
+	Quirks rvfs.QuirkOptions `yaml:"quirks"`
+
+	// Theme selects the color theme (dark, light, mono) and role overrides
+	Theme theme.Config `yaml:"theme"`
+
+	// Keymap remaps bfui key bindings: mode, then binding name, then the
+	// keys that trigger it
+	Keymap map[string]map[string][]string `yaml:"keymap"`
+
+	// Record writes the session's HTTP exchanges to a cassette file on exit,
+	// for replay in tests
+	Record string `yaml:"record"`
+
+	// CommandTimeout bounds how long one command waits for the service,
+	// e.g. 30s; a command still waiting then fails with a timeout
+	CommandTimeout time.Duration `yaml:"command_timeout"`
+
+	// Scrape sets how crawls retry transient failures and which paths
+	// they stay away from
+	Scrape rvfs.ScrapePolicy `yaml:"scrape"`
+
+	// Redact masks the values of properties by name in what the frontends
+	// show and export
+	Redact rvfs.Redaction `yaml:"redact"`
+
+	// Simulate slows the service down and injects server errors and
+	// malformed payloads, for exercising the frontends' error paths
+	Simulate rvfs.Simulation `yaml:"simulate"`
+
+	// Commands adds shell commands carried out by external programs
+	Commands []plugin.External `yaml:"commands"`
+
+	// OnConnect lists the commands the shells run after connecting, as if
+	// typed, e.g. ["cd Systems/1", "ll Status"]
+	OnConnect []string `yaml:"on_connect"`
+
+	// Settings are the imported settings the config was read over, for
+	// what the shells take from them beyond theme, keymap and redact
+	Settings Settings `yaml:"-"`
+
+	// Session records the session for a bug report, or replays a recorded
+	// one; set by -record-session and by replay, never from the file
+	Session *session.Session `yaml:"-"`
+}
+
+// Load reads the config from path and validates it
+func Load(path string) (*Config, error) {
+	cfg, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Read reads the config from a YAML file, from stdin when path is "-", or
+// from the environment alone when path is "", over the imported settings.
+// BLUEFISH_ENDPOINT, BLUEFISH_USER, BLUEFISH_PASS and BLUEFISH_INSECURE set
+// the connection, overriding the file, so containers can keep secrets out
+// of it. The result is not validated, so callers can apply their own
+// overrides first.
+func Read(path string) (*Config, error) {
+	var data []byte
+	var err error
+	switch path {
+	case "":
+	case "-":
+		data, err = io.ReadAll(os.Stdin)
+	default:
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	cfg := Config{Scrape: rvfs.ScrapePolicy{Retries: rvfs.DefaultScrapeRetries}}
+	if err := cfg.loadSettings(); err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks that the config can open a connection and fills in
+// defaults
+func (c *Config) Validate() error {
+	if c.Endpoint == "" || c.User == "" || c.Pass == "" {
+		return fmt.Errorf("config must include endpoint, user and pass (or BLUEFISH_ENDPOINT, BLUEFISH_USER, BLUEFISH_PASS)")
+	}
+	if c.CommandTimeout < 0 {
+		return fmt.Errorf("config command_timeout must be positive")
+	}
+	if c.CommandTimeout == 0 {
+		c.CommandTimeout = DefaultCommandTimeout
+	}
+	if err := c.Scrape.Compile(); err != nil {
+		return fmt.Errorf("config scrape: %w", err)
+	}
+	if err := c.Redact.Compile(); err != nil {
+		return fmt.Errorf("config redact: %w", err)
+	}
+	if err := c.Simulate.Validate(); err != nil {
+		return fmt.Errorf("config simulate: %w", err)
+	}
+	for i := range c.Commands {
+		if err := c.Commands[i].Validate(); err != nil {
+			return fmt.Errorf("config commands: %w", err)
+		}
+	}
+	return nil
+}
+
+// Masked returns a copy of the config that is safe to print, with the
+// password hidden
+func (c *Config) Masked() Config {
+	masked := *c
+	if masked.Pass != "" {
+		masked.Pass = "********"
+	}
+	return masked
+}
+
+// EnvOverrides names the BLUEFISH_* variables that are set, which override
+// the config file
+func EnvOverrides() []string {
+	var set []string
+	for _, name := range []string{"BLUEFISH_ENDPOINT", "BLUEFISH_USER", "BLUEFISH_PASS", "BLUEFISH_INSECURE"} {
+		if _, ok := os.LookupEnv(name); ok {
+			set = append(set, name)
+		}
+	}
+	return set
+}
+
+// applyEnv overrides the connection settings with the BLUEFISH_* variables
+// that are set
+func (c *Config) applyEnv() error {
+	for name, field := range map[string]*string{
+		"BLUEFISH_ENDPOINT": &c.Endpoint,
+		"BLUEFISH_USER":     &c.User,
+		"BLUEFISH_PASS":     &c.Pass,
+	} {
+		if value, ok := os.LookupEnv(name); ok {
+			*field = value
+		}
+	}
+	if value, ok := os.LookupEnv("BLUEFISH_INSECURE"); ok {
+		insecure, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("BLUEFISH_INSECURE: want true or false, got %q", value)
+		}
+		c.Insecure = insecure
+	}
+	return nil
+}
+
+// Bundled returns the config as YAML for a session bundle, without the
+// password, the external commands a replay would run, or a cassette to
+// record
+func (c *Config) Bundled() (string, error) {
+	bundled := *c
+	bundled.Pass = "REDACTED"
+	bundled.Commands = nil
+	bundled.Record = ""
+	data, err := yaml.Marshal(&bundled)
+	return string(data), err
+}
+
+// Unbundle reads the config of a session bundle, validated; the
+// environment does not override it
+func Unbundle(data string) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal([]byte(data), &cfg); err != nil {
+		return nil, fmt.Errorf("parsing bundled config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// SessionFile is where the frontends share login sessions, so those
+// connected to the same service use one
+func SessionFile() string {
+	return os.ExpandEnv("$HOME/.bluefish/sessions.json")
+}
+
+// Connect opens the connection the config describes. The returned close
+// function syncs the resource cache, lets go of the login session and
+// saves the cassette and session being recorded; frontends call it on
+// exit.
+func (c *Config) Connect() (rvfs.VFS, func() error, error) {
+	opts := rvfs.Options{Parser: c.Parser, Quirks: c.Quirks, Version: c.Version, Sessions: rvfs.NewSessionStore(SessionFile())}
+	var recorder *rvfs.Cassette
+	if c.Record != "" {
+		recorder = rvfs.RecordCassette(c.Record, rvfs.NewTransport(c.Insecure))
+		opts.Transport = recorder
+	}
+	replaying := c.Session != nil && c.Session.Replaying()
+	if c.Simulate.Enabled() && !replaying {
+		// Above the recorder, so cassettes hold what the service sent
+		next := opts.Transport
+		if next == nil {
+			next = rvfs.NewTransport(c.Insecure)
+		}
+		opts.Transport = c.Simulate.Transport(next)
+	}
+	var cacheDir string
+	if c.Session != nil {
+		// Above any simulation, so a bundle holds what the frontend saw.
+		// A cache and login of its own make every answer pass through it.
+		next := opts.Transport
+		if next == nil {
+			next = rvfs.NewTransport(c.Insecure)
+		}
+		opts.Transport = c.Session.Transport(next)
+		opts.Sessions = nil
+		var err error
+		if cacheDir, err = os.MkdirTemp("", "bluefish-session-"); err != nil {
+			return nil, nil, err
+		}
+		opts.CacheFile = filepath.Join(cacheDir, "cache.json")
+	}
+	vfs, err := rvfs.NewVFS(c.Endpoint, c.User, c.Pass, c.Insecure, opts)
+	if err != nil {
+		if cacheDir != "" {
+			os.RemoveAll(cacheDir)
+		}
+		return nil, nil, err
+	}
+
+	close := func() error {
+		err := vfs.Sync()
+		if logoutErr := vfs.Logout(); logoutErr != nil && err == nil {
+			err = fmt.Errorf("logging out: %w", logoutErr)
+		}
+		if recorder != nil {
+			if saveErr := recorder.Save(); saveErr != nil && err == nil {
+				err = fmt.Errorf("saving cassette: %w", saveErr)
+			}
+		}
+		if c.Session != nil {
+			os.RemoveAll(cacheDir)
+			if saveErr := c.Session.Save(); saveErr != nil && err == nil {
+				err = fmt.Errorf("saving session: %w", saveErr)
+			}
+		}
+		return err
+	}
+	return vfs, close, nil
+}
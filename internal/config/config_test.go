@@ -0,0 +1,170 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// unsetEnv clears the BLUEFISH_* variables for the test, restoring them
+// once it ends
+func unsetEnv(t *testing.T) {
+	for _, name := range []string{"BLUEFISH_ENDPOINT", "BLUEFISH_USER", "BLUEFISH_PASS", "BLUEFISH_INSECURE"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+// connection is the part of a config the sources set
+type connection struct {
+	Endpoint, User, Pass string
+	Insecure             bool
+	CommandTimeout       time.Duration
+}
+
+// TestRead tests reading the config from a file, stdin and the
+// environment, and the environment overriding the others
+func TestRead(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("endpoint: https://file\nuser: fileuser\npass: filepass\ninsecure: false\ncommand_timeout: 30s\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		stdin string
+		env   map[string]string
+		want  connection
+		err   string // Part of the error, "" when the config reads
+	}{
+		{
+			name: "file",
+			path: file,
+			want: connection{Endpoint: "https://file", User: "fileuser", Pass: "filepass", CommandTimeout: 30 * time.Second},
+		},
+		{
+			name:  "stdin",
+			path:  "-",
+			stdin: "endpoint: https://stdin\nuser: stdinuser\npass: stdinpass\ninsecure: true\n",
+			want:  connection{Endpoint: "https://stdin", User: "stdinuser", Pass: "stdinpass", Insecure: true},
+		},
+		{
+			name: "environment alone",
+			env:  map[string]string{"BLUEFISH_ENDPOINT": "https://env", "BLUEFISH_USER": "envuser", "BLUEFISH_PASS": "envpass", "BLUEFISH_INSECURE": "1"},
+			want: connection{Endpoint: "https://env", User: "envuser", Pass: "envpass", Insecure: true},
+		},
+		{
+			name: "environment overrides the file",
+			path: file,
+			env:  map[string]string{"BLUEFISH_PASS": "rotated", "BLUEFISH_INSECURE": "true"},
+			want: connection{Endpoint: "https://file", User: "fileuser", Pass: "rotated", Insecure: true, CommandTimeout: 30 * time.Second},
+		},
+		{
+			name: "invalid BLUEFISH_INSECURE",
+			path: file,
+			env:  map[string]string{"BLUEFISH_INSECURE": "yes"},
+			err:  `BLUEFISH_INSECURE: want true or false, got "yes"`,
+		},
+		{
+			name: "missing file",
+			path: filepath.Join(t.TempDir(), "missing.yaml"),
+			err:  "reading config",
+		},
+		{
+			name:  "malformed YAML",
+			path:  "-",
+			stdin: "endpoint: [\n",
+			err:   "parsing config",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			if tt.path == "-" {
+				stdin := filepath.Join(t.TempDir(), "stdin")
+				if err := os.WriteFile(stdin, []byte(tt.stdin), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				f, err := os.Open(stdin)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close()
+				saved := os.Stdin
+				os.Stdin = f
+				defer func() { os.Stdin = saved }()
+			}
+
+			cfg, err := Read(tt.path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("Read = %v, want an error with %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read = %v", err)
+			}
+			got := connection{Endpoint: cfg.Endpoint, User: cfg.User, Pass: cfg.Pass, Insecure: cfg.Insecure, CommandTimeout: cfg.CommandTimeout}
+			if got != tt.want {
+				t.Errorf("Read = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *Config {
+		return &Config{Endpoint: "https://bmc", User: "admin", Pass: "secret"}
+	}
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		err    string // Part of the error, "" when the config is valid
+	}{
+		{name: "valid", change: func(c *Config) {}},
+		{name: "missing endpoint", change: func(c *Config) { c.Endpoint = "" }, err: "must include endpoint, user and pass"},
+		{name: "missing user", change: func(c *Config) { c.User = "" }, err: "must include endpoint, user and pass"},
+		{name: "missing pass", change: func(c *Config) { c.Pass = "" }, err: "must include endpoint, user and pass"},
+		{name: "negative command_timeout", change: func(c *Config) { c.CommandTimeout = -time.Second }, err: "command_timeout must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.change(cfg)
+			err := cfg.Validate()
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("Validate = %v", err)
+				}
+				if cfg.CommandTimeout != DefaultCommandTimeout {
+					t.Errorf("command_timeout = %v, want the default %v", cfg.CommandTimeout, DefaultCommandTimeout)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Validate = %v, want an error with %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	unsetEnv(t)
+	if set := EnvOverrides(); len(set) != 0 {
+		t.Errorf("EnvOverrides without variables = %q", set)
+	}
+	t.Setenv("BLUEFISH_PASS", "secret")
+	t.Setenv("BLUEFISH_ENDPOINT", "")
+	if set := EnvOverrides(); !slices.Equal(set, []string{"BLUEFISH_ENDPOINT", "BLUEFISH_PASS"}) {
+		t.Errorf("EnvOverrides = %q", set)
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/theme"
+)
+
+// Settings are how the frontends look and behave, not what they connect
+// to: what a team standardizes on across operators and machines with
+// settings export and import. The imported ones are kept in SettingsFile,
+// under every config read; what a config file sets overrides them.
+type Settings struct {
+	Theme  theme.Config                   `yaml:"theme,omitempty"`
+	Keymap map[string]map[string][]string `yaml:"keymap,omitempty"`
+	Redact rvfs.Redaction                 `yaml:"redact,omitempty"`
+
+	// Set holds the shell settings by name, as set turns them on or off
+	Set map[string]bool `yaml:"set,omitempty"`
+
+	// Bookmarks and Watchlist are resources btsh starts with bookmarked
+	// and watched
+	Bookmarks []string `yaml:"bookmarks,omitempty"`
+	Watchlist []string `yaml:"watchlist,omitempty"`
+
+	// Macros are btsh macros by name, their commands in order; they are
+	// exported and imported, but kept as macro files
+	Macros map[string][]string `yaml:"macros,omitempty"`
+}
+
+// SettingsFile is where the imported settings are kept
+func SettingsFile() string {
+	return os.ExpandEnv("$HOME/.bluefish/settings.yaml")
+}
+
+// ReadSettings reads settings from a YAML file and checks them, with the
+// redaction patterns compiled
+func ReadSettings(path string) (*Settings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Settings
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if _, err := theme.Load(s.Theme); err != nil {
+		return nil, fmt.Errorf("%s theme: %w", path, err)
+	}
+	if err := s.Redact.Compile(); err != nil {
+		return nil, fmt.Errorf("%s redact: %w", path, err)
+	}
+	return &s, nil
+}
+
+// WriteSettings writes settings to a YAML file, replacing it whole
+func WriteSettings(path string, s *Settings) error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return rvfs.WriteFile(path, data, 0644)
+}
+
+// loadSettings reads the imported settings into the config, as the base a
+// config file overrides; without any, the config is left as is
+func (c *Config) loadSettings() error {
+	s, err := ReadSettings(SettingsFile())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading settings: %w", err)
+	}
+	c.Settings = *s
+	c.Theme = theme.Config{Name: s.Theme.Name, Colors: maps.Clone(s.Theme.Colors)}
+	c.Keymap = make(map[string]map[string][]string, len(s.Keymap))
+	for mode, bindings := range s.Keymap {
+		c.Keymap[mode] = maps.Clone(bindings)
+	}
+	c.Redact = rvfs.Redaction{Properties: s.Redact.Properties}
+	return nil
+}
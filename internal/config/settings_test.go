@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+	"github.com/bluefish-project/bluefish/theme"
+)
+
+func TestSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// Without imported settings, the config stands alone
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("endpoint: https://bmc\ntheme:\n  colors:\n    accent: 3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Read(file)
+	if err != nil || cfg.Theme.Name != "" || cfg.Keymap != nil {
+		t.Fatalf("Read without settings = %+v, %v", cfg, err)
+	}
+
+	s := &Settings{
+		Theme:     theme.Config{Name: "light", Colors: map[string]int{"child": 4}},
+		Keymap:    map[string]map[string][]string{"normal": {"quit": {"Q"}}},
+		Redact:    rvfs.Redaction{Properties: []string{"SerialNumber"}},
+		Set:       map[string]bool{"humanize": true},
+		Bookmarks: []string{"/redfish/v1/Systems/1"},
+	}
+	if err := WriteSettings(SettingsFile(), s); err != nil {
+		t.Fatal(err)
+	}
+
+	// The config file overrides the settings it sets and keeps the others
+	cfg, err = Read(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Theme.Name != "light" || cfg.Theme.Colors["child"] != 4 || cfg.Theme.Colors["accent"] != 3 {
+		t.Errorf("theme = %+v", cfg.Theme)
+	}
+	if _, ok := cfg.Settings.Theme.Colors["accent"]; ok {
+		t.Error("config overrides leaked into the settings")
+	}
+	if !slices.Equal(cfg.Keymap["normal"]["quit"], []string{"Q"}) || !slices.Equal(cfg.Redact.Properties, []string{"SerialNumber"}) {
+		t.Errorf("keymap %v, redact %v", cfg.Keymap, cfg.Redact.Properties)
+	}
+	if !cfg.Settings.Set["humanize"] || !slices.Equal(cfg.Settings.Bookmarks, s.Bookmarks) {
+		t.Errorf("settings = %+v", cfg.Settings)
+	}
+
+	// Broken settings are refused, not ignored
+	if err := os.WriteFile(SettingsFile(), []byte("redact:\n  properties: ['(']\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(file); err == nil {
+		t.Error("Read over an invalid redaction pattern succeeded")
+	}
+}
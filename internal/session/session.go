@@ -0,0 +1,189 @@
+// Package session records a bfui or btsh session, the service's answers
+// and the keys pressed, into a bundle a maintainer replays to reproduce a
+// bug against vendor data that cannot be shared live. Replay answers the
+// requests from the bundle, as a cassette does, and presses the keys again
+// at the moments they were pressed.
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+)
+
+// Bundle is a recorded session
+type Bundle struct {
+	Frontend string             `json:"frontend"` // ui or tsh
+	Config   string             `json:"config"`   // YAML config the session ran with, without secrets
+	Events   []Event            `json:"events"`
+	HTTP     []rvfs.Interaction `json:"http"`
+}
+
+// Event is a key pressed or a terminal resized
+type Event struct {
+	At     time.Duration `json:"at"` // Since the frontend started
+	Key    *Key          `json:"key,omitempty"`
+	Width  int           `json:"width,omitempty"`
+	Height int           `json:"height,omitempty"`
+}
+
+// Key is a key press as Bubble Tea reports it
+type Key struct {
+	Type  tea.KeyType `json:"type"`
+	Runes string      `json:"runes,omitempty"`
+	Alt   bool        `json:"alt,omitempty"`
+	Paste bool        `json:"paste,omitempty"`
+}
+
+// Session is a session being recorded or replayed. Its transport carries
+// the connection's requests and its filter sees the frontend's messages.
+type Session struct {
+	file      string
+	replaying bool
+	cassette  *rvfs.Cassette
+
+	mu     sync.Mutex
+	bundle Bundle
+	start  time.Time
+	played atomic.Bool // Replay has pressed every key
+}
+
+// played is an event replay sends the frontend, unwrapped by Filter
+type played struct {
+	msg tea.Msg
+}
+
+// Record starts the recording of a frontend's session, to be saved to
+// file; config is the YAML config it runs with
+func Record(file, frontend, config string) *Session {
+	return &Session{file: file, bundle: Bundle{Frontend: frontend, Config: config}}
+}
+
+// Load reads a bundle for replay
+func Load(file string) (*Session, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	s := &Session{file: file, replaying: true}
+	if err := json.Unmarshal(data, &s.bundle); err != nil {
+		return nil, fmt.Errorf("invalid session bundle %s: %w", file, err)
+	}
+	if s.bundle.Frontend != "ui" && s.bundle.Frontend != "tsh" {
+		return nil, fmt.Errorf("invalid session bundle %s: unknown frontend %q", file, s.bundle.Frontend)
+	}
+	s.cassette = rvfs.ReplayCassette(file, s.bundle.HTTP)
+	return s, nil
+}
+
+// Frontend returns the frontend the session ran in: ui or tsh
+func (s *Session) Frontend() string {
+	return s.bundle.Frontend
+}
+
+// Config returns the YAML config the session ran with
+func (s *Session) Config() string {
+	return s.bundle.Config
+}
+
+// Replaying reports whether the session is replayed, not recorded
+func (s *Session) Replaying() bool {
+	return s.replaying
+}
+
+// Transport returns the transport the connection sends through: one
+// recording what next answers, or the bundle's answers on replay
+func (s *Session) Transport(next http.RoundTripper) http.RoundTripper {
+	if !s.replaying {
+		s.cassette = rvfs.RecordCassette(s.file, next)
+	}
+	return s.cassette
+}
+
+// Start starts the session's clock once the frontend's program is made.
+// On replay it presses the recorded keys in p, each at its moment.
+func (s *Session) Start(p *tea.Program) {
+	s.mu.Lock()
+	s.start = time.Now()
+	s.mu.Unlock()
+	if !s.replaying {
+		return
+	}
+	go func() {
+		for _, e := range s.bundle.Events {
+			time.Sleep(time.Until(s.start.Add(e.At)))
+			if e.Key != nil {
+				p.Send(played{tea.KeyMsg{Type: e.Key.Type, Runes: []rune(e.Key.Runes), Alt: e.Key.Alt, Paste: e.Key.Paste}})
+			} else {
+				p.Send(played{tea.WindowSizeMsg{Width: e.Width, Height: e.Height}})
+			}
+		}
+		s.played.Store(true)
+	}()
+}
+
+// Filter is the frontend's tea.WithFilter. Recording, it notes the keys
+// and resizes, leaving out the key that ended the session, so a replay
+// stays open where it ended. Replaying, it lets through the replayed ones
+// and, until they are all pressed, drops the terminal's, but for Ctrl+C.
+func (s *Session) Filter(_ tea.Model, msg tea.Msg) tea.Msg {
+	if s.replaying {
+		switch msg := msg.(type) {
+		case played:
+			return msg.msg
+		case tea.KeyMsg:
+			if !s.played.Load() && msg.Type != tea.KeyCtrlC {
+				return nil
+			}
+		case tea.WindowSizeMsg:
+			if !s.played.Load() {
+				return nil
+			}
+		}
+		return msg
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	at := time.Since(s.start)
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		s.bundle.Events = append(s.bundle.Events, Event{At: at, Key: &Key{Type: msg.Type, Runes: string(msg.Runes), Alt: msg.Alt, Paste: msg.Paste}})
+	case tea.WindowSizeMsg:
+		s.bundle.Events = append(s.bundle.Events, Event{At: at, Width: msg.Width, Height: msg.Height})
+	case tea.QuitMsg:
+		for i := len(s.bundle.Events) - 1; i >= 0; i-- {
+			if s.bundle.Events[i].Key != nil {
+				s.bundle.Events = append(s.bundle.Events[:i], s.bundle.Events[i+1:]...)
+				break
+			}
+		}
+	}
+	return msg
+}
+
+// Save writes a recorded session's bundle to its file, readable only by
+// the user as it holds what the service sent; a replayed one is not saved
+func (s *Session) Save() error {
+	if s.replaying {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cassette != nil {
+		s.bundle.HTTP = s.cassette.Interactions()
+	}
+	data, err := json.MarshalIndent(s.bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.file, data, 0o600)
+}
@@ -0,0 +1,114 @@
+package session
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/bluefish-project/bluefish/rvfs/rvfstest"
+)
+
+// keyModel notes the keys and sizes it gets and quits after n keys
+type keyModel struct {
+	n     int
+	keys  []string
+	sizes []tea.WindowSizeMsg
+}
+
+func (m keyModel) Init() tea.Cmd { return nil }
+
+func (m keyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		m.keys = append(m.keys, msg.String())
+		if len(m.keys) == m.n {
+			return m, tea.Quit
+		}
+	case tea.WindowSizeMsg:
+		m.sizes = append(m.sizes, msg)
+	}
+	return m, nil
+}
+
+func (m keyModel) View() string { return "" }
+
+func TestRecordReplay(t *testing.T) {
+	server := rvfstest.NewServer(rvfstest.Service())
+	defer server.Close()
+	file := filepath.Join(t.TempDir(), "session.json")
+
+	// Recorded: the answers and the keys, but for the one that quit
+	s := Record(file, "tsh", "endpoint: x\n")
+	client := &http.Client{Transport: s.Transport(http.DefaultTransport)}
+	resp, err := client.Get(server.URL + "/redfish/v1/Systems/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	s.Start(nil)
+	for _, msg := range []tea.Msg{
+		tea.WindowSizeMsg{Width: 100, Height: 30},
+		tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")},
+		tea.KeyMsg{Type: tea.KeyEnter},
+		tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")},
+		tea.QuitMsg{},
+	} {
+		if s.Filter(nil, msg) == nil {
+			t.Errorf("recording filtered %v", msg)
+		}
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Replaying() || r.Frontend() != "tsh" || r.Config() != "endpoint: x\n" || len(r.bundle.Events) != 3 || len(r.bundle.HTTP) != 1 {
+		t.Fatalf("loaded bundle = %+v", r.bundle)
+	}
+
+	// Replayed: the service's answer without the service
+	server.Close()
+	client = &http.Client{Transport: r.Transport(nil)}
+	resp, err = client.Get(server.URL + "/redfish/v1/Systems/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `"Fake 1000"`) {
+		t.Errorf("replayed answer = %s", body)
+	}
+
+	// The keys, pressed again; the terminal's are dropped meanwhile
+	if r.Filter(nil, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")}) != nil || r.Filter(nil, tea.WindowSizeMsg{Width: 80}) != nil {
+		t.Error("terminal input let through during playback")
+	}
+	if _, ok := r.Filter(nil, tea.KeyMsg{Type: tea.KeyCtrlC}).(tea.KeyMsg); !ok {
+		t.Error("Ctrl+C dropped during playback")
+	}
+	p := tea.NewProgram(keyModel{n: 2}, tea.WithInput(nil), tea.WithOutput(io.Discard), tea.WithFilter(r.Filter))
+	r.Start(p)
+	done := make(chan tea.Model)
+	go func() {
+		final, _ := p.Run()
+		done <- final
+	}()
+	select {
+	case final := <-done:
+		m := final.(keyModel)
+		if strings.Join(m.keys, " ") != "l enter" || len(m.sizes) == 0 || m.sizes[len(m.sizes)-1].Width != 100 {
+			t.Errorf("replayed keys %q, sizes %v", m.keys, m.sizes)
+		}
+	case <-time.After(5 * time.Second):
+		p.Kill()
+		t.Fatal("replay did not press the keys")
+	}
+}
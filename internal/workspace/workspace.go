@@ -0,0 +1,95 @@
+// Package workspace saves a navigation context under a name, so it can be
+// reopened later or handed to a colleague as a file. btsh and bfui share
+// the format: each restores the parts it has a use for.
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+)
+
+// Workspace is a saved navigation context
+type Workspace struct {
+	Endpoint  string   `json:"endpoint"`
+	BasePath  string   `json:"base_path"`          // btsh working directory, bfui tree root
+	Expanded  []string `json:"expanded,omitempty"` // bfui expanded nodes, parents first
+	Cursor    string   `json:"cursor,omitempty"`   // bfui selected node
+	Bookmarks []string `json:"bookmarks,omitempty"`
+	Pinned    string   `json:"pinned,omitempty"` // bfui resource compared against
+}
+
+// Dir is where named workspaces are kept
+func Dir() string {
+	return os.ExpandEnv("$HOME/.bluefish/workspaces")
+}
+
+// File returns the file a workspace is kept in. A name ending in .json or
+// holding a directory is that file, so a shared workspace opens where it
+// was put; any other name is kept in Dir.
+func File(name string) string {
+	if strings.HasSuffix(name, ".json") || strings.ContainsRune(name, filepath.Separator) {
+		return name
+	}
+	return filepath.Join(Dir(), name+".json")
+}
+
+// Load reads a workspace by name or file
+func Load(name string) (*Workspace, error) {
+	path := File(name)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no workspace %s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var w Workspace
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if w.BasePath == "" {
+		return nil, fmt.Errorf("%s: workspace has no base_path", path)
+	}
+	return &w, nil
+}
+
+// Save writes a workspace by name or file, replacing any saved before; the
+// file is readable by others, as it is made to be handed on
+func Save(name string, w *Workspace) error {
+	path := File(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	return rvfs.WriteFile(path, data, 0644)
+}
+
+// List returns the names of the workspaces kept in Dir, sorted
+func List() ([]string, error) {
+	entries, err := os.ReadDir(Dir())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".json"); ok && !e.IsDir() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
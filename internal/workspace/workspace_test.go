@@ -0,0 +1,97 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFile(t *testing.T) {
+	t.Setenv("HOME", "/home/op")
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"r740", filepath.Join("/home/op/.bluefish/workspaces", "r740.json")},
+		{"r740.json", "r740.json"},
+		{"shared" + string(filepath.Separator) + "r740", "shared" + string(filepath.Separator) + "r740"},
+		{"./r740-fans.json", "./r740-fans.json"},
+	}
+	for _, tt := range tests {
+		if got := File(tt.name); got != tt.want {
+			t.Errorf("File(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	if _, err := Load("missing"); err == nil || !strings.Contains(err.Error(), "no workspace missing") {
+		t.Errorf("Load of a missing workspace = %v", err)
+	}
+
+	noBase := filepath.Join(dir, "nobase.json")
+	if err := os.WriteFile(noBase, []byte(`{"endpoint": "https://bmc1"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(noBase); err == nil || !strings.Contains(err.Error(), "no base_path") {
+		t.Errorf("Load of a workspace without base_path = %v", err)
+	}
+
+	damaged := filepath.Join(dir, "damaged.json")
+	if err := os.WriteFile(damaged, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(damaged); err == nil {
+		t.Error("Load of a damaged workspace succeeded")
+	}
+}
+
+// TestSaveList tests that saved workspaces load back as they were, are
+// listed by name, and can be read by the colleague they are handed to
+func TestSaveList(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	if names, err := List(); err != nil || len(names) != 0 {
+		t.Errorf("List without a workspace directory = %q, %v", names, err)
+	}
+
+	fans := &Workspace{
+		Endpoint:  "https://bmc1",
+		BasePath:  "/redfish/v1/Chassis/1",
+		Expanded:  []string{"/redfish/v1/Chassis/1/Thermal"},
+		Cursor:    "/redfish/v1/Chassis/1/Thermal/Fans",
+		Bookmarks: []string{"/redfish/v1/Systems/1"},
+		Pinned:    "/redfish/v1/Chassis/2",
+	}
+	for _, name := range []string{"r740-fans", "boot"} {
+		if err := Save(name, fans); err != nil {
+			t.Fatalf("Save(%s) = %v", name, err)
+		}
+	}
+	shared := filepath.Join(dir, "shared.json")
+	if err := Save(shared, &Workspace{BasePath: "/redfish/v1"}); err != nil {
+		t.Fatalf("Save(%s) = %v", shared, err)
+	}
+
+	if got, err := Load("r740-fans"); err != nil || !reflect.DeepEqual(got, fans) {
+		t.Errorf("Load = %+v, %v, want %+v", got, err, fans)
+	}
+	if got, err := Load(shared); err != nil || got.BasePath != "/redfish/v1" {
+		t.Errorf("Load(%s) = %+v, %v", shared, got, err)
+	}
+	if names, err := List(); err != nil || !slices.Equal(names, []string{"boot", "r740-fans"}) {
+		t.Errorf("List = %q, %v", names, err)
+	}
+	for _, path := range []string{File("boot"), shared} {
+		if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o644 {
+			t.Errorf("%s = %v, %v, want mode 0644", path, fi, err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// External is a command carried out by an external program. The program
+// runs with the exec arguments, then those typed, path arguments made
+// absolute. The resource the shell is in comes as JSON on its standard
+// input, and BLUEFISH_ENDPOINT, BLUEFISH_USER and BLUEFISH_PATH (the
+// shell's cwd) in its environment; its output is the command's.
+//
+//	commands:
+//	  - name: drain
+//	    usage: <system>
+//	    summary: Drain a node from the scheduler
+//	    exec: [/usr/local/bin/drain-node, --reason, bluefish]
+//	    args: [path]
+type External struct {
+	Name    string   `yaml:"name"`
+	Usage   string   `yaml:"usage"`
+	Summary string   `yaml:"summary"`
+	Exec    []string `yaml:"exec"`
+	// Args completes the positional arguments: "path" for a path, other
+	// values are the words to complete, separated by |, e.g. "now|later"
+	Args []string `yaml:"args"`
+}
+
+// Validate checks that the command has a name and a program
+func (e *External) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("command without a name")
+	}
+	if len(e.Exec) == 0 || e.Exec[0] == "" {
+		return fmt.Errorf("command %s has no exec", e.Name)
+	}
+	return nil
+}
+
+// Command returns the shell command that runs the program
+func (e *External) Command() Command {
+	args := make([]Arg, len(e.Args))
+	for i, spec := range e.Args {
+		if spec == "path" {
+			args[i] = Arg{Path: true}
+		} else {
+			args[i] = Arg{Choices: strings.Split(spec, "|")}
+		}
+	}
+	return Command{
+		Name:    e.Name,
+		Usage:   e.Usage,
+		Summary: e.Summary,
+		Args:    args,
+		Run: func(env *Env, typed []string) error {
+			return e.run(env, args, typed)
+		},
+	}
+}
+
+// run runs the program with the typed arguments
+func (e *External) run(env *Env, args []Arg, typed []string) error {
+	argv := append([]string{}, e.Exec[1:]...)
+	for i, arg := range typed {
+		if i < len(args) && args[i].Path {
+			arg = env.VFS.Join(env.Cwd, arg)
+		}
+		argv = append(argv, arg)
+	}
+
+	cmd := exec.CommandContext(env.Context, e.Exec[0], argv...)
+	cmd.Env = append(os.Environ(),
+		"BLUEFISH_ENDPOINT="+env.Endpoint,
+		"BLUEFISH_USER="+env.User,
+		"BLUEFISH_PATH="+env.Cwd,
+	)
+	if target, err := env.VFS.ResolveTarget(env.VFS.Root(), env.Cwd); err == nil && target.Resource != nil {
+		cmd.Stdin = bytes.NewReader(target.Resource.RawJSON)
+	}
+	cmd.Stdout = env.Out
+	cmd.Stderr = env.Out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", e.Name, err)
+	}
+	return nil
+}
+
+// RegisterExternal registers the commands a config lists
+func RegisterExternal(commands []External) error {
+	for i := range commands {
+		if err := Register(commands[i].Command()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
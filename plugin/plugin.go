@@ -0,0 +1,110 @@
+// Package plugin adds site-specific commands to the bluefish shells, such
+// as draining a node or opening a ticket with its inventory attached,
+// without forking them. Commands come from Go code built into a binary,
+// which calls Register from an init function, or from external programs a
+// config lists under commands:.
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+)
+
+// Env is what a command runs against
+type Env struct {
+	Context  context.Context // Cancelled when the user interrupts the command
+	VFS      rvfs.VFS
+	Cwd      string    // Path the shell is in
+	Endpoint string    // Service the shell is connected to
+	User     string    // Account the shell logs in as
+	Out      io.Writer // Where the command's output goes
+}
+
+// Arg describes how a positional argument of a command completes
+type Arg struct {
+	Path    bool     // A resource or property path
+	Choices []string // Fixed words, when not a path
+}
+
+// Command is a shell command added by a plugin
+type Command struct {
+	Name    string
+	Usage   string // Arguments for help, e.g. "<system> [reason]"
+	Summary string // One line for help
+	Args    []Arg  // Completion of the positional arguments, in order
+	Run     func(env *Env, args []string) error
+}
+
+// Complete returns the words the argument at position i completes to,
+// those starting with prefix; path reports that it is a path, which the
+// shell completes itself
+func (c *Command) Complete(i int, prefix string) (words []string, path bool) {
+	if i >= len(c.Args) {
+		return nil, false
+	}
+	if c.Args[i].Path {
+		return nil, true
+	}
+	for _, choice := range c.Args[i].Choices {
+		if strings.HasPrefix(choice, prefix) {
+			words = append(words, choice)
+		}
+	}
+	return words, false
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]*Command{}
+)
+
+// Register adds a command to the shells. A name already registered is an
+// error; a name a shell has a built-in command for is shadowed by it.
+func Register(cmd Command) error {
+	if cmd.Name == "" || strings.ContainsAny(cmd.Name, " \t") {
+		return fmt.Errorf("plugin command name %q must be one word", cmd.Name)
+	}
+	if cmd.Run == nil {
+		return fmt.Errorf("plugin command %s has no Run", cmd.Name)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[cmd.Name]; ok {
+		return fmt.Errorf("plugin command %s is registered twice", cmd.Name)
+	}
+	registry[cmd.Name] = &cmd
+	return nil
+}
+
+// Lookup returns the command registered as name
+func Lookup(name string) (*Command, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	cmd, ok := registry[name]
+	return cmd, ok
+}
+
+// Commands returns the registered commands, sorted by name
+func Commands() []*Command {
+	mu.RLock()
+	defer mu.RUnlock()
+	cmds := make([]*Command, 0, len(registry))
+	for _, cmd := range registry {
+		cmds = append(cmds, cmd)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds
+}
+
+// unregister removes a command, for tests
+func unregister(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(registry, name)
+}
@@ -0,0 +1,86 @@
+package plugin
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bluefish-project/bluefish/rvfs/rvfstest"
+)
+
+func TestRegister(t *testing.T) {
+	run := func(env *Env, args []string) error { return nil }
+	if err := Register(Command{Name: "drain", Run: run, Args: []Arg{{Path: true}, {Choices: []string{"now", "later", "never"}}}}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	defer unregister("drain")
+
+	for _, cmd := range []Command{
+		{Name: "drain", Run: run},
+		{Name: "two words", Run: run},
+		{Name: "", Run: run},
+		{Name: "norun"},
+	} {
+		if err := Register(cmd); err == nil {
+			t.Errorf("Register(%q) succeeded", cmd.Name)
+			unregister(cmd.Name)
+		}
+	}
+
+	cmd, ok := Lookup("drain")
+	if !ok {
+		t.Fatal("Lookup(drain) found nothing")
+	}
+	if _, path := cmd.Complete(0, ""); !path {
+		t.Error("first argument does not complete as a path")
+	}
+	if words, _ := cmd.Complete(1, "n"); !slices.Equal(words, []string{"now", "never"}) {
+		t.Errorf("Complete(1, n) = %v", words)
+	}
+	if words, path := cmd.Complete(2, ""); words != nil || path {
+		t.Errorf("Complete past the last argument = %v, %v", words, path)
+	}
+	if names := Commands(); len(names) != 1 || names[0].Name != "drain" {
+		t.Errorf("Commands() = %v", names)
+	}
+}
+
+func TestExternal(t *testing.T) {
+	server := rvfstest.NewServer(rvfstest.Service())
+	defer server.Close()
+	v := server.VFS(t)
+
+	external := External{
+		Name: "ticket",
+		Exec: []string{"sh", "-c", `echo "$BLUEFISH_ENDPOINT $BLUEFISH_PATH $1 $2"; grep -o '"Id": *"[^"]*"' | head -1`, "--"},
+		Args: []string{"path", "low|high"},
+	}
+	if err := external.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	cmd := external.Command()
+	if words, _ := cmd.Complete(1, "h"); !slices.Equal(words, []string{"high"}) {
+		t.Errorf("Complete(1, h) = %v", words)
+	}
+
+	var out strings.Builder
+	env := &Env{Context: context.Background(), VFS: v, Cwd: "/redfish/v1/Systems/1", Endpoint: "https://bmc", Out: &out}
+	if err := cmd.Run(env, []string{"..", "high"}); err != nil {
+		t.Fatalf("Run failed: %v\n%s", err, out.String())
+	}
+	want := "https://bmc /redfish/v1/Systems/1 /redfish/v1/Systems high\n\"Id\": \"1\"\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	failing := External{Name: "fail", Exec: []string{"sh", "-c", "echo no; exit 3"}}
+	out.Reset()
+	if err := failing.Command().Run(env, nil); err == nil || out.String() != "no\n" {
+		t.Errorf("failing program: err %v, output %q", err, out.String())
+	}
+
+	if err := (&External{Name: "noexec"}).Validate(); err == nil {
+		t.Error("Validate of a command without exec succeeded")
+	}
+}
@@ -0,0 +1,120 @@
+package rvfs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Action is an action a resource exposes and the parameters it takes
+type Action struct {
+	Resource   string // Resource exposing it
+	Name       string // Full name, such as #ComputerSystem.Reset
+	Target     string // URI to POST to
+	InfoURI    string // @Redfish.ActionInfo, "" without one
+	Parameters []ActionParameter
+}
+
+// ActionParameter is a parameter of an action, from its ActionInfo or,
+// without a readable one, from the @Redfish.AllowableValues annotations
+type ActionParameter struct {
+	Name      string
+	DataType  string // "" when only annotated
+	Required  bool
+	Allowable []string
+}
+
+// ResourceActions lists the actions a resource exposes, Oem aside, by
+// name. The ActionInfo of each is read through r; one r cannot read leaves
+// the parameters to the annotations.
+func ResourceActions(res *Resource, r Reader) []Action {
+	if res == nil {
+		return nil
+	}
+	actions, ok := res.Properties["Actions"]
+	if !ok || actions.Type != PropertyObject {
+		return nil
+	}
+
+	var list []Action
+	for name, prop := range actions.Children {
+		if name == "Oem" || prop.Type != PropertyObject {
+			continue
+		}
+		target, ok := prop.Children["target"]
+		if !ok || target.Type != PropertyLink {
+			continue
+		}
+		a := Action{Resource: res.Path, Name: name, Target: target.LinkTarget}
+		if info, ok := prop.Children["@Redfish.ActionInfo"]; ok && info.Type == PropertyLink {
+			a.InfoURI = info.LinkTarget
+			if res, err := r.Get(a.InfoURI); err == nil {
+				a.Parameters = infoParameters(res)
+			}
+		}
+		if a.Parameters == nil {
+			a.Parameters = annotatedParameters(prop)
+		}
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// infoParameters reads the Parameters of an ActionInfo resource
+func infoParameters(info *Resource) []ActionParameter {
+	params, ok := info.Properties["Parameters"]
+	if !ok || params.Type != PropertyArray {
+		return nil
+	}
+	list := []ActionParameter{}
+	for _, elem := range params.Elements {
+		if elem.Type != PropertyObject {
+			continue
+		}
+		var p ActionParameter
+		if name, ok := elem.Children["Name"]; ok && name.Type == PropertySimple {
+			p.Name = fmt.Sprint(name.Value)
+		}
+		if dt, ok := elem.Children["DataType"]; ok && dt.Type == PropertySimple {
+			p.DataType = fmt.Sprint(dt.Value)
+		}
+		if req, ok := elem.Children["Required"]; ok && req.Type == PropertySimple {
+			p.Required, _ = req.Value.(bool)
+		}
+		if av, ok := elem.Children["AllowableValues"]; ok {
+			p.Allowable = simpleValues(av)
+		}
+		list = append(list, p)
+	}
+	return list
+}
+
+// annotatedParameters reads the parameters an action names in its
+// @Redfish.AllowableValues annotations, by name
+func annotatedParameters(action *Property) []ActionParameter {
+	var list []ActionParameter
+	for key, prop := range action.Children {
+		name, ok := strings.CutSuffix(key, "@Redfish.AllowableValues")
+		if !ok {
+			continue
+		}
+		list = append(list, ActionParameter{Name: name, Allowable: simpleValues(prop)})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// simpleValues returns the simple elements of an array property as text
+func simpleValues(prop *Property) []string {
+	if prop.Type != PropertyArray {
+		return nil
+	}
+	var values []string
+	for _, elem := range prop.Elements {
+		if elem.Type == PropertySimple {
+			values = append(values, fmt.Sprint(elem.Value))
+		}
+	}
+	return values
+}
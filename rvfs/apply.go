@@ -0,0 +1,404 @@
+package rvfs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DesiredState is what apply converges a service to: the properties each
+// resource should have, and the actions that make changes take effect,
+// such as a reset after boot settings change
+//
+//	resources:
+//	  /redfish/v1/Systems/1:
+//	    Boot:
+//	      BootSourceOverrideTarget: Pxe
+//	      BootSourceOverrideEnabled: Once
+//	  Managers/1/NetworkProtocol:
+//	    NTP:
+//	      ProtocolEnabled: true
+//	      NTPServers: [pool.ntp.org]
+//	actions:
+//	  - resource: /redfish/v1/Systems/1
+//	    action: Reset
+//	    body: {ResetType: GracefulRestart}
+type DesiredState struct {
+	// Resources maps resource paths, absolute or relative to the service
+	// root, to the properties to set. Objects are compared member by member;
+	// arrays and other values are set whole.
+	Resources map[string]map[string]any `yaml:"resources"`
+	// Actions are invoked after the properties, and only when some
+	// property had to change
+	Actions []DesiredAction `yaml:"actions"`
+}
+
+// DesiredAction is an action apply invokes once properties changed
+type DesiredAction struct {
+	Resource string         `yaml:"resource"`
+	Action   string         `yaml:"action"` // Full name (#ComputerSystem.Reset) or short name (Reset)
+	Body     map[string]any `yaml:"body"`
+}
+
+// LoadDesiredState reads a desired state from a YAML file, refusing fields
+// it does not know
+func LoadDesiredState(file string) (*DesiredState, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var desired DesiredState
+	if err := dec.Decode(&desired); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if len(desired.Resources) == 0 {
+		return nil, fmt.Errorf("%s: no resources", file)
+	}
+	return &desired, nil
+}
+
+// Change is one step of an apply plan: a property to PATCH, or an action
+// to POST
+type Change struct {
+	Resource string `json:"resource"`
+	// Property is the path of the property within the resource, one name
+	// per level; for a POST it is the action's full name
+	Property []string `json:"property"`
+	Old      any      `json:"old"` // Live value, nil when the resource lacks the property
+	New      any      `json:"new"` // Desired value; the request body for a POST
+	Method   string   `json:"method"`
+	Target   string   `json:"target,omitempty"` // Action target of a POST
+}
+
+// PropertyName is the property path of a change: Boot/BootSourceOverrideTarget
+func (c Change) PropertyName() string {
+	return strings.Join(c.Property, "/")
+}
+
+// Plan is the changes apply makes, PATCHes first, in the order they are
+// sent
+type Plan struct {
+	Changes []Change `json:"changes"`
+}
+
+// Patches returns the number of properties the plan changes
+func (p *Plan) Patches() int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Method == http.MethodPatch {
+			n++
+		}
+	}
+	return n
+}
+
+// SavePlan writes a plan as indented JSON, for review before it is applied
+// with LoadPlan and ApplyPlan
+func SavePlan(file string, plan *Plan) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0o644)
+}
+
+// LoadPlan reads a plan SavePlan wrote, checking each change is complete
+func LoadPlan(file string) (*Plan, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var plan Plan
+	if err := dec.Decode(&plan); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	for i, c := range plan.Changes {
+		switch {
+		case c.Resource == "" || len(c.Property) == 0:
+			return nil, fmt.Errorf("%s: change %d has no resource or property", file, i+1)
+		case c.Method == http.MethodPost && c.Target == "":
+			return nil, fmt.Errorf("%s: change %d has no action target", file, i+1)
+		case c.Method != http.MethodPatch && c.Method != http.MethodPost:
+			return nil, fmt.Errorf("%s: change %d has method %q, not PATCH or POST", file, i+1, c.Method)
+		}
+	}
+	return &plan, nil
+}
+
+// StaleChanges reads the resources of a plan afresh and returns the PATCHes
+// whose property no longer has the value the plan was made against, so a
+// plan made earlier does not overwrite what changed since
+func StaleChanges(v VFS, plan *Plan) ([]Change, error) {
+	live := make(map[string]map[string]any)
+	var stale []Change
+	for _, c := range plan.Changes {
+		if c.Method != http.MethodPatch {
+			continue
+		}
+		properties, ok := live[c.Resource]
+		if !ok {
+			v.Invalidate(c.Resource)
+			res, err := v.Get(c.Resource)
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(res.RawJSON, &properties); err != nil {
+				return nil, fmt.Errorf("%s: %w", c.Resource, err)
+			}
+			live[c.Resource] = properties
+		}
+		value := liveValue(properties, c.Property)
+		if !reflect.DeepEqual(value, c.Old) {
+			stale = append(stale, Change{Resource: c.Resource, Property: c.Property, Old: c.Old, New: value, Method: c.Method})
+		}
+	}
+	return stale, nil
+}
+
+// liveValue returns the value at names in the properties of a resource,
+// nil when there is none
+func liveValue(properties map[string]any, names []string) any {
+	var value any = properties
+	for _, name := range names {
+		object, _ := value.(map[string]any)
+		value = object[name]
+	}
+	return value
+}
+
+// PlanApply compares a desired state with the live one, read afresh, and
+// returns the changes that bring the service to it. The plan is empty when
+// the service already matches.
+func PlanApply(v VFS, desired *DesiredState) (*Plan, error) {
+	paths := make(map[string]string, len(desired.Resources))
+	for key := range desired.Resources {
+		path, err := applyResource(v, key)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := paths[path]; ok {
+			return nil, fmt.Errorf("%s and %s are the same resource", other, key)
+		}
+		paths[path] = key
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	plan := &Plan{}
+	for _, path := range sorted {
+		v.Invalidate(path)
+		res, err := v.Get(path)
+		if err != nil {
+			return nil, err
+		}
+		var live map[string]any
+		if err := json.Unmarshal(res.RawJSON, &live); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		want, err := jsonValue(desired.Resources[paths[path]])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", paths[path], err)
+		}
+		plan.Changes = append(plan.Changes, diffDesired(path, nil, live, want.(map[string]any))...)
+	}
+	if len(plan.Changes) == 0 {
+		return plan, nil
+	}
+
+	for _, a := range desired.Actions {
+		path, err := applyResource(v, a.Resource)
+		if err != nil {
+			return nil, err
+		}
+		res, err := v.Get(path)
+		if err != nil {
+			return nil, err
+		}
+		name, target := actionTarget(res, a.Action)
+		if target == "" {
+			return nil, fmt.Errorf("%s has no %s action", path, a.Action)
+		}
+		body, err := jsonValue(a.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", a.Resource, a.Action, err)
+		}
+		plan.Changes = append(plan.Changes, Change{Resource: path, Property: []string{name}, New: body, Method: http.MethodPost, Target: target})
+	}
+	return plan, nil
+}
+
+// applyResource resolves a resource path of a desired state
+func applyResource(v VFS, key string) (string, error) {
+	resolved, err := v.ResolveTarget(v.Root(), key)
+	if err != nil {
+		return "", err
+	}
+	if resolved.Type != TargetResource && resolved.Type != TargetLink {
+		return "", fmt.Errorf("not a resource: %s", key)
+	}
+	return resolved.ResourcePath, nil
+}
+
+// jsonValue converts a value decoded from YAML to its JSON form, so it
+// compares equal to the same value decoded from a response
+func jsonValue(value any) (any, error) {
+	if value == nil {
+		return map[string]any{}, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var converted any
+	err = json.Unmarshal(data, &converted)
+	return converted, err
+}
+
+// diffDesired returns a PATCH for each desired property that differs from
+// the live one, recursing into objects the live resource has as well
+func diffDesired(path string, prefix []string, live, want map[string]any) []Change {
+	names := make([]string, 0, len(want))
+	for name := range want {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var changes []Change
+	for _, name := range names {
+		property := append(slices.Clone(prefix), name)
+		liveObject, liveIsObject := live[name].(map[string]any)
+		wantObject, wantIsObject := want[name].(map[string]any)
+		if liveIsObject && wantIsObject {
+			changes = append(changes, diffDesired(path, property, liveObject, wantObject)...)
+			continue
+		}
+		if !reflect.DeepEqual(live[name], want[name]) {
+			changes = append(changes, Change{Resource: path, Property: property, Old: live[name], New: want[name], Method: http.MethodPatch})
+		}
+	}
+	return changes
+}
+
+// actionTarget finds an action of a resource by its full or short name,
+// case-insensitively, and returns its full name and target
+func actionTarget(res *Resource, name string) (string, string) {
+	actions, ok := res.Properties["Actions"]
+	if !ok || actions.Type != PropertyObject {
+		return "", ""
+	}
+	for full, action := range actions.Children {
+		short := full[strings.LastIndex(full, ".")+1:]
+		if !strings.EqualFold(full, name) && !strings.EqualFold(short, name) || action.Type != PropertyObject {
+			continue
+		}
+		if t, ok := action.Children["target"]; ok && t.Type == PropertyLink {
+			return full, t.LinkTarget
+		}
+	}
+	return "", ""
+}
+
+// ApplyResult is the outcome of one request of a plan
+type ApplyResult struct {
+	Method   string
+	Path     string   // Resource PATCHed, or action target POSTed to
+	Changes  []Change // What the request carried
+	Response *Response
+	Err      error
+}
+
+// ApplyPlan sends a plan: one PATCH per resource carrying all its changes,
+// then the actions, through posts so one just sent is held back unless
+// again. A status the service refuses a request with is an HTTPError;
+// actions are not invoked once a PATCH failed.
+func ApplyPlan(v VFS, plan *Plan, posts *PostGuard, again bool) []ApplyResult {
+	var results []ApplyResult
+	index := make(map[string]int)
+	for _, c := range plan.Changes {
+		if c.Method != http.MethodPatch {
+			continue
+		}
+		i, ok := index[c.Resource]
+		if !ok {
+			i = len(results)
+			index[c.Resource] = i
+			results = append(results, ApplyResult{Method: http.MethodPatch, Path: c.Resource})
+		}
+		results[i].Changes = append(results[i].Changes, c)
+	}
+
+	failed := false
+	for i := range results {
+		r := &results[i]
+		body, err := json.Marshal(patchBody(r.Changes))
+		if err != nil {
+			r.Err = err
+			failed = true
+			continue
+		}
+		r.Response, r.Err = v.Patch(r.Path, body)
+		if r.Err == nil && r.Response.StatusCode >= http.StatusMultipleChoices {
+			r.Err = &HTTPError{Path: r.Path, StatusCode: r.Response.StatusCode, Messages: r.Response.Messages}
+		}
+		failed = failed || r.Err != nil
+	}
+
+	for _, c := range plan.Changes {
+		if c.Method != http.MethodPost {
+			continue
+		}
+		r := ApplyResult{Method: http.MethodPost, Path: c.Target, Changes: []Change{c}}
+		if failed {
+			r.Err = fmt.Errorf("not invoked: a PATCH failed")
+			results = append(results, r)
+			continue
+		}
+		body, err := json.Marshal(c.New)
+		if err != nil {
+			r.Err = err
+			results = append(results, r)
+			continue
+		}
+		r.Response, r.Err = posts.Post(v, c.Target, body, again)
+		if r.Err == nil && r.Response.StatusCode >= http.StatusMultipleChoices {
+			r.Err = &HTTPError{Path: c.Target, StatusCode: r.Response.StatusCode, Messages: r.Response.Messages}
+		}
+		v.Invalidate(c.Resource)
+		results = append(results, r)
+	}
+	return results
+}
+
+// patchBody nests the new values of changes to one resource into the body
+// of its PATCH
+func patchBody(changes []Change) map[string]any {
+	body := make(map[string]any)
+	for _, c := range changes {
+		object := body
+		for _, name := range c.Property[:len(c.Property)-1] {
+			next, ok := object[name].(map[string]any)
+			if !ok {
+				next = make(map[string]any)
+				object[name] = next
+			}
+			object = next
+		}
+		object[c.Property[len(c.Property)-1]] = c.New
+	}
+	return body
+}
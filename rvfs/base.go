@@ -0,0 +1,74 @@
+package rvfs
+
+import (
+	"errors"
+	"io"
+	"regexp"
+)
+
+// ErrNotSupported is returned by BaseVFS for writes
+var ErrNotSupported = errors.New("not supported")
+
+// BaseVFS implements VFS with no resources, for embedding: mocks and
+// alternative backends embed it and define only the methods they need.
+// Reads find nothing, writes fail with ErrNotSupported, the cache is
+// empty, and Join and Parent work on paths as VFS does.
+type BaseVFS struct{}
+
+var _ VFS = BaseVFS{}
+
+func (BaseVFS) Get(path string) (*Resource, error) {
+	return nil, &NotFoundError{Path: path}
+}
+
+func (BaseVFS) ResolveTarget(basePath, targetPath string) (*Target, error) {
+	return nil, &NotFoundError{Path: joinPath(basePath, targetPath)}
+}
+
+func (BaseVFS) ResolveChain(basePath, targetPath string) (*Target, []Hop, error) {
+	return nil, nil, &NotFoundError{Path: joinPath(basePath, targetPath)}
+}
+
+func (BaseVFS) Root() string                    { return DefaultRoot }
+func (BaseVFS) Join(base, target string) string { return joinPath(base, target) }
+func (BaseVFS) Parent(path string) string       { return parentPath(path) }
+
+func (BaseVFS) ListAll(path string) ([]*Entry, error) {
+	return nil, &NotFoundError{Path: path}
+}
+
+func (BaseVFS) ListProperties(path string) ([]*Property, error) {
+	return nil, &NotFoundError{Path: path}
+}
+
+func (BaseVFS) Stat(path string) (*ResourceInfo, error) {
+	return nil, &NotFoundError{Path: path}
+}
+
+func (BaseVFS) Post(path string, body []byte) (*Response, error) {
+	return nil, ErrNotSupported
+}
+
+func (BaseVFS) Patch(path string, body []byte) (*Response, error) {
+	return nil, ErrNotSupported
+}
+
+func (BaseVFS) Download(path string, w io.Writer, progress ProgressFunc) (int64, error) {
+	return 0, ErrNotSupported
+}
+
+func (BaseVFS) GetKnownPaths() []string                              { return nil }
+func (BaseVFS) Invalidate(path string)                               {}
+func (BaseVFS) Clear()                                               {}
+func (BaseVFS) Denied(path string) bool                              { return false }
+func (BaseVFS) Cached(path string) *Resource                         { return nil }
+func (BaseVFS) Sync() error                                          { return nil }
+func (BaseVFS) FindCached(base string, re *regexp.Regexp) []Match    { return nil }
+func (BaseVFS) GrepCached(base, text string) []Match                 { return nil }
+func (BaseVFS) Describe(basePath, targetPath string) (string, error) { return "", nil }
+func (BaseVFS) Reauthenticate(password string) error                 { return ErrNotSupported }
+func (BaseVFS) Logout() error                                        { return nil }
+func (BaseVFS) Stats() *Stats                                        { return &Stats{} }
+func (BaseVFS) Quirks() QuirkSet                                     { return nil }
+func (BaseVFS) Auth() AuthMode                                       { return AuthNone }
+func (BaseVFS) Session() string                                      { return "" }
@@ -0,0 +1,31 @@
+package rvfs
+
+import "sync"
+
+// BulkResult is the outcome of one POST of a bulk operation
+type BulkResult struct {
+	Path     string
+	Response *Response
+	Err      error
+}
+
+// PostAll sends body to every path through g, at most limit requests at a
+// time, and returns the results in the order of paths. A POST to a path
+// sent within RepeatWindow fails with a *RepeatError unless again is set.
+func (g *PostGuard) PostAll(v Mutator, paths []string, body []byte, limit int, again bool) []BulkResult {
+	results := make([]BulkResult, len(paths))
+	slots := make(chan struct{}, max(limit, 1))
+	var wg sync.WaitGroup
+	for i, p := range paths {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			resp, err := g.Post(v, p, body, again)
+			results[i] = BulkResult{Path: p, Response: resp, Err: err}
+		}()
+	}
+	wg.Wait()
+	return results
+}
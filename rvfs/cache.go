@@ -1,21 +1,47 @@
 package rvfs
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
 	"os"
+	"regexp"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/buger/jsonparser"
 )
 
-// ResourceCache manages resources with transparent fetch-on-miss
+// ResourceCache manages resources with transparent fetch-on-miss. It is
+// safe for concurrent use: concurrent misses on a path share one fetch, and
+// a fetch overtaken by Invalidate or Clear is returned but not stored.
+// Requests run under the context of the caller sending them.
+// Resources loaded from the cache file are parsed when first used, so
+// loading a large file does not hold up startup.
 type ResourceCache struct {
-	client  *Client
-	parser  *Parser
-	store   map[string]*Resource
-	file    string
-	offline bool
-	mu      sync.RWMutex
+	client   *Client
+	parser   *Parser
+	store    map[string]*Resource
+	stored   map[string]storedEntry // Path → entry of the cache file, not yet parsed
+	inflight map[string]*fetch      // Path → fetch in progress
+	denied   map[string]error       // Path → ForbiddenError, until invalidated
+	allow    map[string][]string    // Path → methods the service allows
+	file     string
+	offline  atomic.Bool
+	stats    *Stats
+	index    *searchIndex // Built on the first search, dropped by Clear
+	mu       sync.RWMutex
+}
+
+// fetch is a fetch in progress; done is closed once resource or err is set
+type fetch struct {
+	done     chan struct{}
+	resource *Resource
+	err      error
 }
 
 // cacheEntry represents a serialized resource for persistence
@@ -27,13 +53,26 @@ type cacheEntry struct {
 	Data      string `json:"data"` // Base64 encoded raw JSON
 }
 
+// storedEntry is a resource loaded from the cache file and not yet parsed
+type storedEntry struct {
+	odataID   string
+	odataType string
+	fetchedAt time.Time
+	data      []byte // Base64 encoded raw JSON
+}
+
 // NewResourceCache creates a cache with auto-fetch capability
 func NewResourceCache(client *Client, parser *Parser, cacheFile string) *ResourceCache {
 	cache := &ResourceCache{
-		client: client,
-		parser: parser,
-		store:  make(map[string]*Resource),
-		file:   cacheFile,
+		client:   client,
+		parser:   parser,
+		store:    make(map[string]*Resource),
+		stored:   make(map[string]storedEntry),
+		inflight: make(map[string]*fetch),
+		denied:   make(map[string]error),
+		allow:    make(map[string][]string),
+		file:     cacheFile,
+		stats:    &Stats{},
 	}
 
 	// Try to load existing cache
@@ -45,11 +84,16 @@ func NewResourceCache(client *Client, parser *Parser, cacheFile string) *Resourc
 // NewOfflineCache creates a cache from disk only (offline mode)
 func NewOfflineCache(cacheFile string) (*ResourceCache, error) {
 	cache := &ResourceCache{
-		parser:  NewParser(),
-		store:   make(map[string]*Resource),
-		file:    cacheFile,
-		offline: true,
+		parser:   NewParser(ParserOptions{}),
+		store:    make(map[string]*Resource),
+		stored:   make(map[string]storedEntry),
+		inflight: make(map[string]*fetch),
+		denied:   make(map[string]error),
+		allow:    make(map[string][]string),
+		file:     cacheFile,
+		stats:    &Stats{},
 	}
+	cache.offline.Store(true)
 
 	if err := cache.Load(); err != nil {
 		return nil, err
@@ -58,49 +102,271 @@ func NewOfflineCache(cacheFile string) (*ResourceCache, error) {
 	return cache, nil
 }
 
-// Get retrieves a resource, fetching if necessary
-func (c *ResourceCache) Get(path string) (*Resource, error) {
+// Get retrieves a resource, fetching it under ctx if necessary
+func (c *ResourceCache) Get(ctx context.Context, path string) (*Resource, error) {
 	path = normalizePath(path)
 
 	// Check cache
 	c.mu.RLock()
-	if resource, ok := c.store[path]; ok {
-		c.mu.RUnlock()
+	resource, ok := c.store[path]
+	c.mu.RUnlock()
+	if ok {
+		c.stats.record(Request{Method: "GET", Path: path, Cached: true})
 		return resource, nil
 	}
-	c.mu.RUnlock()
 
-	// Not cached - check if offline
-	if c.offline {
+	// Join a fetch of the same path in progress, or start one: the parse
+	// of the entry of the cache file, or a request unless offline. A path
+	// the service refused is not asked for again until invalidated. A
+	// fetch joined is waited for as long as ctx allows, and one cut short
+	// by the context of the caller that started it is started again.
+	c.mu.Lock()
+	for {
+		if resource, ok := c.store[path]; ok {
+			c.mu.Unlock()
+			c.stats.record(Request{Method: "GET", Path: path, Cached: true})
+			return resource, nil
+		}
+		if err, ok := c.denied[path]; ok {
+			c.mu.Unlock()
+			return nil, err
+		}
+		f, ok := c.inflight[path]
+		if !ok {
+			break
+		}
+		c.mu.Unlock()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, &NetworkError{Path: path, Err: ctx.Err()}
+		}
+		if f.err == nil || !interrupted(f.err) || ctx.Err() != nil {
+			return f.resource, f.err
+		}
+		c.mu.Lock()
+	}
+	entry, stored := c.stored[path]
+	if !stored && c.offline.Load() {
+		c.mu.Unlock()
 		return nil, &NotCachedError{Path: path}
 	}
+	f := &fetch{done: make(chan struct{})}
+	c.inflight[path] = f
+	delete(c.stored, path)
+	c.mu.Unlock()
+
+	// A corrupted entry is fetched again
+	if stored {
+		f.resource, f.err = c.parseStored(path, entry)
+		if f.err == nil {
+			c.stats.record(Request{Method: "GET", Path: path, Cached: true})
+		}
+	}
+	if !stored || f.err != nil {
+		if c.offline.Load() {
+			f.resource, f.err = nil, &NotCachedError{Path: path}
+		} else {
+			f.resource, f.err = c.fetch(ctx, path)
+		}
+	}
+
+	// Store in cache, unless invalidated meanwhile
+	c.mu.Lock()
+	if c.inflight[path] == f {
+		delete(c.inflight, path)
+		var forbidden *ForbiddenError
+		if f.err == nil {
+			c.store[path] = f.resource
+			if c.index != nil {
+				c.index.add(path, f.resource)
+			}
+		} else if errors.As(f.err, &forbidden) {
+			c.denied[path] = f.err
+		}
+	}
+	c.mu.Unlock()
+	close(f.done)
+
+	return f.resource, f.err
+}
 
-	// Fetch from server
-	data, err := c.client.Fetch(path)
+// fetch requests and parses a resource, recording the request
+func (c *ResourceCache) fetch(ctx context.Context, path string) (*Resource, error) {
+	start := time.Now()
+	data, err := c.client.Fetch(ctx, path)
+	r := Request{
+		Method:   "GET",
+		Path:     path,
+		Status:   statusOf(err),
+		Bytes:    len(data),
+		Duration: time.Since(start),
+	}
 	if err != nil {
+		c.stats.record(r)
 		return nil, err
 	}
 
 	// Parse into resource
 	resource, err := c.parser.Parse(path, data)
 	if err != nil {
+		c.stats.record(r)
 		return nil, err
 	}
+	r.Warnings = resource.Warnings
+	c.stats.record(r)
+	return resource, nil
+}
 
-	// Store in cache
+// parseStored parses an entry of the cache file, restoring its fetch time
+func (c *ResourceCache) parseStored(path string, entry storedEntry) (*Resource, error) {
+	rawJSON, err := base64.StdEncoding.DecodeString(string(entry.data))
+	if err != nil {
+		return nil, err
+	}
+	parser := c.parser
+	if parser == nil {
+		parser = NewParser(ParserOptions{})
+	}
+	resource, err := parser.Parse(path, rawJSON)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.fetchedAt.IsZero() {
+		resource.FetchedAt = entry.fetchedAt
+	}
+	return resource, nil
+}
+
+// Download streams a payload from the client to w; it is not cached
+func (c *ResourceCache) Download(ctx context.Context, path string, w io.Writer, progress ProgressFunc) (int64, error) {
+	if c.offline.Load() {
+		return 0, &NotCachedError{Path: path}
+	}
+
+	start := time.Now()
+	n, err := c.client.Download(ctx, path, w, progress)
+	c.stats.record(Request{Method: "GET", Path: path, Status: statusOf(err), Bytes: int(n), Duration: time.Since(start)})
+	return n, err
+}
+
+// Post delegates a POST request to the client (no caching for writes)
+func (c *ResourceCache) Post(ctx context.Context, path string, body []byte) (*Response, error) {
+	if c.offline.Load() {
+		return nil, &NotCachedError{Path: path}
+	}
+
+	start := time.Now()
+	resp, err := c.client.Post(ctx, path, body)
+	r := Request{Method: "POST", Path: path, Status: statusOf(err), Duration: time.Since(start)}
+	if resp != nil {
+		r.Status = resp.StatusCode
+		r.Bytes = len(resp.Body)
+	}
+	c.stats.record(r)
+	return resp, err
+}
+
+// Patch sends a PATCH conditional on the @odata.etag of the cached copy
+// of path, unconditional when there is none. A service that insists on a
+// condition (428) is asked again with the ETag of a fresh copy. The
+// resource is invalidated either way, as it may have changed.
+func (c *ResourceCache) Patch(ctx context.Context, path string, body []byte) (*Response, error) {
+	if c.offline.Load() {
+		return nil, &NotCachedError{Path: path}
+	}
+	path = normalizePath(path)
+	defer c.Invalidate(path)
+
+	etag := ""
+	c.mu.RLock()
+	if res, ok := c.store[path]; ok {
+		etag = resourceETag(res)
+	}
+	c.mu.RUnlock()
+
+	resp, err := c.patch(ctx, path, body, etag)
+	if err == nil && resp.StatusCode == http.StatusPreconditionRequired && etag == "" {
+		c.Invalidate(path)
+		if res, getErr := c.Get(ctx, path); getErr == nil && resourceETag(res) != "" {
+			resp, err = c.patch(ctx, path, body, resourceETag(res))
+		}
+	}
+	return resp, err
+}
+
+// resourceETag returns the @odata.etag of a resource, "" without one
+func resourceETag(res *Resource) string {
+	etag, _ := jsonparser.GetString(res.RawJSON, "@odata.etag")
+	return etag
+}
+
+// patch sends one PATCH and records it
+func (c *ResourceCache) patch(ctx context.Context, path string, body []byte, etag string) (*Response, error) {
+	start := time.Now()
+	resp, err := c.client.Patch(ctx, path, body, etag)
+	r := Request{Method: "PATCH", Path: path, Status: statusOf(err), Duration: time.Since(start)}
+	if resp != nil {
+		r.Status = resp.StatusCode
+		r.Bytes = len(resp.Body)
+	}
+	c.stats.record(r)
+	return resp, err
+}
+
+// Allow returns the methods the service allows on path, asking it the
+// first time. Like resources, the answer is kept until path is invalidated.
+func (c *ResourceCache) Allow(ctx context.Context, path string) ([]string, error) {
+	path = normalizePath(path)
+
+	c.mu.RLock()
+	methods, ok := c.allow[path]
+	c.mu.RUnlock()
+	if ok {
+		return methods, nil
+	}
+	if c.offline.Load() {
+		return nil, &NotCachedError{Path: path}
+	}
+
+	start := time.Now()
+	methods, err := c.client.Allow(ctx, path)
+	c.stats.record(Request{Method: "OPTIONS", Path: path, Status: statusOf(err), Duration: time.Since(start)})
+	if err != nil {
+		return nil, err
+	}
 	c.mu.Lock()
-	c.store[path] = resource
+	c.allow[path] = methods
 	c.mu.Unlock()
+	return methods, nil
+}
 
-	return resource, nil
+// Stats returns the request statistics of this cache
+func (c *ResourceCache) Stats() *Stats {
+	return c.stats
 }
 
-// Post delegates a POST request to the client (no caching for writes)
-func (c *ResourceCache) Post(path string, body []byte) ([]byte, int, error) {
-	if c.offline {
-		return nil, 0, &NotCachedError{Path: path}
+// interrupted reports whether err is a request given up on because its
+// context was cancelled or its deadline passed
+func interrupted(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// statusOf returns the HTTP status implied by a fetch error: 200 for
+// success, the response status for HTTP errors, 0 for transport failures
+func statusOf(err error) int {
+	if err == nil {
+		return 200
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode
 	}
-	return c.client.Post(path, body)
+	var forbidden *ForbiddenError
+	if errors.As(err, &forbidden) {
+		return forbidden.StatusCode
+	}
+	return 0
 }
 
 // Put stores a resource in cache
@@ -108,6 +374,10 @@ func (c *ResourceCache) Put(resource *Resource) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.store[resource.Path] = resource
+	delete(c.stored, resource.Path)
+	if c.index != nil {
+		c.index.add(resource.Path, resource)
+	}
 }
 
 // GetKnownPaths returns all cached paths
@@ -115,10 +385,13 @@ func (c *ResourceCache) GetKnownPaths() []string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	paths := make([]string, 0, len(c.store))
+	paths := make([]string, 0, len(c.store)+len(c.stored))
 	for path := range c.store {
 		paths = append(paths, path)
 	}
+	for path := range c.stored {
+		paths = append(paths, path)
+	}
 	return paths
 }
 
@@ -130,6 +403,13 @@ func (c *ResourceCache) Invalidate(path string) {
 	defer c.mu.Unlock()
 
 	delete(c.store, path)
+	delete(c.stored, path)
+	delete(c.inflight, path)
+	delete(c.denied, path)
+	delete(c.allow, path)
+	if c.index != nil {
+		c.index.remove(path)
+	}
 }
 
 // Clear removes all cached resources
@@ -138,6 +418,100 @@ func (c *ResourceCache) Clear() {
 	defer c.mu.Unlock()
 
 	c.store = make(map[string]*Resource)
+	c.stored = make(map[string]storedEntry)
+	c.inflight = make(map[string]*fetch)
+	c.denied = make(map[string]error)
+	c.allow = make(map[string][]string)
+	c.index = nil
+}
+
+// Denied reports whether the service refused path to the logged-in role
+func (c *ResourceCache) Denied(path string) bool {
+	path = normalizePath(path)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.denied[path]
+	return ok
+}
+
+// Cached returns the resource at path when it is in the store, nil when it
+// is not cached or only in the cache file, not parsed yet
+func (c *ResourceCache) Cached(path string) *Resource {
+	path = normalizePath(path)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.store[path]
+}
+
+// FetchedAt returns when the cached resource at path was fetched, false
+// when it is not cached
+func (c *ResourceCache) FetchedAt(path string) (time.Time, bool) {
+	path = normalizePath(path)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if resource, ok := c.store[path]; ok {
+		return resource.FetchedAt, true
+	}
+	if entry, ok := c.stored[path]; ok {
+		return entry.fetchedAt, true
+	}
+	return time.Time{}, false
+}
+
+// SearchNames returns the cached resources with a property, at any depth,
+// whose name matches re
+func (c *ResourceCache) SearchNames(re *regexp.Regexp) []*Resource {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.resources(c.searchIndex().matchNames(re))
+}
+
+// SearchValues returns the cached resources whose property values may
+// contain text
+func (c *ResourceCache) SearchValues(text string) []*Resource {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.resources(c.searchIndex().matchText(text))
+}
+
+// searchIndex returns the index of the cached resources, building it when
+// none is kept. Entries of the cache file not yet parsed are parsed to be
+// indexed, and corrupted ones dropped. Callers hold the write lock.
+func (c *ResourceCache) searchIndex() *searchIndex {
+	for path, entry := range c.stored {
+		delete(c.stored, path)
+		resource, err := c.parseStored(path, entry)
+		if err != nil {
+			continue
+		}
+		c.store[path] = resource
+		if c.index != nil {
+			c.index.add(path, resource)
+		}
+	}
+	if c.index == nil {
+		c.index = newSearchIndex()
+		for path, resource := range c.store {
+			c.index.add(path, resource)
+		}
+	}
+	return c.index
+}
+
+// resources returns the cached resources at paths
+func (c *ResourceCache) resources(paths map[string]bool) []*Resource {
+	resources := make([]*Resource, 0, len(paths))
+	for path := range paths {
+		if resource, ok := c.store[path]; ok {
+			resources = append(resources, resource)
+		}
+	}
+	return resources
 }
 
 // Size returns the number of cached resources
@@ -145,7 +519,7 @@ func (c *ResourceCache) Size() int {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return len(c.store)
+	return len(c.store) + len(c.stored)
 }
 
 // Save persists cache to disk
@@ -157,11 +531,21 @@ func (c *ResourceCache) Save() error {
 		return nil
 	}
 
-	// Convert to cache entries
-	entries := make(map[string]cacheEntry)
+	// Convert to cache entries; those of the file not yet parsed are
+	// written back as they were read
+	entries := make(map[string]cacheEntry, len(c.store)+len(c.stored))
+	for path, entry := range c.stored {
+		entries[path] = cacheEntry{
+			Path:      path,
+			ODataID:   entry.odataID,
+			ODataType: entry.odataType,
+			FetchedAt: entry.fetchedAt.Format("2006-01-02T15:04:05Z07:00"),
+			Data:      string(entry.data),
+		}
+	}
 	for path, resource := range c.store {
 		entries[path] = cacheEntry{
-			Path:      resource.Path,
+			Path:      path, // Cache key, including query options of partial resources
 			ODataID:   resource.ODataID,
 			ODataType: resource.ODataType,
 			FetchedAt: resource.FetchedAt.Format("2006-01-02T15:04:05Z07:00"),
@@ -174,10 +558,11 @@ func (c *ResourceCache) Save() error {
 		return err
 	}
 
-	return os.WriteFile(c.file, data, 0644)
+	return WriteFile(c.file, data, 0644)
 }
 
-// Load restores cache from disk
+// Load restores cache from disk. It reads the paths and fetch times of
+// the entries; their resources are parsed when first used.
 func (c *ResourceCache) Load() error {
 	if c.file == "" {
 		return nil
@@ -191,39 +576,38 @@ func (c *ResourceCache) Load() error {
 		return err
 	}
 
-	var entries map[string]cacheEntry
-	if err := json.Unmarshal(data, &entries); err != nil {
+	entries := make(map[string]storedEntry)
+	err = jsonparser.ObjectEach(data, func(key []byte, value []byte, dataType jsonparser.ValueType, offset int) error {
+		path, err := jsonparser.GetString(value, "path")
+		if err != nil {
+			return nil // Skip corrupted entries
+		}
+		encoded, dataType, _, err := jsonparser.Get(value, "data")
+		if err != nil || dataType != jsonparser.String {
+			return nil
+		}
+		entry := storedEntry{data: encoded}
+		entry.odataID, _ = jsonparser.GetString(value, "odataId")
+		entry.odataType, _ = jsonparser.GetString(value, "odataType")
+		if fetched, err := jsonparser.GetString(value, "fetchedAt"); err == nil {
+			entry.fetchedAt, _ = time.Parse(time.RFC3339, fetched)
+		}
+		entries[path] = entry
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 
-	// Convert to resources
-	parser := c.parser
-	if parser == nil {
-		parser = NewParser()
-	}
-
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	for _, entry := range entries {
-		// Decode raw JSON
-		rawJSON, err := base64.StdEncoding.DecodeString(entry.Data)
-		if err != nil {
-			continue // Skip corrupted entries
+	for path, entry := range entries {
+		delete(c.store, path)
+		if c.index != nil {
+			c.index.remove(path)
 		}
-
-		// Re-parse to get full structure
-		resource, err := parser.Parse(entry.Path, rawJSON)
-		if err != nil {
-			continue // Skip unparseable entries
-		}
-
-		// Restore original fetch timestamp
-		if t, err := time.Parse(time.RFC3339, entry.FetchedAt); err == nil {
-			resource.FetchedAt = t
-		}
-
-		c.store[entry.Path] = resource
+		c.stored[path] = entry
 	}
 
 	return nil
@@ -231,10 +615,10 @@ func (c *ResourceCache) Load() error {
 
 // IsOffline returns true if cache is in offline mode
 func (c *ResourceCache) IsOffline() bool {
-	return c.offline
+	return c.offline.Load()
 }
 
 // SetOffline sets offline mode
 func (c *ResourceCache) SetOffline(offline bool) {
-	c.offline = offline
+	c.offline.Store(offline)
 }
@@ -0,0 +1,182 @@
+package rvfs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// redacted replaces credentials in recorded interactions
+const redacted = "REDACTED"
+
+// Interaction is one recorded HTTP exchange
+type Interaction struct {
+	Method string `json:"method"`
+	URI    string `json:"uri"` // Path and query, without scheme and host
+	Body   string `json:"body,omitempty"`
+
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header,omitempty"`
+	Response string      `json:"response,omitempty"`
+}
+
+// Cassette is an HTTP transport that records the exchanges it carries, or
+// replays recorded ones without a network. Give it to a VFS as
+// Options.Transport.
+//
+// Replay answers the Nth request for a method and URI with the Nth
+// recording of them, and repeats the last once they run out; request
+// bodies are not compared. Passwords in session logins and session tokens
+// are redacted when recording.
+type Cassette struct {
+	mu           sync.Mutex
+	file         string
+	next         http.RoundTripper // Transport being recorded; nil when replaying
+	interactions []Interaction
+	played       map[string]int // Method and URI → requests answered
+}
+
+// RecordCassette returns a cassette that records the exchanges carried by
+// next; Save writes them to file
+func RecordCassette(file string, next http.RoundTripper) *Cassette {
+	return &Cassette{file: file, next: next}
+}
+
+// LoadCassette reads a recorded cassette for replay
+func LoadCassette(file string) (*Cassette, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var interactions []Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", file, err)
+	}
+	return ReplayCassette(file, interactions), nil
+}
+
+// ReplayCassette returns a cassette that replays interactions recorded
+// elsewhere, such as in a session bundle; name stands for their file in
+// errors
+func ReplayCassette(name string, interactions []Interaction) *Cassette {
+	return &Cassette{file: name, interactions: interactions, played: make(map[string]int)}
+}
+
+// Interactions returns the exchanges recorded so far, or loaded
+func (c *Cassette) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Interaction(nil), c.interactions...)
+}
+
+// Save writes the recorded exchanges to the cassette's file
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.file, data, 0644)
+}
+
+// RoundTrip records or replays one exchange
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if c.next == nil {
+		return c.replay(req)
+	}
+
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	header := resp.Header.Clone()
+	for _, name := range []string{"X-Auth-Token", "Set-Cookie"} {
+		if header.Get(name) != "" {
+			header.Set(name, redacted)
+		}
+	}
+	c.mu.Lock()
+	c.interactions = append(c.interactions, Interaction{
+		Method:   req.Method,
+		URI:      req.URL.RequestURI(),
+		Body:     redactLogin(body),
+		Status:   resp.StatusCode,
+		Header:   header,
+		Response: string(data),
+	})
+	c.mu.Unlock()
+	return resp, nil
+}
+
+// replay answers a request from the recordings
+func (c *Cassette) replay(req *http.Request) (*http.Response, error) {
+	uri := req.URL.RequestURI()
+	key := req.Method + " " + uri
+
+	c.mu.Lock()
+	var matches []int
+	for i, in := range c.interactions {
+		if in.Method == req.Method && in.URI == uri {
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) == 0 {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("cassette %s has no recording of %s", c.file, key)
+	}
+	n := min(c.played[key], len(matches)-1)
+	c.played[key]++
+	in := c.interactions[matches[n]]
+	c.mu.Unlock()
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+		StatusCode:    in.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        in.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(in.Response)),
+		ContentLength: int64(len(in.Response)),
+		Request:       req,
+	}, nil
+}
+
+// redactLogin masks the password of a session login body
+func redactLogin(body []byte) string {
+	var login map[string]any
+	if json.Unmarshal(body, &login) != nil {
+		return string(body)
+	}
+	if _, ok := login["Password"]; !ok {
+		return string(body)
+	}
+	login["Password"] = redacted
+	data, err := json.Marshal(login)
+	if err != nil {
+		return string(body)
+	}
+	return string(data)
+}
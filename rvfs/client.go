@@ -2,56 +2,195 @@ package rvfs
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 // Client handles HTTP communication with Redfish endpoint
 type Client struct {
 	endpoint string
+	root     string     // Service root of the protocol version in use
+	version  string     // Protocol version asked for, "" for the newest offered
+	mu       sync.Mutex // Guards token; bulk operations POST concurrently
 	token    string
+	session  string     // Path of the login session, from its Location
+	loginMu  sync.Mutex // Serializes logins, so concurrent 401s make one session
 	username string
 	password string
 	http     *http.Client
+	quirks   QuirkSet
+	sessions *SessionStore // Shares the login session with other processes, nil for none
+	holder   string        // Who this connection is in sessions
 }
 
-// NewClient creates and authenticates a Redfish client
+// NewClient creates a Redfish client and checks that the service answers.
+// No session is created up front: many services serve the root without
+// one, so the client logs in on the first 401.
 func NewClient(endpoint, username, password string, insecure bool) (*Client, error) {
+	return newClient(endpoint, username, password, "", NewTransport(insecure))
+}
+
+// NewTransport returns the HTTP transport clients reach services with,
+// skipping TLS verification when insecure
+func NewTransport(insecure bool) http.RoundTripper {
+	return &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+	}
+}
+
+// newClient creates a client whose requests go through transport, speaking
+// the protocol version given, or the newest the service offers
+func newClient(endpoint, username, password, version string, transport http.RoundTripper) (*Client, error) {
 	// Parse endpoint to validate
-	_, err := url.Parse(endpoint)
+	u, err := url.Parse(endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("invalid endpoint: %w", err)
 	}
-
-	// Create HTTP client with optional TLS verification
-	httpClient := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
-		},
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q: want scheme://host, e.g. https://10.0.0.1", endpoint)
 	}
 
 	client := &Client{
 		endpoint: endpoint,
+		version:  version,
 		username: username,
 		password: password,
-		http:     httpClient,
+		http:     &http.Client{Transport: transport},
 	}
 
-	// Authenticate
-	if err := client.Login(); err != nil {
+	if err := client.probe(); err != nil {
 		return nil, err
 	}
 
 	return client, nil
 }
 
+// probe picks the service root from the versions the service lists, then
+// fetches it without credentials. Any HTTP answer but 404 shows a Redfish
+// service is there, including a 401 from services that protect the root
+// too.
+func (c *Client) probe() error {
+	root, err := c.negotiate()
+	if err != nil {
+		return err
+	}
+	c.root = root
+
+	req, err := http.NewRequestWithContext(context.Background(), "GET", c.endpoint+c.root, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return &UnreachableError{Endpoint: c.endpoint, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("no Redfish service at %s: %w", c.endpoint, httpError(c.root, resp))
+	}
+	return nil
+}
+
+// negotiate reads the protocol versions the service lists at /redfish and
+// returns the service root of the one asked for, or of the newest. A
+// service that lists none, or cannot be asked, is taken to speak v1 only.
+func (c *Client) negotiate() (string, error) {
+	versions := c.versions()
+	if c.version != "" {
+		if root, ok := versions[c.version]; ok {
+			return root, nil
+		}
+		if len(versions) == 0 && c.version == "v1" {
+			return DefaultRoot, nil
+		}
+		offered := slices.Sorted(maps.Keys(versions))
+		if len(offered) == 0 {
+			offered = []string{"v1"}
+		}
+		return "", fmt.Errorf("%s does not offer Redfish %s (offers %s)", c.endpoint, c.version, strings.Join(offered, ", "))
+	}
+
+	newest, root := 0, DefaultRoot
+	for version, path := range versions {
+		if n, err := strconv.Atoi(strings.TrimPrefix(version, "v")); err == nil && n > newest {
+			newest, root = n, path
+		}
+	}
+	return root, nil
+}
+
+// versions fetches the version document without credentials, mapping each
+// version (v1) to its service root path (/redfish/v1). Members that are not
+// a version with a root under /redfish are ignored.
+func (c *Client) versions() map[string]string {
+	req, err := http.NewRequestWithContext(context.Background(), "GET", c.endpoint+VersionsPath, nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	var doc map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil
+	}
+	versions := make(map[string]string)
+	for version, value := range doc {
+		path, ok := value.(string)
+		if !ok || !isVersion(version) {
+			continue
+		}
+		if path = normalizePath(locationPath(path)); versionRoot(path) == path {
+			versions[version] = path
+		}
+	}
+	return versions
+}
+
+// Root returns the service root of the protocol version in use
+func (c *Client) Root() string {
+	return c.root
+}
+
+// Reauthenticate logs in with a new password, as after the password was
+// rotated mid-session, and keeps it for later logins. The old password is
+// kept when the login fails.
+func (c *Client) Reauthenticate(ctx context.Context, password string) error {
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	old := c.password
+	c.password = password
+	if err := c.login(ctx, "", true); err != nil {
+		c.password = old
+		return err
+	}
+	return nil
+}
+
 // Login performs session-based authentication
-func (c *Client) Login() error {
-	loginURL := c.endpoint + "/redfish/v1/SessionService/Sessions"
+func (c *Client) Login(ctx context.Context) error {
+	loginURL := c.endpoint + c.root + "/SessionService/Sessions"
 
 	payload := map[string]string{
 		"UserName": c.username,
@@ -63,7 +202,7 @@ func (c *Client) Login() error {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", loginURL, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", loginURL, bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
@@ -75,146 +214,436 @@ func (c *Client) Login() error {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		return &HTTPError{Path: "/SessionService/Sessions", StatusCode: resp.StatusCode}
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusOK:
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden:
+		data, _ := io.ReadAll(resp.Body)
+		return &AuthError{User: c.username, StatusCode: resp.StatusCode, Messages: parseMessages(data)}
+	default:
+		return httpError("/SessionService/Sessions", resp)
 	}
 
 	// Extract session token from header
-	c.token = resp.Header.Get("X-Auth-Token")
-	if c.token == "" {
+	token := resp.Header.Get("X-Auth-Token")
+	location := resp.Header.Get("Location")
+	if token == "" && location != "" {
 		// Some implementations use Location header
-		location := resp.Header.Get("Location")
-		if location != "" {
-			c.token = "session-based"
-		}
+		token = "session-based"
 	}
 
+	c.mu.Lock()
+	c.token = token
+	c.session = locationPath(location)
+	c.mu.Unlock()
 	return nil
 }
 
-// Logout closes the session
-func (c *Client) Logout() error {
-	// Session logout implementation would go here
-	// For now, just clear the token
-	c.token = ""
+// AuthMode is how a connection authenticates its requests
+type AuthMode string
+
+const (
+	// AuthNone means no request has needed credentials yet
+	AuthNone AuthMode = "none"
+	// AuthSession means requests carry a Redfish session token
+	AuthSession AuthMode = "session"
+)
+
+// Auth returns how the client currently authenticates
+func (c *Client) Auth() AuthMode {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" {
+		return AuthSession
+	}
+	return AuthNone
+}
+
+// Session returns the path of the login session, "" before a login
+func (c *Client) Session() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.session
+}
+
+// authorize adds the session token, once there is one, to a request and
+// returns the token it added
+func (c *Client) authorize(req *http.Request) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" {
+		req.Header.Set("X-Auth-Token", c.token)
+	}
+	return c.token
+}
+
+// renew logs in after a request sent with token stale was refused, unless
+// a concurrent request already replaced that token. A login given up on
+// with ctx leaves the token stale, for the next request to log in again.
+func (c *Client) renew(ctx context.Context, stale string) error {
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	c.mu.Lock()
+	current := c.token
+	c.mu.Unlock()
+	if current != stale {
+		return nil
+	}
+	return c.login(ctx, stale, false)
+}
+
+// login logs in after a request sent with token stale was refused. With a
+// SessionStore the connection joins the session other processes share,
+// unless that is the stale one or force asks for a new login; a new
+// session replaces the shared one.
+func (c *Client) login(ctx context.Context, stale string, force bool) error {
+	if c.sessions == nil {
+		return c.Login(ctx)
+	}
+	token, session, err := c.sessions.acquire(c.sessionKey(), c.holder, stale, force, func() (string, string, error) {
+		if err := c.Login(ctx); err != nil {
+			return "", "", err
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.token, c.session, nil
+	})
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.token, c.session = token, session
+	c.mu.Unlock()
 	return nil
 }
 
-// Fetch retrieves raw JSON from a path
-func (c *Client) Fetch(path string) ([]byte, error) {
+// sessionKey is what the session of the connection is shared by
+func (c *Client) sessionKey() string {
+	return c.username + "@" + c.endpoint
+}
+
+// Logout lets go of the session and deletes it from the service; a
+// shared session is deleted by the last process holding it
+func (c *Client) Logout(ctx context.Context) error {
+	c.mu.Lock()
+	token, session := c.token, c.session
+	c.token, c.session = "", ""
+	c.mu.Unlock()
+	if token == "" {
+		return nil
+	}
+	if c.sessions != nil {
+		last, err := c.sessions.release(c.sessionKey(), c.holder, token)
+		if err != nil || !last {
+			return err
+		}
+	}
+	if session == "" {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.endpoint+session, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Auth-Token", token)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return &NetworkError{Path: session, Err: err}
+	}
+	defer resp.Body.Close()
+	// A session already gone, expired or deleted, is as good as deleted
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusAccepted, http.StatusNotFound, http.StatusUnauthorized:
+		return nil
+	}
+	return httpError(session, resp)
+}
+
+// Fetch retrieves raw JSON from a path; cancelling ctx, or its deadline
+// passing, aborts the request with a NetworkError wrapping ctx.Err()
+func (c *Client) Fetch(ctx context.Context, path string) ([]byte, error) {
+	data, contentType, err := c.get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if !jsonContentType(contentType, c.quirks) {
+		return nil, &ContentTypeError{Path: path, ContentType: contentType}
+	}
+	return data, nil
+}
+
+// get retrieves a response body and its content type
+func (c *Client) get(ctx context.Context, path string) ([]byte, string, error) {
 	// Normalize path
 	if path[0] != '/' {
 		path = "/" + path
 	}
 
-	url := c.endpoint + path
+	// OData query options ($select, $filter, ...) are encoded for the wire
+	base, query := splitQuery(path)
+	url := c.endpoint + withQuery(base, encodeQuery(query))
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	if c.token != "" {
-		req.Header.Set("X-Auth-Token", c.token)
-	}
+	used := c.authorize(req)
 	req.Header.Set("Accept", "application/json")
 
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return nil, &NetworkError{Path: path, Err: err}
+		return nil, "", &NetworkError{Path: path, Err: err}
 	}
 	defer resp.Body.Close()
 
-	// Handle 401 Unauthorized - session may have expired
+	// Handle 401 Unauthorized - no session yet, or it expired
 	if resp.StatusCode == http.StatusUnauthorized {
-		// Attempt to re-authenticate
-		if err := c.Login(); err != nil {
-			return nil, &HTTPError{Path: path, StatusCode: resp.StatusCode}
+		if err := c.renew(ctx, used); err != nil {
+			return nil, "", err
 		}
 
 		// Retry the request with new token
-		req, err = http.NewRequest("GET", url, nil)
+		req, err = http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
-		if c.token != "" {
-			req.Header.Set("X-Auth-Token", c.token)
-		}
+		c.authorize(req)
 		req.Header.Set("Accept", "application/json")
 
 		resp, err = c.http.Do(req)
 		if err != nil {
-			return nil, &NetworkError{Path: path, Err: err}
+			return nil, "", &NetworkError{Path: path, Err: err}
 		}
 		defer resp.Body.Close()
 	}
 
+	// A session that logged in and is still refused lacks the privilege
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusUnauthorized {
+		data, _ := io.ReadAll(resp.Body)
+		return nil, "", &ForbiddenError{Path: path, StatusCode: resp.StatusCode, Messages: parseMessages(data)}
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, &HTTPError{Path: path, StatusCode: resp.StatusCode}
+		return nil, "", httpError(path, resp)
 	}
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, &NetworkError{Path: path, Err: err}
+		return nil, "", &NetworkError{Path: path, Err: err}
 	}
 
-	return data, nil
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// httpError builds an HTTPError, keeping any @Message.ExtendedInfo the
+// service put in the error body
+func httpError(path string, resp *http.Response) *HTTPError {
+	data, _ := io.ReadAll(resp.Body)
+	return &HTTPError{Path: path, StatusCode: resp.StatusCode, Messages: parseMessages(data)}
+}
+
+// locationPath returns the path of a Location header, which services send
+// either as a path or as an absolute URL
+func locationPath(location string) string {
+	if u, err := url.Parse(location); err == nil && u.Path != "" {
+		return u.Path
+	}
+	return location
 }
 
-// Post sends a POST request with a JSON body
-func (c *Client) Post(path string, body []byte) ([]byte, int, error) {
+// Allow returns the HTTP methods the service allows on path, from the
+// Allow header of an OPTIONS request. A service that does not implement
+// OPTIONS is asked with a HEAD, which carries the header as a GET would.
+// nil means the service sent no Allow header.
+func (c *Client) Allow(ctx context.Context, path string) ([]string, error) {
 	if path[0] != '/' {
 		path = "/" + path
 	}
+	resp, data, err := c.probeMethod(ctx, "OPTIONS", path)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented {
+		if resp, data, err = c.probeMethod(ctx, "HEAD", path); err != nil {
+			return nil, err
+		}
+	}
 
-	url := c.endpoint + path
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusUnauthorized {
+		return nil, &ForbiddenError{Path: path, StatusCode: resp.StatusCode, Messages: parseMessages(data)}
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, &HTTPError{Path: path, StatusCode: resp.StatusCode, Messages: parseMessages(data)}
+	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
-	if err != nil {
-		return nil, 0, err
+	var methods []string
+	for _, value := range resp.Header.Values("Allow") {
+		for _, method := range strings.Split(value, ",") {
+			if method = strings.ToUpper(strings.TrimSpace(method)); method != "" {
+				methods = append(methods, method)
+			}
+		}
 	}
+	return methods, nil
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	if c.token != "" {
-		req.Header.Set("X-Auth-Token", c.token)
+// probeMethod sends a request without a body, logging in on a 401 as get
+// does, and returns the response with its body read
+func (c *Client) probeMethod(ctx context.Context, method, path string) (*http.Response, []byte, error) {
+	send := func() (*http.Response, []byte, string, error) {
+		req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, nil)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		used := c.authorize(req)
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, nil, "", &NetworkError{Path: path, Err: err}
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, nil, "", &NetworkError{Path: path, Err: err}
+		}
+		return resp, data, used, nil
 	}
-	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.http.Do(req)
+	resp, data, used, err := send()
 	if err != nil {
-		return nil, 0, &NetworkError{Path: path, Err: err}
+		return nil, nil, err
 	}
-	defer resp.Body.Close()
-
-	// Handle 401 Unauthorized - session may have expired
+	// Handle 401 Unauthorized - no session yet, or it expired
 	if resp.StatusCode == http.StatusUnauthorized {
-		if err := c.Login(); err != nil {
-			return nil, resp.StatusCode, &HTTPError{Path: path, StatusCode: resp.StatusCode}
+		if err := c.renew(ctx, used); err != nil {
+			return nil, nil, err
 		}
+		if resp, data, _, err = send(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return resp, data, nil
+}
 
-		req, err = http.NewRequest("POST", url, bytes.NewReader(body))
+// Download streams the body at path to w without parsing it, for binary
+// payloads such as log bundles, SPD dumps and debug collections. progress,
+// when not nil, is called as the body arrives. It returns the bytes
+// written; a failed write to w is returned as it is.
+func (c *Client) Download(ctx context.Context, path string, w io.Writer, progress ProgressFunc) (int64, error) {
+	if path[0] != '/' {
+		path = "/" + path
+	}
+
+	var resp *http.Response
+	for retried := false; ; retried = true {
+		req, err := http.NewRequestWithContext(ctx, "GET", c.endpoint+path, nil)
 		if err != nil {
-			return nil, 0, err
+			return 0, err
 		}
+		used := c.authorize(req)
+		req.Header.Set("Accept", "*/*")
 
-		req.Header.Set("Content-Type", "application/json")
-		if c.token != "" {
-			req.Header.Set("X-Auth-Token", c.token)
+		resp, err = c.http.Do(req)
+		if err != nil {
+			return 0, &NetworkError{Path: path, Err: err}
 		}
-		req.Header.Set("Accept", "application/json")
+		// Handle 401 Unauthorized - no session yet, or it expired
+		if resp.StatusCode != http.StatusUnauthorized || retried {
+			break
+		}
+		resp.Body.Close()
+		if err := c.renew(ctx, used); err != nil {
+			return 0, err
+		}
+	}
+	defer resp.Body.Close()
 
-		resp, err = c.http.Do(req)
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusUnauthorized {
+		data, _ := io.ReadAll(resp.Body)
+		return 0, &ForbiddenError{Path: path, StatusCode: resp.StatusCode, Messages: parseMessages(data)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, httpError(path, resp)
+	}
+
+	counter := &progressWriter{w: w, total: resp.ContentLength, progress: progress}
+	n, err := io.Copy(counter, resp.Body)
+	if counter.err != nil {
+		return n, counter.err
+	}
+	if err != nil {
+		return n, &NetworkError{Path: path, Err: err}
+	}
+	return n, nil
+}
+
+// Post sends a POST request with a JSON body. Any HTTP status is returned
+// as a Response; only transport failures are errors.
+func (c *Client) Post(ctx context.Context, path string, body []byte) (*Response, error) {
+	return c.write(ctx, "POST", path, body, "")
+}
+
+// Patch sends a PATCH request with a JSON body, conditional on the ETag
+// when one is given. Like Post, any HTTP status is returned as a Response.
+func (c *Client) Patch(ctx context.Context, path string, body []byte, etag string) (*Response, error) {
+	return c.write(ctx, "PATCH", path, body, etag)
+}
+
+// write sends a request with a JSON body, renewing the session once when
+// the service answers 401
+func (c *Client) write(ctx context.Context, method, path string, body []byte, etag string) (*Response, error) {
+	if path[0] != '/' {
+		path = "/" + path
+	}
+
+	url := c.endpoint + path
+	send := func() (*http.Response, string, error) {
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
 		if err != nil {
-			return nil, 0, &NetworkError{Path: path, Err: err}
+			return nil, "", err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		used := c.authorize(req)
+		req.Header.Set("Accept", "application/json")
+		if etag != "" {
+			req.Header.Set("If-Match", etag)
+		}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, "", &NetworkError{Path: path, Err: err}
+		}
+		return resp, used, nil
+	}
+
+	resp, used, err := send()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Handle 401 Unauthorized - no session yet, or it expired
+	if resp.StatusCode == http.StatusUnauthorized {
+		if err := c.renew(ctx, used); err != nil {
+			return nil, err
+		}
+		if resp, _, err = send(); err != nil {
+			return nil, err
 		}
 		defer resp.Body.Close()
 	}
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, 0, &NetworkError{Path: path, Err: err}
+		return nil, &NetworkError{Path: path, Err: err}
 	}
 
-	return data, resp.StatusCode, nil
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Body:       data,
+		Location:   locationPath(resp.Header.Get("Location")),
+		Messages:   parseMessages(data),
+	}, nil
 }
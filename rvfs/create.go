@@ -0,0 +1,192 @@
+package rvfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/buger/jsonparser"
+)
+
+// CreateField is a property to supply when creating a collection member
+type CreateField struct {
+	Name      string // Nested properties are dotted: "Links.ClassOfService"
+	Required  bool
+	Allowable []string
+}
+
+// CreateCapability is a use case for creating members of a collection,
+// with the fields its capabilities object asks for
+type CreateCapability struct {
+	Capability
+	Fields []CreateField // Required fields first
+}
+
+// CreateCapabilities returns the ways of creating members of the collection
+// at path. Each capabilities object is fetched to learn its fields from the
+// @Redfish.RequiredOnCreate, @Redfish.OptionalOnCreate and
+// @Redfish.AllowableValues annotations. A collection without
+// @Redfish.CollectionCapabilities yields none; members are then created from
+// whatever fields are given. Creates the annotation rules out, because the
+// collection is full or lists no use case, are an error.
+func CreateCapabilities(v Reader, path string) ([]CreateCapability, error) {
+	collection, err := v.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	cc := collection.Capabilities
+	if cc == nil {
+		return nil, nil
+	}
+	if len(cc.Capabilities) == 0 {
+		return nil, fmt.Errorf("%s does not accept new members", path)
+	}
+	if count := MemberCount(collection); cc.MaxMembers > 0 && count >= cc.MaxMembers {
+		return nil, fmt.Errorf("%s is full (%d of %d members)", path, count, cc.MaxMembers)
+	}
+
+	capabilities := make([]CreateCapability, len(cc.Capabilities))
+	for i, c := range cc.Capabilities {
+		capabilities[i].Capability = c
+		if capabilities[i].Fields, err = CapabilityFields(v, c); err != nil {
+			return nil, err
+		}
+	}
+	return capabilities, nil
+}
+
+// CapabilityFields fetches the capabilities object of a use case and
+// returns the fields it marks as settable on create
+func CapabilityFields(v Reader, c Capability) ([]CreateField, error) {
+	if c.CapabilitiesObject == "" {
+		return nil, nil
+	}
+	object, err := v.Get(c.CapabilitiesObject)
+	if err != nil {
+		return nil, err
+	}
+	return createFields(object.RawJSON, ""), nil
+}
+
+// MemberCount returns the number of members of a collection, as the
+// service counts them when it says
+func MemberCount(collection *Resource) int {
+	if count, err := jsonparser.GetInt(collection.RawJSON, "Members@odata.count"); err == nil {
+		return int(count)
+	}
+	count := 0
+	jsonparser.ArrayEach(collection.RawJSON, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		count++
+	}, "Members")
+	return count
+}
+
+// CheckFields verifies that values give every required field and only
+// allowable values
+func CheckFields(fields []CreateField, values map[string]string) error {
+	var missing []string
+	for _, f := range fields {
+		val, ok := values[f.Name]
+		if !ok {
+			if f.Required {
+				missing = append(missing, f.Name)
+			}
+			continue
+		}
+		if len(f.Allowable) > 0 && !slices.Contains(f.Allowable, val) {
+			return fmt.Errorf("invalid value %q for %s (allowed: %s)", val, f.Name, strings.Join(f.Allowable, ", "))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// createFields collects the fields a capabilities object marks as required
+// or optional on create, recursing into nested objects
+func createFields(data []byte, prefix string) []CreateField {
+	var fields []CreateField
+	jsonparser.ObjectEach(data, func(key []byte, value []byte, dataType jsonparser.ValueType, offset int) error {
+		k := string(key)
+		name, annotation, annotated := strings.Cut(k, "@")
+		switch {
+		case !annotated && dataType == jsonparser.Object:
+			fields = append(fields, createFields(value, prefix+k+".")...)
+		case annotated && name != "" && (annotation == "Redfish.RequiredOnCreate" || annotation == "Redfish.OptionalOnCreate"):
+			if on, _ := jsonparser.GetBoolean(value); !on {
+				return nil
+			}
+			f := CreateField{Name: prefix + name, Required: annotation == "Redfish.RequiredOnCreate"}
+			jsonparser.ArrayEach(data, func(v []byte, t jsonparser.ValueType, _ int, _ error) {
+				if t == jsonparser.String {
+					f.Allowable = append(f.Allowable, string(v))
+				}
+			}, name+"@Redfish.AllowableValues")
+			fields = append(fields, f)
+		}
+		return nil
+	})
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		if fields[i].Required != fields[j].Required {
+			return fields[i].Required
+		}
+		return fields[i].Name < fields[j].Name
+	})
+	return fields
+}
+
+// CreateBody builds the JSON body of a create request from field values.
+// Dotted names nest ("Links.ClassOfService"); values that parse as JSON
+// (numbers, booleans, null, arrays, objects, quoted strings) keep their
+// type, anything else is a string.
+func CreateBody(values map[string]string) ([]byte, error) {
+	body := make(map[string]any)
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		parts := strings.Split(name, ".")
+		obj := body
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := obj[part].(map[string]any)
+			if !ok {
+				if _, taken := obj[part]; taken {
+					return nil, fmt.Errorf("field %s conflicts with %s", name, part)
+				}
+				next = make(map[string]any)
+				obj[part] = next
+			}
+			obj = next
+		}
+		obj[parts[len(parts)-1]] = fieldValue(values[name])
+	}
+	return json.MarshalIndent(body, "", "  ")
+}
+
+// fieldValue converts an entered value to its JSON type
+func fieldValue(s string) any {
+	var v any
+	if json.Unmarshal([]byte(s), &v) == nil {
+		return v
+	}
+	return s
+}
+
+// CreatedPath returns the path of the resource a create request made: the
+// Location header, or the @odata.id of the returned representation
+func CreatedPath(resp *Response) string {
+	if resp.Location != "" {
+		return resp.Location
+	}
+	if id, err := jsonparser.GetString(resp.Body, "@odata.id"); err == nil {
+		return id
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package rvfs
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// CollectDiagnosticDataAction is the action of a LogService that has the
+// service gather diagnostic data into a new log entry
+const CollectDiagnosticDataAction = "#LogService.CollectDiagnosticData"
+
+// DiagnosticCollection is where a CollectDiagnosticData action left its
+// result
+type DiagnosticCollection struct {
+	Task     *TaskStatus // Final status of the task, nil when none was spawned
+	Entry    string      // Path of the LogEntry holding the data
+	Download string      // URI of the data, from the entry's AdditionalDataURI
+}
+
+// CollectDiagnosticData invokes CollectDiagnosticData on a log service with
+// body, waits for the task it spawns, polling every interval, and finds the
+// entry the data went into and the URI to download the data from. The entry
+// is the one the task names in its Location header; a task that names none
+// leaves the entry that is new to the service's Entries.
+func CollectDiagnosticData(v VFS, logService string, body []byte, interval time.Duration, progress func(*TaskStatus)) (*DiagnosticCollection, error) {
+	service, err := v.Get(logService)
+	if err != nil {
+		return nil, err
+	}
+	target := ""
+	if actions, ok := service.Properties["Actions"]; ok && actions.Type == PropertyObject {
+		if action, ok := actions.Children[CollectDiagnosticDataAction]; ok && action.Type == PropertyObject {
+			if t, ok := action.Children["target"]; ok && t.Type == PropertyLink {
+				target = t.LinkTarget
+			}
+		}
+	}
+	if target == "" {
+		return nil, fmt.Errorf("%s has no CollectDiagnosticData action", service.Path)
+	}
+	entriesLink, ok := service.Children["Entries"]
+	if !ok {
+		return nil, fmt.Errorf("%s has no Entries", service.Path)
+	}
+
+	// The entries there were before, so a new one can be told apart
+	before := make(map[string]bool)
+	v.Invalidate(entriesLink.Target)
+	if entries, err := v.Get(entriesLink.Target); err == nil {
+		for _, member := range entries.Children {
+			before[member.Target] = true
+		}
+	}
+
+	resp, err := v.Post(target, body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, &HTTPError{Path: target, StatusCode: resp.StatusCode, Messages: resp.Messages}
+	}
+
+	result := &DiagnosticCollection{}
+	if task := SpawnedTask(resp); task != "" {
+		if result.Task, err = WaitTask(v, task, interval, progress); err != nil {
+			return nil, err
+		}
+		result.Entry = result.Task.Location
+	} else {
+		result.Entry = CreatedPath(resp)
+	}
+	if result.Entry == "" {
+		if result.Entry, err = newestEntry(v, entriesLink.Target, before); err != nil {
+			return nil, err
+		}
+	}
+
+	v.Invalidate(result.Entry)
+	entry, err := v.Get(result.Entry)
+	if err != nil {
+		return nil, err
+	}
+	if result.Download = referencePath(entry.Properties["AdditionalDataURI"]); result.Download == "" {
+		return nil, fmt.Errorf("%s has no AdditionalDataURI to download", result.Entry)
+	}
+	return result, nil
+}
+
+// newestEntry returns the member of a log entry collection not in before,
+// the one Created last when there are several
+func newestEntry(v VFS, entries string, before map[string]bool) (string, error) {
+	v.Invalidate(entries)
+	collection, err := v.Get(entries)
+	if err != nil {
+		return "", err
+	}
+	newest, created := "", ""
+	for _, member := range collection.Children {
+		if before[member.Target] {
+			continue
+		}
+		entry, err := v.Get(member.Target)
+		if err != nil {
+			return "", err
+		}
+		if at := stringProperty(entry, "Created"); newest == "" || at > created {
+			newest, created = member.Target, at
+		}
+	}
+	if newest == "" {
+		return "", fmt.Errorf("no new entry in %s", entries)
+	}
+	return newest, nil
+}
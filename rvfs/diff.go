@@ -0,0 +1,109 @@
+package rvfs
+
+import "sort"
+
+// DiffKind is how a property differs between two property trees
+type DiffKind int
+
+const (
+	DiffChanged DiffKind = iota // Present in both with different values
+	DiffAdded                   // Only in the second tree
+	DiffRemoved                 // Only in the first tree
+)
+
+// Difference is a property that differs between two property trees
+type Difference struct {
+	Property string // Path of the property: Status/Health, Members[2]
+	Kind     DiffKind
+	Old      *Property // nil when added
+	New      *Property // nil when removed
+}
+
+// DiffResources compares the properties and children of two resources.
+// Children are compared by target, as link properties.
+func DiffResources(old, new *Resource) []Difference {
+	return DiffProperties(linkedProperties(old), linkedProperties(new))
+}
+
+// linkedProperties returns the properties of a resource with its children
+// added as link properties
+func linkedProperties(r *Resource) map[string]*Property {
+	props := make(map[string]*Property, len(r.Properties)+len(r.Children))
+	for name, prop := range r.Properties {
+		props[name] = prop
+	}
+	for name, child := range r.Children {
+		if _, ok := props[name]; !ok {
+			props[name] = &Property{Name: name, Type: PropertyLink, LinkTarget: child.Target}
+		}
+	}
+	return props
+}
+
+// DiffProperties compares two property trees and returns what differs,
+// ordered by property path. Objects are compared member by member and
+// arrays element by element, so each difference is reported at the deepest
+// property it concerns; a property that changes type is reported whole.
+func DiffProperties(old, new map[string]*Property) []Difference {
+	var diffs []Difference
+	diffMembers(old, new, "", &diffs)
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Property < diffs[j].Property
+	})
+	return diffs
+}
+
+// diffMembers compares the members of two objects at path
+func diffMembers(old, new map[string]*Property, path string, diffs *[]Difference) {
+	join := func(name string) string {
+		if path == "" {
+			return name
+		}
+		return path + "/" + name
+	}
+	for name, o := range old {
+		if n, ok := new[name]; ok {
+			diffProperty(o, n, join(name), diffs)
+		} else {
+			*diffs = append(*diffs, Difference{Property: join(name), Kind: DiffRemoved, Old: o})
+		}
+	}
+	for name, n := range new {
+		if _, ok := old[name]; !ok {
+			*diffs = append(*diffs, Difference{Property: join(name), Kind: DiffAdded, New: n})
+		}
+	}
+}
+
+// diffProperty compares two properties found at path
+func diffProperty(o, n *Property, path string, diffs *[]Difference) {
+	if o.Type != n.Type {
+		*diffs = append(*diffs, Difference{Property: path, Kind: DiffChanged, Old: o, New: n})
+		return
+	}
+	switch o.Type {
+	case PropertySimple:
+		if o.Value != n.Value {
+			*diffs = append(*diffs, Difference{Property: path, Kind: DiffChanged, Old: o, New: n})
+		}
+	case PropertyLink:
+		if o.LinkTarget != n.LinkTarget {
+			*diffs = append(*diffs, Difference{Property: path, Kind: DiffChanged, Old: o, New: n})
+		}
+	case PropertyObject:
+		diffMembers(o.Children, n.Children, path, diffs)
+	case PropertyArray:
+		for i := range max(len(o.Elements), len(n.Elements)) {
+			switch {
+			case i >= len(n.Elements):
+				elem := o.Elements[i]
+				*diffs = append(*diffs, Difference{Property: path + elem.Name, Kind: DiffRemoved, Old: elem})
+			case i >= len(o.Elements):
+				elem := n.Elements[i]
+				*diffs = append(*diffs, Difference{Property: path + elem.Name, Kind: DiffAdded, New: elem})
+			default:
+				diffProperty(o.Elements[i], n.Elements[i], path+n.Elements[i].Name, diffs)
+			}
+		}
+	}
+}
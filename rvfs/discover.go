@@ -0,0 +1,110 @@
+package rvfs
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// redfishSearchTarget is the SSDP search target Redfish services answer
+// (DSP0266, Discovery); responses carry a minor version suffix
+const redfishSearchTarget = "urn:dmtf-org:service:redfish-rest:1"
+
+// ssdpAddress is the SSDP multicast group and port
+const ssdpAddress = "239.255.255.250:1900"
+
+// DiscoveredService is a Redfish service that answered an SSDP search
+type DiscoveredService struct {
+	Endpoint    string // scheme://host[:port], as a config's endpoint
+	ServiceRoot string // URL of the service root, from the AL header
+	UUID        string // Service UUID, from the USN header
+}
+
+// Discover searches the local network for Redfish services with SSDP and
+// returns those that answer within timeout, ordered by endpoint. Services
+// only answer when SSDP is enabled in their ManagerNetworkProtocol.
+func Discover(timeout time.Duration) ([]DiscoveredService, error) {
+	return discover(ssdpAddress, timeout)
+}
+
+// discover sends an M-SEARCH to addr and collects the answers until timeout
+func discover(addr string, timeout time.Duration) ([]DiscoveredService, error) {
+	dst, err := net.ResolveUDPAddr("udp4", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// MX asks services to spread their answers over up to that many seconds
+	mx := int(timeout / time.Second)
+	mx = max(1, min(mx, 5))
+	search := fmt.Sprintf("M-SEARCH * HTTP/1.1\r\nHOST: %s\r\nMAN: \"ssdp:discover\"\r\nMX: %d\r\nST: %s\r\n\r\n",
+		ssdpAddress, mx, redfishSearchTarget)
+	if _, err := conn.WriteTo([]byte(search), dst); err != nil {
+		return nil, fmt.Errorf("SSDP search: %w", err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	seen := make(map[DiscoveredService]bool)
+	var services []DiscoveredService
+	buf := make([]byte, 8192)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("SSDP search: %w", err)
+		}
+		if svc, ok := parseSSDPResponse(buf[:n]); ok && !seen[svc] {
+			seen[svc] = true
+			services = append(services, svc)
+		}
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Endpoint < services[j].Endpoint
+	})
+	return services, nil
+}
+
+// parseSSDPResponse reads the answer of a Redfish service to an M-SEARCH.
+// Answers for other search targets, or without a service root, are
+// rejected.
+func parseSSDPResponse(data []byte) (DiscoveredService, bool) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), nil)
+	if err != nil {
+		return DiscoveredService{}, false
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("ST"), redfishSearchTarget) {
+		return DiscoveredService{}, false
+	}
+
+	root, err := url.Parse(resp.Header.Get("AL"))
+	if err != nil || root.Scheme == "" || root.Host == "" {
+		return DiscoveredService{}, false
+	}
+
+	// USN: uuid:<UUID>::urn:dmtf-org:service:redfish-rest:1:<minor>
+	uuid, _, _ := strings.Cut(strings.TrimPrefix(resp.Header.Get("USN"), "uuid:"), "::")
+	return DiscoveredService{
+		Endpoint:    root.Scheme + "://" + root.Host,
+		ServiceRoot: root.String(),
+		UUID:        uuid,
+	}, true
+}
@@ -0,0 +1,65 @@
+package rvfs
+
+import (
+	"fmt"
+	"io"
+)
+
+// ProgressFunc is told how far a download has come: the bytes written so
+// far and the length the service announced, -1 when it announced none
+type ProgressFunc func(written, total int64)
+
+// progressWriter counts the bytes written through it and reports them.
+// A failed write is kept, so it is not mistaken for a network failure.
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	total    int64
+	progress ProgressFunc
+	err      error
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	if err != nil {
+		p.err = err
+		return n, err
+	}
+	if p.progress != nil {
+		p.progress(p.written, p.total)
+	}
+	return n, nil
+}
+
+// DownloadPath returns the path to download for target, relative to base.
+// A property holding a URI, such as a log entry's AdditionalDataURI, gives
+// the URI it holds; anything else is downloaded at its own path. Only the
+// resource holding target is fetched: target itself may be a large binary
+// payload, which must not go through the JSON parser.
+func DownloadPath(v VFS, base, target string) (string, error) {
+	full := v.Join(base, target)
+	holder, err := v.ResolveTarget(v.Parent(full), "")
+	if err != nil {
+		return full, nil
+	}
+
+	name := BaseName(full)
+	var prop *Property
+	switch holder.Type {
+	case TargetResource, TargetLink:
+		prop = holder.Resource.Properties[name]
+	case TargetProperty:
+		if holder.Property.Type == PropertyObject {
+			prop = holder.Property.Children[name]
+		}
+	}
+	if prop == nil {
+		return full, nil
+	}
+	uri := referencePath(prop)
+	if uri == "" {
+		return "", fmt.Errorf("%s holds no URI to download", target)
+	}
+	return uri, nil
+}
@@ -0,0 +1,31 @@
+package rvfs
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFile writes data to path like os.WriteFile, but to a sibling renamed
+// over it once complete, so a reader never sees a partial file and
+// concurrent writes do not interleave. The file gets perm whether or not
+// it existed.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,78 @@
+package rvfs
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Match is a property found by name or value
+type Match struct {
+	Resource string    // Path of the resource holding the property
+	Property string    // Path of the property within the resource: Status/Health
+	Value    *Property // The matching property
+}
+
+// FindProperties returns the properties of a resource, at any depth, whose
+// names match re, ordered by property path
+func FindProperties(r *Resource, re *regexp.Regexp) []Match {
+	return collectProperties(r, func(p *Property) bool {
+		return re.MatchString(p.Name)
+	})
+}
+
+// GrepProperties returns the simple properties of a resource, at any depth,
+// whose values contain text, ignoring case, ordered by property path
+func GrepProperties(r *Resource, text string) []Match {
+	lower := strings.ToLower(text)
+	return collectProperties(r, func(p *Property) bool {
+		return p.Type == PropertySimple && strings.Contains(strings.ToLower(valueText(p.Value)), lower)
+	})
+}
+
+// collectProperties walks the properties of a resource and returns those
+// match accepts, ordered by property path
+func collectProperties(r *Resource, match func(*Property) bool) []Match {
+	var matches []Match
+	for _, prop := range r.Properties {
+		collectProperty(r.Path, prop, prop.Name, match, &matches)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Property < matches[j].Property
+	})
+	return matches
+}
+
+// collectProperty collects prop, known as fullPath, and its descendants
+func collectProperty(resource string, prop *Property, fullPath string, match func(*Property) bool, matches *[]Match) {
+	if match(prop) {
+		*matches = append(*matches, Match{Resource: resource, Property: fullPath, Value: prop})
+	}
+	switch prop.Type {
+	case PropertyObject:
+		for _, child := range prop.Children {
+			collectProperty(resource, child, fullPath+"/"+child.Name, match, matches)
+		}
+	case PropertyArray:
+		for _, elem := range prop.Elements {
+			collectProperty(resource, elem, fullPath+elem.Name, match, matches)
+		}
+	}
+}
+
+// valueText renders a simple value as it is searched
+func valueText(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,61 @@
+package rvfs
+
+import (
+	"fmt"
+
+	"github.com/buger/jsonparser"
+)
+
+// SoftwareComponent is a SoftwareInventory resource: a firmware image a
+// component runs and whether it can be updated
+type SoftwareComponent struct {
+	Path       string
+	ID         string
+	Name       string
+	Version    string
+	Updateable bool
+	State      string   // Status.State
+	Health     string   // Status.Health
+	Related    []string // Paths of the RelatedItem resources the firmware runs on
+}
+
+// ReadFirmwareInventory reads every member of the FirmwareInventory the
+// UpdateService links, in collection order. Members that cannot be read
+// are skipped.
+func ReadFirmwareInventory(v VFS) ([]*SoftwareComponent, error) {
+	root, err := v.Get(v.Root())
+	if err != nil {
+		return nil, err
+	}
+	link, ok := root.Children["UpdateService"]
+	if !ok {
+		return nil, fmt.Errorf("service has no UpdateService")
+	}
+	service, err := v.Get(link.Target)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := service.Children["FirmwareInventory"]; !ok {
+		return nil, fmt.Errorf("%s has no FirmwareInventory", service.Path)
+	}
+
+	var inventory []*SoftwareComponent
+	for _, path := range linkedMembers(v, service.RawJSON, "FirmwareInventory") {
+		res, err := v.Get(path)
+		if err != nil {
+			continue
+		}
+		c := &SoftwareComponent{
+			Path:    res.Path,
+			ID:      stringProperty(res, "Id"),
+			Name:    stringProperty(res, "Name"),
+			Version: stringProperty(res, "Version"),
+			Related: linkTargets(res.RawJSON, "RelatedItem"),
+		}
+		c.Updateable, _ = jsonparser.GetBoolean(res.RawJSON, "Updateable")
+		c.State, _ = jsonparser.GetString(res.RawJSON, "Status", "State")
+		c.Health, _ = jsonparser.GetString(res.RawJSON, "Status", "Health")
+		inventory = append(inventory, c)
+	}
+	return inventory, nil
+}
@@ -0,0 +1,98 @@
+package rvfs
+
+import (
+	"net/http"
+	"slices"
+)
+
+// History keeps the writes a session made, so the PATCHes among them can
+// be undone: each remembers the values it replaced. Actions and creates are
+// kept too, to be named when an undo reaches them, as neither can be
+// reversed by a PATCH.
+type History struct {
+	writes []Write // Oldest first
+}
+
+// Write is a request that changed the service
+type Write struct {
+	Method  string   // PATCH, or POST for actions and creates
+	Path    string   // Resource patched, or the target posted to
+	Changes []Change // Properties a PATCH changed, from the value each had
+}
+
+// historyLimit is how many writes a History keeps; older ones are forgotten
+const historyLimit = 100
+
+// Record adds the requests of ApplyPlan that succeeded
+func (h *History) Record(results []ApplyResult) {
+	for _, r := range results {
+		if r.Err == nil {
+			h.add(Write{Method: r.Method, Path: r.Path, Changes: r.Changes})
+		}
+	}
+}
+
+// RecordPatch adds a PATCH of path that succeeded, made outside a plan
+func (h *History) RecordPatch(path string, changes []Change) {
+	h.add(Write{Method: http.MethodPatch, Path: path, Changes: changes})
+}
+
+// RecordPost adds a POST to path, an action or a create, when it succeeded
+func (h *History) RecordPost(path string, resp *Response, err error) {
+	if err == nil && resp != nil && resp.StatusCode < http.StatusMultipleChoices {
+		h.add(Write{Method: http.MethodPost, Path: path})
+	}
+}
+
+// Len returns the number of writes kept
+func (h *History) Len() int {
+	return len(h.writes)
+}
+
+// Undo returns the plan that reverses the last n writes: PATCHes setting
+// each property they changed back to the value it had before the first
+// of them. The writes no PATCH reverses, most recent first, are returned
+// apart.
+func (h *History) Undo(n int) (*Plan, []Write) {
+	plan := &Plan{}
+	var irreversible []Write
+	for _, w := range slices.Backward(h.writes[len(h.writes)-min(n, len(h.writes)):]) {
+		if w.Method != http.MethodPatch {
+			irreversible = append(irreversible, w)
+			continue
+		}
+		for _, c := range w.Changes {
+			// Walking back in time, an earlier write of a property sets the
+			// value to return to; the live value stays the latest one written
+			i := slices.IndexFunc(plan.Changes, func(u Change) bool {
+				return u.Resource == c.Resource && slices.Equal(u.Property, c.Property)
+			})
+			if i >= 0 {
+				plan.Changes[i].New = c.Old
+				continue
+			}
+			plan.Changes = append(plan.Changes, Change{Resource: c.Resource, Property: c.Property, Old: c.New, New: c.Old, Method: http.MethodPatch})
+		}
+	}
+	return plan, irreversible
+}
+
+// Undone forgets the last n writes once the plan of Undo was sent, keeping
+// those on resources whose reversing PATCH failed, to be undone again
+func (h *History) Undone(n int, results []ApplyResult) {
+	start := len(h.writes) - min(n, len(h.writes))
+	kept := slices.DeleteFunc(slices.Clone(h.writes[start:]), func(w Write) bool {
+		return !slices.ContainsFunc(results, func(r ApplyResult) bool {
+			return r.Err != nil && w.Method == http.MethodPatch && r.Path == w.Path
+		})
+	})
+	h.writes = append(h.writes[:start], kept...)
+}
+
+// add keeps a write, forgetting the oldest past historyLimit
+func (h *History) add(w Write) {
+	h.writes = append(h.writes, w)
+	if len(h.writes) > historyLimit {
+		h.writes = slices.Delete(h.writes, 0, len(h.writes)-historyLimit)
+	}
+}
@@ -0,0 +1,157 @@
+package rvfs
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// searchIndex maps property names and value tokens to the cached resources
+// holding them, so a search over the cache only visits resources that can
+// match. Keys are cache paths.
+type searchIndex struct {
+	names   map[string]map[string]bool // Property name → paths
+	tokens  map[string]map[string]bool // Lowercase value token → paths
+	entries map[string]indexEntry      // Path → the keys it is filed under
+}
+
+// indexEntry records the keys a resource was indexed under, for removal
+type indexEntry struct {
+	names  []string
+	tokens []string
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		names:   make(map[string]map[string]bool),
+		tokens:  make(map[string]map[string]bool),
+		entries: make(map[string]indexEntry),
+	}
+}
+
+// add indexes the resource cached at path, replacing what was indexed there
+func (x *searchIndex) add(path string, r *Resource) {
+	x.remove(path)
+
+	names := make(map[string]bool)
+	tokens := make(map[string]bool)
+	for _, prop := range r.Properties {
+		indexProperty(prop, names, tokens)
+	}
+
+	var entry indexEntry
+	for name := range names {
+		fileUnder(x.names, name, path)
+		entry.names = append(entry.names, name)
+	}
+	for token := range tokens {
+		fileUnder(x.tokens, token, path)
+		entry.tokens = append(entry.tokens, token)
+	}
+	x.entries[path] = entry
+}
+
+// remove drops the resource cached at path from the index
+func (x *searchIndex) remove(path string) {
+	entry, ok := x.entries[path]
+	if !ok {
+		return
+	}
+	for _, name := range entry.names {
+		unfile(x.names, name, path)
+	}
+	for _, token := range entry.tokens {
+		unfile(x.tokens, token, path)
+	}
+	delete(x.entries, path)
+}
+
+// matchNames returns the paths of the resources with a property, at any
+// depth, whose name matches re
+func (x *searchIndex) matchNames(re *regexp.Regexp) map[string]bool {
+	paths := make(map[string]bool)
+	for name, holders := range x.names {
+		if re.MatchString(name) {
+			for p := range holders {
+				paths[p] = true
+			}
+		}
+	}
+	return paths
+}
+
+// matchText returns the paths of the resources whose values could contain
+// text: for every token of text, some value token contains it. Text without
+// tokens cannot narrow the search and yields every path.
+func (x *searchIndex) matchText(text string) map[string]bool {
+	var paths map[string]bool
+	for _, query := range tokenize(text) {
+		holders := make(map[string]bool)
+		for token, tokenPaths := range x.tokens {
+			if !strings.Contains(token, query) {
+				continue
+			}
+			for p := range tokenPaths {
+				if paths == nil || paths[p] {
+					holders[p] = true
+				}
+			}
+		}
+		paths = holders
+		if len(paths) == 0 {
+			return paths
+		}
+	}
+	if paths == nil {
+		paths = make(map[string]bool, len(x.entries))
+		for p := range x.entries {
+			paths[p] = true
+		}
+	}
+	return paths
+}
+
+// indexProperty collects the names and value tokens of a property and its
+// descendants
+func indexProperty(prop *Property, names, tokens map[string]bool) {
+	names[prop.Name] = true
+	switch prop.Type {
+	case PropertySimple:
+		for _, token := range tokenize(valueText(prop.Value)) {
+			tokens[token] = true
+		}
+	case PropertyObject:
+		for _, child := range prop.Children {
+			indexProperty(child, names, tokens)
+		}
+	case PropertyArray:
+		for _, elem := range prop.Elements {
+			indexProperty(elem, names, tokens)
+		}
+	}
+}
+
+// tokenize splits text into lowercase runs of letters and digits
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// fileUnder adds path to the set of paths held under key
+func fileUnder(index map[string]map[string]bool, key, path string) {
+	paths, ok := index[key]
+	if !ok {
+		paths = make(map[string]bool)
+		index[key] = paths
+	}
+	paths[path] = true
+}
+
+// unfile removes path from the set held under key, dropping emptied keys
+func unfile(index map[string]map[string]bool, key, path string) {
+	delete(index[key], path)
+	if len(index[key]) == 0 {
+		delete(index, key)
+	}
+}
@@ -0,0 +1,94 @@
+package rvfs
+
+import "github.com/buger/jsonparser"
+
+// OriginOfCondition returns the path of the resource a log entry, event
+// record or condition refers to, or "" when the target carries no such
+// reference. The reference comes in several shapes: a link object, which
+// the parser turns into a child at the top level of a resource and into a
+// link property inside objects; a bare path string in older Event schemas;
+// and either of these nested under Links, as in LogEntry.
+func OriginOfCondition(t *Target) string {
+	switch t.Type {
+	case TargetResource:
+		if t.Resource == nil {
+			return ""
+		}
+		if child, ok := t.Resource.Children["OriginOfCondition"]; ok {
+			return child.Target
+		}
+		return originIn(t.Resource.Properties)
+	case TargetProperty:
+		if t.Property == nil || t.Property.Type != PropertyObject {
+			return ""
+		}
+		return originIn(t.Property.Children)
+	}
+	return ""
+}
+
+// originIn finds OriginOfCondition among properties or under their Links
+func originIn(props map[string]*Property) string {
+	if origin := referencePath(props["OriginOfCondition"]); origin != "" {
+		return origin
+	}
+	if links, ok := props["Links"]; ok && links.Type == PropertyObject {
+		return referencePath(links.Children["OriginOfCondition"])
+	}
+	return ""
+}
+
+// referencePath returns the path a reference property points to: the target
+// of a link, or a string value holding a Redfish path
+func referencePath(p *Property) string {
+	if p == nil {
+		return ""
+	}
+	switch p.Type {
+	case PropertyLink:
+		return p.LinkTarget
+	case PropertySimple:
+		if s, ok := p.Value.(string); ok && versionRoot(s) != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// linkTargets returns the paths the value at keys links: the @odata.id of a
+// link object, or of each link in an array of them
+func linkTargets(data []byte, keys ...string) []string {
+	value, dataType, _, err := jsonparser.Get(data, keys...)
+	if err != nil {
+		return nil
+	}
+	switch dataType {
+	case jsonparser.Object:
+		if id, err := jsonparser.GetString(value, "@odata.id"); err == nil && id != "" {
+			return []string{normalizePath(id)}
+		}
+	case jsonparser.Array:
+		var targets []string
+		jsonparser.ArrayEach(value, func(link []byte, _ jsonparser.ValueType, _ int, _ error) {
+			if id, err := jsonparser.GetString(link, "@odata.id"); err == nil && id != "" {
+				targets = append(targets, normalizePath(id))
+			}
+		})
+		return targets
+	}
+	return nil
+}
+
+// linkedMembers returns the paths a resource links as name: the members of
+// the collection it links, or the links of an array, as older schemas have
+func linkedMembers(v Reader, data []byte, name string) []string {
+	targets := linkTargets(data, name)
+	if _, dataType, _, _ := jsonparser.Get(data, name); dataType != jsonparser.Object || len(targets) != 1 {
+		return targets
+	}
+	collection, err := v.Get(targets[0])
+	if err != nil {
+		return nil
+	}
+	return linkTargets(collection.RawJSON, "Members")
+}
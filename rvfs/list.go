@@ -0,0 +1,150 @@
+package rvfs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/buger/jsonparser"
+)
+
+// EntryKind selects which entries a listing includes
+type EntryKind int
+
+const (
+	KindAll        EntryKind = iota
+	KindChildren             // Child resources
+	KindProperties           // Values, objects and arrays
+	KindLinks                // Links to resources outside the hierarchy
+)
+
+// ListSort selects the order of a listing
+type ListSort int
+
+const (
+	SortByName ListSort = iota // Listing order (entries arrive sorted by name)
+	SortByType                 // Children, links, objects, arrays, values
+	SortBySize                 // Largest JSON first
+)
+
+// ListOptions controls how a listing is filtered and ordered
+type ListOptions struct {
+	Kind EntryKind
+	Sort ListSort
+	All  bool // Include @odata annotations
+}
+
+// ParseListArgs splits ls arguments into listing options and a target path:
+// ls [-t|-S] [-a] [children|props|links] [path]
+func ParseListArgs(args []string) (ListOptions, string, error) {
+	var opts ListOptions
+	var rest []string
+	for _, arg := range args {
+		switch {
+		case len(arg) > 1 && arg[0] == '-':
+			for _, flag := range arg[1:] {
+				switch flag {
+				case 't':
+					opts.Sort = SortByType
+				case 'S':
+					opts.Sort = SortBySize
+				case 'a':
+					opts.All = true
+				default:
+					return opts, "", fmt.Errorf("ls: unknown flag -%c", flag)
+				}
+			}
+		case arg == "children":
+			opts.Kind = KindChildren
+		case arg == "props":
+			opts.Kind = KindProperties
+		case arg == "links":
+			opts.Kind = KindLinks
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return opts, TargetArg(rest), nil
+}
+
+// Arrange filters and orders entries for display. Without All, entries
+// carrying @odata annotations (e.g. Members@odata.count) are hidden.
+func Arrange(entries []*Entry, opts ListOptions) []*Entry {
+	arranged := make([]*Entry, 0, len(entries))
+	for _, entry := range entries {
+		if !opts.All && isAnnotation(entry.Name) {
+			continue
+		}
+		if opts.Kind != KindAll && kindOf(entry.Type) != opts.Kind {
+			continue
+		}
+		arranged = append(arranged, entry)
+	}
+
+	switch opts.Sort {
+	case SortByType:
+		sort.SliceStable(arranged, func(i, j int) bool {
+			return typeRank(arranged[i].Type) < typeRank(arranged[j].Type)
+		})
+	case SortBySize:
+		sort.SliceStable(arranged, func(i, j int) bool {
+			return arranged[i].Size > arranged[j].Size
+		})
+	}
+	return arranged
+}
+
+// AnnotationEntries returns the resource's top-level @odata annotations
+// (@odata.id, @odata.type, ...), which the parser keeps out of Properties
+func AnnotationEntries(r *Resource) []*Entry {
+	var entries []*Entry
+	jsonparser.ObjectEach(r.RawJSON, func(key []byte, value []byte, dataType jsonparser.ValueType, offset int) error {
+		if k := string(key); strings.HasPrefix(k, "@odata.") {
+			entries = append(entries, &Entry{
+				Name:     k,
+				Path:     r.Path + "/" + k,
+				Type:     EntryProperty,
+				Size:     int64(len(value)),
+				Modified: r.FetchedAt,
+			})
+		}
+		return nil
+	})
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// isAnnotation returns true for @odata annotation names
+func isAnnotation(name string) bool {
+	return strings.Contains(name, "@odata.")
+}
+
+// kindOf returns the listing kind of an entry type
+func kindOf(t EntryType) EntryKind {
+	switch t {
+	case EntryResource, EntryLink:
+		return KindChildren
+	case EntrySymlink:
+		return KindLinks
+	default:
+		return KindProperties
+	}
+}
+
+// typeRank orders entry types for SortByType
+func typeRank(t EntryType) int {
+	switch t {
+	case EntryResource, EntryLink:
+		return 0
+	case EntrySymlink:
+		return 1
+	case EntryComplex:
+		return 2
+	case EntryArray:
+		return 3
+	default:
+		return 4
+	}
+}
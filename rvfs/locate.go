@@ -0,0 +1,71 @@
+package rvfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// LocationIndicator is the property that lights a resource's locator LED:
+// LocationIndicatorActive, or IndicatorLED on resources that predate it
+type LocationIndicator struct {
+	Property string // LocationIndicatorActive or IndicatorLED
+	On       bool   // Whether the locator is lit or blinking now
+}
+
+// FindLocationIndicator returns the locator property of a resource, such as
+// a ComputerSystem, Chassis or Drive; ok is false when it has none
+func FindLocationIndicator(res *Resource) (indicator LocationIndicator, ok bool) {
+	if p, ok := res.Properties["LocationIndicatorActive"]; ok && p.Type == PropertySimple {
+		on, _ := p.Value.(bool)
+		return LocationIndicator{Property: "LocationIndicatorActive", On: on}, true
+	}
+	if p, ok := res.Properties["IndicatorLED"]; ok && p.Type == PropertySimple {
+		state, _ := p.Value.(string)
+		return LocationIndicator{Property: "IndicatorLED", On: state == "Lit" || state == "Blinking"}, true
+	}
+	return LocationIndicator{}, false
+}
+
+// SetLocationIndicator turns the locator of the resource at path on or off
+// with a PATCH of its LocationIndicatorActive, or of IndicatorLED, which is
+// set Blinking or Off. It returns the change made, from the value the
+// property had, for History to undo; a status the service refuses the PATCH
+// with is an HTTPError.
+func SetLocationIndicator(v VFS, path string, on bool) (Change, error) {
+	res, err := v.Get(path)
+	if err != nil {
+		return Change{}, err
+	}
+	indicator, ok := FindLocationIndicator(res)
+	if !ok {
+		return Change{}, fmt.Errorf("%s has no LocationIndicatorActive or IndicatorLED", res.Path)
+	}
+
+	var value any = on
+	if indicator.Property == "IndicatorLED" {
+		value = "Off"
+		if on {
+			value = "Blinking"
+		}
+	}
+	change := Change{
+		Resource: res.Path,
+		Property: []string{indicator.Property},
+		Old:      res.Properties[indicator.Property].Value,
+		New:      value,
+		Method:   http.MethodPatch,
+	}
+	body, err := json.Marshal(map[string]any{indicator.Property: value})
+	if err != nil {
+		return Change{}, err
+	}
+	resp, err := v.Patch(res.Path, body)
+	if err != nil {
+		return Change{}, err
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return Change{}, &HTTPError{Path: res.Path, StatusCode: resp.StatusCode, Messages: resp.Messages}
+	}
+	return change, nil
+}
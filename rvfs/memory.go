@@ -0,0 +1,96 @@
+package rvfs
+
+import (
+	"fmt"
+
+	"github.com/buger/jsonparser"
+)
+
+// MemoryModule is a Memory resource: a DIMM or other memory device, or the
+// empty slot for one
+type MemoryModule struct {
+	Path         string
+	Slot         string // Location ServiceLabel, else DeviceLocator, else Id
+	CapacityMiB  int64
+	SpeedMHz     int64  // OperatingSpeedMhz
+	Type         string // MemoryDeviceType: DDR4, DDR5, ...
+	Manufacturer string
+	PartNumber   string
+	SerialNumber string
+	State        string // Status.State; Absent for an empty slot
+	Health       string // Status.Health
+}
+
+// Populated reports whether a module is fitted in the slot
+func (m *MemoryModule) Populated() bool {
+	return m.State != "Absent"
+}
+
+// Failed reports whether a fitted module is unhealthy or taken offline
+func (m *MemoryModule) Failed() bool {
+	return m.Populated() && (severityRank(m.Health) > severityRank("OK") || m.State == "UnavailableOffline")
+}
+
+// SystemMemory is the memory of a system: its MemorySummary and each
+// module of its Memory collection
+type SystemMemory struct {
+	System        string
+	TotalGiB      float64 // MemorySummary.TotalSystemMemoryGiB, 0 when not reported
+	PersistentGiB float64 // TotalSystemPersistentMemoryGiB
+	Health        string  // MemorySummary Status.HealthRollup, else Health
+	Modules       []*MemoryModule
+}
+
+// ReadMemory reads the memory of the systems under base: every system for
+// the service root, else the system at base. Modules are in collection
+// order; those that cannot be read are skipped.
+func ReadMemory(v VFS, base string) ([]*SystemMemory, error) {
+	systems, chassis, err := hostsUnder(v, base)
+	if err != nil {
+		return nil, err
+	}
+	if len(chassis) > 0 && base != v.Root() {
+		return nil, fmt.Errorf("%s is not a system", chassis[0].Path)
+	}
+
+	var memory []*SystemMemory
+	for _, system := range systems {
+		m := &SystemMemory{System: system.Path}
+		m.TotalGiB, _ = jsonparser.GetFloat(system.RawJSON, "MemorySummary", "TotalSystemMemoryGiB")
+		m.PersistentGiB, _ = jsonparser.GetFloat(system.RawJSON, "MemorySummary", "TotalSystemPersistentMemoryGiB")
+		m.Health, _ = jsonparser.GetString(system.RawJSON, "MemorySummary", "Status", "HealthRollup")
+		if m.Health == "" {
+			m.Health, _ = jsonparser.GetString(system.RawJSON, "MemorySummary", "Status", "Health")
+		}
+		for _, path := range linkedMembers(v, system.RawJSON, "Memory") {
+			if res, err := v.Get(path); err == nil {
+				m.Modules = append(m.Modules, parseMemoryModule(res))
+			}
+		}
+		memory = append(memory, m)
+	}
+	return memory, nil
+}
+
+// parseMemoryModule reads a Memory resource
+func parseMemoryModule(res *Resource) *MemoryModule {
+	m := &MemoryModule{
+		Path:         res.Path,
+		Type:         stringProperty(res, "MemoryDeviceType"),
+		Manufacturer: stringProperty(res, "Manufacturer"),
+		PartNumber:   stringProperty(res, "PartNumber"),
+		SerialNumber: stringProperty(res, "SerialNumber"),
+	}
+	m.CapacityMiB, _ = jsonparser.GetInt(res.RawJSON, "CapacityMiB")
+	m.SpeedMHz, _ = jsonparser.GetInt(res.RawJSON, "OperatingSpeedMhz")
+	m.State, _ = jsonparser.GetString(res.RawJSON, "Status", "State")
+	m.Health, _ = jsonparser.GetString(res.RawJSON, "Status", "Health")
+	m.Slot = slotLabel(res.RawJSON)
+	if m.Slot == "" {
+		m.Slot = stringProperty(res, "DeviceLocator")
+	}
+	if m.Slot == "" {
+		m.Slot = stringProperty(res, "Id")
+	}
+	return m
+}
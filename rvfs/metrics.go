@@ -0,0 +1,411 @@
+package rvfs
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buger/jsonparser"
+)
+
+// MetricDefinition is what a MetricReportDefinition says a report holds and
+// when it is generated
+type MetricDefinition struct {
+	Path     string
+	ID       string
+	Type     string   // MetricReportDefinitionType: Periodic, OnChange, OnRequest
+	Interval string   // Schedule.RecurrenceInterval of a periodic report, an ISO 8601 duration
+	Metrics  []string // MetricId of each metric, or its first property when it has none
+	Report   string   // Path of the MetricReport it generates, "" when not linked
+}
+
+// Telemetry is what the TelemetryService holds: its report definitions and
+// the reports generated so far
+type Telemetry struct {
+	Service     string
+	Definitions []*MetricDefinition
+	Reports     []*MetricReport // Read in full, so their readings can be counted
+}
+
+// MetricReading is one value of a MetricReport
+type MetricReading struct {
+	MetricID  string
+	Property  string // MetricProperty: the URI of the property read, with a JSON pointer
+	Value     string // MetricValue, as the service reports it
+	Timestamp string
+}
+
+// MetricReport is a MetricReport resource: the readings a definition
+// collected
+type MetricReport struct {
+	Path       string
+	ID         string
+	Definition string // Path of the MetricReportDefinition
+	Timestamp  string // When the report was generated
+	Readings   []MetricReading
+}
+
+// MetricSeries is the readings of one metric of a report, in report order
+type MetricSeries struct {
+	MetricID string
+	Property string
+	Readings []MetricReading
+}
+
+// ReadTelemetry reads the TelemetryService the service root links: every
+// MetricReportDefinition and every MetricReport. Members that cannot be
+// read are skipped.
+func ReadTelemetry(v VFS) (*Telemetry, error) {
+	service, err := telemetryService(v)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Telemetry{Service: service.Path}
+	_, definitions := collectionMembers(v, service, "MetricReportDefinitions", true)
+	for _, res := range definitions {
+		t.Definitions = append(t.Definitions, parseMetricDefinition(res))
+	}
+	_, reports := collectionMembers(v, service, "MetricReports", true)
+	for _, res := range reports {
+		t.Reports = append(t.Reports, parseMetricReport(res))
+	}
+	return t, nil
+}
+
+// MetricReportPath returns the path of the report named id in the
+// TelemetryService's MetricReports
+func MetricReportPath(v VFS, id string) (string, error) {
+	path, err := telemetryCollection(v, "MetricReports")
+	if err != nil {
+		return "", err
+	}
+	reports, err := v.Get(path)
+	if err != nil {
+		return "", err
+	}
+	member, ok := reports.Children[id]
+	if !ok {
+		return "", &NotFoundError{Path: v.Join(reports.Path, id)}
+	}
+	return member.Target, nil
+}
+
+// telemetryService reads the TelemetryService the service root links
+func telemetryService(v VFS) (*Resource, error) {
+	root, err := v.Get(v.Root())
+	if err != nil {
+		return nil, err
+	}
+	link, ok := root.Children["TelemetryService"]
+	if !ok {
+		return nil, fmt.Errorf("service has no TelemetryService")
+	}
+	return v.Get(link.Target)
+}
+
+// telemetryCollection returns the path of a collection the TelemetryService
+// links: MetricReports, MetricReportDefinitions or MetricDefinitions
+func telemetryCollection(v VFS, name string) (string, error) {
+	service, err := telemetryService(v)
+	if err != nil {
+		return "", err
+	}
+	link, ok := service.Children[name]
+	if !ok {
+		return "", fmt.Errorf("%s has no %s", service.Path, name)
+	}
+	return link.Target, nil
+}
+
+// ReadMetricReport reads the MetricReport at path
+func ReadMetricReport(v VFS, path string) (*MetricReport, error) {
+	res, err := v.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, dataType, _, err := jsonparser.Get(res.RawJSON, "MetricValues"); err != nil || dataType != jsonparser.Array {
+		return nil, fmt.Errorf("%s is not a metric report", res.Path)
+	}
+	return parseMetricReport(res), nil
+}
+
+// parseMetricDefinition reads a MetricReportDefinition
+func parseMetricDefinition(res *Resource) *MetricDefinition {
+	d := &MetricDefinition{
+		Path: res.Path,
+		ID:   stringProperty(res, "Id"),
+		Type: stringProperty(res, "MetricReportDefinitionType"),
+	}
+	d.Interval, _ = jsonparser.GetString(res.RawJSON, "Schedule", "RecurrenceInterval")
+	jsonparser.ArrayEach(res.RawJSON, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		name, _ := jsonparser.GetString(value, "MetricId")
+		if name == "" {
+			if properties := parseStringArray(value, "MetricProperties"); len(properties) > 0 {
+				name = properties[0]
+			}
+		}
+		d.Metrics = append(d.Metrics, name)
+	}, "Metrics")
+	if id, err := jsonparser.GetString(res.RawJSON, "MetricReport", "@odata.id"); err == nil {
+		d.Report = normalizePath(id)
+	}
+	return d
+}
+
+// parseMetricReport reads a MetricReport. Values are strings in the schema;
+// numbers some services send instead are kept in their JSON form.
+func parseMetricReport(res *Resource) *MetricReport {
+	r := &MetricReport{
+		Path:      res.Path,
+		ID:        stringProperty(res, "Id"),
+		Timestamp: stringProperty(res, "Timestamp"),
+	}
+	if id, err := jsonparser.GetString(res.RawJSON, "MetricReportDefinition", "@odata.id"); err == nil {
+		r.Definition = normalizePath(id)
+	}
+	jsonparser.ArrayEach(res.RawJSON, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		reading := MetricReading{}
+		reading.MetricID, _ = jsonparser.GetString(value, "MetricId")
+		reading.Property, _ = jsonparser.GetString(value, "MetricProperty")
+		reading.Timestamp, _ = jsonparser.GetString(value, "Timestamp")
+		if raw, valueType, _, err := jsonparser.Get(value, "MetricValue"); err == nil && valueType != jsonparser.Null {
+			reading.Value = string(raw)
+		}
+		r.Readings = append(r.Readings, reading)
+	}, "MetricValues")
+	return r
+}
+
+// Series groups the readings of a report by metric: its MetricId and
+// MetricProperty. Series are in the order their first reading appears.
+func (r *MetricReport) Series() []*MetricSeries {
+	var series []*MetricSeries
+	index := make(map[[2]string]*MetricSeries)
+	for _, reading := range r.Readings {
+		key := [2]string{reading.MetricID, reading.Property}
+		s, ok := index[key]
+		if !ok {
+			s = &MetricSeries{MetricID: reading.MetricID, Property: reading.Property}
+			index[key] = s
+			series = append(series, s)
+		}
+		s.Readings = append(s.Readings, reading)
+	}
+	return series
+}
+
+// Name is the MetricId of the series, or its property when it has none
+func (s *MetricSeries) Name() string {
+	if s.MetricID != "" {
+		return s.MetricID
+	}
+	return s.Property
+}
+
+// Values returns the readings that are numbers, in order
+func (s *MetricSeries) Values() []float64 {
+	var values []float64
+	for _, reading := range s.Readings {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(reading.Value), 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+			values = append(values, f)
+		}
+	}
+	return values
+}
+
+// sparkBlocks are the bars of a sparkline, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws values as a row of bars scaled between their minimum and
+// maximum; a flat series is drawn at the lowest bar. At most width values,
+// the latest, are drawn.
+func Sparkline(values []float64, width int) string {
+	if width > 0 && len(values) > width {
+		values = values[len(values)-width:]
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	bars := make([]rune, len(values))
+	for i, v := range values {
+		level := 0
+		if hi > lo {
+			level = int((v - lo) / (hi - lo) * float64(len(sparkBlocks)-1))
+		}
+		bars[i] = sparkBlocks[level]
+	}
+	return string(bars)
+}
+
+// WriteMetricCSV writes the readings of a report as CSV, one row per
+// reading under a header: Timestamp,MetricId,MetricProperty,MetricValue
+func WriteMetricCSV(w io.Writer, r *MetricReport) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"Timestamp", "MetricId", "MetricProperty", "MetricValue"})
+	for _, reading := range r.Readings {
+		out.Write([]string{reading.Timestamp, reading.MetricID, reading.Property, reading.Value})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// Metric is a MetricDefinition: a metric the service can put in a report
+type Metric struct {
+	Path       string
+	ID         string
+	Units      string
+	Properties []string // MetricProperties it reads, possibly with {Wildcard} segments
+}
+
+// MetricReportTypes are the values of MetricReportDefinitionType
+var MetricReportTypes = []string{"Periodic", "OnChange", "OnRequest"}
+
+// MetricReportActions are the values of ReportActions
+var MetricReportActions = []string{"LogToMetricReportsCollection", "RedfishEvent"}
+
+// MetricCatalog reads the metrics the TelemetryService defines in its
+// MetricDefinitions, sorted by Id. Members that cannot be read are skipped.
+func MetricCatalog(v VFS) ([]*Metric, error) {
+	service, err := telemetryService(v)
+	if err != nil {
+		return nil, err
+	}
+	count, members := collectionMembers(v, service, "MetricDefinitions", true)
+	if count < 0 {
+		return nil, fmt.Errorf("%s has no MetricDefinitions", service.Path)
+	}
+	metrics := make([]*Metric, 0, len(members))
+	for _, res := range members {
+		metrics = append(metrics, &Metric{
+			Path:       res.Path,
+			ID:         stringProperty(res, "Id"),
+			Units:      stringProperty(res, "Units"),
+			Properties: parseStringArray(res.RawJSON, "MetricProperties"),
+		})
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].ID < metrics[j].ID })
+	return metrics, nil
+}
+
+// MatchMetrics returns the metrics of a catalog named name, ignoring case,
+// or else those whose Id starts with it
+func MatchMetrics(catalog []*Metric, name string) []*Metric {
+	for _, m := range catalog {
+		if strings.EqualFold(m.ID, name) {
+			return []*Metric{m}
+		}
+	}
+	var matches []*Metric
+	for _, m := range catalog {
+		if strings.HasPrefix(strings.ToLower(m.ID), strings.ToLower(name)) {
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// MetricReportDefinitionsPath returns the path of the collection new
+// MetricReportDefinitions are POSTed to
+func MetricReportDefinitionsPath(v VFS) (string, error) {
+	return telemetryCollection(v, "MetricReportDefinitions")
+}
+
+// ReportDefinitionSpec is a MetricReportDefinition to create
+type ReportDefinitionSpec struct {
+	ID         string
+	Type       string   // One of MetricReportTypes
+	Interval   string   // ISO 8601 RecurrenceInterval; required for Periodic
+	Actions    []string // Of MetricReportActions
+	Metrics    []*Metric
+	Properties []string // MetricProperties given directly, not from the catalog
+}
+
+// Body checks the spec and builds the JSON body that creates it. Each
+// catalog metric becomes a Metrics entry with its MetricId and properties;
+// properties given directly share one entry.
+func (s *ReportDefinitionSpec) Body() ([]byte, error) {
+	if s.ID == "" {
+		return nil, fmt.Errorf("a report definition needs an Id")
+	}
+	if !slices.Contains(MetricReportTypes, s.Type) {
+		return nil, fmt.Errorf("invalid type %q (allowed: %s)", s.Type, strings.Join(MetricReportTypes, ", "))
+	}
+	if s.Type == "Periodic" && s.Interval == "" {
+		return nil, fmt.Errorf("a periodic report needs an interval")
+	}
+	for _, action := range s.Actions {
+		if !slices.Contains(MetricReportActions, action) {
+			return nil, fmt.Errorf("invalid report action %q (allowed: %s)", action, strings.Join(MetricReportActions, ", "))
+		}
+	}
+	if len(s.Metrics) == 0 && len(s.Properties) == 0 {
+		return nil, fmt.Errorf("a report definition needs at least one metric")
+	}
+
+	var metrics []map[string]any
+	for _, m := range s.Metrics {
+		entry := map[string]any{"MetricId": m.ID}
+		if len(m.Properties) > 0 {
+			entry["MetricProperties"] = m.Properties
+		}
+		metrics = append(metrics, entry)
+	}
+	if len(s.Properties) > 0 {
+		metrics = append(metrics, map[string]any{"MetricProperties": s.Properties})
+	}
+	body := map[string]any{
+		"Id":                         s.ID,
+		"MetricReportDefinitionType": s.Type,
+		"Metrics":                    metrics,
+	}
+	if len(s.Actions) > 0 {
+		body["ReportActions"] = s.Actions
+	}
+	if s.Interval != "" {
+		body["Schedule"] = map[string]any{"RecurrenceInterval": s.Interval}
+	}
+	return json.MarshalIndent(body, "", "  ")
+}
+
+// isoDurationPattern matches the ISO 8601 durations Redfish uses
+var isoDurationPattern = regexp.MustCompile(`^P(\d+D)?(T(\d+H)?(\d+M)?(\d+(\.\d+)?S)?)?$`)
+
+// ISODuration turns an interval into the ISO 8601 duration Redfish takes:
+// a Go duration such as 30s or 1h30m is converted, an ISO one such as PT30S
+// is kept
+func ISODuration(interval string) (string, error) {
+	if upper := strings.ToUpper(interval); isoDurationPattern.MatchString(upper) && upper != "P" && upper != "PT" {
+		return upper, nil
+	}
+	d, err := time.ParseDuration(interval)
+	if err != nil || d <= 0 {
+		return "", fmt.Errorf("invalid interval %q (e.g. 30s, 5m or PT30S)", interval)
+	}
+	var b strings.Builder
+	b.WriteString("PT")
+	if h := int64(d / time.Hour); h > 0 {
+		fmt.Fprintf(&b, "%dH", h)
+		d -= time.Duration(h) * time.Hour
+	}
+	if m := int64(d / time.Minute); m > 0 {
+		fmt.Fprintf(&b, "%dM", m)
+		d -= time.Duration(m) * time.Minute
+	}
+	if d > 0 {
+		b.WriteString(strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "S")
+	}
+	return b.String(), nil
+}
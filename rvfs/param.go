@@ -0,0 +1,30 @@
+package rvfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ParseParameter parses an action parameter argument, typed the way httpie
+// types request items: name=value is always the string value, and
+// name:=json a raw JSON value for numbers, booleans, null, arrays and
+// objects. ResetType=On, Version=1.10, Count:=3, Enabled:=true. Numbers
+// are kept as written, so Version:=1.10 is sent as 1.10.
+func ParseParameter(arg string) (name string, value any, err error) {
+	name, raw, ok := strings.Cut(arg, "=")
+	if !ok || name == "" || name == ":" {
+		return "", nil, fmt.Errorf("invalid argument %q (expected key=value or key:=json)", arg)
+	}
+	name, typed := strings.CutSuffix(name, ":")
+	if !typed {
+		return name, raw, nil
+	}
+
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil || strings.TrimSpace(raw[dec.InputOffset():]) != "" {
+		return "", nil, fmt.Errorf("invalid JSON for %s: %q", name, raw)
+	}
+	return name, value, nil
+}
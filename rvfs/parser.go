@@ -1,25 +1,74 @@
 package rvfs
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/buger/jsonparser"
 )
 
+// odataPrefix starts the names of the OData metadata of an object
+var odataPrefix = []byte("@odata.")
+
+// errStopEach ends a jsonparser.ObjectEach early once the answer is known
+var errStopEach = errors.New("stop")
+
 // Parser extracts structure from Redfish JSON
-type Parser struct{}
+type Parser struct {
+	uriProperties     map[string]bool
+	notURIProperties  map[string]bool
+	uriHeuristic      bool
+	linkArrayChildren bool
+	quirks            QuirkSet
+}
+
+// ParserOptions overrides how properties are classified as links and children.
+// Some vendor payloads defeat the Uri/URI name convention: strings that are
+// not resource paths get classified as links, or real references are missed.
+type ParserOptions struct {
+	// URIProperties are property names always treated as URI references
+	URIProperties []string `yaml:"uri_properties"`
+	// NotURIProperties are property names never treated as URI references;
+	// they take precedence over every other rule
+	NotURIProperties []string `yaml:"not_uri_properties"`
+	// DisableURIHeuristic turns off the Uri/URI name-suffix convention
+	DisableURIHeuristic bool `yaml:"disable_uri_heuristic"`
+	// LinkArrayChildren promotes every top-level array of @odata.id links
+	// (OEM collections such as "Entries" or "Devices") into children, like
+	// Members. Arrays whose entry names collide with existing children stay
+	// properties.
+	LinkArrayChildren bool `yaml:"link_array_children"`
+}
 
 // NewParser creates a new parser
-func NewParser() *Parser {
-	return &Parser{}
+func NewParser(opts ParserOptions) *Parser {
+	p := &Parser{
+		uriProperties:     make(map[string]bool),
+		notURIProperties:  make(map[string]bool),
+		uriHeuristic:      !opts.DisableURIHeuristic,
+		linkArrayChildren: opts.LinkArrayChildren,
+	}
+	for _, name := range opts.URIProperties {
+		p.uriProperties[name] = true
+	}
+	for _, name := range opts.NotURIProperties {
+		p.notURIProperties[name] = true
+	}
+	return p
 }
 
-// Parse converts raw JSON into a Resource structure
-func (p *Parser) Parse(path string, data []byte) (*Resource, error) {
+// Parse converts raw JSON into a Resource structure.
+// A path carrying OData query options ($select, $top, ...) yields a partial
+// resource whose Query records the options it was fetched with.
+func (p *Parser) Parse(fullPath string, data []byte) (*Resource, error) {
+	path, query := splitQuery(normalizePath(fullPath))
 	resource := &Resource{
-		Path:       normalizePath(path),
+		Path:       path,
+		Query:      query,
 		RawJSON:    data,
 		Properties: make(map[string]*Property),
 		Children:   make(map[string]*Child),
@@ -27,21 +76,40 @@ func (p *Parser) Parse(path string, data []byte) (*Resource, error) {
 	}
 
 	// Extract @odata.id and @odata.type
+	// Without @odata.id the resource is known by the path it was fetched from
 	if odataID, err := jsonparser.GetString(data, "@odata.id"); err == nil {
 		resource.ODataID = odataID
+	} else {
+		resource.ODataID = path
+		resource.Warnings = append(resource.Warnings, "no @odata.id; using the request path")
 	}
 	if odataType, err := jsonparser.GetString(data, "@odata.type"); err == nil {
 		resource.ODataType = odataType
 	}
+	// Link arrays other than Members, promoted once all other children are known
+	type linkArray struct {
+		name  string
+		value []byte
+	}
+	var linkArrays []linkArray
 
 	// Parse properties and children
 	err := jsonparser.ObjectEach(data, func(key []byte, value []byte, dataType jsonparser.ValueType, offset int) error {
-		k := string(key)
-
 		// Skip @odata.* metadata
-		if strings.HasPrefix(k, "@odata.") {
+		if bytes.HasPrefix(key, odataPrefix) {
 			return nil
 		}
+		k := string(key)
+
+		// Messages, conditions and capabilities are read on the same pass,
+		// and their properties kept
+		resource.Messages = append(resource.Messages, messagesOf(k, value, dataType)...)
+		switch {
+		case k == "Status" && dataType == jsonparser.Object:
+			resource.Conditions = parseConditions(value)
+		case k == "@Redfish.CollectionCapabilities" && dataType == jsonparser.Object:
+			resource.Capabilities = parseCollectionCapabilities(value, path)
+		}
 
 		// Check if it's a child resource (object with ONLY @odata properties)
 		if dataType == jsonparser.Object && p.isLinkOnly(value) {
@@ -62,6 +130,11 @@ func (p *Parser) Parse(path string, data []byte) (*Resource, error) {
 			return nil
 		}
 
+		if p.linkArrayChildren && dataType == jsonparser.Array && p.isLinkArray(value) {
+			linkArrays = append(linkArrays, linkArray{name: k, value: value})
+			return nil
+		}
+
 		// Everything else is a property (parse recursively)
 		prop := p.parseProperty(k, value, dataType)
 		resource.Properties[k] = prop
@@ -70,12 +143,38 @@ func (p *Parser) Parse(path string, data []byte) (*Resource, error) {
 	})
 
 	if err != nil {
-		return nil, &ParseError{Path: path, Err: err}
+		return nil, &ParseError{Path: fullPath, Err: err}
+	}
+
+	for _, la := range linkArrays {
+		promoted := make(map[string]*Child)
+		p.extractLinkArrayChildren(la.value, path, promoted)
+		if p.collides(promoted, resource.Children) {
+			resource.Properties[la.name] = p.parseProperty(la.name, la.value, jsonparser.Array)
+			continue
+		}
+		for name, child := range promoted {
+			resource.Children[name] = child
+		}
+	}
+
+	if actions, ok := resource.Properties["Actions"]; ok && actions.Type == PropertyObject && p.quirks[QuirkUndecoratedActions] {
+		decorateActions(actions)
 	}
 
 	return resource, nil
 }
 
+// collides reports whether any promoted child name is already taken
+func (p *Parser) collides(promoted, children map[string]*Child) bool {
+	for name := range promoted {
+		if _, ok := children[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 // parseProperty recursively parses a property into a tree structure
 func (p *Parser) parseProperty(name string, value []byte, dataType jsonparser.ValueType) *Property {
 	prop := &Property{
@@ -97,15 +196,14 @@ func (p *Parser) parseProperty(name string, value []byte, dataType jsonparser.Va
 		prop.Children = make(map[string]*Property)
 
 		jsonparser.ObjectEach(value, func(childKey, childValue []byte, childType jsonparser.ValueType, offset int) error {
-			k := string(childKey)
-
 			// Skip OData metadata fields only (@odata.*)
 			// Keep Redfish annotations (@Redfish.*), Message annotations (@Message.*), etc.
-			if strings.HasPrefix(k, "@odata.") {
+			if bytes.HasPrefix(childKey, odataPrefix) {
 				return nil
 			}
 
 			// Recursive call
+			k := string(childKey)
 			childProp := p.parseProperty(k, childValue, childType)
 			prop.Children[k] = childProp
 			return nil
@@ -118,7 +216,7 @@ func (p *Parser) parseProperty(name string, value []byte, dataType jsonparser.Va
 
 		idx := 0
 		jsonparser.ArrayEach(value, func(elemValue []byte, elemType jsonparser.ValueType, offset int, err error) {
-			elemProp := p.parseProperty(fmt.Sprintf("[%d]", idx), elemValue, elemType)
+			elemProp := p.parseProperty("["+strconv.Itoa(idx)+"]", elemValue, elemType)
 			prop.Elements = append(prop.Elements, elemProp)
 			idx++
 		})
@@ -130,6 +228,7 @@ func (p *Parser) parseProperty(name string, value []byte, dataType jsonparser.Va
 			if strings.HasPrefix(linkTarget, "/") {
 				prop.Type = PropertyLink
 				prop.LinkTarget = linkTarget
+				prop.URIString = true
 				return prop
 			}
 		}
@@ -146,12 +245,146 @@ func (p *Parser) parseProperty(name string, value []byte, dataType jsonparser.Va
 	return prop
 }
 
+// parseMessages collects @Message.ExtendedInfo entries from a response body:
+// the top-level annotation, the one inside an "error" object (along with the
+// error's own code/message), and property-level Prop@Message.ExtendedInfo
+// annotations, which get Prop as their related property when none is given.
+func parseMessages(data []byte) []Message {
+	if len(data) == 0 || data[0] != '{' {
+		return nil
+	}
+
+	var messages []Message
+	jsonparser.ObjectEach(data, func(key []byte, value []byte, dataType jsonparser.ValueType, offset int) error {
+		messages = append(messages, messagesOf(string(key), value, dataType)...)
+		return nil
+	})
+	return messages
+}
+
+// messagesOf returns the messages a member of a response body carries, by
+// its name k
+func messagesOf(k string, value []byte, dataType jsonparser.ValueType) []Message {
+	switch {
+	case k == "error" && dataType == jsonparser.Object:
+		var messages []Message
+		code, _ := jsonparser.GetString(value, "code")
+		text, _ := jsonparser.GetString(value, "message")
+		extended, extType, _, err := jsonparser.Get(value, "@Message.ExtendedInfo")
+		if err == nil && extType == jsonparser.Array {
+			messages = parseMessageArray(extended, "")
+		}
+		// The general error only adds information without extended details
+		if (err != nil || extType != jsonparser.Array) && (code != "" || text != "") {
+			messages = append(messages, Message{MessageID: code, Message: text})
+		}
+		return messages
+	case k == "@Message.ExtendedInfo" && dataType == jsonparser.Array:
+		return parseMessageArray(value, "")
+	case strings.HasSuffix(k, "@Message.ExtendedInfo") && dataType == jsonparser.Array:
+		return parseMessageArray(value, strings.TrimSuffix(k, "@Message.ExtendedInfo"))
+	}
+	return nil
+}
+
+// parseMessageArray parses an array of Message objects
+func parseMessageArray(data []byte, property string) []Message {
+	var messages []Message
+	jsonparser.ArrayEach(data, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		if dataType != jsonparser.Object {
+			return
+		}
+		m := parseMessage(value)
+		if len(m.RelatedProperties) == 0 && property != "" {
+			m.RelatedProperties = []string{property}
+		}
+		messages = append(messages, m)
+	})
+	return messages
+}
+
+// parseMessage parses the Message fields of an object
+func parseMessage(data []byte) Message {
+	var m Message
+	m.MessageID, _ = jsonparser.GetString(data, "MessageId")
+	m.Message, _ = jsonparser.GetString(data, "Message")
+	m.Resolution, _ = jsonparser.GetString(data, "Resolution")
+	if sev, err := jsonparser.GetString(data, "MessageSeverity"); err == nil {
+		m.Severity = sev
+	} else {
+		m.Severity, _ = jsonparser.GetString(data, "Severity")
+	}
+	m.MessageArgs = parseStringArray(data, "MessageArgs")
+	m.RelatedProperties = parseStringArray(data, "RelatedProperties")
+	return m
+}
+
+// parseConditions parses the Conditions array of a resource's Status
+func parseConditions(status []byte) []Condition {
+	var conditions []Condition
+	jsonparser.ArrayEach(status, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		if dataType != jsonparser.Object {
+			return
+		}
+		c := Condition{Message: parseMessage(value)}
+		c.Timestamp, _ = jsonparser.GetString(value, "Timestamp")
+		c.OriginOfCondition, _ = jsonparser.GetString(value, "OriginOfCondition", "@odata.id")
+		c.LogEntry, _ = jsonparser.GetString(value, "LogEntry", "@odata.id")
+		conditions = append(conditions, c)
+	}, "Conditions")
+	return conditions
+}
+
+// parseCollectionCapabilities reads a @Redfish.CollectionCapabilities
+// annotation; use cases without a target collection POST to the annotated
+// collection
+func parseCollectionCapabilities(annotation []byte, path string) *CollectionCapabilities {
+	cc := &CollectionCapabilities{}
+	if max, err := jsonparser.GetInt(annotation, "MaxMembers"); err == nil {
+		cc.MaxMembers = int(max)
+	}
+	jsonparser.ArrayEach(annotation, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		if dataType != jsonparser.Object {
+			return
+		}
+		c := Capability{TargetCollection: path}
+		c.UseCase, _ = jsonparser.GetString(value, "UseCase")
+		c.CapabilitiesObject, _ = jsonparser.GetString(value, "CapabilitiesObject", "@odata.id")
+		if target, err := jsonparser.GetString(value, "Links", "TargetCollection", "@odata.id"); err == nil {
+			c.TargetCollection = target
+		}
+		cc.Capabilities = append(cc.Capabilities, c)
+	}, "Capabilities")
+	return cc
+}
+
+// parseStringArray returns the string elements of an array member
+func parseStringArray(data []byte, keys ...string) []string {
+	var values []string
+	jsonparser.ArrayEach(data, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		if dataType == jsonparser.String {
+			s, _ := jsonparser.ParseString(value)
+			values = append(values, s)
+		} else {
+			values = append(values, string(value))
+		}
+	}, keys...)
+	return values
+}
+
 // isURIProperty checks if a property name indicates a URI reference per DMTF spec.
 // These string properties contain Redfish paths and should be treated as PropertyLinks.
+// The configured deny and allow lists are consulted first.
 func (p *Parser) isURIProperty(name string) bool {
+	if p.notURIProperties[name] {
+		return false
+	}
+	if p.uriProperties[name] {
+		return true
+	}
 	// DMTF spec: "Non-resource reference properties shall include the Uri or URI
 	// term in their property name and shall be of type string."
-	if strings.HasSuffix(name, "Uri") || strings.HasSuffix(name, "URI") {
+	if p.uriHeuristic && (strings.HasSuffix(name, "Uri") || strings.HasSuffix(name, "URI")) {
 		return true
 	}
 	// @Redfish.ActionInfo is always a URI string pointing to action info resource
@@ -162,29 +395,30 @@ func (p *Parser) isURIProperty(name string) bool {
 	if name == "target" {
 		return true
 	}
+	// Paging link of a partial collection (e.g. Members@odata.nextLink)
+	if strings.HasSuffix(name, "@odata.nextLink") {
+		return true
+	}
 	return false
 }
 
 // isLinkOnly checks if JSON object contains ONLY OData metadata (no actual data)
 // A link-only object has @odata.id and optionally other @odata.* fields, but no data properties
 func (p *Parser) isLinkOnly(data []byte) bool {
-	// Must have @odata.id
-	if odataID, _ := jsonparser.GetString(data, "@odata.id"); odataID == "" {
-		return false
-	}
-
-	// All keys must be OData metadata (@odata.*)
-	// If we find any non-@odata.* key (including @Redfish.*, regular properties, etc.), it has data
-	hasData := false
-	jsonparser.ObjectEach(data, func(key []byte, value []byte, dataType jsonparser.ValueType, offset int) error {
-		k := string(key)
-		if !strings.HasPrefix(k, "@odata.") {
-			hasData = true
+	// All keys must be OData metadata (@odata.*), and one a non-empty
+	// @odata.id. The first other key (@Redfish.*, regular properties, etc.)
+	// means the object has data, which ends the scan.
+	hasID := false
+	err := jsonparser.ObjectEach(data, func(key []byte, value []byte, dataType jsonparser.ValueType, offset int) error {
+		if !bytes.HasPrefix(key, odataPrefix) {
+			return errStopEach
+		}
+		if string(key) == "@odata.id" && dataType == jsonparser.String && len(value) > 0 {
+			hasID = true
 		}
 		return nil
 	})
-
-	return !hasData
+	return err == nil && hasID
 }
 
 // isLinkArray checks if JSON array contains only OData links
@@ -193,12 +427,19 @@ func (p *Parser) isLinkArray(data []byte) bool {
 		return false
 	}
 
+	// An expanded collection is told by its first member, without walking
+	// the rest
+	first, dataType, _, err := jsonparser.Get(bytes.TrimLeft(data[1:], " \t\r\n"))
+	if err != nil || dataType != jsonparser.Object || !p.isLinkOnly(first) {
+		return false
+	}
+
 	allLinks := true
 	count := 0
 
 	jsonparser.ArrayEach(data, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
 		count++
-		if dataType != jsonparser.Object || !p.isLinkOnly(value) {
+		if allLinks && (dataType != jsonparser.Object || !p.isLinkOnly(value)) {
 			allLinks = false
 		}
 	})
@@ -262,10 +503,10 @@ func (p *Parser) parseValue(value []byte, dataType jsonparser.ValueType) interfa
 		s, _ := jsonparser.ParseString(value)
 		return s
 	case jsonparser.Number:
-		f, _ := jsonparser.GetFloat(value)
+		f, _ := jsonparser.ParseFloat(value)
 		return f
 	case jsonparser.Boolean:
-		b, _ := jsonparser.GetBoolean(value)
+		b, _ := jsonparser.ParseBoolean(value)
 		return b
 	case jsonparser.Null:
 		return nil
@@ -277,13 +518,75 @@ func (p *Parser) parseValue(value []byte, dataType jsonparser.ValueType) interfa
 	}
 }
 
-// normalizePath ensures path starts with / and has no trailing /
+// normalizePath ensures path starts with / and has no trailing /.
+// OData query options are carried through unchanged.
 func normalizePath(path string) string {
+	path, query := splitQuery(path)
 	if path == "" {
-		return "/redfish/v1"
+		return withQuery("/", query)
 	}
 	if path[0] != '/' {
 		path = "/" + path
 	}
-	return strings.TrimRight(path, "/")
+	return withQuery(strings.TrimRight(path, "/"), query)
+}
+
+// splitQuery separates OData query options from a path:
+// /redfish/v1/Systems?$top=2 → (/redfish/v1/Systems, $top=2)
+func splitQuery(path string) (string, string) {
+	if idx := strings.IndexByte(path, '?'); idx != -1 {
+		return path[:idx], path[idx+1:]
+	}
+	return path, ""
+}
+
+// fragmentPath rewrites a link target carrying a JSON pointer fragment into
+// the equivalent property path, numeric tokens becoming array indexes:
+// /redfish/v1/Chassis/1/Thermal#/Temperatures/0 → /redfish/v1/Chassis/1/Thermal/Temperatures[0]
+func fragmentPath(target string) string {
+	base, pointer, ok := strings.Cut(target, "#")
+	if !ok {
+		return target
+	}
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(base, "/"))
+	for _, token := range strings.Split(pointer, "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		if _, err := strconv.Atoi(token); err == nil {
+			b.WriteString("[" + token + "]")
+			continue
+		}
+		b.WriteString("/" + token)
+	}
+	return b.String()
+}
+
+// withQuery appends OData query options to a path
+func withQuery(path, query string) string {
+	if query == "" {
+		return path
+	}
+	return path + "?" + query
+}
+
+// encodeQuery percent-encodes a query string for the wire, keeping the
+// characters OData expressions rely on ($, ',', quotes, parentheses) readable.
+// Existing %XX escapes are passed through so pre-encoded input still works.
+func encodeQuery(query string) string {
+	var b strings.Builder
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+			b.WriteByte(c)
+		case strings.IndexByte("-._~$&=,'()*/:@!;%", c) != -1:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
 }
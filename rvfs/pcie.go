@@ -0,0 +1,281 @@
+package rvfs
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/buger/jsonparser"
+)
+
+// PCIeLink is the generation and width of a PCIe link: Gen4 x16
+type PCIeLink struct {
+	Type  string // PCIeType: Gen1 to Gen6, "" when not reported
+	Lanes int    // 0 when not reported
+}
+
+func (l PCIeLink) String() string {
+	switch {
+	case l.Type == "" && l.Lanes == 0:
+		return ""
+	case l.Lanes == 0:
+		return l.Type
+	case l.Type == "":
+		return "x" + strconv.Itoa(l.Lanes)
+	}
+	return l.Type + " x" + strconv.Itoa(l.Lanes)
+}
+
+// PCIeAssociation is a resource a PCIe device or function belongs to or
+// serves, such as the Processor it is or the EthernetInterface it backs
+type PCIeAssociation struct {
+	Kind string // Resource type: Processor, NetworkAdapter, StorageController, Drive, ...
+	Path string
+}
+
+// PCIeFunction is a function of a PCIe device and what it is associated with
+type PCIeFunction struct {
+	Path         string
+	ID           string // FunctionId
+	Type         string // FunctionType: Physical or Virtual
+	Class        string // DeviceClass: NetworkController, MassStorageController, ...
+	VendorID     string
+	DeviceID     string
+	Associations []PCIeAssociation
+}
+
+// PCIeDevice is a PCIe device with its slot, link and functions
+type PCIeDevice struct {
+	Path         string
+	Name         string
+	Manufacturer string
+	Model        string
+	Firmware     string   // FirmwareVersion
+	DeviceType   string   // SingleFunction, MultiFunction, Simulated, Retimer
+	Slot         string   // Service label of the slot, from the device or its chassis' PCIeSlots
+	SlotType     string   // FullLength, HalfLength, M2, OEM, ...
+	Link         PCIeLink // Negotiated: PCIeType and LanesInUse
+	MaxLink      PCIeLink // What the device supports: MaxPCIeType and MaxLanes
+	Functions    []*PCIeFunction
+	// Associations are the resources that link the device itself rather
+	// than one of its functions
+	Associations []PCIeAssociation
+}
+
+// PCIeTopology gathers the PCIe devices of the systems and chassis under
+// base, the whole service when base is the service root, with their
+// functions. The links between them and Processors, NetworkAdapters,
+// Storage and PCIeSlots are followed both ways, so a device shows what it
+// serves whichever side reports the association. Devices are sorted by
+// path; resources that cannot be read are skipped.
+func PCIeTopology(v VFS, base string) ([]*PCIeDevice, error) {
+	systems, chassis, err := hostsUnder(v, base)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &pcieTopology{v: v, devices: make(map[string]*PCIeDevice), functions: make(map[string]*PCIeFunction)}
+	for _, host := range append(slices.Clone(systems), chassis...) {
+		for _, path := range linkedMembers(t.v, host.RawJSON, "PCIeDevices") {
+			t.addDevice(path)
+		}
+	}
+
+	// Then the reverse links: the resources that name a device or function
+	for _, system := range systems {
+		for _, path := range linkedMembers(t.v, system.RawJSON, "Processors") {
+			if res, err := v.Get(path); err == nil {
+				t.associate("Processor", res.Path, linkTargets(res.RawJSON, "Links", "PCIeDevice"))
+				t.associate("Processor", res.Path, linkTargets(res.RawJSON, "Links", "PCIeFunctions"))
+			}
+		}
+		for _, path := range linkedMembers(t.v, system.RawJSON, "Storage") {
+			if res, err := v.Get(path); err == nil {
+				t.storage(res)
+			}
+		}
+	}
+	for _, c := range chassis {
+		for _, path := range linkedMembers(t.v, c.RawJSON, "NetworkAdapters") {
+			if res, err := v.Get(path); err == nil {
+				jsonparser.ArrayEach(res.RawJSON, func(controller []byte, _ jsonparser.ValueType, _ int, _ error) {
+					t.associate("NetworkAdapter", res.Path, linkTargets(controller, "Links", "PCIeDevices"))
+					t.associate("NetworkAdapter", res.Path, linkTargets(controller, "Links", "PCIeFunctions"))
+				}, "Controllers")
+			}
+		}
+		t.slots(c)
+	}
+
+	devices := make([]*PCIeDevice, 0, len(t.devices))
+	for path, d := range t.devices {
+		// Devices are filed under the path they were linked by as well
+		if path == d.Path {
+			devices = append(devices, d)
+		}
+	}
+	slices.SortFunc(devices, func(a, b *PCIeDevice) int { return strings.Compare(a.Path, b.Path) })
+	return devices, nil
+}
+
+// pcieTopology is the state PCIeTopology builds up
+type pcieTopology struct {
+	v         VFS
+	devices   map[string]*PCIeDevice
+	functions map[string]*PCIeFunction
+}
+
+// addDevice reads a device and its functions, once
+func (t *pcieTopology) addDevice(path string) {
+	if _, ok := t.devices[path]; ok {
+		return
+	}
+	res, err := t.v.Get(path)
+	if err != nil {
+		return
+	}
+	d := &PCIeDevice{
+		Path:         res.Path,
+		Name:         stringProperty(res, "Name"),
+		Manufacturer: stringProperty(res, "Manufacturer"),
+		Model:        stringProperty(res, "Model"),
+		Firmware:     stringProperty(res, "FirmwareVersion"),
+		DeviceType:   stringProperty(res, "DeviceType"),
+		Link:         pcieLink(res.RawJSON, "PCIeType", "LanesInUse"),
+		MaxLink:      pcieLink(res.RawJSON, "MaxPCIeType", "MaxLanes"),
+	}
+	d.Slot = slotLabel(res.RawJSON, "Slot")
+	d.SlotType, _ = jsonparser.GetString(res.RawJSON, "Slot", "SlotType")
+	t.devices[path] = d
+	if res.Path != path {
+		t.devices[res.Path] = d
+	}
+
+	// Functions are a collection since PCIeDevice v1.4, links before
+	functions := linkedMembers(t.v, res.RawJSON, "PCIeFunctions")
+	if len(functions) == 0 {
+		functions = linkTargets(res.RawJSON, "Links", "PCIeFunctions")
+	}
+	for _, path := range functions {
+		if f := t.function(path); f != nil {
+			d.Functions = append(d.Functions, f)
+		}
+	}
+}
+
+// function reads a PCIe function and the resources its Links name
+func (t *pcieTopology) function(path string) *PCIeFunction {
+	res, err := t.v.Get(path)
+	if err != nil {
+		return nil
+	}
+	f := &PCIeFunction{
+		Path:     res.Path,
+		Type:     stringProperty(res, "FunctionType"),
+		Class:    stringProperty(res, "DeviceClass"),
+		VendorID: stringProperty(res, "VendorId"),
+		DeviceID: stringProperty(res, "DeviceId"),
+	}
+	if raw, dataType, _, err := jsonparser.Get(res.RawJSON, "FunctionId"); err == nil && dataType != jsonparser.Null {
+		f.ID = string(raw)
+	}
+	jsonparser.ObjectEach(res.RawJSON, func(key, value []byte, _ jsonparser.ValueType, _ int) error {
+		name := string(key)
+		if name == "PCIeDevice" || name == "Oem" || strings.Contains(name, "@") {
+			return nil
+		}
+		kind := strings.TrimSuffix(name, "s")
+		for _, target := range linkTargets(value) {
+			f.Associations = appendAssociation(f.Associations, PCIeAssociation{Kind: kind, Path: target})
+		}
+		return nil
+	}, "Links")
+	t.functions[path] = f
+	t.functions[res.Path] = f
+	return f
+}
+
+// associate records that the resource at path of the given kind links the
+// devices or functions at targets
+func (t *pcieTopology) associate(kind, path string, targets []string) {
+	a := PCIeAssociation{Kind: kind, Path: path}
+	for _, target := range targets {
+		if f, ok := t.functions[target]; ok {
+			f.Associations = appendAssociation(f.Associations, a)
+		} else if d, ok := t.devices[target]; ok {
+			d.Associations = appendAssociation(d.Associations, a)
+		}
+	}
+}
+
+// storage associates a Storage resource with the functions its controllers
+// link, in the StorageControllers array or, since Storage v1.13, the
+// Controllers collection
+func (t *pcieTopology) storage(res *Resource) {
+	jsonparser.ArrayEach(res.RawJSON, func(controller []byte, _ jsonparser.ValueType, _ int, _ error) {
+		t.associate("Storage", res.Path, linkTargets(controller, "Links", "PCIeFunctions"))
+	}, "StorageControllers")
+	if _, dataType, _, _ := jsonparser.Get(res.RawJSON, "Controllers"); dataType != jsonparser.Object {
+		return
+	}
+	for _, path := range linkedMembers(t.v, res.RawJSON, "Controllers") {
+		if controller, err := t.v.Get(path); err == nil {
+			t.associate("StorageController", controller.Path, linkTargets(controller.RawJSON, "Links", "PCIeFunctions"))
+		}
+	}
+}
+
+// slots labels the devices of a chassis' PCIeSlots that do not name their
+// slot themselves
+func (t *pcieTopology) slots(chassis *Resource) {
+	targets := linkTargets(chassis.RawJSON, "PCIeSlots")
+	if len(targets) != 1 {
+		return
+	}
+	res, err := t.v.Get(targets[0])
+	if err != nil {
+		return
+	}
+	jsonparser.ArrayEach(res.RawJSON, func(slot []byte, _ jsonparser.ValueType, _ int, _ error) {
+		label := slotLabel(slot)
+		slotType, _ := jsonparser.GetString(slot, "SlotType")
+		for _, target := range linkTargets(slot, "Links", "PCIeDevice") {
+			if d, ok := t.devices[target]; ok && d.Slot == "" {
+				d.Slot = label
+				if d.SlotType == "" {
+					d.SlotType = slotType
+				}
+			}
+		}
+	}, "Slots")
+}
+
+// pcieLink reads a link from a device's PCIeInterface
+func pcieLink(data []byte, typeName, lanesName string) PCIeLink {
+	link := PCIeLink{}
+	link.Type, _ = jsonparser.GetString(data, "PCIeInterface", typeName)
+	lanes, _ := jsonparser.GetInt(data, "PCIeInterface", lanesName)
+	link.Lanes = int(lanes)
+	return link
+}
+
+// slotLabel returns the service label of the slot at keys, or "Slot N" from
+// its ordinal when it has no label
+func slotLabel(data []byte, keys ...string) string {
+	location := append(slices.Clone(keys), "Location", "PartLocation")
+	if label, _ := jsonparser.GetString(data, append(location, "ServiceLabel")...); label != "" {
+		return label
+	}
+	if ordinal, err := jsonparser.GetInt(data, append(location, "LocationOrdinalValue")...); err == nil {
+		return "Slot " + strconv.FormatInt(ordinal, 10)
+	}
+	return ""
+}
+
+// appendAssociation adds an association unless it is there already
+func appendAssociation(associations []PCIeAssociation, a PCIeAssociation) []PCIeAssociation {
+	if slices.Contains(associations, a) {
+		return associations
+	}
+	return append(associations, a)
+}
@@ -0,0 +1,138 @@
+package rvfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RepeatWindow is how long after an action is POSTed the same POST is held
+// back, as more likely a key pressed twice than meant
+const RepeatWindow = 5 * time.Second
+
+// RepeatError is an action POST held back as a repeat of one sent within
+// RepeatWindow
+type RepeatError struct {
+	Target string
+	Ago    time.Duration
+}
+
+func (e *RepeatError) Error() string {
+	return fmt.Sprintf("the same POST to %s was sent %.1fs ago", e.Target, e.Ago.Seconds())
+}
+
+// PostGuard holds back an action POSTed twice in quick succession with the
+// same body, such as a reset confirmed twice: frontends send actions
+// through it, and let the operator repeat one on purpose. It is safe for
+// concurrent use.
+type PostGuard struct {
+	mu   sync.Mutex
+	sent map[postKey]time.Time // When each POST was last sent
+	now  func() time.Time      // time.Now when nil
+}
+
+// postKey is a POST by target and body
+type postKey struct {
+	target string
+	body   string
+}
+
+// Check returns a *RepeatError when the same POST was sent within
+// RepeatWindow, nil otherwise. It sends nothing, so a frontend can refuse a
+// repeat before asking to confirm it; Post checks again as it sends.
+func (g *PostGuard) Check(target string, body []byte) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.repeat(postKey{target, string(body)}, g.clock())
+}
+
+// CheckAll is Check for the same body sent to every target, returning the
+// first repeat
+func (g *PostGuard) CheckAll(targets []string, body []byte) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.clock()
+	for _, target := range targets {
+		if err := g.repeat(postKey{target, string(body)}, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckPlan is Check for the actions of a plan, returning the first repeat
+func (g *PostGuard) CheckPlan(plan *Plan) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.clock()
+	for _, c := range plan.Changes {
+		if c.Method != http.MethodPost {
+			continue
+		}
+		body, err := json.Marshal(c.New)
+		if err != nil {
+			return err
+		}
+		if err := g.repeat(postKey{c.Target, string(body)}, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Post sends an action through v unless the same POST was sent within
+// RepeatWindow, when it returns a *RepeatError; again sends it regardless.
+// The check and the record are one step, so of two callers sending the
+// same POST at once one is held back, and a POST counts as sent from the
+// moment it leaves. A POST the service refuses is forgotten, to be retried
+// at once; one lost to the network is not, as it may have reached the
+// service.
+func (g *PostGuard) Post(v Mutator, target string, body []byte, again bool) (*Response, error) {
+	key := postKey{target, string(body)}
+	g.mu.Lock()
+	at := g.clock()
+	if !again {
+		if err := g.repeat(key, at); err != nil {
+			g.mu.Unlock()
+			return nil, err
+		}
+	}
+	if g.sent == nil {
+		g.sent = make(map[postKey]time.Time)
+	}
+	g.sent[key] = at
+	g.mu.Unlock()
+
+	resp, err := v.Post(target, body)
+	if resp != nil && resp.StatusCode >= http.StatusMultipleChoices {
+		g.mu.Lock()
+		if g.sent[key].Equal(at) {
+			delete(g.sent, key)
+		}
+		g.mu.Unlock()
+	}
+	return resp, err
+}
+
+// repeat returns a *RepeatError when key was sent within RepeatWindow of
+// now, forgetting the POSTs sent before it. g.mu must be held.
+func (g *PostGuard) repeat(key postKey, now time.Time) error {
+	for k, at := range g.sent {
+		if now.Sub(at) >= RepeatWindow {
+			delete(g.sent, k)
+		}
+	}
+	if at, ok := g.sent[key]; ok {
+		return &RepeatError{Target: key.target, Ago: now.Sub(at)}
+	}
+	return nil
+}
+
+func (g *PostGuard) clock() time.Time {
+	if g.now != nil {
+		return g.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,124 @@
+package rvfs
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PowerWatchInterval is how often frontends poll the PowerState of the
+// system they show
+const PowerWatchInterval = 15 * time.Second
+
+// PowerChange is a PowerState the service reports for a system that
+// differs from the one its cached copy shows
+type PowerChange struct {
+	System string // Path of the ComputerSystem
+	Cached string // PowerState of the cached system
+	Now    string // PowerState the service reports
+}
+
+func (c *PowerChange) String() string {
+	return fmt.Sprintf("PowerState of %s changed: %s → %s", c.System, c.Cached, c.Now)
+}
+
+// HostSystem returns the ComputerSystem that path lies in: the resource
+// at path or the nearest resource above it that is a ComputerSystem with
+// a PowerState. It is "" when there is none.
+func HostSystem(v VFS, path string) string {
+	target, err := v.ResolveTarget(v.Root(), path)
+	if err != nil || target.Resource == nil {
+		return ""
+	}
+	for p := target.Resource.Path; p != "" && p != v.Root(); {
+		res, err := v.Get(p)
+		if err != nil {
+			return ""
+		}
+		if isSystem(res) {
+			return res.Path
+		}
+		parent := v.Parent(p)
+		if parent == p {
+			break
+		}
+		p = parent
+	}
+	return ""
+}
+
+// isSystem reports whether a resource is a ComputerSystem with a
+// PowerState
+func isSystem(res *Resource) bool {
+	namespace, _, ok := splitODataType(res.ODataType)
+	if !ok || (namespace != "ComputerSystem" && !strings.HasPrefix(namespace, "ComputerSystem.")) {
+		return false
+	}
+	_, ok = powerState(res)
+	return ok
+}
+
+// powerState returns the PowerState of a resource
+func powerState(res *Resource) (string, bool) {
+	prop, ok := res.Properties["PowerState"]
+	if !ok || prop.Type != PropertySimple {
+		return "", false
+	}
+	state, ok := prop.Value.(string)
+	return state, ok
+}
+
+// PollPowerState asks the service for the PowerState of a system, past
+// the cache and without replacing the cached system: it requests the
+// property alone ($select=PowerState), which is cached apart from the
+// system, and drops it again
+func PollPowerState(v VFS, system string) (string, error) {
+	path := withQuery(system, "$select=PowerState")
+	v.Invalidate(path)
+	res, err := v.Get(path)
+	v.Invalidate(path)
+	if err != nil {
+		return "", err
+	}
+	state, ok := powerState(res)
+	if !ok {
+		return "", fmt.Errorf("%s has no PowerState", system)
+	}
+	return state, nil
+}
+
+// PowerWatch notices the PowerState of a system changing behind the
+// user's back, such as a node someone else power-cycled, while the cached
+// copy shows the old state. Each change is reported once. It is safe for
+// concurrent use.
+type PowerWatch struct {
+	mu       sync.Mutex
+	reported PowerChange
+}
+
+// Check polls the PowerState of system and returns the change from the
+// cached system, nil when there is none or it was reported already
+func (w *PowerWatch) Check(v VFS, system string) (*PowerChange, error) {
+	res, err := v.Get(system)
+	if err != nil {
+		return nil, err
+	}
+	cached, ok := powerState(res)
+	if !ok {
+		return nil, fmt.Errorf("%s has no PowerState", system)
+	}
+	now, err := PollPowerState(v, system)
+	if err != nil || now == cached {
+		return nil, err
+	}
+
+	change := PowerChange{System: res.Path, Cached: cached, Now: now}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if change == w.reported {
+		return nil, nil
+	}
+	w.reported = change
+	return &change, nil
+}
@@ -0,0 +1,86 @@
+package rvfs
+
+import (
+	"context"
+	"sync"
+)
+
+// prefetchHints maps resource types to the children navigation goes to
+// next, in the order worth fetching them
+var prefetchHints = map[string][]string{
+	"ServiceRoot":      {"Systems", "Chassis", "Managers"},
+	"ComputerSystem":   {"Processors", "Memory", "Storage", "EthernetInterfaces", "Bios"},
+	"Chassis":          {"Thermal", "Power", "ThermalSubsystem", "PowerSubsystem", "Sensors"},
+	"Manager":          {"EthernetInterfaces", "NetworkProtocol", "LogServices"},
+	"Storage":          {"Controllers", "Volumes"},
+	"AccountService":   {"Accounts", "Roles"},
+	"UpdateService":    {"FirmwareInventory", "SoftwareInventory"},
+	"SessionService":   {"Sessions"},
+	"EventService":     {"Subscriptions"},
+	"TaskService":      {"Tasks"},
+	"LogService":       {"Entries"},
+	"TelemetryService": {"MetricReports", "MetricDefinitions"},
+}
+
+// prefetchConcurrency caps the background fetches a Prefetcher runs at
+// once, so warming the cache never crowds out what was asked for
+const prefetchConcurrency = 2
+
+// PrefetchHints returns the paths of the children of res its type says
+// are likely visited next, those it links, in hint order
+func PrefetchHints(res *Resource) []string {
+	if res == nil {
+		return nil
+	}
+	var paths []string
+	for _, name := range prefetchHints[res.TypeName()] {
+		if child, ok := res.Children[name]; ok {
+			paths = append(paths, child.Target)
+		}
+	}
+	return paths
+}
+
+// Prefetcher warms the cache in the background with the children a
+// resource's type hints at, so navigating to them is instant. Each path is
+// fetched once, and only when not cached; a failed fetch may be started
+// again. It is safe for concurrent use.
+type Prefetcher struct {
+	mu      sync.Mutex
+	started map[string]bool
+	slots   chan struct{}
+	wg      sync.WaitGroup
+}
+
+// Warm starts fetching the hinted children of res that are not cached and
+// returns without waiting for them. The fetches outlive the context v may
+// be bound to, so ending the command that warmed does not abort them.
+func (p *Prefetcher) Warm(v VFS, res *Resource) {
+	v = WithContext(context.Background(), v)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started == nil {
+		p.started = make(map[string]bool)
+		p.slots = make(chan struct{}, prefetchConcurrency)
+	}
+	for _, path := range PrefetchHints(res) {
+		if p.started[path] || v.Cached(path) != nil {
+			continue
+		}
+		p.started[path] = true
+		p.wg.Go(func() {
+			p.slots <- struct{}{}
+			defer func() { <-p.slots }()
+			if _, err := v.Get(path); err != nil {
+				p.mu.Lock()
+				delete(p.started, path)
+				p.mu.Unlock()
+			}
+		})
+	}
+}
+
+// Wait blocks until the fetches started have ended
+func (p *Prefetcher) Wait() {
+	p.wg.Wait()
+}
@@ -0,0 +1,298 @@
+package rvfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// PrivilegeOperations are the operations a privilege registry maps, in
+// the order they are shown
+var PrivilegeOperations = []string{"GET", "HEAD", "PATCH", "POST", "PUT", "DELETE"}
+
+// privilegeRegistry is the part of a PrivilegeRegistry read to map
+// resources to the privileges their operations require
+type privilegeRegistry struct {
+	ID       string             `json:"Id"`
+	Mappings []privilegeMapping `json:"Mappings"`
+}
+
+// privilegeMapping is what the operations on one entity type require,
+// and where that differs
+type privilegeMapping struct {
+	Entity               string              `json:"Entity"`
+	OperationMap         operationMap        `json:"OperationMap"`
+	SubordinateOverrides []privilegeOverride `json:"SubordinateOverrides"`
+	ResourceURIOverrides []privilegeOverride `json:"ResourceURIOverrides"`
+	PropertyOverrides    []privilegeOverride `json:"PropertyOverrides"`
+}
+
+// privilegeOverride replaces the operation map of a mapping for the
+// targets it names: resource types above the entity, URIs, or properties
+type privilegeOverride struct {
+	Targets      []string     `json:"Targets"`
+	OperationMap operationMap `json:"OperationMap"`
+}
+
+// operationMap holds, by operation, the privilege sets that allow it
+type operationMap map[string][]struct {
+	Privilege []string `json:"Privilege"`
+}
+
+// privileges returns the sets allowing an operation
+func (m operationMap) privileges(operation string) [][]string {
+	var sets [][]string
+	for _, entry := range m[operation] {
+		sets = append(sets, entry.Privilege)
+	}
+	return sets
+}
+
+// PrivilegeMap is who may do what to a resource: the privileges the
+// service's PrivilegeRegistry requires for each operation on it, and the
+// roles of the account service that hold them
+type PrivilegeMap struct {
+	Resource   string
+	Entity     string // Type of the resource, as the registry names it
+	Registry   string // Id of the PrivilegeRegistry
+	Override   string // Override of the entity's mapping that applies, "" for none
+	Operations []OperationAccess
+	Properties []PropertyAccess // Properties whose PATCH needs other privileges
+}
+
+// OperationAccess is what an operation on a resource requires and which
+// roles may carry it out
+type OperationAccess struct {
+	Operation  string
+	Privileges [][]string // Any one of these sets, whole; none when unmapped
+	Roles      []string   // Roles holding a set
+	SelfRoles  []string   // Roles holding only a set with ConfigureSelf, which allows the account's own resources
+}
+
+// PropertyAccess is a property whose PATCH requires other privileges than
+// its resource's
+type PropertyAccess struct {
+	Property string
+	Access   OperationAccess
+}
+
+// Role is a role of the account service and the privileges it assigns
+type Role struct {
+	ID         string
+	Privileges []string // AssignedPrivileges, then OemPrivileges
+}
+
+// ReadRoles reads the roles of the account service, by member name
+func ReadRoles(v VFS) ([]Role, error) {
+	service, err := serviceResource(v, "AccountService")
+	if err != nil {
+		return nil, err
+	}
+	rolesLink, ok := service.Children["Roles"]
+	if !ok {
+		return nil, fmt.Errorf("%s has no Roles", service.Path)
+	}
+	roles, err := v.Get(rolesLink.Target)
+	if err != nil {
+		return nil, err
+	}
+	var list []Role
+	for _, name := range slices.Sorted(maps.Keys(roles.Children)) {
+		res, err := v.Get(roles.Children[name].Target)
+		if err != nil {
+			return nil, err
+		}
+		id := stringProperty(res, "Id")
+		if id == "" {
+			id = name
+		}
+		privileges := append(parseStringArray(res.RawJSON, "AssignedPrivileges"), parseStringArray(res.RawJSON, "OemPrivileges")...)
+		list = append(list, Role{ID: id, Privileges: privileges})
+	}
+	return list, nil
+}
+
+// ResourcePrivileges maps the resource at path against the service's
+// PrivilegeRegistry, found among its Registries, and the roles of its
+// account service. A ResourceURIOverride naming the resource wins over a
+// SubordinateOverride, whose targets must be the types of resources above
+// it in order, which wins over the entity's own mapping.
+func ResourcePrivileges(v VFS, path string) (*PrivilegeMap, error) {
+	res, err := v.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	entity := res.TypeName()
+	if entity == "" {
+		return nil, fmt.Errorf("%s has no @odata.type to look up", res.Path)
+	}
+	registry, err := readPrivilegeRegistry(v)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(registry.Mappings, func(m privilegeMapping) bool { return m.Entity == entity })
+	if i < 0 {
+		return nil, fmt.Errorf("privilege registry %s does not map %s", registry.ID, entity)
+	}
+	mapping := registry.Mappings[i]
+	roles, err := ReadRoles(v)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &PrivilegeMap{Resource: res.Path, Entity: entity, Registry: registry.ID}
+	ops := mapping.OperationMap
+	if override, ok := uriOverride(mapping, res.Path); ok {
+		ops, m.Override = override.OperationMap, "resource URI "+res.Path
+	} else if len(mapping.SubordinateOverrides) > 0 {
+		above := typesAbove(v, res.Path)
+		for _, override := range mapping.SubordinateOverrides {
+			if isSubsequence(override.Targets, above) {
+				ops, m.Override = override.OperationMap, "subordinate to "+strings.Join(override.Targets, "/")
+				break
+			}
+		}
+	}
+	for _, op := range PrivilegeOperations {
+		m.Operations = append(m.Operations, operationAccess(op, ops.privileges(op), roles))
+	}
+	for _, override := range mapping.PropertyOverrides {
+		sets := override.OperationMap.privileges("PATCH")
+		if sets == nil {
+			continue
+		}
+		for _, property := range override.Targets {
+			m.Properties = append(m.Properties, PropertyAccess{Property: property, Access: operationAccess("PATCH", sets, roles)})
+		}
+	}
+	return m, nil
+}
+
+// readPrivilegeRegistry finds the PrivilegeRegistry among the service's
+// Registries and reads the copy the service hosts
+func readPrivilegeRegistry(v VFS) (*privilegeRegistry, error) {
+	registries, err := serviceResource(v, "Registries")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range slices.Sorted(maps.Keys(registries.Children)) {
+		file, err := v.Get(registries.Children[name].Target)
+		if err != nil || !strings.Contains(stringProperty(file, "Registry")+" "+name, "PrivilegeRegistry") {
+			continue
+		}
+		var locations struct {
+			Location []struct {
+				URI            string `json:"Uri"`
+				PublicationURI string `json:"PublicationUri"`
+			}
+		}
+		if err := json.Unmarshal(file.RawJSON, &locations); err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Path, err)
+		}
+		published := ""
+		for _, loc := range locations.Location {
+			if loc.URI == "" {
+				published = loc.PublicationURI
+				continue
+			}
+			res, err := v.Get(loc.URI)
+			if err != nil {
+				return nil, err
+			}
+			var registry privilegeRegistry
+			if err := json.Unmarshal(res.RawJSON, &registry); err != nil {
+				return nil, fmt.Errorf("%s: %w", loc.URI, err)
+			}
+			return &registry, nil
+		}
+		if published != "" {
+			return nil, fmt.Errorf("service does not host its privilege registry, only names it at %s", published)
+		}
+		return nil, fmt.Errorf("%s locates no privilege registry", file.Path)
+	}
+	return nil, fmt.Errorf("service publishes no privilege registry in %s", registries.Path)
+}
+
+// serviceResource reads the resource the service root links under name
+func serviceResource(v VFS, name string) (*Resource, error) {
+	root, err := v.Get(v.Root())
+	if err != nil {
+		return nil, err
+	}
+	link, ok := root.Children[name]
+	if !ok {
+		return nil, fmt.Errorf("service has no %s", name)
+	}
+	return v.Get(link.Target)
+}
+
+// uriOverride returns the ResourceURIOverride of a mapping naming path
+func uriOverride(mapping privilegeMapping, path string) (privilegeOverride, bool) {
+	for _, override := range mapping.ResourceURIOverrides {
+		if slices.Contains(override.Targets, path) {
+			return override, true
+		}
+	}
+	return privilegeOverride{}, false
+}
+
+// typesAbove returns the types of the resources above path, from the
+// service root down; those that cannot be read are left out
+func typesAbove(v VFS, path string) []string {
+	var types []string
+	for p := v.Parent(path); p != path && strings.HasPrefix(p, v.Root()); p = v.Parent(p) {
+		if res, err := v.Get(p); err == nil && res.TypeName() != "" {
+			types = append(types, res.TypeName())
+		}
+		path = p
+	}
+	slices.Reverse(types)
+	return types
+}
+
+// isSubsequence reports whether targets appear in types in order
+func isSubsequence(targets, types []string) bool {
+	i := 0
+	for _, t := range types {
+		if i < len(targets) && targets[i] == t {
+			i++
+		}
+	}
+	return len(targets) > 0 && i == len(targets)
+}
+
+// operationAccess sorts the roles by whether they hold a set of
+// privileges allowing an operation
+func operationAccess(operation string, sets [][]string, roles []Role) OperationAccess {
+	access := OperationAccess{Operation: operation, Privileges: sets}
+	for _, role := range roles {
+		self := false
+		for _, set := range sets {
+			if !containsAll(role.Privileges, set) {
+				continue
+			}
+			if !slices.Contains(set, PrivilegeConfigureSelf) {
+				access.Roles = append(access.Roles, role.ID)
+				self = false
+				break
+			}
+			self = true
+		}
+		if self {
+			access.SelfRoles = append(access.SelfRoles, role.ID)
+		}
+	}
+	return access
+}
+
+// containsAll reports whether have holds every privilege of set
+func containsAll(have, set []string) bool {
+	for _, p := range set {
+		if !slices.Contains(have, p) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package rvfs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ConnectionReport describes how a service answers below the Redfish
+// layer: the TLS session it negotiates and how long a request takes
+type ConnectionReport struct {
+	Status int                  // HTTP status of the unauthenticated version document
+	TLS    *tls.ConnectionState // nil for plain http
+	// VerifyErr is why the certificate does not verify against the system
+	// roots for the endpoint's host, nil when it does. It is checked even
+	// when the connection skips verification.
+	VerifyErr error
+	// Connect is the first request, including the TCP and TLS handshakes
+	Connect time.Duration
+	// RoundTrips are the later requests, over the connection kept alive
+	RoundTrips []time.Duration
+}
+
+// ProbeConnection requests the version document at /redfish, which needs
+// no credentials, rounds times over one connection, as a session with the
+// given TLS setting would
+func ProbeConnection(endpoint string, insecure bool, rounds int) (*ConnectionReport, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	client := &http.Client{Transport: NewTransport(insecure)}
+	defer client.CloseIdleConnections()
+
+	report := &ConnectionReport{}
+	for i := range max(rounds, 1) {
+		req, err := http.NewRequest("GET", endpoint+VersionsPath, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, &UnreachableError{Endpoint: endpoint, Err: err}
+		}
+		// Drain the body so the connection is reused
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		elapsed := time.Since(start)
+
+		if i == 0 {
+			report.Connect = elapsed
+			report.Status = resp.StatusCode
+			report.TLS = resp.TLS
+		} else {
+			report.RoundTrips = append(report.RoundTrips, elapsed)
+		}
+	}
+
+	if report.TLS != nil {
+		report.VerifyErr = verifyChain(report.TLS.PeerCertificates, u.Hostname())
+	}
+	return report, nil
+}
+
+// verifyChain checks a presented certificate chain against the system roots
+func verifyChain(certs []*x509.Certificate, host string) error {
+	if len(certs) == 0 {
+		return fmt.Errorf("no certificate presented")
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{DNSName: host, Intermediates: intermediates})
+	return err
+}
@@ -0,0 +1,152 @@
+package rvfs
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/buger/jsonparser"
+)
+
+// acceleratorTypes are the ProcessorTypes shown as accelerators rather than
+// CPUs
+var acceleratorTypes = []string{"GPU", "Accelerator", "FPGA", "DSP"}
+
+// oemProcessorReadings are the names vendors report processor readings
+// under in a Processor's Oem object, by the standard reading they stand in
+// for. They are only looked for when the service has no standard reading.
+var oemProcessorReadings = map[string][]string{
+	"TemperatureCelsius": {"TemperatureCelsius", "Temperature", "TemperatureReading"},
+	"PowerWatts":         {"PowerWatts", "PowerConsumedWatts", "ConsumedPowerWatt", "PowerConsumption"},
+}
+
+// ProcessorReading is a temperature or power reading of a processor
+type ProcessorReading struct {
+	Name   string // TemperatureCelsius or PowerWatts, the unit in the name as Humanize expects
+	Value  float64
+	Source string // Path of the resource it was read from, with a JSON pointer for OEM readings
+}
+
+// Processor is a Processor resource: a CPU socket or an accelerator
+type Processor struct {
+	Path         string
+	Socket       string // Socket, else Id
+	Type         string // ProcessorType: CPU, GPU, Accelerator, FPGA, ...
+	Manufacturer string
+	Model        string
+	Cores        int64 // TotalCores
+	Threads      int64 // TotalThreads
+	SpeedMHz     int64 // OperatingSpeedMHz
+	MaxSpeedMHz  int64
+	State        string // Status.State; Absent for an empty socket
+	Health       string // Status.Health
+	Readings     []ProcessorReading
+}
+
+// Accelerator reports whether a processor is a GPU, FPGA or other
+// accelerator rather than a CPU
+func (p *Processor) Accelerator() bool {
+	return slices.Contains(acceleratorTypes, p.Type)
+}
+
+// Reading returns the reading of the given name, ok false when there is none
+func (p *Processor) Reading(name string) (reading ProcessorReading, ok bool) {
+	for _, r := range p.Readings {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return ProcessorReading{}, false
+}
+
+// SystemProcessors is the processors of a system, in collection order
+type SystemProcessors struct {
+	System     string
+	Processors []*Processor
+}
+
+// ReadProcessors reads the processors of the systems under base: every
+// system for the service root, else the system at base. Each processor's
+// temperature and power come from its EnvironmentMetrics, else its
+// ProcessorMetrics, else from where vendors put them under Oem.
+// Processors that cannot be read are skipped.
+func ReadProcessors(v VFS, base string) ([]*SystemProcessors, error) {
+	systems, chassis, err := hostsUnder(v, base)
+	if err != nil {
+		return nil, err
+	}
+	if len(chassis) > 0 && base != v.Root() {
+		return nil, fmt.Errorf("%s is not a system", chassis[0].Path)
+	}
+
+	var all []*SystemProcessors
+	for _, system := range systems {
+		s := &SystemProcessors{System: system.Path}
+		for _, path := range linkedMembers(v, system.RawJSON, "Processors") {
+			if res, err := v.Get(path); err == nil {
+				s.Processors = append(s.Processors, readProcessor(v, res))
+			}
+		}
+		all = append(all, s)
+	}
+	return all, nil
+}
+
+// readProcessor reads a Processor resource and the metrics it links
+func readProcessor(v VFS, res *Resource) *Processor {
+	p := &Processor{
+		Path:         res.Path,
+		Socket:       stringProperty(res, "Socket"),
+		Type:         stringProperty(res, "ProcessorType"),
+		Manufacturer: stringProperty(res, "Manufacturer"),
+		Model:        stringProperty(res, "Model"),
+	}
+	if p.Socket == "" {
+		p.Socket = stringProperty(res, "Id")
+	}
+	p.Cores, _ = jsonparser.GetInt(res.RawJSON, "TotalCores")
+	p.Threads, _ = jsonparser.GetInt(res.RawJSON, "TotalThreads")
+	p.SpeedMHz, _ = jsonparser.GetInt(res.RawJSON, "OperatingSpeedMHz")
+	p.MaxSpeedMHz, _ = jsonparser.GetInt(res.RawJSON, "MaxSpeedMHz")
+	p.State, _ = jsonparser.GetString(res.RawJSON, "Status", "State")
+	p.Health, _ = jsonparser.GetString(res.RawJSON, "Status", "Health")
+
+	// EnvironmentMetrics holds excerpts of sensors, ProcessorMetrics plain
+	// numbers it has since deprecated
+	if targets := linkTargets(res.RawJSON, "EnvironmentMetrics"); len(targets) == 1 {
+		if metrics, err := v.Get(targets[0]); err == nil {
+			p.addReading(metrics.RawJSON, metrics.Path, "TemperatureCelsius", "TemperatureCelsius", "Reading")
+			p.addReading(metrics.RawJSON, metrics.Path, "PowerWatts", "PowerWatts", "Reading")
+		}
+	}
+	if targets := linkTargets(res.RawJSON, "Metrics"); len(targets) == 1 {
+		if metrics, err := v.Get(targets[0]); err == nil {
+			p.addReading(metrics.RawJSON, metrics.Path, "TemperatureCelsius", "TemperatureCelsius")
+			p.addReading(metrics.RawJSON, metrics.Path, "PowerWatts", "ConsumedPowerWatt")
+		}
+	}
+	jsonparser.ObjectEach(res.RawJSON, func(vendor, oem []byte, dataType jsonparser.ValueType, _ int) error {
+		if dataType != jsonparser.Object {
+			return nil
+		}
+		for _, name := range []string{"TemperatureCelsius", "PowerWatts"} {
+			for _, key := range oemProcessorReadings[name] {
+				pointer := res.Path + "#/Oem/" + string(vendor) + "/" + key
+				p.addReading(oem, pointer, name, key)
+				p.addReading(oem, pointer+"/Reading", name, key, "Reading")
+			}
+		}
+		return nil
+	}, "Oem")
+	return p
+}
+
+// addReading records the number at keys as the reading name, unless the
+// processor has that reading already
+func (p *Processor) addReading(data []byte, source, name string, keys ...string) {
+	if _, ok := p.Reading(name); ok {
+		return
+	}
+	if value, err := jsonparser.GetFloat(data, keys...); err == nil {
+		p.Readings = append(p.Readings, ProcessorReading{Name: name, Value: value, Source: source})
+	}
+}
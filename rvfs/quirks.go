@@ -0,0 +1,237 @@
+package rvfs
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/buger/jsonparser"
+)
+
+// Quirk names a known way a firmware departs from the Redfish spec, and the
+// workaround bluefish applies for it
+type Quirk string
+
+const (
+	// QuirkTextPlainJSON accepts JSON bodies served as text/plain
+	QuirkTextPlainJSON Quirk = "text_plain_json"
+	// QuirkUndecoratedActions accepts action names without the leading "#"
+	// ("ComputerSystem.Reset"), so they get short names like the rest
+	QuirkUndecoratedActions Quirk = "undecorated_actions"
+)
+
+// allQuirks lists the known quirks, in the order they are reported
+var allQuirks = []Quirk{QuirkTextPlainJSON, QuirkUndecoratedActions}
+
+// QuirkRule enables quirks for the services of a vendor, optionally only for
+// a firmware line
+type QuirkRule struct {
+	// Vendor is matched case-insensitively against the service root's Vendor
+	// property and its Oem keys
+	Vendor string `yaml:"vendor"`
+	// Firmware is a prefix of the first manager's FirmwareVersion; empty
+	// matches every firmware
+	Firmware string  `yaml:"firmware"`
+	Quirks   []Quirk `yaml:"quirks"`
+}
+
+// QuirkOptions selects the quirks of a connection. Rules are consulted
+// before the built-in registry; Enable and Disable override both:
+//
+//	quirks:
+//	  enable: [text_plain_json]
+//	  disable: [undecorated_actions]
+//	  rules:
+//	    - vendor: Contoso
+//	      firmware: "1."
+//	      quirks: [text_plain_json]
+type QuirkOptions struct {
+	Enable  []Quirk     `yaml:"enable"`
+	Disable []Quirk     `yaml:"disable"`
+	Rules   []QuirkRule `yaml:"rules"`
+}
+
+// quirkRegistry holds the firmware known to need quirks. It is deliberately
+// conservative: an entry belongs here once a firmware has been seen
+// misbehaving, until then a config rule covers it.
+var quirkRegistry []QuirkRule
+
+// QuirkSet is the set of quirks active for a connection
+type QuirkSet map[Quirk]bool
+
+// List returns the active quirks in report order
+func (s QuirkSet) List() []Quirk {
+	var quirks []Quirk
+	for _, q := range allQuirks {
+		if s[q] {
+			quirks = append(quirks, q)
+		}
+	}
+	return quirks
+}
+
+// String lists the active quirks, comma separated
+func (s QuirkSet) String() string {
+	names := make([]string, 0, len(s))
+	for _, q := range s.List() {
+		names = append(names, string(q))
+	}
+	return strings.Join(names, ", ")
+}
+
+// validate checks that every quirk named in the options exists
+func (o QuirkOptions) validate() error {
+	names := append(append([]Quirk(nil), o.Enable...), o.Disable...)
+	for _, rule := range o.Rules {
+		if rule.Vendor == "" {
+			return fmt.Errorf("quirk rule without vendor")
+		}
+		names = append(names, rule.Quirks...)
+	}
+	for _, q := range names {
+		if !knownQuirk(q) {
+			available := make([]string, len(allQuirks))
+			for i, k := range allQuirks {
+				available[i] = string(k)
+			}
+			return fmt.Errorf("unknown quirk %q (available: %s)", q, strings.Join(available, ", "))
+		}
+	}
+	return nil
+}
+
+// knownQuirk returns true for the quirks bluefish implements
+func knownQuirk(q Quirk) bool {
+	for _, k := range allQuirks {
+		if k == q {
+			return true
+		}
+	}
+	return false
+}
+
+// detectQuirks resolves the quirks for the service behind client. The
+// service root (and, for firmware rules, the first manager) is only
+// fetched when some rule could apply.
+func detectQuirks(client *Client, opts QuirkOptions) (QuirkSet, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	set := make(QuirkSet)
+	rules := append(append([]QuirkRule(nil), opts.Rules...), quirkRegistry...)
+	if len(rules) > 0 {
+		root, _, err := client.get(context.Background(), client.root)
+		if err != nil {
+			return nil, err
+		}
+		vendors := serviceVendors(root)
+
+		firmware, fetched := "", false
+		for _, rule := range rules {
+			if !matchVendor(vendors, rule.Vendor) {
+				continue
+			}
+			if rule.Firmware != "" {
+				if !fetched {
+					firmware = managerFirmware(client, root)
+					fetched = true
+				}
+				if !strings.HasPrefix(firmware, rule.Firmware) {
+					continue
+				}
+			}
+			for _, q := range rule.Quirks {
+				set[q] = true
+			}
+		}
+	}
+
+	for _, q := range opts.Enable {
+		set[q] = true
+	}
+	for _, q := range opts.Disable {
+		delete(set, q)
+	}
+	return set, nil
+}
+
+// serviceVendors returns the strings identifying the vendor of a service:
+// the root's Vendor property and the keys of its Oem object
+func serviceVendors(root []byte) []string {
+	var vendors []string
+	if vendor, err := jsonparser.GetString(root, "Vendor"); err == nil && vendor != "" {
+		vendors = append(vendors, vendor)
+	}
+	jsonparser.ObjectEach(root, func(key []byte, value []byte, dataType jsonparser.ValueType, offset int) error {
+		vendors = append(vendors, string(key))
+		return nil
+	}, "Oem")
+	return vendors
+}
+
+// matchVendor reports whether a rule's vendor names the service
+func matchVendor(vendors []string, vendor string) bool {
+	for _, v := range vendors {
+		if strings.EqualFold(v, vendor) {
+			return true
+		}
+	}
+	return false
+}
+
+// managerFirmware returns the FirmwareVersion of the service's first
+// manager, or "" when it cannot be read
+func managerFirmware(client *Client, root []byte) string {
+	managers, err := jsonparser.GetString(root, "Managers", "@odata.id")
+	if err != nil {
+		return ""
+	}
+	collection, _, err := client.get(context.Background(), managers)
+	if err != nil {
+		return ""
+	}
+	first, err := jsonparser.GetString(collection, "Members", "[0]", "@odata.id")
+	if err != nil {
+		return ""
+	}
+	manager, _, err := client.get(context.Background(), first)
+	if err != nil {
+		return ""
+	}
+	firmware, _ := jsonparser.GetString(manager, "FirmwareVersion")
+	return firmware
+}
+
+// jsonContentType reports whether a response content type declares JSON.
+// A missing content type is accepted; text/plain only with the quirk.
+func jsonContentType(contentType string, quirks QuirkSet) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+		return true
+	case mediaType == "text/plain":
+		return quirks[QuirkTextPlainJSON]
+	}
+	return false
+}
+
+// decorateActions prefixes "#" to action names that lack it, so
+// "ComputerSystem.Reset" reads as "#ComputerSystem.Reset"
+func decorateActions(actions *Property) {
+	for name, action := range actions.Children {
+		if name == "Oem" || strings.HasPrefix(name, "#") || !strings.Contains(name, ".") {
+			continue
+		}
+		delete(actions.Children, name)
+		action.Name = "#" + name
+		actions.Children[action.Name] = action
+	}
+}
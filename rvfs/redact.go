@@ -0,0 +1,114 @@
+package rvfs
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/buger/jsonparser"
+)
+
+// RedactMask replaces the values of redacted properties
+const RedactMask = "***"
+
+// Redaction masks the values of properties by name, so serial numbers,
+// asset tags and addresses stay out of screenshots, transcripts and
+// exports
+type Redaction struct {
+	// Properties holds regular expressions; properties whose name matches
+	// one have their values masked, e.g. SerialNumber, AssetTag, MACAddress
+	Properties []string `yaml:"properties"`
+
+	properties []*regexp.Regexp
+}
+
+// Compile compiles the property patterns; Redacts matches nothing until it
+// has run
+func (r *Redaction) Compile() error {
+	r.properties = make([]*regexp.Regexp, len(r.Properties))
+	for i, pattern := range r.Properties {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("property pattern %q: %w", pattern, err)
+		}
+		r.properties[i] = re
+	}
+	return nil
+}
+
+// Active reports whether the redaction has patterns to mask by
+func (r *Redaction) Active() bool {
+	return len(r.properties) > 0
+}
+
+// Redacts reports whether the values of properties named name are masked
+func (r *Redaction) Redacts(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, re := range r.properties {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a JSON value with the values of the redacted properties in
+// it replaced by RedactMask; name is the property the value belongs to, ""
+// for a resource. An object is redacted member by member, any other value
+// of a redacted property (arrays included) whole. Nulls hide nothing and
+// are kept.
+func (r *Redaction) Redact(name string, raw []byte) []byte {
+	if !r.Active() {
+		return raw
+	}
+	value, dataType, _, err := jsonparser.Get(raw)
+	if err != nil {
+		return raw
+	}
+	var b bytes.Buffer
+	r.redact(&b, name, value, dataType)
+	return b.Bytes()
+}
+
+// redact writes a value, compact, with the redacted properties in it masked
+func (r *Redaction) redact(b *bytes.Buffer, name string, value []byte, dataType jsonparser.ValueType) {
+	switch {
+	case dataType == jsonparser.Object:
+		b.WriteByte('{')
+		first := true
+		jsonparser.ObjectEach(value, func(key []byte, member []byte, memberType jsonparser.ValueType, offset int) error {
+			if !first {
+				b.WriteByte(',')
+			}
+			first = false
+			b.WriteByte('"')
+			b.Write(key)
+			b.WriteString(`":`)
+			k, _ := jsonparser.ParseString(key)
+			r.redact(b, k, member, memberType)
+			return nil
+		})
+		b.WriteByte('}')
+	case dataType != jsonparser.Null && r.Redacts(name):
+		b.WriteString(`"` + RedactMask + `"`)
+	case dataType == jsonparser.Array:
+		b.WriteByte('[')
+		first := true
+		jsonparser.ArrayEach(value, func(elem []byte, elemType jsonparser.ValueType, offset int, err error) {
+			if !first {
+				b.WriteByte(',')
+			}
+			first = false
+			r.redact(b, "", elem, elemType)
+		})
+		b.WriteByte(']')
+	case dataType == jsonparser.String:
+		b.WriteByte('"')
+		b.Write(value)
+		b.WriteByte('"')
+	default:
+		b.Write(value)
+	}
+}
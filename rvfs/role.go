@@ -0,0 +1,185 @@
+package rvfs
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Privileges of the Redfish privilege model that roles assign
+const (
+	PrivilegeLogin               = "Login"
+	PrivilegeConfigureManager    = "ConfigureManager"
+	PrivilegeConfigureUsers      = "ConfigureUsers"
+	PrivilegeConfigureComponents = "ConfigureComponents"
+	PrivilegeConfigureSelf       = "ConfigureSelf"
+)
+
+// writePrivileges maps resource types to the privilege the base privilege
+// registry requires to write to them: PATCH, DELETE, and POST to create a
+// member or invoke an action. Types not listed need ConfigureComponents.
+// The subordinate overrides the registry also defines, such as a manager's
+// EthernetInterface needing ConfigureManager, are not applied.
+var writePrivileges = map[string]string{
+	"AccountService":                   PrivilegeConfigureUsers,
+	"ManagerAccount":                   PrivilegeConfigureUsers,
+	"ManagerAccountCollection":         PrivilegeConfigureUsers,
+	"Role":                             PrivilegeConfigureUsers,
+	"RoleCollection":                   PrivilegeConfigureUsers,
+	"Manager":                          PrivilegeConfigureManager,
+	"ManagerCollection":                PrivilegeConfigureManager,
+	"ManagerNetworkProtocol":           PrivilegeConfigureManager,
+	"SerialInterface":                  PrivilegeConfigureManager,
+	"VirtualMedia":                     PrivilegeConfigureManager,
+	"SessionService":                   PrivilegeConfigureManager,
+	"Session":                          PrivilegeConfigureManager,
+	"SessionCollection":                PrivilegeLogin,
+	"EventService":                     PrivilegeConfigureManager,
+	"EventDestination":                 PrivilegeConfigureManager,
+	"EventDestinationCollection":       PrivilegeConfigureManager,
+	"CertificateService":               PrivilegeConfigureManager,
+	"Certificate":                      PrivilegeConfigureManager,
+	"CertificateCollection":            PrivilegeConfigureManager,
+	"TaskService":                      PrivilegeConfigureManager,
+	"Task":                             PrivilegeConfigureManager,
+	"TelemetryService":                 PrivilegeConfigureManager,
+	"MetricReportDefinition":           PrivilegeConfigureManager,
+	"MetricReportDefinitionCollection": PrivilegeConfigureManager,
+}
+
+// SessionRole is the account a connection acts as and what its role may do
+type SessionRole struct {
+	UserName   string
+	Account    string // Path of the ManagerAccount
+	Session    string // Path of the login session, "" without one
+	RoleID     string
+	Privileges []string // AssignedPrivileges, then OemPrivileges
+}
+
+// ResolveRole follows the connection's login session to the account it
+// belongs to, and the account to its role. The session names the user;
+// when there is none, or it cannot be read, userName is looked for. It
+// fails when no account has the user name or the role cannot be read.
+func ResolveRole(v VFS, userName string) (*SessionRole, error) {
+	root, err := v.Get(v.Root())
+	if err != nil {
+		return nil, err
+	}
+	serviceLink, ok := root.Children["AccountService"]
+	if !ok {
+		return nil, fmt.Errorf("service has no AccountService")
+	}
+	service, err := v.Get(serviceLink.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reading the account service logged in, if the service wants a session
+	role := &SessionRole{UserName: userName, Session: v.Session()}
+	if role.Session != "" {
+		if session, err := v.Get(role.Session); err == nil {
+			if name := stringProperty(session, "UserName"); name != "" {
+				role.UserName = name
+			}
+		}
+	}
+	if role.UserName == "" {
+		return nil, fmt.Errorf("no user name to find the account of")
+	}
+
+	accountsLink, ok := service.Children["Accounts"]
+	if !ok {
+		return nil, fmt.Errorf("%s has no Accounts", service.Path)
+	}
+	accounts, err := v.Get(accountsLink.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	var account *Resource
+	for _, member := range accounts.Children {
+		res, err := v.Get(member.Target)
+		if err != nil {
+			continue
+		}
+		if stringProperty(res, "UserName") == role.UserName {
+			account = res
+			break
+		}
+	}
+	if account == nil {
+		return nil, fmt.Errorf("no account for user %s in %s", role.UserName, accounts.Path)
+	}
+	role.Account = account.Path
+	role.RoleID = stringProperty(account, "RoleId")
+
+	// The account links its role; older accounts only name it, as a member
+	// of the Roles collection
+	var rolePath string
+	if links, ok := account.Properties["Links"]; ok && links.Type == PropertyObject {
+		rolePath = referencePath(links.Children["Role"])
+	}
+	if rolePath == "" {
+		if rolesLink, ok := service.Children["Roles"]; ok && role.RoleID != "" {
+			if roles, err := v.Get(rolesLink.Target); err == nil {
+				if member, ok := roles.Children[role.RoleID]; ok {
+					rolePath = member.Target
+				}
+			}
+		}
+	}
+	if rolePath == "" {
+		return nil, fmt.Errorf("account %s links no role", account.Path)
+	}
+	res, err := v.Get(rolePath)
+	if err != nil {
+		return nil, err
+	}
+	if role.RoleID == "" {
+		role.RoleID = stringProperty(res, "Id")
+	}
+	role.Privileges = append(parseStringArray(res.RawJSON, "AssignedPrivileges"), parseStringArray(res.RawJSON, "OemPrivileges")...)
+	return role, nil
+}
+
+// Has reports whether the role grants a privilege
+func (r *SessionRole) Has(privilege string) bool {
+	return slices.Contains(r.Privileges, privilege)
+}
+
+// MissingPrivilege returns the privilege the role lacks to write to a
+// resource, or "" when it may. A nil role, one that could not be resolved,
+// is let write anywhere, leaving the service to refuse. The account's own
+// resource and session need only ConfigureSelf.
+func (r *SessionRole) MissingPrivilege(res *Resource) string {
+	if r == nil || res == nil {
+		return ""
+	}
+	if (res.Path == r.Account || res.Path == r.Session) && r.Has(PrivilegeConfigureSelf) {
+		return ""
+	}
+	needed := PrivilegeConfigureComponents
+	if _, typeName, ok := splitODataType(res.ODataType); ok {
+		if p, ok := writePrivileges[typeName]; ok {
+			needed = p
+		}
+	}
+	if r.Has(needed) {
+		return ""
+	}
+	return needed
+}
+
+// Refusal returns the error a write to res would meet for lack of a
+// privilege, nil when the role may write to it
+func (r *SessionRole) Refusal(res *Resource) error {
+	if missing := r.MissingPrivilege(res); missing != "" {
+		return &PrivilegeError{Role: r.RoleID, Privilege: missing, Path: res.Path}
+	}
+	return nil
+}
+
+// String describes the role: Operator (Login, ConfigureComponents) as alice
+func (r *SessionRole) String() string {
+	return fmt.Sprintf("%s (%s) as %s", r.RoleID, strings.Join(r.Privileges, ", "), r.UserName)
+}
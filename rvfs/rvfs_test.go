@@ -1,12 +1,28 @@
 package rvfs
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // Test data
@@ -79,12 +95,21 @@ func TestClient_Post(t *testing.T) {
 	var receivedContentType string
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
 		if r.URL.Path == "/redfish/v1/SessionService/Sessions" && r.Method == "POST" {
 			w.Header().Set("X-Auth-Token", "test-token-123")
 			w.WriteHeader(http.StatusCreated)
 			w.Write([]byte(`{}`))
 			return
 		}
+		if r.URL.Path == "/redfish/v1" {
+			w.Write(serviceRoot)
+			return
+		}
+		if r.Header.Get("X-Auth-Token") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
 		if r.Method == "POST" {
 			receivedBody, _ = io.ReadAll(r.Body)
 			receivedToken = r.Header.Get("X-Auth-Token")
@@ -101,15 +126,21 @@ func TestClient_Post(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewClient failed: %v", err)
 	}
+	if client.Auth() != AuthNone {
+		t.Errorf("Auth before login = %q", client.Auth())
+	}
 
 	body, _ := json.Marshal(map[string]string{"ResetType": "ForceOff"})
-	data, status, err := client.Post("/redfish/v1/Systems/1/Actions/ComputerSystem.Reset", body)
+	resp, err := client.Post(context.Background(), "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset", body)
 	if err != nil {
 		t.Fatalf("Post failed: %v", err)
 	}
+	if client.Auth() != AuthSession {
+		t.Errorf("Auth after login = %q", client.Auth())
+	}
 
-	if status != http.StatusOK {
-		t.Errorf("status = %d, want %d", status, http.StatusOK)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
 	}
 	if receivedToken != "test-token-123" {
 		t.Errorf("token = %q, want %q", receivedToken, "test-token-123")
@@ -120,14 +151,14 @@ func TestClient_Post(t *testing.T) {
 	if string(receivedBody) != string(body) {
 		t.Errorf("body = %q, want %q", string(receivedBody), string(body))
 	}
-	if len(data) == 0 {
+	if len(resp.Body) == 0 {
 		t.Error("expected response body, got empty")
 	}
 }
 
 // TestParser_Basic tests basic parsing functionality
 func TestParser_Basic(t *testing.T) {
-	parser := NewParser()
+	parser := NewParser(ParserOptions{})
 
 	t.Run("parse service root", func(t *testing.T) {
 		resource, err := parser.Parse("/redfish/v1", serviceRoot)
@@ -250,9 +281,30 @@ func TestParser_Basic(t *testing.T) {
 	})
 }
 
+func TestLinkElements(t *testing.T) {
+	link := func(target string) *Property { return &Property{Type: PropertyLink, LinkTarget: target} }
+	prop := &Property{Type: PropertyArray, Elements: []*Property{
+		link("/redfish/v1/Chassis/1"),
+		link("/redfish/v1/Chassis/2"),
+		link("/redfish/v1/Systems/2"),
+	}}
+	if !prop.IsLinkArray() {
+		t.Fatal("IsLinkArray = false for an array of links")
+	}
+	elements := LinkElements(prop)
+	if len(elements) != 1 || elements["1"] != prop.Elements[0] {
+		t.Errorf("LinkElements = %v, want only 1, as 2 is shared", elements)
+	}
+
+	prop.Elements = append(prop.Elements, &Property{Type: PropertySimple, Value: "x"})
+	if prop.IsLinkArray() {
+		t.Error("IsLinkArray = true for an array holding a value")
+	}
+}
+
 // TestParser_URIStringDetection tests that URI string properties are detected as PropertyLinks
 func TestParser_URIStringDetection(t *testing.T) {
-	parser := NewParser()
+	parser := NewParser(ParserOptions{})
 	resource, err := parser.Parse("/redfish/v1/Systems/1", system1)
 	if err != nil {
 		t.Fatalf("Parse failed: %v", err)
@@ -269,6 +321,9 @@ func TestParser_URIStringDetection(t *testing.T) {
 		if prop.LinkTarget != "/redfish/v1/UpdateService/FirmwareInventory/BMC" {
 			t.Errorf("LinkTarget = %q, want %q", prop.LinkTarget, "/redfish/v1/UpdateService/FirmwareInventory/BMC")
 		}
+		if !prop.URIString {
+			t.Error("URIString not set")
+		}
 	})
 
 	t.Run("ImageURI with external URL stays PropertySimple", func(t *testing.T) {
@@ -360,16 +415,168 @@ func TestParser_URIStringDetection(t *testing.T) {
 	})
 }
 
+// TestParser_URIOptions tests the configurable URI reference detection
+func TestParser_URIOptions(t *testing.T) {
+	data := []byte(`{
+		"@odata.id": "/redfish/v1/Systems/1",
+		"FirmwareInventoryUri": "/redfish/v1/UpdateService/FirmwareInventory/BMC",
+		"OemDumpUri": "/not/a/resource",
+		"LogLocation": "/redfish/v1/Managers/1/LogServices/Log1",
+		"target": "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset"
+	}`)
+
+	tests := []struct {
+		name  string
+		opts  ParserOptions
+		links map[string]bool
+	}{
+		{
+			name:  "default heuristic",
+			opts:  ParserOptions{},
+			links: map[string]bool{"FirmwareInventoryUri": true, "OemDumpUri": true, "LogLocation": false, "target": true},
+		},
+		{
+			name:  "allow and deny lists",
+			opts:  ParserOptions{URIProperties: []string{"LogLocation"}, NotURIProperties: []string{"OemDumpUri"}},
+			links: map[string]bool{"FirmwareInventoryUri": true, "OemDumpUri": false, "LogLocation": true, "target": true},
+		},
+		{
+			name:  "heuristic disabled",
+			opts:  ParserOptions{DisableURIHeuristic: true, URIProperties: []string{"FirmwareInventoryUri"}},
+			links: map[string]bool{"FirmwareInventoryUri": true, "OemDumpUri": false, "LogLocation": false, "target": true},
+		},
+		{
+			name:  "deny list wins over built-in names",
+			opts:  ParserOptions{URIProperties: []string{"target"}, NotURIProperties: []string{"target"}},
+			links: map[string]bool{"target": false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource, err := NewParser(tt.opts).Parse("/redfish/v1/Systems/1", data)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			for name, wantLink := range tt.links {
+				isLink := resource.Properties[name].Type == PropertyLink
+				if isLink != wantLink {
+					t.Errorf("%s: link = %v, want %v", name, isLink, wantLink)
+				}
+			}
+		})
+	}
+}
+
+// TestParser_LinkArrayChildren tests promotion of OEM link arrays into children
+func TestParser_LinkArrayChildren(t *testing.T) {
+	data := []byte(`{
+		"@odata.id": "/redfish/v1/Managers/1/Oem/Vendor",
+		"Devices": [
+			{"@odata.id": "/redfish/v1/Managers/1/Oem/Vendor/Devices/A"},
+			{"@odata.id": "/redfish/v1/Managers/1/Oem/Vendor/Devices/B"}
+		],
+		"Log": {"@odata.id": "/redfish/v1/Managers/1/Oem/Vendor/Log"},
+		"Entries": [
+			{"@odata.id": "/redfish/v1/Managers/1/Oem/Vendor/Log"}
+		],
+		"Names": ["A", "B"]
+	}`)
+
+	t.Run("disabled by default", func(t *testing.T) {
+		resource, err := NewParser(ParserOptions{}).Parse("/redfish/v1/Managers/1/Oem/Vendor", data)
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if prop := resource.Properties["Devices"]; prop == nil || prop.Type != PropertyArray {
+			t.Errorf("Devices should stay a PropertyArray, got %+v", prop)
+		}
+		if _, ok := resource.Children["A"]; ok {
+			t.Error("Devices entries should not be children")
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		resource, err := NewParser(ParserOptions{LinkArrayChildren: true}).Parse("/redfish/v1/Managers/1/Oem/Vendor", data)
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		for _, name := range []string{"A", "B"} {
+			child, ok := resource.Children[name]
+			if !ok {
+				t.Fatalf("missing promoted child %s", name)
+			}
+			if child.Type != ChildLink {
+				t.Errorf("%s: type = %v, want ChildLink", name, child.Type)
+			}
+		}
+		if _, ok := resource.Properties["Devices"]; ok {
+			t.Error("promoted Devices should not remain a property")
+		}
+		// Entry name "Log" collides with an existing child: array stays a property
+		if prop := resource.Properties["Entries"]; prop == nil || prop.Type != PropertyArray {
+			t.Errorf("colliding Entries should stay a PropertyArray, got %+v", prop)
+		}
+		if prop := resource.Properties["Names"]; prop == nil || prop.Type != PropertyArray {
+			t.Errorf("plain string array should stay a PropertyArray, got %+v", prop)
+		}
+	})
+}
+
+func TestParser_MissingODataID(t *testing.T) {
+	parser := NewParser(ParserOptions{})
+
+	res, err := parser.Parse("/redfish/v1/Systems/1/Bios", []byte(`{"Id": "Bios", "Attributes": {}}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if res.ODataID != "/redfish/v1/Systems/1/Bios" {
+		t.Errorf("ODataID = %q, want the request path", res.ODataID)
+	}
+	if len(res.Warnings) != 1 {
+		t.Errorf("warnings = %v, want one", res.Warnings)
+	}
+
+	res, err = parser.Parse("/redfish/v1/Systems/1", system1)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(res.Warnings) != 0 {
+		t.Errorf("warnings = %v for a resource with @odata.id", res.Warnings)
+	}
+
+	// The warning reaches the request log
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"Id": "Bios"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "admin", "pass", true)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	cache := NewResourceCache(client, parser, "")
+	if _, err := cache.Get(context.Background(), "/redfish/v1/Systems/1/Bios"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	requests, _ := cache.Stats().Since(0)
+	if len(requests) != 1 || len(requests[0].Warnings) != 1 {
+		t.Errorf("requests = %+v, want one with a warning", requests)
+	}
+}
+
 // mockCache implements a simple in-memory cache for testing
 type mockCache struct {
 	resources map[string]*Resource
 	parser    *Parser
+	stats     Stats
 }
 
 func newMockCache() *mockCache {
 	return &mockCache{
 		resources: make(map[string]*Resource),
-		parser:    NewParser(),
+		parser:    NewParser(ParserOptions{}),
 	}
 }
 
@@ -382,7 +589,7 @@ func (m *mockCache) loadJSON(path string, data []byte) error {
 	return nil
 }
 
-func (m *mockCache) Get(path string) (*Resource, error) {
+func (m *mockCache) Get(ctx context.Context, path string) (*Resource, error) {
 	if res, ok := m.resources[path]; ok {
 		return res, nil
 	}
@@ -405,14 +612,66 @@ func (m *mockCache) Clear() {
 	m.resources = make(map[string]*Resource)
 }
 
-func (m *mockCache) Post(path string, body []byte) ([]byte, int, error) {
-	return nil, 0, fmt.Errorf("post not supported in mock")
+func (m *mockCache) Denied(path string) bool {
+	return false
+}
+
+func (m *mockCache) Cached(path string) *Resource {
+	return m.resources[path]
+}
+
+func (m *mockCache) FetchedAt(path string) (time.Time, bool) {
+	if r, ok := m.resources[path]; ok {
+		return r.FetchedAt, true
+	}
+	return time.Time{}, false
+}
+
+func (m *mockCache) Download(ctx context.Context, path string, w io.Writer, progress ProgressFunc) (int64, error) {
+	return 0, &NotCachedError{Path: path}
+}
+
+func (m *mockCache) Allow(ctx context.Context, path string) ([]string, error) {
+	return nil, &NotCachedError{Path: path}
+}
+
+func (m *mockCache) Post(ctx context.Context, path string, body []byte) (*Response, error) {
+	return nil, fmt.Errorf("post not supported in mock")
+}
+
+func (m *mockCache) Patch(ctx context.Context, path string, body []byte) (*Response, error) {
+	return nil, fmt.Errorf("patch not supported in mock")
 }
 
 func (m *mockCache) Save() error {
 	return nil
 }
 
+func (m *mockCache) Stats() *Stats {
+	return &m.stats
+}
+
+func (m *mockCache) SearchNames(re *regexp.Regexp) []*Resource {
+	return m.search(func(x *searchIndex) map[string]bool { return x.matchNames(re) })
+}
+
+func (m *mockCache) SearchValues(text string) []*Resource {
+	return m.search(func(x *searchIndex) map[string]bool { return x.matchText(text) })
+}
+
+// search answers a query from an index of the mock's resources
+func (m *mockCache) search(query func(*searchIndex) map[string]bool) []*Resource {
+	index := newSearchIndex()
+	for path, res := range m.resources {
+		index.add(path, res)
+	}
+	var resources []*Resource
+	for path := range query(index) {
+		resources = append(resources, m.resources[path])
+	}
+	return resources
+}
+
 // TestVFS_PathResolution tests path resolution
 func TestVFS_PathResolution(t *testing.T) {
 	cache := newMockCache()
@@ -420,7 +679,7 @@ func TestVFS_PathResolution(t *testing.T) {
 	cache.loadJSON("/redfish/v1/Systems", systemsCollection)
 	cache.loadJSON("/redfish/v1/Systems/1", system1)
 
-	vfs := &vfs{cache: cache}
+	vfs := &vfs{cache: cache, root: DefaultRoot}
 
 	t.Run("absolute resource path", func(t *testing.T) {
 		target, err := vfs.ResolveTarget("/redfish/v1", "/redfish/v1/Systems/1")
@@ -507,6 +766,40 @@ func TestVFS_PathResolution(t *testing.T) {
 		if target.ResourcePath != "/redfish/v1/Chassis/1" {
 			t.Errorf("ResourcePath = %q, want %q", target.ResourcePath, "/redfish/v1/Chassis/1")
 		}
+		if target.ViaURIString {
+			t.Error("ViaURIString set for an @odata.id link")
+		}
+	})
+
+	t.Run("link array element by target name", func(t *testing.T) {
+		target, err := vfs.ResolveTarget("/redfish/v1/Systems/1", "Links/Chassis/1")
+		if err != nil {
+			t.Fatalf("ResolveTarget failed: %v", err)
+		}
+
+		if target.Type != TargetLink {
+			t.Errorf("Type = %v, want TargetLink", target.Type)
+		}
+		if target.ResourcePath != "/redfish/v1/Chassis/1" {
+			t.Errorf("ResourcePath = %q, want %q", target.ResourcePath, "/redfish/v1/Chassis/1")
+		}
+		if _, err := vfs.ResolveTarget("/redfish/v1/Systems/1", "Links/Chassis/2"); err == nil {
+			t.Error("ResolveTarget of a target the array does not link succeeded")
+		}
+	})
+
+	t.Run("URI string link", func(t *testing.T) {
+		target, err := vfs.ResolveTarget("/redfish/v1/Systems/1", "FirmwareInventoryUri")
+		if err != nil {
+			t.Fatalf("ResolveTarget failed: %v", err)
+		}
+
+		if target.Type != TargetLink {
+			t.Errorf("Type = %v, want TargetLink", target.Type)
+		}
+		if !target.ViaURIString {
+			t.Error("ViaURIString not set for a link inferred from a URI string")
+		}
 	})
 
 	t.Run("nested property access", func(t *testing.T) {
@@ -591,12 +884,107 @@ func TestVFS_PathResolution(t *testing.T) {
 	})
 }
 
+// TestVFS_LinkTraversal tests composite paths that cross resources through links
+func TestVFS_LinkTraversal(t *testing.T) {
+	cache := newMockCache()
+	cache.loadJSON("/redfish/v1", serviceRoot)
+	cache.loadJSON("/redfish/v1/Systems", systemsCollection)
+	cache.loadJSON("/redfish/v1/Systems/1", system1)
+	cache.loadJSON("/redfish/v1/Chassis", []byte(`{
+		"@odata.id": "/redfish/v1/Chassis",
+		"Members": [{"@odata.id": "/redfish/v1/Chassis/1"}]
+	}`))
+	cache.loadJSON("/redfish/v1/Chassis/1", []byte(`{
+		"@odata.id": "/redfish/v1/Chassis/1",
+		"Thermal": {"@odata.id": "/redfish/v1/Chassis/1/Thermal"},
+		"Links": {"ComputerSystems": [{"@odata.id": "/redfish/v1/Systems/1"}]}
+	}`))
+	cache.loadJSON("/redfish/v1/Chassis/1/Thermal", []byte(`{
+		"@odata.id": "/redfish/v1/Chassis/1/Thermal",
+		"Temperatures": [
+			{
+				"@odata.id": "/redfish/v1/Chassis/1/Thermal#/Temperatures/0",
+				"Name": "CPU Temp",
+				"RelatedItem": [{"@odata.id": "/redfish/v1/Systems/1"}]
+			}
+		],
+		"Fans": [
+			{"@odata.id": "/redfish/v1/Chassis/1/Thermal#/Fans/0", "Name": "Fan 1"}
+		],
+		"Redundancy": [
+			{
+				"@odata.id": "/redfish/v1/Chassis/1/Thermal#/Redundancy/0",
+				"Mode": "N+m",
+				"RedundancySet": [
+					{"@odata.id": "/redfish/v1/Chassis/1/Thermal#/Fans/0"}
+				]
+			}
+		],
+		"Loop": {"@odata.id": "/redfish/v1/Chassis/1/Thermal#/Loop"}
+	}`))
+
+	vfs := &vfs{cache: cache, root: DefaultRoot}
+
+	tests := []struct {
+		name     string
+		base     string
+		target   string
+		wantType TargetType
+		wantPath string // ResourcePath for resources, property value otherwise
+	}{
+		{"link then child", "/redfish/v1/Systems/1", "Links/Chassis[0]/Thermal", TargetResource, "/redfish/v1/Chassis/1/Thermal"},
+		{"link then property", "/redfish/v1/Systems/1", "Links/Chassis[0]/Thermal/Temperatures[0]/Name", TargetProperty, "CPU Temp"},
+		{"link chain back to start", "/redfish/v1/Systems/1", "Links/Chassis[0]/Links/ComputerSystems[0]/Status/Health", TargetProperty, "OK"},
+		{"links three resources deep", "/redfish/v1/Chassis/1", "Thermal/Temperatures[0]/RelatedItem[0]/Boot/BootOrder[1]", TargetProperty, "Hdd"},
+		{"pointer link then property", "/redfish/v1/Chassis/1/Thermal", "Redundancy[0]/RedundancySet[0]/Name", TargetProperty, "Fan 1"},
+		{"absolute path with pointer", "/redfish/v1", "/redfish/v1/Chassis/1/Thermal#/Fans/0/Name", TargetProperty, "Fan 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := vfs.ResolveTarget(tt.base, tt.target)
+			if err != nil {
+				t.Fatalf("ResolveTarget(%q, %q) failed: %v", tt.base, tt.target, err)
+			}
+			if target.Type != tt.wantType {
+				t.Fatalf("Type = %v, want %v", target.Type, tt.wantType)
+			}
+			got := target.ResourcePath
+			if target.Type == TargetProperty {
+				got = fmt.Sprint(target.Property.Value)
+			}
+			if got != tt.wantPath {
+				t.Errorf("got %q, want %q", got, tt.wantPath)
+			}
+		})
+	}
+
+	t.Run("pointer link ending the path resolves to the pointed property", func(t *testing.T) {
+		target, err := vfs.ResolveTarget("/redfish/v1/Chassis/1/Thermal", "Redundancy[0]/RedundancySet[0]")
+		if err != nil {
+			t.Fatalf("ResolveTarget failed: %v", err)
+		}
+		if target.Type != TargetProperty || target.Property.Type != PropertyObject {
+			t.Fatalf("got %v/%v, want object property", target.Type, target.Property.Type)
+		}
+		if target.Resource.Path != "/redfish/v1/Chassis/1/Thermal" {
+			t.Errorf("Resource = %q, want Thermal", target.Resource.Path)
+		}
+	})
+
+	t.Run("self-referencing pointer is bounded", func(t *testing.T) {
+		if _, err := vfs.ResolveTarget("/redfish/v1/Chassis/1/Thermal", "Loop"); err == nil {
+			t.Error("expected an error for a pointer that resolves to itself")
+		}
+	})
+}
+
 // TestVFS_ListOperations tests list operations
 func TestVFS_ListOperations(t *testing.T) {
 	cache := newMockCache()
 	cache.loadJSON("/redfish/v1/Systems/1", system1)
 
-	vfs := &vfs{cache: cache}
+	vfs := &vfs{cache: cache, root: DefaultRoot}
 
 	t.Run("ListAll", func(t *testing.T) {
 		entries, err := vfs.ListAll("/redfish/v1/Systems/1")
@@ -648,7 +1036,7 @@ func TestVFS_ListOperations(t *testing.T) {
 // TestVFS_PathUtilities tests path utility functions
 func TestVFS_PathUtilities(t *testing.T) {
 	cache := newMockCache()
-	vfs := &vfs{cache: cache}
+	vfs := &vfs{cache: cache, root: DefaultRoot}
 
 	t.Run("Join", func(t *testing.T) {
 		tests := []struct {
@@ -707,3 +1095,4327 @@ func TestVFS_PathUtilities(t *testing.T) {
 		}
 	})
 }
+
+// TestVFS_QueryOptions tests OData query pass-through and partial resources
+func TestVFS_QueryOptions(t *testing.T) {
+	cache := newMockCache()
+	cache.loadJSON("/redfish/v1", serviceRoot)
+	cache.loadJSON("/redfish/v1/Systems", systemsCollection)
+	cache.loadJSON("/redfish/v1/Systems/1", system1)
+	cache.loadJSON("/redfish/v1/Systems/1?$select=Status", []byte(`{
+		"@odata.id": "/redfish/v1/Systems/1",
+		"Status": {"State": "Enabled", "Health": "OK"}
+	}`))
+	cache.loadJSON("/redfish/v1/Systems?$top=1", []byte(`{
+		"@odata.id": "/redfish/v1/Systems",
+		"Members": [{"@odata.id": "/redfish/v1/Systems/1"}],
+		"Members@odata.count": 3,
+		"Members@odata.nextLink": "/redfish/v1/Systems?$skip=1"
+	}`))
+
+	vfs := &vfs{cache: cache, root: DefaultRoot}
+
+	t.Run("query on final resource", func(t *testing.T) {
+		target, err := vfs.ResolveTarget("/redfish/v1", "Systems/1?$select=Status")
+		if err != nil {
+			t.Fatalf("ResolveTarget failed: %v", err)
+		}
+		if target.Type != TargetResource {
+			t.Errorf("Type = %v, want TargetResource", target.Type)
+		}
+		if target.ResourcePath != "/redfish/v1/Systems/1?$select=Status" {
+			t.Errorf("ResourcePath = %q", target.ResourcePath)
+		}
+		if !target.Resource.IsPartial() {
+			t.Error("expected partial resource")
+		}
+		if target.Resource.Path != "/redfish/v1/Systems/1" {
+			t.Errorf("Resource.Path = %q, want /redfish/v1/Systems/1", target.Resource.Path)
+		}
+		if _, ok := target.Resource.Properties["BiosVersion"]; ok {
+			t.Error("partial resource should not contain unselected properties")
+		}
+	})
+
+	t.Run("partial collection keeps members and paging link", func(t *testing.T) {
+		target, err := vfs.ResolveTarget("/redfish/v1", "Systems?$top=1")
+		if err != nil {
+			t.Fatalf("ResolveTarget failed: %v", err)
+		}
+		if _, ok := target.Resource.Children["1"]; !ok {
+			t.Error("expected member 1 as child")
+		}
+		next := target.Resource.Properties["Members@odata.nextLink"]
+		if next == nil || next.Type != PropertyLink || next.LinkTarget != "/redfish/v1/Systems?$skip=1" {
+			t.Errorf("nextLink = %+v, want PropertyLink", next)
+		}
+	})
+
+	t.Run("query on base path is dropped for relative targets", func(t *testing.T) {
+		target, err := vfs.ResolveTarget("/redfish/v1/Systems/1?$select=Status", "BiosVersion")
+		if err != nil {
+			t.Fatalf("ResolveTarget failed: %v", err)
+		}
+		if target.Property.Value != "2.1.0" {
+			t.Errorf("Property value = %v, want 2.1.0", target.Property.Value)
+		}
+	})
+
+	t.Run("query on base path kept for empty target", func(t *testing.T) {
+		target, err := vfs.ResolveTarget("/redfish/v1/Systems/1?$select=Status", "")
+		if err != nil {
+			t.Fatalf("ResolveTarget failed: %v", err)
+		}
+		if !target.Resource.IsPartial() {
+			t.Error("expected partial resource")
+		}
+	})
+
+	t.Run("query on property is an error", func(t *testing.T) {
+		if _, err := vfs.ResolveTarget("/redfish/v1/Systems/1", "Status?$select=Health"); err == nil {
+			t.Error("expected error for query on property")
+		}
+	})
+
+	t.Run("ListAll uses canonical entry paths", func(t *testing.T) {
+		entries, err := vfs.ListAll("/redfish/v1/Systems/1?$select=Status")
+		if err != nil {
+			t.Fatalf("ListAll failed: %v", err)
+		}
+		if len(entries) != 1 || entries[0].Path != "/redfish/v1/Systems/1/Status" {
+			t.Errorf("entries = %+v", entries)
+		}
+	})
+
+	t.Run("Join and Parent", func(t *testing.T) {
+		tests := []struct {
+			base, target, want string
+		}{
+			{"/redfish/v1", "Systems?$top=2", "/redfish/v1/Systems?$top=2"},
+			{"/redfish/v1/Systems?$top=2", "1", "/redfish/v1/Systems/1"},
+			{"/redfish/v1/Systems", "/redfish/v1/Chassis?$select=Name", "/redfish/v1/Chassis?$select=Name"},
+		}
+		for _, tt := range tests {
+			if got := vfs.Join(tt.base, tt.target); got != tt.want {
+				t.Errorf("Join(%q, %q) = %q, want %q", tt.base, tt.target, got, tt.want)
+			}
+		}
+		if got := vfs.Parent("/redfish/v1/Systems/1?$select=Status"); got != "/redfish/v1/Systems" {
+			t.Errorf("Parent = %q, want /redfish/v1/Systems", got)
+		}
+	})
+
+	t.Run("TargetArg", func(t *testing.T) {
+		tests := []struct {
+			args []string
+			want string
+		}{
+			{nil, ""},
+			{[]string{"Systems/1"}, "Systems/1"},
+			{[]string{"'Systems?$top=5'"}, "Systems?$top=5"},
+			{[]string{`"Systems?$filter=Status/Health`, "eq", `'OK'"`}, "Systems?$filter=Status/Health eq 'OK'"},
+			{[]string{"'Systems"}, "'Systems"},
+		}
+		for _, tt := range tests {
+			if got := TargetArg(tt.args); got != tt.want {
+				t.Errorf("TargetArg(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		}
+	})
+}
+
+// TestClient_FetchQuery tests that query options reach the server intact
+func TestClient_FetchQuery(t *testing.T) {
+	var receivedQuery string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/redfish/v1/SessionService/Sessions" && r.Method == "POST" {
+			w.Header().Set("X-Auth-Token", "test-token-123")
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		receivedQuery = r.URL.Query().Get("$filter")
+		w.Write([]byte(`{"@odata.id": "/redfish/v1/Systems"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "admin", "pass", true)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	if _, err := client.Fetch(context.Background(), "/redfish/v1/Systems?$filter=Status/Health eq 'OK'"); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if receivedQuery != "Status/Health eq 'OK'" {
+		t.Errorf("$filter = %q, want %q", receivedQuery, "Status/Health eq 'OK'")
+	}
+}
+
+func TestParser_Conditions(t *testing.T) {
+	data := []byte(`{
+		"@odata.id": "/redfish/v1/Systems/1",
+		"Status": {
+			"Health": "Critical",
+			"Conditions": [
+				{
+					"MessageId": "Processor.1.0.Overheat",
+					"Message": "CPU1 is too hot",
+					"Severity": "Critical",
+					"Timestamp": "2024-03-01T10:00:00Z",
+					"OriginOfCondition": {"@odata.id": "/redfish/v1/Systems/1/Processors/CPU1"},
+					"LogEntry": {"@odata.id": "/redfish/v1/Systems/1/LogServices/Log/Entries/7"}
+				},
+				{"MessageId": "Base.1.8.Success", "Message": "Recovered", "Severity": "OK"}
+			]
+		}
+	}`)
+
+	res, err := NewParser(ParserOptions{}).Parse("/redfish/v1/Systems/1", data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(res.Conditions) != 2 {
+		t.Fatalf("got %d conditions, want 2", len(res.Conditions))
+	}
+	c := res.Conditions[0]
+	if c.MessageID != "Processor.1.0.Overheat" || c.Severity != "Critical" || c.Timestamp != "2024-03-01T10:00:00Z" {
+		t.Errorf("condition = %+v", c)
+	}
+	if c.OriginOfCondition != "/redfish/v1/Systems/1/Processors/CPU1" {
+		t.Errorf("OriginOfCondition = %q", c.OriginOfCondition)
+	}
+	if c.LogEntry != "/redfish/v1/Systems/1/LogServices/Log/Entries/7" {
+		t.Errorf("LogEntry = %q", c.LogEntry)
+	}
+	if got := res.ConditionSeverity(); got != "Critical" {
+		t.Errorf("ConditionSeverity = %q, want Critical", got)
+	}
+
+	// The array stays available as a property
+	if _, ok := res.Properties["Status"].Children["Conditions"]; !ok {
+		t.Error("Status.Conditions property missing")
+	}
+
+	res, _ = NewParser(ParserOptions{}).Parse("/redfish/v1/Systems/1", system1)
+	if len(res.Conditions) != 0 || res.ConditionSeverity() != "" {
+		t.Errorf("conditions = %+v for a resource without any", res.Conditions)
+	}
+}
+
+func TestParser_Messages(t *testing.T) {
+	parser := NewParser(ParserOptions{})
+
+	t.Run("resource and property ExtendedInfo", func(t *testing.T) {
+		data := []byte(`{
+			"@odata.id": "/redfish/v1/Systems/1",
+			"@Message.ExtendedInfo": [
+				{"MessageId": "Base.1.8.Success", "Message": "Completed", "MessageSeverity": "OK"}
+			],
+			"AssetTag": "",
+			"AssetTag@Message.ExtendedInfo": [
+				{"MessageId": "Base.1.8.PropertyNotWritable", "Message": "Read only", "Severity": "Warning", "Resolution": "Remove it"}
+			]
+		}`)
+		resource, err := parser.Parse("/redfish/v1/Systems/1", data)
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if len(resource.Messages) != 2 {
+			t.Fatalf("messages = %d, want 2", len(resource.Messages))
+		}
+		if resource.Messages[0].Severity != "OK" {
+			t.Errorf("severity = %q, want %q", resource.Messages[0].Severity, "OK")
+		}
+		prop := resource.Messages[1]
+		if prop.Severity != "Warning" || prop.Resolution != "Remove it" {
+			t.Errorf("property message = %+v", prop)
+		}
+		if len(prop.RelatedProperties) != 1 || prop.RelatedProperties[0] != "AssetTag" {
+			t.Errorf("related properties = %v, want [AssetTag]", prop.RelatedProperties)
+		}
+	})
+
+	t.Run("error without ExtendedInfo", func(t *testing.T) {
+		messages := parseMessages([]byte(`{"error": {"code": "Base.1.8.GeneralError", "message": "Failed"}}`))
+		if len(messages) != 1 {
+			t.Fatalf("messages = %d, want 1", len(messages))
+		}
+		if messages[0].MessageID != "Base.1.8.GeneralError" || messages[0].Message != "Failed" {
+			t.Errorf("message = %+v", messages[0])
+		}
+	})
+
+	t.Run("non-JSON body", func(t *testing.T) {
+		if messages := parseMessages([]byte("Internal Server Error")); len(messages) != 0 {
+			t.Errorf("messages = %v, want none", messages)
+		}
+	})
+}
+
+func TestClient_ErrorMessages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/redfish/v1/SessionService/Sessions" && r.Method == "POST" {
+			w.Header().Set("X-Auth-Token", "test-token-123")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"code": "Base.1.8.GeneralError", "message": "See ExtendedInfo",
+			"@Message.ExtendedInfo": [
+				{"MessageId": "Base.1.8.ActionParameterMissing", "Message": "ResetType is missing", "MessageSeverity": "Critical"}
+			]}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "admin", "pass", true)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	_, err = client.Fetch(context.Background(), "/redfish/v1/Systems/1")
+	httpErr, ok := err.(*HTTPError)
+	if !ok {
+		t.Fatalf("Fetch error = %v, want *HTTPError", err)
+	}
+	if httpErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", httpErr.StatusCode, http.StatusBadRequest)
+	}
+	if len(httpErr.Messages) != 1 || httpErr.Messages[0].MessageID != "Base.1.8.ActionParameterMissing" {
+		t.Fatalf("messages = %+v", httpErr.Messages)
+	}
+	if !strings.Contains(httpErr.Error(), "ResetType is missing") {
+		t.Errorf("error %q does not include message text", httpErr.Error())
+	}
+
+	resp, err := client.Post(context.Background(), "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset", []byte(`{}`))
+	if err != nil {
+		t.Fatalf("Post failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	if len(resp.Messages) != 1 || resp.Messages[0].Severity != "Critical" {
+		t.Errorf("messages = %+v", resp.Messages)
+	}
+}
+
+func TestStats_Summarize(t *testing.T) {
+	requests := []Request{
+		{Method: "GET", Path: "/redfish/v1/Systems/1", Status: 200, Bytes: 1000, Duration: 300 * time.Millisecond},
+		{Method: "GET", Path: "/redfish/v1/Systems/1", Cached: true},
+		{Method: "GET", Path: "/redfish/v1/Systems/1/Processors?$top=2", Status: 200, Bytes: 500, Duration: 100 * time.Millisecond},
+		{Method: "GET", Path: "/redfish/v1/Chassis/1", Status: 404, Duration: 50 * time.Millisecond},
+		{Method: "POST", Path: "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset", Status: 204, Duration: 800 * time.Millisecond},
+		{Method: "GET", Path: "/redfish/v1", Cached: true},
+	}
+
+	s := Summarize(requests)
+
+	if s.Requests != 4 || s.Hits != 2 || s.Misses != 3 || s.Errors != 1 {
+		t.Errorf("requests/hits/misses/errors = %d/%d/%d/%d, want 4/2/3/1", s.Requests, s.Hits, s.Misses, s.Errors)
+	}
+	if s.Bytes != 1500 {
+		t.Errorf("bytes = %d, want 1500", s.Bytes)
+	}
+	if s.HTTPTime != 1250*time.Millisecond {
+		t.Errorf("http time = %v, want 1.25s", s.HTTPTime)
+	}
+	if ratio := s.HitRatio(); ratio != 0.4 {
+		t.Errorf("hit ratio = %v, want 0.4", ratio)
+	}
+
+	if len(s.Endpoints) != 2 {
+		t.Fatalf("endpoints = %+v, want 2", s.Endpoints)
+	}
+	systems := s.Endpoints[0]
+	if systems.Endpoint != "/redfish/v1/Systems" || systems.Requests != 3 || systems.Max != 800*time.Millisecond {
+		t.Errorf("slowest endpoint = %+v", systems)
+	}
+	if systems.Average() != 400*time.Millisecond {
+		t.Errorf("average = %v, want 400ms", systems.Average())
+	}
+
+	if len(s.Slowest) != 4 || s.Slowest[0].Method != "POST" || s.Slowest[3].Path != "/redfish/v1/Chassis/1" {
+		t.Errorf("slowest = %+v", s.Slowest)
+	}
+}
+
+func TestResourceCache_Stats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/redfish/v1/SessionService/Sessions" && r.Method == "POST" {
+			w.Header().Set("X-Auth-Token", "test-token-123")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{}`))
+			return
+		}
+		if r.URL.Path == "/redfish/v1" {
+			w.Write(serviceRoot)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "admin", "pass", true)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	cache := NewResourceCache(client, NewParser(ParserOptions{}), "")
+
+	cache.Get(context.Background(), "/redfish/v1")
+	mark := cache.Stats().Len()
+	cache.Get(context.Background(), "/redfish/v1")
+	cache.Get(context.Background(), "/redfish/v1/Missing")
+
+	all, _ := cache.Stats().Since(0)
+	if len(all) != 3 {
+		t.Fatalf("recorded %d requests, want 3", len(all))
+	}
+	if all[0].Cached || all[0].Status != 200 || all[0].Bytes != len(serviceRoot) {
+		t.Errorf("first request = %+v", all[0])
+	}
+
+	since, _ := cache.Stats().Since(mark)
+	if len(since) != 2 {
+		t.Fatalf("since mark = %d requests, want 2", len(since))
+	}
+	if !since[0].Cached {
+		t.Errorf("second Get should be a cache hit: %+v", since[0])
+	}
+	if since[1].Cached || since[1].Status != http.StatusNotFound {
+		t.Errorf("missing resource = %+v, want status 404", since[1])
+	}
+
+	if s := cache.Stats().Summary(); s.Requests != 2 || s.Hits != 1 || s.Errors != 1 {
+		t.Errorf("summary = %+v", s)
+	}
+
+	cache.Stats().Reset()
+	if n := cache.Stats().Len(); n != 0 {
+		t.Errorf("after reset = %d requests, want 0", n)
+	}
+}
+
+// TestStats_Window tests that Stats keeps only the latest requests while
+// the summary counts them all, and that Since reports a mark dropped since
+func TestStats_Window(t *testing.T) {
+	var stats Stats
+	stats.record(Request{Method: "GET", Path: "/redfish/v1", Status: 200, Duration: time.Hour})
+	mark := stats.Len()
+	for i := range 2 * statsWindow {
+		stats.record(Request{Method: "GET", Path: fmt.Sprintf("/redfish/v1/Systems/%d", i), Status: 200, Duration: time.Duration(i)})
+	}
+
+	if n := stats.Len(); n != 2*statsWindow+1 {
+		t.Errorf("Len = %d, want %d", n, 2*statsWindow+1)
+	}
+	requests, dropped := stats.Since(mark)
+	if len(requests) != statsWindow || dropped != statsWindow {
+		t.Errorf("Since(mark) = %d requests, %d dropped, want %d and %d", len(requests), dropped, statsWindow, statsWindow)
+	}
+	if last := requests[len(requests)-1].Path; last != fmt.Sprintf("/redfish/v1/Systems/%d", 2*statsWindow-1) {
+		t.Errorf("latest request kept = %s", last)
+	}
+	mark = stats.Len()
+	stats.record(Request{Method: "GET", Path: "/redfish/v1/Chassis", Cached: true})
+	if requests, dropped := stats.Since(mark); len(requests) != 1 || dropped != 0 || requests[0].Path != "/redfish/v1/Chassis" {
+		t.Errorf("Since(latest mark) = %+v, %d dropped", requests, dropped)
+	}
+
+	s := stats.Summary()
+	if s.Requests != 2*statsWindow+1 || s.Hits != 1 {
+		t.Errorf("summary counts %d requests, %d hits", s.Requests, s.Hits)
+	}
+	if len(s.Slowest) != statsSlowest || s.Slowest[0].Path != "/redfish/v1" || s.Slowest[1].Duration != time.Duration(2*statsWindow-1) {
+		t.Errorf("summary keeps %d slowest, first %+v", len(s.Slowest), s.Slowest[:2])
+	}
+}
+
+func TestArrange(t *testing.T) {
+	entries := []*Entry{
+		{Name: "Id", Type: EntryProperty, Size: 3},
+		{Name: "Members@odata.count", Type: EntryProperty, Size: 1},
+		{Name: "Processors", Type: EntryLink},
+		{Name: "Chassis", Type: EntrySymlink, Size: 40},
+		{Name: "Status", Type: EntryComplex, Size: 50},
+		{Name: "BootOrder", Type: EntryArray, Size: 20},
+	}
+	names := func(entries []*Entry) string {
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.Name)
+		}
+		return strings.Join(out, " ")
+	}
+
+	tests := []struct {
+		name string
+		opts ListOptions
+		want string
+	}{
+		{"default hides annotations", ListOptions{}, "Id Processors Chassis Status BootOrder"},
+		{"all", ListOptions{All: true}, "Id Members@odata.count Processors Chassis Status BootOrder"},
+		{"by type", ListOptions{Sort: SortByType}, "Processors Chassis Status BootOrder Id"},
+		{"by size", ListOptions{Sort: SortBySize}, "Status Chassis BootOrder Id Processors"},
+		{"children", ListOptions{Kind: KindChildren}, "Processors"},
+		{"props", ListOptions{Kind: KindProperties}, "Id Status BootOrder"},
+		{"links", ListOptions{Kind: KindLinks}, "Chassis"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(Arrange(entries, tt.opts)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseListArgs(t *testing.T) {
+	opts, target, err := ParseListArgs([]string{"-t", "-a", "links", "Systems/1"})
+	if err != nil {
+		t.Fatalf("ParseListArgs: %v", err)
+	}
+	if opts.Sort != SortByType || !opts.All || opts.Kind != KindLinks || target != "Systems/1" {
+		t.Errorf("unexpected result: %+v %q", opts, target)
+	}
+
+	opts, target, err = ParseListArgs([]string{"-Sa"})
+	if err != nil || opts.Sort != SortBySize || !opts.All || target != "" {
+		t.Errorf("combined flags: %+v %q %v", opts, target, err)
+	}
+
+	if _, _, err := ParseListArgs([]string{"-x"}); err == nil {
+		t.Error("expected error for unknown flag")
+	}
+}
+
+func TestAnnotationEntries(t *testing.T) {
+	parser := NewParser(ParserOptions{})
+	resource, err := parser.Parse("/redfish/v1", serviceRoot)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	entries := AnnotationEntries(resource)
+	if len(entries) != 2 || entries[0].Name != "@odata.id" || entries[1].Name != "@odata.type" {
+		t.Fatalf("unexpected annotation entries: %+v", entries)
+	}
+	if entries[0].Path != "/redfish/v1/@odata.id" {
+		t.Errorf("unexpected path %q", entries[0].Path)
+	}
+}
+
+func TestHumanize(t *testing.T) {
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"CapacityBytes", float64(960197124096), "894.3 GiB"},
+		{"BlockSizeBytes", float64(512), "512 B"},
+		{"TotalSystemMemoryGiB", float64(1024), "1 TiB"},
+		{"MemorySizeMiB", float64(32768), "32 GiB"},
+		{"PowerOnHours", float64(12345), "1y 149d"},
+		{"SessionTimeoutSeconds", float64(90), "1m 30s"},
+		{"PredictedMediaLifeLeftPercent", float64(87.5), "87.5%"},
+		{"ReadingCelsius", float64(45), "45 °C"},
+		{"PowerConsumedWatts", float64(350), "350 W"},
+		{"ReadingVolts", float64(12.1), "12.1 V"},
+	}
+	for _, tt := range tests {
+		got, ok := Humanize(tt.name, tt.value, now)
+		if !ok || got != tt.want {
+			t.Errorf("Humanize(%s, %v) = %q, %v; want %q", tt.name, tt.value, got, ok, tt.want)
+		}
+	}
+
+	got, ok := Humanize("DateTime", "2024-03-01T12:00:00Z", now)
+	if !ok || !strings.HasSuffix(got, "(3d ago)") {
+		t.Errorf("timestamp humanized to %q, %v", got, ok)
+	}
+
+	for _, v := range []struct {
+		name  string
+		value any
+	}{
+		{"Id", "1"},
+		{"ProcessorCount", float64(2)},
+		{"CapacityBytes", "big"},
+		{"Enabled", true},
+	} {
+		if got, ok := Humanize(v.name, v.value, now); ok {
+			t.Errorf("Humanize(%s, %v) = %q; want no unit", v.name, v.value, got)
+		}
+	}
+}
+
+func TestVFS_ArraySlice(t *testing.T) {
+	cache := newMockCache()
+	cache.loadJSON("/redfish/v1", serviceRoot)
+	cache.loadJSON("/redfish/v1/Systems", systemsCollection)
+	cache.loadJSON("/redfish/v1/Systems/1", system1)
+	vfs := &vfs{cache: cache, root: DefaultRoot}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"Boot/BootOrder[1:3]", []string{"Hdd", "Usb"}},
+		{"Boot/BootOrder[1:]", []string{"Hdd", "Usb"}},
+		{"Boot/BootOrder[:1]", []string{"Pxe"}},
+		{"Boot/BootOrder[2:10]", []string{"Usb"}},
+		{"Boot/BootOrder[5:]", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			target, err := vfs.ResolveTarget("/redfish/v1/Systems/1", tt.path)
+			if err != nil {
+				t.Fatalf("ResolveTarget failed: %v", err)
+			}
+			prop := target.Property
+			if target.Type != TargetProperty || prop.Type != PropertyArray {
+				t.Fatalf("got %v/%v, want an array property", target.Type, prop.Type)
+			}
+			var got []string
+			for _, elem := range prop.Elements {
+				got = append(got, elem.Value.(string))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("elements = %v, want %v", got, tt.want)
+			}
+			var raw []string
+			if err := json.Unmarshal(prop.RawJSON, &raw); err != nil || len(raw) != len(tt.want) {
+				t.Errorf("RawJSON = %s (%v)", prop.RawJSON, err)
+			}
+		})
+	}
+
+	target, err := vfs.ResolveTarget("/redfish/v1/Systems/1", "Boot/BootOrder[1:]")
+	if err == nil && target.Property.Elements[0].Name != "[1]" {
+		t.Errorf("slice element name = %q, want [1]", target.Property.Elements[0].Name)
+	}
+
+	if _, err := vfs.ResolveTarget("/redfish/v1/Systems/1", "Boot/BootOrder[a:2]"); err == nil {
+		t.Error("expected error for invalid slice bound")
+	}
+}
+
+func TestClient_Bootstrap(t *testing.T) {
+	// A service that protects even the root, accepting only admin/pass
+	var logins int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/redfish/v1/SessionService/Sessions" && r.Method == "POST" {
+			logins++
+			var creds map[string]string
+			json.NewDecoder(r.Body).Decode(&creds)
+			if creds["UserName"] != "admin" || creds["Password"] != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("X-Auth-Token", "test-token-123")
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		if r.Header.Get("X-Auth-Token") != "test-token-123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write(serviceRoot)
+	}))
+	defer server.Close()
+
+	t.Run("session created on first 401", func(t *testing.T) {
+		logins = 0
+		client, err := NewClient(server.URL, "admin", "pass", true)
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		if logins != 0 {
+			t.Errorf("NewClient logged in %d times, want 0", logins)
+		}
+		if _, err := client.Fetch(context.Background(), "/redfish/v1"); err != nil {
+			t.Fatalf("Fetch failed: %v", err)
+		}
+		if _, err := client.Fetch(context.Background(), "/redfish/v1"); err != nil {
+			t.Fatalf("second Fetch failed: %v", err)
+		}
+		if logins != 1 {
+			t.Errorf("logged in %d times, want 1", logins)
+		}
+	})
+
+	t.Run("bad credentials", func(t *testing.T) {
+		client, err := NewClient(server.URL, "admin", "wrong", true)
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		_, err = client.Fetch(context.Background(), "/redfish/v1")
+		var authErr *AuthError
+		if !errors.As(err, &authErr) {
+			t.Fatalf("Fetch error = %v, want *AuthError", err)
+		}
+		if authErr.User != "admin" || authErr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("auth error = %+v", authErr)
+		}
+	})
+
+	t.Run("unreachable host", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		url := closed.URL
+		closed.Close()
+
+		_, err := NewClient(url, "admin", "pass", true)
+		var unreachable *UnreachableError
+		if !errors.As(err, &unreachable) {
+			t.Fatalf("NewClient error = %v, want *UnreachableError", err)
+		}
+	})
+
+	t.Run("not a Redfish service", func(t *testing.T) {
+		other := httptest.NewServer(http.NotFoundHandler())
+		defer other.Close()
+
+		_, err := NewClient(other.URL, "admin", "pass", true)
+		if err == nil || !strings.Contains(err.Error(), "no Redfish service") {
+			t.Errorf("NewClient error = %v, want no Redfish service", err)
+		}
+	})
+
+	t.Run("endpoint without scheme", func(t *testing.T) {
+		if _, err := NewClient("10.0.0.1", "admin", "pass", true); err == nil {
+			t.Error("expected error for endpoint without scheme")
+		}
+	})
+}
+
+func TestQuirks(t *testing.T) {
+	// A vendor service serving JSON as text/plain
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		switch r.URL.Path {
+		case "/redfish/v1":
+			w.Write([]byte(`{"@odata.id": "/redfish/v1", "Vendor": "Contoso",
+				"Managers": {"@odata.id": "/redfish/v1/Managers"}}`))
+		case "/redfish/v1/Managers":
+			w.Write([]byte(`{"Members": [{"@odata.id": "/redfish/v1/Managers/BMC"}]}`))
+		case "/redfish/v1/Managers/BMC":
+			w.Write([]byte(`{"FirmwareVersion": "2.10.4"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "admin", "pass", true)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	t.Run("content type", func(t *testing.T) {
+		client.quirks = nil
+		_, err := client.Fetch(context.Background(), "/redfish/v1")
+		var ctErr *ContentTypeError
+		if !errors.As(err, &ctErr) {
+			t.Fatalf("Fetch error = %v, want *ContentTypeError", err)
+		}
+
+		client.quirks = QuirkSet{QuirkTextPlainJSON: true}
+		if _, err := client.Fetch(context.Background(), "/redfish/v1"); err != nil {
+			t.Errorf("Fetch with text_plain_json failed: %v", err)
+		}
+
+		if jsonContentType("text/html", QuirkSet{QuirkTextPlainJSON: true}) {
+			t.Error("text/html accepted as JSON")
+		}
+		if !jsonContentType("application/json; charset=utf-8", nil) {
+			t.Error("application/json rejected")
+		}
+	})
+
+	t.Run("detection", func(t *testing.T) {
+		tests := []struct {
+			name string
+			opts QuirkOptions
+			want []Quirk
+		}{
+			{"no rules", QuirkOptions{}, nil},
+			{"vendor rule", QuirkOptions{Rules: []QuirkRule{
+				{Vendor: "contoso", Quirks: []Quirk{QuirkTextPlainJSON}},
+			}}, []Quirk{QuirkTextPlainJSON}},
+			{"other vendor", QuirkOptions{Rules: []QuirkRule{
+				{Vendor: "Fabrikam", Quirks: []Quirk{QuirkTextPlainJSON}},
+			}}, nil},
+			{"firmware rule", QuirkOptions{Rules: []QuirkRule{
+				{Vendor: "Contoso", Firmware: "2.", Quirks: []Quirk{QuirkTextPlainJSON}},
+				{Vendor: "Contoso", Firmware: "1.", Quirks: []Quirk{QuirkUndecoratedActions}},
+			}}, []Quirk{QuirkTextPlainJSON}},
+			{"enable and disable", QuirkOptions{
+				Enable:  []Quirk{QuirkUndecoratedActions},
+				Disable: []Quirk{QuirkTextPlainJSON},
+				Rules: []QuirkRule{
+					{Vendor: "Contoso", Quirks: []Quirk{QuirkTextPlainJSON}},
+				},
+			}, []Quirk{QuirkUndecoratedActions}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				set, err := detectQuirks(client, tt.opts)
+				if err != nil {
+					t.Fatalf("detectQuirks failed: %v", err)
+				}
+				if got := set.List(); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+					t.Errorf("quirks = %v, want %v", got, tt.want)
+				}
+			})
+		}
+
+		if _, err := detectQuirks(client, QuirkOptions{Enable: []Quirk{"bogus"}}); err == nil {
+			t.Error("expected error for unknown quirk")
+		}
+	})
+
+	t.Run("parser", func(t *testing.T) {
+		data := []byte(`{
+			"Id": "1",
+			"Actions": {
+				"ComputerSystem.Reset": {"target": "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset"},
+				"Oem": {}
+			}
+		}`)
+
+		parser := NewParser(ParserOptions{})
+		res, err := parser.Parse("/redfish/v1/Systems/1", data)
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if _, ok := res.Properties["Actions"].Children["ComputerSystem.Reset"]; !ok {
+			t.Error("action renamed without quirk")
+		}
+
+		parser.quirks = QuirkSet{QuirkUndecoratedActions: true}
+		res, err = parser.Parse("/redfish/v1/Systems/1", data)
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		actions := res.Properties["Actions"].Children
+		if reset, ok := actions["#ComputerSystem.Reset"]; !ok || reset.Name != "#ComputerSystem.Reset" {
+			t.Errorf("actions = %v, want #ComputerSystem.Reset", actions)
+		}
+		if _, ok := actions["Oem"]; !ok {
+			t.Error("Oem action group renamed")
+		}
+	})
+}
+
+func TestOriginOfCondition(t *testing.T) {
+	parser := NewParser(ParserOptions{})
+	parse := func(path, data string) *Resource {
+		t.Helper()
+		res, err := parser.Parse(path, []byte(data))
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		return res
+	}
+	const cpu = "/redfish/v1/Systems/1/Processors/CPU1"
+
+	// LogEntry: reference under Links
+	entry := parse("/redfish/v1/Systems/1/LogServices/Log/Entries/7", `{
+		"@odata.id": "/redfish/v1/Systems/1/LogServices/Log/Entries/7",
+		"Message": "CPU1 is too hot",
+		"Links": {"OriginOfCondition": {"@odata.id": "`+cpu+`"}}
+	}`)
+	if got := OriginOfCondition(&Target{Type: TargetResource, Resource: entry}); got != cpu {
+		t.Errorf("log entry origin = %q, want %q", got, cpu)
+	}
+
+	// Top-level link object, parsed as a child
+	entry = parse("/redfish/v1/Systems/1/LogServices/Log/Entries/8", `{
+		"@odata.id": "/redfish/v1/Systems/1/LogServices/Log/Entries/8",
+		"OriginOfCondition": {"@odata.id": "`+cpu+`"}
+	}`)
+	if got := OriginOfCondition(&Target{Type: TargetResource, Resource: entry}); got != cpu {
+		t.Errorf("top-level origin = %q, want %q", got, cpu)
+	}
+
+	// Event records: link objects and bare path strings
+	event := parse("/redfish/v1/EventService/Events/1", `{
+		"@odata.id": "/redfish/v1/EventService/Events/1",
+		"Events": [
+			{"EventType": "Alert", "OriginOfCondition": {"@odata.id": "`+cpu+`"}},
+			{"EventType": "Alert", "OriginOfCondition": "/redfish/v1/Chassis/1"},
+			{"EventType": "Alert", "OriginOfCondition": "not a path"}
+		]
+	}`)
+	records := event.Properties["Events"].Elements
+	for i, want := range []string{cpu, "/redfish/v1/Chassis/1", ""} {
+		if got := OriginOfCondition(&Target{Type: TargetProperty, Property: records[i]}); got != want {
+			t.Errorf("event record %d origin = %q, want %q", i, got, want)
+		}
+	}
+
+	// Nothing to follow
+	if got := OriginOfCondition(&Target{Type: TargetResource, Resource: event}); got != "" {
+		t.Errorf("event origin = %q, want none", got)
+	}
+	if got := OriginOfCondition(&Target{Type: TargetProperty, Property: records[0].Children["EventType"]}); got != "" {
+		t.Errorf("simple property origin = %q, want none", got)
+	}
+}
+
+// TestGlob tests wildcard expansion over the cached tree
+func TestGlob(t *testing.T) {
+	cache := newMockCache()
+	cache.loadJSON("/redfish/v1", serviceRoot)
+	cache.loadJSON("/redfish/v1/Systems", systemsCollection)
+	cache.loadJSON("/redfish/v1/Systems/1", system1)
+
+	vfs := &vfs{cache: cache, root: DefaultRoot}
+
+	tests := []struct {
+		base    string
+		pattern string
+		want    []string
+	}{
+		{"/redfish/v1", "Systems/*", []string{"/redfish/v1/Systems/1"}},
+		{"/redfish/v1/Systems", "*", []string{"/redfish/v1/Systems/1"}},
+		{"/redfish/v1", "/redfish/v1/S*", []string{"/redfish/v1/Systems"}},
+		{"/redfish/v1", "Systems/1/Actions", []string{"/redfish/v1/Systems/1/Actions"}},
+		{"/redfish/v1", "Systems/9*", nil},
+	}
+	for _, tt := range tests {
+		got, err := Glob(vfs, tt.base, tt.pattern)
+		if err != nil {
+			t.Errorf("Glob(%q, %q) failed: %v", tt.base, tt.pattern, err)
+			continue
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("Glob(%q, %q) = %v, want %v", tt.base, tt.pattern, got, tt.want)
+		}
+	}
+
+	if _, err := Glob(vfs, "/redfish/v1", "Systems/[*"); err == nil {
+		t.Error("Glob with a malformed pattern succeeded, want error")
+	}
+}
+
+// postCache records the POSTs of a bulk operation
+type postCache struct {
+	*mockCache
+	mu      sync.Mutex
+	running int
+	peak    int
+	posts   int
+}
+
+func (c *postCache) Post(ctx context.Context, path string, body []byte) (*Response, error) {
+	c.mu.Lock()
+	c.posts++
+	c.running++
+	c.peak = max(c.peak, c.running)
+	c.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	c.mu.Lock()
+	c.running--
+	c.mu.Unlock()
+	if strings.HasSuffix(path, "/3") {
+		return nil, &HTTPError{StatusCode: http.StatusBadRequest, Path: path}
+	}
+	return &Response{StatusCode: http.StatusNoContent}, nil
+}
+
+// TestPostAll tests that bulk POSTs keep their order and concurrency cap,
+// and that a bulk POST repeated at once is held back
+func TestPostAll(t *testing.T) {
+	cache := &postCache{mockCache: newMockCache()}
+	vfs := &vfs{cache: cache, root: DefaultRoot}
+
+	var paths []string
+	for i := range 10 {
+		paths = append(paths, fmt.Sprintf("/redfish/v1/Systems/%d", i))
+	}
+	var g PostGuard
+	results := g.PostAll(vfs, paths, []byte(`{}`), 3, false)
+
+	if len(results) != len(paths) {
+		t.Fatalf("got %d results, want %d", len(results), len(paths))
+	}
+	for i, r := range results {
+		if r.Path != paths[i] {
+			t.Errorf("results[%d].Path = %q, want %q", i, r.Path, paths[i])
+		}
+		if (r.Err != nil) != (i == 3) {
+			t.Errorf("results[%d].Err = %v", i, r.Err)
+		}
+	}
+	if cache.peak > 3 {
+		t.Errorf("peak concurrency = %d, want at most 3", cache.peak)
+	}
+
+	var repeat *RepeatError
+	for i, r := range g.PostAll(vfs, paths, []byte(`{}`), 3, false) {
+		if !errors.As(r.Err, &repeat) || repeat.Target != paths[i] {
+			t.Errorf("repeated results[%d].Err = %v, want a RepeatError", i, r.Err)
+		}
+	}
+	if cache.posts != len(paths) {
+		t.Errorf("%d POSTs sent after a repeat, want %d", cache.posts, len(paths))
+	}
+	for i, r := range g.PostAll(vfs, paths, []byte(`{}`), 3, true) {
+		if (r.Err != nil) != (i == 3) {
+			t.Errorf("results[%d].Err sent again = %v", i, r.Err)
+		}
+	}
+	if cache.posts != 2*len(paths) {
+		t.Errorf("%d POSTs sent again, want %d", cache.posts, 2*len(paths))
+	}
+
+	// Of the same POST sent twice at once, one is held back
+	var once PostGuard
+	twice := []string{"/redfish/v1/Systems/5", "/redfish/v1/Systems/5"}
+	results = once.PostAll(vfs, twice, []byte(`{}`), 2, false)
+	if (results[0].Err == nil) == (results[1].Err == nil) || cache.posts != 2*len(paths)+1 {
+		t.Errorf("same POST twice at once = %v, %v after %d POSTs", results[0].Err, results[1].Err, cache.posts-2*len(paths))
+	}
+}
+
+// TestCreateCapabilities tests reading create fields from capabilities
+func TestCreateCapabilities(t *testing.T) {
+	cache := newMockCache()
+	cache.loadJSON("/redfish/v1/Systems/1/Storage/1/Volumes", []byte(`{
+		"@odata.id": "/redfish/v1/Systems/1/Storage/1/Volumes",
+		"Members": [],
+		"@Redfish.CollectionCapabilities": {
+			"Capabilities": [{
+				"CapabilitiesObject": {"@odata.id": "/redfish/v1/Systems/1/Storage/1/Volumes/Capabilities"},
+				"Links": {"TargetCollection": {"@odata.id": "/redfish/v1/Systems/1/Storage/1/Volumes"}},
+				"UseCase": "VolumeCreation"
+			}]
+		}
+	}`))
+	cache.loadJSON("/redfish/v1/Systems/1/Storage/1/Volumes/Capabilities", []byte(`{
+		"@odata.id": "/redfish/v1/Systems/1/Storage/1/Volumes/Capabilities",
+		"Name": "Capabilities for the volume collection",
+		"Name@Redfish.OptionalOnCreate": true,
+		"RAIDType@Redfish.RequiredOnCreate": true,
+		"RAIDType@Redfish.AllowableValues": ["RAID0", "RAID1"],
+		"CapacityBytes@Redfish.RequiredOnCreate": true,
+		"Encrypted@Redfish.OptionalOnCreate": false,
+		"Links": {
+			"Drives@Redfish.RequiredOnCreate": true
+		}
+	}`))
+	cache.loadJSON("/redfish/v1/EventService/Subscriptions", []byte(`{
+		"@odata.id": "/redfish/v1/EventService/Subscriptions",
+		"Members": []
+	}`))
+
+	vfs := &vfs{cache: cache, root: DefaultRoot}
+
+	capabilities, err := CreateCapabilities(vfs, "/redfish/v1/Systems/1/Storage/1/Volumes")
+	if err != nil {
+		t.Fatalf("CreateCapabilities failed: %v", err)
+	}
+	if len(capabilities) != 1 {
+		t.Fatalf("got %d capabilities, want 1", len(capabilities))
+	}
+	c := capabilities[0]
+	if c.UseCase != "VolumeCreation" || c.TargetCollection != "/redfish/v1/Systems/1/Storage/1/Volumes" {
+		t.Errorf("capability = %+v", c)
+	}
+	var names []string
+	for _, f := range c.Fields {
+		names = append(names, fmt.Sprintf("%s:%v", f.Name, f.Required))
+	}
+	if got, want := strings.Join(names, " "), "CapacityBytes:true Links.Drives:true RAIDType:true Name:false"; got != want {
+		t.Errorf("fields = %s, want %s", got, want)
+	}
+	if raid := c.Fields[2]; strings.Join(raid.Allowable, ",") != "RAID0,RAID1" {
+		t.Errorf("RAIDType allowable = %v", raid.Allowable)
+	}
+
+	capabilities, err = CreateCapabilities(vfs, "/redfish/v1/EventService/Subscriptions")
+	if err != nil || capabilities != nil {
+		t.Errorf("collection without capabilities = %v, %v", capabilities, err)
+	}
+}
+
+// TestCreateBody tests building a create body from entered fields
+func TestCreateBody(t *testing.T) {
+	body, err := CreateBody(map[string]string{
+		"Destination":   "https://collector.example.com/events",
+		"Context":       "rack 12",
+		"CapacityBytes": "1073741824",
+		"Enabled":       "true",
+		"Id":            `"42"`,
+		"EventTypes":    `["Alert"]`,
+		"Links.Drives":  `[{"@odata.id": "/redfish/v1/Chassis/1/Drives/0"}]`,
+	})
+	if err != nil {
+		t.Fatalf("CreateBody failed: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("body is not JSON: %v", err)
+	}
+	if got["Context"] != "rack 12" || got["CapacityBytes"] != float64(1073741824) || got["Enabled"] != true || got["Id"] != "42" {
+		t.Errorf("body = %s", body)
+	}
+	if types, ok := got["EventTypes"].([]any); !ok || len(types) != 1 {
+		t.Errorf("EventTypes = %v", got["EventTypes"])
+	}
+	if links, ok := got["Links"].(map[string]any); !ok || links["Drives"] == nil {
+		t.Errorf("Links = %v", got["Links"])
+	}
+
+	if _, err := CreateBody(map[string]string{"Links": "x", "Links.Drives": "[]"}); err == nil {
+		t.Error("CreateBody with conflicting fields succeeded, want error")
+	}
+}
+
+// TestCreatedPath tests locating the resource a create made
+func TestCreatedPath(t *testing.T) {
+	tests := []struct {
+		resp *Response
+		want string
+	}{
+		{&Response{Location: locationPath("https://bmc.example.com/redfish/v1/SessionService/Sessions/7")}, "/redfish/v1/SessionService/Sessions/7"},
+		{&Response{Location: locationPath("/redfish/v1/EventService/Subscriptions/1")}, "/redfish/v1/EventService/Subscriptions/1"},
+		{&Response{Body: []byte(`{"@odata.id": "/redfish/v1/Systems/1/Storage/1/Volumes/2"}`)}, "/redfish/v1/Systems/1/Storage/1/Volumes/2"},
+		{&Response{}, ""},
+	}
+	for _, tt := range tests {
+		if got := CreatedPath(tt.resp); got != tt.want {
+			t.Errorf("CreatedPath = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+// TestParser_CollectionCapabilities tests parsing @Redfish.CollectionCapabilities
+func TestParser_CollectionCapabilities(t *testing.T) {
+	parser := NewParser(ParserOptions{})
+	resource, err := parser.Parse("/redfish/v1/EventService/Subscriptions", []byte(`{
+		"@odata.id": "/redfish/v1/EventService/Subscriptions",
+		"Members": [
+			{"@odata.id": "/redfish/v1/EventService/Subscriptions/1"},
+			{"@odata.id": "/redfish/v1/EventService/Subscriptions/2"}
+		],
+		"@Redfish.CollectionCapabilities": {
+			"MaxMembers": 2,
+			"Capabilities": [
+				{"CapabilitiesObject": {"@odata.id": "/redfish/v1/EventService/SubscriptionCapabilities"}, "UseCase": "EventSubscription"},
+				{"UseCase": "RedirectedSubscription", "Links": {"TargetCollection": {"@odata.id": "/redfish/v1/EventService/Redirected"}}}
+			]
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	cc := resource.Capabilities
+	if cc == nil {
+		t.Fatal("Capabilities not parsed")
+	}
+	if cc.MaxMembers != 2 || len(cc.Capabilities) != 2 {
+		t.Fatalf("Capabilities = %+v", cc)
+	}
+	want := []Capability{
+		{UseCase: "EventSubscription", CapabilitiesObject: "/redfish/v1/EventService/SubscriptionCapabilities", TargetCollection: "/redfish/v1/EventService/Subscriptions"},
+		{UseCase: "RedirectedSubscription", TargetCollection: "/redfish/v1/EventService/Redirected"},
+	}
+	for i, c := range cc.Capabilities {
+		if c != want[i] {
+			t.Errorf("Capabilities[%d] = %+v, want %+v", i, c, want[i])
+		}
+	}
+	if got := MemberCount(resource); got != 2 {
+		t.Errorf("MemberCount = %d, want 2", got)
+	}
+
+	// The collection is full: creating is refused before any POST
+	cache := newMockCache()
+	cache.resources[resource.Path] = resource
+	if _, err := CreateCapabilities(&vfs{cache: cache, root: DefaultRoot}, resource.Path); err == nil || !strings.Contains(err.Error(), "full (2 of 2 members)") {
+		t.Errorf("CreateCapabilities on a full collection = %v, want full error", err)
+	}
+
+	plain, _ := parser.Parse("/redfish/v1/Systems", systemsCollection)
+	if plain.Capabilities != nil {
+		t.Errorf("Capabilities without annotation = %+v, want nil", plain.Capabilities)
+	}
+}
+
+// TestCheckFields tests client-side validation of create fields
+func TestCheckFields(t *testing.T) {
+	fields := []CreateField{
+		{Name: "RAIDType", Required: true, Allowable: []string{"RAID0", "RAID1"}},
+		{Name: "CapacityBytes", Required: true},
+		{Name: "Name"},
+	}
+	tests := []struct {
+		values map[string]string
+		err    string
+	}{
+		{map[string]string{"RAIDType": "RAID1", "CapacityBytes": "1024"}, ""},
+		{map[string]string{"RAIDType": "RAID1", "CapacityBytes": "1024", "Name": "x", "Extra": "y"}, ""},
+		{map[string]string{"Name": "x"}, "missing required RAIDType, CapacityBytes"},
+		{map[string]string{"RAIDType": "RAID5", "CapacityBytes": "1024"}, `invalid value "RAID5" for RAIDType (allowed: RAID0, RAID1)`},
+	}
+	for _, tt := range tests {
+		err := CheckFields(fields, tt.values)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.err {
+			t.Errorf("CheckFields(%v) = %q, want %q", tt.values, got, tt.err)
+		}
+	}
+}
+
+func TestFindProperties(t *testing.T) {
+	parser := NewParser(ParserOptions{})
+	res, err := parser.Parse("/redfish/v1/Systems/1", system1)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	matches := FindProperties(res, regexp.MustCompile("(?i)^(health|state)$"))
+	var got []string
+	for _, m := range matches {
+		if m.Resource != "/redfish/v1/Systems/1" {
+			t.Errorf("match %s in %s", m.Property, m.Resource)
+		}
+		got = append(got, fmt.Sprintf("%s=%v", m.Property, m.Value.Value))
+	}
+	want := []string{"Status/Health=OK", "Status/State=Enabled"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("FindProperties = %v, want %v", got, want)
+	}
+
+	if matches := FindProperties(res, regexp.MustCompile("NoSuchProperty")); len(matches) != 0 {
+		t.Errorf("expected no matches, got %d", len(matches))
+	}
+}
+
+func TestCacheSearch(t *testing.T) {
+	parser := NewParser(ParserOptions{})
+	parse := func(path string, data []byte) *Resource {
+		t.Helper()
+		res, err := parser.Parse(path, data)
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		return res
+	}
+	paths := func(resources []*Resource) string {
+		var p []string
+		for _, r := range resources {
+			p = append(p, r.Path)
+		}
+		sort.Strings(p)
+		return strings.Join(p, " ")
+	}
+
+	cache := NewResourceCache(nil, parser, "")
+	cache.Put(parse("/redfish/v1", serviceRoot))
+	cache.Put(parse("/redfish/v1/Systems/1", system1))
+
+	if got := paths(cache.SearchNames(regexp.MustCompile("(?i)^health$"))); got != "/redfish/v1/Systems/1" {
+		t.Errorf("SearchNames(health) = %q", got)
+	}
+	// Value tokens match by substring, every token must be held
+	if got := paths(cache.SearchValues("enab")); got != "/redfish/v1/Systems/1" {
+		t.Errorf("SearchValues(enab) = %q", got)
+	}
+	if got := paths(cache.SearchValues("2.1.0 pxe")); got != "/redfish/v1/Systems/1" {
+		t.Errorf("SearchValues(2.1.0 pxe) = %q", got)
+	}
+	if got := paths(cache.SearchValues("enabled nosuchvalue")); got != "" {
+		t.Errorf("SearchValues(enabled nosuchvalue) = %q", got)
+	}
+
+	// The index follows the cache once built
+	cache.Invalidate("/redfish/v1/Systems/1")
+	if got := paths(cache.SearchNames(regexp.MustCompile("(?i)^health$"))); got != "" {
+		t.Errorf("after Invalidate, SearchNames(health) = %q", got)
+	}
+	cache.Put(parse("/redfish/v1/Systems/1", system1))
+	if got := paths(cache.SearchNames(regexp.MustCompile("(?i)^health$"))); got != "/redfish/v1/Systems/1" {
+		t.Errorf("after Put, SearchNames(health) = %q", got)
+	}
+
+	// Clear drops the index; it is rebuilt from what is cached next
+	cache.Clear()
+	if cache.index != nil {
+		t.Error("Clear kept the index")
+	}
+	cache.Put(parse("/redfish/v1", serviceRoot))
+	if got := paths(cache.SearchNames(regexp.MustCompile("(?i)^health$"))); got != "" {
+		t.Errorf("after Clear, SearchNames(health) = %q", got)
+	}
+	if got := paths(cache.SearchNames(regexp.MustCompile("^Systems$|^Name$"))); got != "/redfish/v1" {
+		t.Errorf("after Clear, SearchNames(Name) = %q", got)
+	}
+}
+
+func TestVFS_SearchCached(t *testing.T) {
+	cache := newMockCache()
+	cache.loadJSON("/redfish/v1", serviceRoot)
+	cache.loadJSON("/redfish/v1/Systems", systemsCollection)
+	cache.loadJSON("/redfish/v1/Systems/1", system1)
+	vfs := &vfs{cache: cache, root: DefaultRoot}
+
+	format := func(matches []Match) string {
+		var got []string
+		for _, m := range matches {
+			got = append(got, m.Resource+" "+m.Property)
+		}
+		return strings.Join(got, ", ")
+	}
+
+	if got, want := format(vfs.FindCached(DefaultRoot, regexp.MustCompile("^Name$"))),
+		"/redfish/v1 Name, /redfish/v1/Systems Name, /redfish/v1/Systems/1 Name"; got != want {
+		t.Errorf("FindCached(Name) = %q, want %q", got, want)
+	}
+	if got, want := format(vfs.FindCached("/redfish/v1/Systems/1", regexp.MustCompile("^Name$"))),
+		"/redfish/v1/Systems/1 Name"; got != want {
+		t.Errorf("FindCached under Systems/1 = %q, want %q", got, want)
+	}
+	if got, want := format(vfs.GrepCached("/redfish/v1/Systems", "hdd")),
+		"/redfish/v1/Systems/1 Boot/BootOrder[1]"; got != want {
+		t.Errorf("GrepCached(hdd) = %q, want %q", got, want)
+	}
+	if got := format(vfs.GrepCached(DefaultRoot, "nosuchvalue")); got != "" {
+		t.Errorf("GrepCached(nosuchvalue) = %q", got)
+	}
+}
+
+func TestSummarizeService(t *testing.T) {
+	cache := newMockCache()
+	cache.loadJSON("/redfish/v1", []byte(`{
+		"@odata.id": "/redfish/v1",
+		"RedfishVersion": "1.15.0",
+		"Systems": {"@odata.id": "/redfish/v1/Systems"},
+		"Chassis": {"@odata.id": "/redfish/v1/Chassis"},
+		"Managers": {"@odata.id": "/redfish/v1/Managers"}
+	}`))
+	cache.loadJSON("/redfish/v1/Systems", systemsCollection)
+	cache.loadJSON("/redfish/v1/Systems/1", []byte(`{
+		"@odata.id": "/redfish/v1/Systems/1",
+		"Manufacturer": "Contoso",
+		"Model": "3500",
+		"BiosVersion": "2.1.0",
+		"Status": {"State": "Enabled", "Health": "OK", "HealthRollup": "OK"}
+	}`))
+	cache.loadJSON("/redfish/v1/Chassis", []byte(`{
+		"@odata.id": "/redfish/v1/Chassis",
+		"Members": [{"@odata.id": "/redfish/v1/Chassis/1"}, {"@odata.id": "/redfish/v1/Chassis/2"}],
+		"Members@odata.count": 2
+	}`))
+	cache.loadJSON("/redfish/v1/Managers", []byte(`{
+		"@odata.id": "/redfish/v1/Managers",
+		"Members": [{"@odata.id": "/redfish/v1/Managers/BMC"}, {"@odata.id": "/redfish/v1/Managers/Gone"}]
+	}`))
+	cache.loadJSON("/redfish/v1/Managers/BMC", []byte(`{
+		"@odata.id": "/redfish/v1/Managers/BMC",
+		"FirmwareVersion": "1.4.2",
+		"Status": {"State": "Enabled", "Health": "Warning"}
+	}`))
+
+	s, err := SummarizeService(&vfs{cache: cache, root: DefaultRoot})
+	if err != nil {
+		t.Fatalf("SummarizeService failed: %v", err)
+	}
+	if s.Vendor != "Contoso" || s.Model != "3500" || s.RedfishVersion != "1.15.0" {
+		t.Errorf("identity = %q %q %q", s.Vendor, s.Model, s.RedfishVersion)
+	}
+	if s.Systems != 1 || s.Chassis != 2 || s.Managers != 2 {
+		t.Errorf("counts = %d systems, %d chassis, %d managers", s.Systems, s.Chassis, s.Managers)
+	}
+	if s.Health != "Warning" {
+		t.Errorf("Health = %q, want the manager's Warning", s.Health)
+	}
+	want := []Firmware{
+		{Resource: "/redfish/v1/Managers/BMC", Kind: "BMC", Version: "1.4.2"},
+		{Resource: "/redfish/v1/Systems/1", Kind: "BIOS", Version: "2.1.0"},
+	}
+	if len(s.Firmware) != len(want) || s.Firmware[0] != want[0] || s.Firmware[1] != want[1] {
+		t.Errorf("Firmware = %+v, want %+v", s.Firmware, want)
+	}
+	if s.Auth != AuthNone {
+		t.Errorf("Auth = %q", s.Auth)
+	}
+
+	// A service root without collections still summarizes
+	bare := newMockCache()
+	bare.loadJSON("/redfish/v1", serviceRoot)
+	s, err = SummarizeService(&vfs{cache: bare, root: DefaultRoot})
+	if err != nil {
+		t.Fatalf("SummarizeService failed: %v", err)
+	}
+	if s.Systems != -1 || s.Managers != -1 || s.Health != "" {
+		t.Errorf("bare summary = %+v", s)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	const answer = "HTTP/1.1 200 OK\r\n" +
+		"CACHE-CONTROL: max-age=1800\r\n" +
+		"ST: urn:dmtf-org:service:redfish-rest:1:15\r\n" +
+		"USN: uuid:92384634-2938-2342-8820-489239905423::urn:dmtf-org:service:redfish-rest:1:15\r\n" +
+		"AL: https://10.0.0.7/redfish/v1/\r\n" +
+		"EXT:\r\n\r\n"
+
+	// A fake service answers every search, twice, with one unrelated answer
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP failed: %v", err)
+	}
+	defer conn.Close()
+	searches := make(chan string, 1)
+	go func() {
+		buf := make([]byte, 2048)
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		searches <- string(buf[:n])
+		conn.WriteTo([]byte(answer), from)
+		conn.WriteTo([]byte(answer), from)
+		conn.WriteTo([]byte("HTTP/1.1 200 OK\r\nST: upnp:rootdevice\r\nAL: http://10.0.0.9/\r\n\r\n"), from)
+	}()
+
+	services, err := discover(conn.LocalAddr().String(), 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("discover failed: %v", err)
+	}
+	search := <-searches
+	if !strings.HasPrefix(search, "M-SEARCH * HTTP/1.1\r\n") || !strings.Contains(search, "ST: urn:dmtf-org:service:redfish-rest:1\r\n") {
+		t.Errorf("search = %q", search)
+	}
+	want := DiscoveredService{
+		Endpoint:    "https://10.0.0.7",
+		ServiceRoot: "https://10.0.0.7/redfish/v1/",
+		UUID:        "92384634-2938-2342-8820-489239905423",
+	}
+	if len(services) != 1 || services[0] != want {
+		t.Errorf("services = %+v, want [%+v]", services, want)
+	}
+
+	if _, ok := parseSSDPResponse([]byte("HTTP/1.1 200 OK\r\nST: urn:dmtf-org:service:redfish-rest:1\r\n\r\n")); ok {
+		t.Error("accepted an answer without AL")
+	}
+}
+
+func TestCassette(t *testing.T) {
+	t.Chdir(t.TempDir())
+	file := filepath.Join(t.TempDir(), "service.json")
+
+	// A service behind session auth, recorded through a VFS
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/redfish/v1/SessionService/Sessions" && r.Method == "POST" {
+			w.Header().Set("X-Auth-Token", "secret-token")
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		if r.Header.Get("X-Auth-Token") != "secret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/redfish/v1":
+			w.Write([]byte(`{"@odata.id": "/redfish/v1", "Systems": {"@odata.id": "/redfish/v1/Systems"}}`))
+		case "/redfish/v1/Systems":
+			w.Write([]byte(`{"@odata.id": "/redfish/v1/Systems", "Members": [{"@odata.id": "/redfish/v1/Systems/1"}]}`))
+		case "/redfish/v1/Systems/1":
+			w.Write([]byte(`{"@odata.id": "/redfish/v1/Systems/1", "Status": {"Health": "OK"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	walk := func(v VFS) {
+		t.Helper()
+		target, err := v.ResolveTarget(DefaultRoot, "Systems/1/Status/Health")
+		if err != nil {
+			t.Fatalf("ResolveTarget failed: %v", err)
+		}
+		if target.Property == nil || target.Property.Value != "OK" {
+			t.Errorf("Health = %+v, want OK", target.Property)
+		}
+	}
+
+	recorder := RecordCassette(file, NewTransport(true))
+	v, err := NewVFS(server.URL, "admin", "pass", true, Options{Transport: recorder})
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+	walk(v)
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	server.Close()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	for _, secret := range []string{`"pass"`, "secret-token"} {
+		if bytes.Contains(data, []byte(secret)) {
+			t.Errorf("cassette contains %s", secret)
+		}
+	}
+
+	// Replayed with the service gone
+	player, err := LoadCassette(file)
+	if err != nil {
+		t.Fatalf("LoadCassette failed: %v", err)
+	}
+	if len(player.Interactions()) != len(recorder.Interactions()) {
+		t.Errorf("loaded %d interactions, recorded %d", len(player.Interactions()), len(recorder.Interactions()))
+	}
+	v, err = NewVFS(server.URL, "admin", "pass", true, Options{Transport: player})
+	if err != nil {
+		t.Fatalf("NewVFS from cassette failed: %v", err)
+	}
+	walk(v)
+	v.Invalidate("/redfish/v1/Systems/1")
+	walk(v)
+
+	if _, err := v.Get("/redfish/v1/Chassis"); err == nil {
+		t.Error("expected error for a request the cassette did not record")
+	}
+}
+
+func TestBaseVFS(t *testing.T) {
+	// A backend defining only Get, with everything else from BaseVFS
+	type backend struct {
+		BaseVFS
+	}
+	var v VFS = backend{}
+
+	var notFound *NotFoundError
+	if _, err := v.Get("/redfish/v1"); !errors.As(err, &notFound) {
+		t.Errorf("Get = %v, want NotFoundError", err)
+	}
+	if _, err := v.Post("/redfish/v1", nil); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Post = %v, want ErrNotSupported", err)
+	}
+	if got := v.Join("/redfish/v1/Systems", "../Chassis?$top=2"); got != "/redfish/v1/Chassis?$top=2" {
+		t.Errorf("Join = %q", got)
+	}
+	if got := v.Parent("/redfish/v1/Systems/1"); got != "/redfish/v1/Systems" {
+		t.Errorf("Parent = %q", got)
+	}
+	if got := v.Parent(DefaultRoot); got != DefaultRoot {
+		t.Errorf("Parent of the root = %q", got)
+	}
+}
+
+func TestResourceCache_Concurrent(t *testing.T) {
+	// A slow service behind session auth, counting requests per path
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.Method+" "+r.URL.Path]++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/redfish/v1/SessionService/Sessions" && r.Method == "POST" {
+			w.Header().Set("X-Auth-Token", "test-token-123")
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		if r.Header.Get("X-Auth-Token") != "test-token-123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprintf(w, `{"@odata.id": "%s", "Status": {"Health": "OK"}}`, r.URL.Path)
+	}))
+	defer server.Close()
+	count := func(key string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests[key]
+	}
+
+	client, err := NewClient(server.URL, "admin", "pass", true)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	cache := NewResourceCache(client, NewParser(ParserOptions{}), filepath.Join(t.TempDir(), "cache.json"))
+
+	// Concurrent misses on one path share a fetch and a login
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.Get(context.Background(), "/redfish/v1/Systems/1"); err != nil {
+				t.Errorf("Get failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := count("GET /redfish/v1/Systems/1"); n != 2 {
+		t.Errorf("fetched %d times, want 2 (refused, then with the session)", n)
+	}
+	if n := count("POST /redfish/v1/SessionService/Sessions"); n != 1 {
+		t.Errorf("logged in %d times, want 1", n)
+	}
+
+	// Everything at once; run with -race
+	paths := []string{"/redfish/v1/Systems/1", "/redfish/v1/Systems/2", "/redfish/v1/Chassis/1", "/redfish/v1/Managers/1"}
+	for i := range 40 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := paths[i%len(paths)]
+			switch i % 8 {
+			case 0:
+				cache.Invalidate(p)
+			case 1:
+				if err := cache.Save(); err != nil {
+					t.Errorf("Save failed: %v", err)
+				}
+			case 2:
+				cache.SearchValues("ok")
+			case 3:
+				cache.GetKnownPaths()
+			case 4:
+				cache.Clear()
+			default:
+				if _, err := cache.Get(context.Background(), p); err != nil {
+					t.Errorf("Get %s failed: %v", p, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// A fetch overtaken by Invalidate is not stored
+	done := make(chan struct{})
+	go func() {
+		cache.Get(context.Background(), "/redfish/v1/Systems/3")
+		close(done)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	cache.Invalidate("/redfish/v1/Systems/3")
+	<-done
+	for _, p := range cache.GetKnownPaths() {
+		if p == "/redfish/v1/Systems/3" {
+			t.Error("stored a fetch that was invalidated while in flight")
+		}
+	}
+}
+
+func TestResourceCache_LazyLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cache.json")
+	parser := NewParser(ParserOptions{})
+	saved := NewResourceCache(nil, parser, file)
+	fetched := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for path, body := range map[string]string{
+		"/redfish/v1/Systems/1": `{"@odata.id": "/redfish/v1/Systems/1", "Name": "Server One"}`,
+		"/redfish/v1/Systems/2": `{"@odata.id": "/redfish/v1/Systems/2", "Name": "Server Two"}`,
+	} {
+		resource, err := parser.Parse(path, []byte(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resource.FetchedAt = fetched
+		saved.Put(resource)
+	}
+	if err := saved.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// Loading reads the entries without parsing them
+	cache, err := NewOfflineCache(file)
+	if err != nil {
+		t.Fatalf("NewOfflineCache failed: %v", err)
+	}
+	if len(cache.store) != 0 || len(cache.stored) != 2 {
+		t.Fatalf("loaded %d parsed and %d stored entries, want 0 and 2", len(cache.store), len(cache.stored))
+	}
+	if n := cache.Size(); n != 2 {
+		t.Errorf("Size = %d, want 2", n)
+	}
+	if paths := cache.GetKnownPaths(); len(paths) != 2 {
+		t.Errorf("GetKnownPaths = %v, want both systems", paths)
+	}
+	if at, ok := cache.FetchedAt("/redfish/v1/Systems/2"); !ok || !at.Equal(fetched) {
+		t.Errorf("FetchedAt = %v, %v, want %v", at, ok, fetched)
+	}
+
+	// Get parses the entry it needs, with its fetch time
+	resource, err := cache.Get(context.Background(), "/redfish/v1/Systems/1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if resource.Properties["Name"].Value != "Server One" || !resource.FetchedAt.Equal(fetched) {
+		t.Errorf("Get = %v fetched %v", resource.Properties["Name"].Value, resource.FetchedAt)
+	}
+	if len(cache.store) != 1 || len(cache.stored) != 1 {
+		t.Errorf("after Get %d parsed and %d stored entries, want 1 and 1", len(cache.store), len(cache.stored))
+	}
+
+	// Saving writes unparsed entries back as they were
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	reloaded, err := NewOfflineCache(file)
+	if err != nil {
+		t.Fatalf("NewOfflineCache failed: %v", err)
+	}
+	if resource, err := reloaded.Get(context.Background(), "/redfish/v1/Systems/2"); err != nil || resource.Properties["Name"].Value != "Server Two" {
+		t.Errorf("Get after a save of an unparsed entry = %v, %v", resource, err)
+	}
+
+	// Searches see unparsed entries
+	if found := cache.SearchValues("two"); len(found) != 1 || found[0].Path != "/redfish/v1/Systems/2" {
+		t.Errorf("SearchValues = %v, want Systems/2", found)
+	}
+
+	// A corrupted entry is not cached
+	if err := os.WriteFile(file, []byte(`{"/redfish/v1/Systems/3": {"path": "/redfish/v1/Systems/3", "data": "not base64!"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	corrupted, err := NewOfflineCache(file)
+	if err != nil {
+		t.Fatalf("NewOfflineCache failed: %v", err)
+	}
+	var notCached *NotCachedError
+	if _, err := corrupted.Get(context.Background(), "/redfish/v1/Systems/3"); !errors.As(err, &notCached) {
+		t.Errorf("Get of a corrupted entry = %v, want NotCachedError", err)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	t.Chdir(t.TempDir())
+	resources := map[string]string{
+		"/redfish/v1": `{"@odata.id": "/redfish/v1", "@odata.type": "#ServiceRoot.v1_5_0.ServiceRoot",
+			"Systems": {"@odata.id": "/redfish/v1/Systems"}, "JsonSchemas": {"@odata.id": "/redfish/v1/JsonSchemas"}}`,
+		"/redfish/v1/Systems": `{"@odata.id": "/redfish/v1/Systems", "Members": [{"@odata.id": "/redfish/v1/Systems/1"}]}`,
+		"/redfish/v1/Systems/1": `{"@odata.id": "/redfish/v1/Systems/1", "@odata.type": "#ComputerSystem.v1_20_0.ComputerSystem",
+			"Boot": {"BootSourceOverrideMode": "UEFI", "BootOrder": ["Pxe", "Hdd"]},
+			"Status": {"Health": "OK"}, "Oem": {"Vendor": {}}}`,
+		"/redfish/v1/JsonSchemas": `{"@odata.id": "/redfish/v1/JsonSchemas", "Members": [
+			{"@odata.id": "/redfish/v1/JsonSchemas/ComputerSystem.v1_20_0"}, {"@odata.id": "/redfish/v1/JsonSchemas/Resource"}]}`,
+		"/redfish/v1/JsonSchemas/ComputerSystem.v1_20_0": `{"@odata.id": "/redfish/v1/JsonSchemas/ComputerSystem.v1_20_0",
+			"Location": [{"Language": "en", "Uri": "/schemas/ComputerSystem.v1_20_0.json"}]}`,
+		"/redfish/v1/JsonSchemas/Resource": `{"@odata.id": "/redfish/v1/JsonSchemas/Resource",
+			"Location": [{"Language": "en", "PublicationUri": "http://redfish.dmtf.org/schemas/v1/Resource.json", "Uri": "/schemas/Resource.json"}]}`,
+		"/schemas/ComputerSystem.v1_20_0.json": `{"definitions": {
+			"ComputerSystem": {"description": "The ComputerSystem schema represents a computer or system instance.", "properties": {
+				"Boot": {"$ref": "#/definitions/Boot", "description": "The boot settings for this system."},
+				"Status": {"$ref": "http://redfish.dmtf.org/schemas/v1/Resource.json#/definitions/Status"}}},
+			"Boot": {"properties": {
+				"BootSourceOverrideMode": {"anyOf": [{"$ref": "#/definitions/BootSourceOverrideMode"}, {"type": "null"}],
+					"description": "The BIOS boot mode to use when the system boots from the BootSourceOverrideTarget boot source."},
+				"BootOrder": {"items": {"type": ["string", "null"]}, "description": "An array of BootOptionReference strings that represent the persistent boot order."}}}}}`,
+		"/schemas/Resource.json": `{"definitions": {
+			"Status": {"description": "The status and health of a resource and its children.", "properties": {
+				"Health": {"$ref": "#/definitions/Health"}}},
+			"Health": {"enum": ["OK", "Warning", "Critical"], "description": "The health of a resource."}}}`,
+	}
+	requests := make(map[string]int)
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		payload, ok := resources[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/schemas/") {
+			w.Header().Set("Content-Type", "application/schema+json")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	v, err := NewVFS(server.URL, "", "", true, Options{})
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", "The ComputerSystem schema represents a computer or system instance."},
+		{"Boot", "The boot settings for this system."},
+		{"Boot/BootSourceOverrideMode", "The BIOS boot mode to use when the system boots from the BootSourceOverrideTarget boot source."},
+		{"Boot/BootOrder[1]", "An array of BootOptionReference strings that represent the persistent boot order."},
+		{"Status", "The status and health of a resource and its children."},
+		{"Status/Health", "The health of a resource."},
+		{"Oem/Vendor", ""},
+	}
+	for _, tt := range tests {
+		got, err := v.Describe("/redfish/v1/Systems/1", tt.path)
+		if err != nil {
+			t.Errorf("Describe(%q) failed: %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Describe(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+	if n := requests["/schemas/ComputerSystem.v1_20_0.json"]; n != 1 {
+		t.Errorf("schema fetched %d times, want once", n)
+	}
+
+	// Types the service publishes no schema for have no descriptions
+	if got, err := v.Describe(DefaultRoot, ""); err != nil || got != "" {
+		t.Errorf("Describe(root) = %q, %v; want no description", got, err)
+	}
+	if _, err := v.Describe("/redfish/v1/Systems/1", "Missing"); err == nil {
+		t.Error("Describe of a missing property succeeded")
+	}
+}
+
+func TestDiffResources(t *testing.T) {
+	parser := NewParser(ParserOptions{})
+	parse := func(data string) *Resource {
+		t.Helper()
+		res, err := parser.Parse("/redfish/v1/Systems/1/Memory/DIMM0", []byte(data))
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		return res
+	}
+	old := parse(`{
+		"@odata.id": "/redfish/v1/Systems/1/Memory/DIMM0",
+		"Id": "DIMM0",
+		"CapacityMiB": 32768,
+		"Status": {"Health": "OK", "State": "Enabled"},
+		"AllowedSpeedsMHz": [2400, 2666],
+		"Location": {"PartLocation": {"ServiceLabel": "A0"}},
+		"Links": {"Chassis": {"@odata.id": "/redfish/v1/Chassis/1"}},
+		"Assembly": {"@odata.id": "/redfish/v1/Systems/1/Memory/DIMM0/Assembly"},
+		"ErrorCorrection": "MultiBitECC"
+	}`)
+	new := parse(`{
+		"@odata.id": "/redfish/v1/Systems/1/Memory/DIMM1",
+		"Id": "DIMM1",
+		"CapacityMiB": 32768,
+		"Status": {"Health": "Critical", "State": "Enabled"},
+		"AllowedSpeedsMHz": [2400, 2666, 2933],
+		"Location": "slot 1",
+		"Links": {"Chassis": {"@odata.id": "/redfish/v1/Chassis/2"}},
+		"Assembly": {"@odata.id": "/redfish/v1/Systems/1/Memory/DIMM1/Assembly"},
+		"OperatingSpeedMhz": 2933
+	}`)
+
+	var got []string
+	for _, d := range DiffResources(old, new) {
+		got = append(got, fmt.Sprintf("%s:%d", d.Property, d.Kind))
+	}
+	want := []string{
+		"AllowedSpeedsMHz[2]:1",
+		"Assembly:0",
+		"ErrorCorrection:2",
+		"Id:0",
+		"Links/Chassis:0",
+		"Location:0",
+		"OperatingSpeedMhz:1",
+		"Status/Health:0",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("DiffResources = %v, want %v", got, want)
+	}
+
+	if diffs := DiffResources(old, old); len(diffs) != 0 {
+		t.Errorf("resource differs from itself: %v", diffs)
+	}
+}
+
+func TestClient_Context(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redfish/v1/Systems" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(serviceRoot)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	v, err := NewVFS(server.URL, "admin", "pass", true, Options{CacheFile: filepath.Join(t.TempDir(), "cache.json")})
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+
+	// A request under a context past its deadline is aborted, while the
+	// connection's own requests, sent meanwhile, are not bound to it
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		_, err := WithContext(ctx, v).Get("/redfish/v1/Systems")
+		done <- err
+	}()
+	if _, err := v.Get("/redfish/v1"); err != nil {
+		t.Errorf("Get beside a bound request: %v", err)
+	}
+	err = <-done
+	var netErr *NetworkError
+	if !errors.As(err, &netErr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get of a hung resource = %v, want a NetworkError past the deadline", err)
+	}
+	if _, err := v.Get("/redfish/v1/Chassis"); err != nil {
+		t.Errorf("Get after the bound request: %v", err)
+	}
+}
+
+func TestClient_Reauthenticate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/redfish/v1/SessionService/Sessions":
+			var creds map[string]string
+			json.NewDecoder(r.Body).Decode(&creds)
+			if creds["Password"] != "rotated" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("X-Auth-Token", "fresh")
+			w.WriteHeader(http.StatusCreated)
+		case r.URL.Path == "/redfish/v1":
+			w.Write(serviceRoot)
+		case r.Header.Get("X-Auth-Token") != "fresh":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.Write([]byte(`{"@odata.id": "/redfish/v1/Systems"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "admin", "old", true)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	var authErr *AuthError
+	if _, err := client.Fetch(context.Background(), "/redfish/v1/Systems"); !errors.As(err, &authErr) {
+		t.Fatalf("Fetch with a stale password = %v, want AuthError", err)
+	}
+	if err := client.Reauthenticate(context.Background(), "wrong"); !errors.As(err, &authErr) {
+		t.Fatalf("Reauthenticate with a wrong password = %v, want AuthError", err)
+	}
+	if err := client.Reauthenticate(context.Background(), "rotated"); err != nil {
+		t.Fatalf("Reauthenticate: %v", err)
+	}
+	if _, err := client.Fetch(context.Background(), "/redfish/v1/Systems"); err != nil {
+		t.Errorf("Fetch after reauthenticating: %v", err)
+	}
+}
+
+func TestVFS_Denied(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/redfish/v1":
+			w.Write([]byte(`{"@odata.id": "/redfish/v1", "AccountService": {"@odata.id": "/redfish/v1/AccountService"}}`))
+		case "/redfish/v1/AccountService":
+			requests.Add(1)
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error": {"code": "Base.1.8.InsufficientPrivilege", "message": "denied",
+				"@Message.ExtendedInfo": [{"MessageId": "Base.1.8.InsufficientPrivilege", "Message": "Insufficient privilege."}]}}`))
+		}
+	}))
+	defer server.Close()
+
+	v, err := NewVFS(server.URL, "", "", true, Options{CacheFile: filepath.Join(t.TempDir(), "cache.json")})
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+
+	const path = "/redfish/v1/AccountService"
+	var forbidden *ForbiddenError
+	for range 2 {
+		if _, err := v.Get(path); !errors.As(err, &forbidden) || forbidden.StatusCode != http.StatusForbidden || len(forbidden.Messages) != 1 {
+			t.Fatalf("Get = %v, want 403 ForbiddenError with a message", err)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("denied resource requested %d times, want 1", n)
+	}
+	if !v.Denied(path) {
+		t.Error("Denied = false after a 403")
+	}
+
+	entries, err := v.ListAll(DefaultRoot)
+	if err != nil {
+		t.Fatalf("ListAll failed: %v", err)
+	}
+	for _, e := range entries {
+		if e.Name == "AccountService" && !e.Denied {
+			t.Error("AccountService entry not marked denied")
+		}
+	}
+
+	// Invalidating asks again, as after the role changed
+	v.Invalidate(path)
+	if v.Denied(path) {
+		t.Error("Denied = true after Invalidate")
+	}
+	v.Get(path)
+	if n := requests.Load(); n != 2 {
+		t.Errorf("requests after Invalidate = %d, want 2", n)
+	}
+}
+
+func TestCategorize(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorCategory
+	}{
+		{&ForbiddenError{Path: "/a", StatusCode: 403}, CategoryAuth},
+		{&AuthError{User: "u", StatusCode: 401}, CategoryAuth},
+		{&HTTPError{Path: "/a", StatusCode: 404}, CategoryNotFound},
+		{&NotFoundError{Path: "/a"}, CategoryNotFound},
+		{&HTTPError{Path: "/a", StatusCode: 503}, CategoryServer},
+		{&HTTPError{Path: "/a", StatusCode: 400}, CategoryOther},
+		{&NetworkError{Path: "/a", Err: context.DeadlineExceeded}, CategoryTimeout},
+		{&NetworkError{Path: "/a", Err: errors.New("connection reset")}, CategoryNetwork},
+		{&ParseError{Path: "/a", Err: errors.New("bad")}, CategoryOther},
+	}
+	for _, tt := range tests {
+		if got := Categorize(tt.err); got != tt.want {
+			t.Errorf("Categorize(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+
+	tally := ErrorTally{}
+	for _, tt := range tests {
+		tally.Add(tt.err)
+	}
+	if got, want := tally.String(), "2 auth, 2 notfound, 1 server, 1 timeout, 1 network, 2 other"; got != want {
+		t.Errorf("tally = %q, want %q", got, want)
+	}
+}
+
+func TestScrapePolicy(t *testing.T) {
+	scrapeRetryDelay = 0
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/redfish/v1":
+			w.Write([]byte(`{"@odata.id": "/redfish/v1"}`))
+		case "/redfish/v1/Flaky":
+			if requests.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"@odata.id": "/redfish/v1/Flaky"}`))
+		case "/redfish/v1/Broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	v, err := NewVFS(server.URL, "", "", true, Options{CacheFile: filepath.Join(t.TempDir(), "cache.json")})
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+
+	policy := ScrapePolicy{Retries: 2, Skip: []string{`/LogServices/.*/Entries$`}}
+	if err := policy.Compile(); err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	if _, retries, err := policy.Fetch(v, "/redfish/v1/Flaky"); err != nil || retries != 2 {
+		t.Errorf("Fetch(Flaky) = %d retries, %v; want 2 retries, success", retries, err)
+	}
+	if _, retries, err := policy.Fetch(v, "/redfish/v1/Broken"); Categorize(err) != CategoryServer || retries != 2 {
+		t.Errorf("Fetch(Broken) = %d retries, %v; want 2 retries, server error", retries, err)
+	}
+	if _, retries, err := policy.Fetch(v, "/redfish/v1/Missing"); Categorize(err) != CategoryNotFound || retries != 0 {
+		t.Errorf("Fetch(Missing) = %d retries, %v; want no retry, not found", retries, err)
+	}
+
+	if !policy.Skipped("/redfish/v1/Managers/1/LogServices/SEL/Entries") {
+		t.Error("log entries not skipped")
+	}
+	if policy.Skipped("/redfish/v1/Managers/1/LogServices/SEL") {
+		t.Error("log service skipped")
+	}
+	if err := (&ScrapePolicy{Skip: []string{"("}}).Compile(); err == nil {
+		t.Error("Compile accepted a bad pattern")
+	}
+}
+
+func TestProbeConnection(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"@odata.id": "/redfish/v1"}`))
+	}))
+	defer server.Close()
+
+	report, err := ProbeConnection(server.URL, true, 3)
+	if err != nil {
+		t.Fatalf("ProbeConnection failed: %v", err)
+	}
+	if report.Status != http.StatusOK {
+		t.Errorf("Status = %d, want 200", report.Status)
+	}
+	if report.TLS == nil || len(report.TLS.PeerCertificates) == 0 {
+		t.Fatal("no TLS session reported")
+	}
+	if report.VerifyErr == nil {
+		t.Error("self-signed certificate verified")
+	}
+	if len(report.RoundTrips) != 2 {
+		t.Errorf("%d round trips, want 2 after the connect", len(report.RoundTrips))
+	}
+
+	if _, err := ProbeConnection(server.URL, false, 1); err == nil {
+		t.Error("probe with verification accepted a self-signed certificate")
+	}
+}
+
+func TestEntryAge(t *testing.T) {
+	cache := newMockCache()
+	cache.loadJSON("/redfish/v1", serviceRoot)
+	cache.loadJSON("/redfish/v1/Systems", systemsCollection)
+	now := time.Now()
+	cache.resources["/redfish/v1"].FetchedAt = now.Add(-2 * time.Hour)
+	cache.resources["/redfish/v1/Systems"].FetchedAt = now.Add(-10 * time.Second)
+	v := &vfs{cache: cache, root: DefaultRoot}
+
+	entries, err := v.ListAll("/redfish/v1")
+	if err != nil {
+		t.Fatalf("ListAll failed: %v", err)
+	}
+	want := map[string]EntryAge{
+		"Systems":        AgeFresh,     // its own fetch, not the listing's
+		"Chassis":        AgeUnfetched, // not cached
+		"RedfishVersion": AgeStale,     // the listing's fetch
+	}
+	for _, e := range entries {
+		if age, ok := want[e.Name]; ok && e.Age(now) != age {
+			t.Errorf("%s: Age = %d, want %d", e.Name, e.Age(now), age)
+		}
+	}
+
+	recent := Entry{Modified: now.Add(-5 * time.Minute)}
+	if age := recent.Age(now); age != AgeRecent {
+		t.Errorf("5m old: Age = %d, want AgeRecent", age)
+	}
+}
+
+func TestEntryActions(t *testing.T) {
+	cache := newMockCache()
+	cache.loadJSON("/redfish/v1/Systems", systemsCollection)
+	cache.loadJSON("/redfish/v1/Systems/1", system1)
+	cache.loadJSON("/redfish/v1/Chassis/1", []byte(`{"@odata.id": "/redfish/v1/Chassis/1",
+		"Actions": {"Oem": {"#Contoso.Blink": {"target": "/redfish/v1/Chassis/1/Actions/Oem/Contoso.Blink"}}}}`))
+	v := &vfs{cache: cache, root: DefaultRoot}
+
+	entries, err := v.ListAll("/redfish/v1/Systems")
+	if err != nil {
+		t.Fatalf("ListAll failed: %v", err)
+	}
+	if i := slices.IndexFunc(entries, func(e *Entry) bool { return e.Name == "1" }); i < 0 || !entries[i].Actions {
+		t.Errorf("Systems/1 entry not marked as exposing actions: %+v", entries)
+	}
+	if cache.resources["/redfish/v1/Chassis/1"].HasActions() {
+		t.Error("Oem actions alone counted")
+	}
+	if (*Resource)(nil).HasActions() {
+		t.Error("nil resource has actions")
+	}
+}
+
+func TestResourceActions(t *testing.T) {
+	cache := newMockCache()
+	cache.loadJSON("/redfish/v1/Systems/1", []byte(`{"@odata.id": "/redfish/v1/Systems/1",
+		"Actions": {
+			"#ComputerSystem.Reset": {"target": "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset",
+				"@Redfish.ActionInfo": "/redfish/v1/Systems/1/ResetActionInfo"},
+			"#ComputerSystem.AddResourceBlock": {"target": "/redfish/v1/Systems/1/Actions/ComputerSystem.AddResourceBlock",
+				"ComputerSystemETag@Redfish.AllowableValues": ["A"]},
+			"Oem": {"#Contoso.Blink": {"target": "/redfish/v1/Systems/1/Actions/Oem/Contoso.Blink"}}}}`))
+	cache.loadJSON("/redfish/v1/Systems/1/ResetActionInfo", []byte(`{"@odata.id": "/redfish/v1/Systems/1/ResetActionInfo",
+		"Parameters": [{"Name": "ResetType", "Required": true, "DataType": "String", "AllowableValues": ["On", "ForceOff"]}]}`))
+	v := &vfs{cache: cache, root: DefaultRoot}
+
+	res, _ := v.Get("/redfish/v1/Systems/1")
+	actions := ResourceActions(res, v)
+	if len(actions) != 2 || actions[0].Name != "#ComputerSystem.AddResourceBlock" || actions[1].Name != "#ComputerSystem.Reset" {
+		t.Fatalf("ResourceActions = %+v", actions)
+	}
+	want := []ActionParameter{{Name: "ResetType", DataType: "String", Required: true, Allowable: []string{"On", "ForceOff"}}}
+	if !reflect.DeepEqual(actions[1].Parameters, want) || actions[1].InfoURI != "/redfish/v1/Systems/1/ResetActionInfo" {
+		t.Errorf("Reset from its ActionInfo = %+v", actions[1])
+	}
+	if want := []ActionParameter{{Name: "ComputerSystemETag", Allowable: []string{"A"}}}; !reflect.DeepEqual(actions[0].Parameters, want) {
+		t.Errorf("AddResourceBlock from its annotations = %+v", actions[0].Parameters)
+	}
+
+	// Read from the cache alone, an uncached ActionInfo leaves the annotations
+	cache.Invalidate("/redfish/v1/Systems/1/ResetActionInfo")
+	if actions := ResourceActions(res, CacheReader{Cache: v}); len(actions) != 2 || actions[1].Parameters != nil {
+		t.Errorf("ResourceActions from the cache = %+v", actions)
+	}
+}
+
+func TestResolveRole(t *testing.T) {
+	resources := map[string]string{
+		"/redfish/v1": `{"@odata.id": "/redfish/v1",
+			"AccountService": {"@odata.id": "/redfish/v1/AccountService"}}`,
+		"/redfish/v1/AccountService": `{"@odata.id": "/redfish/v1/AccountService",
+			"Accounts": {"@odata.id": "/redfish/v1/AccountService/Accounts"},
+			"Roles": {"@odata.id": "/redfish/v1/AccountService/Roles"}}`,
+		"/redfish/v1/AccountService/Accounts": `{"@odata.id": "/redfish/v1/AccountService/Accounts",
+			"Members": [{"@odata.id": "/redfish/v1/AccountService/Accounts/1"}, {"@odata.id": "/redfish/v1/AccountService/Accounts/2"}]}`,
+		"/redfish/v1/AccountService/Accounts/1": `{"@odata.id": "/redfish/v1/AccountService/Accounts/1",
+			"@odata.type": "#ManagerAccount.v1_10_0.ManagerAccount",
+			"UserName": "admin", "RoleId": "Administrator"}`,
+		"/redfish/v1/AccountService/Accounts/2": `{"@odata.id": "/redfish/v1/AccountService/Accounts/2",
+			"@odata.type": "#ManagerAccount.v1_10_0.ManagerAccount",
+			"UserName": "viewer", "RoleId": "ReadOnly",
+			"Links": {"Role": {"@odata.id": "/redfish/v1/AccountService/Roles/ReadOnly"}}}`,
+		"/redfish/v1/AccountService/Roles": `{"@odata.id": "/redfish/v1/AccountService/Roles",
+			"Members": [{"@odata.id": "/redfish/v1/AccountService/Roles/Administrator"}, {"@odata.id": "/redfish/v1/AccountService/Roles/ReadOnly"}]}`,
+		"/redfish/v1/AccountService/Roles/Administrator": `{"@odata.id": "/redfish/v1/AccountService/Roles/Administrator",
+			"Id": "Administrator",
+			"AssignedPrivileges": ["Login", "ConfigureManager", "ConfigureUsers", "ConfigureComponents", "ConfigureSelf"]}`,
+		"/redfish/v1/AccountService/Roles/ReadOnly": `{"@odata.id": "/redfish/v1/AccountService/Roles/ReadOnly",
+			"Id": "ReadOnly", "AssignedPrivileges": ["Login", "ConfigureSelf"], "OemPrivileges": ["ViewLogs"]}`,
+		"/redfish/v1/SessionService/Sessions/7": `{"@odata.id": "/redfish/v1/SessionService/Sessions/7",
+			"@odata.type": "#Session.v1_7_0.Session", "UserName": "viewer"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost && r.URL.Path == "/redfish/v1/SessionService/Sessions" {
+			w.Header().Set("X-Auth-Token", "token")
+			w.Header().Set("Location", "/redfish/v1/SessionService/Sessions/7")
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		if r.Header.Get("X-Auth-Token") != "token" && r.URL.Path != "/redfish/v1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		payload, ok := resources[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	v, err := NewVFS(server.URL, "admin", "secret", true, Options{CacheFile: filepath.Join(t.TempDir(), "cache.json")})
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+
+	// Logging in as admin lands in a session of viewer, which wins
+	role, err := ResolveRole(v, "admin")
+	if err != nil {
+		t.Fatalf("ResolveRole failed: %v", err)
+	}
+	if v.Session() != "/redfish/v1/SessionService/Sessions/7" {
+		t.Errorf("Session = %q", v.Session())
+	}
+	if role.UserName != "viewer" || role.RoleID != "ReadOnly" || role.Account != "/redfish/v1/AccountService/Accounts/2" {
+		t.Errorf("role = %+v", role)
+	}
+	if want := []string{"Login", "ConfigureSelf", "ViewLogs"}; !slices.Equal(role.Privileges, want) {
+		t.Errorf("Privileges = %v, want %v", role.Privileges, want)
+	}
+
+	system := &Resource{Path: "/redfish/v1/Systems/1", ODataType: "#ComputerSystem.v1_20_0.ComputerSystem"}
+	if got := role.MissingPrivilege(system); got != PrivilegeConfigureComponents {
+		t.Errorf("MissingPrivilege(system) = %q, want ConfigureComponents", got)
+	}
+	var refusal *PrivilegeError
+	if err := role.Refusal(system); !errors.As(err, &refusal) || refusal.Role != "ReadOnly" {
+		t.Errorf("Refusal(system) = %v", err)
+	}
+	own, _ := v.Get(role.Account)
+	if got := role.MissingPrivilege(own); got != "" {
+		t.Errorf("MissingPrivilege(own account) = %q, want none", got)
+	}
+	other, _ := v.Get("/redfish/v1/AccountService/Accounts/1")
+	if got := role.MissingPrivilege(other); got != PrivilegeConfigureUsers {
+		t.Errorf("MissingPrivilege(other account) = %q, want ConfigureUsers", got)
+	}
+
+	// An account naming its role only by RoleId finds it in Roles
+	resources["/redfish/v1/SessionService/Sessions/7"] = `{"@odata.id": "/redfish/v1/SessionService/Sessions/7", "UserName": "admin"}`
+	v.Clear()
+	role, err = ResolveRole(v, "")
+	if err != nil {
+		t.Fatalf("ResolveRole failed: %v", err)
+	}
+	if role.RoleID != "Administrator" || role.MissingPrivilege(system) != "" {
+		t.Errorf("admin role = %+v", role)
+	}
+
+	var nilRole *SessionRole
+	if nilRole.MissingPrivilege(system) != "" || nilRole.Refusal(system) != nil {
+		t.Error("an unresolved role refused a write")
+	}
+}
+
+func TestStat(t *testing.T) {
+	var options int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/redfish/v1":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"@odata.id": "/redfish/v1"}`))
+		case r.Method == http.MethodOptions && r.URL.Path == "/redfish/v1/Systems/1":
+			options++
+			w.Header().Set("Allow", "GET, HEAD")
+			w.Header().Add("Allow", "patch")
+		case r.Method == http.MethodOptions:
+			// Services without OPTIONS answer the HEAD instead
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case r.Method == http.MethodHead && r.URL.Path == "/redfish/v1/Managers/1":
+			w.Header().Set("Allow", "GET, HEAD, POST")
+		case r.Method == http.MethodHead && r.URL.Path == "/redfish/v1/Chassis/1":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	v, err := NewVFS(server.URL, "", "", true, Options{CacheFile: filepath.Join(t.TempDir(), "cache.json")})
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+
+	info, err := v.Stat("/redfish/v1/Systems/1/")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Path != "/redfish/v1/Systems/1" || !slices.Equal(info.Allow, []string{"GET", "HEAD", "PATCH"}) {
+		t.Errorf("Stat = %+v", info)
+	}
+	if !info.Allows("PATCH") || info.Allows("DELETE") {
+		t.Errorf("Allows PATCH/DELETE = %v/%v", info.Allows("PATCH"), info.Allows("DELETE"))
+	}
+
+	// The answer is kept until the path is invalidated
+	v.Stat("/redfish/v1/Systems/1")
+	if options != 1 {
+		t.Errorf("OPTIONS sent %d times, want 1", options)
+	}
+	v.Invalidate("/redfish/v1/Systems/1")
+	v.Stat("/redfish/v1/Systems/1")
+	if options != 2 {
+		t.Errorf("OPTIONS sent %d times after Invalidate, want 2", options)
+	}
+
+	info, err = v.Stat("/redfish/v1/Managers/1")
+	if err != nil {
+		t.Fatalf("Stat with HEAD fallback failed: %v", err)
+	}
+	if !slices.Equal(info.Allow, []string{"GET", "HEAD", "POST"}) {
+		t.Errorf("Allow from HEAD = %v", info.Allow)
+	}
+
+	// No Allow header leaves every method to the service
+	info, err = v.Stat("/redfish/v1/Chassis/1")
+	if err != nil {
+		t.Fatalf("Stat without Allow failed: %v", err)
+	}
+	if info.Allow != nil || !info.Allows("DELETE") {
+		t.Errorf("Stat without Allow = %+v", info)
+	}
+
+	var httpErr *HTTPError
+	if _, err := v.Stat("/redfish/v1/Missing"); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Stat of a missing resource = %v, want a 404", err)
+	}
+}
+
+func TestVersionNegotiation(t *testing.T) {
+	resources := map[string]string{
+		"/redfish":    `{"v1": "/redfish/v1/", "v2": "https://bmc.example.com/redfish/v2/", "@odata.id": "/redfish"}`,
+		"/redfish/v1": `{"@odata.id": "/redfish/v1", "Systems": {"@odata.id": "/redfish/v1/Systems"}}`,
+		"/redfish/v2": `{"@odata.id": "/redfish/v2", "Systems": {"@odata.id": "/redfish/v2/Systems"}}`,
+		"/redfish/v2/Systems": `{"@odata.id": "/redfish/v2/Systems",
+			"Members": [{"@odata.id": "/redfish/v2/Systems/1"}]}`,
+		"/redfish/v2/Systems/1": `{"@odata.id": "/redfish/v2/Systems/1", "Status": {"Health": "OK"}}`,
+	}
+	var listed atomic.Bool
+	listed.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, ok := resources[r.URL.Path]
+		if !ok || (r.URL.Path == "/redfish" && !listed.Load()) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	connect := func(version string) (VFS, error) {
+		return NewVFS(server.URL, "", "", true, Options{Version: version, CacheFile: filepath.Join(t.TempDir(), "cache.json")})
+	}
+
+	// The newest version listed wins, and paths resolve under its root
+	v, err := connect("")
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+	if v.Root() != "/redfish/v2" {
+		t.Fatalf("Root = %q, want /redfish/v2", v.Root())
+	}
+	target, err := v.ResolveTarget("", "Systems/1/Status/Health")
+	if err != nil || target.Property.Value != "OK" {
+		t.Errorf("ResolveTarget under v2 = %+v, %v", target, err)
+	}
+	if got := v.Parent("/redfish/v2"); got != "/redfish/v2" {
+		t.Errorf("Parent of the v2 root = %q", got)
+	}
+	if !IsServiceRoot("/redfish/v2") || IsServiceRoot("/redfish/v2/Systems") || IsServiceRoot("/redfish") {
+		t.Error("IsServiceRoot misjudged a path")
+	}
+
+	// A pinned version is used even when a newer one is listed
+	if v, err := connect("v1"); err != nil {
+		t.Errorf("pinned v1 failed: %v", err)
+	} else if v.Root() != "/redfish/v1" {
+		t.Errorf("pinned v1: Root = %q", v.Root())
+	}
+	if _, err := connect("v3"); err == nil || !strings.Contains(err.Error(), "offers v1, v2") {
+		t.Errorf("pinned v3 = %v, want the versions offered", err)
+	}
+
+	// A service that lists no versions speaks v1
+	listed.Store(false)
+	if v, err := connect(""); err != nil {
+		t.Errorf("NewVFS without listed versions failed: %v", err)
+	} else if v.Root() != DefaultRoot {
+		t.Errorf("unlisted versions: Root = %q, want %s", v.Root(), DefaultRoot)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	const attachment = "/redfish/v1/Systems/1/LogServices/Dump/Entries/1/attachment"
+	payload := bytes.Repeat([]byte{0x00, 0xff, 0x7f}, 50000)
+	resources := map[string]string{
+		"/redfish/v1":                       `{"@odata.id": "/redfish/v1", "Systems": {"@odata.id": "/redfish/v1/Systems"}}`,
+		"/redfish/v1/Systems":               `{"@odata.id": "/redfish/v1/Systems", "Members": [{"@odata.id": "/redfish/v1/Systems/1"}]}`,
+		"/redfish/v1/Systems/1":             `{"@odata.id": "/redfish/v1/Systems/1", "LogServices": {"@odata.id": "/redfish/v1/Systems/1/LogServices"}}`,
+		"/redfish/v1/Systems/1/LogServices": `{"@odata.id": "/redfish/v1/Systems/1/LogServices", "Members": [{"@odata.id": "/redfish/v1/Systems/1/LogServices/Dump"}]}`,
+		"/redfish/v1/Systems/1/LogServices/Dump": `{"@odata.id": "/redfish/v1/Systems/1/LogServices/Dump",
+			"Entries": {"@odata.id": "/redfish/v1/Systems/1/LogServices/Dump/Entries"}}`,
+		"/redfish/v1/Systems/1/LogServices/Dump/Entries": `{"@odata.id": "/redfish/v1/Systems/1/LogServices/Dump/Entries",
+			"Members": [{"@odata.id": "/redfish/v1/Systems/1/LogServices/Dump/Entries/1"}]}`,
+		"/redfish/v1/Systems/1/LogServices/Dump/Entries/1": `{"@odata.id": "/redfish/v1/Systems/1/LogServices/Dump/Entries/1",
+			"AdditionalDataURI": "` + attachment + `", "Message": "Dump ready"}`,
+	}
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if payload, ok := resources[r.URL.Path]; ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(payload))
+			return
+		}
+		switch r.URL.Path {
+		case "/redfish/v1/SessionService/Sessions":
+			w.Header().Set("X-Auth-Token", "token")
+			w.WriteHeader(http.StatusCreated)
+		case attachment:
+			// The payload needs the session the JSON resources do not
+			if r.Header.Get("X-Auth-Token") != "token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			downloads.Add(1)
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+			w.Write(payload)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	v, err := NewVFS(server.URL, "admin", "pass", true, Options{CacheFile: filepath.Join(t.TempDir(), "cache.json")})
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+
+	entry := "/redfish/v1/Systems/1/LogServices/Dump/Entries/1"
+	for _, target := range []string{"AdditionalDataURI", "attachment"} {
+		path, err := DownloadPath(v, entry, target)
+		if err != nil || path != attachment {
+			t.Errorf("DownloadPath(%s) = %q, %v; want %s", target, path, err, attachment)
+		}
+	}
+	if _, err := DownloadPath(v, entry, "Message"); err == nil {
+		t.Error("DownloadPath of a property without a URI succeeded")
+	}
+	if n := downloads.Load(); n != 0 {
+		t.Fatalf("resolving the download path downloaded the payload %d times", n)
+	}
+
+	var buf bytes.Buffer
+	var lastWritten, lastTotal int64
+	n, err := v.Download(attachment, &buf, func(written, total int64) {
+		lastWritten, lastTotal = written, total
+	})
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if n != int64(len(payload)) || !bytes.Equal(buf.Bytes(), payload) {
+		t.Errorf("Download wrote %d bytes, want the %d of the payload", n, len(payload))
+	}
+	if lastWritten != n || lastTotal != n {
+		t.Errorf("last progress = %d of %d, want %d of %d", lastWritten, lastTotal, n, n)
+	}
+
+	// Failing writes are the writer's error, not a network failure
+	var netErr *NetworkError
+	if _, err := v.Download(attachment, failingWriter{}, nil); err == nil || errors.As(err, &netErr) {
+		t.Errorf("Download to a failing writer = %v", err)
+	}
+	var httpErr *HTTPError
+	if _, err := v.Download("/redfish/v1/Missing", &buf, nil); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Download of a missing payload = %v, want a 404", err)
+	}
+}
+
+// failingWriter refuses every write, like a full disk
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+// TestCollectDiagnosticData tests the CollectDiagnosticData workflow: the
+// POST spawns a task, the monitor answers 202 until the task ends, and the
+// entry the task names or the new entry holds the URI of the data
+func TestCollectDiagnosticData(t *testing.T) {
+	const (
+		service = "/redfish/v1/Managers/1/LogServices/Dump"
+		entries = service + "/Entries"
+		monitor = "/redfish/v1/TaskService/TaskMonitors/1"
+		task    = "/redfish/v1/TaskService/Tasks/1"
+	)
+	var mu sync.Mutex
+	resources := map[string]string{
+		"/redfish/v1": `{"@odata.id": "/redfish/v1"}`,
+		service: `{"@odata.id": "` + service + `", "@odata.type": "#LogService.v1_5_0.LogService",
+			"Entries": {"@odata.id": "` + entries + `"},
+			"Actions": {"#LogService.CollectDiagnosticData": {"target": "` + service + `/Actions/LogService.CollectDiagnosticData"}}}`,
+		entries:        `{"@odata.id": "` + entries + `", "Members": [{"@odata.id": "` + entries + `/1"}]}`,
+		entries + "/1": `{"@odata.id": "` + entries + `/1", "AdditionalDataURI": "` + entries + `/1/attachment"}`,
+	}
+	// What the task does: how many polls it runs for and how it ends
+	var polls, runFor int
+	var ending string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case service + "/Actions/LogService.CollectDiagnosticData":
+			polls = 0
+			// The collected entry appears once the POST is taken
+			resources[entries] = `{"@odata.id": "` + entries + `", "Members": [{"@odata.id": "` + entries + `/1"}, {"@odata.id": "` + entries + `/2"}]}`
+			resources[entries+"/2"] = `{"@odata.id": "` + entries + `/2", "Created": "2026-10-16T10:00:00Z", "AdditionalDataURI": "` + entries + `/2/attachment"}`
+			w.Header().Set("Location", monitor)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"@odata.id": "` + task + `", "TaskState": "New", "TaskMonitor": "` + monitor + `"}`))
+			return
+		case monitor:
+			polls++
+			if polls <= runFor {
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(ending))
+			return
+		case task:
+			if polls++; polls <= runFor {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"@odata.id": "` + task + `", "TaskState": "Running", "PercentComplete": ` + strconv.Itoa(polls*10) + `}`))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(ending))
+			return
+		}
+		if payload, ok := resources[r.URL.Path]; ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(payload))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	v, err := NewVFS(server.URL, "", "", true, Options{CacheFile: filepath.Join(t.TempDir(), "cache.json")})
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+
+	completed := func(headers string) string {
+		return `{"@odata.id": "` + task + `", "TaskState": "Completed", "PercentComplete": 100,
+			"Payload": {"HttpHeaders": [` + headers + `]}}`
+	}
+	tests := []struct {
+		name      string
+		runFor    int
+		ending    string
+		wantEntry string
+		wantErr   bool
+	}{
+		{"task names the entry", 2, completed(`"Content-Type: application/json", "Location: ` + server.URL + entries + `/1"`), entries + "/1", false},
+		{"new entry", 3, completed(`"Content-Type: application/json"`), entries + "/2", false},
+		{"task fails", 1, `{"@odata.id": "` + task + `", "TaskState": "Exception",
+			"Messages": [{"MessageId": "Base.1.8.GeneralError", "Message": "Collection failed"}]}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			runFor, ending = tt.runFor, tt.ending
+			delete(resources, entries+"/2")
+			resources[entries] = `{"@odata.id": "` + entries + `", "Members": [{"@odata.id": "` + entries + `/1"}]}`
+			mu.Unlock()
+
+			var states []string
+			result, err := CollectDiagnosticData(v, service, []byte(`{"DiagnosticDataType": "Manager"}`), time.Millisecond, func(s *TaskStatus) {
+				states = append(states, s.String())
+			})
+			if tt.wantErr {
+				var taskErr *TaskError
+				if !errors.As(err, &taskErr) || taskErr.State != "Exception" || len(taskErr.Messages) != 1 {
+					t.Fatalf("err = %v, want a TaskError in Exception with its message", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CollectDiagnosticData failed: %v", err)
+			}
+			if len(states) != tt.runFor+1 || states[len(states)-1] != "Completed 100%" {
+				t.Errorf("progress = %v, want %d polls ending Completed 100%%", states, tt.runFor+1)
+			}
+			if result.Entry != tt.wantEntry || result.Download != tt.wantEntry+"/attachment" {
+				t.Errorf("result = %+v, want entry %s", result, tt.wantEntry)
+			}
+		})
+	}
+}
+
+// TestPollTask tests reading task states from Task resources and monitors
+func TestPollTask(t *testing.T) {
+	resources := map[string]string{
+		"/redfish/v1":                     `{"@odata.id": "/redfish/v1"}`,
+		"/redfish/v1/TaskService/Tasks/1": `{"TaskState": "Running", "PercentComplete": 40, "Messages": []}`,
+		"/redfish/v1/TaskService/Tasks/2": `{"TaskState": "Killed"}`,
+		"/redfish/v1/Systems/1":           `{"@odata.id": "/redfish/v1/Systems/1"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redfish/v1/TaskService/TaskMonitors/1":
+			w.WriteHeader(http.StatusAccepted)
+			return
+		case "/redfish/v1/TaskService/TaskMonitors/2":
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if payload, ok := resources[r.URL.Path]; ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(payload))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	v, err := NewVFS(server.URL, "", "", true, Options{CacheFile: filepath.Join(t.TempDir(), "cache.json")})
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		want     string
+		done     bool
+		failed   bool
+		location string
+	}{
+		{"/redfish/v1/TaskService/Tasks/1", "Running 40%", false, false, ""},
+		{"/redfish/v1/TaskService/Tasks/2", "Killed", true, true, ""},
+		{"/redfish/v1/TaskService/TaskMonitors/1", "Running", false, false, ""},
+		{"/redfish/v1/TaskService/TaskMonitors/2", "Completed", true, false, ""},
+		{"/redfish/v1/Systems/1", "Completed", true, false, "/redfish/v1/Systems/1"},
+	}
+	for _, tt := range tests {
+		status, err := PollTask(v, tt.path)
+		if err != nil {
+			t.Fatalf("PollTask(%s) failed: %v", tt.path, err)
+		}
+		if status.String() != tt.want || status.Done != tt.done || status.Failed() != tt.failed || status.Location != tt.location {
+			t.Errorf("PollTask(%s) = %+v, want %s", tt.path, status, tt.want)
+		}
+	}
+	if _, err := PollTask(v, "/redfish/v1/TaskService/Tasks/9"); err == nil {
+		t.Error("PollTask of a missing task succeeded")
+	}
+
+	accepted := &Response{StatusCode: http.StatusAccepted, Location: "/redfish/v1/TaskService/TaskMonitors/7"}
+	if got := SpawnedTask(accepted); got != accepted.Location {
+		t.Errorf("SpawnedTask without a Task body = %q, want the monitor", got)
+	}
+	accepted.Body = []byte(`{"@odata.id": "/redfish/v1/TaskService/Tasks/7/", "TaskState": "New"}`)
+	if got := SpawnedTask(accepted); got != "/redfish/v1/TaskService/Tasks/7" {
+		t.Errorf("SpawnedTask with a Task body = %q, want the Task", got)
+	}
+	if got := SpawnedTask(&Response{StatusCode: http.StatusOK, Location: "/x"}); got != "" {
+		t.Errorf("SpawnedTask of a 200 = %q, want none", got)
+	}
+}
+
+// telemetryFixture is a TelemetryService with one periodic power report
+var telemetryFixture = map[string]string{
+	"/redfish/v1": `{"@odata.id": "/redfish/v1", "TelemetryService": {"@odata.id": "/redfish/v1/TelemetryService"}}`,
+	"/redfish/v1/TelemetryService": `{"@odata.id": "/redfish/v1/TelemetryService",
+		"MetricReportDefinitions": {"@odata.id": "/redfish/v1/TelemetryService/MetricReportDefinitions"},
+		"MetricReports": {"@odata.id": "/redfish/v1/TelemetryService/MetricReports"},
+		"MetricDefinitions": {"@odata.id": "/redfish/v1/TelemetryService/MetricDefinitions"}}`,
+	"/redfish/v1/TelemetryService/MetricDefinitions": `{"@odata.id": "/redfish/v1/TelemetryService/MetricDefinitions",
+		"Members": [{"@odata.id": "/redfish/v1/TelemetryService/MetricDefinitions/PowerConsumedWatts"},
+			{"@odata.id": "/redfish/v1/TelemetryService/MetricDefinitions/PowerCapacityWatts"},
+			{"@odata.id": "/redfish/v1/TelemetryService/MetricDefinitions/FanReading"}]}`,
+	"/redfish/v1/TelemetryService/MetricDefinitions/PowerConsumedWatts": `{"@odata.id": "/redfish/v1/TelemetryService/MetricDefinitions/PowerConsumedWatts",
+		"Id": "PowerConsumedWatts", "Units": "W", "MetricProperties": ["/redfish/v1/Chassis/{ChassisId}/Power#/PowerControl/0/PowerConsumedWatts"]}`,
+	"/redfish/v1/TelemetryService/MetricDefinitions/PowerCapacityWatts": `{"@odata.id": "/redfish/v1/TelemetryService/MetricDefinitions/PowerCapacityWatts",
+		"Id": "PowerCapacityWatts", "Units": "W"}`,
+	"/redfish/v1/TelemetryService/MetricDefinitions/FanReading": `{"@odata.id": "/redfish/v1/TelemetryService/MetricDefinitions/FanReading",
+		"Id": "FanReading", "Units": "RPM"}`,
+	"/redfish/v1/TelemetryService/MetricReportDefinitions": `{"@odata.id": "/redfish/v1/TelemetryService/MetricReportDefinitions",
+		"Members": [{"@odata.id": "/redfish/v1/TelemetryService/MetricReportDefinitions/Power"}]}`,
+	"/redfish/v1/TelemetryService/MetricReportDefinitions/Power": `{"@odata.id": "/redfish/v1/TelemetryService/MetricReportDefinitions/Power",
+		"Id": "Power", "MetricReportDefinitionType": "Periodic", "Schedule": {"RecurrenceInterval": "PT10S"},
+		"Metrics": [{"MetricId": "PowerConsumedWatts"}, {"MetricProperties": ["/redfish/v1/Chassis/1/Thermal#/Fans/0/Reading"]}],
+		"MetricReport": {"@odata.id": "/redfish/v1/TelemetryService/MetricReports/Power"}}`,
+	"/redfish/v1/TelemetryService/MetricReports": `{"@odata.id": "/redfish/v1/TelemetryService/MetricReports",
+		"Members": [{"@odata.id": "/redfish/v1/TelemetryService/MetricReports/Power"}]}`,
+	"/redfish/v1/TelemetryService/MetricReports/Power": `{"@odata.id": "/redfish/v1/TelemetryService/MetricReports/Power",
+		"Id": "Power", "Timestamp": "2026-10-16T10:00:30Z",
+		"MetricReportDefinition": {"@odata.id": "/redfish/v1/TelemetryService/MetricReportDefinitions/Power"},
+		"MetricValues": [
+			{"MetricId": "PowerConsumedWatts", "MetricValue": "200", "Timestamp": "2026-10-16T10:00:00Z", "MetricProperty": "/redfish/v1/Chassis/1/Power#/PowerControl/0/PowerConsumedWatts"},
+			{"MetricId": "PowerConsumedWatts", "MetricValue": "300", "Timestamp": "2026-10-16T10:00:10Z", "MetricProperty": "/redfish/v1/Chassis/1/Power#/PowerControl/0/PowerConsumedWatts"},
+			{"MetricId": "FanReading", "MetricValue": 4200, "Timestamp": "2026-10-16T10:00:10Z"},
+			{"MetricId": "PowerConsumedWatts", "MetricValue": "250", "Timestamp": "2026-10-16T10:00:20Z", "MetricProperty": "/redfish/v1/Chassis/1/Power#/PowerControl/0/PowerConsumedWatts"},
+			{"MetricId": "FanReading", "MetricValue": "n/a", "Timestamp": "2026-10-16T10:00:20Z"}
+		]}`,
+}
+
+// TestTelemetry tests reading metric report definitions and reports, and
+// grouping, drawing and exporting readings
+func TestTelemetry(t *testing.T) {
+	cache := newMockCache()
+	for path, payload := range telemetryFixture {
+		if err := cache.loadJSON(path, []byte(payload)); err != nil {
+			t.Fatalf("loadJSON(%s) failed: %v", path, err)
+		}
+	}
+	v := &vfs{cache: cache, root: DefaultRoot}
+
+	telemetry, err := ReadTelemetry(v)
+	if err != nil {
+		t.Fatalf("ReadTelemetry failed: %v", err)
+	}
+	if len(telemetry.Definitions) != 1 || len(telemetry.Reports) != 1 {
+		t.Fatalf("ReadTelemetry = %d definitions, %d reports; want 1 each", len(telemetry.Definitions), len(telemetry.Reports))
+	}
+	d := telemetry.Definitions[0]
+	if d.ID != "Power" || d.Type != "Periodic" || d.Interval != "PT10S" || d.Report != "/redfish/v1/TelemetryService/MetricReports/Power" ||
+		!slices.Equal(d.Metrics, []string{"PowerConsumedWatts", "/redfish/v1/Chassis/1/Thermal#/Fans/0/Reading"}) {
+		t.Errorf("definition = %+v", d)
+	}
+
+	path, err := MetricReportPath(v, "Power")
+	if err != nil || path != "/redfish/v1/TelemetryService/MetricReports/Power" {
+		t.Fatalf("MetricReportPath = %q, %v", path, err)
+	}
+	if _, err := MetricReportPath(v, "Thermal"); err == nil {
+		t.Error("MetricReportPath of a missing report succeeded")
+	}
+	if _, err := ReadMetricReport(v, "/redfish/v1/TelemetryService"); err == nil {
+		t.Error("ReadMetricReport of a resource that is no report succeeded")
+	}
+	report, err := ReadMetricReport(v, path)
+	if err != nil {
+		t.Fatalf("ReadMetricReport failed: %v", err)
+	}
+	if report.Definition != "/redfish/v1/TelemetryService/MetricReportDefinitions/Power" || len(report.Readings) != 5 {
+		t.Errorf("report = %+v", report)
+	}
+
+	series := report.Series()
+	if len(series) != 2 || series[0].Name() != "PowerConsumedWatts" || series[1].Name() != "FanReading" {
+		t.Fatalf("Series = %+v", series)
+	}
+	if values := series[0].Values(); !slices.Equal(values, []float64{200, 300, 250}) {
+		t.Errorf("power values = %v", values)
+	}
+	// A number sent as one is kept; a reading that is no number is skipped
+	if values := series[1].Values(); !slices.Equal(values, []float64{4200}) {
+		t.Errorf("fan values = %v", values)
+	}
+
+	tests := []struct {
+		values []float64
+		width  int
+		want   string
+	}{
+		{[]float64{200, 300, 250}, 40, "▁█▄"},
+		{[]float64{1, 2, 3, 4, 5, 6, 7, 8}, 3, "▁▄█"},
+		{[]float64{5, 5}, 40, "▁▁"},
+		{nil, 40, ""},
+	}
+	for _, tt := range tests {
+		if got := Sparkline(tt.values, tt.width); got != tt.want {
+			t.Errorf("Sparkline(%v, %d) = %q, want %q", tt.values, tt.width, got, tt.want)
+		}
+	}
+
+	var csv bytes.Buffer
+	if err := WriteMetricCSV(&csv, report); err != nil {
+		t.Fatalf("WriteMetricCSV failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if len(lines) != 6 || lines[0] != "Timestamp,MetricId,MetricProperty,MetricValue" ||
+		lines[3] != "2026-10-16T10:00:10Z,FanReading,,4200" {
+		t.Errorf("CSV =\n%s", csv.String())
+	}
+}
+
+// TestReportDefinitionSpec tests picking metrics from the catalog and
+// building the body that creates a MetricReportDefinition
+func TestReportDefinitionSpec(t *testing.T) {
+	cache := newMockCache()
+	for path, payload := range telemetryFixture {
+		if err := cache.loadJSON(path, []byte(payload)); err != nil {
+			t.Fatalf("loadJSON(%s) failed: %v", path, err)
+		}
+	}
+	v := &vfs{cache: cache, root: DefaultRoot}
+
+	catalog, err := MetricCatalog(v)
+	if err != nil {
+		t.Fatalf("MetricCatalog failed: %v", err)
+	}
+	if len(catalog) != 3 || catalog[0].ID != "FanReading" || catalog[2].Units != "W" || len(catalog[2].Properties) != 1 {
+		t.Fatalf("MetricCatalog = %+v", catalog)
+	}
+	if path, err := MetricReportDefinitionsPath(v); err != nil || path != "/redfish/v1/TelemetryService/MetricReportDefinitions" {
+		t.Errorf("MetricReportDefinitionsPath = %q, %v", path, err)
+	}
+
+	matchTests := []struct {
+		name string
+		want int
+	}{
+		{"fanreading", 1}, {"Power", 2}, {"PowerCo", 1}, {"Temp", 0},
+	}
+	for _, tt := range matchTests {
+		if got := MatchMetrics(catalog, tt.name); len(got) != tt.want {
+			t.Errorf("MatchMetrics(%q) = %d metrics, want %d", tt.name, len(got), tt.want)
+		}
+	}
+
+	durationTests := []struct {
+		in, want string
+	}{
+		{"30s", "PT30S"}, {"1h30m", "PT1H30M"}, {"1.5s", "PT1.5S"}, {"pt5m", "PT5M"}, {"P1DT2H", "P1DT2H"},
+	}
+	for _, tt := range durationTests {
+		if got, err := ISODuration(tt.in); err != nil || got != tt.want {
+			t.Errorf("ISODuration(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "soon", "PT", "-5s"} {
+		if _, err := ISODuration(bad); err == nil {
+			t.Errorf("ISODuration(%q) succeeded", bad)
+		}
+	}
+
+	spec := &ReportDefinitionSpec{
+		ID: "Power", Type: "Periodic", Interval: "PT10S",
+		Actions:    []string{"LogToMetricReportsCollection"},
+		Metrics:    MatchMetrics(catalog, "PowerConsumedWatts"),
+		Properties: []string{"/redfish/v1/Chassis/1/Thermal#/Fans/0/Reading"},
+	}
+	body, err := spec.Body()
+	if err != nil {
+		t.Fatalf("Body failed: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("Body is not JSON: %v", err)
+	}
+	want := map[string]any{
+		"Id": "Power", "MetricReportDefinitionType": "Periodic",
+		"Schedule":      map[string]any{"RecurrenceInterval": "PT10S"},
+		"ReportActions": []any{"LogToMetricReportsCollection"},
+		"Metrics": []any{
+			map[string]any{"MetricId": "PowerConsumedWatts", "MetricProperties": []any{"/redfish/v1/Chassis/{ChassisId}/Power#/PowerControl/0/PowerConsumedWatts"}},
+			map[string]any{"MetricProperties": []any{"/redfish/v1/Chassis/1/Thermal#/Fans/0/Reading"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Body = %s", body)
+	}
+
+	invalid := []ReportDefinitionSpec{
+		{Type: "Periodic", Interval: "PT10S", Metrics: catalog},
+		{ID: "x", Type: "Sometimes", Metrics: catalog},
+		{ID: "x", Type: "Periodic", Metrics: catalog},
+		{ID: "x", Type: "OnChange", Actions: []string{"Email"}, Metrics: catalog},
+		{ID: "x", Type: "OnRequest"},
+	}
+	for _, spec := range invalid {
+		if _, err := spec.Body(); err == nil {
+			t.Errorf("Body of %+v succeeded", spec)
+		}
+	}
+}
+
+func TestLocationIndicator(t *testing.T) {
+	resources := map[string]string{
+		"/redfish/v1":                  `{"@odata.id": "/redfish/v1"}`,
+		"/redfish/v1/Chassis/1":        `{"@odata.id": "/redfish/v1/Chassis/1", "@odata.etag": "W/\"1\"", "LocationIndicatorActive": false}`,
+		"/redfish/v1/Systems/1":        `{"@odata.id": "/redfish/v1/Systems/1", "IndicatorLED": "Off"}`,
+		"/redfish/v1/Systems/1/Memory": `{"@odata.id": "/redfish/v1/Systems/1/Memory", "Members": []}`,
+	}
+	var patches []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			body, _ := io.ReadAll(r.Body)
+			patches = append(patches, r.URL.Path+" "+r.Header.Get("If-Match")+" "+string(body))
+			// Systems/1 wants an If-Match, and only reports its ETag from
+			// then on
+			if r.URL.Path == "/redfish/v1/Systems/1" && r.Header.Get("If-Match") == "" {
+				resources[r.URL.Path] = `{"@odata.id": "/redfish/v1/Systems/1", "@odata.etag": "7", "IndicatorLED": "Off"}`
+				w.WriteHeader(http.StatusPreconditionRequired)
+				return
+			}
+			var resource, changes map[string]any
+			json.Unmarshal([]byte(resources[r.URL.Path]), &resource)
+			json.Unmarshal(body, &changes)
+			maps.Copy(resource, changes)
+			payload, _ := json.Marshal(resource)
+			resources[r.URL.Path] = string(payload)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		payload, ok := resources[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	v, err := NewVFS(server.URL, "", "", true, Options{CacheFile: filepath.Join(t.TempDir(), "cache.json")})
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+
+	var history History
+	change, err := SetLocationIndicator(v, "/redfish/v1/Chassis/1", true)
+	if err != nil || change.Property[0] != "LocationIndicatorActive" || change.Old != false || change.New != true {
+		t.Fatalf("SetLocationIndicator(Chassis) = %+v, %v", change, err)
+	}
+	history.RecordPatch(change.Resource, []Change{change})
+	change, err = SetLocationIndicator(v, "/redfish/v1/Systems/1", true)
+	if err != nil || change.Property[0] != "IndicatorLED" || change.Old != "Off" || change.New != "Blinking" {
+		t.Fatalf("SetLocationIndicator(Systems) = %+v, %v", change, err)
+	}
+	history.RecordPatch(change.Resource, []Change{change})
+	want := []string{
+		`/redfish/v1/Chassis/1 W/"1" {"LocationIndicatorActive":true}`,
+		`/redfish/v1/Systems/1  {"IndicatorLED":"Blinking"}`,
+		`/redfish/v1/Systems/1 7 {"IndicatorLED":"Blinking"}`,
+	}
+	if !reflect.DeepEqual(patches, want) {
+		t.Errorf("patches = %q, want %q", patches, want)
+	}
+
+	// Undo turns both locators back off
+	plan, irreversible := history.Undo(2)
+	if len(irreversible) != 0 {
+		t.Fatalf("locate writes undo cannot reverse: %v", irreversible)
+	}
+	if stale, err := StaleChanges(v, plan); err != nil || len(stale) != 0 {
+		t.Fatalf("StaleChanges of the undo = %v, %v", stale, err)
+	}
+	for _, r := range ApplyPlan(v, plan, &PostGuard{}, false) {
+		if r.Err != nil {
+			t.Fatalf("undo %s: %v", r.Path, r.Err)
+		}
+	}
+	want = append(want,
+		`/redfish/v1/Systems/1 7 {"IndicatorLED":"Off"}`,
+		`/redfish/v1/Chassis/1 W/"1" {"LocationIndicatorActive":false}`,
+	)
+	if !reflect.DeepEqual(patches, want) {
+		t.Errorf("patches after undo = %q, want %q", patches, want)
+	}
+
+	if _, err := SetLocationIndicator(v, "/redfish/v1/Systems/1/Memory", true); err == nil {
+		t.Error("SetLocationIndicator on a resource without a locator succeeded")
+	}
+}
+
+// pcieFixture has a GPU the system's PCIeDevices collection lists, whose
+// function a Processor links, and a NIC the chassis lists in the older link
+// array, whose slot only the chassis' PCIeSlots names
+var pcieFixture = map[string]string{
+	"/redfish/v1":         `{"@odata.id": "/redfish/v1", "Systems": {"@odata.id": "/redfish/v1/Systems"}, "Chassis": {"@odata.id": "/redfish/v1/Chassis"}}`,
+	"/redfish/v1/Systems": `{"@odata.id": "/redfish/v1/Systems", "Members": [{"@odata.id": "/redfish/v1/Systems/1"}]}`,
+	"/redfish/v1/Systems/1": `{"@odata.id": "/redfish/v1/Systems/1", "@odata.type": "#ComputerSystem.v1_20_0.ComputerSystem",
+		"PCIeDevices": {"@odata.id": "/redfish/v1/Systems/1/PCIeDevices"},
+		"Processors": {"@odata.id": "/redfish/v1/Systems/1/Processors"},
+		"Storage": {"@odata.id": "/redfish/v1/Systems/1/Storage"}}`,
+	"/redfish/v1/Systems/1/PCIeDevices": `{"@odata.id": "/redfish/v1/Systems/1/PCIeDevices", "Members": [{"@odata.id": "/redfish/v1/Systems/1/PCIeDevices/GPU"}]}`,
+	"/redfish/v1/Systems/1/PCIeDevices/GPU": `{"@odata.id": "/redfish/v1/Systems/1/PCIeDevices/GPU", "Name": "GPU", "Manufacturer": "Contoso", "FirmwareVersion": "96.1",
+		"Slot": {"SlotType": "FullLength", "Location": {"PartLocation": {"LocationOrdinalValue": 2}}},
+		"PCIeInterface": {"PCIeType": "Gen4", "LanesInUse": 8, "MaxPCIeType": "Gen4", "MaxLanes": 16},
+		"PCIeFunctions": {"@odata.id": "/redfish/v1/Systems/1/PCIeDevices/GPU/PCIeFunctions"}}`,
+	"/redfish/v1/Systems/1/PCIeDevices/GPU/PCIeFunctions": `{"@odata.id": "/redfish/v1/Systems/1/PCIeDevices/GPU/PCIeFunctions",
+		"Members": [{"@odata.id": "/redfish/v1/Systems/1/PCIeDevices/GPU/PCIeFunctions/0"}]}`,
+	"/redfish/v1/Systems/1/PCIeDevices/GPU/PCIeFunctions/0": `{"@odata.id": "/redfish/v1/Systems/1/PCIeDevices/GPU/PCIeFunctions/0",
+		"FunctionId": 0, "FunctionType": "Physical", "DeviceClass": "DisplayController", "VendorId": "0x10de", "DeviceId": "0x20b0",
+		"Links": {"PCIeDevice": {"@odata.id": "/redfish/v1/Systems/1/PCIeDevices/GPU"}}}`,
+	"/redfish/v1/Systems/1/Processors": `{"@odata.id": "/redfish/v1/Systems/1/Processors", "Members": [{"@odata.id": "/redfish/v1/Systems/1/Processors/GPU0"}]}`,
+	"/redfish/v1/Systems/1/Processors/GPU0": `{"@odata.id": "/redfish/v1/Systems/1/Processors/GPU0",
+		"Links": {"PCIeFunctions": [{"@odata.id": "/redfish/v1/Systems/1/PCIeDevices/GPU/PCIeFunctions/0"}]}}`,
+	"/redfish/v1/Systems/1/Storage": `{"@odata.id": "/redfish/v1/Systems/1/Storage", "Members": []}`,
+	"/redfish/v1/Chassis":           `{"@odata.id": "/redfish/v1/Chassis", "Members": [{"@odata.id": "/redfish/v1/Chassis/1"}]}`,
+	"/redfish/v1/Chassis/1": `{"@odata.id": "/redfish/v1/Chassis/1", "@odata.type": "#Chassis.v1_25_0.Chassis",
+		"PCIeDevices": [{"@odata.id": "/redfish/v1/Chassis/1/PCIeDevices/NIC"}],
+		"NetworkAdapters": {"@odata.id": "/redfish/v1/Chassis/1/NetworkAdapters"},
+		"PCIeSlots": {"@odata.id": "/redfish/v1/Chassis/1/PCIeSlots"}}`,
+	"/redfish/v1/Chassis/1/PCIeDevices/NIC": `{"@odata.id": "/redfish/v1/Chassis/1/PCIeDevices/NIC", "Name": "NIC",
+		"Links": {"PCIeFunctions": [{"@odata.id": "/redfish/v1/Chassis/1/PCIeDevices/NIC/PCIeFunctions/1"}]}}`,
+	"/redfish/v1/Chassis/1/PCIeDevices/NIC/PCIeFunctions/1": `{"@odata.id": "/redfish/v1/Chassis/1/PCIeDevices/NIC/PCIeFunctions/1",
+		"FunctionId": 1, "DeviceClass": "NetworkController",
+		"Links": {"EthernetInterfaces": [{"@odata.id": "/redfish/v1/Systems/1/EthernetInterfaces/1"}], "EthernetInterfaces@odata.count": 1}}`,
+	"/redfish/v1/Chassis/1/NetworkAdapters": `{"@odata.id": "/redfish/v1/Chassis/1/NetworkAdapters", "Members": [{"@odata.id": "/redfish/v1/Chassis/1/NetworkAdapters/1"}]}`,
+	"/redfish/v1/Chassis/1/NetworkAdapters/1": `{"@odata.id": "/redfish/v1/Chassis/1/NetworkAdapters/1",
+		"Controllers": [{"Links": {"PCIeDevices": [{"@odata.id": "/redfish/v1/Chassis/1/PCIeDevices/NIC"}]}}]}`,
+	"/redfish/v1/Chassis/1/PCIeSlots": `{"@odata.id": "/redfish/v1/Chassis/1/PCIeSlots",
+		"Slots": [{"SlotType": "HalfLength", "Location": {"PartLocation": {"ServiceLabel": "Riser 1"}},
+			"Links": {"PCIeDevice": [{"@odata.id": "/redfish/v1/Chassis/1/PCIeDevices/NIC"}]}}]}`,
+}
+
+func TestPCIeTopology(t *testing.T) {
+	cache := newMockCache()
+	for path, payload := range pcieFixture {
+		if err := cache.loadJSON(path, []byte(payload)); err != nil {
+			t.Fatalf("loadJSON(%s) failed: %v", path, err)
+		}
+	}
+	v := &vfs{cache: cache, root: DefaultRoot}
+
+	devices, err := PCIeTopology(v, DefaultRoot)
+	if err != nil {
+		t.Fatalf("PCIeTopology failed: %v", err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("PCIeTopology = %d devices, want 2", len(devices))
+	}
+
+	nic, gpu := devices[0], devices[1]
+	if nic.Slot != "Riser 1" || nic.SlotType != "HalfLength" {
+		t.Errorf("NIC slot = %q %q, want the one PCIeSlots names", nic.Slot, nic.SlotType)
+	}
+	wantNIC := []PCIeAssociation{{Kind: "NetworkAdapter", Path: "/redfish/v1/Chassis/1/NetworkAdapters/1"}}
+	if !reflect.DeepEqual(nic.Associations, wantNIC) {
+		t.Errorf("NIC associations = %+v, want %+v", nic.Associations, wantNIC)
+	}
+	wantFunction := []PCIeAssociation{{Kind: "EthernetInterface", Path: "/redfish/v1/Systems/1/EthernetInterfaces/1"}}
+	if len(nic.Functions) != 1 || nic.Functions[0].ID != "1" || !reflect.DeepEqual(nic.Functions[0].Associations, wantFunction) {
+		t.Errorf("NIC functions = %+v", nic.Functions)
+	}
+
+	if gpu.Slot != "Slot 2" || gpu.Link.String() != "Gen4 x8" || gpu.MaxLink.String() != "Gen4 x16" || gpu.Firmware != "96.1" {
+		t.Errorf("GPU = %+v", gpu)
+	}
+	wantGPU := []PCIeAssociation{{Kind: "Processor", Path: "/redfish/v1/Systems/1/Processors/GPU0"}}
+	if len(gpu.Functions) != 1 || !reflect.DeepEqual(gpu.Functions[0].Associations, wantGPU) {
+		t.Errorf("GPU functions = %+v", gpu.Functions)
+	}
+
+	// A chassis only has its own devices
+	devices, err = PCIeTopology(v, "/redfish/v1/Chassis/1")
+	if err != nil || len(devices) != 1 || devices[0].Path != "/redfish/v1/Chassis/1/PCIeDevices/NIC" {
+		t.Errorf("PCIeTopology(Chassis/1) = %v, %v", devices, err)
+	}
+	if _, err := PCIeTopology(v, "/redfish/v1/Chassis"); err == nil {
+		t.Error("PCIeTopology of a collection succeeded")
+	}
+}
+
+func TestReadMemory(t *testing.T) {
+	cache := newMockCache()
+	for path, payload := range map[string]string{
+		"/redfish/v1":         `{"@odata.id": "/redfish/v1", "Systems": {"@odata.id": "/redfish/v1/Systems"}}`,
+		"/redfish/v1/Systems": `{"@odata.id": "/redfish/v1/Systems", "Members": [{"@odata.id": "/redfish/v1/Systems/1"}]}`,
+		"/redfish/v1/Systems/1": `{"@odata.id": "/redfish/v1/Systems/1", "@odata.type": "#ComputerSystem.v1_20_0.ComputerSystem",
+			"MemorySummary": {"TotalSystemMemoryGiB": 64, "Status": {"HealthRollup": "Warning"}},
+			"Memory": {"@odata.id": "/redfish/v1/Systems/1/Memory"}}`,
+		"/redfish/v1/Systems/1/Memory": `{"@odata.id": "/redfish/v1/Systems/1/Memory", "Members": [
+			{"@odata.id": "/redfish/v1/Systems/1/Memory/DIMM2"}, {"@odata.id": "/redfish/v1/Systems/1/Memory/DIMM10"},
+			{"@odata.id": "/redfish/v1/Systems/1/Memory/DIMM11"}]}`,
+		"/redfish/v1/Systems/1/Memory/DIMM2": `{"@odata.id": "/redfish/v1/Systems/1/Memory/DIMM2", "Id": "DIMM2", "CapacityMiB": 32768,
+			"OperatingSpeedMhz": 4800, "MemoryDeviceType": "DDR5", "Manufacturer": "Contoso", "PartNumber": "C32G",
+			"Location": {"PartLocation": {"ServiceLabel": "DIMM A2"}}, "Status": {"State": "Enabled", "Health": "OK"}}`,
+		"/redfish/v1/Systems/1/Memory/DIMM10": `{"@odata.id": "/redfish/v1/Systems/1/Memory/DIMM10", "Id": "DIMM10", "CapacityMiB": 32768,
+			"DeviceLocator": "B0", "Status": {"State": "Enabled", "Health": "Critical"}}`,
+		"/redfish/v1/Systems/1/Memory/DIMM11": `{"@odata.id": "/redfish/v1/Systems/1/Memory/DIMM11", "Id": "DIMM11", "Status": {"State": "Absent"}}`,
+	} {
+		if err := cache.loadJSON(path, []byte(payload)); err != nil {
+			t.Fatalf("loadJSON(%s) failed: %v", path, err)
+		}
+	}
+	v := &vfs{cache: cache, root: DefaultRoot}
+
+	memory, err := ReadMemory(v, DefaultRoot)
+	if err != nil {
+		t.Fatalf("ReadMemory failed: %v", err)
+	}
+	if len(memory) != 1 || memory[0].TotalGiB != 64 || memory[0].Health != "Warning" || len(memory[0].Modules) != 3 {
+		t.Fatalf("ReadMemory = %+v", memory)
+	}
+	tests := []struct {
+		slot      string
+		populated bool
+		failed    bool
+	}{
+		{"DIMM A2", true, false},
+		{"B0", true, true},
+		{"DIMM11", false, false},
+	}
+	for i, tt := range tests {
+		m := memory[0].Modules[i]
+		if m.Slot != tt.slot || m.Populated() != tt.populated || m.Failed() != tt.failed {
+			t.Errorf("module %d = %+v, populated %v, failed %v; want %+v", i, m, m.Populated(), m.Failed(), tt)
+		}
+	}
+	if m := memory[0].Modules[0]; m.CapacityMiB != 32768 || m.SpeedMHz != 4800 || m.Type != "DDR5" || m.PartNumber != "C32G" {
+		t.Errorf("DIMM A2 = %+v", m)
+	}
+
+	if _, err := ReadMemory(v, "/redfish/v1/Systems/1/Memory"); err == nil {
+		t.Error("ReadMemory of a collection succeeded")
+	}
+}
+
+func TestReadProcessors(t *testing.T) {
+	cache := newMockCache()
+	for path, payload := range map[string]string{
+		"/redfish/v1":         `{"@odata.id": "/redfish/v1", "Systems": {"@odata.id": "/redfish/v1/Systems"}}`,
+		"/redfish/v1/Systems": `{"@odata.id": "/redfish/v1/Systems", "Members": [{"@odata.id": "/redfish/v1/Systems/1"}]}`,
+		"/redfish/v1/Systems/1": `{"@odata.id": "/redfish/v1/Systems/1", "@odata.type": "#ComputerSystem.v1_20_0.ComputerSystem",
+			"Processors": {"@odata.id": "/redfish/v1/Systems/1/Processors"}}`,
+		"/redfish/v1/Systems/1/Processors": `{"@odata.id": "/redfish/v1/Systems/1/Processors", "Members": [
+			{"@odata.id": "/redfish/v1/Systems/1/Processors/CPU1"}, {"@odata.id": "/redfish/v1/Systems/1/Processors/CPU2"},
+			{"@odata.id": "/redfish/v1/Systems/1/Processors/GPU1"}]}`,
+		"/redfish/v1/Systems/1/Processors/CPU1": `{"@odata.id": "/redfish/v1/Systems/1/Processors/CPU1", "Id": "CPU1", "Socket": "CPU 1",
+			"ProcessorType": "CPU", "Model": "Contoso 9000", "TotalCores": 32, "TotalThreads": 64, "OperatingSpeedMHz": 2100, "MaxSpeedMHz": 4000,
+			"Status": {"State": "Enabled", "Health": "OK"},
+			"EnvironmentMetrics": {"@odata.id": "/redfish/v1/Systems/1/Processors/CPU1/EnvironmentMetrics"},
+			"Metrics": {"@odata.id": "/redfish/v1/Systems/1/Processors/CPU1/ProcessorMetrics"}}`,
+		"/redfish/v1/Systems/1/Processors/CPU1/EnvironmentMetrics": `{"@odata.id": "/redfish/v1/Systems/1/Processors/CPU1/EnvironmentMetrics",
+			"TemperatureCelsius": {"Reading": 54}}`,
+		"/redfish/v1/Systems/1/Processors/CPU1/ProcessorMetrics": `{"@odata.id": "/redfish/v1/Systems/1/Processors/CPU1/ProcessorMetrics",
+			"TemperatureCelsius": 99, "ConsumedPowerWatt": 180}`,
+		"/redfish/v1/Systems/1/Processors/CPU2": `{"@odata.id": "/redfish/v1/Systems/1/Processors/CPU2", "Id": "CPU2", "ProcessorType": "CPU",
+			"Status": {"State": "Absent"}}`,
+		"/redfish/v1/Systems/1/Processors/GPU1": `{"@odata.id": "/redfish/v1/Systems/1/Processors/GPU1", "Id": "GPU1", "ProcessorType": "GPU",
+			"Oem": {"Contoso": {"Temperature": {"Reading": 61}, "PowerConsumedWatts": 300}}}`,
+	} {
+		if err := cache.loadJSON(path, []byte(payload)); err != nil {
+			t.Fatalf("loadJSON(%s) failed: %v", path, err)
+		}
+	}
+	v := &vfs{cache: cache, root: DefaultRoot}
+
+	all, err := ReadProcessors(v, "/redfish/v1/Systems/1")
+	if err != nil {
+		t.Fatalf("ReadProcessors failed: %v", err)
+	}
+	if len(all) != 1 || len(all[0].Processors) != 3 {
+		t.Fatalf("ReadProcessors = %+v", all)
+	}
+	cpu, absent, gpu := all[0].Processors[0], all[0].Processors[1], all[0].Processors[2]
+	if cpu.Socket != "CPU 1" || cpu.Cores != 32 || cpu.Threads != 64 || cpu.SpeedMHz != 2100 || cpu.MaxSpeedMHz != 4000 || cpu.Accelerator() {
+		t.Errorf("CPU1 = %+v", cpu)
+	}
+	// EnvironmentMetrics wins over ProcessorMetrics, which fills the gaps
+	wantCPU := []ProcessorReading{
+		{Name: "TemperatureCelsius", Value: 54, Source: "/redfish/v1/Systems/1/Processors/CPU1/EnvironmentMetrics"},
+		{Name: "PowerWatts", Value: 180, Source: "/redfish/v1/Systems/1/Processors/CPU1/ProcessorMetrics"},
+	}
+	if !reflect.DeepEqual(cpu.Readings, wantCPU) {
+		t.Errorf("CPU1 readings = %+v, want %+v", cpu.Readings, wantCPU)
+	}
+	if absent.Socket != "CPU2" || absent.State != "Absent" || len(absent.Readings) != 0 {
+		t.Errorf("CPU2 = %+v", absent)
+	}
+	wantGPU := []ProcessorReading{
+		{Name: "TemperatureCelsius", Value: 61, Source: "/redfish/v1/Systems/1/Processors/GPU1#/Oem/Contoso/Temperature/Reading"},
+		{Name: "PowerWatts", Value: 300, Source: "/redfish/v1/Systems/1/Processors/GPU1#/Oem/Contoso/PowerConsumedWatts"},
+	}
+	if !gpu.Accelerator() || !reflect.DeepEqual(gpu.Readings, wantGPU) {
+		t.Errorf("GPU1 = %+v, want readings %+v", gpu, wantGPU)
+	}
+}
+
+func TestReadFirmwareInventory(t *testing.T) {
+	cache := newMockCache()
+	for path, payload := range map[string]string{
+		"/redfish/v1":               `{"@odata.id": "/redfish/v1", "UpdateService": {"@odata.id": "/redfish/v1/UpdateService"}}`,
+		"/redfish/v1/UpdateService": `{"@odata.id": "/redfish/v1/UpdateService", "FirmwareInventory": {"@odata.id": "/redfish/v1/UpdateService/FirmwareInventory"}}`,
+		"/redfish/v1/UpdateService/FirmwareInventory": `{"@odata.id": "/redfish/v1/UpdateService/FirmwareInventory", "Members": [
+			{"@odata.id": "/redfish/v1/UpdateService/FirmwareInventory/BMC"}, {"@odata.id": "/redfish/v1/UpdateService/FirmwareInventory/CPLD"}]}`,
+		"/redfish/v1/UpdateService/FirmwareInventory/BMC": `{"@odata.id": "/redfish/v1/UpdateService/FirmwareInventory/BMC", "Id": "BMC",
+			"Name": "BMC Firmware", "Version": "2.0.0", "Updateable": true, "Status": {"State": "Enabled", "Health": "OK"},
+			"RelatedItem": [{"@odata.id": "/redfish/v1/Managers/1"}]}`,
+		"/redfish/v1/UpdateService/FirmwareInventory/CPLD": `{"@odata.id": "/redfish/v1/UpdateService/FirmwareInventory/CPLD", "Id": "CPLD",
+			"Version": "0.9", "Updateable": false}`,
+	} {
+		if err := cache.loadJSON(path, []byte(payload)); err != nil {
+			t.Fatalf("loadJSON(%s) failed: %v", path, err)
+		}
+	}
+	v := &vfs{cache: cache, root: DefaultRoot}
+
+	inventory, err := ReadFirmwareInventory(v)
+	if err != nil {
+		t.Fatalf("ReadFirmwareInventory failed: %v", err)
+	}
+	want := []*SoftwareComponent{
+		{Path: "/redfish/v1/UpdateService/FirmwareInventory/BMC", ID: "BMC", Name: "BMC Firmware", Version: "2.0.0", Updateable: true,
+			State: "Enabled", Health: "OK", Related: []string{"/redfish/v1/Managers/1"}},
+		{Path: "/redfish/v1/UpdateService/FirmwareInventory/CPLD", ID: "CPLD", Version: "0.9"},
+	}
+	if !reflect.DeepEqual(inventory, want) {
+		t.Errorf("ReadFirmwareInventory = %+v, want %+v", inventory, want)
+	}
+}
+
+func TestApply(t *testing.T) {
+	resources := map[string]string{
+		"/redfish/v1": `{"@odata.id": "/redfish/v1", "Systems": {"@odata.id": "/redfish/v1/Systems"}, "Managers": {"@odata.id": "/redfish/v1/Managers"}}`,
+		"/redfish/v1/Systems/1": `{"@odata.id": "/redfish/v1/Systems/1", "Boot": {"BootSourceOverrideTarget": "None", "BootSourceOverrideEnabled": "Once"},
+			"Actions": {"#ComputerSystem.Reset": {"target": "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset"}}}`,
+		"/redfish/v1/Managers/1/NetworkProtocol": `{"@odata.id": "/redfish/v1/Managers/1/NetworkProtocol", "NTP": {"ProtocolEnabled": true, "NTPServers": ["a"]}}`,
+		"/redfish/v1/Systems":                    `{"@odata.id": "/redfish/v1/Systems", "Members": [{"@odata.id": "/redfish/v1/Systems/1"}]}`,
+		"/redfish/v1/Managers":                   `{"@odata.id": "/redfish/v1/Managers", "Members": [{"@odata.id": "/redfish/v1/Managers/1"}]}`,
+		"/redfish/v1/Managers/1":                 `{"@odata.id": "/redfish/v1/Managers/1", "NetworkProtocol": {"@odata.id": "/redfish/v1/Managers/1/NetworkProtocol"}}`,
+	}
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch || r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		payload, ok := resources[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	v, err := NewVFS(server.URL, "", "", true, Options{CacheFile: filepath.Join(t.TempDir(), "cache.json")})
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "desired.yaml")
+	os.WriteFile(file, []byte(`resources:
+  /redfish/v1/Systems/1:
+    Boot:
+      BootSourceOverrideTarget: Pxe
+      BootSourceOverrideEnabled: Once
+  Managers/1/NetworkProtocol:
+    NTP:
+      NTPServers: [a, b]
+      NTPPort: 123
+actions:
+  - resource: Systems/1
+    action: reset
+    body: {ResetType: GracefulRestart}
+`), 0o644)
+	desired, err := LoadDesiredState(file)
+	if err != nil {
+		t.Fatalf("LoadDesiredState failed: %v", err)
+	}
+	plan, err := PlanApply(v, desired)
+	if err != nil {
+		t.Fatalf("PlanApply failed: %v", err)
+	}
+	want := []Change{
+		{Resource: "/redfish/v1/Managers/1/NetworkProtocol", Property: []string{"NTP", "NTPPort"}, New: float64(123), Method: "PATCH"},
+		{Resource: "/redfish/v1/Managers/1/NetworkProtocol", Property: []string{"NTP", "NTPServers"}, Old: []any{"a"}, New: []any{"a", "b"}, Method: "PATCH"},
+		{Resource: "/redfish/v1/Systems/1", Property: []string{"Boot", "BootSourceOverrideTarget"}, Old: "None", New: "Pxe", Method: "PATCH"},
+		{Resource: "/redfish/v1/Systems/1", Property: []string{"#ComputerSystem.Reset"}, New: map[string]any{"ResetType": "GracefulRestart"},
+			Method: "POST", Target: "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset"},
+	}
+	if !reflect.DeepEqual(plan.Changes, want) {
+		t.Fatalf("plan = %+v\nwant %+v", plan.Changes, want)
+	}
+	if plan.Patches() != 3 {
+		t.Errorf("Patches() = %d, want 3", plan.Patches())
+	}
+
+	var posts PostGuard
+	results := ApplyPlan(v, plan, &posts, false)
+	for _, r := range results {
+		if r.Err != nil {
+			t.Errorf("%s %s failed: %v", r.Method, r.Path, r.Err)
+		}
+	}
+	wantRequests := []string{
+		`PATCH /redfish/v1/Managers/1/NetworkProtocol {"NTP":{"NTPPort":123,"NTPServers":["a","b"]}}`,
+		`PATCH /redfish/v1/Systems/1 {"Boot":{"BootSourceOverrideTarget":"Pxe"}}`,
+		`POST /redfish/v1/Systems/1/Actions/ComputerSystem.Reset {"ResetType":"GracefulRestart"}`,
+	}
+	if !reflect.DeepEqual(requests, wantRequests) {
+		t.Errorf("requests = %q\nwant %q", requests, wantRequests)
+	}
+
+	// The action just sent is held back, unless again
+	actions := &Plan{Changes: plan.Changes[3:]}
+	var repeat *RepeatError
+	if err := posts.CheckPlan(actions); !errors.As(err, &repeat) {
+		t.Errorf("CheckPlan of the action just sent = %v", err)
+	}
+	requests = nil
+	if results := ApplyPlan(v, actions, &posts, false); len(results) != 1 || !errors.As(results[0].Err, &repeat) || len(requests) != 0 {
+		t.Errorf("repeated action = %+v, sent %q", results, requests)
+	}
+	if results := ApplyPlan(v, actions, &posts, true); len(results) != 1 || results[0].Err != nil || len(requests) != 1 {
+		t.Errorf("repeated action with again = %+v, sent %q", results, requests)
+	}
+
+	// Without differences there is nothing to do, not even the actions
+	desired.Resources = map[string]map[string]any{"Systems/1": {"Boot": map[string]any{"BootSourceOverrideEnabled": "Once"}}}
+	if plan, err := PlanApply(v, desired); err != nil || len(plan.Changes) != 0 {
+		t.Errorf("PlanApply of the live state = %+v, %v", plan, err)
+	}
+
+	os.WriteFile(file, []byte("resources:\n  Systems/1: {}\nunknown: 1\n"), 0o644)
+	if _, err := LoadDesiredState(file); err == nil {
+		t.Error("LoadDesiredState accepted an unknown field")
+	}
+}
+
+func TestLoadPlan(t *testing.T) {
+	dir := t.TempDir()
+	plan := &Plan{Changes: []Change{
+		{Resource: "/redfish/v1/Systems/1", Property: []string{"Boot", "BootSourceOverrideTarget"}, Old: "None", New: "Pxe", Method: "PATCH"},
+		{Resource: "/redfish/v1/Systems/1", Property: []string{"#ComputerSystem.Reset"}, New: map[string]any{"ResetType": "On"}, Method: "POST", Target: "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset"},
+	}}
+	file := filepath.Join(dir, "plan.json")
+	if err := SavePlan(file, plan); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPlan(file)
+	if err != nil || !reflect.DeepEqual(loaded, plan) {
+		t.Fatalf("LoadPlan = %+v, %v", loaded, err)
+	}
+
+	for name, data := range map[string]string{
+		"method":   `{"changes": [{"resource": "/redfish/v1/Systems/1", "property": ["Name"], "method": "DELETE"}]}`,
+		"property": `{"changes": [{"resource": "/redfish/v1/Systems/1", "property": [], "method": "PATCH"}]}`,
+		"target":   `{"changes": [{"resource": "/redfish/v1/Systems/1", "property": ["#ComputerSystem.Reset"], "method": "POST"}]}`,
+		"unknown":  `{"changes": [], "extra": true}`,
+	} {
+		bad := filepath.Join(dir, name+".json")
+		os.WriteFile(bad, []byte(data), 0o644)
+		if _, err := LoadPlan(bad); err == nil {
+			t.Errorf("LoadPlan accepted a plan with a bad %s", name)
+		}
+	}
+}
+
+func TestParseParameter(t *testing.T) {
+	for _, tt := range []struct {
+		arg   string
+		name  string
+		value string // JSON of the parsed value
+	}{
+		{"ResetType=On", "ResetType", `"On"`},
+		{"Version=1.10", "Version", `"1.10"`},
+		{"Id=007", "Id", `"007"`},
+		{"Empty=", "Empty", `""`},
+		{"Expr=a=b", "Expr", `"a=b"`},
+		{"Count:=3", "Count", `3`},
+		{"Version:=1.10", "Version", `1.10`},
+		{"Enabled:=true", "Enabled", `true`},
+		{"Nothing:=null", "Nothing", `null`},
+		{`Targets:=["a","b"]`, "Targets", `["a","b"]`},
+		{`Body:={"k":1}`, "Body", `{"k":1}`},
+	} {
+		name, value, err := ParseParameter(tt.arg)
+		if err != nil {
+			t.Errorf("ParseParameter(%q): %v", tt.arg, err)
+			continue
+		}
+		data, _ := json.Marshal(value)
+		if name != tt.name || string(data) != tt.value {
+			t.Errorf("ParseParameter(%q) = %s, %s; want %s, %s", tt.arg, name, data, tt.name, tt.value)
+		}
+	}
+	for _, arg := range []string{"ResetType", "=On", ":=1", "Count:=", "Count:=007", "Count:=1 2", "Flag:=yes"} {
+		if _, _, err := ParseParameter(arg); err == nil {
+			t.Errorf("ParseParameter(%q) accepted", arg)
+		}
+	}
+}
+
+// logEntries returns an expanded LogEntryCollection of at least size bytes,
+// as services return SEL and event logs of thousands of entries
+func logEntries(size int) []byte {
+	var b bytes.Buffer
+	b.WriteString(`{"@odata.id": "/redfish/v1/Managers/1/LogServices/SEL/Entries", "@odata.type": "#LogEntryCollection.LogEntryCollection", "Name": "Log Entries", "Members": [`)
+	for i := 0; b.Len() < size; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `{"@odata.id": "/redfish/v1/Managers/1/LogServices/SEL/Entries/%d", "@odata.type": "#LogEntry.v1_15_0.LogEntry", "Id": "%d", "Name": "Log Entry %d", "Created": "2024-03-02T04:11:%02d+00:00", "EntryType": "SEL", "Severity": "OK", "Message": "The system inlet temperature is within range.", "MessageId": "TMP0120", "MessageArgs": ["System Board Inlet Temp"], "SensorNumber": 1, "Links": {"OriginOfCondition": {"@odata.id": "/redfish/v1/Chassis/1/Sensors/InletTemp"}}, "Oem": {"Vendor": {"Category": "Temperature", "Acknowledged": false}}}`, i, i, i, i%60)
+	}
+	b.WriteString(`], "Members@odata.count": 0}`)
+	return b.Bytes()
+}
+
+func BenchmarkParse_LogEntries(b *testing.B) {
+	for _, size := range []int{1 << 20, 10 << 20} {
+		data := logEntries(size)
+		b.Run(fmt.Sprintf("%dMB", size>>20), func(b *testing.B) {
+			parser := NewParser(ParserOptions{})
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := parser.Parse("/redfish/v1/Managers/1/LogServices/SEL/Entries", data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// deepPathVFS returns a VFS holding the resources deepPath crosses: a
+// system, the chassis it links and the chassis' Thermal
+func deepPathVFS() *vfs {
+	cache := newMockCache()
+	cache.loadJSON("/redfish/v1", serviceRoot)
+	cache.loadJSON("/redfish/v1/Systems", systemsCollection)
+	cache.loadJSON("/redfish/v1/Systems/1", system1)
+	cache.loadJSON("/redfish/v1/Chassis/1", []byte(`{"@odata.id": "/redfish/v1/Chassis/1", "Thermal": {"@odata.id": "/redfish/v1/Chassis/1/Thermal"}}`))
+	cache.loadJSON("/redfish/v1/Chassis/1/Thermal", []byte(`{"@odata.id": "/redfish/v1/Chassis/1/Thermal", "Fans": [
+		{"Name": "Fan 1", "Status": {"Health": "OK", "State": "Enabled"}},
+		{"Name": "Fan 2", "Status": {"Health": "Warning", "State": "Enabled"}}]}`))
+	return &vfs{cache: cache, root: DefaultRoot}
+}
+
+// deepPath is a composite path through properties, a link, a child and
+// array elements, resolved from /redfish/v1/Systems/1/Status
+const deepPath = "../Links/Chassis[0]/Thermal/Fans[1]/Status/Health"
+
+func BenchmarkResolveTarget_Deep(b *testing.B) {
+	vfs := deepPathVFS()
+	b.ReportAllocs()
+	for b.Loop() {
+		target, err := vfs.ResolveTarget("/redfish/v1/Systems/1/Status", deepPath)
+		if err != nil || target.Property.Value != "Warning" {
+			b.Fatalf("ResolveTarget = %+v, %v", target, err)
+		}
+	}
+}
+
+// TestAllocationBudget holds parsing and path resolution to the
+// allocations the benchmarks above measured, which unlike their timings do
+// not depend on the machine. Raise a budget only for a reason.
+func TestAllocationBudget(t *testing.T) {
+	const (
+		allocsPerLogEntry = 72 // Parse of an expanded log entry
+		allocsPerResolve  = 7  // ResolveTarget of the deep composite path
+	)
+
+	data := logEntries(64 << 10)
+	parser := NewParser(ParserOptions{})
+	res, err := parser.Parse("/redfish/v1/Managers/1/LogServices/SEL/Entries", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := len(res.Properties["Members"].Elements)
+	allocs := testing.AllocsPerRun(10, func() {
+		parser.Parse("/redfish/v1/Managers/1/LogServices/SEL/Entries", data)
+	})
+	if perEntry := allocs / float64(entries); perEntry > allocsPerLogEntry {
+		t.Errorf("Parse allocates %.1f times per log entry, budget %d", perEntry, allocsPerLogEntry)
+	}
+
+	vfs := deepPathVFS()
+	allocs = testing.AllocsPerRun(100, func() {
+		vfs.ResolveTarget("/redfish/v1/Systems/1/Status", deepPath)
+	})
+	if allocs > allocsPerResolve {
+		t.Errorf("ResolveTarget allocates %.0f times, budget %d", allocs, allocsPerResolve)
+	}
+}
+
+func BenchmarkResourceCache_Load(b *testing.B) {
+	file := filepath.Join(b.TempDir(), "cache.json")
+	parser := NewParser(ParserOptions{})
+	saved := NewResourceCache(nil, parser, file)
+	for i := range 5000 {
+		path := fmt.Sprintf("/redfish/v1/Systems/1/LogServices/Sel/Entries/%d", i)
+		resource, err := parser.Parse(path, logEntries(4<<10))
+		if err != nil {
+			b.Fatal(err)
+		}
+		saved.Put(resource)
+	}
+	if err := saved.Save(); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := NewOfflineCache(file); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	r := Redaction{Properties: []string{"^SerialNumber$", "MACAddress"}}
+	if err := r.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, property, raw, want string
+	}{
+		{"resource", "", `{"Id": "1", "SerialNumber": "SN1", "Model": "R750"}`, `{"Id":"1","SerialNumber":"***","Model":"R750"}`},
+		{"nested", "", `{"Oem": {"Dell": {"SerialNumber": 42}}, "Ports": [{"MACAddress": "aa:bb"}]}`, `{"Oem":{"Dell":{"SerialNumber":"***"}},"Ports":[{"MACAddress":"***"}]}`},
+		{"array masked whole", "", `{"PermanentMACAddresses": ["aa", "bb"]}`, `{"PermanentMACAddresses":"***"}`},
+		{"null kept", "", `{"SerialNumber": null}`, `{"SerialNumber":null}`},
+		{"escapes kept", "", `{"Name": "a \"b\""}`, `{"Name":"a \"b\""}`},
+		{"property", "SerialNumber", `"SN1"`, `"***"`},
+		{"other property", "Model", `"R750"`, `"R750"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(r.Redact(tt.property, []byte(tt.raw))); got != tt.want {
+				t.Errorf("Redact = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	bad := Redaction{Properties: []string{"("}}
+	if err := bad.Compile(); err == nil {
+		t.Error("Compile accepted an invalid pattern")
+	}
+	var none Redaction
+	if raw := `{"SerialNumber": "SN1"}`; string(none.Redact("", []byte(raw))) != raw {
+		t.Error("a redaction without patterns changed the JSON")
+	}
+}
+
+func TestPowerWatch(t *testing.T) {
+	var mu sync.Mutex
+	resources := map[string]string{
+		"/redfish/v1":         `{"@odata.id": "/redfish/v1", "Systems": {"@odata.id": "/redfish/v1/Systems"}, "Chassis": {"@odata.id": "/redfish/v1/Chassis"}}`,
+		"/redfish/v1/Systems": `{"@odata.id": "/redfish/v1/Systems", "Members": [{"@odata.id": "/redfish/v1/Systems/1"}]}`,
+		"/redfish/v1/Systems/1": `{"@odata.id": "/redfish/v1/Systems/1", "@odata.type": "#ComputerSystem.v1_20_0.ComputerSystem",
+			"PowerState": "On", "Boot": {"BootOrder": ["Pxe"]}, "Memory": {"@odata.id": "/redfish/v1/Systems/1/Memory"}}`,
+		"/redfish/v1/Systems/1/Memory": `{"@odata.id": "/redfish/v1/Systems/1/Memory", "Members": []}`,
+		"/redfish/v1/Chassis/1":        `{"@odata.id": "/redfish/v1/Chassis/1", "@odata.type": "#Chassis.v1_25_0.Chassis", "PowerState": "On"}`,
+	}
+	var selects []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.RawQuery != "" {
+			selects = append(selects, r.URL.Path+"?"+r.URL.Query().Get("$select"))
+		}
+		payload, ok := resources[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(payload))
+	}))
+	defer server.Close()
+	v, err := NewVFS(server.URL, "", "", true, Options{CacheFile: filepath.Join(t.TempDir(), "cache.json")})
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+
+	for path, want := range map[string]string{
+		"/redfish/v1/Systems/1":                "/redfish/v1/Systems/1",
+		"/redfish/v1/Systems/1/Boot/BootOrder": "/redfish/v1/Systems/1",
+		"/redfish/v1/Systems/1/Memory":         "/redfish/v1/Systems/1",
+		"/redfish/v1/Chassis/1":                "",
+		"/redfish/v1":                          "",
+	} {
+		if got := HostSystem(v, path); got != want {
+			t.Errorf("HostSystem(%s) = %q, want %q", path, got, want)
+		}
+	}
+
+	var watch PowerWatch
+	if change, err := watch.Check(v, "/redfish/v1/Systems/1"); change != nil || err != nil {
+		t.Errorf("Check before a change = %v, %v", change, err)
+	}
+
+	// Someone else powers the system off
+	mu.Lock()
+	resources["/redfish/v1/Systems/1"] = strings.Replace(resources["/redfish/v1/Systems/1"], `"On"`, `"Off"`, 1)
+	mu.Unlock()
+	change, err := watch.Check(v, "/redfish/v1/Systems/1")
+	if err != nil || change == nil {
+		t.Fatalf("Check after a change = %v, %v", change, err)
+	}
+	if want := (PowerChange{System: "/redfish/v1/Systems/1", Cached: "On", Now: "Off"}); *change != want {
+		t.Errorf("Check = %+v, want %+v", *change, want)
+	}
+	if change, _ := watch.Check(v, "/redfish/v1/Systems/1"); change != nil {
+		t.Errorf("Check reported %v twice", change)
+	}
+
+	// The cached system is left alone until refreshed
+	res, _ := v.Get("/redfish/v1/Systems/1")
+	if res.Properties["PowerState"].Value != "On" {
+		t.Errorf("polling replaced the cached system: PowerState %v", res.Properties["PowerState"].Value)
+	}
+	for _, p := range v.GetKnownPaths() {
+		if strings.Contains(p, "?") {
+			t.Errorf("polling left %s in the cache", p)
+		}
+	}
+	mu.Lock()
+	if len(selects) == 0 || selects[0] != "/redfish/v1/Systems/1?PowerState" {
+		t.Errorf("polls = %q, want $select=PowerState", selects)
+	}
+	mu.Unlock()
+
+	v.Invalidate("/redfish/v1/Systems/1")
+	if change, err := watch.Check(v, "/redfish/v1/Systems/1"); change != nil || err != nil {
+		t.Errorf("Check after a refresh = %v, %v", change, err)
+	}
+}
+
+func TestWatchList(t *testing.T) {
+	var mu sync.Mutex
+	resources := map[string]string{
+		"/redfish/v1":           `{"@odata.id": "/redfish/v1"}`,
+		"/redfish/v1/Systems/1": `{"@odata.id": "/redfish/v1/Systems/1", "PowerState": "On", "Status": {"Health": "OK"}}`,
+		"/redfish/v1/Chassis/1": `{"@odata.id": "/redfish/v1/Chassis/1", "Name": "Chassis"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		payload, ok := resources[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(payload))
+	}))
+	defer server.Close()
+	v, err := NewVFS(server.URL, "", "", true, Options{CacheFile: filepath.Join(t.TempDir(), "cache.json")})
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+
+	var watch WatchList
+	if !watch.Add("/redfish/v1/Systems/1") || !watch.Add("/redfish/v1/Chassis/1") || watch.Add("/redfish/v1/Systems/1") {
+		t.Error("Add did not report what was new")
+	}
+	if paths := watch.Paths(); !slices.Equal(paths, []string{"/redfish/v1/Chassis/1", "/redfish/v1/Systems/1"}) {
+		t.Errorf("Paths = %q", paths)
+	}
+
+	// Nothing cached yet to differ from
+	if changes, err := watch.Check(v); changes != nil || err != nil {
+		t.Errorf("first Check = %+v, %v", changes, err)
+	}
+
+	mu.Lock()
+	resources["/redfish/v1/Systems/1"] = `{"@odata.id": "/redfish/v1/Systems/1", "PowerState": "Off", "Status": {"Health": "OK"}}`
+	mu.Unlock()
+	changes, err := watch.Check(v)
+	if err != nil || len(changes) != 1 || changes[0].Path != "/redfish/v1/Systems/1" {
+		t.Fatalf("Check after a change = %+v, %v", changes, err)
+	}
+	if d := changes[0].Differences; len(d) != 1 || d[0].Property != "PowerState" || d[0].Old.Value != "On" || d[0].New.Value != "Off" {
+		t.Errorf("differences = %+v", d)
+	}
+	if changes, _ := watch.Check(v); changes != nil {
+		t.Errorf("Check reported %+v twice", changes)
+	}
+
+	// A resource gone is an error; the others are still refreshed
+	mu.Lock()
+	delete(resources, "/redfish/v1/Chassis/1")
+	mu.Unlock()
+	if _, err := watch.Check(v); err == nil {
+		t.Error("Check of a missing resource succeeded")
+	}
+	if !watch.Remove("/redfish/v1/Chassis/1") || watch.Remove("/redfish/v1/Chassis/1") {
+		t.Error("Remove did not report what was there")
+	}
+	watch.Clear()
+	if len(watch.Paths()) != 0 {
+		t.Errorf("Clear left %q", watch.Paths())
+	}
+}
+
+func TestSimulation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"@odata.id": %q, "Name": "Thing"}`, r.URL.Path)
+	}))
+	defer server.Close()
+	connect := func(t *testing.T, sim Simulation) VFS {
+		t.Helper()
+		if err := sim.Validate(); err != nil {
+			t.Fatalf("Validate failed: %v", err)
+		}
+		v, err := NewVFS(server.URL, "", "", true, Options{
+			CacheFile: filepath.Join(t.TempDir(), "cache.json"),
+			Transport: sim.Transport(NewTransport(true)),
+		})
+		if err != nil {
+			t.Fatalf("NewVFS failed: %v", err)
+		}
+		return v
+	}
+
+	t.Run("latency", func(t *testing.T) {
+		v := connect(t, Simulation{Latency: 30 * time.Millisecond, Jitter: 10 * time.Millisecond})
+		start := time.Now()
+		if _, err := v.Get("/redfish/v1/Systems/1"); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+			t.Errorf("Get took %v, want at least the 30ms latency", elapsed)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		v := connect(t, Simulation{Errors: 1})
+		_, err := v.Get("/redfish/v1/Systems/1")
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("Get error = %v, want an HTTP 500", err)
+		}
+		if len(httpErr.Messages) == 0 || !strings.Contains(httpErr.Messages[0].Message, "Simulated") {
+			t.Errorf("Messages = %v, want the simulated error", httpErr.Messages)
+		}
+		if !Retryable(err) {
+			t.Errorf("simulated 500 is not retryable")
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		v := connect(t, Simulation{Malformed: 1})
+		if _, err := v.Get("/redfish/v1/Systems/1"); err == nil {
+			t.Fatal("Get of a malformed payload succeeded")
+		}
+	})
+
+	t.Run("seeded", func(t *testing.T) {
+		outcomes := func() string {
+			v := connect(t, Simulation{Errors: 0.5, Seed: 42})
+			var b strings.Builder
+			for i := range 20 {
+				if _, err := v.Get(fmt.Sprintf("/redfish/v1/Systems/%d", i)); err != nil {
+					b.WriteByte('x')
+				} else {
+					b.WriteByte('.')
+				}
+			}
+			return b.String()
+		}
+		first := outcomes()
+		if !strings.Contains(first, "x") || !strings.Contains(first, ".") {
+			t.Errorf("outcomes = %s, want some failures and some successes", first)
+		}
+		if second := outcomes(); second != first {
+			t.Errorf("outcomes with the same seed differ: %s, %s", first, second)
+		}
+	})
+
+	for _, sim := range []Simulation{{Errors: 1.5}, {Malformed: -0.1}, {Latency: -time.Second}} {
+		if err := sim.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded", sim)
+		}
+	}
+	if got, want := (Simulation{Latency: 200 * time.Millisecond, Jitter: 300 * time.Millisecond, Errors: 0.05}).String(), "latency 200ms (+300ms), 5% errors"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestSessionStore(t *testing.T) {
+	var mu sync.Mutex
+	sessions := map[string]bool{} // Live tokens
+	logins, deletes := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/redfish/v1/SessionService/Sessions":
+			logins++
+			token := fmt.Sprintf("token%d", logins)
+			sessions[token] = true
+			w.Header().Set("X-Auth-Token", token)
+			w.Header().Set("Location", "/redfish/v1/SessionService/Sessions/"+token)
+			w.WriteHeader(http.StatusCreated)
+		case r.URL.Path == "/redfish/v1":
+			w.Write([]byte(`{"@odata.id": "/redfish/v1", "Systems": {"@odata.id": "/redfish/v1/Systems"}}`))
+		case !sessions[r.Header.Get("X-Auth-Token")]:
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Method == http.MethodDelete:
+			deletes++
+			delete(sessions, strings.TrimPrefix(r.URL.Path, "/redfish/v1/SessionService/Sessions/"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Write([]byte(`{"@odata.id": "/redfish/v1/Systems", "Members": []}`))
+		}
+	}))
+	defer server.Close()
+
+	store := NewSessionStore(filepath.Join(t.TempDir(), "state", "sessions.json"))
+	connect := func() VFS {
+		t.Helper()
+		v, err := NewVFS(server.URL, "admin", "secret", true, Options{CacheFile: filepath.Join(t.TempDir(), "cache.json"), Sessions: store})
+		if err != nil {
+			t.Fatalf("NewVFS failed: %v", err)
+		}
+		return v
+	}
+	fetch := func(v VFS) {
+		t.Helper()
+		v.Invalidate("/redfish/v1/Systems")
+		if _, err := v.Get("/redfish/v1/Systems"); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+	}
+	counts := func() (int, int) {
+		mu.Lock()
+		defer mu.Unlock()
+		return logins, deletes
+	}
+
+	// Two connections share one session
+	shell, tui := connect(), connect()
+	fetch(shell)
+	fetch(tui)
+	if l, _ := counts(); l != 1 {
+		t.Errorf("logins = %d, want 1 shared session", l)
+	}
+	if shell.Session() != tui.Session() {
+		t.Errorf("sessions differ: %s, %s", shell.Session(), tui.Session())
+	}
+
+	// An expired session is replaced once, and the other connection moves
+	// to the replacement
+	mu.Lock()
+	clear(sessions)
+	mu.Unlock()
+	fetch(shell)
+	fetch(tui)
+	if l, _ := counts(); l != 2 {
+		t.Errorf("logins after expiry = %d, want 2", l)
+	}
+
+	// The session outlives the first logout and goes with the last
+	if err := shell.Logout(); err != nil {
+		t.Fatalf("Logout failed: %v", err)
+	}
+	if _, d := counts(); d != 0 {
+		t.Errorf("deletes after the first logout = %d, want 0", d)
+	}
+	fetch(tui)
+	if err := tui.Logout(); err != nil {
+		t.Fatalf("Logout failed: %v", err)
+	}
+	if l, d := counts(); l != 2 || d != 1 {
+		t.Errorf("logins, deletes after the last logout = %d, %d, want 2, 1", l, d)
+	}
+	info, err := os.Stat(store.path)
+	if err != nil {
+		t.Fatalf("store not written: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("store mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// A session left by a process that died is taken over
+	dead := NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	key := "admin@" + server.URL
+	if _, _, err := dead.acquire(key, "gone", "", false, func() (string, string, error) {
+		return "token9", "/redfish/v1/SessionService/Sessions/token9", nil
+	}); err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	sessionsFile, _ := dead.read()
+	sessionsFile[key].Holders[0].PID = 1 << 30
+	dead.write(sessionsFile)
+	if last, err := dead.release(key, "other", "token9"); err != nil || !last {
+		t.Errorf("release with only a dead holder = %v, %v, want the last", last, err)
+	}
+}
+
+func TestResolveChain(t *testing.T) {
+	vfs := deepPathVFS()
+
+	target, chain, err := vfs.ResolveChain("/redfish/v1/Systems/1/Status", deepPath)
+	if err != nil {
+		t.Fatalf("ResolveChain failed: %v", err)
+	}
+	if target.Property == nil || target.Property.Value != "Warning" {
+		t.Errorf("target = %+v", target)
+	}
+	want := []Hop{{From: "/redfish/v1/Systems/1/Links/Chassis[0]", To: "/redfish/v1/Chassis/1"}}
+	if !slices.Equal(chain, want) {
+		t.Errorf("chain = %+v, want %+v", chain, want)
+	}
+
+	// A path ending on a link ends with the hop open would take
+	target, chain, err = vfs.ResolveChain("/redfish/v1/Systems/1", "Links/Chassis[0]")
+	if err != nil {
+		t.Fatalf("ResolveChain failed: %v", err)
+	}
+	if target.Type != TargetLink || len(chain) != 1 || chain[0].To != "/redfish/v1/Chassis/1" {
+		t.Errorf("ResolveChain(Links/Chassis[0]) = %+v, %+v", target, chain)
+	}
+
+	// Children are not links to report
+	if _, chain, err := vfs.ResolveChain("/redfish/v1", "Systems/1/Status"); err != nil || len(chain) != 0 {
+		t.Errorf("ResolveChain(Systems/1/Status) = %+v, %v", chain, err)
+	}
+}
+
+// statusMutator answers every write with one status, counting the POSTs
+type statusMutator struct {
+	status int
+	posts  int
+}
+
+func (m *statusMutator) Post(path string, body []byte) (*Response, error) {
+	m.posts++
+	return &Response{StatusCode: m.status}, nil
+}
+
+func (m *statusMutator) Patch(path string, body []byte) (*Response, error) {
+	return &Response{StatusCode: m.status}, nil
+}
+
+// TestPostGuard tests that an action POSTed again within RepeatWindow is
+// held back, and only that one
+func TestPostGuard(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := &PostGuard{now: func() time.Time { return now }}
+	target := "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset"
+	restart := []byte(`{"ResetType":"ForceRestart"}`)
+
+	if err := g.Check(target, restart); err != nil {
+		t.Fatalf("Check before any POST = %v", err)
+	}
+	m := &statusMutator{status: http.StatusNoContent}
+	if _, err := g.Post(m, target, restart, false); err != nil || m.posts != 1 {
+		t.Fatalf("Post = %v after %d POSTs", err, m.posts)
+	}
+
+	now = now.Add(2 * time.Second)
+	var repeat *RepeatError
+	if err := g.Check(target, restart); !errors.As(err, &repeat) || repeat.Ago != 2*time.Second {
+		t.Errorf("Check of a repeat = %v", err)
+	}
+	if err := g.Check(target, []byte(`{"ResetType":"On"}`)); err != nil {
+		t.Errorf("Check with another body = %v", err)
+	}
+	if err := g.Check("/redfish/v1/Systems/2/Actions/ComputerSystem.Reset", restart); err != nil {
+		t.Errorf("Check of another target = %v", err)
+	}
+	if _, err := g.Post(m, target, restart, false); !errors.As(err, &repeat) || m.posts != 1 {
+		t.Errorf("Post of a repeat = %v after %d POSTs", err, m.posts)
+	}
+	if _, err := g.Post(m, target, restart, true); err != nil || m.posts != 2 {
+		t.Errorf("Post of a repeat sent again = %v after %d POSTs", err, m.posts)
+	}
+
+	now = now.Add(RepeatWindow)
+	if err := g.Check(target, restart); err != nil {
+		t.Errorf("Check after RepeatWindow = %v", err)
+	}
+
+	// A POST the service refuses can be retried at once
+	m.status = http.StatusBadRequest
+	if _, err := g.Post(m, target, restart, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Check(target, restart); err != nil {
+		t.Errorf("Check after a refused POST = %v", err)
+	}
+}
+
+func TestResourcePrivileges(t *testing.T) {
+	resources := map[string]string{
+		"/redfish/v1": `{"@odata.id": "/redfish/v1",
+			"AccountService": {"@odata.id": "/redfish/v1/AccountService"},
+			"Registries": {"@odata.id": "/redfish/v1/Registries"},
+			"Managers": {"@odata.id": "/redfish/v1/Managers"},
+			"Systems": {"@odata.id": "/redfish/v1/Systems"}}`,
+		"/redfish/v1/AccountService": `{"@odata.id": "/redfish/v1/AccountService",
+			"Roles": {"@odata.id": "/redfish/v1/AccountService/Roles"}}`,
+		"/redfish/v1/AccountService/Roles": `{"@odata.id": "/redfish/v1/AccountService/Roles",
+			"Members": [{"@odata.id": "/redfish/v1/AccountService/Roles/Administrator"},
+				{"@odata.id": "/redfish/v1/AccountService/Roles/Operator"},
+				{"@odata.id": "/redfish/v1/AccountService/Roles/ReadOnly"}]}`,
+		"/redfish/v1/AccountService/Roles/Administrator": `{"@odata.id": "/redfish/v1/AccountService/Roles/Administrator",
+			"Id": "Administrator", "AssignedPrivileges": ["Login", "ConfigureManager", "ConfigureUsers", "ConfigureComponents", "ConfigureSelf"]}`,
+		"/redfish/v1/AccountService/Roles/Operator": `{"@odata.id": "/redfish/v1/AccountService/Roles/Operator",
+			"Id": "Operator", "AssignedPrivileges": ["Login", "ConfigureComponents", "ConfigureSelf"]}`,
+		"/redfish/v1/AccountService/Roles/ReadOnly": `{"@odata.id": "/redfish/v1/AccountService/Roles/ReadOnly",
+			"Id": "ReadOnly", "AssignedPrivileges": ["Login", "ConfigureSelf"]}`,
+		"/redfish/v1/Registries": `{"@odata.id": "/redfish/v1/Registries",
+			"Members": [{"@odata.id": "/redfish/v1/Registries/Base"}, {"@odata.id": "/redfish/v1/Registries/PrivilegeRegistry"}]}`,
+		"/redfish/v1/Registries/Base": `{"@odata.id": "/redfish/v1/Registries/Base",
+			"Registry": "Base.1.15", "Location": [{"Uri": "/redfish/v1/Registries/Base/Base.json"}]}`,
+		"/redfish/v1/Registries/PrivilegeRegistry": `{"@odata.id": "/redfish/v1/Registries/PrivilegeRegistry",
+			"Registry": "Redfish_1.3.0_PrivilegeRegistry",
+			"Location": [{"Uri": "/redfish/v1/Registries/PrivilegeRegistry/PrivilegeRegistry.json"}]}`,
+		"/redfish/v1/Registries/PrivilegeRegistry/PrivilegeRegistry.json": `{
+			"@odata.type": "#PrivilegeRegistry.v1_1_4.PrivilegeRegistry",
+			"Id": "Redfish_1.3.0_PrivilegeRegistry",
+			"Mappings": [
+				{"Entity": "ComputerSystem", "OperationMap": {
+					"GET": [{"Privilege": ["Login"]}],
+					"PATCH": [{"Privilege": ["ConfigureComponents"]}],
+					"POST": [{"Privilege": ["ConfigureComponents"]}]},
+				 "PropertyOverrides": [{"Targets": ["AssetTag"], "OperationMap": {"PATCH": [{"Privilege": ["ConfigureManager"]}]}}]},
+				{"Entity": "EthernetInterface", "OperationMap": {
+					"GET": [{"Privilege": ["Login"]}],
+					"PATCH": [{"Privilege": ["ConfigureComponents"]}]},
+				 "SubordinateOverrides": [{"Targets": ["Manager", "EthernetInterfaceCollection"], "OperationMap": {
+					"GET": [{"Privilege": ["Login"]}],
+					"PATCH": [{"Privilege": ["ConfigureManager"]}, {"Privilege": ["ConfigureSelf"]}]}}]}
+			]}`,
+		"/redfish/v1/Systems": `{"@odata.id": "/redfish/v1/Systems",
+			"@odata.type": "#ComputerSystemCollection.ComputerSystemCollection",
+			"Members": [{"@odata.id": "/redfish/v1/Systems/1"}]}`,
+		"/redfish/v1/Systems/1": `{"@odata.id": "/redfish/v1/Systems/1",
+			"@odata.type": "#ComputerSystem.v1_20_0.ComputerSystem", "Id": "1"}`,
+		"/redfish/v1/Managers": `{"@odata.id": "/redfish/v1/Managers",
+			"@odata.type": "#ManagerCollection.ManagerCollection",
+			"Members": [{"@odata.id": "/redfish/v1/Managers/1"}]}`,
+		"/redfish/v1/Managers/1": `{"@odata.id": "/redfish/v1/Managers/1",
+			"@odata.type": "#Manager.v1_19_0.Manager", "Id": "1",
+			"EthernetInterfaces": {"@odata.id": "/redfish/v1/Managers/1/EthernetInterfaces"}}`,
+		"/redfish/v1/Managers/1/EthernetInterfaces": `{"@odata.id": "/redfish/v1/Managers/1/EthernetInterfaces",
+			"@odata.type": "#EthernetInterfaceCollection.EthernetInterfaceCollection",
+			"Members": [{"@odata.id": "/redfish/v1/Managers/1/EthernetInterfaces/1"}]}`,
+		"/redfish/v1/Managers/1/EthernetInterfaces/1": `{"@odata.id": "/redfish/v1/Managers/1/EthernetInterfaces/1",
+			"@odata.type": "#EthernetInterface.v1_12_0.EthernetInterface", "Id": "1"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, ok := resources[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(payload))
+	}))
+	defer server.Close()
+	v, err := NewVFS(server.URL, "", "", true, Options{CacheFile: filepath.Join(t.TempDir(), "cache.json")})
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+
+	m, err := ResourcePrivileges(v, "/redfish/v1/Systems/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Entity != "ComputerSystem" || m.Registry != "Redfish_1.3.0_PrivilegeRegistry" || m.Override != "" || len(m.Operations) != len(PrivilegeOperations) {
+		t.Fatalf("map = %+v", m)
+	}
+	get, patch, put := m.Operations[0], m.Operations[2], m.Operations[4]
+	if !slices.Equal(get.Roles, []string{"Administrator", "Operator", "ReadOnly"}) {
+		t.Errorf("GET roles = %v", get.Roles)
+	}
+	if !slices.Equal(patch.Roles, []string{"Administrator", "Operator"}) {
+		t.Errorf("PATCH roles = %v", patch.Roles)
+	}
+	if put.Privileges != nil || put.Roles != nil {
+		t.Errorf("unmapped PUT = %+v", put)
+	}
+	if len(m.Properties) != 1 || m.Properties[0].Property != "AssetTag" || !slices.Equal(m.Properties[0].Access.Roles, []string{"Administrator"}) {
+		t.Errorf("property overrides = %+v", m.Properties)
+	}
+
+	// A manager's interface falls under the subordinate override
+	m, err = ResourcePrivileges(v, "/redfish/v1/Managers/1/EthernetInterfaces/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch = m.Operations[2]
+	if m.Override != "subordinate to Manager/EthernetInterfaceCollection" || !slices.Equal(patch.Roles, []string{"Administrator"}) ||
+		!slices.Equal(patch.SelfRoles, []string{"Operator", "ReadOnly"}) {
+		t.Errorf("subordinate map = %+v, PATCH %+v", m, patch)
+	}
+
+	if _, err := ResourcePrivileges(v, "/redfish/v1/Managers/1"); err == nil || !strings.Contains(err.Error(), "does not map Manager") {
+		t.Errorf("unmapped entity error = %v", err)
+	}
+}
+
+func TestPrefetch(t *testing.T) {
+	resources := map[string]string{
+		"/redfish/v1": `{"@odata.id": "/redfish/v1", "@odata.type": "#ServiceRoot.v1_15_0.ServiceRoot"}`,
+		"/redfish/v1/Systems/1": `{"@odata.id": "/redfish/v1/Systems/1", "@odata.type": "#ComputerSystem.v1_20_0.ComputerSystem",
+			"Memory": {"@odata.id": "/redfish/v1/Systems/1/Memory"},
+			"Processors": {"@odata.id": "/redfish/v1/Systems/1/Processors"},
+			"LogServices": {"@odata.id": "/redfish/v1/Systems/1/LogServices"}}`,
+		"/redfish/v1/Systems/1/Processors": `{"@odata.id": "/redfish/v1/Systems/1/Processors", "Members": []}`,
+		"/redfish/v1/Systems/1/Memory":     `{"@odata.id": "/redfish/v1/Systems/1/Memory", "Members": []}`,
+	}
+	requests := make(map[string]int)
+	failing := make(map[string]bool)
+	var mu sync.Mutex
+	held := make(chan struct{}) // Answers wait for it while hold is set
+	hold, stall := false, false // stall leaves requests unanswered
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		wait, stalled, fail := hold, stall, failing[r.URL.Path]
+		mu.Unlock()
+		if wait {
+			<-held
+		}
+		if stalled {
+			<-r.Context().Done()
+			return
+		}
+		payload, ok := resources[r.URL.Path]
+		if !ok || fail {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	v, err := NewVFS(server.URL, "", "", true, Options{CacheFile: filepath.Join(t.TempDir(), "cache.json")})
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+	system, err := v.Get("/redfish/v1/Systems/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Hinted children in hint order, those the type does not hint at left out
+	want := []string{"/redfish/v1/Systems/1/Processors", "/redfish/v1/Systems/1/Memory"}
+	if got := PrefetchHints(system); !slices.Equal(got, want) {
+		t.Errorf("PrefetchHints = %v, want %v", got, want)
+	}
+
+	var p Prefetcher
+	p.Warm(v, system)
+	p.Warm(v, system)
+	p.Wait()
+	for _, path := range want {
+		if v.Cached(path) == nil {
+			t.Errorf("%s not cached after Warm", path)
+		}
+		if requests[path] != 1 {
+			t.Errorf("%s fetched %d times", path, requests[path])
+		}
+	}
+	if requests["/redfish/v1/Systems/1/LogServices"] != 0 {
+		t.Error("Warm fetched a child its type does not hint at")
+	}
+
+	// Warming through a view bound to a command's context outlives the
+	// command, and a fetch that failed is started again
+	v.Invalidate("/redfish/v1/Systems/1/Processors")
+	v.Invalidate("/redfish/v1/Systems/1/Memory")
+	mu.Lock()
+	hold, failing["/redfish/v1/Systems/1/Memory"] = true, true
+	mu.Unlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	p = Prefetcher{}
+	p.Warm(WithContext(ctx, v), system)
+	cancel()
+	mu.Lock()
+	hold = false
+	mu.Unlock()
+	close(held)
+	p.Wait()
+	if v.Cached("/redfish/v1/Systems/1/Processors") == nil {
+		t.Error("Processors not cached after the warming command was cancelled")
+	}
+	mu.Lock()
+	failing["/redfish/v1/Systems/1/Memory"] = false
+	mu.Unlock()
+	p.Warm(v, system)
+	p.Wait()
+	if v.Cached("/redfish/v1/Systems/1/Memory") == nil {
+		t.Error("Memory not fetched again after its prefetch failed")
+	}
+
+	// A caller waiting on a fetch another caller's cancellation cut short
+	// fetches again
+	v.Invalidate("/redfish/v1/Systems/1/Processors")
+	mu.Lock()
+	stall = true
+	mu.Unlock()
+	ctx, cancel = context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := WithContext(ctx, v).Get("/redfish/v1/Systems/1/Processors")
+		first <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	second := make(chan error)
+	go func() {
+		_, err := v.Get("/redfish/v1/Systems/1/Processors")
+		second <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	stall = false
+	mu.Unlock()
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled Get = %v, want context.Canceled", err)
+	}
+	if err := <-second; err != nil {
+		t.Errorf("Get waiting on the cancelled fetch = %v", err)
+	}
+}
+
+func TestTopology(t *testing.T) {
+	resources := map[string]string{
+		"/redfish/v1": `{"@odata.id": "/redfish/v1",
+			"Chassis": {"@odata.id": "/redfish/v1/Chassis"},
+			"Systems": {"@odata.id": "/redfish/v1/Systems"},
+			"Managers": {"@odata.id": "/redfish/v1/Managers"}}`,
+		"/redfish/v1/Chassis": `{"@odata.id": "/redfish/v1/Chassis",
+			"Members": [{"@odata.id": "/redfish/v1/Chassis/Rack"}, {"@odata.id": "/redfish/v1/Chassis/Blade"},
+				{"@odata.id": "/redfish/v1/Chassis/Loop"}]}`,
+		"/redfish/v1/Chassis/Rack": `{"@odata.id": "/redfish/v1/Chassis/Rack", "Id": "Rack", "ChassisType": "Rack",
+			"Links": {"Contains": [{"@odata.id": "/redfish/v1/Chassis/Blade"}],
+				"ComputerSystems": [{"@odata.id": "/redfish/v1/Systems/1"}]}}`,
+		"/redfish/v1/Chassis/Blade": `{"@odata.id": "/redfish/v1/Chassis/Blade", "Id": "Blade", "Name": "Blade 1", "ChassisType": "Blade",
+			"Status": {"Health": "OK"}, "Links": {"ContainedBy": {"@odata.id": "/redfish/v1/Chassis/Loop"}}}`,
+		"/redfish/v1/Chassis/Loop": `{"@odata.id": "/redfish/v1/Chassis/Loop", "Id": "Loop",
+			"Links": {"ContainedBy": {"@odata.id": "/redfish/v1/Chassis/Blade"}}}`,
+		"/redfish/v1/Systems": `{"@odata.id": "/redfish/v1/Systems", "Members": [{"@odata.id": "/redfish/v1/Systems/1"}]}`,
+		"/redfish/v1/Systems/1": `{"@odata.id": "/redfish/v1/Systems/1", "Id": "1", "SystemType": "Physical",
+			"Links": {"Chassis": [{"@odata.id": "/redfish/v1/Chassis/Blade"}]}}`,
+		"/redfish/v1/Managers": `{"@odata.id": "/redfish/v1/Managers", "Members": [{"@odata.id": "/redfish/v1/Managers/BMC"}]}`,
+		"/redfish/v1/Managers/BMC": `{"@odata.id": "/redfish/v1/Managers/BMC", "Id": "BMC", "ManagerType": "BMC",
+			"Links": {"ManagerInChassis": {"@odata.id": "/redfish/v1/Chassis/Blade"},
+				"ManagerForServers": [{"@odata.id": "/redfish/v1/Systems/1"}]}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, ok := resources[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	v, err := NewVFS(server.URL, "", "", true, Options{CacheFile: filepath.Join(t.TempDir(), "cache.json")})
+	if err != nil {
+		t.Fatalf("NewVFS failed: %v", err)
+	}
+
+	// The blade is in the rack, which lists it, and the loop, which it names
+	// as its own container, is placed in the blade instead of round again
+	nodes, err := Topology(v, v.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || nodes[0].ID != "Rack" || len(nodes[0].Contains) != 1 {
+		t.Fatalf("roots = %+v", nodes)
+	}
+	blade := nodes[0].Contains[0]
+	var inside []string
+	for _, node := range blade.Contains {
+		inside = append(inside, node.Kind+" "+node.ID)
+	}
+	if blade.ID != "Blade" || blade.Health != "OK" || !slices.Equal(inside, []string{"Chassis Loop", "ComputerSystem 1", "Manager BMC"}) {
+		t.Errorf("blade = %+v holding %v", blade, inside)
+	}
+
+	// The system is listed by the rack too, but placed in the innermost chassis
+	system := blade.Contains[1]
+	if system.Type != "Physical" || !slices.Equal(system.ManagedBy, []string{"BMC"}) {
+		t.Errorf("system = %+v", system)
+	}
+
+	nodes, err = Topology(v, "/redfish/v1/Chassis/Blade")
+	if err != nil || len(nodes) != 1 || nodes[0].ID != "Blade" {
+		t.Errorf("Topology of the blade = %v, %v", nodes, err)
+	}
+	if _, err := Topology(v, "/redfish/v1/Systems/1"); err == nil || !strings.Contains(err.Error(), "not a chassis") {
+		t.Errorf("Topology of a system error = %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package rvfstest
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// mockups is the fixture corpus: services of several vendors, laid out as
+// DMTF mockups are, each resource in an index.json under the directory its
+// path names
+//
+//go:embed mockups
+var mockups embed.FS
+
+// Mockups names the services of the fixture corpus, sorted
+func Mockups() []string {
+	entries, _ := mockups.ReadDir("mockups")
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return names
+}
+
+// Mockup returns the resources of a service of the fixture corpus, as
+// Service does. The map is new on every call. It panics for a name that is
+// not in the corpus.
+func Mockup(name string) map[string]string {
+	root := path.Join("mockups", name)
+	resources := make(map[string]string)
+	err := fs.WalkDir(mockups, root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() != "index.json" {
+			return err
+		}
+		payload, err := mockups.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		resources["/"+path.Dir(strings.TrimPrefix(file, root+"/"))] = string(payload)
+		return nil
+	})
+	if err != nil {
+		panic(fmt.Sprintf("rvfstest: mockup %s: %v", name, err))
+	}
+	return resources
+}
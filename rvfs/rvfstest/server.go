@@ -0,0 +1,292 @@
+// Package rvfstest provides a fake Redfish service for tests of rvfs and
+// the programs built on it. The service serves a configurable resource
+// tree over HTTP and can add latency, fail chosen resources and require a
+// session, so tests run a real VFS against it instead of mocking one.
+package rvfstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+)
+
+// sessionsPath is where clients create sessions
+const sessionsPath = "/redfish/v1/SessionService/Sessions"
+
+// Reply is the answer to a POST
+type Reply struct {
+	Status   int
+	Location string // Location header, for creates
+	Body     string
+}
+
+// PostHandler answers a POST to a resource given the request body
+type PostHandler func(body []byte) Reply
+
+// Server is a fake Redfish service. Resources are served from the tree at
+// their exact paths; anything else is 404. All methods are safe to call
+// while requests are served.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	resources map[string]string      // Path → JSON payload
+	faults    map[string]int         // Path → status served instead
+	posts     map[string]PostHandler // Path → POST handler
+	allow     map[string][]string    // Path → methods allowed beyond GET and HEAD
+	latency   time.Duration
+	user      string // Sessions required when set
+	pass      string
+	token     string
+	requests  map[string]int // "METHOD path" → requests seen
+	running   int            // Requests being served
+	peak      int            // Most requests served at once
+}
+
+// NewServer starts a fake service serving resources, a map of paths to
+// JSON payloads. Close it when done.
+func NewServer(resources map[string]string) *Server {
+	s := &Server{
+		resources: make(map[string]string),
+		faults:    make(map[string]int),
+		posts:     make(map[string]PostHandler),
+		allow:     make(map[string][]string),
+		requests:  make(map[string]int),
+	}
+	for path, payload := range resources {
+		s.resources[path] = payload
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Set adds or replaces the payload of a resource
+func (s *Server) Set(path, payload string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resources[path] = payload
+}
+
+// Remove deletes a resource, which then answers 404
+func (s *Server) Remove(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.resources, path)
+}
+
+// Fail makes every request for path answer status with a Redfish error;
+// status 0 restores the resource
+func (s *Server) Fail(path string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status == 0 {
+		delete(s.faults, path)
+		return
+	}
+	s.faults[path] = status
+}
+
+// SetLatency delays every answer by d
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// RequireSession makes every request but session creation answer 401
+// without a token from a session created as user with pass
+func (s *Server) RequireSession(user, pass string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user, s.pass = user, pass
+}
+
+// ExpireSessions invalidates the session tokens handed out so far
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = ""
+}
+
+// HandlePost answers POSTs to path with h; POSTs elsewhere answer 405
+func (s *Server) HandlePost(path string, h PostHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.posts[path] = h
+}
+
+// Allow adds methods to the Allow header a resource answers OPTIONS and
+// HEAD with. Every resource allows GET and HEAD, and POST once handled.
+// A PATCH allowed is served by merging its body into the resource; other
+// methods added are only reported, not served.
+func (s *Server) Allow(path string, methods ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.allow[path] = append(s.allow[path], methods...)
+}
+
+// Requests returns how many requests for method and path were served,
+// including failed ones
+func (s *Server) Requests(method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[method+" "+path]
+}
+
+// PeakRequests returns the most requests the service served at once
+func (s *Server) PeakRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.peak
+}
+
+// VFS connects a VFS to the service as admin with password "password",
+// failing the test if it cannot. Its cache file lives in a temporary
+// directory.
+func (s *Server) VFS(t testing.TB) rvfs.VFS {
+	t.Helper()
+	v, err := rvfs.NewVFS(s.URL, "admin", "password", false, rvfs.Options{
+		CacheFile: filepath.Join(t.TempDir(), "cache.json"),
+	})
+	if err != nil {
+		t.Fatalf("connecting to fake service: %v", err)
+	}
+	return v
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	path := r.URL.Path
+
+	s.mu.Lock()
+	s.requests[r.Method+" "+path]++
+	s.running++
+	s.peak = max(s.peak, s.running)
+	latency := s.latency
+	fault := s.faults[path]
+	payload, exists := s.resources[path]
+	post := s.posts[path]
+	allow := append([]string{"GET", "HEAD"}, s.allow[path]...)
+	if post != nil {
+		allow = append(allow, "POST")
+	}
+	authorized := s.user == "" || (s.token != "" && r.Header.Get("X-Auth-Token") == s.token)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.running--
+		s.mu.Unlock()
+	}()
+
+	time.Sleep(latency)
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == http.MethodPost && path == sessionsPath:
+		s.login(w, body)
+	case !authorized:
+		writeError(w, http.StatusUnauthorized, "Base.1.8.NoValidSession")
+	case fault != 0:
+		writeError(w, fault, "Base.1.8.InternalError")
+	case r.Method == http.MethodGet && exists:
+		fmt.Fprint(w, payload)
+	case (r.Method == http.MethodOptions || r.Method == http.MethodHead) && (exists || post != nil):
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPatch && exists && slices.Contains(allow, "PATCH"):
+		s.patch(w, r, path, body)
+	case r.Method == http.MethodPost && post != nil:
+		reply := post(body)
+		if reply.Location != "" {
+			w.Header().Set("Location", reply.Location)
+		}
+		w.WriteHeader(reply.Status)
+		fmt.Fprint(w, reply.Body)
+	case exists:
+		writeError(w, http.StatusMethodNotAllowed, "Base.1.8.OperationNotAllowed")
+	default:
+		writeError(w, http.StatusNotFound, "Base.1.8.ResourceMissingAtURI")
+	}
+}
+
+// patch merges a PATCH body into a resource, as a JSON merge patch, and
+// answers with the result. An If-Match that is not the resource's
+// @odata.etag fails with 412.
+func (s *Server) patch(w http.ResponseWriter, r *http.Request, path string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var resource, changes map[string]any
+	if err := json.Unmarshal([]byte(s.resources[path]), &resource); err != nil {
+		writeError(w, http.StatusInternalServerError, "Base.1.8.InternalError")
+		return
+	}
+	if err := json.Unmarshal(body, &changes); err != nil {
+		writeError(w, http.StatusBadRequest, "Base.1.8.MalformedJSON")
+		return
+	}
+	if etag, ok := resource["@odata.etag"].(string); ok && r.Header.Get("If-Match") != "" && r.Header.Get("If-Match") != etag {
+		writeError(w, http.StatusPreconditionFailed, "Base.1.8.PreconditionFailed")
+		return
+	}
+	mergePatch(resource, changes)
+	data, _ := json.Marshal(resource)
+	s.resources[path] = string(data)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// mergePatch applies changes to target: objects merge, null removes a
+// member, anything else replaces it
+func mergePatch(target, changes map[string]any) {
+	for name, value := range changes {
+		switch value := value.(type) {
+		case nil:
+			delete(target, name)
+		case map[string]any:
+			if existing, ok := target[name].(map[string]any); ok {
+				mergePatch(existing, value)
+			} else {
+				target[name] = value
+			}
+		default:
+			target[name] = value
+		}
+	}
+}
+
+// login creates a session, checking the credentials when sessions are
+// required
+func (s *Server) login(w http.ResponseWriter, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.user != "" {
+		var creds struct{ UserName, Password string }
+		if err := json.Unmarshal(body, &creds); err != nil || creds.UserName != s.user || creds.Password != s.pass {
+			writeError(w, http.StatusUnauthorized, "Base.1.8.GeneralError")
+			return
+		}
+	}
+	if s.token == "" {
+		s.token = fmt.Sprintf("token-%d", time.Now().UnixNano())
+	}
+	w.Header().Set("X-Auth-Token", s.token)
+	w.Header().Set("Location", sessionsPath+"/1")
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, `{"@odata.id": "%s/1", "Id": "1"}`, sessionsPath)
+}
+
+// writeError answers status with a Redfish error body
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"error": {"code": "%s", "message": "%s"}}`, code, http.StatusText(status))
+}
@@ -0,0 +1,138 @@
+package rvfstest
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bluefish-project/bluefish/rvfs"
+)
+
+func TestServer(t *testing.T) {
+	s := NewServer(Service())
+	defer s.Close()
+	v := s.VFS(t)
+
+	target, err := v.ResolveTarget(v.Root(), "Systems/1/Status/Health")
+	if err != nil {
+		t.Fatalf("ResolveTarget failed: %v", err)
+	}
+	if target.Property == nil || target.Property.Value != "OK" {
+		t.Errorf("Health = %+v, want OK", target.Property)
+	}
+	if n := s.Requests(http.MethodGet, "/redfish/v1/Systems/1"); n != 1 {
+		t.Errorf("system fetched %d times, want 1", n)
+	}
+
+	// Faults answer a Redfish error until cleared
+	s.Fail("/redfish/v1/Chassis/1", http.StatusServiceUnavailable)
+	_, err = v.Get("/redfish/v1/Chassis/1")
+	var httpErr *rvfs.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable || len(httpErr.Messages) != 1 {
+		t.Errorf("Get error = %v, want 503 with a message", err)
+	}
+	s.Fail("/redfish/v1/Chassis/1", 0)
+	if _, err := v.Get("/redfish/v1/Chassis/1"); err != nil {
+		t.Errorf("Get after clearing the fault failed: %v", err)
+	}
+
+	s.Set("/redfish/v1/Chassis/2", `{"@odata.id": "/redfish/v1/Chassis/2"}`)
+	if _, err := v.Get("/redfish/v1/Chassis/2"); err != nil {
+		t.Errorf("Get of an added resource failed: %v", err)
+	}
+	s.Remove("/redfish/v1/Chassis/2")
+	v.Invalidate("/redfish/v1/Chassis/2")
+	if _, err := v.Get("/redfish/v1/Chassis/2"); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Get of a removed resource = %v, want 404", err)
+	}
+
+	const reset = "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset"
+	var posted string
+	s.HandlePost(reset, func(body []byte) Reply {
+		posted = string(body)
+		return Reply{Status: http.StatusNoContent}
+	})
+	resp, err := v.Post(reset, []byte(`{"ResetType": "On"}`))
+	if err != nil || resp.StatusCode != http.StatusNoContent || posted != `{"ResetType": "On"}` {
+		t.Errorf("Post = %+v, %v; posted %q", resp, err, posted)
+	}
+	if resp, err := v.Post("/redfish/v1/Systems/1", nil); err != nil || resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Post without handler = %+v, %v; want 405", resp, err)
+	}
+
+	s.Allow("/redfish/v1/Systems/1", "PATCH")
+	info, err := v.Stat("/redfish/v1/Systems/1")
+	if err != nil || !slices.Equal(info.Allow, []string{"GET", "HEAD", "PATCH"}) {
+		t.Errorf("Stat = %+v, %v; want GET, HEAD, PATCH", info, err)
+	}
+	if info, err := v.Stat(reset); err != nil || !info.Allows("POST") || info.Allows("PATCH") {
+		t.Errorf("Stat of a handled action = %+v, %v; want POST only", info, err)
+	}
+
+	s.SetLatency(50 * time.Millisecond)
+	v.Invalidate("/redfish/v1/Managers/1")
+	start := time.Now()
+	if _, err := v.Get("/redfish/v1/Managers/1"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Get took %s, want at least the latency", elapsed)
+	}
+}
+
+func TestServer_Sessions(t *testing.T) {
+	s := NewServer(Service())
+	defer s.Close()
+	s.RequireSession("admin", "password")
+	v := s.VFS(t)
+
+	if _, err := v.Get("/redfish/v1/Systems/1"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if v.Auth() != rvfs.AuthSession {
+		t.Errorf("Auth = %s, want session", v.Auth())
+	}
+	logins := s.Requests(http.MethodPost, sessionsPath)
+
+	// An expired session is renewed on the next 401
+	s.ExpireSessions()
+	v.Invalidate("/redfish/v1/Systems/1")
+	if _, err := v.Get("/redfish/v1/Systems/1"); err != nil {
+		t.Fatalf("Get after expiry failed: %v", err)
+	}
+	if n := s.Requests(http.MethodPost, sessionsPath); n != logins+1 {
+		t.Errorf("logged in %d times after expiry, want %d", n, logins+1)
+	}
+
+	s.RequireSession("admin", "other")
+	s.ExpireSessions()
+	v.Invalidate("/redfish/v1/Systems/1")
+	var authErr *rvfs.AuthError
+	if _, err := v.Get("/redfish/v1/Systems/1"); !errors.As(err, &authErr) {
+		t.Errorf("Get with bad credentials = %v, want AuthError", err)
+	}
+}
+
+func TestMockups(t *testing.T) {
+	if got := Mockups(); !slices.Equal(got, []string{"dell", "hpe", "supermicro"}) {
+		t.Errorf("Mockups = %v", got)
+	}
+	for _, name := range Mockups() {
+		s := NewServer(Mockup(name))
+		v := s.VFS(t)
+		for path := range Mockup(name) {
+			if path == "/redfish" {
+				continue
+			}
+			res, err := v.Get(path)
+			if err != nil {
+				t.Errorf("%s: Get %s failed: %v", name, path, err)
+			} else if res.ODataID != path {
+				t.Errorf("%s: %s has @odata.id %s", name, path, res.ODataID)
+			}
+		}
+		s.Close()
+	}
+}
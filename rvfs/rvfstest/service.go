@@ -0,0 +1,87 @@
+package rvfstest
+
+// Service returns the resources of a small service to start tests from: the
+// version document, a v1 service root with one system, chassis and manager, and their collections.
+// The map is new on every call, so tests can change it freely.
+func Service() map[string]string {
+	return map[string]string{
+		"/redfish": `{"v1": "/redfish/v1/"}`,
+		"/redfish/v1": `{
+			"@odata.id": "/redfish/v1",
+			"@odata.type": "#ServiceRoot.v1_15_0.ServiceRoot",
+			"Id": "RootService",
+			"Name": "Root Service",
+			"RedfishVersion": "1.17.0",
+			"Vendor": "Contoso",
+			"Product": "Fake BMC",
+			"Systems": {"@odata.id": "/redfish/v1/Systems"},
+			"Chassis": {"@odata.id": "/redfish/v1/Chassis"},
+			"Managers": {"@odata.id": "/redfish/v1/Managers"},
+			"SessionService": {"@odata.id": "/redfish/v1/SessionService"}
+		}`,
+		"/redfish/v1/Systems": `{
+			"@odata.id": "/redfish/v1/Systems",
+			"@odata.type": "#ComputerSystemCollection.ComputerSystemCollection",
+			"Name": "Computer System Collection",
+			"Members@odata.count": 1,
+			"Members": [{"@odata.id": "/redfish/v1/Systems/1"}]
+		}`,
+		"/redfish/v1/Systems/1": `{
+			"@odata.id": "/redfish/v1/Systems/1",
+			"@odata.type": "#ComputerSystem.v1_20_0.ComputerSystem",
+			"Id": "1",
+			"Name": "System",
+			"Manufacturer": "Contoso",
+			"Model": "Fake 1000",
+			"PowerState": "On",
+			"BiosVersion": "1.0.0",
+			"Status": {"State": "Enabled", "Health": "OK", "HealthRollup": "OK"},
+			"Boot": {"BootOrder": ["Pxe", "Hdd"]},
+			"Links": {"Chassis": [{"@odata.id": "/redfish/v1/Chassis/1"}]},
+			"Actions": {
+				"#ComputerSystem.Reset": {
+					"target": "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset",
+					"ResetType@Redfish.AllowableValues": ["On", "ForceOff", "GracefulRestart"]
+				}
+			}
+		}`,
+		"/redfish/v1/Chassis": `{
+			"@odata.id": "/redfish/v1/Chassis",
+			"@odata.type": "#ChassisCollection.ChassisCollection",
+			"Name": "Chassis Collection",
+			"Members@odata.count": 1,
+			"Members": [{"@odata.id": "/redfish/v1/Chassis/1"}]
+		}`,
+		"/redfish/v1/Chassis/1": `{
+			"@odata.id": "/redfish/v1/Chassis/1",
+			"@odata.type": "#Chassis.v1_25_0.Chassis",
+			"Id": "1",
+			"Name": "Chassis",
+			"ChassisType": "RackMount",
+			"Status": {"State": "Enabled", "Health": "OK"}
+		}`,
+		"/redfish/v1/Managers": `{
+			"@odata.id": "/redfish/v1/Managers",
+			"@odata.type": "#ManagerCollection.ManagerCollection",
+			"Name": "Manager Collection",
+			"Members@odata.count": 1,
+			"Members": [{"@odata.id": "/redfish/v1/Managers/1"}]
+		}`,
+		"/redfish/v1/Managers/1": `{
+			"@odata.id": "/redfish/v1/Managers/1",
+			"@odata.type": "#Manager.v1_19_0.Manager",
+			"Id": "1",
+			"Name": "Manager",
+			"ManagerType": "BMC",
+			"FirmwareVersion": "2.0.0",
+			"Status": {"State": "Enabled", "Health": "OK"}
+		}`,
+		"/redfish/v1/SessionService": `{
+			"@odata.id": "/redfish/v1/SessionService",
+			"@odata.type": "#SessionService.v1_1_9.SessionService",
+			"Id": "SessionService",
+			"Name": "Session Service",
+			"Sessions": {"@odata.id": "/redfish/v1/SessionService/Sessions"}
+		}`,
+	}
+}
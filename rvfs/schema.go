@@ -0,0 +1,256 @@
+package rvfs
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/buger/jsonparser"
+)
+
+// maxSchemaRefs bounds how many $refs one description lookup follows
+const maxSchemaRefs = 16
+
+// schemaStore reads the JSON schemas a service publishes under JsonSchemas
+// and looks up descriptions in them. Documents are fetched once, when first
+// needed, and kept in memory; they are not resources and stay out of the
+// resource cache.
+type schemaStore struct {
+	cache  cache
+	root   string  // Service root the JsonSchemas collection is linked from
+	client *Client // nil for a cache without a connection
+
+	mu   sync.Mutex
+	docs map[string]map[string]any // Schema file Id → document; nil when the service has none
+}
+
+func newSchemaStore(cache cache, root string, client *Client) *schemaStore {
+	return &schemaStore{cache: cache, root: root, client: client, docs: make(map[string]map[string]any)}
+}
+
+// describe returns the description of the property at names within a
+// resource of odataType, or of the type itself when names is empty. It is
+// "" when the schema does not describe the property; an error means the
+// schema could not be read.
+func (s *schemaStore) describe(ctx context.Context, odataType string, names []string) (string, error) {
+	namespace, typeName, ok := splitODataType(odataType)
+	if !ok {
+		return "", nil
+	}
+	doc, err := s.document(ctx, namespace)
+	if err == nil && doc == nil {
+		// Services may publish only the unversioned schema
+		if base, _, ok := strings.Cut(namespace, "."); ok {
+			doc, err = s.document(ctx, base)
+		}
+	}
+	if err != nil || doc == nil {
+		return "", err
+	}
+	def := definition(doc, typeName)
+	if len(names) == 0 {
+		return description(def), nil
+	}
+
+	for i, name := range names {
+		if def, doc, err = s.expand(ctx, def, doc); err != nil || def == nil {
+			return "", err
+		}
+		props, _ := def["properties"].(map[string]any)
+		prop, _ := props[name].(map[string]any)
+		if prop == nil {
+			return "", nil
+		}
+		if i == len(names)-1 {
+			if text := description(prop); text != "" {
+				return text, nil
+			}
+		}
+		if def, doc, err = s.follow(ctx, prop, doc); err != nil || def == nil {
+			return "", err
+		}
+	}
+	return description(def), nil
+}
+
+// expand follows the $refs of a definition until it reaches one that lists
+// properties, as unversioned schemas define types as anyOf their versions
+func (s *schemaStore) expand(ctx context.Context, def, doc map[string]any) (map[string]any, map[string]any, error) {
+	for range maxSchemaRefs {
+		if def == nil {
+			return nil, nil, nil
+		}
+		if _, ok := def["properties"]; ok {
+			return def, doc, nil
+		}
+		var err error
+		if def, doc, err = s.follow(ctx, def, doc); err != nil {
+			return nil, nil, err
+		}
+	}
+	return nil, nil, nil
+}
+
+// follow resolves the $ref of a schema, looking through array items and
+// anyOf; of several references the last, newest version wins
+func (s *schemaStore) follow(ctx context.Context, schema, doc map[string]any) (map[string]any, map[string]any, error) {
+	ref := schemaRef(schema)
+	if ref == "" {
+		return nil, nil, nil
+	}
+	file, pointer, _ := strings.Cut(ref, "#")
+	if file != "" {
+		namespace := strings.TrimSuffix(BaseName(file), ".json")
+		var err error
+		if doc, err = s.document(ctx, namespace); err != nil || doc == nil {
+			return nil, nil, err
+		}
+	}
+	name, ok := strings.CutPrefix(pointer, "/definitions/")
+	if !ok {
+		return nil, nil, nil
+	}
+	return definition(doc, name), doc, nil
+}
+
+// schemaRef returns the reference a schema stands for, or ""
+func schemaRef(schema map[string]any) string {
+	if ref, ok := schema["$ref"].(string); ok {
+		return ref
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		return schemaRef(items)
+	}
+	ref := ""
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		for _, alt := range anyOf {
+			if alt, ok := alt.(map[string]any); ok {
+				if r := schemaRef(alt); r != "" && !strings.HasSuffix(r, "/idRef") {
+					ref = r
+				}
+			}
+		}
+	}
+	return ref
+}
+
+// document returns the schema file with Id namespace, nil when the service
+// does not publish it
+func (s *schemaStore) document(ctx context.Context, namespace string) (map[string]any, error) {
+	s.mu.Lock()
+	doc, ok := s.docs[namespace]
+	s.mu.Unlock()
+	if ok {
+		return doc, nil
+	}
+
+	doc, err := s.load(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.docs[namespace] = doc
+	s.mu.Unlock()
+	return doc, nil
+}
+
+// load finds the JsonSchemaFile for namespace and fetches the local copy of
+// its schema. Members are matched by the last segment of their path, which
+// services set to the file's Id.
+func (s *schemaStore) load(ctx context.Context, namespace string) (map[string]any, error) {
+	root, err := s.cache.Get(ctx, s.root)
+	if err != nil {
+		return nil, err
+	}
+	link, ok := root.Children["JsonSchemas"]
+	if !ok {
+		return nil, nil
+	}
+	collection, err := s.cache.Get(ctx, link.Target)
+	if err != nil {
+		return nil, err
+	}
+	var member *Child
+	for _, child := range collection.Children {
+		if BaseName(child.Target) == namespace {
+			member = child
+			break
+		}
+	}
+	if member == nil {
+		return nil, nil
+	}
+	file, err := s.cache.Get(ctx, member.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := ""
+	jsonparser.ArrayEach(file.RawJSON, func(value []byte, _ jsonparser.ValueType, _ int, _ error) {
+		if u, err := jsonparser.GetString(value, "Uri"); err == nil && uri == "" && strings.HasPrefix(u, "/") {
+			uri = u
+		}
+	}, "Location")
+	if uri == "" {
+		return nil, nil
+	}
+	if s.client == nil {
+		return nil, &NotCachedError{Path: uri}
+	}
+	// Schemas are served as application/schema+json or worse; the body is
+	// all that matters
+	data, _, err := s.client.get(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, &ParseError{Path: uri, Err: err}
+	}
+	return doc, nil
+}
+
+// splitODataType splits "#ComputerSystem.v1_20_0.ComputerSystem" into its
+// namespace and type name
+func splitODataType(odataType string) (namespace, typeName string, ok bool) {
+	odataType = strings.TrimPrefix(odataType, "#")
+	i := strings.LastIndex(odataType, ".")
+	if i <= 0 || i == len(odataType)-1 {
+		return "", "", false
+	}
+	return odataType[:i], odataType[i+1:], true
+}
+
+// definition returns a named definition of a schema document
+func definition(doc map[string]any, name string) map[string]any {
+	defs, _ := doc["definitions"].(map[string]any)
+	def, _ := defs[name].(map[string]any)
+	return def
+}
+
+// description returns the one-line description of a schema
+func description(schema map[string]any) string {
+	text, _ := schema["description"].(string)
+	return text
+}
+
+// propertyNames returns the names leading from p down to target, or false
+// when target is not within p. Array elements add no name: the schema
+// describes them with their array.
+func propertyNames(p, target *Property) ([]string, bool) {
+	if p == target {
+		return nil, true
+	}
+	for name, child := range p.Children {
+		if names, ok := propertyNames(child, target); ok {
+			return append([]string{name}, names...), true
+		}
+	}
+	for _, elem := range p.Elements {
+		if names, ok := propertyNames(elem, target); ok {
+			return names, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,157 @@
+package rvfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrorCategory groups fetch failures for crawl reports
+type ErrorCategory string
+
+const (
+	CategoryAuth     ErrorCategory = "auth"     // Credentials rejected or resource refused to the role
+	CategoryNotFound ErrorCategory = "notfound" // The service has no such resource
+	CategoryServer   ErrorCategory = "server"   // 5xx: the service failed to answer
+	CategoryTimeout  ErrorCategory = "timeout"  // No answer in time
+	CategoryNetwork  ErrorCategory = "network"  // The connection failed
+	CategoryOther    ErrorCategory = "other"    // Other 4xx, bodies that are not JSON
+)
+
+// categoryOrder is the order categories are reported in
+var categoryOrder = []ErrorCategory{
+	CategoryAuth, CategoryNotFound, CategoryServer, CategoryTimeout, CategoryNetwork, CategoryOther,
+}
+
+// Categorize returns the category of a fetch failure
+func Categorize(err error) ErrorCategory {
+	var auth *AuthError
+	var forbidden *ForbiddenError
+	var notFound *NotFoundError
+	var httpErr *HTTPError
+	var timeout interface{ Timeout() bool }
+	switch {
+	case errors.As(err, &auth), errors.As(err, &forbidden):
+		return CategoryAuth
+	case errors.As(err, &notFound):
+		return CategoryNotFound
+	case errors.As(err, &httpErr):
+		switch {
+		case httpErr.StatusCode == 401 || httpErr.StatusCode == 403:
+			return CategoryAuth
+		case httpErr.StatusCode == 404 || httpErr.StatusCode == 410:
+			return CategoryNotFound
+		case httpErr.StatusCode == 408:
+			return CategoryTimeout
+		case httpErr.StatusCode >= 500:
+			return CategoryServer
+		}
+		return CategoryOther
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &timeout) && timeout.Timeout():
+		return CategoryTimeout
+	}
+	var netErr *NetworkError
+	var unreachable *UnreachableError
+	var opErr *net.OpError
+	if errors.As(err, &netErr) || errors.As(err, &unreachable) || errors.As(err, &opErr) {
+		return CategoryNetwork
+	}
+	return CategoryOther
+}
+
+// Retryable reports whether a failure may clear on a later attempt: a
+// server error or a timeout
+func Retryable(err error) bool {
+	switch Categorize(err) {
+	case CategoryServer, CategoryTimeout:
+		return true
+	}
+	return false
+}
+
+// ErrorTally counts fetch failures by category
+type ErrorTally map[ErrorCategory]int
+
+// Add counts one failure
+func (t ErrorTally) Add(err error) {
+	t[Categorize(err)]++
+}
+
+// String lists the counts, e.g. "2 server, 1 timeout"
+func (t ErrorTally) String() string {
+	var parts []string
+	for _, c := range categoryOrder {
+		if n := t[c]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, c))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DefaultScrapeRetries is how many times a crawl retries a server error or
+// timeout when the config does not say
+const DefaultScrapeRetries = 2
+
+// scrapeRetryDelay is the wait before the first retry; it doubles with
+// each further attempt
+var scrapeRetryDelay = 500 * time.Millisecond
+
+// ScrapePolicy decides how a crawl treats failing and unwanted resources
+type ScrapePolicy struct {
+	// Retries is how many more times a server error or timeout is fetched
+	// before it counts as a failure
+	Retries int `yaml:"retries"`
+
+	// Skip holds regular expressions; resources whose path matches one
+	// are neither fetched nor crawled past, e.g. log entry collections
+	// that hang the service
+	Skip []string `yaml:"skip"`
+
+	skip []*regexp.Regexp
+}
+
+// Compile checks the policy and compiles its skip patterns; Skipped
+// matches nothing until it has run
+func (p *ScrapePolicy) Compile() error {
+	if p.Retries < 0 {
+		return fmt.Errorf("retries must not be negative")
+	}
+	p.skip = make([]*regexp.Regexp, len(p.Skip))
+	for i, pattern := range p.Skip {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("skip pattern %q: %w", pattern, err)
+		}
+		p.skip[i] = re
+	}
+	return nil
+}
+
+// Skipped reports whether the policy keeps a crawl away from path
+func (p *ScrapePolicy) Skipped(path string) bool {
+	for _, re := range p.skip {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fetch gets a resource for a crawl, retrying server errors and timeouts
+// with a growing delay. It returns how many retries were made.
+func (p *ScrapePolicy) Fetch(r Reader, path string) (*Resource, int, error) {
+	delay := scrapeRetryDelay
+	for retries := 0; ; retries++ {
+		res, err := r.Get(path)
+		if err == nil || retries >= p.Retries || !Retryable(err) {
+			return res, retries, err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
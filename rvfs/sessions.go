@@ -0,0 +1,184 @@
+package rvfs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// SessionStore shares login sessions between the bluefish processes of a
+// user, so a shell and a TUI connected to the same service log in once:
+// some BMCs allow only a few sessions and lock everyone out past them. It
+// is a JSON file, written under a lock file beside it. Each session lists
+// the processes holding it, and the last one to let go deletes it from the
+// service. The file holds session tokens and is only readable by the user.
+type SessionStore struct {
+	path string
+}
+
+// NewSessionStore returns the store kept in the file at path
+func NewSessionStore(path string) *SessionStore {
+	return &SessionStore{path: path}
+}
+
+// sessionLockStale is how old a lock file is before it is taken for that
+// of a process that died holding it; logins happen under the lock
+const sessionLockStale = 30 * time.Second
+
+// sharedSession is a session in the store
+type sharedSession struct {
+	Token   string          `json:"token"`
+	Session string          `json:"session"` // Path of the session resource
+	Holders []sessionHolder `json:"holders"`
+}
+
+// sessionHolder is a connection using a shared session
+type sessionHolder struct {
+	ID  string `json:"id"`
+	PID int    `json:"pid"`
+}
+
+// newHolderID returns an ID for a connection holding shared sessions
+func newHolderID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// acquire returns the session for key that holder is to use: the shared
+// one, unless it is stale, the token holder was refused with, or force is
+// set; otherwise login makes a new one, which replaces it
+func (s *SessionStore) acquire(key, holder, stale string, force bool, login func() (token, session string, err error)) (string, string, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return "", "", err
+	}
+	defer unlock()
+
+	sessions, err := s.read()
+	if err != nil {
+		return "", "", err
+	}
+	shared := sessions[key]
+	if shared == nil {
+		shared = &sharedSession{}
+		sessions[key] = shared
+	}
+	if force || shared.Token == "" || shared.Token == stale {
+		token, session, err := login()
+		if err != nil {
+			return "", "", err
+		}
+		shared.Token, shared.Session = token, session
+	}
+	shared.Holders = slices.DeleteFunc(shared.Holders, func(h sessionHolder) bool {
+		return h.ID == holder || !processAlive(h.PID)
+	})
+	shared.Holders = append(shared.Holders, sessionHolder{ID: holder, PID: os.Getpid()})
+	if err := s.write(sessions); err != nil {
+		return "", "", err
+	}
+	return shared.Token, shared.Session, nil
+}
+
+// release lets go of holder's hold on the session of key, which it knows
+// by token. It reports whether the session is to be deleted: it is the
+// shared one and no live process holds it any more.
+func (s *SessionStore) release(key, holder, token string) (bool, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	sessions, err := s.read()
+	if err != nil {
+		return false, err
+	}
+	shared := sessions[key]
+	if shared == nil {
+		return false, nil
+	}
+	shared.Holders = slices.DeleteFunc(shared.Holders, func(h sessionHolder) bool {
+		return h.ID == holder || !processAlive(h.PID)
+	})
+	last := shared.Token == token && len(shared.Holders) == 0
+	if last {
+		delete(sessions, key)
+	}
+	return last, s.write(sessions)
+}
+
+// lock takes the store's lock file, waiting while another process holds
+// it, and returns the function that gives it back
+func (s *SessionStore) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return nil, fmt.Errorf("session store: %w", err)
+	}
+	lockPath := s.path + ".lock"
+	deadline := time.Now().Add(2 * sessionLockStale)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("session store: %w", err)
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > sessionLockStale {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("session store: %s is held by another process", lockPath)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// read loads the sessions of the store; a missing file holds none
+func (s *SessionStore) read() (map[string]*sharedSession, error) {
+	sessions := make(map[string]*sharedSession)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return sessions, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("session store: %w", err)
+	}
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		// A damaged store costs a login, not the connection
+		return make(map[string]*sharedSession), nil
+	}
+	return sessions, nil
+}
+
+// write saves the sessions of the store
+func (s *SessionStore) write(sessions map[string]*sharedSession) error {
+	data, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := WriteFile(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("session store: %w", err)
+	}
+	return nil
+}
+
+// processAlive reports whether the process pid is running
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
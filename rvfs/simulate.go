@@ -0,0 +1,155 @@
+package rvfs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Simulation makes a service misbehave on purpose, for developing the
+// frontends: it slows responses down and answers some reads with server
+// errors or malformed payloads, so loading states, retries and error paths
+// can be exercised without a broken BMC. It works below the VFS, on the
+// HTTP requests, so its failures take the paths real ones do.
+type Simulation struct {
+	// Latency is added to every request, and a random share of Jitter
+	// on top
+	Latency time.Duration `yaml:"latency"`
+	Jitter  time.Duration `yaml:"jitter"`
+
+	// Errors is the share of GETs, from 0 to 1, answered with a 500
+	Errors float64 `yaml:"errors"`
+
+	// Malformed is the share of GETs, from 0 to 1, whose payload is cut
+	// off halfway
+	Malformed float64 `yaml:"malformed"`
+
+	// Seed makes the failures repeatable; 0 picks a random seed
+	Seed uint64 `yaml:"seed"`
+}
+
+// Enabled reports whether the simulation changes anything
+func (s Simulation) Enabled() bool {
+	return s.Latency > 0 || s.Jitter > 0 || s.Errors > 0 || s.Malformed > 0
+}
+
+// Validate checks the durations and shares
+func (s Simulation) Validate() error {
+	if s.Latency < 0 || s.Jitter < 0 {
+		return fmt.Errorf("latency and jitter must not be negative")
+	}
+	for name, share := range map[string]float64{"errors": s.Errors, "malformed": s.Malformed} {
+		if share < 0 || share > 1 {
+			return fmt.Errorf("%s must be between 0 and 1, got %v", name, share)
+		}
+	}
+	return nil
+}
+
+// String describes the simulation for the connect banner: latency 200ms
+// (+300ms), 5% errors, 2% malformed
+func (s Simulation) String() string {
+	var parts []string
+	if s.Latency > 0 || s.Jitter > 0 {
+		latency := "latency " + s.Latency.String()
+		if s.Jitter > 0 {
+			latency += " (+" + s.Jitter.String() + ")"
+		}
+		parts = append(parts, latency)
+	}
+	if s.Errors > 0 {
+		parts = append(parts, fmt.Sprintf("%g%% errors", s.Errors*100))
+	}
+	if s.Malformed > 0 {
+		parts = append(parts, fmt.Sprintf("%g%% malformed", s.Malformed*100))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Transport returns an HTTP transport that carries requests over next
+// with the simulated misbehavior. Give it to a VFS as Options.Transport.
+func (s Simulation) Transport(next http.RoundTripper) http.RoundTripper {
+	seed := s.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return &simulator{sim: s, next: next, rand: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// simulatedError is the body of a simulated server error
+const simulatedError = `{"error": {"code": "Base.1.8.GeneralError", "message": "Simulated server error",
+	"@Message.ExtendedInfo": [{"MessageId": "Base.1.8.GeneralError", "Message": "Simulated server error (simulate.errors)", "Severity": "Critical"}]}}`
+
+// simulator is the transport of a Simulation
+type simulator struct {
+	sim  Simulation
+	next http.RoundTripper
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// draw returns the delay for a request and whether it fails or comes back
+// malformed
+func (s *simulator) draw(read bool) (delay time.Duration, fail, malform bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delay = s.sim.Latency
+	if s.sim.Jitter > 0 {
+		delay += time.Duration(s.rand.Int64N(int64(s.sim.Jitter)))
+	}
+	if read {
+		roll := s.rand.Float64()
+		fail = roll < s.sim.Errors
+		malform = !fail && roll < s.sim.Errors+s.sim.Malformed
+	}
+	return delay, fail, malform
+}
+
+// RoundTrip carries one request, late, failed or malformed as drawn
+func (s *simulator) RoundTrip(req *http.Request) (*http.Response, error) {
+	delay, fail, malform := s.draw(req.Method == http.MethodGet)
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+	if fail {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return &http.Response{
+			Status:     "500 Internal Server Error",
+			StatusCode: http.StatusInternalServerError,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(simulatedError)),
+			Request:    req,
+		}, nil
+	}
+
+	resp, err := s.next.RoundTrip(req)
+	if err != nil || !malform || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	data = data[:len(data)/2]
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	resp.ContentLength = int64(len(data))
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
@@ -0,0 +1,154 @@
+package rvfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+)
+
+// Staging collects property changes made one at a time, to be sent
+// together the way BIOS pending settings are: Plan groups them into one
+// PATCH per resource, so a fragile service sees as few writes as possible
+type Staging struct {
+	changes []Change // PATCHes in the order staged, one per property
+}
+
+// Stage records that the property at target, relative to base, is to be
+// set to value: the string itself when the property holds a string, else
+// value as JSON (true, 3, ["Pxe"]); a null property takes either. The
+// property must exist, outside arrays, and not be an object. Staging the live value drops the
+// property's change, and the change is then nil.
+func (s *Staging) Stage(v VFS, base, target, value string) (*Change, error) {
+	resolved, err := v.ResolveTarget(base, target)
+	if err != nil {
+		return nil, err
+	}
+	if resolved.Type != TargetProperty {
+		return nil, fmt.Errorf("not a property: %s", target)
+	}
+	if resolved.Property.Type == PropertyObject {
+		return nil, fmt.Errorf("%s is an object; stage its properties", target)
+	}
+	names, ok := propertyNames(&Property{Children: resolved.Resource.Properties}, resolved.Property)
+	if !ok || lookupProperty(resolved.Resource, names) != resolved.Property {
+		return nil, fmt.Errorf("%s is within an array; stage the whole array", target)
+	}
+
+	var properties map[string]any
+	if err := json.Unmarshal(resolved.Resource.RawJSON, &properties); err != nil {
+		return nil, fmt.Errorf("%s: %w", resolved.Resource.Path, err)
+	}
+	old := liveValue(properties, names)
+	var staged any
+	switch old.(type) {
+	case string:
+		staged = value
+	case nil:
+		if json.Unmarshal([]byte(value), &staged) != nil {
+			staged = value
+		}
+	default:
+		if err := json.Unmarshal([]byte(value), &staged); err != nil {
+			return nil, fmt.Errorf("%s holds %s; give the value as JSON", target, resolved.Property.Text())
+		}
+	}
+
+	change := Change{Resource: resolved.Resource.Path, Property: names, Old: old, New: staged, Method: http.MethodPatch}
+	i := s.index(change.Resource, names)
+	switch {
+	case reflect.DeepEqual(old, staged):
+		if i >= 0 {
+			s.changes = slices.Delete(s.changes, i, i+1)
+		}
+		return nil, nil
+	case i >= 0:
+		s.changes[i] = change
+	default:
+		s.changes = append(s.changes, change)
+	}
+	return &change, nil
+}
+
+// Unstage drops the staged changes of target, relative to base: of the
+// property, or of every property of a resource. It returns how many were
+// dropped.
+func (s *Staging) Unstage(v VFS, base, target string) (int, error) {
+	resolved, err := v.ResolveTarget(base, target)
+	if err != nil {
+		return 0, err
+	}
+	var names []string
+	if resolved.Type == TargetProperty {
+		if names, _ = propertyNames(&Property{Children: resolved.Resource.Properties}, resolved.Property); names == nil {
+			return 0, nil
+		}
+	}
+	before := len(s.changes)
+	s.changes = slices.DeleteFunc(s.changes, func(c Change) bool {
+		return c.Resource == resolved.Resource.Path && (names == nil || slices.Equal(c.Property, names))
+	})
+	return before - len(s.changes), nil
+}
+
+// Clear drops every staged change and returns how many there were
+func (s *Staging) Clear() int {
+	n := len(s.changes)
+	s.changes = nil
+	return n
+}
+
+// Len returns the number of staged changes
+func (s *Staging) Len() int {
+	return len(s.changes)
+}
+
+// Plan returns the staged changes as an apply plan, those of each resource
+// together, resources in the order their first change was staged
+func (s *Staging) Plan() *Plan {
+	plan := &Plan{}
+	for i, c := range s.changes {
+		if slices.ContainsFunc(s.changes[:i], func(prev Change) bool { return prev.Resource == c.Resource }) {
+			continue
+		}
+		for _, same := range s.changes[i:] {
+			if same.Resource == c.Resource {
+				plan.Changes = append(plan.Changes, same)
+			}
+		}
+	}
+	return plan
+}
+
+// Committed drops the changes of the requests of ApplyPlan that succeeded,
+// keeping those the service refused staged to be corrected
+func (s *Staging) Committed(results []ApplyResult) {
+	for _, r := range results {
+		if r.Err != nil || r.Method != http.MethodPatch {
+			continue
+		}
+		s.changes = slices.DeleteFunc(s.changes, func(c Change) bool { return c.Resource == r.Path })
+	}
+}
+
+// index returns the position of the change of a property, -1 when it has
+// none
+func (s *Staging) index(resource string, names []string) int {
+	return slices.IndexFunc(s.changes, func(c Change) bool {
+		return c.Resource == resource && slices.Equal(c.Property, names)
+	})
+}
+
+// lookupProperty returns the property at names in a resource, through
+// objects only
+func lookupProperty(res *Resource, names []string) *Property {
+	p := &Property{Type: PropertyObject, Children: res.Properties}
+	for _, name := range names {
+		if p == nil || p.Type != PropertyObject {
+			return nil
+		}
+		p = p.Children[name]
+	}
+	return p
+}
@@ -0,0 +1,223 @@
+package rvfs
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Request records a single resource access through the cache
+type Request struct {
+	Method   string        // GET, POST, PATCH or OPTIONS
+	Path     string        // Resource path, including query options
+	Status   int           // HTTP status; 0 for cache hits and transport failures
+	Bytes    int           // Response body size
+	Duration time.Duration // Round-trip time; 0 for cache hits
+	Cached   bool          // Served from cache without an HTTP request
+	Warnings []string      // Parser warnings for the fetched resource
+}
+
+// statsWindow is how many of the latest requests Stats keeps for Since
+const statsWindow = 1000
+
+// statsSlowest is how many of the slowest requests the Summary of Stats
+// keeps
+const statsSlowest = 20
+
+// Stats records the requests served by a cache: running totals for the
+// Summary, and the latest statsWindow requests. Shells take a mark with Len
+// before a command and read the command's requests back with Since.
+type Stats struct {
+	mu      sync.Mutex
+	recent  []Request // Ring of the latest requests; request n is at n % statsWindow
+	total   int       // Requests recorded since the last Reset
+	summary summarizer
+}
+
+// record adds a request
+func (s *Stats) record(r Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.recent) < statsWindow {
+		s.recent = append(s.recent, r)
+	} else {
+		s.recent[s.total%statsWindow] = r
+	}
+	s.total++
+	if s.summary.keep == 0 {
+		s.summary.keep = statsSlowest
+	}
+	s.summary.add(r)
+}
+
+// Len returns the number of requests recorded, to mark with
+func (s *Stats) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.total
+}
+
+// Since returns a copy of the requests recorded after mark, and how many of
+// them were recorded so long ago they are no longer kept
+func (s *Stats) Since(mark int) ([]Request, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if mark < 0 || mark > s.total {
+		mark = s.total
+	}
+	oldest := s.total - len(s.recent)
+	requests := make([]Request, 0, s.total-max(mark, oldest))
+	for n := max(mark, oldest); n < s.total; n++ {
+		requests = append(requests, s.recent[n%statsWindow])
+	}
+	return requests, max(oldest-mark, 0)
+}
+
+// Summary aggregates every request recorded since the last Reset, with the
+// statsSlowest slowest
+func (s *Stats) Summary() Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.summary.result()
+}
+
+// Reset discards all recorded requests
+func (s *Stats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recent, s.total, s.summary = nil, 0, summarizer{}
+}
+
+// Summary aggregates a set of requests
+type Summary struct {
+	Requests  int               // HTTP requests sent (cache misses and POSTs)
+	Hits      int               // Reads served from cache
+	Misses    int               // Reads that required a GET
+	Errors    int               // HTTP requests that failed or returned non-2xx
+	Bytes     int64             // Response bytes received
+	HTTPTime  time.Duration     // Total round-trip time of HTTP requests
+	Endpoints []EndpointSummary // Per service, slowest average first
+	Slowest   []Request         // HTTP requests, slowest first
+}
+
+// HitRatio returns the fraction of reads served from cache
+func (s Summary) HitRatio() float64 {
+	reads := s.Hits + s.Misses
+	if reads == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(reads)
+}
+
+// EndpointSummary aggregates HTTP requests to one Redfish service
+// (the first path segment below the service root, e.g. /redfish/v1/Systems)
+type EndpointSummary struct {
+	Endpoint string
+	Requests int
+	Bytes    int64
+	Total    time.Duration
+	Max      time.Duration
+}
+
+// Average returns the mean round-trip time
+func (e EndpointSummary) Average() time.Duration {
+	if e.Requests == 0 {
+		return 0
+	}
+	return e.Total / time.Duration(e.Requests)
+}
+
+// Summarize aggregates requests into totals, per-endpoint latency and the
+// slowest individual requests
+func Summarize(requests []Request) Summary {
+	var sum summarizer
+	for _, r := range requests {
+		sum.add(r)
+	}
+	return sum.result()
+}
+
+// summarizer builds a Summary one request at a time
+type summarizer struct {
+	Summary
+	keep      int // Slowest requests kept; 0 keeps all
+	endpoints map[string]*EndpointSummary
+}
+
+// add counts a request
+func (s *summarizer) add(r Request) {
+	if r.Cached {
+		s.Hits++
+		return
+	}
+	s.Requests++
+	if r.Method == "GET" {
+		s.Misses++
+	}
+	if r.Status < 200 || r.Status > 299 {
+		s.Errors++
+	}
+	s.Bytes += int64(r.Bytes)
+	s.HTTPTime += r.Duration
+	s.Slowest = append(s.Slowest, r)
+	if s.keep > 0 && len(s.Slowest) > 2*s.keep {
+		s.trim()
+	}
+
+	name := endpointOf(r.Path)
+	if s.endpoints == nil {
+		s.endpoints = make(map[string]*EndpointSummary)
+	}
+	e, ok := s.endpoints[name]
+	if !ok {
+		e = &EndpointSummary{Endpoint: name}
+		s.endpoints[name] = e
+	}
+	e.Requests++
+	e.Bytes += int64(r.Bytes)
+	e.Total += r.Duration
+	e.Max = max(e.Max, r.Duration)
+}
+
+// trim orders the slowest requests, slowest first, and drops those past
+// keep
+func (s *summarizer) trim() {
+	sort.SliceStable(s.Slowest, func(i, j int) bool {
+		return s.Slowest[i].Duration > s.Slowest[j].Duration
+	})
+	if s.keep > 0 && len(s.Slowest) > s.keep {
+		s.Slowest = s.Slowest[:s.keep]
+	}
+}
+
+// result returns the Summary of the requests added so far
+func (s *summarizer) result() Summary {
+	s.trim()
+	summary := s.Summary
+	summary.Slowest = slices.Clone(s.Slowest)
+	summary.Endpoints = nil
+	for _, e := range s.endpoints {
+		summary.Endpoints = append(summary.Endpoints, *e)
+	}
+	sort.Slice(summary.Endpoints, func(i, j int) bool {
+		if summary.Endpoints[i].Average() != summary.Endpoints[j].Average() {
+			return summary.Endpoints[i].Average() > summary.Endpoints[j].Average()
+		}
+		return summary.Endpoints[i].Endpoint < summary.Endpoints[j].Endpoint
+	})
+	return summary
+}
+
+// endpointOf returns the service a path belongs to
+func endpointOf(fullPath string) string {
+	p, _ := splitQuery(fullPath)
+	root := versionRoot(p)
+	rest, ok := strings.CutPrefix(p, root+"/")
+	if root == "" || !ok {
+		return p
+	}
+	service, _, _ := strings.Cut(rest, "/")
+	return root + "/" + service
+}
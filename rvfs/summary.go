@@ -0,0 +1,166 @@
+package rvfs
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ServiceSummary describes a Redfish service at a glance: what it is, how
+// much it manages and how healthy that is
+type ServiceSummary struct {
+	Vendor         string // Service root Vendor, else the first system's Manufacturer
+	Model          string // Service root Product, else the first system's Model
+	RedfishVersion string
+	Systems        int // Members of each collection; -1 when it cannot be read
+	Chassis        int
+	Managers       int
+	// Health is the worst Status.HealthRollup (or Health) among the systems
+	// and managers, "" when none reports one
+	Health   string
+	Firmware []Firmware
+	Auth     AuthMode
+}
+
+// Firmware is a firmware version a system or manager reports
+type Firmware struct {
+	Resource string // Path of the reporting resource
+	Kind     string // "BMC" for managers, "BIOS" for systems
+	Version  string
+}
+
+// SummarizeService gathers the summary of the service behind v. It reads the
+// service root, the Systems, Chassis and Managers collections and every
+// system and manager; chassis are only counted, as there can be many.
+// Only a failure to read the service root is an error.
+func SummarizeService(v VFS) (*ServiceSummary, error) {
+	root, err := v.Get(v.Root())
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ServiceSummary{
+		Vendor:         stringProperty(root, "Vendor"),
+		Model:          stringProperty(root, "Product"),
+		RedfishVersion: stringProperty(root, "RedfishVersion"),
+		Auth:           v.Auth(),
+	}
+
+	var systems, managers []*Resource
+	s.Systems, systems = collectionMembers(v, root, "Systems", true)
+	s.Chassis, _ = collectionMembers(v, root, "Chassis", false)
+	s.Managers, managers = collectionMembers(v, root, "Managers", true)
+
+	if len(systems) > 0 {
+		if s.Vendor == "" {
+			s.Vendor = stringProperty(systems[0], "Manufacturer")
+		}
+		if s.Model == "" {
+			s.Model = stringProperty(systems[0], "Model")
+		}
+	}
+
+	rank := 0
+	for _, r := range append(append([]*Resource(nil), systems...), managers...) {
+		if health := resourceHealth(r); severityRank(health) > rank {
+			s.Health, rank = health, severityRank(health)
+		}
+	}
+	for _, m := range managers {
+		if version := stringProperty(m, "FirmwareVersion"); version != "" {
+			s.Firmware = append(s.Firmware, Firmware{Resource: m.Path, Kind: "BMC", Version: version})
+		}
+	}
+	for _, sys := range systems {
+		if version := stringProperty(sys, "BiosVersion"); version != "" {
+			s.Firmware = append(s.Firmware, Firmware{Resource: sys.Path, Kind: "BIOS", Version: version})
+		}
+	}
+	return s, nil
+}
+
+// hostsUnder returns the systems and chassis an inventory covers: all of
+// them for the service root, else the one at base, which must be a system
+// or a chassis
+func hostsUnder(v VFS, base string) (systems, chassis []*Resource, err error) {
+	if base == v.Root() {
+		root, err := v.Get(base)
+		if err != nil {
+			return nil, nil, err
+		}
+		_, systems = collectionMembers(v, root, "Systems", true)
+		_, chassis = collectionMembers(v, root, "Chassis", true)
+		return systems, chassis, nil
+	}
+	res, err := v.Get(base)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, typeName, _ := splitODataType(res.ODataType)
+	switch typeName {
+	case "ComputerSystem":
+		return []*Resource{res}, nil, nil
+	case "Chassis":
+		return nil, []*Resource{res}, nil
+	}
+	return nil, nil, fmt.Errorf("%s is not a system or chassis", res.Path)
+}
+
+// collectionMembers counts the members of the collection the service root
+// links as name, fetching them when fetch is set. The count is -1 when the
+// collection is absent or cannot be read; members that fail are skipped.
+func collectionMembers(v Reader, root *Resource, name string, fetch bool) (int, []*Resource) {
+	child, ok := root.Children[name]
+	if !ok {
+		return -1, nil
+	}
+	collection, err := v.Get(child.Target)
+	if err != nil {
+		return -1, nil
+	}
+	count := MemberCount(collection)
+	if !fetch {
+		return count, nil
+	}
+
+	targets := make([]string, 0, len(collection.Children))
+	for _, member := range collection.Children {
+		if member.Type == ChildLink {
+			targets = append(targets, member.Target)
+		}
+	}
+	sort.Strings(targets)
+	var members []*Resource
+	for _, target := range targets {
+		if member, err := v.Get(target); err == nil {
+			members = append(members, member)
+		}
+	}
+	return count, members
+}
+
+// resourceHealth returns the HealthRollup of a resource's Status, or its
+// Health when it reports no rollup
+func resourceHealth(r *Resource) string {
+	status, ok := r.Properties["Status"]
+	if !ok || status.Type != PropertyObject {
+		return ""
+	}
+	for _, name := range []string{"HealthRollup", "Health"} {
+		if p, ok := status.Children[name]; ok {
+			if health, ok := p.Value.(string); ok {
+				return health
+			}
+		}
+	}
+	return ""
+}
+
+// stringProperty returns a top-level string property, or ""
+func stringProperty(r *Resource, name string) string {
+	if p, ok := r.Properties[name]; ok && p.Type == PropertySimple {
+		if s, ok := p.Value.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,136 @@
+package rvfs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/buger/jsonparser"
+)
+
+// TaskStatus is the state of an operation the service runs as a task,
+// from the Task resource or the task monitor that reports on it
+type TaskStatus struct {
+	Path     string // Task or task monitor polled
+	State    string // TaskState: New, Running, Completed, Exception, ...
+	Percent  int    // PercentComplete, -1 when not reported
+	Messages []Message
+	// Location is where the result of the task is: the Location header
+	// the task's payload carries, "" when it carries none
+	Location string
+	Done     bool
+}
+
+// taskEndStates are the TaskStates a task does not leave
+var taskEndStates = map[string]bool{
+	"Completed":   true,
+	"Exception":   true,
+	"Killed":      true,
+	"Cancelled":   true,
+	"Interrupted": true,
+}
+
+// Failed reports whether the task ended without completing
+func (s *TaskStatus) Failed() bool {
+	return s.Done && s.State != "Completed"
+}
+
+// String describes the status: Running 40%
+func (s *TaskStatus) String() string {
+	if s.Percent < 0 {
+		return s.State
+	}
+	return fmt.Sprintf("%s %d%%", s.State, s.Percent)
+}
+
+// SpawnedTask returns what to poll for the task a write started: the Task
+// in the response body, or else the task monitor its Location names. It
+// is "" when the write did not answer 202 Accepted.
+func SpawnedTask(resp *Response) string {
+	if resp.StatusCode != http.StatusAccepted {
+		return ""
+	}
+	if id, err := jsonparser.GetString(resp.Body, "@odata.id"); err == nil && isTask(resp.Body) {
+		return normalizePath(id)
+	}
+	return resp.Location
+}
+
+// isTask reports whether a payload is a Task resource
+func isTask(data []byte) bool {
+	_, err := jsonparser.GetString(data, "TaskState")
+	return err == nil
+}
+
+// parseTask reads the status out of a Task payload
+func parseTask(path string, data []byte) *TaskStatus {
+	status := &TaskStatus{Path: path, Percent: -1}
+	status.State, _ = jsonparser.GetString(data, "TaskState")
+	status.Done = taskEndStates[status.State]
+	if percent, err := jsonparser.GetInt(data, "PercentComplete"); err == nil {
+		status.Percent = int(percent)
+	}
+	if messages, dataType, _, err := jsonparser.Get(data, "Messages"); err == nil && dataType == jsonparser.Array {
+		status.Messages = parseMessageArray(messages, "")
+	}
+	for _, header := range parseStringArray(data, "Payload", "HttpHeaders") {
+		if name, value, ok := strings.Cut(header, ":"); ok && strings.EqualFold(strings.TrimSpace(name), "Location") {
+			status.Location = locationPath(strings.TrimSpace(value))
+		}
+	}
+	return status
+}
+
+// PollTask fetches the current status of a task or task monitor, past the
+// cache. A monitor answers 202 while the task runs and the result of the
+// operation once it is done.
+func PollTask(v VFS, path string) (*TaskStatus, error) {
+	v.Invalidate(path)
+	res, err := v.Get(path)
+	v.Invalidate(path)
+
+	var httpErr *HTTPError
+	switch {
+	case errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusAccepted:
+		return &TaskStatus{Path: path, State: "Running", Percent: -1, Messages: httpErr.Messages}, nil
+	case errors.As(err, &httpErr) && httpErr.StatusCode < http.StatusMultipleChoices:
+		// A monitor whose operation answered without a body
+		return &TaskStatus{Path: path, State: "Completed", Percent: -1, Done: true}, nil
+	case err != nil:
+		return nil, err
+	}
+	if isTask(res.RawJSON) {
+		return parseTask(path, res.RawJSON), nil
+	}
+	// The monitor answered with the result of the operation, which is the
+	// resource it produced when it names one
+	status := &TaskStatus{Path: path, State: "Completed", Percent: -1, Done: true}
+	if id, err := jsonparser.GetString(res.RawJSON, "@odata.id"); err == nil {
+		status.Location = normalizePath(id)
+	}
+	return status, nil
+}
+
+// WaitTask polls a task every interval until it ends, handing each status
+// to progress when it is not nil. A task that ends without completing is a
+// TaskError. Cancelling the context the VFS runs under stops the wait.
+func WaitTask(v VFS, path string, interval time.Duration, progress func(*TaskStatus)) (*TaskStatus, error) {
+	for {
+		status, err := PollTask(v, path)
+		if err != nil {
+			return nil, err
+		}
+		if progress != nil {
+			progress(status)
+		}
+		if status.Failed() {
+			return status, &TaskError{Path: path, State: status.State, Messages: status.Messages}
+		}
+		if status.Done {
+			return status, nil
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,231 @@
+package rvfs
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TopologyNode is a chassis, system or manager in the physical containment
+// hierarchy, with what it holds
+type TopologyNode struct {
+	Kind      string // Chassis, ComputerSystem or Manager
+	Path      string
+	ID        string
+	Name      string
+	Type      string   // ChassisType, SystemType or ManagerType
+	Health    string   // Status HealthRollup, or Health
+	ManagedBy []string // Ids of the managers of a chassis or system
+	Contains  []*TopologyNode
+}
+
+// topologyKind is a kind of node, the collection of the service root
+// holding its resources and the property naming their type
+type topologyKind struct {
+	kind, collection, typeProperty string
+}
+
+// topologyKinds orders what a chassis holds: chassis, then systems, then
+// managers
+var topologyKinds = []topologyKind{
+	{"Chassis", "Chassis", "ChassisType"},
+	{"ComputerSystem", "Systems", "SystemType"},
+	{"Manager", "Managers", "ManagerType"},
+}
+
+// Topology builds the physical containment hierarchy of the service: the
+// chassis inside one another, the systems and managers in each, and the
+// managers managing them, as opposed to where the URIs put them. Links are
+// followed from both sides, so a chassis holds a system whether it lists the
+// system in ComputerSystems or the system names it in Chassis. Something
+// held by several chassis is placed in the innermost. The outermost nodes
+// are returned, or the chassis at base and what it holds; resources that
+// cannot be read are left out.
+func Topology(v VFS, base string) ([]*TopologyNode, error) {
+	root, err := v.Get(v.Root())
+	if err != nil {
+		return nil, err
+	}
+	t := &topology{
+		nodes:     make(map[string]*TopologyNode),
+		holders:   make(map[string][]string),
+		managedBy: make(map[string][]string),
+	}
+	resources := make(map[string][]*Resource)
+	for _, k := range topologyKinds {
+		_, members := collectionMembers(v, root, k.collection, true)
+		resources[k.kind] = members
+		for _, res := range members {
+			id := stringProperty(res, "Id")
+			if id == "" {
+				id = res.Path
+			}
+			t.nodes[res.Path] = &TopologyNode{
+				Kind:   k.kind,
+				Path:   res.Path,
+				ID:     id,
+				Name:   stringProperty(res, "Name"),
+				Type:   stringProperty(res, k.typeProperty),
+				Health: resourceHealth(res),
+			}
+		}
+	}
+
+	for _, c := range resources["Chassis"] {
+		t.contain(linkTargets(c.RawJSON, "Links", "ContainedBy"), c.Path)
+		for _, name := range []string{"Contains", "ComputerSystems", "ManagersInChassis"} {
+			for _, inner := range linkTargets(c.RawJSON, "Links", name) {
+				t.contain([]string{c.Path}, inner)
+			}
+		}
+		t.manage(linkTargets(c.RawJSON, "Links", "ManagedBy"), c.Path)
+	}
+	for _, s := range resources["ComputerSystem"] {
+		t.contain(linkTargets(s.RawJSON, "Links", "Chassis"), s.Path)
+		t.manage(linkTargets(s.RawJSON, "Links", "ManagedBy"), s.Path)
+	}
+	for _, m := range resources["Manager"] {
+		t.contain(linkTargets(m.RawJSON, "Links", "ManagerInChassis"), m.Path)
+		for _, name := range []string{"ManagerForChassis", "ManagerForServers"} {
+			for _, managed := range linkTargets(m.RawJSON, "Links", name) {
+				t.manage([]string{m.Path}, managed)
+			}
+		}
+	}
+	return t.build(v, base)
+}
+
+// topology is the state Topology builds up, by path
+type topology struct {
+	nodes     map[string]*TopologyNode
+	holders   map[string][]string // Chassis said to hold each node
+	managedBy map[string][]string // Managers said to manage each node
+	parents   map[string]string   // Chassis each node is placed in
+}
+
+// contain records that the chassis at outers hold the node at inner
+func (t *topology) contain(outers []string, inner string) {
+	if _, ok := t.nodes[inner]; !ok {
+		return
+	}
+	for _, outer := range outers {
+		if node, ok := t.nodes[outer]; ok && node.Kind == "Chassis" && outer != inner && !slices.Contains(t.holders[inner], outer) {
+			t.holders[inner] = append(t.holders[inner], outer)
+		}
+	}
+}
+
+// manage records that the managers at paths manage the node at managed
+func (t *topology) manage(managers []string, managed string) {
+	if _, ok := t.nodes[managed]; !ok {
+		return
+	}
+	for _, m := range managers {
+		if node, ok := t.nodes[m]; ok && node.Kind == "Manager" && !slices.Contains(t.managedBy[managed], m) {
+			t.managedBy[managed] = append(t.managedBy[managed], m)
+		}
+	}
+}
+
+// build places each node in one chassis and returns the outermost nodes,
+// or the chassis at base
+func (t *topology) build(v VFS, base string) ([]*TopologyNode, error) {
+	paths := make([]string, 0, len(t.nodes))
+	for path := range t.nodes {
+		paths = append(paths, path)
+	}
+	slices.SortFunc(paths, func(a, b string) int { return compareNodes(t.nodes[a], t.nodes[b]) })
+
+	// Chassis first, so the depth of the chassis holding a system is known.
+	// Each is placed in the first holder reached from the chassis nothing
+	// holds, which leaves out a ContainedBy pointing back inside.
+	t.parents = make(map[string]string)
+	placed := make(map[string]bool)
+	var queue []string
+	for _, path := range paths {
+		if t.nodes[path].Kind == "Chassis" && len(t.holders[path]) == 0 {
+			placed[path] = true
+			queue = append(queue, path)
+		}
+	}
+	for {
+		for len(queue) > 0 {
+			outer := queue[0]
+			queue = queue[1:]
+			for _, path := range paths {
+				if !placed[path] && t.nodes[path].Kind == "Chassis" && slices.Contains(t.holders[path], outer) {
+					t.parents[path] = outer
+					placed[path] = true
+					queue = append(queue, path)
+				}
+			}
+		}
+		// Chassis only holding one another in a circle start from the first
+		i := slices.IndexFunc(paths, func(path string) bool { return t.nodes[path].Kind == "Chassis" && !placed[path] })
+		if i < 0 {
+			break
+		}
+		placed[paths[i]] = true
+		queue = append(queue, paths[i])
+	}
+	for _, path := range paths {
+		if t.nodes[path].Kind == "Chassis" {
+			continue
+		}
+		innermost, depth := "", -1
+		for _, holder := range slices.Sorted(slices.Values(t.holders[path])) {
+			if d := t.depth(holder); d > depth {
+				innermost, depth = holder, d
+			}
+		}
+		if innermost != "" {
+			t.parents[path] = innermost
+		}
+	}
+
+	var roots []*TopologyNode
+	for _, path := range paths {
+		node := t.nodes[path]
+		for _, m := range t.managedBy[path] {
+			node.ManagedBy = append(node.ManagedBy, t.nodes[m].ID)
+		}
+		if parent, ok := t.parents[path]; ok {
+			t.nodes[parent].Contains = append(t.nodes[parent].Contains, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	if base == v.Root() {
+		return roots, nil
+	}
+	res, err := v.Get(base)
+	if err != nil {
+		return nil, err
+	}
+	node, ok := t.nodes[res.Path]
+	if !ok || node.Kind != "Chassis" {
+		return nil, fmt.Errorf("%s is not a chassis", res.Path)
+	}
+	return []*TopologyNode{node}, nil
+}
+
+// depth counts the chassis the chassis at path is placed in
+func (t *topology) depth(path string) int {
+	d := 0
+	for parent, ok := t.parents[path]; ok; parent, ok = t.parents[parent] {
+		d++
+	}
+	return d
+}
+
+// compareNodes orders nodes by kind, as topologyKinds does, then by path
+func compareNodes(a, b *TopologyNode) int {
+	kind := func(n *TopologyNode) int {
+		return slices.IndexFunc(topologyKinds, func(k topologyKind) bool { return k.kind == n.Kind })
+	}
+	if c := kind(a) - kind(b); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Path, b.Path)
+}
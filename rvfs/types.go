@@ -1,7 +1,11 @@
 package rvfs
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -17,13 +21,37 @@ const (
 	EntrySymlink                   // Symlink (external resource reference)
 )
 
+// String names the entry type: resource, link, property, object, array or
+// symlink
+func (t EntryType) String() string {
+	switch t {
+	case EntryResource:
+		return "resource"
+	case EntryLink:
+		return "link"
+	case EntryComplex:
+		return "object"
+	case EntryArray:
+		return "array"
+	case EntrySymlink:
+		return "symlink"
+	}
+	return "property"
+}
+
 // Entry represents any item in the VFS
 type Entry struct {
-	Name     string
-	Path     string
-	Type     EntryType
-	Size     int64
-	Modified time.Time
+	Name      string
+	Path      string
+	Type      EntryType
+	Size      int64
+	Modified  time.Time // When the data behind it was fetched, zero for an uncached child
+	Denied    bool      // The service refused the entry's resource to this role
+	Actions   bool      // The entry's resource is cached and exposes actions
+	URIString bool      // A link inferred from a URI string, not an @odata.id reference
+	// LinkTarget is where a link listed inside a property leads, shown
+	// beside its name; empty for a resource's own children and properties
+	LinkTarget string
 }
 
 // IsDir returns true if entry is navigable
@@ -31,15 +59,96 @@ func (e Entry) IsDir() bool {
 	return e.Type == EntryResource || e.Type == EntryLink || e.Type == EntryComplex || e.Type == EntryArray || e.Type == EntrySymlink
 }
 
+// Age buckets bounds: data fetched within FreshAge is fresh, within
+// RecentAge recent, and stale after that
+const (
+	FreshAge  = time.Minute
+	RecentAge = time.Hour
+)
+
+// EntryAge buckets how long ago the data behind an entry was fetched
+type EntryAge int
+
+const (
+	AgeUnfetched EntryAge = iota // A child whose resource is not cached
+	AgeFresh
+	AgeRecent
+	AgeStale
+)
+
+// Age returns the age bucket of the entry at now
+func (e Entry) Age(now time.Time) EntryAge {
+	if e.Modified.IsZero() {
+		return AgeUnfetched
+	}
+	switch age := now.Sub(e.Modified); {
+	case age < FreshAge:
+		return AgeFresh
+	case age < RecentAge:
+		return AgeRecent
+	}
+	return AgeStale
+}
+
 // Resource represents a Redfish resource at a specific path
 type Resource struct {
 	Path       string
+	Query      string // OData query options ($select, $top, ...) when partial
 	ODataID    string
 	ODataType  string
 	RawJSON    []byte
 	Properties map[string]*Property
 	Children   map[string]*Child
+	Messages   []Message   // @Message.ExtendedInfo carried by the response
+	Conditions []Condition // Status.Conditions reported for the resource
+	Warnings   []string    // Spec violations the parser worked around
 	FetchedAt  time.Time
+
+	// Capabilities is the @Redfish.CollectionCapabilities of a collection
+	// that accepts new members, nil otherwise
+	Capabilities *CollectionCapabilities
+}
+
+// IsPartial returns true if the resource was fetched with query options
+// and may be missing properties or members
+func (r *Resource) IsPartial() bool {
+	return r.Query != ""
+}
+
+// IsCollection returns true if the resource is a Redfish resource collection
+func (r *Resource) IsCollection() bool {
+	if _, ok := r.Properties["Members@odata.count"]; ok {
+		return true
+	}
+	return strings.HasSuffix(r.ODataType, "Collection")
+}
+
+// TypeName returns the type name of the resource's @odata.type, such as
+// ComputerSystem for #ComputerSystem.v1_20_0.ComputerSystem, "" without one
+func (r *Resource) TypeName() string {
+	_, typeName, _ := splitODataType(r.ODataType)
+	return typeName
+}
+
+// HasActions reports whether the resource exposes an action to POST: a
+// member of Actions, Oem aside, with a target. A nil resource has none.
+func (r *Resource) HasActions() bool {
+	if r == nil {
+		return false
+	}
+	actions, ok := r.Properties["Actions"]
+	if !ok || actions.Type != PropertyObject {
+		return false
+	}
+	for name, action := range actions.Children {
+		if name == "Oem" || action.Type != PropertyObject {
+			continue
+		}
+		if target, ok := action.Children["target"]; ok && target.Type == PropertyLink {
+			return true
+		}
+	}
+	return false
 }
 
 // GetProperty retrieves a property by name
@@ -78,6 +187,9 @@ type Property struct {
 
 	// For PropertyLink
 	LinkTarget string // The @odata.id URL
+	// URIString marks a link inferred from a string property named like a
+	// URI (FirmwareInventoryUri), rather than an @odata.id reference
+	URIString bool
 
 	// For PropertyObject
 	Children map[string]*Property // Nested fields
@@ -89,6 +201,52 @@ type Property struct {
 	RawJSON []byte // Original JSON for this property
 }
 
+// IsLinkArray reports whether the property is a non-empty array of links,
+// such as Links/Chassis
+func (p *Property) IsLinkArray() bool {
+	if p.Type != PropertyArray || len(p.Elements) == 0 {
+		return false
+	}
+	for _, elem := range p.Elements {
+		if elem.Type != PropertyLink {
+			return false
+		}
+	}
+	return true
+}
+
+// Text returns the value as a script reads it: a string without its
+// quotes, anything else as compact JSON
+func (p *Property) Text() string {
+	if s, ok := p.Value.(string); ok && p.Type == PropertySimple {
+		return s
+	}
+	var b bytes.Buffer
+	if err := json.Compact(&b, p.RawJSON); err != nil {
+		return string(p.RawJSON)
+	}
+	return b.String()
+}
+
+// JSON returns the value as JSON: the raw JSON of the property, quoted
+// when it is a string, which is kept unquoted
+func (p *Property) JSON() []byte {
+	var s string
+	switch {
+	case p.URIString:
+		s = p.LinkTarget
+	case p.Type == PropertySimple && p.Value != nil:
+		var ok bool
+		if s, ok = p.Value.(string); !ok {
+			return p.RawJSON
+		}
+	default:
+		return p.RawJSON
+	}
+	data, _ := json.Marshal(s)
+	return data
+}
+
 // ChildType represents the type of child resource
 type ChildType int
 
@@ -110,6 +268,100 @@ func (c *Child) IsExternal() bool {
 	return c.Type == ChildSymlink
 }
 
+// Message is a Redfish message from an @Message.ExtendedInfo annotation
+type Message struct {
+	MessageID         string
+	Message           string
+	Severity          string // MessageSeverity (or deprecated Severity): OK, Warning, Critical
+	Resolution        string
+	MessageArgs       []string
+	RelatedProperties []string
+}
+
+// String returns a one-line summary of the message
+func (m Message) String() string {
+	var b strings.Builder
+	if m.Severity != "" {
+		fmt.Fprintf(&b, "[%s] ", m.Severity)
+	}
+	if m.MessageID != "" {
+		b.WriteString(m.MessageID)
+		if m.Message != "" {
+			b.WriteString(": ")
+		}
+	}
+	b.WriteString(m.Message)
+	return b.String()
+}
+
+// Condition is an entry of Status.Conditions: a fault or notable state the
+// service reports for a resource, often raised by a subordinate component
+type Condition struct {
+	Message
+	Timestamp         string
+	OriginOfCondition string // Path of the resource the condition is about
+	LogEntry          string // Path of the log entry recording the condition
+}
+
+// CollectionCapabilities describes how members of a collection are created
+type CollectionCapabilities struct {
+	MaxMembers   int // 0 when the service sets no limit
+	Capabilities []Capability
+}
+
+// Capability is one use case for creating members of a collection
+type Capability struct {
+	UseCase            string
+	CapabilitiesObject string // Path of the resource annotating the fields
+	TargetCollection   string // Collection to POST to
+}
+
+// ConditionSeverity returns the most severe of the resource's conditions
+// (Critical, Warning, OK), or "" when it has none
+func (r *Resource) ConditionSeverity() string {
+	worst, rank := "", 0
+	for _, c := range r.Conditions {
+		if n := severityRank(c.Severity); n > rank {
+			worst, rank = c.Severity, n
+		}
+	}
+	return worst
+}
+
+// severityRank orders Redfish severities, unknown ones lowest
+func severityRank(severity string) int {
+	switch strings.ToUpper(severity) {
+	case "OK":
+		return 1
+	case "WARNING":
+		return 2
+	case "CRITICAL":
+		return 3
+	}
+	return 0
+}
+
+// Response is the result of a write request (POST)
+type Response struct {
+	StatusCode int
+	Body       []byte
+	Location   string    // Path of a created resource, from the Location header
+	Messages   []Message // @Message.ExtendedInfo parsed from Body
+}
+
+// ResourceInfo is what the service allows to be done to a resource
+type ResourceInfo struct {
+	Path  string
+	Allow []string // Methods from the Allow header, nil when not reported
+}
+
+// Allows reports whether the service allows method on the resource. A
+// service that reported no Allow header is taken to allow everything,
+// leaving it to refuse the request.
+func (i *ResourceInfo) Allows(method string) bool {
+	return i.Allow == nil || slices.Contains(i.Allow, method)
+}
+
 // TargetType represents what a path resolves to
 type TargetType int
 
@@ -125,6 +377,16 @@ type Target struct {
 	Resource     *Resource  // The resource we're in
 	Property     *Property  // If Property or Link type
 	ResourcePath string     // For navigation (Resources and Links)
+	// ViaURIString reports that resolution followed a link inferred from a
+	// URI string, at the end of the path or along it
+	ViaURIString bool
+}
+
+// Hop is a link followed while resolving a path
+type Hop struct {
+	From      string // Path of the link
+	To        string // Its target, @odata.id or URI, with any JSON pointer
+	URIString bool   // The link is inferred from a URI string property
 }
 
 // Error types
@@ -157,14 +419,115 @@ func (e *NetworkError) Error() string {
 	return fmt.Sprintf("network error: %s: %v", e.Path, e.Err)
 }
 
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// UnreachableError indicates no service answered at the endpoint
+type UnreachableError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *UnreachableError) Error() string {
+	return fmt.Sprintf("cannot reach %s: %v", e.Endpoint, e.Err)
+}
+
+func (e *UnreachableError) Unwrap() error {
+	return e.Err
+}
+
+// AuthError indicates the service rejected the session credentials
+type AuthError struct {
+	User       string
+	StatusCode int
+	Messages   []Message // @Message.ExtendedInfo from the error body
+}
+
+func (e *AuthError) Error() string {
+	msg := fmt.Sprintf("authentication failed for user %q: check user and pass (HTTP %d)", e.User, e.StatusCode)
+	for _, m := range e.Messages {
+		msg += "; " + m.Message
+	}
+	return msg
+}
+
 // HTTPError indicates an HTTP error response
 type HTTPError struct {
 	Path       string
 	StatusCode int
+	Messages   []Message // @Message.ExtendedInfo from the error body
 }
 
 func (e *HTTPError) Error() string {
-	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Path)
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Path)
+	}
+	texts := make([]string, len(e.Messages))
+	for i, m := range e.Messages {
+		texts[i] = m.String()
+	}
+	return fmt.Sprintf("HTTP %d: %s: %s", e.StatusCode, e.Path, strings.Join(texts, "; "))
+}
+
+// TaskError reports a task that ended without completing
+type TaskError struct {
+	Path     string
+	State    string    // TaskState it ended in: Exception, Killed, ...
+	Messages []Message // Messages of the task
+}
+
+func (e *TaskError) Error() string {
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("task %s ended in %s", e.Path, e.State)
+	}
+	texts := make([]string, len(e.Messages))
+	for i, m := range e.Messages {
+		texts[i] = m.String()
+	}
+	return fmt.Sprintf("task %s ended in %s: %s", e.Path, e.State, strings.Join(texts, "; "))
+}
+
+// ForbiddenError indicates the service refuses a resource to the logged-in
+// role: a 403, or a 401 that a fresh session does not cure
+type ForbiddenError struct {
+	Path       string
+	StatusCode int
+	Messages   []Message // @Message.ExtendedInfo from the error body
+}
+
+func (e *ForbiddenError) Error() string {
+	msg := fmt.Sprintf("permission denied: %s (HTTP %d)", e.Path, e.StatusCode)
+	for _, m := range e.Messages {
+		msg += "; " + m.String()
+	}
+	return msg
+}
+
+// PrivilegeError indicates a write the logged-in role lacks the privilege
+// for, refused before it is sent
+type PrivilegeError struct {
+	Role      string
+	Privilege string // The privilege the write needs
+	Path      string
+}
+
+func (e *PrivilegeError) Error() string {
+	return fmt.Sprintf("role %s lacks %s, needed to write to %s", e.Role, e.Privilege, e.Path)
+}
+
+// ContentTypeError indicates a response that is not declared as JSON
+type ContentTypeError struct {
+	Path        string
+	ContentType string
+}
+
+func (e *ContentTypeError) Error() string {
+	msg := fmt.Sprintf("%s: content type %q is not JSON", e.Path, e.ContentType)
+	if strings.HasPrefix(e.ContentType, "text/plain") {
+		msg += " (the text_plain_json quirk accepts it)"
+	}
+	return msg
 }
 
 // ParseError indicates a JSON parsing error
@@ -0,0 +1,107 @@
+package rvfs
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unitSuffixes maps property name suffixes to formatters. Redfish names
+// quantities after their unit of measure (CapacityBytes, MemorySizeMiB,
+// PowerOnHours, ReadingCelsius), so the suffix carries the schema's unit.
+var unitSuffixes = []struct {
+	suffix string
+	format func(float64) string
+}{
+	{"Bytes", func(v float64) string { return humanBytes(v) }},
+	{"KiB", func(v float64) string { return humanBytes(v * (1 << 10)) }},
+	{"MiB", func(v float64) string { return humanBytes(v * (1 << 20)) }},
+	{"GiB", func(v float64) string { return humanBytes(v * (1 << 30)) }},
+	{"Seconds", func(v float64) string { return humanDuration(time.Duration(v * float64(time.Second))) }},
+	{"Hours", func(v float64) string { return humanDuration(time.Duration(v * float64(time.Hour))) }},
+	{"Percent", func(v float64) string { return formatNumber(v) + "%" }},
+	{"Celsius", func(v float64) string { return formatNumber(v) + " °C" }},
+	{"Watts", func(v float64) string { return formatNumber(v) + " W" }},
+	{"Volts", func(v float64) string { return formatNumber(v) + " V" }},
+}
+
+// Humanize renders a property value for people: sizes in binary multiples,
+// durations, percentages and readings with their unit symbol, and
+// timestamps in local time with their age relative to now. It returns
+// false when the value has no known unit.
+func Humanize(name string, value any, now time.Time) (string, bool) {
+	switch v := value.(type) {
+	case float64:
+		for _, u := range unitSuffixes {
+			if strings.HasSuffix(name, u.suffix) {
+				return u.format(v), true
+			}
+		}
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return "", false
+		}
+		return t.Local().Format("2006-01-02 15:04:05 MST") + " (" + humanAge(now.Sub(t)) + ")", true
+	}
+	return "", false
+}
+
+// humanBytes formats a byte count in binary multiples (KiB, MiB, ...)
+func humanBytes(b float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	i := 0
+	for math.Abs(b) >= 1024 && i < len(units)-1 {
+		b /= 1024
+		i++
+	}
+	return formatNumber(math.Round(b*10)/10) + " " + units[i]
+}
+
+// humanDuration formats a duration using its two largest units
+func humanDuration(d time.Duration) string {
+	const day = 24 * time.Hour
+	const year = 365 * day
+	switch {
+	case d >= year:
+		return fmt.Sprintf("%dy %dd", d/year, d%year/day)
+	case d >= day:
+		return fmt.Sprintf("%dd %dh", d/day, d%day/time.Hour)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh %dm", d/time.Hour, d%time.Hour/time.Minute)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm %ds", d/time.Minute, d%time.Minute/time.Second)
+	default:
+		return d.Round(time.Millisecond).String()
+	}
+}
+
+// humanAge formats the distance to a timestamp: "3d ago" or "in 2h"
+func humanAge(d time.Duration) string {
+	future := d < 0
+	if future {
+		d = -d
+	}
+	var age string
+	switch {
+	case d < time.Minute:
+		age = fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		age = fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		age = fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		age = fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+	if future {
+		return "in " + age
+	}
+	return age + " ago"
+}
+
+// formatNumber formats a number without trailing zeros
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
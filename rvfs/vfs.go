@@ -1,120 +1,360 @@
 package rvfs
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"path"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
-const RedfishRoot = "/redfish/v1"
+// VersionsPath is where a service lists the protocol versions it speaks,
+// each with the path of its service root: {"v1": "/redfish/v1/"}
+const VersionsPath = "/redfish"
 
-// VFS provides a virtual filesystem view of Redfish resources
+// DefaultRoot is the service root of Redfish v1, spoken by services that
+// list no versions
+const DefaultRoot = "/redfish/v1"
+
+// isVersion reports whether s names a protocol version of the version
+// document: v1, v2, ...
+func isVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// maxLinkHops bounds how many JSON pointer links one resolution follows,
+// guarding against links that point back into themselves
+const maxLinkHops = 16
+
+// VFS provides a virtual filesystem view of Redfish resources. It is
+// composed of the smaller interfaces below; code that needs only part of
+// it takes that part, and implementations that cover only part of it embed
+// BaseVFS for the rest.
 type VFS interface {
-	// Core operations
+	Reader
+	Resolver
+	Lister
+	Mutator
+	Downloader
+	CacheControl
+	Searcher
+	Prober
+	Describer
+	Authenticator
+	Diagnostics
+}
+
+// Reader fetches resources by their canonical paths
+type Reader interface {
 	Get(path string) (*Resource, error)
-	Post(path string, body []byte) ([]byte, int, error)
+}
+
+// Resolver resolves and combines paths
+type Resolver interface {
+	// Root is the service root of the protocol version the connection
+	// speaks, e.g. /redfish/v1; relative paths start there
+	Root() string
 	ResolveTarget(basePath, targetPath string) (*Target, error)
+	// ResolveChain is ResolveTarget that also returns the links followed
+	ResolveChain(basePath, targetPath string) (*Target, []Hop, error)
+	Join(base, target string) string
+	Parent(path string) string
+}
 
-	// Directory-like operations
+// Lister lists what is at a path, like a directory
+type Lister interface {
 	ListAll(path string) ([]*Entry, error)
 	ListProperties(path string) ([]*Property, error)
+}
 
-	// Path utilities
-	Join(base, target string) string
-	Parent(path string) string
+// Mutator sends writes to the service; nothing it sends is cached
+type Mutator interface {
+	Post(path string, body []byte) (*Response, error)
+	Patch(path string, body []byte) (*Response, error)
+}
+
+// Downloader streams payloads that are not JSON, such as log bundles, from
+// the service; nothing it downloads is cached or parsed
+type Downloader interface {
+	Download(path string, w io.Writer, progress ProgressFunc) (int64, error)
+}
 
-	// Cache management
+// CacheControl manages the resource cache
+type CacheControl interface {
 	GetKnownPaths() []string
 	Invalidate(path string)
 	Clear()
 	Sync() error
+	// Denied reports whether the service refused path to the logged-in
+	// role; the refusal is remembered until the path is invalidated
+	Denied(path string) bool
+	// Cached returns the resource at path when it is cached and parsed,
+	// nil otherwise; it never fetches
+	Cached(path string) *Resource
+}
+
+// Searcher searches cached resources at or below base
+type Searcher interface {
+	FindCached(base string, re *regexp.Regexp) []Match
+	GrepCached(base, text string) []Match
+}
+
+// Prober asks the service what may be done to a resource
+type Prober interface {
+	// Stat reports the HTTP methods the service allows on the resource at
+	// path, without fetching it
+	Stat(path string) (*ResourceInfo, error)
+}
+
+// Describer looks up what properties mean in the schemas the service
+// publishes
+type Describer interface {
+	Describe(basePath, targetPath string) (string, error)
+}
+
+// Interrupter is a VFS whose requests a context can bound, so a frontend
+// can give up on a command whose service stopped answering
+type Interrupter interface {
+	// WithContext returns the VFS with the requests sent through it run
+	// under ctx: cancelling it aborts them. The VFS itself and other views
+	// of it are unaffected, and all share the cache and the session.
+	WithContext(ctx context.Context) VFS
+}
+
+// WithContext returns v with the requests sent through it run under ctx,
+// or v as it is when it sends none that could be aborted
+func WithContext(ctx context.Context, v VFS) VFS {
+	if i, ok := v.(Interrupter); ok {
+		return i.WithContext(ctx)
+	}
+	return v
+}
+
+// Authenticator replaces the credentials of the connection
+type Authenticator interface {
+	// Reauthenticate logs in again with a new password; it fails with an
+	// AuthError when the service refuses it
+	Reauthenticate(password string) error
+	// Logout ends the login session, deleting it from the service unless
+	// other processes still share it
+	Logout() error
+}
+
+// Diagnostics reports on the connection
+type Diagnostics interface {
+	Stats() *Stats
+	Quirks() QuirkSet
+	Auth() AuthMode
+	// Session is the path of the login session, "" before a login or when
+	// the service named none
+	Session() string
 }
 
 // cache interface for dependency injection
 type cache interface {
-	Get(path string) (*Resource, error)
-	Post(path string, body []byte) ([]byte, int, error)
+	Get(ctx context.Context, path string) (*Resource, error)
+	Post(ctx context.Context, path string, body []byte) (*Response, error)
+	Patch(ctx context.Context, path string, body []byte) (*Response, error)
+	Download(ctx context.Context, path string, w io.Writer, progress ProgressFunc) (int64, error)
 	GetKnownPaths() []string
 	Invalidate(path string)
 	Clear()
+	Denied(path string) bool
+	Cached(path string) *Resource
+	FetchedAt(path string) (time.Time, bool)
+	Allow(ctx context.Context, path string) ([]string, error)
 	Save() error
+	Stats() *Stats
+	SearchNames(re *regexp.Regexp) []*Resource
+	SearchValues(text string) []*Resource
 }
 
 // vfs implements VFS interface
 type vfs struct {
-	cache cache
+	cache   cache
+	root    string  // Service root of the protocol version in use
+	client  *Client // nil for a cache without a connection
+	quirks  QuirkSet
+	schemas *schemaStore    // nil for a cache without a connection
+	ctx     context.Context // Requests run under it; nil runs them to completion
 }
 
-// NewVFS creates a new VFS instance
-func NewVFS(endpoint, username, password string, insecure bool) (VFS, error) {
-	client, err := NewClient(endpoint, username, password, insecure)
+// Options configures a VFS beyond its connection parameters
+type Options struct {
+	Parser ParserOptions
+	Quirks QuirkOptions
+
+	// Version is the Redfish protocol version to speak, e.g. v1; by default
+	// the newest the service lists at /redfish
+	Version string
+
+	// Transport carries the HTTP requests instead of NewTransport(insecure),
+	// e.g. a Cassette recording or replaying them
+	Transport http.RoundTripper
+
+	// CacheFile is where the cache is loaded from and synced to; by default
+	// .bfsh_cache_<host>.json in the working directory
+	CacheFile string
+
+	// Sessions shares the login session with the other processes using the
+	// store; nil logs in for this connection alone
+	Sessions *SessionStore
+}
+
+// NewVFS creates a new VFS instance. The service root is that of
+// opts.Version, or of the newest version the service lists at /redfish.
+func NewVFS(endpoint, username, password string, insecure bool, opts Options) (VFS, error) {
+	transport := opts.Transport
+	if transport == nil {
+		transport = NewTransport(insecure)
+	}
+	client, err := newClient(endpoint, username, password, opts.Version, transport)
 	if err != nil {
 		return nil, err
 	}
+	if opts.Sessions != nil {
+		client.sessions, client.holder = opts.Sessions, newHolderID()
+	}
 
-	u, _ := url.Parse(endpoint)
-	cacheFile := fmt.Sprintf(".bfsh_cache_%s.json", u.Hostname())
+	quirks, err := detectQuirks(client, opts.Quirks)
+	if err != nil {
+		return nil, err
+	}
+	client.quirks = quirks
 
-	parser := NewParser()
+	cacheFile := opts.CacheFile
+	if cacheFile == "" {
+		u, _ := url.Parse(endpoint)
+		cacheFile = fmt.Sprintf(".bfsh_cache_%s.json", u.Hostname())
+	}
+
+	parser := NewParser(opts.Parser)
+	parser.quirks = quirks
 	cache := NewResourceCache(client, parser, cacheFile)
 
-	return &vfs{cache: cache}, nil
+	return &vfs{cache: cache, root: client.Root(), client: client, quirks: quirks, schemas: newSchemaStore(cache, client.Root(), client)}, nil
 }
 
 // Get retrieves a resource by its canonical path
 func (v *vfs) Get(path string) (*Resource, error) {
-	return v.cache.Get(path)
+	return v.cache.Get(v.context(), path)
+}
+
+// Download streams the payload at path to w
+func (v *vfs) Download(path string, w io.Writer, progress ProgressFunc) (int64, error) {
+	return v.cache.Download(v.context(), normalizePath(path), w, progress)
 }
 
 // Post sends a POST request (no caching for writes)
-func (v *vfs) Post(path string, body []byte) ([]byte, int, error) {
-	return v.cache.Post(path, body)
+func (v *vfs) Post(path string, body []byte) (*Response, error) {
+	return v.cache.Post(v.context(), path, body)
+}
+
+// Patch sends a PATCH request; the resource is invalidated, as it changed
+func (v *vfs) Patch(path string, body []byte) (*Response, error) {
+	return v.cache.Patch(v.context(), path, body)
 }
 
 // ResolveTarget resolves a target path from a base path.
 // All paths use / as the separator. Handles:
-// - Absolute paths: /redfish/v1/Systems/1/Status/Health
-// - Relative paths: Status/Health (joined with basePath)
-// - Array indexing: BootOrder[0]
+//   - Absolute paths: /redfish/v1/Systems/1/Status/Health
+//   - Relative paths: Status/Health (joined with basePath)
+//   - Array indexing: BootOrder[0], and slicing: BootOrder[2:10]
+//   - OData query options on the final resource: Systems?$top=10
+//   - Links anywhere in the path, including JSON pointer links
+//     (Thermal#/Temperatures/0), which resolve to the property they point to
+//
+// Query options on basePath only apply when targetPath is empty; any other
+// target is resolved against the full (unqueried) base resource.
 func (v *vfs) ResolveTarget(basePath, targetPath string) (*Target, error) {
+	if basePath == "" {
+		basePath = v.root
+	}
+
 	// Empty target = resolve basePath itself
 	if targetPath == "" {
-		return v.resolveAbsolute(basePath)
+		return v.resolveAbsolute(normalizePath(basePath), 0, nil)
 	}
 
-	// Absolute path - resolve from root
-	if strings.HasPrefix(targetPath, "/") {
-		return v.resolveAbsolute(targetPath)
-	}
+	// Join resolves .., . segments, strips trailing slashes, and handles
+	// absolute targets
+	return v.resolveAbsolute(v.Join(basePath, targetPath), 0, nil)
+}
 
-	// Relative path - join with base and resolve from root
-	// path.Join resolves .., . segments and strips trailing slashes
-	return v.resolveAbsolute(normalizePath(path.Join(basePath, targetPath)))
+// ResolveChain resolves a target path like ResolveTarget and also returns
+// the links followed on the way, in order. A path ending on a link ends
+// with the hop to its target, which open would take.
+func (v *vfs) ResolveChain(basePath, targetPath string) (*Target, []Hop, error) {
+	if basePath == "" {
+		basePath = v.root
+	}
+	fullPath := normalizePath(basePath)
+	if targetPath != "" {
+		fullPath = v.Join(basePath, targetPath)
+	}
+	var chain []Hop
+	target, err := v.resolveAbsolute(fullPath, 0, &chain)
+	return target, chain, err
 }
 
-// resolveAbsolute resolves an absolute path like /redfish/v1/Systems/1/Status:Health
-func (v *vfs) resolveAbsolute(path string) (*Target, error) {
-	// Strip /redfish/v1 prefix
-	if !strings.HasPrefix(path, RedfishRoot) {
+// resolveAbsolute resolves an absolute path like /redfish/v1/Systems/1/Status/Health.
+// hops counts the JSON pointer links followed so far; the links followed
+// are added to chain, unless it is nil.
+func (v *vfs) resolveAbsolute(fullPath string, hops int, chain *[]Hop) (*Target, error) {
+	path, query := splitQuery(fullPath)
+	path = fragmentPath(path)
+
+	// Strip the service root prefix, /redfish/v1 or that of another version
+	root := versionRoot(path)
+	if root == "" {
 		return nil, fmt.Errorf("invalid absolute path: %s", path)
 	}
 
-	if path == RedfishRoot {
-		res, err := v.cache.Get(RedfishRoot)
+	if path == root {
+		rootPath := withQuery(root, query)
+		res, err := v.cache.Get(v.context(), rootPath)
 		if err != nil {
 			return nil, err
 		}
 		return &Target{
 			Type:         TargetResource,
 			Resource:     res,
-			ResourcePath: RedfishRoot,
+			ResourcePath: rootPath,
 		}, nil
 	}
 
-	relativePath := strings.TrimPrefix(path, RedfishRoot+"/")
-	return v.resolveRelative(RedfishRoot, relativePath)
+	relativePath := strings.TrimPrefix(path, root+"/")
+	return v.resolveRelative(root, relativePath, query, hops, chain)
+}
+
+// followPointer continues resolution through a link whose target carries a
+// JSON pointer fragment: the pointer becomes property segments of the
+// target resource, followed by the segments that remain after the link
+func (v *vfs) followPointer(linkTarget string, rest []string, query string, hops int, chain *[]Hop) (*Target, error) {
+	if hops >= maxLinkHops {
+		return nil, fmt.Errorf("too many link hops resolving %s", linkTarget)
+	}
+	fullPath := fragmentPath(linkTarget)
+	if len(rest) > 0 {
+		fullPath += "/" + strings.Join(rest, "/")
+	}
+	return v.resolveAbsolute(withQuery(fullPath, query), hops+1, chain)
 }
 
 // resolveRelative resolves a path relative to a base resource.
@@ -123,8 +363,12 @@ func (v *vfs) resolveAbsolute(path string) (*Target, error) {
 //   - In property mode: check property children
 //   - PropertyLink + more segments: follow link, back to resource mode
 //   - PropertyObject + more segments: descend into children
+//   - Link array + more segments: the next names an element by its target
 //   - [n] within a segment handles array indexing
-func (v *vfs) resolveRelative(basePath, targetPath string) (*Target, error) {
+//
+// query is applied to the resource the path ends on (or links to); it is an
+// error for a path ending on a non-link property.
+func (v *vfs) resolveRelative(basePath, targetPath, query string, hops int, chain *[]Hop) (*Target, error) {
 	// Filter empty segments (from trailing or double slashes)
 	allSegments := strings.Split(targetPath, "/")
 	segments := allSegments[:0]
@@ -138,18 +382,33 @@ func (v *vfs) resolveRelative(basePath, targetPath string) (*Target, error) {
 	var currentResource *Resource
 	var currentProps map[string]*Property // nil = resource mode, non-nil = property mode
 	var err error
+	viaURIString := false // A URI string link was followed on the way
+	propStart := 0        // Segment the properties of currentResource start at
+
+	// hop records a link followed from the property ending at segment i
+	hop := func(i int, to string, uriString bool) {
+		if chain != nil {
+			from := currentPath + "/" + strings.Join(segments[propStart:i+1], "/")
+			*chain = append(*chain, Hop{From: from, To: to, URIString: uriString})
+		}
+	}
 
 	for i, seg := range segments {
 		// In resource mode, try children first
 		if currentProps == nil {
 			if currentResource == nil {
-				currentResource, err = v.cache.Get(currentPath)
+				currentResource, err = v.cache.Get(v.context(), currentPath)
 				if err != nil {
 					return nil, err
 				}
 			}
 
 			if child, ok := currentResource.Children[seg]; ok {
+				if strings.Contains(child.Target, "#") {
+					propStart = i
+					hop(i, child.Target, false)
+					return v.followPointer(child.Target, segments[i+1:], query, hops, chain)
+				}
 				currentPath = child.Target
 				currentResource = nil
 				continue
@@ -157,6 +416,7 @@ func (v *vfs) resolveRelative(basePath, targetPath string) (*Target, error) {
 
 			// Not a child — fall through to property lookup
 			currentProps = currentResource.Properties
+			propStart = i
 		}
 
 		// Property lookup (works in both resource and property mode)
@@ -165,20 +425,36 @@ func (v *vfs) resolveRelative(basePath, targetPath string) (*Target, error) {
 			return nil, err
 		}
 
+		// A link into part of another resource resolves to what it points to
+		if prop.Type == PropertyLink && strings.Contains(prop.LinkTarget, "#") {
+			hop(i, prop.LinkTarget, prop.URIString)
+			target, err := v.followPointer(prop.LinkTarget, segments[i+1:], query, hops, chain)
+			if target != nil {
+				target.ViaURIString = target.ViaURIString || viaURIString || prop.URIString
+			}
+			return target, err
+		}
+
 		// Last segment — return result
 		if i == len(segments)-1 {
 			if prop.Type == PropertyLink {
+				hop(i, prop.LinkTarget, prop.URIString)
 				return &Target{
 					Type:         TargetLink,
 					Resource:     currentResource,
 					Property:     prop,
-					ResourcePath: prop.LinkTarget,
+					ResourcePath: withQuery(prop.LinkTarget, query),
+					ViaURIString: viaURIString || prop.URIString,
 				}, nil
 			}
+			if query != "" {
+				return nil, fmt.Errorf("query options apply to resources, not properties: %s", seg)
+			}
 			return &Target{
-				Type:     TargetProperty,
-				Resource: currentResource,
-				Property: prop,
+				Type:         TargetProperty,
+				Resource:     currentResource,
+				Property:     prop,
+				ViaURIString: viaURIString,
 			}, nil
 		}
 
@@ -186,19 +462,28 @@ func (v *vfs) resolveRelative(basePath, targetPath string) (*Target, error) {
 		switch prop.Type {
 		case PropertyLink:
 			// Follow link, back to resource mode
+			hop(i, prop.LinkTarget, prop.URIString)
+			viaURIString = viaURIString || prop.URIString
 			currentPath = prop.LinkTarget
 			currentResource = nil
 			currentProps = nil
 		case PropertyObject:
 			currentProps = prop.Children
+		case PropertyArray:
+			if !prop.IsLinkArray() {
+				return nil, fmt.Errorf("cannot navigate into %s: not an object or link", seg)
+			}
+			// Elements of a link array are named by their targets: Links/Chassis/1
+			currentProps = LinkElements(prop)
 		default:
 			return nil, fmt.Errorf("cannot navigate into %s: not an object or link", seg)
 		}
 	}
 
 	// Ended on a resource
-	if currentResource == nil {
-		currentResource, err = v.cache.Get(currentPath)
+	resourcePath := withQuery(currentPath, query)
+	if currentResource == nil || query != "" {
+		currentResource, err = v.cache.Get(v.context(), resourcePath)
 		if err != nil {
 			return nil, err
 		}
@@ -207,13 +492,14 @@ func (v *vfs) resolveRelative(basePath, targetPath string) (*Target, error) {
 	return &Target{
 		Type:         TargetResource,
 		Resource:     currentResource,
-		ResourcePath: currentPath,
+		ResourcePath: resourcePath,
+		ViaURIString: viaURIString,
 	}, nil
 }
 
 // navigatePropertySegment handles a single property segment with optional array indexing
 func (v *vfs) navigatePropertySegment(properties map[string]*Property, segment string) (*Property, error) {
-	// Check for array indexing: PropertyName[n]
+	// Check for array indexing: PropertyName[n] or PropertyName[start:end]
 	if idx := strings.Index(segment, "["); idx != -1 {
 		if !strings.HasSuffix(segment, "]") {
 			return nil, &NotFoundError{Path: segment}
@@ -231,9 +517,14 @@ func (v *vfs) navigatePropertySegment(properties map[string]*Property, segment s
 			return nil, fmt.Errorf("%s is not an array", propName)
 		}
 
-		index := 0
-		fmt.Sscanf(indexStr, "%d", &index)
+		if start, end, ok := strings.Cut(indexStr, ":"); ok {
+			return sliceArray(prop, segment, start, end)
+		}
 
+		index, err := strconv.Atoi(indexStr)
+		if err != nil || index < 0 {
+			return nil, fmt.Errorf("invalid index: %s", segment)
+		}
 		if index >= len(prop.Elements) {
 			return nil, fmt.Errorf("index %d out of bounds", index)
 		}
@@ -250,9 +541,67 @@ func (v *vfs) navigatePropertySegment(properties map[string]*Property, segment s
 	return prop, nil
 }
 
+// LinkElements names the elements of a link array by the last segment of
+// their targets, so the element linking /redfish/v1/Chassis/1 of
+// Links/Chassis is Links/Chassis/1. A name two elements share is left out,
+// as it would not say which one is meant; [n] reaches those.
+func LinkElements(prop *Property) map[string]*Property {
+	elements := make(map[string]*Property, len(prop.Elements))
+	shared := make(map[string]bool)
+	for _, elem := range prop.Elements {
+		if elem.Type != PropertyLink {
+			continue
+		}
+		name := BaseName(fragmentPath(elem.LinkTarget))
+		if _, ok := elements[name]; ok || shared[name] {
+			delete(elements, name)
+			shared[name] = true
+			continue
+		}
+		elements[name] = elem
+	}
+	return elements
+}
+
+// sliceArray returns elements [start:end) of an array as an array property
+// named after the segment. Either bound may be omitted; both are clamped to
+// the array. Elements keep their original [n] names.
+func sliceArray(prop *Property, segment, startStr, endStr string) (*Property, error) {
+	start, end := 0, len(prop.Elements)
+	var err error
+	if startStr != "" {
+		if start, err = strconv.Atoi(startStr); err != nil || start < 0 {
+			return nil, fmt.Errorf("invalid slice: %s", segment)
+		}
+	}
+	if endStr != "" {
+		if end, err = strconv.Atoi(endStr); err != nil || end < 0 {
+			return nil, fmt.Errorf("invalid slice: %s", segment)
+		}
+	}
+	end = min(end, len(prop.Elements))
+	start = min(start, end)
+
+	elements := prop.Elements[start:end]
+	raw := make([][]byte, len(elements))
+	for i, elem := range elements {
+		raw[i] = elem.RawJSON
+		// String values are kept without their quotes
+		if str, ok := elem.Value.(string); ok && elem.Type == PropertySimple {
+			raw[i], _ = json.Marshal(str)
+		}
+	}
+	return &Property{
+		Name:     segment,
+		Type:     PropertyArray,
+		Elements: elements,
+		RawJSON:  append(append([]byte("["), bytes.Join(raw, []byte(","))...), ']'),
+	}, nil
+}
+
 // ListAll returns all entries (children and properties) at a resource path
 func (v *vfs) ListAll(path string) ([]*Entry, error) {
-	resource, err := v.cache.Get(path)
+	resource, err := v.cache.Get(v.context(), path)
 	if err != nil {
 		return nil, err
 	}
@@ -265,11 +614,15 @@ func (v *vfs) ListAll(path string) ([]*Entry, error) {
 		if child.Type == ChildSymlink {
 			entryType = EntrySymlink
 		}
+		// A child is as old as its own cached resource, not the listing's
+		fetched, _ := v.cache.FetchedAt(child.Target)
 		entries = append(entries, &Entry{
 			Name:     child.Name,
 			Path:     child.Target,
 			Type:     entryType,
-			Modified: resource.FetchedAt,
+			Modified: fetched,
+			Denied:   v.cache.Denied(child.Target),
+			Actions:  v.cache.Cached(child.Target).HasActions(),
 		})
 	}
 
@@ -277,11 +630,12 @@ func (v *vfs) ListAll(path string) ([]*Entry, error) {
 	for _, prop := range resource.Properties {
 		entryType := entryTypeForProperty(prop)
 		entries = append(entries, &Entry{
-			Name:     prop.Name,
-			Path:     path + "/" + prop.Name,
-			Type:     entryType,
-			Size:     int64(len(prop.RawJSON)),
-			Modified: resource.FetchedAt,
+			Name:      prop.Name,
+			Path:      resource.Path + "/" + prop.Name,
+			Type:      entryType,
+			Size:      int64(len(prop.RawJSON)),
+			Modified:  resource.FetchedAt,
+			URIString: prop.URIString,
 		})
 	}
 
@@ -294,7 +648,7 @@ func (v *vfs) ListAll(path string) ([]*Entry, error) {
 
 // ListProperties returns properties at a resource path
 func (v *vfs) ListProperties(path string) ([]*Property, error) {
-	resource, err := v.cache.Get(path)
+	resource, err := v.cache.Get(v.context(), path)
 	if err != nil {
 		return nil, err
 	}
@@ -325,25 +679,133 @@ func entryTypeForProperty(prop *Property) EntryType {
 	}
 }
 
-// Join joins path segments
+// Join joins path segments. An absolute target replaces base; query options
+// on base are dropped and those on target are kept.
 func (v *vfs) Join(base, target string) string {
-	return normalizePath(path.Join(base, target))
+	return joinPath(base, target)
+}
+
+// joinPath joins target to base, keeping target's query options
+func joinPath(base, target string) string {
+	base, _ = splitQuery(base)
+	target, query := splitQuery(target)
+	if strings.HasPrefix(target, "/") {
+		return normalizePath(withQuery(path.Clean(target), query))
+	}
+	return normalizePath(withQuery(path.Join(base, target), query))
+}
+
+// TargetArg joins command arguments into a single path argument, stripping
+// surrounding quotes so query options can be quoted: ls 'Systems?$top=5'
+func TargetArg(args []string) string {
+	target := strings.Join(args, " ")
+	if len(target) >= 2 && (target[0] == '\'' || target[0] == '"') && target[len(target)-1] == target[0] {
+		target = target[1 : len(target)-1]
+	}
+	return target
+}
+
+// Glob expands a pattern into the sorted absolute paths it matches. A "*"
+// in a segment matches any run of characters among the navigable entries
+// (resources, links, objects, arrays) at that level: Systems/*,
+// Chassis/*/Sensors. Segments without "*" are taken as they are.
+func Glob(v Lister, basePath, pattern string) ([]string, error) {
+	full, _ := splitQuery(joinPath(basePath, pattern))
+	root := versionRoot(full)
+	if root == "" {
+		return nil, fmt.Errorf("invalid absolute path: %s", full)
+	}
+
+	paths := []string{root}
+	for _, segment := range strings.Split(strings.TrimPrefix(full, root), "/") {
+		if segment == "" {
+			continue
+		}
+		var next []string
+		for _, p := range paths {
+			if !strings.Contains(segment, "*") {
+				next = append(next, p+"/"+segment)
+				continue
+			}
+			entries, err := v.ListAll(p)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					continue
+				}
+				matched, err := path.Match(segment, entry.Name)
+				if err != nil {
+					return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+				}
+				if matched {
+					next = append(next, p+"/"+entry.Name)
+				}
+			}
+		}
+		paths = next
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Root returns the service root of the protocol version in use
+func (v *vfs) Root() string {
+	return v.root
 }
 
 // Parent returns the parent path
 func (v *vfs) Parent(p string) string {
-	p = normalizePath(p)
-	if p == RedfishRoot || p == "/" {
+	return parentPath(p)
+}
+
+// parentPath returns the parent of p; a service root is its own parent
+func parentPath(p string) string {
+	p, _ = splitQuery(normalizePath(p))
+	if p == versionRoot(p) || p == "/" {
 		return p
 	}
 	return path.Dir(p)
 }
 
+// IsServiceRoot reports whether p is the service root of a protocol
+// version, such as /redfish/v1
+func IsServiceRoot(p string) bool {
+	return p != "" && versionRoot(p) == p
+}
+
+// versionRoot returns the service root a path lies under, /redfish/v1 for
+// /redfish/v1/Systems/1 and /redfish/v2 for /redfish/v2/Systems, or "" when
+// it lies under none
+func versionRoot(p string) string {
+	rest, ok := strings.CutPrefix(p, VersionsPath+"/")
+	if !ok {
+		return ""
+	}
+	version, _, _ := strings.Cut(rest, "/")
+	if !isVersion(version) {
+		return ""
+	}
+	return VersionsPath + "/" + version
+}
+
 // GetKnownPaths returns all cached paths
 func (v *vfs) GetKnownPaths() []string {
 	return v.cache.GetKnownPaths()
 }
 
+// Denied reports whether the service refused path to the logged-in role
+func (v *vfs) Denied(path string) bool {
+	return v.cache.Denied(path)
+}
+
+// Cached returns the resource at path when it is cached and parsed
+func (v *vfs) Cached(path string) *Resource {
+	return v.cache.Cached(path)
+}
+
 // Invalidate removes a single resource from cache, forcing re-fetch on next Get
 func (v *vfs) Invalidate(path string) {
 	v.cache.Invalidate(path)
@@ -354,11 +816,145 @@ func (v *vfs) Clear() {
 	v.cache.Clear()
 }
 
+// Stat asks the service which methods it allows on a resource
+func (v *vfs) Stat(path string) (*ResourceInfo, error) {
+	path = normalizePath(path)
+	methods, err := v.cache.Allow(v.context(), path)
+	if err != nil {
+		return nil, err
+	}
+	return &ResourceInfo{Path: path, Allow: methods}, nil
+}
+
 // Sync saves cache to disk
 func (v *vfs) Sync() error {
 	return v.cache.Save()
 }
 
+// FindCached returns the properties of the cached resources at or below
+// base whose names match re, ordered by resource and property path. The
+// cache's index picks the resources to search, so nothing is fetched.
+func (v *vfs) FindCached(base string, re *regexp.Regexp) []Match {
+	return searchCached(v.cache.SearchNames(re), base, func(r *Resource) []Match {
+		return FindProperties(r, re)
+	})
+}
+
+// GrepCached returns the simple properties of the cached resources at or
+// below base whose values contain text, ignoring case, ordered by resource
+// and property path
+func (v *vfs) GrepCached(base, text string) []Match {
+	return searchCached(v.cache.SearchValues(text), base, func(r *Resource) []Match {
+		return GrepProperties(r, text)
+	})
+}
+
+// searchCached collects the matches of the candidate resources at or below
+// base
+func searchCached(candidates []*Resource, base string, search func(*Resource) []Match) []Match {
+	base, _ = splitQuery(normalizePath(base))
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Path < candidates[j].Path
+	})
+	var matches []Match
+	for _, r := range candidates {
+		p, _ := splitQuery(r.Path)
+		if p != base && !strings.HasPrefix(p, strings.TrimSuffix(base, "/")+"/") {
+			continue
+		}
+		matches = append(matches, search(r)...)
+	}
+	return matches
+}
+
+// Describe returns the one-line schema description of what a path
+// resolves to: the property within its resource, or the resource's type.
+// It is "" when the service publishes no schema describing it; an error
+// means the path or a schema could not be read.
+func (v *vfs) Describe(basePath, targetPath string) (string, error) {
+	if v.schemas == nil {
+		return "", nil
+	}
+	target, err := v.ResolveTarget(basePath, targetPath)
+	if err != nil {
+		return "", err
+	}
+	if target.Resource == nil {
+		return "", nil
+	}
+	var names []string
+	if target.Property != nil {
+		var ok bool
+		// Slices and properties reached through JSON pointers are not
+		// in the resource's tree
+		if names, ok = propertyNames(&Property{Children: target.Resource.Properties}, target.Property); !ok {
+			return "", nil
+		}
+	}
+	text, err := v.schemas.describe(v.context(), target.Resource.ODataType, names)
+	if err != nil {
+		return "", fmt.Errorf("schema for %s: %w", target.Resource.ODataType, err)
+	}
+	return text, nil
+}
+
+// Stats returns the requests recorded this session
+func (v *vfs) Stats() *Stats {
+	return v.cache.Stats()
+}
+
+// WithContext returns a view of the connection whose requests run under ctx
+func (v *vfs) WithContext(ctx context.Context) VFS {
+	bound := *v
+	bound.ctx = ctx
+	return &bound
+}
+
+// context returns the context requests are sent under
+func (v *vfs) context() context.Context {
+	if v.ctx == nil {
+		return context.Background()
+	}
+	return v.ctx
+}
+
+// Reauthenticate logs in again with a new password
+func (v *vfs) Reauthenticate(password string) error {
+	if v.client == nil {
+		return ErrNotSupported
+	}
+	return v.client.Reauthenticate(v.context(), password)
+}
+
+// Logout ends the login session
+func (v *vfs) Logout() error {
+	if v.client == nil {
+		return nil
+	}
+	return v.client.Logout(v.context())
+}
+
+// Quirks returns the vendor quirks active for this connection
+func (v *vfs) Quirks() QuirkSet {
+	return v.quirks
+}
+
+// Auth returns how the connection authenticates
+func (v *vfs) Auth() AuthMode {
+	if v.client == nil {
+		return AuthNone
+	}
+	return v.client.Auth()
+}
+
+// Session returns the path of the login session
+func (v *vfs) Session() string {
+	if v.client == nil {
+		return ""
+	}
+	return v.client.Session()
+}
+
 // BaseName returns the last segment of a path, trimming trailing slashes
 func BaseName(p string) string {
 	return path.Base(strings.TrimRight(p, "/"))
@@ -0,0 +1,48 @@
+package rvfs
+
+import "sort"
+
+// Walk reads every resource reachable from start through the children of
+// each, breadth first and each once, children in name order, and calls fn
+// with the resource or the error reading it. The walk stops once fn
+// returns false.
+func Walk(r Reader, start string, fn func(path string, res *Resource, err error) bool) {
+	visited := map[string]bool{start: true}
+	queue := []string{start}
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		res, err := r.Get(path)
+		if !fn(path, res, err) {
+			return
+		}
+		if err != nil {
+			continue
+		}
+		names := make([]string, 0, len(res.Children))
+		for name := range res.Children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if target := res.Children[name].Target; !visited[target] {
+				visited[target] = true
+				queue = append(queue, target)
+			}
+		}
+	}
+}
+
+// CacheReader reads only the resources a VFS holds parsed; the others fail
+// with NotCachedError, so a Walk through it never fetches
+type CacheReader struct {
+	Cache CacheControl
+}
+
+// Get returns the cached resource at path
+func (c CacheReader) Get(path string) (*Resource, error) {
+	if res := c.Cache.Cached(path); res != nil {
+		return res, nil
+	}
+	return nil, &NotCachedError{Path: path}
+}
@@ -0,0 +1,89 @@
+package rvfs
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// WatchListInterval is how often frontends refresh the resources on a
+// watch list
+const WatchListInterval = 30 * time.Second
+
+// WatchChange is a watched resource whose properties changed between two
+// refreshes
+type WatchChange struct {
+	Path        string
+	Differences []Difference
+}
+
+// WatchList is a set of resources refreshed on an interval to notice
+// their properties changing: a middle ground between polling one property
+// and subscribing to the service's events. It is safe for concurrent use.
+type WatchList struct {
+	mu    sync.Mutex
+	paths []string // Sorted
+}
+
+// Add puts a resource on the list and reports whether it was not there
+func (w *WatchList) Add(path string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	i, found := slices.BinarySearch(w.paths, path)
+	if found {
+		return false
+	}
+	w.paths = slices.Insert(w.paths, i, path)
+	return true
+}
+
+// Remove takes a resource off the list and reports whether it was there
+func (w *WatchList) Remove(path string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	i, found := slices.BinarySearch(w.paths, path)
+	if found {
+		w.paths = slices.Delete(w.paths, i, i+1)
+	}
+	return found
+}
+
+// Clear empties the list
+func (w *WatchList) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.paths = nil
+}
+
+// Paths returns the resources on the list, sorted
+func (w *WatchList) Paths() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return slices.Clone(w.paths)
+}
+
+// Check refreshes the resources on the list and returns those whose
+// properties differ from their cached copies, in path order. A resource
+// that was not cached has nothing to differ from and is only fetched. The
+// refreshes that fail are joined in the error; the others still count.
+func (w *WatchList) Check(v VFS) ([]WatchChange, error) {
+	var changes []WatchChange
+	var errs []error
+	for _, path := range w.Paths() {
+		old := v.Cached(path)
+		v.Invalidate(path)
+		res, err := v.Get(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if old == nil {
+			continue
+		}
+		if diffs := DiffResources(old, res); len(diffs) > 0 {
+			changes = append(changes, WatchChange{Path: path, Differences: diffs})
+		}
+	}
+	return changes, errors.Join(errs...)
+}
@@ -0,0 +1,236 @@
+package theme
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// JSONOptions selects how RenderJSON lays out a document
+type JSONOptions struct {
+	Compact bool // One line without spaces
+	// MaxElements shows at most this many elements of each array, then a
+	// count of the rest; 0 shows every element
+	MaxElements int
+	// Fields shows only these members of the top-level object, in the
+	// document's order; nil shows every member
+	Fields []string
+}
+
+// RenderJSON renders a JSON document with property names, strings,
+// numbers, booleans and null in their roles' colors. Key order and number
+// formatting are kept. A nil theme renders without color. Collapsed arrays
+// make the output a view rather than valid JSON.
+func (t *Theme) RenderJSON(data []byte, opts JSONOptions) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	r := &jsonRenderer{dec: dec, opts: opts}
+	if t != nil {
+		r.styles = map[Role]lipgloss.Style{}
+		for _, role := range []Role{Property, String, Number, True, False, Null, Dim} {
+			r.styles[role] = t.Fg(role)
+		}
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+	if d, ok := tok.(json.Delim); len(opts.Fields) > 0 && (!ok || d != '{') {
+		return "", fmt.Errorf("fields: not an object")
+	}
+	if err := r.value(tok, 0); err != nil {
+		return "", err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return "", fmt.Errorf("invalid JSON: data after the document")
+	}
+	return r.b.String(), nil
+}
+
+// jsonRenderer writes the tokens of a decoder as highlighted JSON
+type jsonRenderer struct {
+	dec    *json.Decoder
+	opts   JSONOptions
+	styles map[Role]lipgloss.Style // nil renders plain
+	b      strings.Builder
+}
+
+// value renders the value starting with tok
+func (r *jsonRenderer) value(tok json.Token, depth int) error {
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '{' {
+			return r.object(depth)
+		}
+		if v == '[' {
+			return r.array(depth)
+		}
+		return fmt.Errorf("invalid JSON: unexpected %s", v)
+	case string:
+		r.write(String, quote(v))
+	case json.Number:
+		r.write(Number, v.String())
+	case bool:
+		if v {
+			r.write(True, "true")
+		} else {
+			r.write(False, "false")
+		}
+	case nil:
+		r.write(Null, "null")
+	}
+	return nil
+}
+
+// object renders the members of an object after its opening brace; at the
+// top level, only those in Fields when it is set
+func (r *jsonRenderer) object(depth int) error {
+	r.b.WriteByte('{')
+	selected := depth == 0 && len(r.opts.Fields) > 0
+	var found []string
+	n := 0
+	for r.dec.More() {
+		tok, err := r.dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		if selected {
+			if !slices.Contains(r.opts.Fields, key) {
+				if tok, err = r.dec.Token(); err != nil {
+					return err
+				}
+				if err := r.skip(tok); err != nil {
+					return err
+				}
+				continue
+			}
+			found = append(found, key)
+		}
+		r.separate(n, depth+1)
+		r.write(Property, quote(key))
+		r.b.WriteByte(':')
+		if !r.opts.Compact {
+			r.b.WriteByte(' ')
+		}
+		if tok, err = r.dec.Token(); err != nil {
+			return err
+		}
+		if err := r.value(tok, depth+1); err != nil {
+			return err
+		}
+		n++
+	}
+	if selected {
+		for _, field := range r.opts.Fields {
+			if !slices.Contains(found, field) {
+				return fmt.Errorf("no member %s", field)
+			}
+		}
+	}
+	return r.close('}', n, depth)
+}
+
+// array renders the elements of an array after its opening bracket,
+// collapsing those beyond MaxElements
+func (r *jsonRenderer) array(depth int) error {
+	r.b.WriteByte('[')
+	n, hidden := 0, 0
+	for r.dec.More() {
+		tok, err := r.dec.Token()
+		if err != nil {
+			return err
+		}
+		if r.opts.MaxElements > 0 && n >= r.opts.MaxElements {
+			if err := r.skip(tok); err != nil {
+				return err
+			}
+			hidden++
+			continue
+		}
+		r.separate(n, depth+1)
+		if err := r.value(tok, depth+1); err != nil {
+			return err
+		}
+		n++
+	}
+	if hidden > 0 {
+		r.separate(n, depth+1)
+		r.write(Dim, fmt.Sprintf("… %d more", hidden))
+		n++
+	}
+	return r.close(']', n, depth)
+}
+
+// skip consumes the value starting with tok without rendering it
+func (r *jsonRenderer) skip(tok json.Token) error {
+	if d, ok := tok.(json.Delim); !ok || (d != '{' && d != '[') {
+		return nil
+	}
+	for open := 1; open > 0; {
+		tok, err := r.dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); ok {
+			if d == '{' || d == '[' {
+				open++
+			} else {
+				open--
+			}
+		}
+	}
+	return nil
+}
+
+// separate starts the nth member of a container
+func (r *jsonRenderer) separate(n, depth int) {
+	if n > 0 {
+		r.b.WriteByte(',')
+	}
+	r.newline(depth)
+}
+
+// close consumes a container's closing delimiter and writes it
+func (r *jsonRenderer) close(delim byte, n, depth int) error {
+	if _, err := r.dec.Token(); err != nil {
+		return err
+	}
+	if n > 0 {
+		r.newline(depth)
+	}
+	r.b.WriteByte(delim)
+	return nil
+}
+
+func (r *jsonRenderer) newline(depth int) {
+	if r.opts.Compact {
+		return
+	}
+	r.b.WriteByte('\n')
+	r.b.WriteString(strings.Repeat("  ", depth))
+}
+
+// write renders text in a role's style
+func (r *jsonRenderer) write(role Role, text string) {
+	if r.styles == nil {
+		r.b.WriteString(text)
+		return
+	}
+	r.b.WriteString(r.styles[role].Render(text))
+}
+
+// quote encodes a string as JSON, leaving <, > and & as they are
+func quote(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,159 @@
+// Package theme is the style registry shared by the bluefish frontends. It maps
+// semantic roles (child resource, link, health, ...) to ANSI colors 0–15 so
+// output follows the terminal's own palette.
+package theme
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"golang.org/x/term"
+)
+
+// Role is what a piece of output means, independent of its color
+type Role int
+
+const (
+	Child     Role = iota // Child resources
+	Link                  // Links to other resources
+	Object                // Objects and arrays
+	Property              // Property names
+	String                // String values
+	Number                // Numeric values
+	True                  // true
+	False                 // false
+	Null                  // null
+	OK                    // Health OK/Enabled
+	Warning               // Health Warning
+	Critical              // Health Critical/Disabled
+	Text                  // Body text
+	Bright                // Emphasized text
+	Dim                   // Secondary text, borders
+	Accent                // Prompts, labels, argument hints
+	Highlight             // Status bar
+	Match                 // Search matches, selected completion
+	Error                 // Errors and destructive actions
+
+	numRoles
+)
+
+// roleNames are the config keys for color overrides
+var roleNames = [numRoles]string{
+	"child", "link", "object", "property",
+	"string", "number", "true", "false", "null",
+	"ok", "warning", "critical",
+	"text", "bright", "dim", "accent", "highlight", "match", "error",
+}
+
+// noColor marks a role rendered without color
+const noColor = -1
+
+// ANSI colors per role for each built-in theme
+var palettes = map[string][numRoles]int{
+	"dark": {
+		12, 6, 5, 2,
+		2, 4, 10, 1, 8,
+		10, 11, 9,
+		7, 15, 8, 3, 11, 14, 1,
+	},
+	"light": {
+		4, 6, 5, 2,
+		2, 4, 2, 1, 8,
+		2, 3, 1,
+		0, 0, 8, 5, 3, 6, 1,
+	},
+	"mono": {
+		noColor, noColor, noColor, noColor,
+		noColor, noColor, noColor, noColor, noColor,
+		noColor, noColor, noColor,
+		noColor, noColor, noColor, noColor, noColor, noColor, noColor,
+	},
+}
+
+// Config selects a built-in theme and overrides individual roles:
+//
+//	theme:
+//	  name: light
+//	  colors:
+//	    link: 14
+type Config struct {
+	Name   string         `yaml:"name"`   // dark (default), light or mono
+	Colors map[string]int `yaml:"colors"` // Role name → ANSI color 0–15
+}
+
+// Theme is a resolved palette
+type Theme struct {
+	colors [numRoles]int
+}
+
+// Load resolves cfg into a theme. A non-empty NO_COLOR environment variable
+// selects mono regardless of cfg; bold and reverse video are kept on a
+// terminal so cursors and headings stay visible. Output that is not a
+// terminal is rendered without any styling.
+func Load(cfg Config) (*Theme, error) {
+	name := cfg.Name
+	if name == "" {
+		name = "dark"
+	}
+	palette, ok := palettes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+
+	for key, color := range cfg.Colors {
+		role, ok := roleByName(key)
+		if !ok {
+			return nil, fmt.Errorf("unknown theme color %q (available: %s)", key, strings.Join(roleNames[:], ", "))
+		}
+		if color < 0 || color > 15 {
+			return nil, fmt.Errorf("theme color %s: %d is not an ANSI color (0-15)", key, color)
+		}
+		palette[role] = color
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		palette = palettes["mono"]
+		if term.IsTerminal(int(os.Stdout.Fd())) {
+			lipgloss.SetColorProfile(termenv.ANSI)
+		}
+	}
+
+	return &Theme{colors: palette}, nil
+}
+
+// Names returns the built-in theme names
+func Names() []string {
+	names := make([]string, 0, len(palettes))
+	for name := range palettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Color returns the terminal color for a role
+func (t *Theme) Color(r Role) lipgloss.TerminalColor {
+	if t.colors[r] == noColor {
+		return lipgloss.NoColor{}
+	}
+	return lipgloss.ANSIColor(t.colors[r])
+}
+
+// Fg returns a style with the role's foreground color
+func (t *Theme) Fg(r Role) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(t.Color(r))
+}
+
+// roleByName looks up a role by its config key
+func roleByName(name string) (Role, bool) {
+	for i, n := range roleNames {
+		if n == name {
+			return Role(i), true
+		}
+	}
+	return 0, false
+}
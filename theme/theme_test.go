@@ -0,0 +1,152 @@
+package theme
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+func TestLoad(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+
+	th, err := Load(Config{})
+	if err != nil {
+		t.Fatalf("Load default: %v", err)
+	}
+	if got := th.Color(Child); got != lipgloss.ANSIColor(12) {
+		t.Errorf("dark child color = %v, want 12", got)
+	}
+
+	th, err = Load(Config{Name: "light", Colors: map[string]int{"link": 14}})
+	if err != nil {
+		t.Fatalf("Load light: %v", err)
+	}
+	if got := th.Color(Link); got != lipgloss.ANSIColor(14) {
+		t.Errorf("overridden link color = %v, want 14", got)
+	}
+	if got := th.Color(Child); got != lipgloss.ANSIColor(4) {
+		t.Errorf("light child color = %v, want 4", got)
+	}
+
+	th, err = Load(Config{Name: "mono"})
+	if err != nil {
+		t.Fatalf("Load mono: %v", err)
+	}
+	if _, ok := th.Color(Error).(lipgloss.NoColor); !ok {
+		t.Errorf("mono error color = %v, want NoColor", th.Color(Error))
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+
+	tests := []Config{
+		{Name: "solarized"},
+		{Colors: map[string]int{"links": 3}},
+		{Colors: map[string]int{"link": 16}},
+	}
+	for _, cfg := range tests {
+		if _, err := Load(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestLoad_NoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	th, err := Load(Config{Name: "dark", Colors: map[string]int{"link": 14}})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	for r := Role(0); r < numRoles; r++ {
+		if _, ok := th.Color(r).(lipgloss.NoColor); !ok {
+			t.Errorf("%s has color %v under NO_COLOR", roleNames[r], th.Color(r))
+		}
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	doc := []byte(`{"Name": "Sys <1>", "Count": 1.50, "On": true, "Off": false, "Gone": null, "Empty": {}, "List": [1, {"A": [2, 3]}, 4, 5], "Z": []}`)
+
+	var want bytes.Buffer
+	json.Indent(&want, doc, "", "  ")
+	got, err := (*Theme)(nil).RenderJSON(doc, JSONOptions{})
+	if err != nil {
+		t.Fatalf("RenderJSON failed: %v", err)
+	}
+	if got != want.String() {
+		t.Errorf("indented:\n%s\nwant:\n%s", got, want.String())
+	}
+
+	want.Reset()
+	json.Compact(&want, doc)
+	got, _ = (*Theme)(nil).RenderJSON(doc, JSONOptions{Compact: true})
+	if got != want.String() {
+		t.Errorf("compact = %s, want %s", got, want.String())
+	}
+
+	got, _ = (*Theme)(nil).RenderJSON(doc, JSONOptions{Compact: true, MaxElements: 2})
+	if !strings.Contains(got, `"List":[1,{"A":[2,3]},… 2 more]`) {
+		t.Errorf("collapsed = %s", got)
+	}
+
+	got, _ = (*Theme)(nil).RenderJSON(doc, JSONOptions{Compact: true, Fields: []string{"List", "On"}})
+	if got != `{"On":true,"List":[1,{"A":[2,3]},4,5]}` {
+		t.Errorf("fields = %s", got)
+	}
+	if _, err := (*Theme)(nil).RenderJSON(doc, JSONOptions{Fields: []string{"On", "Missing"}}); err == nil || !strings.Contains(err.Error(), "Missing") {
+		t.Errorf("missing field: %v", err)
+	}
+	if _, err := (*Theme)(nil).RenderJSON([]byte(`[1]`), JSONOptions{Fields: []string{"A"}}); err == nil {
+		t.Error("expected error for fields of an array")
+	}
+
+	if _, err := (*Theme)(nil).RenderJSON([]byte(`{"A": 1} {}`), JSONOptions{}); err == nil {
+		t.Error("expected error for trailing data")
+	}
+	if _, err := (*Theme)(nil).RenderJSON([]byte(`{"A": `), JSONOptions{}); err == nil {
+		t.Error("expected error for truncated JSON")
+	}
+
+	// Roles are colored on a color terminal
+	t.Setenv("NO_COLOR", "")
+	prev := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.ANSI)
+	defer lipgloss.SetColorProfile(prev)
+	th, _ := Load(Config{})
+	got, _ = th.RenderJSON([]byte(`{"Count": 2}`), JSONOptions{Compact: true})
+	if want := "{" + th.Fg(Property).Render(`"Count"`) + ":" + th.Fg(Number).Render("2") + "}"; got != want || !strings.Contains(got, "\x1b[") {
+		t.Errorf("colored = %q, want %q", got, want)
+	}
+}
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+	}{
+		{"Fan1", 4},
+		{"温度センサー", 12},
+		{"🔥 Hot", 6},
+		{"\x1b[1;32m電源\x1b[0m", 4},
+	}
+	for _, tt := range tests {
+		if got := Width(tt.text); got != tt.width {
+			t.Errorf("Width(%q) = %d, want %d", tt.text, got, tt.width)
+		}
+	}
+	if got := Pad("電源", 6); got != "電源  " {
+		t.Errorf("Pad = %q", got)
+	}
+	if got := Pad("温度センサー", 6); got != "温度センサー" {
+		t.Errorf("Pad of wider text = %q", got)
+	}
+	if got := Truncate("温度センサー", 5, "…"); got != "温度…" {
+		t.Errorf("Truncate = %q", got)
+	}
+}
@@ -0,0 +1,25 @@
+package theme
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Width returns the number of terminal cells text takes: ANSI escapes take
+// none, and wide runes, such as CJK and most emoji, take two
+func Width(text string) int {
+	return ansi.StringWidth(text)
+}
+
+// Pad fills text with spaces to width cells; text as wide or wider is
+// returned as is
+func Pad(text string, width int) string {
+	return text + strings.Repeat(" ", max(0, width-Width(text)))
+}
+
+// Truncate cuts text to width cells, ending it with tail when cut, without
+// splitting a wide rune or an ANSI escape
+func Truncate(text string, width int, tail string) string {
+	return ansi.Truncate(text, width, tail)
+}